@@ -147,25 +147,34 @@ func runServ(c *cli.Context) error {
 		return nil
 	}
 
+	// key-<id> for a public key or a registered principal, user-<id> for a certificate mapped to a user
 	keys := strings.Split(c.Args().First(), "-")
-	if len(keys) != 2 || keys[0] != "key" {
+	if len(keys) != 2 || (keys[0] != "key" && keys[0] != "user") {
 		return fail(ctx, "Key ID format error", "Invalid key argument: %s", c.Args().First())
 	}
-	keyID, err := strconv.ParseInt(keys[1], 10, 64)
+	id, err := strconv.ParseInt(keys[1], 10, 64)
 	if err != nil {
 		return fail(ctx, "Key ID parsing error", "Invalid key argument: %s", c.Args().Get(1))
 	}
+	var keyID, userID int64
+	if keys[0] == "key" {
+		keyID = id
+	} else {
+		userID = id
+	}
 
 	cmd := os.Getenv("SSH_ORIGINAL_COMMAND")
 	if len(cmd) == 0 {
-		key, user, err := private.ServNoCommand(ctx, keyID)
+		key, user, err := private.ServNoCommand(ctx, keyID, userID)
 		if err != nil {
 			return fail(ctx, "Key check failed", "Failed to check provided key: %v", err)
 		}
-		switch key.Type {
-		case asymkey_model.KeyTypeDeploy:
+		switch {
+		case key.ID == 0:
+			fmt.Println("Hi there, " + user.Name + "! You've successfully authenticated with an SSH certificate, but Forgejo does not provide shell access.")
+		case key.Type == asymkey_model.KeyTypeDeploy:
 			fmt.Println("Hi there! You've successfully authenticated with the deploy key named " + key.Name + ", but Forgejo does not provide shell access.")
-		case asymkey_model.KeyTypePrincipal:
+		case key.Type == asymkey_model.KeyTypePrincipal:
 			fmt.Println("Hi there! You've successfully authenticated with the principal " + key.Content + ", but Forgejo does not provide shell access.")
 		default:
 			fmt.Println("Hi there, " + user.Name + "! You've successfully authenticated with the key named " + key.Name + ", but Forgejo does not provide shell access.")
@@ -259,7 +268,7 @@ func runServ(c *cli.Context) error {
 		}
 	}
 
-	results, extra := private.ServCommand(ctx, keyID, username, reponame, requestedMode, verb, lfsVerb)
+	results, extra := private.ServCommand(ctx, keyID, userID, username, reponame, requestedMode, verb, lfsVerb)
 	if extra.HasError() {
 		return fail(ctx, extra.UserMsg, "ServCommand failed: %s", extra.Error)
 	}
@@ -249,8 +249,9 @@ func CancelPreviousJobs(ctx context.Context, repoID int64, ref, workflowID strin
 	return nil
 }
 
-// InsertRun inserts a run
-func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWorkflow) error {
+// InsertRun inserts the run and its jobs, the settings of the jobs which are not kept by the job parser are given by
+// job id
+func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWorkflow, jobSettings map[string]*RunJobSettings) error {
 	ctx, commiter, err := db.TxContext(ctx)
 	if err != nil {
 		return err
@@ -295,7 +296,7 @@ func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWork
 			hasWaiting = true
 		}
 		job.Name, _ = util.SplitStringAtByteN(job.Name, 255)
-		runJobs = append(runJobs, &ActionRunJob{
+		runJob := &ActionRunJob{
 			RunID:             run.ID,
 			RepoID:            run.RepoID,
 			OwnerID:           run.OwnerID,
@@ -307,7 +308,12 @@ func InsertRun(ctx context.Context, run *ActionRun, jobs []*jobparser.SingleWork
 			Needs:             needs,
 			RunsOn:            job.RunsOn(),
 			Status:            status,
-		})
+		}
+		if settings, ok := jobSettings[id]; ok {
+			runJob.IDTokenPermission = settings.IDTokenPermission
+			runJob.Environment, _ = util.SplitStringAtByteN(settings.Environment, 255)
+		}
+		runJobs = append(runJobs, runJob)
 	}
 	if err := db.Insert(ctx, runJobs); err != nil {
 		return err
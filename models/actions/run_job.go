@@ -33,6 +33,8 @@ type ActionRunJob struct {
 	RunsOn            []string `xorm:"JSON TEXT"`
 	TaskID            int64    // the latest task of the job
 	Status            Status   `xorm:"index"`
+	IDTokenPermission bool     `xorm:"NOT NULL DEFAULT false"` // the job may request OpenID Connect ID tokens
+	Environment       string   `xorm:"VARCHAR(255)"`           // the deployment environment of the job
 	Started           timeutil.TimeStamp
 	Stopped           timeutil.TimeStamp
 	Created           timeutil.TimeStamp `xorm:"created"`
@@ -0,0 +1,77 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// RunJobSettings are the settings of a job which are not kept in the workflow payload by the job parser
+type RunJobSettings struct {
+	// IDTokenPermission is true if the job may request OpenID Connect ID tokens, it is granted by
+	// `permissions: id-token: write` or `permissions: write-all` on the job or, if the job has no permissions, on the
+	// workflow
+	IDTokenPermission bool
+	// Environment is the name of the deployment environment of the job
+	Environment string
+}
+
+type rawWorkflowSettings struct {
+	Permissions yaml.Node `yaml:"permissions"`
+	Jobs        map[string]struct {
+		Permissions yaml.Node `yaml:"permissions"`
+		Environment yaml.Node `yaml:"environment"`
+	} `yaml:"jobs"`
+}
+
+// hasIDTokenPermission returns whether the permissions grant `id-token: write`, it returns nil if there are no
+// permissions
+func hasIDTokenPermission(permissions *yaml.Node) *bool {
+	var granted bool
+	switch permissions.Kind {
+	case yaml.ScalarNode:
+		granted = permissions.Value == "write-all"
+	case yaml.MappingNode:
+		var m map[string]string
+		if err := permissions.Decode(&m); err == nil {
+			granted = m["id-token"] == "write"
+		}
+	default:
+		return nil
+	}
+	return &granted
+}
+
+// ParseRunJobSettings returns the settings of the jobs of the workflow content by job id
+func ParseRunJobSettings(content []byte) (map[string]*RunJobSettings, error) {
+	var workflow rawWorkflowSettings
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	workflowIDToken := hasIDTokenPermission(&workflow.Permissions)
+	settings := make(map[string]*RunJobSettings, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		s := &RunJobSettings{}
+		if idToken := hasIDTokenPermission(&job.Permissions); idToken != nil {
+			s.IDTokenPermission = *idToken
+		} else if workflowIDToken != nil {
+			s.IDTokenPermission = *workflowIDToken
+		}
+
+		switch job.Environment.Kind {
+		case yaml.ScalarNode:
+			s.Environment = job.Environment.Value
+		case yaml.MappingNode:
+			var environment struct {
+				Name string `yaml:"name"`
+			}
+			if err := job.Environment.Decode(&environment); err == nil {
+				s.Environment = environment.Name
+			}
+		}
+		settings[id] = s
+	}
+	return settings, nil
+}
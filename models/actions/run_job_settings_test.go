@@ -0,0 +1,49 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRunJobSettings(t *testing.T) {
+	settings, err := ParseRunJobSettings([]byte(`
+on: push
+permissions:
+  id-token: write
+  contents: read
+jobs:
+  inherited:
+    runs-on: docker
+    environment: staging
+  overridden:
+    runs-on: docker
+    permissions:
+      contents: write
+    environment:
+      name: production
+      url: https://example.com
+  all:
+    runs-on: docker
+    permissions: write-all
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*RunJobSettings{
+		"inherited":  {IDTokenPermission: true, Environment: "staging"},
+		"overridden": {Environment: "production"},
+		"all":        {IDTokenPermission: true},
+	}, settings)
+
+	settings, err = ParseRunJobSettings([]byte(`
+on: push
+jobs:
+  test:
+    runs-on: docker
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*RunJobSettings{"test": {}}, settings)
+}
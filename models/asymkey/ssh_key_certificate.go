@@ -0,0 +1,129 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package asymkey
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const sourceAddressCriticalOption = "source-address"
+
+// IsTrustedUserCA returns true if key is one of the SSH_TRUSTED_USER_CA_KEYS
+func IsTrustedUserCA(key ssh.PublicKey) bool {
+	marshaled := key.Marshal()
+	for _, k := range setting.SSH.TrustedUserCAKeysParsed {
+		if bytes.Equal(marshaled, k.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckUserCertificate checks that cert is a user certificate signed by a trusted certificate authority, that it is
+// valid for principal at the moment and that it has no critical option but source-address. The source-address is
+// checked against remoteAddr unless it is nil, in which case the SSH server must have enforced it.
+func CheckUserCertificate(cert *ssh.Certificate, principal string, remoteAddr net.Addr) error {
+	if cert.CertType != ssh.UserCert {
+		return errors.New("not a user certificate")
+	}
+	c := &ssh.CertChecker{
+		IsUserAuthority: IsTrustedUserCA,
+	}
+	if !c.IsUserAuthority(cert.SignatureKey) {
+		return fmt.Errorf("untrusted authority with fingerprint %s", ssh.FingerprintSHA256(cert.SignatureKey))
+	}
+	if err := c.CheckCert(principal, cert); err != nil {
+		return err
+	}
+	if sourceAddrs, ok := cert.CriticalOptions[sourceAddressCriticalOption]; ok && remoteAddr != nil {
+		return checkSourceAddress(remoteAddr, sourceAddrs)
+	}
+	return nil
+}
+
+// checkSourceAddress checks that addr is in the comma separated list of addresses and CIDR ranges of the
+// source-address critical option
+func checkSourceAddress(addr net.Addr, sourceAddrs string) error {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("remote address %v is not a TCP address", addr)
+	}
+	for _, sourceAddr := range strings.Split(sourceAddrs, ",") {
+		if ip := net.ParseIP(sourceAddr); ip != nil {
+			if ip.Equal(tcpAddr.IP) {
+				return nil
+			}
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(sourceAddr)
+		if err != nil {
+			return fmt.Errorf("invalid source-address %q: %w", sourceAddr, err)
+		}
+		if ipNet.Contains(tcpAddr.IP) {
+			return nil
+		}
+	}
+	return fmt.Errorf("remote address %v is not allowed by source-address", addr)
+}
+
+// usernameFromMappingTemplate returns the username that SSH_TRUSTED_USER_CA_MAPPING_TEMPLATE expands to name
+func usernameFromMappingTemplate(name string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(setting.SSH.TrustedUserCAMappingTemplate, "{username}")
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+	return name[len(prefix) : len(name)-len(suffix)], true
+}
+
+// GetUserByCertificate returns the user a certificate is mapped to by SSH_TRUSTED_USER_CA_MAPPING, and the principals
+// of the certificate the user may authenticate as. It does not check the certificate itself.
+func GetUserByCertificate(ctx context.Context, cert *ssh.Certificate) (*user_model.User, []string, error) {
+	var names []string
+	switch setting.SSH.TrustedUserCAMapping {
+	case "principal":
+		names = cert.ValidPrincipals
+	case "key_id":
+		names = []string{cert.KeyId}
+	}
+
+	for _, name := range names {
+		username, ok := usernameFromMappingTemplate(name)
+		if !ok {
+			continue
+		}
+		u, err := user_model.GetUserByName(ctx, username)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, nil, err
+		}
+		if !u.IsIndividual() {
+			continue
+		}
+		if setting.SSH.TrustedUserCAMapping == "principal" {
+			return u, []string{name}, nil
+		}
+		return u, cert.ValidPrincipals, nil
+	}
+	return nil, nil, user_model.ErrUserNotExist{Name: strings.Join(names, ",")}
+}
+
+// AuthorizedStringForCertificate creates the authorized principals string for a principal of a certificate mapped to
+// the user
+func AuthorizedStringForCertificate(userID int64, principal string) string {
+	command := fmt.Sprintf("%s --config=%s serv user-%d", util.ShellEscape(setting.AppPath), util.ShellEscape(setting.CustomConf), userID)
+	return fmt.Sprintf(tplPublicKey, util.ShellEscape(command), principal)
+}
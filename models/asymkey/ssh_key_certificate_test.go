@@ -0,0 +1,152 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package asymkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestCertificate(t *testing.T, ca ssh.Signer, keyID string, principals []string, mutate func(*ssh.Certificate)) *ssh.Certificate {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        ssh.UserCert,
+		KeyId:           keyID,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(time.Now().Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	if mutate != nil {
+		mutate(cert)
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+func newTestCA(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestCheckUserCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	defer test.MockVariableValue(&setting.SSH.TrustedUserCAKeysParsed, []ssh.PublicKey{ca.PublicKey()})()
+
+	cert := newTestCertificate(t, ca, "user2", []string{"user2"}, nil)
+	assert.NoError(t, CheckUserCertificate(cert, "user2", nil))
+	assert.Error(t, CheckUserCertificate(cert, "user4", nil))
+
+	t.Run("Untrusted", func(t *testing.T) {
+		cert := newTestCertificate(t, newTestCA(t), "user2", []string{"user2"}, nil)
+		assert.Error(t, CheckUserCertificate(cert, "user2", nil))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		cert := newTestCertificate(t, ca, "user2", []string{"user2"}, func(cert *ssh.Certificate) {
+			cert.ValidBefore = uint64(time.Now().Add(-time.Minute).Unix())
+		})
+		assert.Error(t, CheckUserCertificate(cert, "user2", nil))
+	})
+
+	t.Run("HostCertificate", func(t *testing.T) {
+		cert := newTestCertificate(t, ca, "user2", []string{"user2"}, func(cert *ssh.Certificate) {
+			cert.CertType = ssh.HostCert
+		})
+		assert.Error(t, CheckUserCertificate(cert, "user2", nil))
+	})
+
+	t.Run("CriticalOptions", func(t *testing.T) {
+		cert := newTestCertificate(t, ca, "user2", []string{"user2"}, func(cert *ssh.Certificate) {
+			cert.CriticalOptions = map[string]string{"force-command": "/bin/true"}
+		})
+		assert.Error(t, CheckUserCertificate(cert, "user2", nil))
+
+		cert = newTestCertificate(t, ca, "user2", []string{"user2"}, func(cert *ssh.Certificate) {
+			cert.CriticalOptions = map[string]string{"source-address": "10.0.0.0/8,192.168.1.1"}
+		})
+		assert.NoError(t, CheckUserCertificate(cert, "user2", nil))
+		assert.NoError(t, CheckUserCertificate(cert, "user2", &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 22}))
+		assert.NoError(t, CheckUserCertificate(cert, "user2", &net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 22}))
+		assert.Error(t, CheckUserCertificate(cert, "user2", &net.TCPAddr{IP: net.ParseIP("192.168.1.2"), Port: 22}))
+	})
+}
+
+func TestGetUserByCertificate(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	ca := newTestCA(t)
+	cert := newTestCertificate(t, ca, "user4@example.com", []string{"ops", "user2@example.com"}, nil)
+	defer test.MockVariableValue(&setting.SSH.TrustedUserCAMappingTemplate, "{username}@example.com")()
+
+	t.Run("Off", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.SSH.TrustedUserCAMapping, "off")()
+
+		_, _, err := GetUserByCertificate(db.DefaultContext, cert)
+		assert.True(t, user_model.IsErrUserNotExist(err))
+	})
+
+	t.Run("Principal", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.SSH.TrustedUserCAMapping, "principal")()
+
+		u, principals, err := GetUserByCertificate(db.DefaultContext, cert)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, u.ID)
+		assert.Equal(t, []string{"user2@example.com"}, principals)
+	})
+
+	t.Run("KeyID", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.SSH.TrustedUserCAMapping, "key_id")()
+
+		u, principals, err := GetUserByCertificate(db.DefaultContext, cert)
+		require.NoError(t, err)
+		assert.EqualValues(t, 4, u.ID)
+		assert.Equal(t, []string{"ops", "user2@example.com"}, principals)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.SSH.TrustedUserCAMapping, "key_id")()
+
+		cert := newTestCertificate(t, ca, "org3@example.com", nil, nil)
+		_, _, err := GetUserByCertificate(db.DefaultContext, cert)
+		assert.True(t, user_model.IsErrUserNotExist(err))
+	})
+
+	t.Run("Template", func(t *testing.T) {
+		for name, username := range map[string]string{
+			"user2@example.com": "user2",
+			"user2@example.org": "",
+			"@example.com":      "",
+			"user2":             "",
+		} {
+			got, ok := usernameFromMappingTemplate(name)
+			assert.Equal(t, username != "", ok, name)
+			assert.Equal(t, username, got, name)
+		}
+	})
+}
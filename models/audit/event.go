@@ -0,0 +1,265 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// Action is the kind of a security-relevant action recorded in the audit log
+type Action string
+
+// The actions recorded in the audit log
+const (
+	ActionUserSignIn       Action = "user_signin"
+	ActionUserSignInFailed Action = "user_signin_failed"
+	ActionUserSignOut      Action = "user_signout"
+	ActionUserPassword     Action = "user_password_change"
+	ActionTwoFactorEnable  Action = "user_two_factor_enable"
+	ActionTwoFactorDisable Action = "user_two_factor_disable"
+
+	ActionCollaboratorAdd    Action = "collaborator_add"
+	ActionCollaboratorUpdate Action = "collaborator_update"
+	ActionCollaboratorRemove Action = "collaborator_remove"
+	ActionTeamCreate         Action = "team_create"
+	ActionTeamUpdate         Action = "team_update"
+	ActionTeamDelete         Action = "team_delete"
+	ActionTeamMemberAdd      Action = "team_member_add"
+	ActionTeamMemberRemove   Action = "team_member_remove"
+
+	ActionBranchProtectionUpdate Action = "branch_protection_update"
+	ActionBranchProtectionDelete Action = "branch_protection_delete"
+	ActionRulesetUpdate          Action = "ruleset_update"
+	ActionRulesetDelete          Action = "ruleset_delete"
+
+	ActionDeployKeyAdd    Action = "deploy_key_add"
+	ActionDeployKeyRemove Action = "deploy_key_remove"
+	ActionPublicKeyAdd    Action = "public_key_add"
+	ActionPublicKeyRemove Action = "public_key_remove"
+
+	ActionAccessTokenCreate Action = "access_token_create"
+	ActionAccessTokenDelete Action = "access_token_delete"
+
+	ActionWebhookCreate Action = "webhook_create"
+	ActionWebhookUpdate Action = "webhook_update"
+	ActionWebhookDelete Action = "webhook_delete"
+
+	ActionSecretUpdate Action = "secret_update"
+	ActionSecretDelete Action = "secret_delete"
+
+	ActionAdminUserCreate       Action = "admin_user_create"
+	ActionAdminUserUpdate       Action = "admin_user_update"
+	ActionAdminUserDelete       Action = "admin_user_delete"
+	ActionAdminAuthSourceCreate Action = "admin_auth_source_create"
+	ActionAdminAuthSourceUpdate Action = "admin_auth_source_update"
+	ActionAdminAuthSourceDelete Action = "admin_auth_source_delete"
+)
+
+// Actions lists all the actions recorded in the audit log
+var Actions = []Action{
+	ActionUserSignIn, ActionUserSignInFailed, ActionUserSignOut, ActionUserPassword, ActionTwoFactorEnable, ActionTwoFactorDisable,
+	ActionCollaboratorAdd, ActionCollaboratorUpdate, ActionCollaboratorRemove,
+	ActionTeamCreate, ActionTeamUpdate, ActionTeamDelete, ActionTeamMemberAdd, ActionTeamMemberRemove,
+	ActionBranchProtectionUpdate, ActionBranchProtectionDelete, ActionRulesetUpdate, ActionRulesetDelete,
+	ActionDeployKeyAdd, ActionDeployKeyRemove, ActionPublicKeyAdd, ActionPublicKeyRemove,
+	ActionAccessTokenCreate, ActionAccessTokenDelete,
+	ActionWebhookCreate, ActionWebhookUpdate, ActionWebhookDelete,
+	ActionSecretUpdate, ActionSecretDelete,
+	ActionAdminUserCreate, ActionAdminUserUpdate, ActionAdminUserDelete,
+	ActionAdminAuthSourceCreate, ActionAdminAuthSourceUpdate, ActionAdminAuthSourceDelete,
+}
+
+// IsValid returns whether the action is one of the actions recorded in the audit log
+func (a Action) IsValid() bool {
+	return slices.Contains(Actions, a)
+}
+
+// Event is an entry of the audit log. Every event carries the hash of the previous one so that modifying or removing
+// an event in the middle of the log breaks the chain, the hashes are keyed with the SECRET_KEY so that they cannot be
+// recomputed with the access to the database only.
+type Event struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Action      Action             `xorm:"INDEX NOT NULL"`
+	ActorID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	ActorName   string             `xorm:"NOT NULL DEFAULT ''"`
+	ActorIP     string             `xorm:"NOT NULL DEFAULT ''"`
+	OwnerID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RepoID      int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	TargetType  string             `xorm:"NOT NULL DEFAULT ''"`
+	TargetID    int64              `xorm:"NOT NULL DEFAULT 0"`
+	TargetName  string             `xorm:"NOT NULL DEFAULT ''"`
+	Before      string             `xorm:"TEXT"`
+	After       string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL"`
+	PrevHash    string             `xorm:"VARCHAR(64) UNIQUE NOT NULL DEFAULT ''"`
+	Hash        string             `xorm:"VARCHAR(64) NOT NULL DEFAULT ''"`
+}
+
+func init() {
+	db.RegisterModel(new(Event))
+}
+
+// TableName returns the table name of Event
+func (Event) TableName() string {
+	return "audit_event"
+}
+
+// ComputeHash returns the HMAC of the event chained to the hash of the previous event
+func (e *Event) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		strconv.FormatInt(e.ID, 10),
+		string(e.Action),
+		strconv.FormatInt(e.ActorID, 10),
+		e.ActorName,
+		e.ActorIP,
+		strconv.FormatInt(e.OwnerID, 10),
+		strconv.FormatInt(e.RepoID, 10),
+		e.TargetType,
+		strconv.FormatInt(e.TargetID, 10),
+		e.TargetName,
+		e.Before,
+		e.After,
+		strconv.FormatInt(int64(e.CreatedUnix), 10),
+	}
+	mac := hmac.New(sha256.New, []byte(setting.SecretKey))
+	_, _ = mac.Write([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// insertLock serializes the insertion of the events of this instance, which are all chained to the last event
+var insertLock sync.Mutex
+
+// lastEventID returns the ID of the last event of the audit log, 0 if it is empty
+func lastEventID(ctx context.Context) (int64, error) {
+	last := &Event{}
+	if _, err := db.GetEngine(ctx).Desc("id").Limit(1).Cols("id").Get(last); err != nil {
+		return 0, err
+	}
+	return last.ID, nil
+}
+
+// InsertEvent appends the event to the audit log. The events of this instance are inserted one at a time, and as the
+// hash of the previous event is unique, the database rejects an event chained concurrently by another instance sharing
+// it to the same event. The event is then chained again to the new last event, as many times as other events are
+// appended meanwhile, so that it is never dropped.
+func InsertEvent(ctx context.Context, e *Event) error {
+	insertLock.Lock()
+	defer insertLock.Unlock()
+
+	if e.CreatedUnix == 0 {
+		e.CreatedUnix = timeutil.TimeStampNow()
+	}
+	for {
+		var lastID int64
+		e.ID, e.PrevHash, e.Hash = 0, "", ""
+		err := db.WithTx(ctx, func(ctx context.Context) error {
+			last := &Event{}
+			has, err := db.GetEngine(ctx).Desc("id").Limit(1).Get(last)
+			if err != nil {
+				return err
+			}
+			if has {
+				lastID, e.PrevHash = last.ID, last.Hash
+			}
+			if err := db.Insert(ctx, e); err != nil {
+				return err
+			}
+			e.Hash = e.ComputeHash()
+			_, err = db.GetEngine(ctx).ID(e.ID).Cols("hash").Update(e)
+			return err
+		})
+		if err == nil {
+			return nil
+		}
+
+		// only retry when the failure is caused by another event appended meanwhile
+		newLastID, lastErr := lastEventID(ctx)
+		if lastErr != nil || newLastID == lastID {
+			return err
+		}
+	}
+}
+
+// FindEventsOptions represents the options to search the audit log
+type FindEventsOptions struct {
+	db.ListOptions
+	OwnerID int64
+	RepoID  int64
+	ActorID int64
+	Action  Action
+	Since   timeutil.TimeStamp
+	Before  timeutil.TimeStamp
+	// UntilID limits the events to the ones recorded up to this one so that paginating is not shifted by the events
+	// recorded meanwhile
+	UntilID int64
+}
+
+// ToConds implements db.FindOptions
+func (opts FindEventsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.ActorID > 0 {
+		cond = cond.And(builder.Eq{"actor_id": opts.ActorID})
+	}
+	if opts.Action != "" {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	if opts.UntilID > 0 {
+		cond = cond.And(builder.Lte{"id": opts.UntilID})
+	}
+	return cond
+}
+
+// ToOrders implements db.FindOptions
+func (opts FindEventsOptions) ToOrders() string {
+	return "id DESC"
+}
+
+// VerifyChain checks the hashes of the whole audit log, it returns the ID of the first event which does not match
+// its hash or is not chained to the previous event, 0 if the log is intact
+func VerifyChain(ctx context.Context) (int64, error) {
+	var prevHash string
+	var lastID int64
+	for {
+		events := make([]*Event, 0, setting.Database.IterateBufferSize)
+		if err := db.GetEngine(ctx).Where("id > ?", lastID).Asc("id").Limit(setting.Database.IterateBufferSize).Find(&events); err != nil {
+			return 0, err
+		}
+		if len(events) == 0 {
+			return 0, nil
+		}
+		for _, e := range events {
+			if e.PrevHash != prevHash || e.Hash != e.ComputeHash() {
+				return e.ID, nil
+			}
+			prevHash = e.Hash
+			lastID = e.ID
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit_test
+
+import (
+	"sync"
+	"testing"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	events := []*audit_model.Event{
+		{Action: audit_model.ActionUserSignIn, ActorID: 2, ActorName: "user2", ActorIP: "127.0.0.1"},
+		{Action: audit_model.ActionDeployKeyAdd, ActorID: 2, ActorName: "user2", OwnerID: 2, RepoID: 1, TargetType: "deploy_key", TargetID: 1, TargetName: "key", After: `{"mode":"write"}`},
+		{Action: audit_model.ActionTeamMemberAdd, ActorID: 2, ActorName: "user2", OwnerID: 3, TargetType: "user", TargetID: 4, TargetName: "user4"},
+	}
+	for _, e := range events {
+		require.NoError(t, audit_model.InsertEvent(db.DefaultContext, e))
+	}
+	assert.Empty(t, events[0].PrevHash)
+	assert.Equal(t, events[0].Hash, events[1].PrevHash)
+	assert.Equal(t, events[1].Hash, events[2].PrevHash)
+
+	found, err := db.Find[audit_model.Event](db.DefaultContext, audit_model.FindEventsOptions{OwnerID: 2})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, events[1].ID, found[0].ID)
+
+	found, err = db.Find[audit_model.Event](db.DefaultContext, audit_model.FindEventsOptions{Action: audit_model.ActionTeamMemberAdd})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, events[2].ID, found[0].ID)
+
+	brokenID, err := audit_model.VerifyChain(db.DefaultContext)
+	require.NoError(t, err)
+	assert.Zero(t, brokenID)
+
+	// altering an event breaks the chain
+	_, err = db.GetEngine(db.DefaultContext).ID(events[1].ID).Cols("after").Update(&audit_model.Event{After: `{"mode":"read"}`})
+	require.NoError(t, err)
+	brokenID, err = audit_model.VerifyChain(db.DefaultContext)
+	require.NoError(t, err)
+	assert.Equal(t, events[1].ID, brokenID)
+}
+
+func TestInsertEventsConcurrently(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	require.NoError(t, db.TruncateBeans(db.DefaultContext, &audit_model.Event{}))
+
+	var wg sync.WaitGroup
+	// more concurrent events than a bounded number of retries would chain
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, audit_model.InsertEvent(db.DefaultContext, &audit_model.Event{Action: audit_model.ActionUserSignInFailed, ActorName: "user2"}))
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 50, unittest.GetCount(t, &audit_model.Event{}))
+	brokenID, err := audit_model.VerifyChain(db.DefaultContext)
+	require.NoError(t, err)
+	assert.Zero(t, brokenID)
+}
+
+func TestEventHashIsKeyed(t *testing.T) {
+	e := &audit_model.Event{ID: 1, Action: audit_model.ActionUserSignIn, ActorID: 2, ActorName: "user2"}
+	hash := e.ComputeHash()
+
+	// the hash cannot be recomputed without the secret key
+	defer test.MockVariableValue(&setting.SecretKey, "another secret")()
+	assert.NotEqual(t, hash, e.ComputeHash())
+}
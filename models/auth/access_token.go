@@ -9,6 +9,7 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"time"
 
 	"code.gitea.io/gitea/models/db"
@@ -68,9 +69,14 @@ type AccessToken struct {
 	TokenSalt      string
 	TokenLastEight string `xorm:"INDEX token_last_eight"`
 	Scope          AccessTokenScope
+	// OrgID restricts the token to the organization and its repositories
+	OrgID int64 `xorm:"NOT NULL DEFAULT 0"`
+	// RepoIDs restricts the token to these repositories
+	RepoIDs []int64 `xorm:"JSON TEXT"`
 
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
+	ExpiresUnix       timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"` // the token never expires if 0
 	HasRecentActivity bool               `xorm:"-"`
 	HasUsed           bool               `xorm:"-"`
 }
@@ -114,6 +120,30 @@ func NewAccessToken(ctx context.Context, t *AccessToken) error {
 	return err
 }
 
+// IsExpired returns true if the token has an expiry date which has passed
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix != 0 && t.ExpiresUnix <= timeutil.TimeStampNow()
+}
+
+// IsRestricted returns true if the token is restricted to an organization or to a set of repositories
+func (t *AccessToken) IsRestricted() bool {
+	return t.OrgID != 0 || len(t.RepoIDs) > 0
+}
+
+// AllowsRepo returns true if the token may access the repository of the owner
+func (t *AccessToken) AllowsRepo(ownerID, repoID int64) bool {
+	if !t.IsRestricted() {
+		return true
+	}
+	return (t.OrgID != 0 && t.OrgID == ownerID) || slices.Contains(t.RepoIDs, repoID)
+}
+
+// AllowsOwner returns true if the token may access the user or organization itself and not only some of its
+// repositories
+func (t *AccessToken) AllowsOwner(ownerID int64) bool {
+	return !t.IsRestricted() || t.OrgID == ownerID
+}
+
 // DisplayPublicOnly whether to display this as a public-only token.
 func (t *AccessToken) DisplayPublicOnly() bool {
 	publicOnly, err := t.Scope.PublicOnly()
@@ -164,10 +194,13 @@ func GetAccessTokenBySHA(ctx context.Context, token string) (*AccessToken, error
 		if err != nil {
 			return nil, err
 		}
-		if has {
+		if has && !accessToken.IsExpired() {
 			return accessToken, nil
 		}
 		successfulAccessTokenCache.Remove(token)
+		if has {
+			return nil, ErrAccessTokenNotExist{token}
+		}
 	}
 
 	var tokens []AccessToken
@@ -181,6 +214,10 @@ func GetAccessTokenBySHA(ctx context.Context, token string) (*AccessToken, error
 	for _, t := range tokens {
 		tempHash := HashToken(token, t.TokenSalt)
 		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(tempHash)) == 1 {
+			// expired tokens are kept so that their owners can see them until they delete them
+			if t.IsExpired() {
+				return nil, ErrAccessTokenNotExist{token}
+			}
 			if successfulAccessTokenCache != nil {
 				successfulAccessTokenCache.Add(token, t.ID)
 			}
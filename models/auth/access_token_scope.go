@@ -268,6 +268,12 @@ func (s AccessTokenScope) parse() (accessTokenScopeBitmap, error) {
 	return bitmap, nil
 }
 
+// IsAccessTokenScope returns true if the string is a single access token scope
+func IsAccessTokenScope(s string) bool {
+	_, ok := allAccessTokenScopeBits[AccessTokenScope(s)]
+	return ok
+}
+
 // StringSlice returns the AccessTokenScope as a []string
 func (s AccessTokenScope) StringSlice() []string {
 	return strings.Split(string(s), ",")
@@ -9,6 +9,7 @@ import (
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -130,3 +131,42 @@ func TestDeleteAccessTokenByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, auth_model.IsErrAccessTokenNotExist(err))
 }
+
+func TestGetAccessTokenBySHAExpired(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	token := &auth_model.AccessToken{
+		UID:         1,
+		Name:        "Token C",
+		ExpiresUnix: timeutil.TimeStampNow().Add(3600),
+	}
+	assert.NoError(t, auth_model.NewAccessToken(db.DefaultContext, token))
+
+	_, err := auth_model.GetAccessTokenBySHA(db.DefaultContext, token.Token)
+	assert.NoError(t, err)
+
+	token.ExpiresUnix = timeutil.TimeStampNow().Add(-3600)
+	assert.NoError(t, auth_model.UpdateAccessToken(db.DefaultContext, token))
+	_, err = auth_model.GetAccessTokenBySHA(db.DefaultContext, token.Token)
+	assert.True(t, auth_model.IsErrAccessTokenNotExist(err))
+}
+
+func TestAccessTokenAllowsRepo(t *testing.T) {
+	unrestricted := &auth_model.AccessToken{}
+	assert.False(t, unrestricted.IsRestricted())
+	assert.True(t, unrestricted.AllowsRepo(2, 1))
+	assert.True(t, unrestricted.AllowsOwner(2))
+
+	org := &auth_model.AccessToken{OrgID: 3}
+	assert.True(t, org.IsRestricted())
+	assert.True(t, org.AllowsRepo(3, 3))
+	assert.False(t, org.AllowsRepo(2, 1))
+	assert.True(t, org.AllowsOwner(3))
+	assert.False(t, org.AllowsOwner(2))
+
+	repos := &auth_model.AccessToken{RepoIDs: []int64{1, 4}}
+	assert.True(t, repos.IsRestricted())
+	assert.True(t, repos.AllowsRepo(2, 1))
+	assert.True(t, repos.AllowsRepo(5, 4))
+	assert.False(t, repos.AllowsRepo(2, 2))
+	assert.False(t, repos.AllowsOwner(2))
+}
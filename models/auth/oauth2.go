@@ -16,6 +16,7 @@ import (
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
@@ -37,16 +38,21 @@ type OAuth2Application struct {
 	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.1
 	// "Authorization servers MUST record the client type in the client registration details"
 	// https://datatracker.ietf.org/doc/html/rfc8252#section-8.4
-	ConfidentialClient bool               `xorm:"NOT NULL DEFAULT TRUE"`
-	RedirectURIs       []string           `xorm:"redirect_uris JSON TEXT"`
-	CreatedUnix        timeutil.TimeStamp `xorm:"INDEX created"`
-	UpdatedUnix        timeutil.TimeStamp `xorm:"INDEX updated"`
+	ConfidentialClient bool     `xorm:"NOT NULL DEFAULT TRUE"`
+	RedirectURIs       []string `xorm:"redirect_uris JSON TEXT"`
+	// ClientCredentialsUserID is the user which the application acts as with the client credentials grant,
+	// the grant is disabled if it is 0
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+	ClientCredentialsUserID int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix             timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix             timeutil.TimeStamp `xorm:"INDEX updated"`
 }
 
 func init() {
 	db.RegisterModel(new(OAuth2Application))
 	db.RegisterModel(new(OAuth2AuthorizationCode))
 	db.RegisterModel(new(OAuth2Grant))
+	db.RegisterModel(new(OAuth2DeviceAuthorization))
 }
 
 type BuiltinOAuth2Application struct {
@@ -258,21 +264,23 @@ func GetOAuth2ApplicationByID(ctx context.Context, id int64) (app *OAuth2Applica
 
 // CreateOAuth2ApplicationOptions holds options to create an oauth2 application
 type CreateOAuth2ApplicationOptions struct {
-	Name               string
-	UserID             int64
-	ConfidentialClient bool
-	RedirectURIs       []string
+	Name                    string
+	UserID                  int64
+	ConfidentialClient      bool
+	RedirectURIs            []string
+	ClientCredentialsUserID int64
 }
 
 // CreateOAuth2Application inserts a new oauth2 application
 func CreateOAuth2Application(ctx context.Context, opts CreateOAuth2ApplicationOptions) (*OAuth2Application, error) {
 	clientID := uuid.New().String()
 	app := &OAuth2Application{
-		UID:                opts.UserID,
-		Name:               opts.Name,
-		ClientID:           clientID,
-		RedirectURIs:       opts.RedirectURIs,
-		ConfidentialClient: opts.ConfidentialClient,
+		UID:                     opts.UserID,
+		Name:                    opts.Name,
+		ClientID:                clientID,
+		RedirectURIs:            opts.RedirectURIs,
+		ConfidentialClient:      opts.ConfidentialClient,
+		ClientCredentialsUserID: opts.ClientCredentialsUserID,
 	}
 	if err := db.Insert(ctx, app); err != nil {
 		return nil, err
@@ -282,11 +290,12 @@ func CreateOAuth2Application(ctx context.Context, opts CreateOAuth2ApplicationOp
 
 // UpdateOAuth2ApplicationOptions holds options to update an oauth2 application
 type UpdateOAuth2ApplicationOptions struct {
-	ID                 int64
-	Name               string
-	UserID             int64
-	ConfidentialClient bool
-	RedirectURIs       []string
+	ID                      int64
+	Name                    string
+	UserID                  int64
+	ConfidentialClient      bool
+	RedirectURIs            []string
+	ClientCredentialsUserID optional.Option[int64]
 }
 
 // UpdateOAuth2Application updates an oauth2 application
@@ -312,6 +321,9 @@ func UpdateOAuth2Application(ctx context.Context, opts UpdateOAuth2ApplicationOp
 	app.Name = opts.Name
 	app.RedirectURIs = opts.RedirectURIs
 	app.ConfidentialClient = opts.ConfidentialClient
+	if opts.ClientCredentialsUserID.Has() {
+		app.ClientCredentialsUserID = opts.ClientCredentialsUserID.Value()
+	}
 
 	if err = updateOAuth2Application(ctx, app); err != nil {
 		return nil, err
@@ -322,7 +334,7 @@ func UpdateOAuth2Application(ctx context.Context, opts UpdateOAuth2ApplicationOp
 }
 
 func updateOAuth2Application(ctx context.Context, app *OAuth2Application) error {
-	if _, err := db.GetEngine(ctx).ID(app.ID).UseBool("confidential_client").Update(app); err != nil {
+	if _, err := db.GetEngine(ctx).ID(app.ID).UseBool("confidential_client").MustCols("client_credentials_user_id").Update(app); err != nil {
 		return err
 	}
 	return nil
@@ -354,6 +366,10 @@ func deleteOAuth2Application(ctx context.Context, id, userid int64) error {
 	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2Grant)); err != nil {
 		return err
 	}
+
+	if _, err := sess.Where("application_id = ?", id).Delete(new(OAuth2DeviceAuthorization)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -521,6 +537,58 @@ func (grant *OAuth2Grant) ScopeContains(scope string) bool {
 	return false
 }
 
+// AccessTokenScope returns the access token scope which the scope of the grant restricts its tokens to, the scope
+// items which are not access token scopes, like the OpenID Connect scopes, are ignored and the tokens of grants
+// without access token scopes are not restricted
+func (grant *OAuth2Grant) AccessTokenScope() AccessTokenScope {
+	var scopes []string
+	for _, scope := range strings.Fields(grant.Scope) {
+		if IsAccessTokenScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return AccessTokenScopeAll
+	}
+	scope, err := AccessTokenScope(strings.Join(scopes, ",")).Normalize()
+	if err != nil {
+		return AccessTokenScopeAll
+	}
+	return scope
+}
+
+// MergedScope returns the union of the scope of the grant and the given scope, as a grant without access token
+// scopes is not restricted the access token scopes are dropped when either of them has none
+func (grant *OAuth2Grant) MergedScope(scope string) string {
+	restricted := func(scope string) bool {
+		for _, item := range strings.Fields(scope) {
+			if IsAccessTokenScope(item) {
+				return true
+			}
+		}
+		return false
+	}
+	keepAccessTokenScopes := restricted(grant.Scope) && restricted(scope)
+
+	var merged []string
+	seen := make(map[string]bool)
+	for _, item := range append(strings.Fields(grant.Scope), strings.Fields(scope)...) {
+		if seen[item] || (!keepAccessTokenScopes && IsAccessTokenScope(item)) {
+			continue
+		}
+		seen[item] = true
+		merged = append(merged, item)
+	}
+	return strings.Join(merged, " ")
+}
+
+// SetScope updates the scope of a grant
+func (grant *OAuth2Grant) SetScope(ctx context.Context, scope string) error {
+	grant.Scope = scope
+	_, err := db.GetEngine(ctx).ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // SetNonce updates the current nonce value of a grant
 func (grant *OAuth2Grant) SetNonce(ctx context.Context, nonce string) error {
 	grant.Nonce = nonce
@@ -633,6 +701,23 @@ func GetActiveOAuth2SourceByName(ctx context.Context, name string) (*Source, err
 	return authSource, nil
 }
 
+// GetActiveExternalSourceByName returns an active OAuth2 or SAML AuthSource based on the given name, these sources
+// sign in users through an external identity provider
+func GetActiveExternalSourceByName(ctx context.Context, name string) (*Source, error) {
+	authSource := new(Source)
+	has, err := db.GetEngine(ctx).Where("name = ? and is_active = ?", name, true).
+		In("type", OAuth2, SAML).Get(authSource)
+	if err != nil {
+		return nil, err
+	}
+
+	if !has {
+		return nil, fmt.Errorf("external source not found, name: %q", name)
+	}
+
+	return authSource, nil
+}
+
 func DeleteOAuth2RelictsByUserID(ctx context.Context, userID int64) error {
 	deleteCond := builder.Select("id").From("oauth2_grant").Where(builder.Eq{"oauth2_grant.user_id": userID})
 
@@ -644,10 +729,16 @@ func DeleteOAuth2RelictsByUserID(ctx context.Context, userID int64) error {
 	if err := db.DeleteBeans(ctx,
 		&OAuth2Application{UID: userID},
 		&OAuth2Grant{UserID: userID},
+		&OAuth2DeviceAuthorization{UserID: userID},
 	); err != nil {
 		return fmt.Errorf("DeleteBeans: %w", err)
 	}
 
+	if _, err := db.GetEngine(ctx).Where(builder.Eq{"client_credentials_user_id": userID}).
+		Cols("client_credentials_user_id").Update(&OAuth2Application{}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
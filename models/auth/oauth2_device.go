@@ -0,0 +1,216 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// OAuth2DeviceAuthorizationStatus is the status of a device authorization request
+type OAuth2DeviceAuthorizationStatus int
+
+const (
+	// OAuth2DeviceAuthorizationPending means that the user did not approve or deny the request yet
+	OAuth2DeviceAuthorizationPending OAuth2DeviceAuthorizationStatus = iota
+	// OAuth2DeviceAuthorizationApproved means that the user approved the request
+	OAuth2DeviceAuthorizationApproved
+	// OAuth2DeviceAuthorizationDenied means that the user denied the request
+	OAuth2DeviceAuthorizationDenied
+)
+
+// The characters of the user codes, they are case-insensitive and exclude vowels and similar looking characters
+// https://datatracker.ietf.org/doc/html/rfc8628#section-6.1
+const userCodeChars = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength is the number of characters of the user codes
+const userCodeLength = 8
+
+// OAuth2DeviceAuthorization is a pending request of a device to be authorized by a user (RFC 8628)
+type OAuth2DeviceAuthorization struct {
+	ID            int64 `xorm:"pk autoincr"`
+	ApplicationID int64 `xorm:"INDEX"`
+	// DeviceCode is only set on creation, the device code is stored hashed
+	DeviceCode     string                          `xorm:"-"`
+	DeviceCodeHash string                          `xorm:"UNIQUE"`
+	UserCode       string                          `xorm:"UNIQUE"`
+	Scope          string                          `xorm:"TEXT"`
+	Status         OAuth2DeviceAuthorizationStatus `xorm:"NOT NULL DEFAULT 0"`
+	// UserID is the user who approved or denied the request
+	UserID int64
+	// PollingInterval is the minimum number of seconds between two token requests of the device
+	PollingInterval int64
+	LastPolledUnix  timeutil.TimeStamp
+	ExpiresUnix     timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix     timeutil.TimeStamp `xorm:"created"`
+}
+
+// TableName sets the table name to `oauth2_device_authorization`
+func (*OAuth2DeviceAuthorization) TableName() string {
+	return "oauth2_device_authorization"
+}
+
+// IsExpired returns true if the device code expired
+func (d *OAuth2DeviceAuthorization) IsExpired() bool {
+	return d.ExpiresUnix <= timeutil.TimeStampNow()
+}
+
+// FormattedUserCode returns the user code in the format shown to users, e.g. WDJB-MJHT
+func (d *OAuth2DeviceAuthorization) FormattedUserCode() string {
+	return d.UserCode[:userCodeLength/2] + "-" + d.UserCode[userCodeLength/2:]
+}
+
+func hashDeviceCode(deviceCode string) string {
+	h := sha256.Sum256([]byte(deviceCode))
+	return hex.EncodeToString(h[:])
+}
+
+func generateUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := util.CryptoRandomInt(int64(len(userCodeChars)))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeChars[n]
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode removes the separators which users may enter with the user code
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
+
+// CreateOAuth2DeviceAuthorization creates a device authorization request for the application, the device code is only
+// returned by this function
+func CreateOAuth2DeviceAuthorization(ctx context.Context, app *OAuth2Application, scope string) (*OAuth2DeviceAuthorization, error) {
+	rBytes, err := util.CryptoRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	// Add a prefix to the base32, this is in order to make it easier
+	// for code scanners to grab sensitive tokens.
+	deviceCode := "gtd_" + base32Lower.EncodeToString(rBytes)
+
+	d := &OAuth2DeviceAuthorization{
+		ApplicationID:   app.ID,
+		DeviceCode:      deviceCode,
+		DeviceCodeHash:  hashDeviceCode(deviceCode),
+		Scope:           scope,
+		PollingInterval: setting.OAuth2.DeviceCodePollingInterval,
+		ExpiresUnix:     timeutil.TimeStampNow().Add(setting.OAuth2.DeviceCodeExpirationTime),
+	}
+	return d, db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.GetEngine(ctx).Where(builder.Lte{"expires_unix": timeutil.TimeStampNow()}).
+			Delete(new(OAuth2DeviceAuthorization)); err != nil {
+			return err
+		}
+		// the user codes are short, so they can collide with the ones of other pending requests
+		for attempt := 0; attempt < 3 && d.UserCode == ""; attempt++ {
+			userCode, err := generateUserCode()
+			if err != nil {
+				return err
+			}
+			exists, err := db.GetEngine(ctx).Exist(&OAuth2DeviceAuthorization{UserCode: userCode})
+			if err != nil {
+				return err
+			}
+			if !exists {
+				d.UserCode = userCode
+			}
+		}
+		if d.UserCode == "" {
+			return errors.New("unable to generate a unique user code")
+		}
+		return db.Insert(ctx, d)
+	})
+}
+
+// GetPendingOAuth2DeviceAuthorizationByUserCode returns the pending and not expired device authorization request with
+// the user code
+func GetPendingOAuth2DeviceAuthorizationByUserCode(ctx context.Context, userCode string) (*OAuth2DeviceAuthorization, error) {
+	userCode = normalizeUserCode(userCode)
+	if len(userCode) != userCodeLength {
+		return nil, nil
+	}
+	d := new(OAuth2DeviceAuthorization)
+	has, err := db.GetEngine(ctx).Where(builder.Eq{
+		"user_code": userCode,
+		"status":    OAuth2DeviceAuthorizationPending,
+	}).And(builder.Gt{"expires_unix": timeutil.TimeStampNow()}).Get(d)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// GetOAuth2DeviceAuthorizationByDeviceCode returns the device authorization request with the device code
+func GetOAuth2DeviceAuthorizationByDeviceCode(ctx context.Context, deviceCode string) (*OAuth2DeviceAuthorization, error) {
+	if deviceCode == "" {
+		return nil, nil
+	}
+	d := new(OAuth2DeviceAuthorization)
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"device_code_hash": hashDeviceCode(deviceCode)}).Get(d)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// SetStatus approves or denies the pending device authorization request on behalf of the user, it returns false if
+// the request is not pending anymore
+func (d *OAuth2DeviceAuthorization) SetStatus(ctx context.Context, status OAuth2DeviceAuthorizationStatus, userID int64) (bool, error) {
+	updated, err := db.GetEngine(ctx).ID(d.ID).Where(builder.Eq{"status": OAuth2DeviceAuthorizationPending}).
+		Cols("status", "user_id").Update(&OAuth2DeviceAuthorization{Status: status, UserID: userID})
+	if err != nil || updated == 0 {
+		return false, err
+	}
+	d.Status = status
+	d.UserID = userID
+	return true, nil
+}
+
+// Poll records a token request of the device, it returns false if the device polls faster than its interval, which is
+// then increased as required by https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+func (d *OAuth2DeviceAuthorization) Poll(ctx context.Context) (bool, error) {
+	now := timeutil.TimeStampNow()
+	tooFast := d.LastPolledUnix.Add(d.PollingInterval) > now
+	d.LastPolledUnix = now
+	cols := []string{"last_polled_unix"}
+	if tooFast {
+		d.PollingInterval += 5
+		cols = append(cols, "polling_interval")
+	}
+	if _, err := db.GetEngine(ctx).ID(d.ID).Cols(cols...).Update(d); err != nil {
+		return false, err
+	}
+	return !tooFast, nil
+}
+
+// Consume deletes the device authorization request once the device got its tokens, it returns false if the request
+// was already consumed
+func (d *OAuth2DeviceAuthorization) Consume(ctx context.Context) (bool, error) {
+	deleted, err := db.GetEngine(ctx).ID(d.ID).NoAutoCondition().Delete(d)
+	return deleted > 0, err
+}
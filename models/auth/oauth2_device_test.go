@@ -0,0 +1,95 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth_test
+
+import (
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuth2DeviceAuthorization(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	app := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 2})
+
+	d, err := auth_model.CreateOAuth2DeviceAuthorization(db.DefaultContext, app, "openid read:repository")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(d.DeviceCode, "gtd_"))
+	assert.Len(t, d.UserCode, 8)
+	assert.Equal(t, d.UserCode[:4]+"-"+d.UserCode[4:], d.FormattedUserCode())
+	assert.False(t, d.IsExpired())
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2DeviceAuthorization{DeviceCodeHash: d.DeviceCode})
+
+	// the user codes are case-insensitive and can be entered with or without their separator
+	found, err := auth_model.GetPendingOAuth2DeviceAuthorizationByUserCode(db.DefaultContext, strings.ToLower(d.FormattedUserCode()))
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, d.ID, found.ID)
+	found, err = auth_model.GetPendingOAuth2DeviceAuthorizationByUserCode(db.DefaultContext, "BCDF-GHJ")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	found, err = auth_model.GetOAuth2DeviceAuthorizationByDeviceCode(db.DefaultContext, d.DeviceCode)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, d.ID, found.ID)
+	found, err = auth_model.GetOAuth2DeviceAuthorizationByDeviceCode(db.DefaultContext, "gtd_invalid")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	// polling faster than the interval slows the device down
+	inInterval, err := d.Poll(db.DefaultContext)
+	require.NoError(t, err)
+	assert.True(t, inInterval)
+	interval := d.PollingInterval
+	inInterval, err = d.Poll(db.DefaultContext)
+	require.NoError(t, err)
+	assert.False(t, inInterval)
+	assert.Equal(t, interval+5, d.PollingInterval)
+
+	updated, err := d.SetStatus(db.DefaultContext, auth_model.OAuth2DeviceAuthorizationApproved, 2)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2DeviceAuthorization{ID: d.ID, UserID: 2, Status: auth_model.OAuth2DeviceAuthorizationApproved})
+	updated, err = d.SetStatus(db.DefaultContext, auth_model.OAuth2DeviceAuthorizationDenied, 2)
+	require.NoError(t, err)
+	assert.False(t, updated)
+	found, err = auth_model.GetPendingOAuth2DeviceAuthorizationByUserCode(db.DefaultContext, d.UserCode)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	consumed, err := d.Consume(db.DefaultContext)
+	require.NoError(t, err)
+	assert.True(t, consumed)
+	consumed, err = d.Consume(db.DefaultContext)
+	require.NoError(t, err)
+	assert.False(t, consumed)
+}
+
+func TestOAuth2DeviceAuthorization_Expired(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	app := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 2})
+
+	expired, err := auth_model.CreateOAuth2DeviceAuthorization(db.DefaultContext, app, "")
+	require.NoError(t, err)
+	_, err = db.GetEngine(db.DefaultContext).ID(expired.ID).Cols("expires_unix").
+		Update(&auth_model.OAuth2DeviceAuthorization{ExpiresUnix: timeutil.TimeStampNow() - 1})
+	require.NoError(t, err)
+
+	found, err := auth_model.GetPendingOAuth2DeviceAuthorizationByUserCode(db.DefaultContext, expired.UserCode)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	// the expired requests are deleted when new ones are created
+	_, err = auth_model.CreateOAuth2DeviceAuthorization(db.DefaultContext, app, "")
+	require.NoError(t, err)
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2DeviceAuthorization{ID: expired.ID})
+}
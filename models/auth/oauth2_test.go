@@ -162,6 +162,36 @@ func TestOAuth2Grant_ScopeContains(t *testing.T) {
 	assert.False(t, grant.ScopeContains("profile2"))
 }
 
+func TestOAuth2Grant_AccessTokenScope(t *testing.T) {
+	for scope, expected := range map[string]auth_model.AccessTokenScope{
+		"":                                     auth_model.AccessTokenScopeAll,
+		"openid profile":                       auth_model.AccessTokenScopeAll,
+		"openid read:repository":               "read:repository",
+		"write:issue read:issue read:user":     "write:issue,read:user",
+		"public-only read:repository whatever": "public-only,read:repository",
+		"all":                                  auth_model.AccessTokenScopeAll,
+	} {
+		grant := &auth_model.OAuth2Grant{Scope: scope}
+		assert.Equal(t, expected, grant.AccessTokenScope(), scope)
+	}
+}
+
+func TestOAuth2Grant_MergedScope(t *testing.T) {
+	for _, c := range []struct {
+		grant, scope, expected string
+	}{
+		{"", "", ""},
+		{"openid", "openid profile", "openid profile"},
+		{"openid read:repository", "read:issue", "openid read:repository read:issue"},
+		{"read:repository", "read:repository", "read:repository"},
+		{"openid", "read:repository", "openid"},
+		{"openid read:repository", "profile", "openid profile"},
+	} {
+		grant := &auth_model.OAuth2Grant{Scope: c.grant}
+		assert.Equal(t, c.expected, grant.MergedScope(c.scope), "%q + %q", c.grant, c.scope)
+	}
+}
+
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	grant := unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{ID: 1})
@@ -0,0 +1,209 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrSCIMGroupNotExist represents a "SCIMGroupNotExist" kind of error.
+type ErrSCIMGroupNotExist struct {
+	ID int64
+}
+
+// IsErrSCIMGroupNotExist checks if an error is a ErrSCIMGroupNotExist.
+func IsErrSCIMGroupNotExist(err error) bool {
+	_, ok := err.(ErrSCIMGroupNotExist)
+	return ok
+}
+
+func (err ErrSCIMGroupNotExist) Error() string {
+	return fmt.Sprintf("SCIM group does not exist [id: %d]", err.ID)
+}
+
+func (err ErrSCIMGroupNotExist) Unwrap() error {
+	return util.ErrNotExist
+}
+
+// ErrSCIMGroupAlreadyExist represents a "SCIMGroupAlreadyExist" kind of error.
+type ErrSCIMGroupAlreadyExist struct {
+	DisplayName string
+}
+
+// IsErrSCIMGroupAlreadyExist checks if an error is a ErrSCIMGroupAlreadyExist.
+func IsErrSCIMGroupAlreadyExist(err error) bool {
+	_, ok := err.(ErrSCIMGroupAlreadyExist)
+	return ok
+}
+
+func (err ErrSCIMGroupAlreadyExist) Error() string {
+	return fmt.Sprintf("SCIM group already exists [display_name: %s]", err.DisplayName)
+}
+
+func (err ErrSCIMGroupAlreadyExist) Unwrap() error {
+	return util.ErrAlreadyExist
+}
+
+// SCIMGroup is a group which the identity provider of an authentication source provisioned over SCIM. The display
+// name of the group is what the group team map of the source maps onto teams.
+type SCIMGroup struct {
+	ID          int64  `xorm:"pk autoincr"`
+	SourceID    int64  `xorm:"UNIQUE(s) NOT NULL"`
+	DisplayName string `xorm:"UNIQUE(s) NOT NULL"`
+	ExternalID  string `xorm:"INDEX"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name to `scim_group`
+func (g *SCIMGroup) TableName() string {
+	return "scim_group"
+}
+
+// SCIMGroupMember is a user in a SCIM group
+type SCIMGroupMember struct {
+	ID      int64 `xorm:"pk autoincr"`
+	GroupID int64 `xorm:"UNIQUE(s) NOT NULL"`
+	UserID  int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+// TableName sets the table name to `scim_group_member`
+func (m *SCIMGroupMember) TableName() string {
+	return "scim_group_member"
+}
+
+func init() {
+	db.RegisterModel(new(SCIMGroup))
+	db.RegisterModel(new(SCIMGroupMember))
+}
+
+// FindSCIMGroupsOptions represents the options to find the SCIM groups of a source
+type FindSCIMGroupsOptions struct {
+	db.ListOptions
+	SourceID int64
+	// Filter is an additional condition on the columns of the groups
+	Filter builder.Cond
+}
+
+func (opts FindSCIMGroupsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond().And(builder.Eq{"source_id": opts.SourceID})
+	if opts.Filter != nil {
+		cond = cond.And(opts.Filter)
+	}
+	return cond
+}
+
+func (opts FindSCIMGroupsOptions) ToOrders() string {
+	return "id ASC"
+}
+
+// CreateSCIMGroup creates a SCIM group, whose display name must be unique in its source
+func CreateSCIMGroup(ctx context.Context, g *SCIMGroup) error {
+	has, err := db.GetEngine(ctx).Exist(&SCIMGroup{SourceID: g.SourceID, DisplayName: g.DisplayName})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrSCIMGroupAlreadyExist{DisplayName: g.DisplayName}
+	}
+	return db.Insert(ctx, g)
+}
+
+// GetSCIMGroupByID returns the SCIM group of the source with the given ID
+func GetSCIMGroupByID(ctx context.Context, sourceID, id int64) (*SCIMGroup, error) {
+	g := &SCIMGroup{}
+	has, err := db.GetEngine(ctx).Where("id = ? AND source_id = ?", id, sourceID).Get(g)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSCIMGroupNotExist{ID: id}
+	}
+	return g, nil
+}
+
+// UpdateSCIMGroup updates the display name and the external ID of a SCIM group
+func UpdateSCIMGroup(ctx context.Context, g *SCIMGroup) error {
+	has, err := db.GetEngine(ctx).Where("source_id = ? AND display_name = ? AND id <> ?", g.SourceID, g.DisplayName, g.ID).Exist(&SCIMGroup{})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrSCIMGroupAlreadyExist{DisplayName: g.DisplayName}
+	}
+	_, err = db.GetEngine(ctx).ID(g.ID).Cols("display_name", "external_id").Update(g)
+	return err
+}
+
+// DeleteSCIMGroup deletes a SCIM group and its members
+func DeleteSCIMGroup(ctx context.Context, g *SCIMGroup) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		return db.DeleteBeans(ctx, &SCIMGroupMember{GroupID: g.ID}, &SCIMGroup{ID: g.ID})
+	})
+}
+
+// GetSCIMGroupMemberIDs returns the IDs of the users in the SCIM group
+func GetSCIMGroupMemberIDs(ctx context.Context, groupID int64) ([]int64, error) {
+	userIDs := make([]int64, 0, 10)
+	return userIDs, db.GetEngine(ctx).Table("scim_group_member").Where("group_id = ?", groupID).Asc("user_id").Cols("user_id").Find(&userIDs)
+}
+
+// AddSCIMGroupMembers adds the users to the SCIM group, users who already are members are skipped
+func AddSCIMGroupMembers(ctx context.Context, groupID int64, userIDs ...int64) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		for _, userID := range userIDs {
+			has, err := db.GetEngine(ctx).Exist(&SCIMGroupMember{GroupID: groupID, UserID: userID})
+			if err != nil {
+				return err
+			} else if has {
+				continue
+			}
+			if err := db.Insert(ctx, &SCIMGroupMember{GroupID: groupID, UserID: userID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveSCIMGroupMembers removes the users from the SCIM group
+func RemoveSCIMGroupMembers(ctx context.Context, groupID int64, userIDs ...int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := db.GetEngine(ctx).Where("group_id = ?", groupID).In("user_id", userIDs).Delete(&SCIMGroupMember{})
+	return err
+}
+
+// GetSCIMGroupsByUserID returns the SCIM groups of the source which the user is a member of
+func GetSCIMGroupsByUserID(ctx context.Context, sourceID, userID int64) ([]*SCIMGroup, error) {
+	groups := make([]*SCIMGroup, 0, 10)
+	return groups, db.GetEngine(ctx).
+		Join("INNER", "scim_group_member", "scim_group_member.group_id = scim_group.id").
+		Where("scim_group.source_id = ? AND scim_group_member.user_id = ?", sourceID, userID).
+		Asc("scim_group.id").
+		Find(&groups)
+}
+
+// DeleteSCIMGroupMembershipsByUserID removes the user from all SCIM groups
+func DeleteSCIMGroupMembershipsByUserID(ctx context.Context, userID int64) error {
+	_, err := db.DeleteByBean(ctx, &SCIMGroupMember{UserID: userID})
+	return err
+}
+
+// DeleteSCIMBySourceID deletes the SCIM token and the SCIM groups of the source
+func DeleteSCIMBySourceID(ctx context.Context, sourceID int64) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		groupIDs := builder.Select("id").From("scim_group").Where(builder.Eq{"source_id": sourceID})
+		if _, err := db.GetEngine(ctx).Where(builder.In("group_id", groupIDs)).Delete(&SCIMGroupMember{}); err != nil {
+			return err
+		}
+		return db.DeleteBeans(ctx, &SCIMGroup{SourceID: sourceID}, &SCIMToken{SourceID: sourceID})
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrSCIMTokenNotExist represents a "SCIMTokenNotExist" kind of error.
+type ErrSCIMTokenNotExist struct {
+	SourceID int64
+}
+
+// IsErrSCIMTokenNotExist checks if an error is a ErrSCIMTokenNotExist.
+func IsErrSCIMTokenNotExist(err error) bool {
+	_, ok := err.(ErrSCIMTokenNotExist)
+	return ok
+}
+
+func (err ErrSCIMTokenNotExist) Error() string {
+	return fmt.Sprintf("SCIM token does not exist [source_id: %d]", err.SourceID)
+}
+
+func (err ErrSCIMTokenNotExist) Unwrap() error {
+	return util.ErrNotExist
+}
+
+// SCIMToken is the bearer token with which the identity provider of an authentication source provisions the users
+// and groups of the source over SCIM. A source has at most one token.
+type SCIMToken struct {
+	ID             int64  `xorm:"pk autoincr"`
+	SourceID       int64  `xorm:"UNIQUE"`
+	Token          string `xorm:"-"`
+	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name to `scim_token`
+func (t *SCIMToken) TableName() string {
+	return "scim_token"
+}
+
+func init() {
+	db.RegisterModel(new(SCIMToken))
+}
+
+// GenerateSCIMToken replaces the SCIM token of the source with a new one, whose value is only known until it is
+// returned
+func GenerateSCIMToken(ctx context.Context, sourceID int64) (*SCIMToken, error) {
+	salt, err := util.CryptoRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+	token, err := util.CryptoRandomBytes(20)
+	if err != nil {
+		return nil, err
+	}
+	t := &SCIMToken{
+		SourceID:  sourceID,
+		Token:     hex.EncodeToString(token),
+		TokenSalt: salt,
+	}
+	t.TokenHash = HashToken(t.Token, t.TokenSalt)
+	t.TokenLastEight = t.Token[len(t.Token)-8:]
+
+	return t, db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.DeleteByBean(ctx, &SCIMToken{SourceID: sourceID}); err != nil {
+			return err
+		}
+		return db.Insert(ctx, t)
+	})
+}
+
+// GetSCIMTokenBySourceID returns the SCIM token of the source, without its value
+func GetSCIMTokenBySourceID(ctx context.Context, sourceID int64) (*SCIMToken, error) {
+	t := &SCIMToken{}
+	has, err := db.GetEngine(ctx).Where("source_id = ?", sourceID).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSCIMTokenNotExist{SourceID: sourceID}
+	}
+	return t, nil
+}
+
+// GetSCIMTokenByToken returns the SCIM token with the given value
+func GetSCIMTokenByToken(ctx context.Context, token string) (*SCIMToken, error) {
+	if len(token) != 40 {
+		return nil, ErrSCIMTokenNotExist{}
+	}
+	for _, x := range []byte(token) {
+		if x < '0' || (x > '9' && x < 'a') || x > 'f' {
+			return nil, ErrSCIMTokenNotExist{}
+		}
+	}
+
+	var tokens []*SCIMToken
+	if err := db.GetEngine(ctx).Where("token_last_eight = ?", token[len(token)-8:]).Find(&tokens); err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(HashToken(token, t.TokenSalt))) == 1 {
+			return t, nil
+		}
+	}
+	return nil, ErrSCIMTokenNotExist{}
+}
+
+// DeleteSCIMTokenBySourceID deletes the SCIM token of the source, which disables SCIM provisioning for it
+func DeleteSCIMTokenBySourceID(ctx context.Context, sourceID int64) error {
+	_, err := db.DeleteByBean(ctx, &SCIMToken{SourceID: sourceID})
+	return err
+}
@@ -52,6 +52,7 @@ type WebAuthnCredential struct {
 	AAGUID          []byte
 	SignCount       uint32 `xorm:"BIGINT"`
 	CloneWarning    bool
+	Passkey         bool               `xorm:"NOT NULL DEFAULT false"` // discoverable and registered with user verification
 	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
 }
@@ -118,6 +119,16 @@ func ExistsWebAuthnCredentialsForUID(ctx context.Context, uid int64) (bool, erro
 	return db.GetEngine(ctx).Where("user_id = ?", uid).Exist(&WebAuthnCredential{})
 }
 
+// HasPasskeysByUID returns whether the given user has registered passkeys
+func HasPasskeysByUID(ctx context.Context, uid int64) (bool, error) {
+	return db.GetEngine(ctx).Where("user_id = ? AND passkey = ?", uid, true).Exist(&WebAuthnCredential{})
+}
+
+// CountPasskeysByUID returns the number of passkeys the given user registered
+func CountPasskeysByUID(ctx context.Context, uid int64) (int64, error) {
+	return db.GetEngine(ctx).Where("user_id = ? AND passkey = ?", uid, true).Count(&WebAuthnCredential{})
+}
+
 // GetWebAuthnCredentialByName returns WebAuthn credential by id
 func GetWebAuthnCredentialByName(ctx context.Context, uid int64, name string) (*WebAuthnCredential, error) {
 	cred := new(WebAuthnCredential)
@@ -156,8 +167,9 @@ func GetWebAuthnCredentialByCredID(ctx context.Context, userID int64, credID []b
 	return cred, nil
 }
 
-// CreateCredential will create a new WebAuthnCredential from the given Credential
-func CreateCredential(ctx context.Context, userID int64, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+// CreateCredential will create a new WebAuthnCredential from the given Credential, passkeys can be used to sign in
+// without a password
+func CreateCredential(ctx context.Context, userID int64, name string, cred *webauthn.Credential, passkey bool) (*WebAuthnCredential, error) {
 	c := &WebAuthnCredential{
 		UserID:          userID,
 		Name:            name,
@@ -167,6 +179,7 @@ func CreateCredential(ctx context.Context, userID int64, name string, cred *weba
 		AAGUID:          cred.Authenticator.AAGUID,
 		SignCount:       cred.Authenticator.SignCount,
 		CloneWarning:    false,
+		Passkey:         passkey,
 	}
 
 	if err := db.Insert(ctx, c); err != nil {
@@ -58,10 +58,30 @@ func TestWebAuthnCredential_UpdateLargeCounter(t *testing.T) {
 func TestCreateCredential(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 
-	res, err := auth_model.CreateCredential(db.DefaultContext, 1, "WebAuthn Created Credential", &webauthn.Credential{ID: []byte("Test")})
+	res, err := auth_model.CreateCredential(db.DefaultContext, 1, "WebAuthn Created Credential", &webauthn.Credential{ID: []byte("Test")}, false)
 	assert.NoError(t, err)
 	assert.Equal(t, "WebAuthn Created Credential", res.Name)
 	assert.Equal(t, []byte("Test"), res.CredentialID)
 
 	unittest.AssertExistsIf(t, true, &auth_model.WebAuthnCredential{Name: "WebAuthn Created Credential", UserID: 1})
 }
+
+func TestHasPasskeysByUID(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	has, err := auth_model.HasPasskeysByUID(db.DefaultContext, 32)
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	cred, err := auth_model.CreateCredential(db.DefaultContext, 32, "Passkey", &webauthn.Credential{ID: []byte("Passkey")}, true)
+	assert.NoError(t, err)
+	assert.True(t, cred.Passkey)
+
+	has, err = auth_model.HasPasskeysByUID(db.DefaultContext, 32)
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	count, err := auth_model.CountPasskeysByUID(db.DefaultContext, 32)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
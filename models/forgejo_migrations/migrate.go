@@ -74,6 +74,34 @@ var migrations = []*Migration{
 	NewMigration("Add `normalized_federated_uri` column to `user` table", AddNormalizedFederatedURIToUser),
 	// v18 -> v19
 	NewMigration("Create the `following_repo` table", CreateFollowingRepoTable),
+	// v19 -> v20
+	NewMigration("Add the `enable_merge_queue` column to `protected_branch` and create the `pull_merge_queue` table", AddMergeQueue),
+	// v20 -> v21
+	NewMigration("Add the `require_code_owner_approval` column to `protected_branch`", AddRequireCodeOwnerApprovalToProtectedBranch),
+	// v21 -> v22
+	NewMigration("Create the `org_ruleset` table", CreateOrgRulesetTable),
+	// v22 -> v23
+	NewMigration("Add the commit policy columns to `protected_branch`", AddCommitPolicyToProtectedBranch),
+	// v23 -> v24
+	NewMigration("Create the `repo_maintenance` table", CreateRepoMaintenanceTable),
+	// v24 -> v25
+	NewMigration("Create the `scim_token`, `scim_group` and `scim_group_member` tables", CreateSCIMTables),
+	// v25 -> v26
+	NewMigration("Add the OAuth2 device authorization and client credentials grants", AddOAuth2DeviceAndClientCredentialsGrants),
+	// v26 -> v27
+	NewMigration("Add the repository restrictions and expiry date of access tokens", AddAccessTokenResourcesAndExpiry),
+	// v27 -> v28
+	NewMigration("Add the ID token permission and the environment to the jobs of Actions runs", AddIDTokenPermissionAndEnvironmentToActionRunJob),
+	// v28 -> v29
+	NewMigration("Add the passkey flag to WebAuthn credentials", AddPasskeyToWebAuthnCredential),
+	// v29 -> v30
+	NewMigration("Create the `org_auth_policy` table", CreateOrgAuthPolicyTable),
+	// v30 -> v31
+	NewMigration("Create the `audit_event` table", CreateAuditEventTable),
+	// v31 -> v32
+	NewMigration("Create the `issue_field` and `issue_field_value` tables", CreateIssueFieldTables),
+	// v32 -> v33
+	NewMigration("Create the `sub_issue` table and add `close_with_sub_issues` to `issue`", AddSubIssues),
 }
 
 // GetCurrentDBVersion returns the current Forgejo database version.
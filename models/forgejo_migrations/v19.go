@@ -0,0 +1,37 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddMergeQueue(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		ID               int64 `xorm:"pk autoincr"`
+		EnableMergeQueue bool  `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	type PullMergeQueue struct {
+		ID           int64              `xorm:"pk autoincr"`
+		RepoID       int64              `xorm:"INDEX(queue) NOT NULL"`
+		BaseBranch   string             `xorm:"INDEX(queue) NOT NULL"`
+		PullID       int64              `xorm:"UNIQUE NOT NULL"`
+		DoerID       int64              `xorm:"INDEX NOT NULL"`
+		MergeStyle   string             `xorm:"varchar(30)"`
+		Message      string             `xorm:"LONGTEXT"`
+		HeadCommitID string             `xorm:"VARCHAR(64)"`
+		BaseCommitID string             `xorm:"VARCHAR(64)"`
+		CommitID     string             `xorm:"VARCHAR(64) INDEX"`
+		CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix  timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	if err := x.Sync(new(ProtectedBranch)); err != nil {
+		return err
+	}
+	return x.Sync(new(PullMergeQueue))
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateOrgRulesetTable(x *xorm.Engine) error {
+	type OrgRuleset struct {
+		ID                    int64  `xorm:"pk autoincr"`
+		OrgID                 int64  `xorm:"INDEX NOT NULL"`
+		Name                  string `xorm:"NOT NULL"`
+		RepoNamePattern       string
+		RepoTopic             string
+		Target                int      `xorm:"NOT NULL DEFAULT 0"`
+		RefPattern            string   `xorm:"NOT NULL"`
+		CanPush               bool     `xorm:"NOT NULL DEFAULT false"`
+		EnableWhitelist       bool     `xorm:"NOT NULL DEFAULT false"`
+		WhitelistUserIDs      []int64  `xorm:"JSON TEXT"`
+		WhitelistTeamIDs      []int64  `xorm:"JSON TEXT"`
+		RequiredApprovals     int64    `xorm:"NOT NULL DEFAULT 0"`
+		EnableStatusCheck     bool     `xorm:"NOT NULL DEFAULT false"`
+		StatusCheckContexts   []string `xorm:"JSON TEXT"`
+		RequireSignedCommits  bool     `xorm:"NOT NULL DEFAULT false"`
+		ProtectedFilePatterns string   `xorm:"TEXT"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync(new(OrgRuleset))
+}
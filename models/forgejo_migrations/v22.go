@@ -0,0 +1,16 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import "xorm.io/xorm"
+
+func AddCommitPolicyToProtectedBranch(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		RequireLinearHistory bool   `xorm:"NOT NULL DEFAULT false"`
+		RequireSignoff       bool   `xorm:"NOT NULL DEFAULT false"`
+		CommitMessagePattern string `xorm:"TEXT"`
+	}
+
+	return x.Sync(new(ProtectedBranch))
+}
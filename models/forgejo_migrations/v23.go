@@ -0,0 +1,25 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func CreateRepoMaintenanceTable(x *xorm.Engine) error {
+	type RepoMaintenance struct {
+		ID           int64              `xorm:"pk autoincr"`
+		RepoID       int64              `xorm:"UNIQUE"`
+		Pushes       int64              `xorm:"NOT NULL DEFAULT 0"`
+		LastRun      timeutil.TimeStamp `xorm:"INDEX"`
+		LastDuration time.Duration
+		LastError    string `xorm:"TEXT"`
+	}
+
+	return x.Sync(new(RepoMaintenance))
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type scimToken struct {
+	ID             int64  `xorm:"pk autoincr"`
+	SourceID       int64  `xorm:"UNIQUE"`
+	TokenHash      string `xorm:"UNIQUE"`
+	TokenSalt      string
+	TokenLastEight string             `xorm:"INDEX token_last_eight"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+}
+
+func (scimToken) TableName() string {
+	return "scim_token"
+}
+
+type scimGroup struct {
+	ID          int64              `xorm:"pk autoincr"`
+	SourceID    int64              `xorm:"UNIQUE(s) NOT NULL"`
+	DisplayName string             `xorm:"UNIQUE(s) NOT NULL"`
+	ExternalID  string             `xorm:"INDEX"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func (scimGroup) TableName() string {
+	return "scim_group"
+}
+
+type scimGroupMember struct {
+	ID      int64 `xorm:"pk autoincr"`
+	GroupID int64 `xorm:"UNIQUE(s) NOT NULL"`
+	UserID  int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+func (scimGroupMember) TableName() string {
+	return "scim_group_member"
+}
+
+func CreateSCIMTables(x *xorm.Engine) error {
+	return x.Sync(new(scimToken), new(scimGroup), new(scimGroupMember))
+}
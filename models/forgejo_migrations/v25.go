@@ -0,0 +1,42 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type oauth2Application struct {
+	ClientCredentialsUserID int64 `xorm:"NOT NULL DEFAULT 0"`
+}
+
+func (oauth2Application) TableName() string {
+	return "oauth2_application"
+}
+
+type oauth2DeviceAuthorization struct {
+	ID              int64  `xorm:"pk autoincr"`
+	ApplicationID   int64  `xorm:"INDEX"`
+	DeviceCodeHash  string `xorm:"UNIQUE"`
+	UserCode        string `xorm:"UNIQUE"`
+	Scope           string `xorm:"TEXT"`
+	Status          int    `xorm:"NOT NULL DEFAULT 0"`
+	UserID          int64
+	PollingInterval int64
+	LastPolledUnix  timeutil.TimeStamp
+	ExpiresUnix     timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix     timeutil.TimeStamp `xorm:"created"`
+}
+
+func (oauth2DeviceAuthorization) TableName() string {
+	return "oauth2_device_authorization"
+}
+
+// AddOAuth2DeviceAndClientCredentialsGrants adds the table of the device authorization requests and the user which
+// OAuth2 applications act as with the client credentials grant
+func AddOAuth2DeviceAndClientCredentialsGrants(x *xorm.Engine) error {
+	return x.Sync(new(oauth2Application), new(oauth2DeviceAuthorization))
+}
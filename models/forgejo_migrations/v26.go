@@ -0,0 +1,19 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddAccessTokenResourcesAndExpiry(x *xorm.Engine) error {
+	type AccessToken struct {
+		OrgID       int64              `xorm:"NOT NULL DEFAULT 0"`
+		RepoIDs     []int64            `xorm:"JSON TEXT"`
+		ExpiresUnix timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	}
+	return x.Sync(&AccessToken{})
+}
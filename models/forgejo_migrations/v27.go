@@ -0,0 +1,14 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import "xorm.io/xorm"
+
+func AddIDTokenPermissionAndEnvironmentToActionRunJob(x *xorm.Engine) error {
+	type ActionRunJob struct {
+		IDTokenPermission bool   `xorm:"NOT NULL DEFAULT false"`
+		Environment       string `xorm:"VARCHAR(255)"`
+	}
+	return x.Sync(&ActionRunJob{})
+}
@@ -0,0 +1,13 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import "xorm.io/xorm"
+
+func AddPasskeyToWebAuthnCredential(x *xorm.Engine) error {
+	type WebauthnCredential struct {
+		Passkey bool `xorm:"NOT NULL DEFAULT false"`
+	}
+	return x.Sync(&WebauthnCredential{})
+}
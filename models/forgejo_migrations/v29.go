@@ -0,0 +1,28 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type orgAuthPolicy struct {
+	ID                   int64              `xorm:"pk autoincr"`
+	OrgID                int64              `xorm:"UNIQUE NOT NULL"`
+	RequireTwoFactor     bool               `xorm:"NOT NULL DEFAULT false"`
+	RequiredAuthSourceID int64              `xorm:"NOT NULL DEFAULT 0"`
+	GracePeriodEndUnix   timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix          timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix          timeutil.TimeStamp `xorm:"updated"`
+}
+
+func (orgAuthPolicy) TableName() string {
+	return "org_auth_policy"
+}
+
+func CreateOrgAuthPolicyTable(x *xorm.Engine) error {
+	return x.Sync(&orgAuthPolicy{})
+}
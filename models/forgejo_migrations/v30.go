@@ -0,0 +1,36 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type auditEvent struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Action      string             `xorm:"INDEX NOT NULL"`
+	ActorID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	ActorName   string             `xorm:"NOT NULL DEFAULT ''"`
+	ActorIP     string             `xorm:"NOT NULL DEFAULT ''"`
+	OwnerID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	RepoID      int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	TargetType  string             `xorm:"NOT NULL DEFAULT ''"`
+	TargetID    int64              `xorm:"NOT NULL DEFAULT 0"`
+	TargetName  string             `xorm:"NOT NULL DEFAULT ''"`
+	Before      string             `xorm:"TEXT"`
+	After       string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL"`
+	PrevHash    string             `xorm:"VARCHAR(64) UNIQUE NOT NULL DEFAULT ''"`
+	Hash        string             `xorm:"VARCHAR(64) NOT NULL DEFAULT ''"`
+}
+
+func (auditEvent) TableName() string {
+	return "audit_event"
+}
+
+func CreateAuditEventTable(x *xorm.Engine) error {
+	return x.Sync(&auditEvent{})
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type issueField struct {
+	ID          int64 `xorm:"pk autoincr"`
+	RepoID      int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	OrgID       int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Name        string
+	Description string             `xorm:"TEXT"`
+	Type        string             `xorm:"VARCHAR(20) NOT NULL"`
+	Options     []string           `xorm:"TEXT JSON"`
+	SortOrder   int                `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func (issueField) TableName() string {
+	return "issue_field"
+}
+
+type issueFieldValue struct {
+	ID      int64  `xorm:"pk autoincr"`
+	IssueID int64  `xorm:"INDEX NOT NULL"`
+	FieldID int64  `xorm:"INDEX NOT NULL"`
+	Value   string `xorm:"VARCHAR(255) INDEX NOT NULL"`
+}
+
+func (issueFieldValue) TableName() string {
+	return "issue_field_value"
+}
+
+func CreateIssueFieldTables(x *xorm.Engine) error {
+	return x.Sync(&issueField{}, &issueFieldValue{})
+}
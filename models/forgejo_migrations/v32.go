@@ -0,0 +1,27 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package forgejo_migrations //nolint:revive
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddSubIssues(x *xorm.Engine) error {
+	type SubIssue struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"NOT NULL"`
+		ParentID    int64              `xorm:"INDEX NOT NULL"`
+		IssueID     int64              `xorm:"UNIQUE NOT NULL"`
+		Sorting     int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type Issue struct {
+		CloseWithSubIssues bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	return x.Sync(new(SubIssue), new(Issue))
+}
@@ -0,0 +1,333 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+// OrgRulesetTarget is the kind of refs an organization ruleset protects
+type OrgRulesetTarget int
+
+const (
+	// OrgRulesetTargetBranch protects branches
+	OrgRulesetTargetBranch OrgRulesetTarget = iota
+	// OrgRulesetTargetTag protects tags
+	OrgRulesetTargetTag
+)
+
+// String returns the name of the target as used in the API
+func (target OrgRulesetTarget) String() string {
+	if target == OrgRulesetTargetTag {
+		return "tag"
+	}
+	return "branch"
+}
+
+// ParseOrgRulesetTarget returns the target of the given name, it defaults to branches
+func ParseOrgRulesetTarget(name string) (OrgRulesetTarget, error) {
+	switch name {
+	case "", "branch":
+		return OrgRulesetTargetBranch, nil
+	case "tag":
+		return OrgRulesetTargetTag, nil
+	}
+	return OrgRulesetTargetBranch, util.NewInvalidArgumentErrorf("invalid ruleset target %q", name)
+}
+
+// OrgRuleset is a set of branch or tag protection settings an organization applies to the matching refs of its
+// matching repositories, on top of the rules of the repositories themselves.
+type OrgRuleset struct {
+	ID                    int64            `xorm:"pk autoincr"`
+	OrgID                 int64            `xorm:"INDEX NOT NULL"`
+	Name                  string           `xorm:"NOT NULL"`
+	RepoNamePattern       string           // a glob matching the names of the repositories, all repositories if empty
+	RepoTopic             string           // a topic the repositories must have, any if empty
+	Target                OrgRulesetTarget `xorm:"NOT NULL DEFAULT 0"`
+	RefPattern            string           `xorm:"NOT NULL"` // a glob matching the names of the branches or tags
+	CanPush               bool             `xorm:"NOT NULL DEFAULT false"`
+	EnableWhitelist       bool             `xorm:"NOT NULL DEFAULT false"`
+	WhitelistUserIDs      []int64          `xorm:"JSON TEXT"`
+	WhitelistTeamIDs      []int64          `xorm:"JSON TEXT"`
+	RequiredApprovals     int64            `xorm:"NOT NULL DEFAULT 0"`
+	EnableStatusCheck     bool             `xorm:"NOT NULL DEFAULT false"`
+	StatusCheckContexts   []string         `xorm:"JSON TEXT"`
+	RequireSignedCommits  bool             `xorm:"NOT NULL DEFAULT false"`
+	ProtectedFilePatterns string           `xorm:"TEXT"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(OrgRuleset))
+}
+
+func compileRulesetGlob(pattern string) (glob.Glob, error) {
+	return glob.Compile(strings.ToLower(pattern), '/')
+}
+
+// Validate checks that the patterns and settings of the ruleset are valid
+func (ruleset *OrgRuleset) Validate() error {
+	if ruleset.RefPattern == "" {
+		return util.NewInvalidArgumentErrorf("ref pattern of ruleset must not be empty")
+	}
+	if _, err := compileRulesetGlob(ruleset.RefPattern); err != nil {
+		return util.NewInvalidArgumentErrorf("invalid ref pattern %q: %v", ruleset.RefPattern, err)
+	}
+	if ruleset.RepoNamePattern != "" {
+		if _, err := compileRulesetGlob(ruleset.RepoNamePattern); err != nil {
+			return util.NewInvalidArgumentErrorf("invalid repository name pattern %q: %v", ruleset.RepoNamePattern, err)
+		}
+	}
+	for _, statusContext := range ruleset.StatusCheckContexts {
+		if _, err := glob.Compile(statusContext); err != nil {
+			return util.NewInvalidArgumentErrorf("invalid status check pattern %q: %v", statusContext, err)
+		}
+	}
+	if ruleset.EnableStatusCheck && len(ruleset.StatusCheckContexts) == 0 {
+		return util.NewInvalidArgumentErrorf("status check patterns of ruleset must not be empty")
+	}
+	if ruleset.RequiredApprovals < 0 {
+		return util.NewInvalidArgumentErrorf("required approvals of ruleset must not be negative")
+	}
+	return nil
+}
+
+func matchRulesetGlob(pattern, name string) bool {
+	g, err := compileRulesetGlob(pattern)
+	if err != nil {
+		log.Warn("Invalid glob pattern in organization ruleset: %s %v", pattern, err)
+		return strings.EqualFold(pattern, name)
+	}
+	return g.Match(strings.ToLower(name))
+}
+
+// MatchRepo returns true if the ruleset applies to the repository
+func (ruleset *OrgRuleset) MatchRepo(repo *repo_model.Repository) bool {
+	if repo.OwnerID != ruleset.OrgID {
+		return false
+	}
+	if ruleset.RepoNamePattern != "" && !matchRulesetGlob(ruleset.RepoNamePattern, repo.Name) {
+		return false
+	}
+	if ruleset.RepoTopic != "" && !slices.Contains(repo.Topics, strings.ToLower(ruleset.RepoTopic)) {
+		return false
+	}
+	return true
+}
+
+// MatchRef returns true if the ruleset applies to the branch or tag name
+func (ruleset *OrgRuleset) MatchRef(name string) bool {
+	return matchRulesetGlob(ruleset.RefPattern, name)
+}
+
+// IsUserAllowedToPush returns true if the ruleset allows the user to push to the refs it protects
+func (ruleset *OrgRuleset) IsUserAllowedToPush(ctx context.Context, userID int64) (bool, error) {
+	if !ruleset.CanPush {
+		return false, nil
+	}
+	if !ruleset.EnableWhitelist || slices.Contains(ruleset.WhitelistUserIDs, userID) {
+		return true, nil
+	}
+	if len(ruleset.WhitelistTeamIDs) == 0 {
+		return false, nil
+	}
+	return organization.IsUserInTeams(ctx, userID, ruleset.WhitelistTeamIDs)
+}
+
+// GetOrgRulesets returns the rulesets of an organization
+func GetOrgRulesets(ctx context.Context, orgID int64) ([]*OrgRuleset, error) {
+	rulesets := make([]*OrgRuleset, 0, 10)
+	return rulesets, db.GetEngine(ctx).Where("org_id = ?", orgID).Asc("id").Find(&rulesets)
+}
+
+// GetOrgRulesetByID returns the ruleset of an organization by its ID, nil if it does not exist
+func GetOrgRulesetByID(ctx context.Context, orgID, id int64) (*OrgRuleset, error) {
+	ruleset, exist, err := db.Get[OrgRuleset](ctx, builder.Eq{"org_id": orgID, "id": id})
+	if err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, nil
+	}
+	return ruleset, nil
+}
+
+// UpdateOrgRuleset saves a ruleset of an organization. If its ID is 0, it creates a new record.
+// Whitelisted teams which are not teams of the organization are dropped.
+func UpdateOrgRuleset(ctx context.Context, ruleset *OrgRuleset) error {
+	if err := ruleset.Validate(); err != nil {
+		return err
+	}
+
+	if len(ruleset.WhitelistTeamIDs) > 0 {
+		teamIDs := make([]int64, 0, len(ruleset.WhitelistTeamIDs))
+		if err := db.GetEngine(ctx).Table("team").Cols("id").
+			Where("org_id = ?", ruleset.OrgID).In("id", ruleset.WhitelistTeamIDs).
+			Find(&teamIDs); err != nil {
+			return fmt.Errorf("find teams: %w", err)
+		}
+		ruleset.WhitelistTeamIDs = teamIDs
+	}
+
+	if ruleset.ID == 0 {
+		_, err := db.GetEngine(ctx).Insert(ruleset)
+		return err
+	}
+	_, err := db.GetEngine(ctx).ID(ruleset.ID).AllCols().Update(ruleset)
+	return err
+}
+
+// DeleteOrgRuleset deletes a ruleset of an organization
+func DeleteOrgRuleset(ctx context.Context, orgID, id int64) error {
+	_, err := db.GetEngine(ctx).Where("org_id = ?", orgID).And("id = ?", id).Delete(new(OrgRuleset))
+	return err
+}
+
+// FindRepoOrgRulesets returns the rulesets of the owner of a repository which apply to it
+func FindRepoOrgRulesets(ctx context.Context, repo *repo_model.Repository, target OrgRulesetTarget) ([]*OrgRuleset, error) {
+	rulesets := make([]*OrgRuleset, 0, 10)
+	if err := db.GetEngine(ctx).Where("org_id = ?", repo.OwnerID).And("target = ?", target).Asc("id").Find(&rulesets); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(rulesets, func(ruleset *OrgRuleset) bool {
+		return !ruleset.MatchRepo(repo)
+	}), nil
+}
+
+// FindRefOrgRulesets returns the rulesets of the owner of a repository which apply to a branch or tag of it
+func FindRefOrgRulesets(ctx context.Context, repoID int64, target OrgRulesetTarget, refName string) ([]*OrgRuleset, error) {
+	// most repositories are not owned by an organization with rulesets, avoid loading the repository for them
+	has, err := db.GetEngine(ctx).Where("target = ?", target).
+		And(builder.In("org_id", builder.Select("owner_id").From("repository").Where(builder.Eq{"id": repoID}))).
+		Exist(new(OrgRuleset))
+	if err != nil || !has {
+		return nil, err
+	}
+
+	repo, err := repo_model.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	rulesets, err := FindRepoOrgRulesets(ctx, repo, target)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(rulesets, func(ruleset *OrgRuleset) bool {
+		return !ruleset.MatchRef(refName)
+	}), nil
+}
+
+// applyOrgRulesets returns the protection of a branch combining the rule of the repository, which may be nil, with
+// the organization rulesets applying to the branch. The most restrictive setting wins.
+func applyOrgRulesets(rule *ProtectedBranch, repoID int64, rulesets []*OrgRuleset) *ProtectedBranch {
+	if len(rulesets) == 0 {
+		return rule
+	}
+
+	var effective ProtectedBranch
+	if rule != nil {
+		effective = *rule
+		effective.StatusCheckContexts = slices.Clone(rule.StatusCheckContexts)
+	} else {
+		effective = ProtectedBranch{
+			RepoID:   repoID,
+			RuleName: rulesets[0].RefPattern,
+			CanPush:  true,
+		}
+	}
+
+	// the repository administrators must not be able to bypass the rulesets with the rule of the repository
+	effective.ApplyToAdmins = true
+
+	protectedFilePatterns := []string{effective.ProtectedFilePatterns}
+	for _, ruleset := range rulesets {
+		// pushes only touching the unprotected files of the rule of the repository are still restricted by the ruleset
+		if !ruleset.CanPush || ruleset.EnableWhitelist {
+			effective.UnprotectedFilePatterns = ""
+		}
+		effective.CanPush = effective.CanPush && ruleset.CanPush
+		effective.RequiredApprovals = max(effective.RequiredApprovals, ruleset.RequiredApprovals)
+		effective.RequireSignedCommits = effective.RequireSignedCommits || ruleset.RequireSignedCommits
+		if ruleset.EnableStatusCheck {
+			effective.EnableStatusCheck = true
+			for _, statusContext := range ruleset.StatusCheckContexts {
+				if !slices.Contains(effective.StatusCheckContexts, statusContext) {
+					effective.StatusCheckContexts = append(effective.StatusCheckContexts, statusContext)
+				}
+			}
+		}
+		protectedFilePatterns = append(protectedFilePatterns, ruleset.ProtectedFilePatterns)
+	}
+	effective.ProtectedFilePatterns = strings.Join(slices.DeleteFunc(protectedFilePatterns, func(patterns string) bool {
+		return strings.TrimSpace(patterns) == ""
+	}), ";")
+	effective.OrgRulesets = rulesets
+	return &effective
+}
+
+// IsUserAllowedToControlTagByOrgRulesets checks if a user can control a tag of a repository according to the rulesets
+// of its owner. It returns true if no ruleset protects the tag or all of the rulesets protecting it allow the user.
+func IsUserAllowedToControlTagByOrgRulesets(ctx context.Context, repoID int64, tagName string, userID int64) (bool, error) {
+	rulesets, err := FindRefOrgRulesets(ctx, repoID, OrgRulesetTargetTag, tagName)
+	if err != nil {
+		return false, err
+	}
+	for _, ruleset := range rulesets {
+		allowed, err := ruleset.IsUserAllowedToPush(ctx, userID)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// RemoveUserIDFromOrgRulesets removes a user from the whitelists of all rulesets
+func RemoveUserIDFromOrgRulesets(ctx context.Context, userID int64) error {
+	rulesets := make([]*OrgRuleset, 0, 10)
+	if err := db.GetEngine(ctx).Find(&rulesets); err != nil {
+		return err
+	}
+	for _, ruleset := range rulesets {
+		if !slices.Contains(ruleset.WhitelistUserIDs, userID) {
+			continue
+		}
+		ruleset.WhitelistUserIDs = util.SliceRemoveAll(ruleset.WhitelistUserIDs, userID)
+		if _, err := db.GetEngine(ctx).ID(ruleset.ID).Cols("whitelist_user_i_ds").Update(ruleset); err != nil {
+			return fmt.Errorf("update ruleset: %w", err)
+		}
+	}
+	return nil
+}
+
+// RemoveTeamIDFromOrgRulesets removes a team from the whitelists of the rulesets of its organization
+func RemoveTeamIDFromOrgRulesets(ctx context.Context, orgID, teamID int64) error {
+	rulesets, err := GetOrgRulesets(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	for _, ruleset := range rulesets {
+		if !slices.Contains(ruleset.WhitelistTeamIDs, teamID) {
+			continue
+		}
+		ruleset.WhitelistTeamIDs = util.SliceRemoveAll(ruleset.WhitelistTeamIDs, teamID)
+		if _, err := db.GetEngine(ctx).ID(ruleset.ID).Cols("whitelist_team_i_ds").Update(ruleset); err != nil {
+			return fmt.Errorf("update ruleset: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgRulesetMatch(t *testing.T) {
+	ruleset := &git_model.OrgRuleset{
+		OrgID:           3,
+		RepoNamePattern: "service-*",
+		RepoTopic:       "Go",
+		RefPattern:      "release/*",
+	}
+
+	assert.True(t, ruleset.MatchRepo(&repo_model.Repository{OwnerID: 3, Name: "Service-A", Topics: []string{"go"}}))
+	assert.False(t, ruleset.MatchRepo(&repo_model.Repository{OwnerID: 3, Name: "service-a", Topics: []string{"rust"}}))
+	assert.False(t, ruleset.MatchRepo(&repo_model.Repository{OwnerID: 3, Name: "website", Topics: []string{"go"}}))
+	assert.False(t, ruleset.MatchRepo(&repo_model.Repository{OwnerID: 6, Name: "service-a", Topics: []string{"go"}}))
+
+	assert.True(t, ruleset.MatchRef("release/v1.0"))
+	assert.False(t, ruleset.MatchRef("release/v1.0/hotfix"))
+	assert.False(t, ruleset.MatchRef("main"))
+
+	assert.NoError(t, ruleset.Validate())
+	assert.Error(t, (&git_model.OrgRuleset{}).Validate())
+	assert.Error(t, (&git_model.OrgRuleset{RefPattern: "main", EnableStatusCheck: true}).Validate())
+	assert.Error(t, (&git_model.OrgRuleset{RefPattern: "[main"}).Validate())
+}
+
+func TestGetFirstMatchProtectedBranchRuleWithOrgRulesets(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+
+	ruleset := &git_model.OrgRuleset{
+		OrgID:                 3,
+		Name:                  "main branches",
+		RepoNamePattern:       "repo3",
+		RefPattern:            "main",
+		CanPush:               true,
+		EnableWhitelist:       true,
+		WhitelistUserIDs:      []int64{2},
+		RequiredApprovals:     2,
+		EnableStatusCheck:     true,
+		StatusCheckContexts:   []string{"ci/*"},
+		RequireSignedCommits:  true,
+		ProtectedFilePatterns: "go.mod",
+	}
+	require.NoError(t, git_model.UpdateOrgRuleset(ctx, ruleset))
+
+	// the ruleset alone protects the branch
+	rule, err := git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "main")
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.EqualValues(t, 2, rule.RequiredApprovals)
+	assert.True(t, rule.RequireSignedCommits)
+	assert.Equal(t, []string{"ci/*"}, rule.StatusCheckContexts)
+	assert.Len(t, rule.OrgRulesets, 1)
+
+	// other branches and repositories are not protected
+	rule, err = git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "develop")
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+	rule, err = git_model.GetFirstMatchProtectedBranchRule(ctx, 5, "main")
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+
+	// the most restrictive settings of the rule of the repository and the ruleset win
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 3})
+	require.NoError(t, git_model.UpdateProtectBranch(ctx, repo, &git_model.ProtectedBranch{
+		RepoID:                repo.ID,
+		RuleName:              "main",
+		CanPush:               true,
+		RequiredApprovals:     3,
+		EnableStatusCheck:     true,
+		StatusCheckContexts:   []string{"lint"},
+		ProtectedFilePatterns: "*.md",
+	}, git_model.WhitelistOptions{}))
+
+	rule, err = git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "main")
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.EqualValues(t, 3, rule.RequiredApprovals)
+	assert.True(t, rule.RequireSignedCommits)
+	assert.Equal(t, []string{"lint", "ci/*"}, rule.StatusCheckContexts)
+	assert.Equal(t, "*.md;go.mod", rule.ProtectedFilePatterns)
+
+	// the rule of the repository is left untouched
+	rules, err := git_model.FindRepoProtectedBranchRules(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lint"}, rules.GetFirstMatched("main").StatusCheckContexts)
+
+	user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	user4 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+	assert.True(t, rule.CanUserPush(ctx, user2))
+	assert.False(t, rule.CanUserPush(ctx, user4))
+}
+
+func TestOrgRulesetsCannotBeBypassedByRepoRule(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+
+	ruleset := &git_model.OrgRuleset{
+		OrgID:             3,
+		Name:              "main branches",
+		RepoNamePattern:   "repo3",
+		RefPattern:        "main",
+		RequiredApprovals: 1,
+	}
+	require.NoError(t, git_model.UpdateOrgRuleset(ctx, ruleset))
+
+	// without a rule of the repository, the ruleset applies to the administrators of the repository
+	rule, err := git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "main")
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.True(t, rule.ApplyToAdmins)
+
+	// the rule of the repository can neither exempt its administrators nor unprotect files
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 3})
+	require.NoError(t, git_model.UpdateProtectBranch(ctx, repo, &git_model.ProtectedBranch{
+		RepoID:                  repo.ID,
+		RuleName:                "main",
+		CanPush:                 true,
+		ApplyToAdmins:           false,
+		UnprotectedFilePatterns: "**",
+	}, git_model.WhitelistOptions{}))
+
+	rule, err = git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "main")
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.False(t, rule.CanPush)
+	assert.True(t, rule.ApplyToAdmins)
+	assert.Empty(t, rule.UnprotectedFilePatterns)
+	assert.Empty(t, rule.GetUnprotectedFilePatterns())
+
+	// the unprotected files are kept if the ruleset does not restrict pushes
+	ruleset.CanPush = true
+	require.NoError(t, git_model.UpdateOrgRuleset(ctx, ruleset))
+	rule, err = git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "main")
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	assert.True(t, rule.CanPush)
+	assert.True(t, rule.ApplyToAdmins)
+	assert.Equal(t, "**", rule.UnprotectedFilePatterns)
+}
+
+func TestIsUserAllowedToControlTagByOrgRulesets(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+
+	require.NoError(t, git_model.UpdateOrgRuleset(ctx, &git_model.OrgRuleset{
+		OrgID:            3,
+		Name:             "releases",
+		Target:           git_model.OrgRulesetTargetTag,
+		RefPattern:       "v*",
+		CanPush:          true,
+		EnableWhitelist:  true,
+		WhitelistTeamIDs: []int64{2, 3}, // team 3 belongs to another organization and is dropped
+	}))
+
+	rulesets, err := git_model.GetOrgRulesets(ctx, 3)
+	require.NoError(t, err)
+	require.Len(t, rulesets, 1)
+	assert.Equal(t, []int64{2}, rulesets[0].WhitelistTeamIDs)
+
+	allowed, err := git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, 3, "v1.0", 4)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, 3, "v1.0", 5)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	allowed, err = git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, 3, "nightly", 5)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// branches are not affected by tag rulesets
+	rule, err := git_model.GetFirstMatchProtectedBranchRule(ctx, 3, "v1.0")
+	require.NoError(t, err)
+	assert.Nil(t, rule)
+}
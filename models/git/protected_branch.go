@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 
@@ -38,27 +39,33 @@ type ProtectedBranch struct {
 	isPlainName                   bool                   `xorm:"-"`
 	CanPush                       bool                   `xorm:"NOT NULL DEFAULT false"`
 	EnableWhitelist               bool
-	WhitelistUserIDs              []int64  `xorm:"JSON TEXT"`
-	WhitelistTeamIDs              []int64  `xorm:"JSON TEXT"`
-	EnableMergeWhitelist          bool     `xorm:"NOT NULL DEFAULT false"`
-	WhitelistDeployKeys           bool     `xorm:"NOT NULL DEFAULT false"`
-	MergeWhitelistUserIDs         []int64  `xorm:"JSON TEXT"`
-	MergeWhitelistTeamIDs         []int64  `xorm:"JSON TEXT"`
-	EnableStatusCheck             bool     `xorm:"NOT NULL DEFAULT false"`
-	StatusCheckContexts           []string `xorm:"JSON TEXT"`
-	EnableApprovalsWhitelist      bool     `xorm:"NOT NULL DEFAULT false"`
-	ApprovalsWhitelistUserIDs     []int64  `xorm:"JSON TEXT"`
-	ApprovalsWhitelistTeamIDs     []int64  `xorm:"JSON TEXT"`
-	RequiredApprovals             int64    `xorm:"NOT NULL DEFAULT 0"`
-	BlockOnRejectedReviews        bool     `xorm:"NOT NULL DEFAULT false"`
-	BlockOnOfficialReviewRequests bool     `xorm:"NOT NULL DEFAULT false"`
-	BlockOnOutdatedBranch         bool     `xorm:"NOT NULL DEFAULT false"`
-	DismissStaleApprovals         bool     `xorm:"NOT NULL DEFAULT false"`
-	IgnoreStaleApprovals          bool     `xorm:"NOT NULL DEFAULT false"`
-	RequireSignedCommits          bool     `xorm:"NOT NULL DEFAULT false"`
-	ProtectedFilePatterns         string   `xorm:"TEXT"`
-	UnprotectedFilePatterns       string   `xorm:"TEXT"`
-	ApplyToAdmins                 bool     `xorm:"NOT NULL DEFAULT false"`
+	WhitelistUserIDs              []int64       `xorm:"JSON TEXT"`
+	WhitelistTeamIDs              []int64       `xorm:"JSON TEXT"`
+	EnableMergeWhitelist          bool          `xorm:"NOT NULL DEFAULT false"`
+	WhitelistDeployKeys           bool          `xorm:"NOT NULL DEFAULT false"`
+	MergeWhitelistUserIDs         []int64       `xorm:"JSON TEXT"`
+	MergeWhitelistTeamIDs         []int64       `xorm:"JSON TEXT"`
+	EnableStatusCheck             bool          `xorm:"NOT NULL DEFAULT false"`
+	StatusCheckContexts           []string      `xorm:"JSON TEXT"`
+	EnableApprovalsWhitelist      bool          `xorm:"NOT NULL DEFAULT false"`
+	ApprovalsWhitelistUserIDs     []int64       `xorm:"JSON TEXT"`
+	ApprovalsWhitelistTeamIDs     []int64       `xorm:"JSON TEXT"`
+	RequiredApprovals             int64         `xorm:"NOT NULL DEFAULT 0"`
+	BlockOnRejectedReviews        bool          `xorm:"NOT NULL DEFAULT false"`
+	BlockOnOfficialReviewRequests bool          `xorm:"NOT NULL DEFAULT false"`
+	BlockOnOutdatedBranch         bool          `xorm:"NOT NULL DEFAULT false"`
+	DismissStaleApprovals         bool          `xorm:"NOT NULL DEFAULT false"`
+	IgnoreStaleApprovals          bool          `xorm:"NOT NULL DEFAULT false"`
+	RequireSignedCommits          bool          `xorm:"NOT NULL DEFAULT false"`
+	ProtectedFilePatterns         string        `xorm:"TEXT"`
+	UnprotectedFilePatterns       string        `xorm:"TEXT"`
+	ApplyToAdmins                 bool          `xorm:"NOT NULL DEFAULT false"`
+	EnableMergeQueue              bool          `xorm:"NOT NULL DEFAULT false"`
+	RequireCodeOwnerApproval      bool          `xorm:"NOT NULL DEFAULT false"`
+	RequireLinearHistory          bool          `xorm:"NOT NULL DEFAULT false"`
+	RequireSignoff                bool          `xorm:"NOT NULL DEFAULT false"`
+	CommitMessagePattern          string        `xorm:"TEXT"`
+	OrgRulesets                   []*OrgRuleset `xorm:"-"` // the organization rulesets applied on top of the rule
 
 	CreatedUnix timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
@@ -114,6 +121,17 @@ func (protectBranch *ProtectedBranch) CanUserPush(ctx context.Context, user *use
 		return false
 	}
 
+	for _, ruleset := range protectBranch.OrgRulesets {
+		allowed, err := ruleset.IsUserAllowedToPush(ctx, user.ID)
+		if err != nil {
+			log.Error("IsUserAllowedToPush: %v", err)
+			return false
+		}
+		if !allowed {
+			return false
+		}
+	}
+
 	if !protectBranch.EnableWhitelist {
 		if err := protectBranch.LoadRepo(ctx); err != nil {
 			log.Error("LoadRepo: %v", err)
@@ -144,6 +162,20 @@ func (protectBranch *ProtectedBranch) CanUserPush(ctx context.Context, user *use
 	return in
 }
 
+// CanDeployKeyPush returns if deploy keys could push to this protected branch
+func (protectBranch *ProtectedBranch) CanDeployKeyPush() bool {
+	if !protectBranch.CanPush || (protectBranch.EnableWhitelist && !protectBranch.WhitelistDeployKeys) {
+		return false
+	}
+	// deploy keys can not be whitelisted by organization rulesets
+	for _, ruleset := range protectBranch.OrgRulesets {
+		if ruleset.EnableWhitelist {
+			return false
+		}
+	}
+	return true
+}
+
 // IsUserMergeWhitelisted checks if some user is whitelisted to merge to this branch
 func IsUserMergeWhitelisted(ctx context.Context, protectBranch *ProtectedBranch, userID int64, permissionInRepo access_model.Permission) bool {
 	if !protectBranch.EnableMergeWhitelist {
@@ -200,6 +232,15 @@ func (protectBranch *ProtectedBranch) GetProtectedFilePatterns() []glob.Glob {
 	return getFilePatterns(protectBranch.ProtectedFilePatterns)
 }
 
+// GetCommitMessageRegexp returns the compiled pattern the messages of the commits pushed to the branch must match,
+// nil if there is none
+func (protectBranch *ProtectedBranch) GetCommitMessageRegexp() (*regexp.Regexp, error) {
+	if protectBranch.CommitMessagePattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(protectBranch.CommitMessagePattern)
+}
+
 // GetUnprotectedFilePatterns parses a semicolon separated list of unprotected file patterns and returns a glob.Glob slice
 func (protectBranch *ProtectedBranch) GetUnprotectedFilePatterns() []glob.Glob {
 	return getFilePatterns(protectBranch.UnprotectedFilePatterns)
@@ -76,13 +76,18 @@ func FindAllMatchedBranches(ctx context.Context, repoID int64, ruleName string)
 	return results, nil
 }
 
-// GetFirstMatchProtectedBranchRule returns the first matched rules
+// GetFirstMatchProtectedBranchRule returns the first matched rules, combined with the organization rulesets applying
+// to the branch
 func GetFirstMatchProtectedBranchRule(ctx context.Context, repoID int64, branchName string) (*ProtectedBranch, error) {
 	rules, err := FindRepoProtectedBranchRules(ctx, repoID)
 	if err != nil {
 		return nil, err
 	}
-	return rules.GetFirstMatched(branchName), nil
+	rulesets, err := FindRefOrgRulesets(ctx, repoID, OrgRulesetTargetBranch, branchName)
+	if err != nil {
+		return nil, err
+	}
+	return applyOrgRulesets(rules.GetFirstMatched(branchName), repoID, rulesets), nil
 }
 
 // IsBranchProtected checks if branch is protected
@@ -148,3 +148,17 @@ func IsUserAllowedToControlTag(ctx context.Context, tags []*ProtectedTag, tagNam
 
 	return isAllowed, nil
 }
+
+// GetMatchingProtectedTags returns the rules protecting the given tag name
+func GetMatchingProtectedTags(tags []*ProtectedTag, tagName string) ([]*ProtectedTag, error) {
+	matched := make([]*ProtectedTag, 0, len(tags))
+	for _, tag := range tags {
+		if err := tag.EnsureCompiledPattern(); err != nil {
+			return nil, err
+		}
+		if tag.matchString(tagName) {
+			matched = append(matched, tag)
+		}
+	}
+	return matched, nil
+}
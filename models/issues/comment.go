@@ -114,6 +114,14 @@ const (
 
 	CommentTypePin   // 36 pin Issue
 	CommentTypeUnpin // 37 unpin Issue
+
+	CommentTypePRAddedToMergeQueue     // 38 pr was added to the merge queue of its base branch
+	CommentTypePRRemovedFromMergeQueue // 39 pr was removed from the merge queue of its base branch
+
+	CommentTypeAddSubIssue       // 40 Sub-issue added, on the parent issue
+	CommentTypeRemoveSubIssue    // 41 Sub-issue removed, on the parent issue
+	CommentTypeAddParentIssue    // 42 Parent issue added, on the sub-issue
+	CommentTypeRemoveParentIssue // 43 Parent issue removed, on the sub-issue
 )
 
 var commentStrings = []string{
@@ -155,6 +163,12 @@ var commentStrings = []string{
 	"pull_cancel_scheduled_merge",
 	"pin",
 	"unpin",
+	"pull_added_to_merge_queue",
+	"pull_removed_from_merge_queue",
+	"add_sub_issue",
+	"remove_sub_issue",
+	"add_parent_issue",
+	"remove_parent_issue",
 }
 
 func (t CommentType) String() string {
@@ -271,6 +285,9 @@ type Comment struct {
 	Patch       string `xorm:"-"`
 	PatchQuoted string `xorm:"LONGTEXT patch"`
 
+	// Suggestion is the change proposed by a code comment, it is rendered separately from its content
+	Suggestion *CodeSuggestion `xorm:"-"`
+
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
 
@@ -1231,6 +1248,29 @@ func CreateAutoMergeComment(ctx context.Context, typ CommentType, pr *PullReques
 	return comment, err
 }
 
+// CreateMergeQueueComment is a internal function, only use it for CommentTypePRAddedToMergeQueue and CommentTypePRRemovedFromMergeQueue CommentTypes
+func CreateMergeQueueComment(ctx context.Context, typ CommentType, pr *PullRequest, doer *user_model.User, reason string) (comment *Comment, err error) {
+	if typ != CommentTypePRAddedToMergeQueue && typ != CommentTypePRRemovedFromMergeQueue {
+		return nil, fmt.Errorf("comment type %d cannot be used to create a merge queue comment", typ)
+	}
+	if err = pr.LoadIssue(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = pr.LoadBaseRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	comment, err = CreateComment(ctx, &CreateCommentOptions{
+		Type:    typ,
+		Doer:    doer,
+		Repo:    pr.BaseRepo,
+		Issue:   pr.Issue,
+		Content: reason,
+	})
+	return comment, err
+}
+
 // RemapExternalUser ExternalUserRemappable interface
 func (c *Comment) RemapExternalUser(externalName string, externalID, userID int64) error {
 	c.OriginalAuthor = externalName
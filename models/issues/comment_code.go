@@ -161,7 +161,7 @@ func findCodeComments(ctx context.Context, opts FindCommentsOptions, issue *Issu
 				Base: issue.Repo.Link(),
 			},
 			Metas: issue.Repo.ComposeMetas(ctx),
-		}, comment.Content); err != nil {
+		}, comment.LoadSuggestion()); err != nil {
 			return nil, err
 		}
 	}
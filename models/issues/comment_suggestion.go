@@ -0,0 +1,127 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"strings"
+)
+
+const codeSuggestionInfo = "suggestion"
+
+// CodeSuggestion represents the change proposed by a ```suggestion block of a code comment,
+// the lines of the block replace the commented line of the proposed side of the diff.
+type CodeSuggestion struct {
+	// Original is the content of the commented line when the comment was made,
+	// HasOriginal is false if it could not be found in the patch of the comment
+	Original    string
+	HasOriginal bool
+	// Lines replace the commented line, the line is removed if there are none
+	Lines []string
+}
+
+// ParseCodeSuggestion extracts the first ```suggestion block of the content of a code comment,
+// it returns the content without the block and the lines of the block.
+func ParseCodeSuggestion(content string) (rest string, lines []string, ok bool) {
+	contentLines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for start, line := range contentLines {
+		indent, fence, info := parseCodeFence(line)
+		if fence == "" || strings.TrimSpace(info) != codeSuggestionInfo {
+			continue
+		}
+
+		end := len(contentLines)
+		for i := start + 1; i < len(contentLines); i++ {
+			if isClosingCodeFence(contentLines[i], fence) {
+				end = i
+				break
+			}
+		}
+
+		lines = make([]string, 0, end-start-1)
+		for _, line := range contentLines[start+1 : end] {
+			lines = append(lines, trimIndent(line, indent))
+		}
+
+		restLines := contentLines[:start:start]
+		if end < len(contentLines) {
+			restLines = append(restLines, contentLines[end+1:]...)
+		}
+		return strings.TrimSpace(strings.Join(restLines, "\n")), lines, true
+	}
+	return content, nil, false
+}
+
+// parseCodeFence returns the indentation, the fence and the info string of an opening code fence
+func parseCodeFence(line string) (indent int, fence, info string) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent = len(line) - len(trimmed)
+	if indent > 3 || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return 0, "", ""
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, trimmed[:1]))
+	if n < 3 {
+		return 0, "", ""
+	}
+	info = trimmed[n:]
+	if trimmed[0] == '`' && strings.Contains(info, "`") {
+		return 0, "", ""
+	}
+	return indent, trimmed[:n], info
+}
+
+// isClosingCodeFence returns whether the line closes the code block opened by fence
+func isClosingCodeFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	trimmed = strings.TrimRight(trimmed, " \t")
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func trimIndent(line string, indent int) string {
+	for i := 0; i < indent && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
+
+// CommentedLine returns the content of the line a code comment was made on,
+// it is read from the last line of the patch of the comment.
+func (c *Comment) CommentedLine() (string, bool) {
+	if c.Patch == "" {
+		return "", false
+	}
+	patch := strings.TrimRight(c.Patch, "\n")
+	last := patch[strings.LastIndexByte(patch, '\n')+1:]
+	if len(last) == 0 {
+		return "", false
+	}
+	switch {
+	case c.Line > 0 && (last[0] == '+' || last[0] == ' '):
+		return last[1:], true
+	case c.Line < 0 && (last[0] == '-' || last[0] == ' '):
+		return last[1:], true
+	}
+	return "", false
+}
+
+// LoadSuggestion extracts the suggestion of a code comment on the proposed side of the diff,
+// the content of the comment has to be rendered without it.
+func (c *Comment) LoadSuggestion() (content string) {
+	if c.Type != CommentTypeCode || c.Line <= 0 {
+		return c.Content
+	}
+	content, lines, ok := ParseCodeSuggestion(c.Content)
+	if !ok {
+		return c.Content
+	}
+	original, hasOriginal := c.CommentedLine()
+	c.Suggestion = &CodeSuggestion{
+		Original:    original,
+		HasOriginal: hasOriginal,
+		Lines:       lines,
+	}
+	return content
+}
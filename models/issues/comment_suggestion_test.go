@@ -0,0 +1,110 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues_test
+
+import (
+	"testing"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCodeSuggestion(t *testing.T) {
+	kases := []struct {
+		content string
+		rest    string
+		lines   []string
+		ok      bool
+	}{
+		{
+			content: "no suggestion here",
+			rest:    "no suggestion here",
+		},
+		{
+			content: "```go\nfmt.Println()\n```",
+			rest:    "```go\nfmt.Println()\n```",
+		},
+		{
+			content: "Use this instead:\n```suggestion\nfoo := bar()\nreturn foo\n```\nWhat do you think?",
+			rest:    "Use this instead:\nWhat do you think?",
+			lines:   []string{"foo := bar()", "return foo"},
+			ok:      true,
+		},
+		{
+			content: "```suggestion\r\n\tindented\r\n```\r\n",
+			rest:    "",
+			lines:   []string{"\tindented"},
+			ok:      true,
+		},
+		{
+			content: "Remove this line\n~~~~ suggestion \n~~~~",
+			rest:    "Remove this line",
+			lines:   []string{},
+			ok:      true,
+		},
+		{
+			content: "````suggestion\n```\ncode\n```\n````",
+			rest:    "",
+			lines:   []string{"```", "code", "```"},
+			ok:      true,
+		},
+		{
+			content: "  ```suggestion\n  two\n   three\n  ```",
+			rest:    "",
+			lines:   []string{"two", " three"},
+			ok:      true,
+		},
+		{
+			content: "```suggestion\nunclosed",
+			rest:    "",
+			lines:   []string{"unclosed"},
+			ok:      true,
+		},
+	}
+
+	for _, kase := range kases {
+		t.Run(kase.content, func(t *testing.T) {
+			rest, lines, ok := issues_model.ParseCodeSuggestion(kase.content)
+			assert.Equal(t, kase.ok, ok)
+			assert.Equal(t, kase.rest, rest)
+			assert.Equal(t, kase.lines, lines)
+		})
+	}
+}
+
+func TestCommentLoadSuggestion(t *testing.T) {
+	patch := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1,2 +1,2 @@\n # repo1\n-old\n+new line"
+
+	comment := &issues_model.Comment{
+		Type:    issues_model.CommentTypeCode,
+		Line:    2,
+		Patch:   patch,
+		Content: "Better:\n```suggestion\nnewer line\n```",
+	}
+	assert.Equal(t, "Better:", comment.LoadSuggestion())
+	assert.Equal(t, &issues_model.CodeSuggestion{
+		Original:    "new line",
+		HasOriginal: true,
+		Lines:       []string{"newer line"},
+	}, comment.Suggestion)
+
+	// suggestions only apply to the proposed side of the diff
+	comment = &issues_model.Comment{
+		Type:    issues_model.CommentTypeCode,
+		Line:    -2,
+		Patch:   patch,
+		Content: "```suggestion\nnewer line\n```",
+	}
+	assert.Equal(t, comment.Content, comment.LoadSuggestion())
+	assert.Nil(t, comment.Suggestion)
+
+	comment = &issues_model.Comment{
+		Type:    issues_model.CommentTypeCode,
+		Line:    2,
+		Content: "```suggestion\nnewer line\n```",
+	}
+	assert.Equal(t, "", comment.LoadSuggestion())
+	assert.False(t, comment.Suggestion.HasOriginal)
+}
@@ -148,6 +148,9 @@ type Issue struct {
 	// with write access
 	IsLocked bool `xorm:"NOT NULL DEFAULT false"`
 
+	// CloseWithSubIssues closes the issue when all its sub-issues are closed
+	CloseWithSubIssues bool `xorm:"NOT NULL DEFAULT false"`
+
 	// For view issue page.
 	ShowRole RoleDescriptor `xorm:"-"`
 }
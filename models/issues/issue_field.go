@@ -0,0 +1,654 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/models/db"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// IssueFieldType is the type of the values of a custom issue field
+type IssueFieldType string
+
+const (
+	IssueFieldTypeText         IssueFieldType = "text"
+	IssueFieldTypeNumber       IssueFieldType = "number"
+	IssueFieldTypeDate         IssueFieldType = "date"
+	IssueFieldTypeSingleSelect IssueFieldType = "single_select"
+	IssueFieldTypeMultiSelect  IssueFieldType = "multi_select"
+	IssueFieldTypeUser         IssueFieldType = "user"
+)
+
+// IssueFieldTypes are all types of custom issue fields
+var IssueFieldTypes = []IssueFieldType{
+	IssueFieldTypeText,
+	IssueFieldTypeNumber,
+	IssueFieldTypeDate,
+	IssueFieldTypeSingleSelect,
+	IssueFieldTypeMultiSelect,
+	IssueFieldTypeUser,
+}
+
+// IsValid returns true if the type is a known type
+func (t IssueFieldType) IsValid() bool {
+	return slices.Contains(IssueFieldTypes, t)
+}
+
+// HasOptions returns true if the values of the type must be one of the options of the field
+func (t IssueFieldType) HasOptions() bool {
+	return t == IssueFieldTypeSingleSelect || t == IssueFieldTypeMultiSelect
+}
+
+// IsMultiple returns true if an issue can have several values of the type
+func (t IssueFieldType) IsMultiple() bool {
+	return t == IssueFieldTypeMultiSelect
+}
+
+// IssueFieldDateFormat is the format of the values of date fields
+const IssueFieldDateFormat = "2006-01-02"
+
+// maxIssueFieldValueLength is the maximum length of a value of a text field
+const maxIssueFieldValueLength = 255
+
+// ErrIssueFieldNotExist represents a "IssueFieldNotExist" kind of error.
+type ErrIssueFieldNotExist struct {
+	ID     int64
+	RepoID int64
+	OrgID  int64
+	Name   string
+}
+
+// IsErrIssueFieldNotExist checks if an error is a ErrIssueFieldNotExist.
+func IsErrIssueFieldNotExist(err error) bool {
+	_, ok := err.(ErrIssueFieldNotExist)
+	return ok
+}
+
+func (err ErrIssueFieldNotExist) Error() string {
+	return fmt.Sprintf("issue field does not exist [id: %d, repo_id: %d, org_id: %d, name: %s]", err.ID, err.RepoID, err.OrgID, err.Name)
+}
+
+func (err ErrIssueFieldNotExist) Unwrap() error {
+	return util.ErrNotExist
+}
+
+// ErrIssueFieldAlreadyExist represents a "IssueFieldAlreadyExist" kind of error.
+type ErrIssueFieldAlreadyExist struct {
+	Name string
+}
+
+// IsErrIssueFieldAlreadyExist checks if an error is a ErrIssueFieldAlreadyExist.
+func IsErrIssueFieldAlreadyExist(err error) bool {
+	_, ok := err.(ErrIssueFieldAlreadyExist)
+	return ok
+}
+
+func (err ErrIssueFieldAlreadyExist) Error() string {
+	return fmt.Sprintf("issue field already exists [name: %s]", err.Name)
+}
+
+func (err ErrIssueFieldAlreadyExist) Unwrap() error {
+	return util.ErrAlreadyExist
+}
+
+// ErrIssueFieldInvalid represents an invalid definition of a custom issue field
+type ErrIssueFieldInvalid struct {
+	Name   string
+	Reason string
+}
+
+// IsErrIssueFieldInvalid checks if an error is a ErrIssueFieldInvalid.
+func IsErrIssueFieldInvalid(err error) bool {
+	_, ok := err.(ErrIssueFieldInvalid)
+	return ok
+}
+
+func (err ErrIssueFieldInvalid) Error() string {
+	return fmt.Sprintf("invalid issue field [name: %s]: %s", err.Name, err.Reason)
+}
+
+func (err ErrIssueFieldInvalid) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ErrIssueFieldInvalidValue represents a value which is not valid for a custom issue field
+type ErrIssueFieldInvalidValue struct {
+	Field string
+	Value string
+}
+
+// IsErrIssueFieldInvalidValue checks if an error is a ErrIssueFieldInvalidValue.
+func IsErrIssueFieldInvalidValue(err error) bool {
+	_, ok := err.(ErrIssueFieldInvalidValue)
+	return ok
+}
+
+func (err ErrIssueFieldInvalidValue) Error() string {
+	return fmt.Sprintf("invalid value of issue field [field: %s, value: %s]", err.Field, err.Value)
+}
+
+func (err ErrIssueFieldInvalidValue) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// IssueField is a custom field of the issues of a repository or of all repositories of an organization
+type IssueField struct {
+	ID          int64 `xorm:"pk autoincr"`
+	RepoID      int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	OrgID       int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Name        string
+	Description string             `xorm:"TEXT"`
+	Type        IssueFieldType     `xorm:"VARCHAR(20) NOT NULL"`
+	Options     []string           `xorm:"TEXT JSON"`
+	SortOrder   int                `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// IssueFieldValue is a value of a custom field of an issue, an issue has one row per value of a multi-select field.
+// The values of user fields are the IDs of the users.
+type IssueFieldValue struct {
+	ID      int64  `xorm:"pk autoincr"`
+	IssueID int64  `xorm:"INDEX NOT NULL"`
+	FieldID int64  `xorm:"INDEX NOT NULL"`
+	Value   string `xorm:"VARCHAR(255) INDEX NOT NULL"`
+}
+
+func init() {
+	db.RegisterModel(new(IssueField))
+	db.RegisterModel(new(IssueFieldValue))
+}
+
+// BelongsToOrg returns true if the field is defined by an organization
+func (f *IssueField) BelongsToOrg() bool {
+	return f.OrgID > 0
+}
+
+// FormatFieldValue returns the value in the format the indexer uses to filter issues by the values of their fields
+func FormatFieldValue(fieldID int64, value string) string {
+	return strconv.FormatInt(fieldID, 10) + ":" + value
+}
+
+// ParseFieldValue parses a value in the format returned by FormatFieldValue
+func ParseFieldValue(s string) (int64, string, error) {
+	id, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid field value %q", s)
+	}
+	fieldID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid field value %q: %w", s, err)
+	}
+	return fieldID, value, nil
+}
+
+// normalize validates the definition of the field and trims its name and options
+func (f *IssueField) normalize() error {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		return ErrIssueFieldInvalid{Name: f.Name, Reason: "the name is empty"}
+	}
+	if !f.Type.IsValid() {
+		return ErrIssueFieldInvalid{Name: f.Name, Reason: fmt.Sprintf("unknown type %q", f.Type)}
+	}
+
+	if !f.Type.HasOptions() {
+		if len(f.Options) > 0 {
+			return ErrIssueFieldInvalid{Name: f.Name, Reason: fmt.Sprintf("a %s field cannot have options", f.Type)}
+		}
+		f.Options = nil
+		return nil
+	}
+
+	options := make([]string, 0, len(f.Options))
+	seen := make(container.Set[string], len(f.Options))
+	for _, option := range f.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			continue
+		}
+		if utf8.RuneCountInString(option) > maxIssueFieldValueLength {
+			return ErrIssueFieldInvalid{Name: f.Name, Reason: fmt.Sprintf("the option %q is too long", option)}
+		}
+		if !seen.Add(strings.ToLower(option)) {
+			return ErrIssueFieldInvalid{Name: f.Name, Reason: fmt.Sprintf("the option %q is duplicated", option)}
+		}
+		options = append(options, option)
+	}
+	if len(options) == 0 {
+		return ErrIssueFieldInvalid{Name: f.Name, Reason: "a select field needs at least one option"}
+	}
+	f.Options = options
+	return nil
+}
+
+// NormalizeValues validates the values for the field of the issues of a repository and returns them in the form they
+// are stored in. The values of user fields are user names, they are stored as user IDs, and must be individual users
+// who can read the issues of the repository. repo is nil when the values are only searched for, the access of the
+// users to it is then not checked.
+func (f *IssueField) NormalizeValues(ctx context.Context, repo *repo_model.Repository, values []string) ([]string, error) {
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		switch f.Type {
+		case IssueFieldTypeText:
+			if utf8.RuneCountInString(value) > maxIssueFieldValueLength {
+				return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+			}
+		case IssueFieldTypeNumber:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+				return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+			}
+			value = strconv.FormatFloat(n, 'f', -1, 64)
+		case IssueFieldTypeDate:
+			if _, err := time.Parse(IssueFieldDateFormat, value); err != nil {
+				return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+			}
+		case IssueFieldTypeSingleSelect, IssueFieldTypeMultiSelect:
+			i := slices.IndexFunc(f.Options, func(option string) bool { return strings.EqualFold(option, value) })
+			if i < 0 {
+				return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+			}
+			value = f.Options[i]
+		case IssueFieldTypeUser:
+			u, err := user_model.GetUserByName(ctx, value)
+			if err != nil {
+				if user_model.IsErrUserNotExist(err) {
+					return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+				}
+				return nil, err
+			}
+			if !u.IsIndividual() {
+				return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+			}
+			if repo != nil {
+				perm, err := access_model.GetUserRepoPermission(ctx, repo, u)
+				if err != nil {
+					return nil, err
+				}
+				if !perm.CanRead(unit.TypeIssues) {
+					return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: value}
+				}
+			}
+			value = strconv.FormatInt(u.ID, 10)
+		}
+
+		if !slices.Contains(normalized, value) {
+			normalized = append(normalized, value)
+		}
+	}
+
+	if len(normalized) > 1 && !f.Type.IsMultiple() {
+		return nil, ErrIssueFieldInvalidValue{Field: f.Name, Value: strings.Join(values, ", ")}
+	}
+	return normalized, nil
+}
+
+// issueFieldNameExists returns true if the repository or the organization of the field already has another field with
+// the same name
+func issueFieldNameExists(ctx context.Context, f *IssueField) (bool, error) {
+	return db.GetEngine(ctx).
+		Where(builder.Eq{"repo_id": f.RepoID, "org_id": f.OrgID}).
+		And(builder.Neq{"id": f.ID}).
+		And("LOWER(name) = ?", strings.ToLower(f.Name)).
+		Exist(new(IssueField))
+}
+
+// NewIssueField creates a custom field of the issues of a repository or an organization
+func NewIssueField(ctx context.Context, f *IssueField) error {
+	if err := f.normalize(); err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if exist, err := issueFieldNameExists(ctx, f); err != nil {
+			return err
+		} else if exist {
+			return ErrIssueFieldAlreadyExist{Name: f.Name}
+		}
+
+		return db.Insert(ctx, f)
+	})
+}
+
+// UpdateIssueField updates the name, the description, the options and the order of a field. The values of the issues
+// which are not an option of the field anymore are removed.
+func UpdateIssueField(ctx context.Context, f *IssueField) error {
+	if err := f.normalize(); err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if exist, err := issueFieldNameExists(ctx, f); err != nil {
+			return err
+		} else if exist {
+			return ErrIssueFieldAlreadyExist{Name: f.Name}
+		}
+
+		if _, err := db.GetEngine(ctx).ID(f.ID).Cols("name", "description", "options", "sort_order").Update(f); err != nil {
+			return err
+		}
+
+		if f.Type.HasOptions() {
+			if _, err := db.GetEngine(ctx).
+				Where(builder.Eq{"field_id": f.ID}).
+				And(builder.NotIn("value", f.Options)).
+				Delete(new(IssueFieldValue)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteIssueField deletes a field and its values
+func DeleteIssueField(ctx context.Context, f *IssueField) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.DeleteByBean(ctx, &IssueFieldValue{FieldID: f.ID}); err != nil {
+			return err
+		}
+		_, err := db.DeleteByID[IssueField](ctx, f.ID)
+		return err
+	})
+}
+
+// DeleteIssueFieldsByRepoID deletes the fields of a repository and their values
+func DeleteIssueFieldsByRepoID(ctx context.Context, repoID int64) error {
+	if _, err := db.GetEngine(ctx).
+		In("field_id", builder.Select("id").From("issue_field").Where(builder.Eq{"repo_id": repoID})).
+		Delete(new(IssueFieldValue)); err != nil {
+		return err
+	}
+
+	_, err := db.DeleteByBean(ctx, &IssueField{RepoID: repoID})
+	return err
+}
+
+// DeleteIssueFieldsByOrgID deletes the fields of an organization and their values
+func DeleteIssueFieldsByOrgID(ctx context.Context, orgID int64) error {
+	if _, err := db.GetEngine(ctx).
+		In("field_id", builder.Select("id").From("issue_field").Where(builder.Eq{"org_id": orgID})).
+		Delete(new(IssueFieldValue)); err != nil {
+		return err
+	}
+
+	_, err := db.DeleteByBean(ctx, &IssueField{OrgID: orgID})
+	return err
+}
+
+func getIssueField(ctx context.Context, cond builder.Cond, notExist ErrIssueFieldNotExist) (*IssueField, error) {
+	f := &IssueField{}
+	has, err := db.GetEngine(ctx).Where(cond).Get(f)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, notExist
+	}
+	return f, nil
+}
+
+// GetIssueFieldInRepoByID returns a field which is defined by the repository
+func GetIssueFieldInRepoByID(ctx context.Context, repoID, id int64) (*IssueField, error) {
+	return getIssueField(ctx, builder.Eq{"id": id, "repo_id": repoID}, ErrIssueFieldNotExist{ID: id, RepoID: repoID})
+}
+
+// GetIssueFieldInOrgByID returns a field which is defined by the organization
+func GetIssueFieldInOrgByID(ctx context.Context, orgID, id int64) (*IssueField, error) {
+	return getIssueField(ctx, builder.Eq{"id": id, "org_id": orgID}, ErrIssueFieldNotExist{ID: id, OrgID: orgID})
+}
+
+// issueFieldsOfRepoCond returns the condition of the fields of the issues of a repository, which are the fields of
+// the repository and of its owner if the owner is an organization
+func issueFieldsOfRepoCond(repoID, ownerID int64) builder.Cond {
+	return builder.Or(builder.Eq{"repo_id": repoID}, builder.Eq{"org_id": ownerID}.And(builder.Gt{"org_id": 0}))
+}
+
+// GetIssueFieldForRepoByID returns a field the issues of a repository can have
+func GetIssueFieldForRepoByID(ctx context.Context, repoID, ownerID, id int64) (*IssueField, error) {
+	return getIssueField(ctx, builder.And(builder.Eq{"id": id}, issueFieldsOfRepoCond(repoID, ownerID)), ErrIssueFieldNotExist{ID: id, RepoID: repoID})
+}
+
+// GetIssueFieldForRepoByName returns a field the issues of a repository can have by its name, a field of the
+// repository has precedence over a field of the organization with the same name
+func GetIssueFieldForRepoByName(ctx context.Context, repoID, ownerID int64, name string) (*IssueField, error) {
+	fields, err := GetIssueFieldsForRepo(ctx, repoID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f, nil
+		}
+	}
+	return nil, ErrIssueFieldNotExist{RepoID: repoID, Name: name}
+}
+
+func findIssueFields(ctx context.Context, cond builder.Cond) ([]*IssueField, error) {
+	fields := make([]*IssueField, 0, 10)
+	return fields, db.GetEngine(ctx).Where(cond).Asc("sort_order", "id").Find(&fields)
+}
+
+// GetIssueFieldsByRepoID returns the fields defined by a repository
+func GetIssueFieldsByRepoID(ctx context.Context, repoID int64) ([]*IssueField, error) {
+	return findIssueFields(ctx, builder.Eq{"repo_id": repoID})
+}
+
+// GetIssueFieldsByOrgID returns the fields defined by an organization
+func GetIssueFieldsByOrgID(ctx context.Context, orgID int64) ([]*IssueField, error) {
+	return findIssueFields(ctx, builder.Eq{"org_id": orgID})
+}
+
+// GetIssueFieldsForRepo returns the fields the issues of a repository can have, the fields of the repository come
+// before the fields of the organization
+func GetIssueFieldsForRepo(ctx context.Context, repoID, ownerID int64) ([]*IssueField, error) {
+	fields, err := findIssueFields(ctx, issueFieldsOfRepoCond(repoID, ownerID))
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(fields, func(a, b *IssueField) int {
+		if a.BelongsToOrg() == b.BelongsToOrg() {
+			return 0
+		} else if a.BelongsToOrg() {
+			return 1
+		}
+		return -1
+	})
+	return fields, nil
+}
+
+// GetIssueFieldValues returns the stored values of the fields of some issues, by issue ID and field ID
+func GetIssueFieldValues(ctx context.Context, issueIDs ...int64) (map[int64]map[int64][]string, error) {
+	values := make(map[int64]map[int64][]string, len(issueIDs))
+	if len(issueIDs) == 0 {
+		return values, nil
+	}
+
+	rows := make([]*IssueFieldValue, 0, len(issueIDs))
+	if err := db.GetEngine(ctx).In("issue_id", issueIDs).Asc("id").Find(&rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if values[row.IssueID] == nil {
+			values[row.IssueID] = make(map[int64][]string)
+		}
+		values[row.IssueID][row.FieldID] = append(values[row.IssueID][row.FieldID], row.Value)
+	}
+	return values, nil
+}
+
+// GetIssueIDsByFieldID returns the IDs of the issues which have a value of a field
+func GetIssueIDsByFieldID(ctx context.Context, fieldID int64) ([]int64, error) {
+	issueIDs := make([]int64, 0, 10)
+	return issueIDs, db.GetEngine(ctx).Table("issue_field_value").
+		Where(builder.Eq{"field_id": fieldID}).
+		Distinct("issue_id").
+		Find(&issueIDs)
+}
+
+// GetIssueFieldDisplayValues returns the values of the fields of some issues as they are shown to users and returned
+// by the API, by issue ID and field ID. The values of user fields are the names of the users.
+func GetIssueFieldDisplayValues(ctx context.Context, fields []*IssueField, issueIDs ...int64) (map[int64]map[int64][]string, error) {
+	values, err := GetIssueFieldValues(ctx, issueIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	userFields := make(container.Set[int64])
+	for _, f := range fields {
+		if f.Type == IssueFieldTypeUser {
+			userFields.Add(f.ID)
+		}
+	}
+
+	display := make(map[int64]map[int64][]string, len(values))
+	userIDs := make(container.Set[int64])
+	for issueID, issueValues := range values {
+		display[issueID] = make(map[int64][]string, len(issueValues))
+		for _, f := range fields {
+			if v, ok := issueValues[f.ID]; ok {
+				display[issueID][f.ID] = v
+				if userFields.Contains(f.ID) {
+					for _, id := range v {
+						if uid, err := strconv.ParseInt(id, 10, 64); err == nil {
+							userIDs.Add(uid)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(userIDs) == 0 {
+		return display, nil
+	}
+
+	users, err := user_model.GetUsersByIDs(ctx, userIDs.Values())
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for _, u := range users {
+		names[strconv.FormatInt(u.ID, 10)] = u.Name
+	}
+	for _, issueValues := range display {
+		for fieldID, v := range issueValues {
+			if !userFields.Contains(fieldID) {
+				continue
+			}
+			userNames := make([]string, 0, len(v))
+			for _, id := range v {
+				if name, ok := names[id]; ok {
+					userNames = append(userNames, name)
+				}
+			}
+			issueValues[fieldID] = userNames
+		}
+	}
+	return display, nil
+}
+
+// SetIssueFieldValues replaces the values of a field of an issue, it removes the values if values is empty
+func SetIssueFieldValues(ctx context.Context, issue *Issue, f *IssueField, values []string) error {
+	if err := issue.LoadRepo(ctx); err != nil {
+		return err
+	}
+	normalized, err := f.NormalizeValues(ctx, issue.Repo, values)
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.DeleteByBean(ctx, &IssueFieldValue{IssueID: issue.ID, FieldID: f.ID}); err != nil {
+			return err
+		}
+		for _, value := range normalized {
+			if err := db.Insert(ctx, &IssueFieldValue{IssueID: issue.ID, FieldID: f.ID, Value: value}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IssueFieldGroup is a group of issues which have the same value of a field
+type IssueFieldGroup struct {
+	Value  string // the display value, it is empty for the issues without a value
+	Issues IssueList
+}
+
+// GroupIssuesByField groups issues by their values of a field. The groups of select fields follow the order of the
+// options, the other groups are sorted by value. An issue with several values is in several groups, and the issues
+// without a value are in the last group.
+func GroupIssuesByField(ctx context.Context, f *IssueField, issues IssueList) ([]*IssueFieldGroup, error) {
+	values, err := GetIssueFieldDisplayValues(ctx, []*IssueField{f}, issues.getIssueIDs()...)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*IssueFieldGroup)
+	none := &IssueFieldGroup{}
+	for _, issue := range issues {
+		issueValues := values[issue.ID][f.ID]
+		if len(issueValues) == 0 {
+			none.Issues = append(none.Issues, issue)
+			continue
+		}
+		for _, value := range issueValues {
+			if groups[value] == nil {
+				groups[value] = &IssueFieldGroup{Value: value}
+			}
+			groups[value].Issues = append(groups[value].Issues, issue)
+		}
+	}
+
+	result := make([]*IssueFieldGroup, 0, len(groups)+1)
+	if f.Type.HasOptions() {
+		for _, option := range f.Options {
+			if g, ok := groups[option]; ok {
+				result = append(result, g)
+			} else {
+				result = append(result, &IssueFieldGroup{Value: option})
+			}
+		}
+	} else {
+		for _, g := range groups {
+			result = append(result, g)
+		}
+		slices.SortFunc(result, func(a, b *IssueFieldGroup) int {
+			if f.Type == IssueFieldTypeNumber {
+				x, _ := strconv.ParseFloat(a.Value, 64)
+				y, _ := strconv.ParseFloat(b.Value, 64)
+				if x < y {
+					return -1
+				} else if x > y {
+					return 1
+				}
+				return 0
+			}
+			return strings.Compare(strings.ToLower(a.Value), strings.ToLower(b.Value))
+		})
+	}
+	return append(result, none), nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIssueField(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	f := &issues_model.IssueField{RepoID: 1, Name: " Priority ", Type: issues_model.IssueFieldTypeSingleSelect, Options: []string{"low", " high ", ""}}
+	require.NoError(t, issues_model.NewIssueField(db.DefaultContext, f))
+	assert.Equal(t, "Priority", f.Name)
+	assert.Equal(t, []string{"low", "high"}, f.Options)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.IssueField{ID: f.ID, RepoID: 1})
+
+	err := issues_model.NewIssueField(db.DefaultContext, &issues_model.IssueField{RepoID: 1, Name: "priority", Type: issues_model.IssueFieldTypeText})
+	assert.True(t, issues_model.IsErrIssueFieldAlreadyExist(err))
+
+	// the same name can be used by another repository
+	require.NoError(t, issues_model.NewIssueField(db.DefaultContext, &issues_model.IssueField{RepoID: 2, Name: "Priority", Type: issues_model.IssueFieldTypeText}))
+
+	for _, invalid := range []*issues_model.IssueField{
+		{RepoID: 1, Name: " ", Type: issues_model.IssueFieldTypeText},
+		{RepoID: 1, Name: "Unknown", Type: "color"},
+		{RepoID: 1, Name: "Options", Type: issues_model.IssueFieldTypeText, Options: []string{"a"}},
+		{RepoID: 1, Name: "No options", Type: issues_model.IssueFieldTypeMultiSelect},
+		{RepoID: 1, Name: "Duplicated", Type: issues_model.IssueFieldTypeMultiSelect, Options: []string{"a", "A"}},
+	} {
+		err := issues_model.NewIssueField(db.DefaultContext, invalid)
+		assert.True(t, issues_model.IsErrIssueFieldInvalid(err), "field %q", invalid.Name)
+	}
+}
+
+func TestIssueField_NormalizeValues(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	cases := []struct {
+		field    issues_model.IssueField
+		values   []string
+		expected []string
+	}{
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeText},
+			values:   []string{" some text ", ""},
+			expected: []string{"some text"},
+		},
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeNumber},
+			values:   []string{"1.50"},
+			expected: []string{"1.5"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeNumber},
+			values: []string{"one"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeNumber},
+			values: []string{"NaN"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeNumber},
+			values: []string{"-Inf"},
+		},
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeDate},
+			values:   []string{"2024-02-29"},
+			expected: []string{"2024-02-29"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeDate},
+			values: []string{"2023-02-29"},
+		},
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeSingleSelect, Options: []string{"Low", "High"}},
+			values:   []string{"high"},
+			expected: []string{"High"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeSingleSelect, Options: []string{"Low", "High"}},
+			values: []string{"Low", "High"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeSingleSelect, Options: []string{"Low", "High"}},
+			values: []string{"Medium"},
+		},
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeMultiSelect, Options: []string{"Linux", "Windows"}},
+			values:   []string{"windows", "Linux", "Windows"},
+			expected: []string{"Windows", "Linux"},
+		},
+		{
+			field:    issues_model.IssueField{Type: issues_model.IssueFieldTypeUser},
+			values:   []string{"user2"},
+			expected: []string{"2"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeUser},
+			values: []string{"user-not-exist"},
+		},
+		{
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeUser},
+			values: []string{"org3"},
+		},
+		{
+			// user 5 cannot read the private repository
+			field:  issues_model.IssueField{Type: issues_model.IssueFieldTypeUser},
+			values: []string{"user5"},
+		},
+	}
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+	for _, c := range cases {
+		normalized, err := c.field.NormalizeValues(db.DefaultContext, repo, c.values)
+		if c.expected == nil {
+			assert.True(t, issues_model.IsErrIssueFieldInvalidValue(err), "%s field with values %v", c.field.Type, c.values)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, normalized)
+	}
+}
+
+func TestIssueFieldValues(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	issue1 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 1})
+	issue2 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 2})
+	issue3 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 3})
+
+	f := &issues_model.IssueField{RepoID: 1, Name: "OS", Type: issues_model.IssueFieldTypeMultiSelect, Options: []string{"Linux", "Windows", "macOS"}}
+	require.NoError(t, issues_model.NewIssueField(db.DefaultContext, f))
+	assignee := &issues_model.IssueField{RepoID: 1, Name: "Reviewer", Type: issues_model.IssueFieldTypeUser}
+	require.NoError(t, issues_model.NewIssueField(db.DefaultContext, assignee))
+
+	require.NoError(t, issues_model.SetIssueFieldValues(db.DefaultContext, issue1, f, []string{"Linux", "Windows"}))
+	require.NoError(t, issues_model.SetIssueFieldValues(db.DefaultContext, issue2, f, []string{"linux"}))
+	require.NoError(t, issues_model.SetIssueFieldValues(db.DefaultContext, issue1, assignee, []string{"user2"}))
+
+	values, err := issues_model.GetIssueFieldValues(db.DefaultContext, issue1.ID, issue2.ID, issue3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]map[int64][]string{
+		issue1.ID: {f.ID: {"Linux", "Windows"}, assignee.ID: {"2"}},
+		issue2.ID: {f.ID: {"Linux"}},
+	}, values)
+
+	display, err := issues_model.GetIssueFieldDisplayValues(db.DefaultContext, []*issues_model.IssueField{f, assignee}, issue1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, display[issue1.ID][assignee.ID])
+
+	groups, err := issues_model.GroupIssuesByField(db.DefaultContext, f, issues_model.IssueList{issue1, issue2, issue3})
+	require.NoError(t, err)
+	require.Len(t, groups, 4)
+	assert.Equal(t, "Linux", groups[0].Value)
+	assert.Equal(t, issues_model.IssueList{issue1, issue2}, groups[0].Issues)
+	assert.Equal(t, "Windows", groups[1].Value)
+	assert.Equal(t, issues_model.IssueList{issue1}, groups[1].Issues)
+	assert.Equal(t, "macOS", groups[2].Value)
+	assert.Empty(t, groups[2].Issues)
+	assert.Empty(t, groups[3].Value)
+	assert.Equal(t, issues_model.IssueList{issue3}, groups[3].Issues)
+
+	// removing an option removes the values of the issues
+	f.Options = []string{"Windows", "macOS"}
+	require.NoError(t, issues_model.UpdateIssueField(db.DefaultContext, f))
+	issueIDs, err := issues_model.GetIssueIDsByFieldID(db.DefaultContext, f.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{issue1.ID}, issueIDs)
+
+	require.NoError(t, issues_model.SetIssueFieldValues(db.DefaultContext, issue1, f, nil))
+	unittest.AssertNotExistsBean(t, &issues_model.IssueFieldValue{IssueID: issue1.ID, FieldID: f.ID})
+
+	require.NoError(t, issues_model.DeleteIssueField(db.DefaultContext, assignee))
+	unittest.AssertNotExistsBean(t, &issues_model.IssueField{ID: assignee.ID})
+	unittest.AssertNotExistsBean(t, &issues_model.IssueFieldValue{FieldID: assignee.ID})
+}
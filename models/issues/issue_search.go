@@ -46,6 +46,7 @@ type IssuesOptions struct { //nolint
 	IssueIDs           []int64
 	UpdatedAfterUnix   int64
 	UpdatedBeforeUnix  int64
+	FieldValues        []string // values of custom fields the issues have, formatted as "<field id>:<value>"
 	// prioritize issues from this repo
 	PriorityRepoID int64
 	IsArchived     optional.Option[bool]
@@ -190,6 +191,18 @@ func applyProjectColumnCondition(sess *xorm.Session, opts *IssuesOptions) {
 	}
 }
 
+func applyFieldValuesCondition(sess *xorm.Session, opts *IssuesOptions) {
+	for _, s := range opts.FieldValues {
+		fieldID, value, err := ParseFieldValue(s)
+		if err != nil {
+			// an invalid value cannot match any issue
+			sess.And(builder.Expr("1 = 0"))
+			return
+		}
+		sess.In("issue.id", builder.Select("issue_id").From("issue_field_value").Where(builder.Eq{"field_id": fieldID, "value": value}))
+	}
+}
+
 func applyRepoConditions(sess *xorm.Session, opts *IssuesOptions) {
 	if len(opts.RepoIDs) == 1 {
 		opts.RepoCond = builder.Eq{"issue.repo_id": opts.RepoIDs[0]}
@@ -267,6 +280,8 @@ func applyConditions(sess *xorm.Session, opts *IssuesOptions) {
 
 	applyLabelsCondition(sess, opts)
 
+	applyFieldValuesCondition(sess, opts)
+
 	if opts.User != nil {
 		sess.And(issuePullAccessibleRepoCond("issue.repo_id", opts.User.ID, opts.Org, opts.Team, opts.IsPull.Value()))
 	}
@@ -147,6 +147,8 @@ func applyIssuesOptions(sess *xorm.Session, opts *IssuesOptions, issueIDs []int6
 
 	applyLabelsCondition(sess, opts)
 
+	applyFieldValuesCondition(sess, opts)
+
 	applyMilestoneCondition(sess, opts)
 
 	applyProjectCondition(sess, opts)
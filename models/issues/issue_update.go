@@ -701,6 +701,17 @@ func DeleteIssuesByRepoID(ctx context.Context, repoID int64) (attachmentPaths []
 			return nil, err
 		}
 
+		// Delete the sub-issues of issues in this repository and their links to parents in other repositories
+		_, err = sess.In("issue_id", issueIDs).Delete(&SubIssue{})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = sess.In("parent_id", issueIDs).Delete(&SubIssue{})
+		if err != nil {
+			return nil, err
+		}
+
 		_, err = sess.In("issue_id", issueIDs).Delete(&IssueUser{})
 		if err != nil {
 			return nil, err
@@ -711,6 +722,11 @@ func DeleteIssuesByRepoID(ctx context.Context, repoID int64) (attachmentPaths []
 			return nil, err
 		}
 
+		_, err = sess.In("issue_id", issueIDs).Delete(&IssueFieldValue{})
+		if err != nil {
+			return nil, err
+		}
+
 		_, err = sess.In("issue_id", issueIDs).Delete(&IssueWatch{})
 		if err != nil {
 			return nil, err
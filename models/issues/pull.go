@@ -18,6 +18,7 @@ import (
 	pull_model "code.gitea.io/gitea/models/pull"
 	repo_model "code.gitea.io/gitea/models/repo"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -200,6 +201,12 @@ func DeletePullsByBaseRepoID(ctx context.Context, repoID int64) error {
 		return err
 	}
 
+	// Delete merge queue entries
+	if _, err := db.GetEngine(ctx).In("pull_id", deleteCond).
+		Delete(&pull_model.MergeQueueEntry{}); err != nil {
+		return err
+	}
+
 	// Delete review states
 	if _, err := db.GetEngine(ctx).In("pull_id", deleteCond).
 		Delete(&pull_model.ReviewState{}); err != nil {
@@ -899,6 +906,115 @@ func MergeBlockedByOutdatedBranch(protectBranch *git_model.ProtectedBranch, pr *
 	return protectBranch.BlockOnOutdatedBranch && pr.CommitsBehind > 0
 }
 
+// CodeOwnerApproval is the approval state of the changed files of a pull request owned by a code owner rule
+type CodeOwnerApproval struct {
+	Rule     *CodeOwnerRule
+	Files    []string
+	Approved bool
+}
+
+// GetCodeOwnerApprovals returns the approval state of every code owner rule owning at least one of the changed files of
+// pr. A rule is approved if the latest review of one of its users, or of a member of one of its teams, is an approval.
+// Dismissed approvals never count and stale approvals do not count if the protected branch ignores them.
+func GetCodeOwnerApprovals(ctx context.Context, protectBranch *git_model.ProtectedBranch, pr *PullRequest, rules []*CodeOwnerRule, changedFiles []string) ([]*CodeOwnerApproval, error) {
+	approvals := make([]*CodeOwnerApproval, 0, len(rules))
+	for _, rule := range rules {
+		var files []string
+		for _, file := range changedFiles {
+			if rule.MatchFile(file) {
+				files = append(files, file)
+			}
+		}
+		if len(files) > 0 {
+			approvals = append(approvals, &CodeOwnerApproval{Rule: rule, Files: files})
+		}
+	}
+	if len(approvals) == 0 {
+		return approvals, nil
+	}
+
+	approverIDs, err := getApproverIDs(ctx, protectBranch, pr)
+	if err != nil {
+		return nil, err
+	}
+	if len(approverIDs) == 0 {
+		return approvals, nil
+	}
+
+	for _, approval := range approvals {
+		if approval.Approved, err = isCodeOwnerRuleApproved(ctx, approval.Rule, approverIDs); err != nil {
+			return nil, err
+		}
+	}
+	return approvals, nil
+}
+
+// getApproverIDs returns the users whose latest review of pr is an approval that counts towards the protected branch
+func getApproverIDs(ctx context.Context, protectBranch *git_model.ProtectedBranch, pr *PullRequest) (container.Set[int64], error) {
+	reviews := make([]*Review, 0, 10)
+	if err := db.GetEngine(ctx).Where("issue_id = ?", pr.IssueID).
+		And("reviewer_id > 0").
+		In("type", ReviewTypeApprove, ReviewTypeReject).
+		Asc("id").
+		Find(&reviews); err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int64]*Review, len(reviews))
+	for _, review := range reviews {
+		latest[review.ReviewerID] = review
+	}
+
+	approverIDs := make(container.Set[int64], len(latest))
+	for reviewerID, review := range latest {
+		if review.Type != ReviewTypeApprove || review.Dismissed {
+			continue
+		}
+		if review.Stale && protectBranch.IgnoreStaleApprovals {
+			continue
+		}
+		approverIDs.Add(reviewerID)
+	}
+	return approverIDs, nil
+}
+
+func isCodeOwnerRuleApproved(ctx context.Context, rule *CodeOwnerRule, approverIDs container.Set[int64]) (bool, error) {
+	for _, u := range rule.Users {
+		if approverIDs.Contains(u.ID) {
+			return true, nil
+		}
+	}
+	for _, team := range rule.Teams {
+		for approverID := range approverIDs {
+			isMember, err := org_model.IsTeamMember(ctx, team.OrgID, team.ID, approverID)
+			if err != nil {
+				return false, err
+			}
+			if isMember {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// CodeOwnersFiles are the paths the code owners file is looked up at, in order
+var CodeOwnersFiles = []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitea/CODEOWNERS"}
+
+// GetCodeOwnersFromCommit returns the code owners configuration of the first code owners file found in commit
+func GetCodeOwnersFromCommit(ctx context.Context, commit *git.Commit) ([]*CodeOwnerRule, []string) {
+	var data string
+	for _, file := range CodeOwnersFiles {
+		if blob, err := commit.GetBlobByPath(file); err == nil {
+			data, err = blob.GetBlobContent(setting.UI.MaxDisplayFileSize)
+			if err == nil {
+				break
+			}
+		}
+	}
+	return GetCodeOwnersFromContent(ctx, data)
+}
+
 // GetCodeOwnersFromContent returns the code owners configuration
 // Return empty slice if files missing
 // Return warning messages on parsing errors
@@ -940,6 +1056,12 @@ type CodeOwnerRule struct {
 	Negative bool
 	Users    []*user_model.User
 	Teams    []*org_model.Team
+	Owners   []string // the users and teams of the rule as written in the file, e.g. @user or @org/team
+}
+
+// MatchFile returns true if the file is owned by the owners of the rule
+func (rule *CodeOwnerRule) MatchFile(file string) bool {
+	return rule.Rule.MatchString(file) != rule.Negative
 }
 
 func ParseCodeOwnersLine(ctx context.Context, tokens []string) (*CodeOwnerRule, []string) {
@@ -985,6 +1107,7 @@ func ParseCodeOwnersLine(ctx context.Context, tokens []string) (*CodeOwnerRule,
 			for _, team := range teams {
 				if team.Name == teamName {
 					rule.Teams = append(rule.Teams, team)
+					rule.Owners = append(rule.Owners, "@"+org.Name+"/"+team.Name)
 				}
 			}
 		} else {
@@ -994,6 +1117,7 @@ func ParseCodeOwnersLine(ctx context.Context, tokens []string) (*CodeOwnerRule,
 				continue
 			}
 			rule.Users = append(rule.Users, u)
+			rule.Owners = append(rule.Owners, "@"+u.Name)
 		}
 	}
 
@@ -5,10 +5,12 @@ package issues_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
 	issues_model "code.gitea.io/gitea/models/issues"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unittest"
@@ -423,6 +425,44 @@ func TestParseCodeOwnersLine(t *testing.T) {
 	}
 }
 
+func TestGetCodeOwnerApprovals(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+	pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 2})
+
+	rules, warnings := issues_model.GetCodeOwnersFromContent(db.DefaultContext, "README.md @user2\n.*\\.go @user4\ndocs/.* @org3/team1\n")
+	assert.Empty(t, warnings)
+	changedFiles := []string{"README.md", "main.go", "docs/index.md", "LICENSE"}
+
+	summarize := func(approvals []*issues_model.CodeOwnerApproval) map[string]bool {
+		summary := make(map[string]bool, len(approvals))
+		for _, approval := range approvals {
+			summary[strings.Join(approval.Rule.Owners, ",")+":"+strings.Join(approval.Files, ",")] = approval.Approved
+		}
+		return summary
+	}
+
+	// user2 requested changes, user4 (a member of org3/team1) approved before the last push
+	approvals, err := issues_model.GetCodeOwnerApprovals(db.DefaultContext, &git_model.ProtectedBranch{}, pr, rules, changedFiles)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"@user2:README.md":          false,
+		"@user4:main.go":            true,
+		"@org3/team1:docs/index.md": true,
+	}, summarize(approvals))
+
+	approvals, err = issues_model.GetCodeOwnerApprovals(db.DefaultContext, &git_model.ProtectedBranch{IgnoreStaleApprovals: true}, pr, rules, changedFiles)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"@user2:README.md":          false,
+		"@user4:main.go":            false,
+		"@org3/team1:docs/index.md": false,
+	}, summarize(approvals))
+
+	approvals, err = issues_model.GetCodeOwnerApprovals(db.DefaultContext, &git_model.ProtectedBranch{}, pr, rules, []string{"LICENSE"})
+	assert.NoError(t, err)
+	assert.Empty(t, approvals)
+}
+
 func TestGetApprovers(t *testing.T) {
 	assert.NoError(t, unittest.PrepareTestDatabase())
 	pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 5})
@@ -0,0 +1,430 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrSubIssueExists represents an error where the issue is already a sub-issue of the parent
+type ErrSubIssueExists struct {
+	ParentID int64
+	IssueID  int64
+}
+
+// IsErrSubIssueExists checks if an error is a ErrSubIssueExists.
+func IsErrSubIssueExists(err error) bool {
+	_, ok := err.(ErrSubIssueExists)
+	return ok
+}
+
+func (err ErrSubIssueExists) Error() string {
+	return fmt.Sprintf("sub-issue does already exist [parent id: %d, issue id: %d]", err.ParentID, err.IssueID)
+}
+
+func (err ErrSubIssueExists) Unwrap() error {
+	return util.ErrAlreadyExist
+}
+
+// ErrSubIssueNotExist represents an error where the issue is not a sub-issue of the parent
+type ErrSubIssueNotExist struct {
+	ParentID int64
+	IssueID  int64
+}
+
+// IsErrSubIssueNotExist checks if an error is a ErrSubIssueNotExist.
+func IsErrSubIssueNotExist(err error) bool {
+	_, ok := err.(ErrSubIssueNotExist)
+	return ok
+}
+
+func (err ErrSubIssueNotExist) Error() string {
+	return fmt.Sprintf("sub-issue does not exist [parent id: %d, issue id: %d]", err.ParentID, err.IssueID)
+}
+
+func (err ErrSubIssueNotExist) Unwrap() error {
+	return util.ErrNotExist
+}
+
+// ErrSubIssueHasParent represents an error where the issue is already a sub-issue of another issue
+type ErrSubIssueHasParent struct {
+	IssueID  int64
+	ParentID int64
+}
+
+// IsErrSubIssueHasParent checks if an error is a ErrSubIssueHasParent.
+func IsErrSubIssueHasParent(err error) bool {
+	_, ok := err.(ErrSubIssueHasParent)
+	return ok
+}
+
+func (err ErrSubIssueHasParent) Error() string {
+	return fmt.Sprintf("issue is already a sub-issue of another issue [issue id: %d, parent id: %d]", err.IssueID, err.ParentID)
+}
+
+func (err ErrSubIssueHasParent) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ErrCircularSubIssue represents an error where the parent is the issue itself or one of its sub-issues
+type ErrCircularSubIssue struct {
+	ParentID int64
+	IssueID  int64
+}
+
+// IsErrCircularSubIssue checks if an error is a ErrCircularSubIssue.
+func IsErrCircularSubIssue(err error) bool {
+	_, ok := err.(ErrCircularSubIssue)
+	return ok
+}
+
+func (err ErrCircularSubIssue) Error() string {
+	return fmt.Sprintf("an issue cannot be a sub-issue of itself or of its sub-issues [parent id: %d, issue id: %d]", err.ParentID, err.IssueID)
+}
+
+func (err ErrCircularSubIssue) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ErrSubIssueTooDeep represents an error where adding the sub-issue would exceed the maximum depth of sub-issues
+type ErrSubIssueTooDeep struct {
+	ParentID int64
+	IssueID  int64
+	MaxDepth int
+}
+
+// IsErrSubIssueTooDeep checks if an error is a ErrSubIssueTooDeep.
+func IsErrSubIssueTooDeep(err error) bool {
+	_, ok := err.(ErrSubIssueTooDeep)
+	return ok
+}
+
+func (err ErrSubIssueTooDeep) Error() string {
+	return fmt.Sprintf("sub-issues cannot be nested more than %d levels deep [parent id: %d, issue id: %d]", err.MaxDepth, err.ParentID, err.IssueID)
+}
+
+func (err ErrSubIssueTooDeep) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ErrSubIssueNotAllowed represents an error where the issue cannot be a sub-issue of the parent, because one of them
+// is a pull request or because their repositories have different owners
+type ErrSubIssueNotAllowed struct {
+	ParentID int64
+	IssueID  int64
+}
+
+// IsErrSubIssueNotAllowed checks if an error is a ErrSubIssueNotAllowed.
+func IsErrSubIssueNotAllowed(err error) bool {
+	_, ok := err.(ErrSubIssueNotAllowed)
+	return ok
+}
+
+func (err ErrSubIssueNotAllowed) Error() string {
+	return fmt.Sprintf("sub-issues must be issues of repositories of the same owner [parent id: %d, issue id: %d]", err.ParentID, err.IssueID)
+}
+
+func (err ErrSubIssueNotAllowed) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// SubIssue represents an issue which is a sub-issue of another issue, an issue has at most one parent
+type SubIssue struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UserID      int64              `xorm:"NOT NULL"`
+	ParentID    int64              `xorm:"INDEX NOT NULL"`
+	IssueID     int64              `xorm:"UNIQUE NOT NULL"`
+	Sorting     int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+func init() {
+	db.RegisterModel(new(SubIssue))
+}
+
+// SubIssueProgress is the number of the sub-issues of an issue and of its closed sub-issues
+type SubIssueProgress struct {
+	Total  int64
+	Closed int64
+}
+
+// Percentage returns the percentage of closed sub-issues
+func (p *SubIssueProgress) Percentage() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return int(p.Closed * 100 / p.Total)
+}
+
+// getParentID returns the ID of the parent of the issue, or 0 if the issue is not a sub-issue
+func getParentID(ctx context.Context, issueID int64) (int64, error) {
+	var parentID int64
+	if _, err := db.GetEngine(ctx).Table("sub_issue").Where("issue_id = ?", issueID).Cols("parent_id").Get(&parentID); err != nil {
+		return 0, err
+	}
+	return parentID, nil
+}
+
+// getSubIssueIDs returns the IDs of the sub-issues of some issues
+func getSubIssueIDs(ctx context.Context, parentIDs []int64) ([]int64, error) {
+	issueIDs := make([]int64, 0, 10)
+	return issueIDs, db.GetEngine(ctx).Table("sub_issue").In("parent_id", parentIDs).Cols("issue_id").Find(&issueIDs)
+}
+
+// checkSubIssueHierarchy checks that issue can become a sub-issue of parent without creating a cycle and without
+// exceeding the maximum depth of sub-issues
+func checkSubIssueHierarchy(ctx context.Context, parent, issue *Issue) error {
+	// the depth of the new sub-issue is the number of its ancestors
+	depth := 1
+	for id := parent.ID; ; depth++ {
+		if id == issue.ID {
+			return ErrCircularSubIssue{ParentID: parent.ID, IssueID: issue.ID}
+		}
+		if depth > setting.Service.MaxSubIssueDepth {
+			return ErrSubIssueTooDeep{ParentID: parent.ID, IssueID: issue.ID, MaxDepth: setting.Service.MaxSubIssueDepth}
+		}
+		parentID, err := getParentID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if parentID == 0 {
+			break
+		}
+		id = parentID
+	}
+
+	// the sub-issues of the issue move down with it
+	for level := []int64{issue.ID}; len(level) > 0; depth++ {
+		if depth > setting.Service.MaxSubIssueDepth {
+			return ErrSubIssueTooDeep{ParentID: parent.ID, IssueID: issue.ID, MaxDepth: setting.Service.MaxSubIssueDepth}
+		}
+		var err error
+		if level, err = getSubIssueIDs(ctx, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddSubIssue makes issue a sub-issue of parent, after the other sub-issues of parent
+func AddSubIssue(ctx context.Context, doer *user_model.User, parent, issue *Issue) error {
+	if parent.IsPull || issue.IsPull {
+		return ErrSubIssueNotAllowed{ParentID: parent.ID, IssueID: issue.ID}
+	}
+	if err := parent.LoadRepo(ctx); err != nil {
+		return err
+	}
+	if err := issue.LoadRepo(ctx); err != nil {
+		return err
+	}
+	if parent.Repo.OwnerID != issue.Repo.OwnerID {
+		return ErrSubIssueNotAllowed{ParentID: parent.ID, IssueID: issue.ID}
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		// the sub-issues are always in the repositories of the same owner, locking the owner serializes the additions
+		// of sub-issues which could otherwise create a cycle together, like adding A to B and B to A concurrently
+		if _, err := db.GetEngine(ctx).Exec("UPDATE `user` SET updated_unix = updated_unix WHERE id = ?", parent.Repo.OwnerID); err != nil {
+			return err
+		}
+
+		parentID, err := getParentID(ctx, issue.ID)
+		if err != nil {
+			return err
+		}
+		if parentID == parent.ID {
+			return ErrSubIssueExists{ParentID: parent.ID, IssueID: issue.ID}
+		} else if parentID != 0 {
+			return ErrSubIssueHasParent{IssueID: issue.ID, ParentID: parentID}
+		}
+
+		if err := checkSubIssueHierarchy(ctx, parent, issue); err != nil {
+			return err
+		}
+
+		var maxSorting int64
+		if _, err := db.GetEngine(ctx).Table("sub_issue").Where("parent_id = ?", parent.ID).Select("MAX(sorting)").Get(&maxSorting); err != nil {
+			return err
+		}
+		if err := db.Insert(ctx, &SubIssue{
+			UserID:   doer.ID,
+			ParentID: parent.ID,
+			IssueID:  issue.ID,
+			Sorting:  maxSorting + 1,
+		}); err != nil {
+			return err
+		}
+
+		return createSubIssueComments(ctx, doer, parent, issue, true)
+	})
+}
+
+// RemoveSubIssue removes issue from the sub-issues of parent
+func RemoveSubIssue(ctx context.Context, doer *user_model.User, parent, issue *Issue) error {
+	if err := parent.LoadRepo(ctx); err != nil {
+		return err
+	}
+	if err := issue.LoadRepo(ctx); err != nil {
+		return err
+	}
+
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		affected, err := db.GetEngine(ctx).Delete(&SubIssue{ParentID: parent.ID, IssueID: issue.ID})
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrSubIssueNotExist{ParentID: parent.ID, IssueID: issue.ID}
+		}
+
+		return createSubIssueComments(ctx, doer, parent, issue, false)
+	})
+}
+
+// createSubIssueComments adds a comment referencing the other issue to both the parent and the sub-issue
+func createSubIssueComments(ctx context.Context, doer *user_model.User, parent, issue *Issue, add bool) error {
+	parentType, issueType := CommentTypeAddSubIssue, CommentTypeAddParentIssue
+	if !add {
+		parentType, issueType = CommentTypeRemoveSubIssue, CommentTypeRemoveParentIssue
+	}
+
+	if _, err := CreateComment(ctx, &CreateCommentOptions{
+		Type:             parentType,
+		Doer:             doer,
+		Repo:             parent.Repo,
+		Issue:            parent,
+		DependentIssueID: issue.ID,
+	}); err != nil {
+		return err
+	}
+	_, err := CreateComment(ctx, &CreateCommentOptions{
+		Type:             issueType,
+		Doer:             doer,
+		Repo:             issue.Repo,
+		Issue:            issue,
+		DependentIssueID: parent.ID,
+	})
+	return err
+}
+
+// MoveSubIssue moves a sub-issue of parent to the given 0-based position among the sub-issues of parent
+func MoveSubIssue(ctx context.Context, parent, issue *Issue, position int) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		subIssues := make([]*SubIssue, 0, 10)
+		if err := db.GetEngine(ctx).Where("parent_id = ?", parent.ID).OrderBy("sorting, id").Find(&subIssues); err != nil {
+			return err
+		}
+
+		from := -1
+		for i, s := range subIssues {
+			if s.IssueID == issue.ID {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			return ErrSubIssueNotExist{ParentID: parent.ID, IssueID: issue.ID}
+		}
+
+		moved := subIssues[from]
+		subIssues = append(subIssues[:from], subIssues[from+1:]...)
+		position = max(0, min(position, len(subIssues)))
+		subIssues = append(subIssues[:position], append([]*SubIssue{moved}, subIssues[position:]...)...)
+
+		for i, s := range subIssues {
+			if s.Sorting == int64(i+1) {
+				continue
+			}
+			s.Sorting = int64(i + 1)
+			if _, err := db.GetEngine(ctx).ID(s.ID).Cols("sorting").Update(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetSubIssues returns the sub-issues of an issue in their order
+func GetSubIssues(ctx context.Context, parentID int64) (IssueList, error) {
+	issues := make(IssueList, 0, 10)
+	return issues, db.GetEngine(ctx).
+		Join("INNER", "sub_issue", "sub_issue.issue_id = issue.id").
+		Where("sub_issue.parent_id = ?", parentID).
+		OrderBy("sub_issue.sorting, sub_issue.id").
+		Find(&issues)
+}
+
+// GetParentIssue returns the issue the issue is a sub-issue of, or nil if it is not a sub-issue
+func GetParentIssue(ctx context.Context, issueID int64) (*Issue, error) {
+	parentID, err := getParentID(ctx, issueID)
+	if err != nil || parentID == 0 {
+		return nil, err
+	}
+	return GetIssueByID(ctx, parentID)
+}
+
+// HasOpenSubIssues returns whether some sub-issues of the issue are open, whoever can read them
+func HasOpenSubIssues(ctx context.Context, parentID int64) (bool, error) {
+	return db.GetEngine(ctx).Table("sub_issue").
+		Join("INNER", "issue", "issue.id = sub_issue.issue_id").
+		Where("sub_issue.parent_id = ? AND issue.is_closed = ?", parentID, false).
+		Exist()
+}
+
+// GetSubIssueProgress returns the progress of the sub-issues of some issues by issue ID, only counting the sub-issues
+// which the viewer can read. The issues without such sub-issues are not in the map.
+func GetSubIssueProgress(ctx context.Context, viewer *user_model.User, parentIDs ...int64) (map[int64]*SubIssueProgress, error) {
+	progress := make(map[int64]*SubIssueProgress, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return progress, nil
+	}
+
+	type count struct {
+		ParentID int64
+		IsClosed bool
+		Count    int64
+	}
+	cond := builder.In("sub_issue.parent_id", parentIDs)
+	if viewer == nil || !viewer.IsAdmin {
+		cond = cond.And(builder.In("issue.repo_id", builder.Select("id").From("repository").
+			Where(repo_model.AccessibleRepositoryCondition(viewer, unit.TypeIssues))))
+	}
+	counts := make([]*count, 0, len(parentIDs))
+	if err := db.GetEngine(ctx).Table("sub_issue").
+		Join("INNER", "issue", "issue.id = sub_issue.issue_id").
+		Where(cond).
+		Select("sub_issue.parent_id AS parent_id, issue.is_closed AS is_closed, COUNT(*) AS count").
+		GroupBy("sub_issue.parent_id, issue.is_closed").
+		Find(&counts); err != nil {
+		return nil, err
+	}
+	for _, c := range counts {
+		if progress[c.ParentID] == nil {
+			progress[c.ParentID] = &SubIssueProgress{}
+		}
+		progress[c.ParentID].Total += c.Count
+		if c.IsClosed {
+			progress[c.ParentID].Closed += c.Count
+		}
+	}
+	return progress, nil
+}
+
+// SetCloseWithSubIssues sets whether the issue is closed automatically when all its sub-issues are closed
+func SetCloseWithSubIssues(ctx context.Context, issue *Issue, closeWithSubIssues bool) error {
+	issue.CloseWithSubIssues = closeWithSubIssues
+	_, err := db.GetEngine(ctx).ID(issue.ID).Cols("close_with_sub_issues").NoAutoTime().Update(issue)
+	return err
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issues_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subIssueIDs(t *testing.T, parentID int64) []int64 {
+	subIssues, err := issues_model.GetSubIssues(db.DefaultContext, parentID)
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(subIssues))
+	for _, subIssue := range subIssues {
+		ids = append(ids, subIssue.ID)
+	}
+	return ids
+}
+
+func TestSubIssues(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	issue1 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 1})
+	pull2 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 2})
+	issue4 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 4})
+	issue5 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 5})
+	issue6 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 6})
+	issue7 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 7})
+
+	// issue 4 belongs to another repository of the same owner
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue1, issue5))
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue1, issue4))
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue4, issue7))
+	assert.Equal(t, []int64{5, 4}, subIssueIDs(t, issue1.ID))
+	unittest.AssertExistsAndLoadBean(t, &issues_model.Comment{Type: issues_model.CommentTypeAddSubIssue, IssueID: issue1.ID, DependentIssueID: issue5.ID})
+	unittest.AssertExistsAndLoadBean(t, &issues_model.Comment{Type: issues_model.CommentTypeAddParentIssue, IssueID: issue5.ID, DependentIssueID: issue1.ID})
+
+	err := issues_model.AddSubIssue(db.DefaultContext, doer, issue1, issue5)
+	assert.True(t, issues_model.IsErrSubIssueExists(err))
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue7, issue5)
+	assert.True(t, issues_model.IsErrSubIssueHasParent(err))
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue7, issue1)
+	assert.True(t, issues_model.IsErrCircularSubIssue(err))
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue6, issue6)
+	assert.True(t, issues_model.IsErrCircularSubIssue(err))
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue1, pull2)
+	assert.True(t, issues_model.IsErrSubIssueNotAllowed(err))
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue1, issue6)
+	assert.True(t, issues_model.IsErrSubIssueNotAllowed(err))
+
+	progress, err := issues_model.GetSubIssueProgress(db.DefaultContext, doer, issue1.ID, issue4.ID, issue5.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]*issues_model.SubIssueProgress{
+		issue1.ID: {Total: 2, Closed: 2},
+		issue4.ID: {Total: 1, Closed: 0},
+	}, progress)
+	assert.Equal(t, 100, progress[issue1.ID].Percentage())
+	open, err := issues_model.HasOpenSubIssues(db.DefaultContext, issue1.ID)
+	require.NoError(t, err)
+	assert.False(t, open)
+	open, err = issues_model.HasOpenSubIssues(db.DefaultContext, issue4.ID)
+	require.NoError(t, err)
+	assert.True(t, open)
+
+	// the sub-issues of the private repository are not counted for the other users
+	progress, err = issues_model.GetSubIssueProgress(db.DefaultContext, nil, issue1.ID, issue4.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]*issues_model.SubIssueProgress{
+		issue1.ID: {Total: 1, Closed: 1},
+	}, progress)
+
+	require.NoError(t, issues_model.MoveSubIssue(db.DefaultContext, issue1, issue4, 0))
+	assert.Equal(t, []int64{4, 5}, subIssueIDs(t, issue1.ID))
+	require.NoError(t, issues_model.MoveSubIssue(db.DefaultContext, issue1, issue4, 10))
+	assert.Equal(t, []int64{5, 4}, subIssueIDs(t, issue1.ID))
+
+	parent, err := issues_model.GetParentIssue(db.DefaultContext, issue7.ID)
+	require.NoError(t, err)
+	assert.Equal(t, issue4.ID, parent.ID)
+
+	require.NoError(t, issues_model.RemoveSubIssue(db.DefaultContext, doer, issue1, issue5))
+	assert.Equal(t, []int64{4}, subIssueIDs(t, issue1.ID))
+	unittest.AssertExistsAndLoadBean(t, &issues_model.Comment{Type: issues_model.CommentTypeRemoveParentIssue, IssueID: issue5.ID, DependentIssueID: issue1.ID})
+	parent, err = issues_model.GetParentIssue(db.DefaultContext, issue5.ID)
+	require.NoError(t, err)
+	assert.Nil(t, parent)
+
+	err = issues_model.RemoveSubIssue(db.DefaultContext, doer, issue1, issue5)
+	assert.True(t, issues_model.IsErrSubIssueNotExist(err))
+	err = issues_model.MoveSubIssue(db.DefaultContext, issue1, issue5, 0)
+	assert.True(t, issues_model.IsErrSubIssueNotExist(err))
+}
+
+func TestSubIssueDepth(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.Service.MaxSubIssueDepth, 2)()
+
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	issue1 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 1})
+	issue4 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 4})
+	issue7 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 7})
+	issue10 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 10})
+
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue1, issue4))
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue4, issue7))
+
+	// issue 10 would be on the third level
+	err := issues_model.AddSubIssue(db.DefaultContext, doer, issue7, issue10)
+	assert.True(t, issues_model.IsErrSubIssueTooDeep(err))
+	// and so would issue 7 if issue 1 became a sub-issue of issue 10
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue10, issue1)
+	assert.True(t, issues_model.IsErrSubIssueTooDeep(err))
+
+	require.NoError(t, issues_model.AddSubIssue(db.DefaultContext, doer, issue4, issue10))
+
+	// the walk through the ancestors ends even if they were made a cycle
+	require.NoError(t, db.Insert(db.DefaultContext, &issues_model.SubIssue{UserID: doer.ID, ParentID: issue7.ID, IssueID: issue1.ID}))
+	issue5 := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: 5})
+	err = issues_model.AddSubIssue(db.DefaultContext, doer, issue7, issue5)
+	assert.True(t, issues_model.IsErrSubIssueTooDeep(err))
+}
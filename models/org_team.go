@@ -322,6 +322,9 @@ func DeleteTeam(ctx context.Context, t *organization.Team) error {
 				return err
 			}
 		}
+		if err := git_model.RemoveTeamIDFromOrgRulesets(ctx, t.OrgID, t.ID); err != nil {
+			return err
+		}
 	}
 
 	if !t.IncludesAllRepositories {
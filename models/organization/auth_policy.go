@@ -0,0 +1,195 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package organization
+
+import (
+	"context"
+	"fmt"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// AuthPolicy represents the authentication requirements an organization sets for its members
+type AuthPolicy struct {
+	ID                   int64              `xorm:"pk autoincr"`
+	OrgID                int64              `xorm:"UNIQUE NOT NULL"`
+	RequireTwoFactor     bool               `xorm:"NOT NULL DEFAULT false"`
+	RequiredAuthSourceID int64              `xorm:"NOT NULL DEFAULT 0"`
+	GracePeriodEndUnix   timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix          timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix          timeutil.TimeStamp `xorm:"updated"`
+}
+
+func init() {
+	db.RegisterModel(new(AuthPolicy))
+}
+
+// TableName returns the table name of AuthPolicy
+func (AuthPolicy) TableName() string {
+	return "org_auth_policy"
+}
+
+// IsEnabled returns whether the policy has any requirement
+func (p *AuthPolicy) IsEnabled() bool {
+	return p.RequireTwoFactor || p.RequiredAuthSourceID != 0
+}
+
+// InGracePeriod returns whether the members who do not fulfil the requirements are still only warned
+func (p *AuthPolicy) InGracePeriod() bool {
+	return p.GracePeriodEndUnix > timeutil.TimeStampNow()
+}
+
+func genAuthPolicyCacheKey(orgID int64) string {
+	return fmt.Sprintf("org_%d.auth_policy", orgID)
+}
+
+// GetAuthPolicy returns the authentication policy of the organization, an organization without policy gets one
+// without requirements. It is cached as it is checked on every access to the resources of the organization.
+func GetAuthPolicy(ctx context.Context, orgID int64) (*AuthPolicy, error) {
+	value, err := cache.GetString(genAuthPolicyCacheKey(orgID), func() (string, error) {
+		p, err := GetAuthPolicyNoCache(ctx, orgID)
+		if err != nil {
+			return "", err
+		}
+		bs, err := json.Marshal(p)
+		return string(bs), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := &AuthPolicy{}
+	if err := json.Unmarshal([]byte(value), p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetAuthPolicyNoCache returns the authentication policy of the organization without using the cache
+func GetAuthPolicyNoCache(ctx context.Context, orgID int64) (*AuthPolicy, error) {
+	p := &AuthPolicy{OrgID: orgID}
+	if _, err := db.GetEngine(ctx).Where("org_id = ?", orgID).Get(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdateAuthPolicy stores the authentication policy of the organization
+func UpdateAuthPolicy(ctx context.Context, p *AuthPolicy) error {
+	defer cache.Remove(genAuthPolicyCacheKey(p.OrgID))
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		exists, err := db.GetEngine(ctx).Where("org_id = ?", p.OrgID).Exist(&AuthPolicy{})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return db.Insert(ctx, p)
+		}
+		_, err = db.GetEngine(ctx).Where("org_id = ?", p.OrgID).
+			Cols("require_two_factor", "required_auth_source_id", "grace_period_end_unix").
+			Update(p)
+		return err
+	})
+}
+
+// AuthPolicyViolation lists the requirements of an authentication policy a member does not fulfil
+type AuthPolicyViolation struct {
+	MissingTwoFactor  bool
+	MissingAuthSource bool
+}
+
+// IsViolated returns whether any requirement is not fulfilled
+func (v *AuthPolicyViolation) IsViolated() bool {
+	return v.MissingTwoFactor || v.MissingAuthSource
+}
+
+// CheckMember returns the requirements of the policy the user does not fulfil. signInSourceID is the authentication
+// source the user signed in with in the current web session (0 for a local sign-in, negative if unknown), it is None
+// for the access without session like the tokens and git, for which a link of the account to the source is enough.
+func (p *AuthPolicy) CheckMember(ctx context.Context, u *user_model.User, signInSourceID optional.Option[int64]) (*AuthPolicyViolation, error) {
+	v := &AuthPolicyViolation{}
+	if p.RequireTwoFactor {
+		hasTOTP, err := auth_model.HasTwoFactorByUID(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		hasWebAuthn, err := auth_model.HasWebAuthnRegistrationsByUID(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		v.MissingTwoFactor = !hasTOTP && !hasWebAuthn
+	}
+	if p.RequiredAuthSourceID != 0 {
+		if signInSourceID.Has() {
+			v.MissingAuthSource = signInSourceID.Value() != p.RequiredAuthSourceID
+		} else if u.LoginSource != p.RequiredAuthSourceID {
+			linked, err := db.GetEngine(ctx).
+				Where("user_id = ? AND login_source_id = ?", u.ID, p.RequiredAuthSourceID).
+				Exist(&user_model.ExternalLoginUser{})
+			if err != nil {
+				return nil, err
+			}
+			v.MissingAuthSource = !linked
+		}
+	}
+	return v, nil
+}
+
+// CheckAuthPolicy checks the user against the authentication policy of the organization if the user is a member of
+// it. The returned violation is nil if the policy does not apply to the user.
+func CheckAuthPolicy(ctx context.Context, orgID int64, u *user_model.User, signInSourceID optional.Option[int64]) (*AuthPolicy, *AuthPolicyViolation, error) {
+	if u == nil || u.IsAdmin {
+		return nil, nil, nil
+	}
+	p, err := GetAuthPolicy(ctx, orgID)
+	if err != nil || !p.IsEnabled() {
+		return nil, nil, err
+	}
+	isMember, err := IsOrganizationMember(ctx, orgID, u.ID)
+	if err != nil || !isMember {
+		return nil, nil, err
+	}
+	v, err := p.CheckMember(ctx, u, signInSourceID)
+	if err != nil || !v.IsViolated() {
+		return nil, nil, err
+	}
+	return p, v, nil
+}
+
+// NonCompliantMember is a member of an organization who does not fulfil its authentication policy
+type NonCompliantMember struct {
+	User      *user_model.User
+	Violation *AuthPolicyViolation
+}
+
+// FindNonCompliantMembers returns the members of the organization who do not fulfil the authentication policy,
+// the authentication source of their sessions is unknown so they fulfil it with a link to the source
+func FindNonCompliantMembers(ctx context.Context, p *AuthPolicy) ([]*NonCompliantMember, error) {
+	if !p.IsEnabled() {
+		return nil, nil
+	}
+	members, _, err := FindOrgMembers(ctx, &FindOrgMembersOpts{OrgID: p.OrgID})
+	if err != nil {
+		return nil, err
+	}
+	var nonCompliant []*NonCompliantMember
+	for _, u := range members {
+		if u.IsAdmin {
+			continue
+		}
+		v, err := p.CheckMember(ctx, u, optional.None[int64]())
+		if err != nil {
+			return nil, err
+		}
+		if v.IsViolated() {
+			nonCompliant = append(nonCompliant, &NonCompliantMember{User: u, Violation: v})
+		}
+	}
+	return nonCompliant, nil
+}
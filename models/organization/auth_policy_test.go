@@ -0,0 +1,121 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package organization_test
+
+import (
+	"testing"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthPolicy(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	p, err := organization.GetAuthPolicy(db.DefaultContext, 3)
+	require.NoError(t, err)
+	assert.False(t, p.IsEnabled())
+
+	p.RequireTwoFactor = true
+	p.GracePeriodEndUnix = timeutil.TimeStampNow().Add(int64(time.Hour.Seconds()))
+	require.NoError(t, organization.UpdateAuthPolicy(db.DefaultContext, p))
+	p, err = organization.GetAuthPolicy(db.DefaultContext, 3)
+	require.NoError(t, err)
+	assert.True(t, p.IsEnabled())
+	assert.True(t, p.InGracePeriod())
+
+	p.GracePeriodEndUnix = 0
+	require.NoError(t, organization.UpdateAuthPolicy(db.DefaultContext, p))
+	p, err = organization.GetAuthPolicy(db.DefaultContext, 3)
+	require.NoError(t, err)
+	assert.False(t, p.InGracePeriod())
+}
+
+func TestCheckAuthPolicy(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	admin := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 1})
+	member := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+	nonMember := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 5})
+
+	require.NoError(t, organization.UpdateAuthPolicy(db.DefaultContext, &organization.AuthPolicy{OrgID: 3, RequireTwoFactor: true}))
+
+	t.Run("TwoFactor", func(t *testing.T) {
+		for _, u := range []*user_model.User{admin, nonMember} {
+			_, v, err := organization.CheckAuthPolicy(db.DefaultContext, 3, u, optional.None[int64]())
+			require.NoError(t, err)
+			assert.Nil(t, v, u.Name)
+		}
+
+		_, v, err := organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.None[int64]())
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		assert.True(t, v.MissingTwoFactor)
+		assert.False(t, v.MissingAuthSource)
+
+		p, err := organization.GetAuthPolicy(db.DefaultContext, 3)
+		require.NoError(t, err)
+		nonCompliant, err := organization.FindNonCompliantMembers(db.DefaultContext, p)
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(nonCompliant))
+		for _, m := range nonCompliant {
+			ids = append(ids, m.User.ID)
+		}
+		assert.Contains(t, ids, member.ID)
+
+		_, err = auth_model.CreateCredential(db.DefaultContext, member.ID, "Security key", &webauthn.Credential{ID: []byte("Security key")}, false)
+		require.NoError(t, err)
+		_, v, err = organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.None[int64]())
+		require.NoError(t, err)
+		assert.Nil(t, v)
+	})
+
+	t.Run("AuthSource", func(t *testing.T) {
+		require.NoError(t, organization.UpdateAuthPolicy(db.DefaultContext, &organization.AuthPolicy{OrgID: 3, RequiredAuthSourceID: 1}))
+
+		// the web session must have been signed in through the source
+		_, v, err := organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.Some[int64](0))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		assert.True(t, v.MissingAuthSource)
+		_, v, err = organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.Some[int64](1))
+		require.NoError(t, err)
+		assert.Nil(t, v)
+
+		// the sessions which do not tell their source, like the ones restored from a remember-me cookie, do not
+		// fulfil the policy even for the accounts of the source
+		memberOfSource := *member
+		memberOfSource.LoginSource = 1
+		_, v, err = organization.CheckAuthPolicy(db.DefaultContext, 3, &memberOfSource, optional.Some[int64](-1))
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		assert.True(t, v.MissingAuthSource)
+
+		// without session the account must be linked to the source
+		_, v, err = organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.None[int64]())
+		require.NoError(t, err)
+		require.NotNil(t, v)
+		assert.True(t, v.MissingAuthSource)
+
+		require.NoError(t, user_model.LinkExternalToUser(db.DefaultContext, member, &user_model.ExternalLoginUser{
+			ExternalID:    "member",
+			UserID:        member.ID,
+			LoginSourceID: 1,
+			Provider:      "provider",
+		}))
+		_, v, err = organization.CheckAuthPolicy(db.DefaultContext, 3, member, optional.None[int64]())
+		require.NoError(t, err)
+		assert.Nil(t, v)
+	})
+}
@@ -6,6 +6,7 @@ package organization
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
@@ -54,6 +55,15 @@ func IsOrganizationOwner(ctx context.Context, orgID, uid int64) (bool, error) {
 	return IsTeamMember(ctx, orgID, ownerTeam.ID, uid)
 }
 
+// IsOwnerOfAnyOrganization returns true if given user is in the owner team of at least one organization.
+func IsOwnerOfAnyOrganization(ctx context.Context, uid int64) (bool, error) {
+	return db.GetEngine(ctx).
+		Join("INNER", "team", "team.id = team_user.team_id").
+		Where("team_user.uid = ?", uid).
+		And("team.lower_name = ?", strings.ToLower(OwnerTeamName)).
+		Exist(new(TeamUser))
+}
+
 // IsOrganizationAdmin returns true if given user is in the owner team or an admin team.
 func IsOrganizationAdmin(ctx context.Context, orgID, uid int64) (bool, error) {
 	teams, err := GetUserOrgTeams(ctx, orgID, uid)
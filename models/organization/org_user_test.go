@@ -64,6 +64,18 @@ func TestIsUserOrgOwner(t *testing.T) {
 	}
 }
 
+func TestIsOwnerOfAnyOrganization(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	is, err := organization.IsOwnerOfAnyOrganization(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.True(t, is)
+
+	is, err = organization.IsOwnerOfAnyOrganization(db.DefaultContext, 4)
+	assert.NoError(t, err)
+	assert.False(t, is)
+}
+
 func testIsUserOrgOwner(t *testing.T, uid, orgID int64, expected bool) {
 	user, err := user_model.GetUserByID(db.DefaultContext, uid)
 	assert.NoError(t, err)
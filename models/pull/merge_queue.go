@@ -0,0 +1,166 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// MergeQueueEntry represents a pull request in the merge queue of its base branch.
+// The entries of a queue are ordered by their ID, every entry is tested with a speculative
+// merge commit of the pull request on top of the commit of the entry ahead of it.
+type MergeQueueEntry struct {
+	ID           int64                 `xorm:"pk autoincr"`
+	RepoID       int64                 `xorm:"INDEX(queue) NOT NULL"`
+	BaseBranch   string                `xorm:"INDEX(queue) NOT NULL"`
+	PullID       int64                 `xorm:"UNIQUE NOT NULL"`
+	DoerID       int64                 `xorm:"INDEX NOT NULL"`
+	Doer         *user_model.User      `xorm:"-"`
+	MergeStyle   repo_model.MergeStyle `xorm:"varchar(30)"`
+	Message      string                `xorm:"LONGTEXT"`
+	HeadCommitID string                `xorm:"VARCHAR(64)"`       // head of the pull request the speculative merge commit was created from
+	BaseCommitID string                `xorm:"VARCHAR(64)"`       // commit the speculative merge commit was created on
+	CommitID     string                `xorm:"VARCHAR(64) INDEX"` // speculative merge commit, empty if not created yet
+	CreatedUnix  timeutil.TimeStamp    `xorm:"created"`
+	UpdatedUnix  timeutil.TimeStamp    `xorm:"updated"`
+}
+
+// TableName return database table name for xorm
+func (MergeQueueEntry) TableName() string {
+	return "pull_merge_queue"
+}
+
+func init() {
+	db.RegisterModel(new(MergeQueueEntry))
+}
+
+// IsTesting returns whether the speculative merge commit of the entry has been created
+func (entry *MergeQueueEntry) IsTesting() bool {
+	return entry.CommitID != ""
+}
+
+// LoadDoer loads the user who added the pull request to the merge queue
+func (entry *MergeQueueEntry) LoadDoer(ctx context.Context) (err error) {
+	if entry.Doer != nil {
+		return nil
+	}
+	entry.Doer, err = user_model.GetPossibleUserByID(ctx, entry.DoerID)
+	return err
+}
+
+// ErrAlreadyInMergeQueue represents an error that a pull request is already in a merge queue
+type ErrAlreadyInMergeQueue struct {
+	PullID int64
+}
+
+func (err ErrAlreadyInMergeQueue) Error() string {
+	return fmt.Sprintf("pull request is already in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// IsErrAlreadyInMergeQueue checks if an error is a ErrAlreadyInMergeQueue.
+func IsErrAlreadyInMergeQueue(err error) bool {
+	_, ok := err.(ErrAlreadyInMergeQueue)
+	return ok
+}
+
+// AddToMergeQueue adds a pull request to the end of the merge queue of its base branch
+func AddToMergeQueue(ctx context.Context, doer *user_model.User, repoID int64, baseBranch string, pullID int64, style repo_model.MergeStyle, message string) (*MergeQueueEntry, error) {
+	if exists, _, err := GetMergeQueueEntryByPullID(ctx, pullID); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, ErrAlreadyInMergeQueue{PullID: pullID}
+	}
+
+	entry := &MergeQueueEntry{
+		RepoID:     repoID,
+		BaseBranch: baseBranch,
+		PullID:     pullID,
+		DoerID:     doer.ID,
+		Doer:       doer,
+		MergeStyle: style,
+		Message:    message,
+	}
+	if err := db.Insert(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetMergeQueueEntryByPullID gets the merge queue entry of a pull request
+func GetMergeQueueEntryByPullID(ctx context.Context, pullID int64) (bool, *MergeQueueEntry, error) {
+	entry := &MergeQueueEntry{}
+	exists, err := db.GetEngine(ctx).Where("pull_id = ?", pullID).Get(entry)
+	if err != nil || !exists {
+		return false, nil, err
+	}
+	return true, entry, nil
+}
+
+// GetMergeQueue returns the entries of the merge queue of a branch in their order
+func GetMergeQueue(ctx context.Context, repoID int64, baseBranch string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 10)
+	return entries, db.GetEngine(ctx).
+		Where("repo_id = ? AND base_branch = ?", repoID, baseBranch).
+		OrderBy("id").
+		Find(&entries)
+}
+
+// GetMergeQueuePosition returns the 1-based position of an entry in its merge queue
+func GetMergeQueuePosition(ctx context.Context, entry *MergeQueueEntry) (int64, error) {
+	return db.GetEngine(ctx).
+		Where("repo_id = ? AND base_branch = ? AND id <= ?", entry.RepoID, entry.BaseBranch, entry.ID).
+		Count(&MergeQueueEntry{})
+}
+
+// FindMergeQueueEntries returns the entries of all merge queues of a repository, ordered by branch and position
+func FindMergeQueueEntries(ctx context.Context, repoID int64) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 10)
+	return entries, db.GetEngine(ctx).
+		Where("repo_id = ?", repoID).
+		OrderBy("base_branch, id").
+		Find(&entries)
+}
+
+// GetMergeQueueBranches returns the branches of a repository that have pull requests in their merge queue
+func GetMergeQueueBranches(ctx context.Context, repoID int64) ([]string, error) {
+	branches := make([]string, 0, 2)
+	return branches, db.GetEngine(ctx).Table("pull_merge_queue").
+		Where("repo_id = ?", repoID).
+		Distinct("base_branch").
+		OrderBy("base_branch").
+		Find(&branches)
+}
+
+// GetMergeQueueEntriesByCommitID returns the entries whose speculative merge commit is the given commit
+func GetMergeQueueEntriesByCommitID(ctx context.Context, repoID int64, commitID string) ([]*MergeQueueEntry, error) {
+	entries := make([]*MergeQueueEntry, 0, 1)
+	return entries, db.GetEngine(ctx).
+		Where(builder.Eq{"repo_id": repoID, "commit_id": commitID}).
+		Find(&entries)
+}
+
+// UpdateMergeQueueEntryCommits updates the commits of the speculative merge commit of an entry
+func UpdateMergeQueueEntryCommits(ctx context.Context, entry *MergeQueueEntry) error {
+	_, err := db.GetEngine(ctx).ID(entry.ID).Cols("head_commit_id", "base_commit_id", "commit_id").Update(entry)
+	return err
+}
+
+// RemoveFromMergeQueue removes a pull request from its merge queue
+func RemoveFromMergeQueue(ctx context.Context, pullID int64) error {
+	deleted, err := db.GetEngine(ctx).Where("pull_id = ?", pullID).Delete(&MergeQueueEntry{})
+	if err != nil {
+		return err
+	} else if deleted == 0 {
+		return db.ErrNotExist{Resource: "merge_queue", ID: pullID}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// RepoMaintenance is the state of the background maintenance of a repository, which is scheduled by the number of
+// pushes since its last run
+type RepoMaintenance struct { //nolint:revive
+	ID           int64              `xorm:"pk autoincr"`
+	RepoID       int64              `xorm:"UNIQUE"`
+	Repo         *Repository        `xorm:"-"`
+	Pushes       int64              `xorm:"NOT NULL DEFAULT 0"`
+	LastRun      timeutil.TimeStamp `xorm:"INDEX"`
+	LastDuration time.Duration
+	LastError    string `xorm:"TEXT"`
+}
+
+func init() {
+	db.RegisterModel(new(RepoMaintenance))
+}
+
+// HasFailed returns true if the last maintenance of the repository failed
+func (m *RepoMaintenance) HasFailed() bool {
+	return m.LastError != ""
+}
+
+// LoadRepo loads the repository of the maintenance
+func (m *RepoMaintenance) LoadRepo(ctx context.Context) (err error) {
+	if m.Repo == nil {
+		m.Repo, err = GetRepositoryByID(ctx, m.RepoID)
+	}
+	return err
+}
+
+// CountRepoMaintenancePush adds a push to the pushes since the last maintenance of the repository. The state of the
+// repository is inserted by its first push, concurrent first pushes only insert it once.
+func CountRepoMaintenancePush(ctx context.Context, repoID int64) error {
+	incrPushes := func() (int64, error) {
+		return db.GetEngine(ctx).Where("repo_id = ?", repoID).Incr("pushes").Update(new(RepoMaintenance))
+	}
+
+	updateCount, err := incrPushes()
+	if err != nil || updateCount != 0 {
+		return err
+	}
+
+	// the insertion fails if a concurrent push inserted the state first, which is incremented in both cases
+	_, errIns := db.GetEngine(ctx).Insert(&RepoMaintenance{RepoID: repoID})
+	updateCount, err = incrPushes()
+	if err != nil {
+		return err
+	}
+	if updateCount == 0 {
+		if errIns != nil {
+			return errIns
+		}
+		return fmt.Errorf("no maintenance state of repository %d to count the push in", repoID)
+	}
+	return nil
+}
+
+// GetRepoMaintenance returns the maintenance state of the repository, which is empty if it has never been pushed to
+func GetRepoMaintenance(ctx context.Context, repoID int64) (*RepoMaintenance, error) {
+	m, exist, err := db.Get[RepoMaintenance](ctx, builder.Eq{"repo_id": repoID})
+	if err != nil {
+		return nil, err
+	} else if !exist {
+		return &RepoMaintenance{RepoID: repoID}, nil
+	}
+	return m, nil
+}
+
+// FindRepoMaintenanceOptions are the options to list the maintenance state of repositories
+type FindRepoMaintenanceOptions struct {
+	db.ListOptions
+	// MinPushes lists the repositories with at least this many pushes since their last maintenance
+	MinPushes int64
+	// LastRunBefore also lists the repositories with any push whose last maintenance ran before this time
+	LastRunBefore timeutil.TimeStamp
+	OnlyFailed    bool
+}
+
+func (opts FindRepoMaintenanceOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.MinPushes > 0 {
+		due := builder.Cond(builder.Gte{"pushes": opts.MinPushes})
+		if opts.LastRunBefore > 0 {
+			due = due.Or(builder.Gt{"pushes": 0}.And(builder.Lt{"last_run": opts.LastRunBefore}))
+		}
+		cond = cond.And(due)
+	}
+	if opts.OnlyFailed {
+		cond = cond.And(builder.Neq{"last_error": ""})
+	}
+	return cond
+}
+
+func (opts FindRepoMaintenanceOptions) ToOrders() string {
+	if opts.MinPushes > 0 {
+		return "pushes DESC, id ASC"
+	}
+	return "last_run DESC, id ASC"
+}
+
+// FinishRepoMaintenance records the result of a maintenance which started when the repository had the given number
+// of pushes since the last maintenance, the pushes during the maintenance are kept for the next one
+func FinishRepoMaintenance(ctx context.Context, m *RepoMaintenance, pushes int64) error {
+	if m.ID == 0 {
+		_, err := db.GetEngine(ctx).Insert(m)
+		return err
+	}
+	_, err := db.GetEngine(ctx).ID(m.ID).Decr("pushes", pushes).Cols("last_run", "last_duration", "last_error").Update(m)
+	return err
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo_test
+
+import (
+	"sync"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoMaintenance(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	m, err := repo_model.GetRepoMaintenance(db.DefaultContext, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, m.ID)
+	assert.EqualValues(t, 0, m.Pushes)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo_model.CountRepoMaintenancePush(db.DefaultContext, 1))
+	}
+	require.NoError(t, repo_model.CountRepoMaintenancePush(db.DefaultContext, 2))
+
+	due, err := db.Find[repo_model.RepoMaintenance](db.DefaultContext, repo_model.FindRepoMaintenanceOptions{MinPushes: 2})
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.EqualValues(t, 1, due[0].RepoID)
+	assert.EqualValues(t, 3, due[0].Pushes)
+
+	due, err = db.Find[repo_model.RepoMaintenance](db.DefaultContext, repo_model.FindRepoMaintenanceOptions{MinPushes: 2, LastRunBefore: timeutil.TimeStampNow()})
+	require.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	// a push during the maintenance is kept for the next one
+	m = due[0]
+	require.NoError(t, repo_model.CountRepoMaintenancePush(db.DefaultContext, 1))
+	m.LastRun = timeutil.TimeStampNow()
+	m.LastError = "fatal: bad object"
+	require.NoError(t, repo_model.FinishRepoMaintenance(db.DefaultContext, m, 3))
+
+	m, err = repo_model.GetRepoMaintenance(db.DefaultContext, 1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, m.Pushes)
+	assert.True(t, m.HasFailed())
+
+	failed, err := db.Find[repo_model.RepoMaintenance](db.DefaultContext, repo_model.FindRepoMaintenanceOptions{OnlyFailed: true})
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.EqualValues(t, 1, failed[0].RepoID)
+}
+
+func TestCountRepoMaintenancePushConcurrently(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo_model.CountRepoMaintenancePush(db.DefaultContext, 3))
+		}()
+	}
+	wg.Wait()
+
+	m, err := repo_model.GetRepoMaintenance(db.DefaultContext, 3)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, m.Pushes)
+}
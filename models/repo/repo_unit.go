@@ -160,6 +160,7 @@ type PullRequestsConfig struct {
 	DefaultDeleteBranchAfterMerge bool
 	DefaultMergeStyle             MergeStyle
 	DefaultAllowMaintainerEdit    bool
+	RebaseStackedPullsOnMerge     bool
 }
 
 // FromDB fills up a PullRequestsConfig from serialized format.
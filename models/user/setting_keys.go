@@ -14,4 +14,7 @@ const (
 	UserActivityPubPrivPem = "activitypub.priv_pem"
 	// UserActivityPubPubPem is user's public key
 	UserActivityPubPubPem = "activitypub.pub_pem"
+	// SettingsKeySessionsGeneration is incremented when the sessions of the user are revoked, sessions signed in with
+	// an older generation are invalid
+	SettingsKeySessionsGeneration = "auth.sessions_generation"
 )
@@ -24,6 +24,7 @@ const (
 	GithubEventGollum                   = "gollum"
 	GithubEventSchedule                 = "schedule"
 	GithubEventWorkflowDispatch         = "workflow_dispatch"
+	GithubEventMergeGroup               = "merge_group"
 )
 
 // IsDefaultBranchWorkflow returns true if the event only triggers workflows on the default branch
@@ -132,6 +132,34 @@ func TestDetectMatched(t *testing.T) {
 			yamlOn:       "on: workflow_dispatch",
 			expected:     true,
 		},
+		{
+			desc:         "HookEventMergeGroup(merge_group) matches GithubEventMergeGroup(merge_group)",
+			triggedEvent: webhook_module.HookEventMergeGroup,
+			payload:      &api.MergeGroupPayload{Action: api.HookMergeGroupChecksRequested, MergeGroup: &api.MergeGroup{BaseRef: "refs/heads/main"}},
+			yamlOn:       "on: merge_group",
+			expected:     true,
+		},
+		{
+			desc:         "HookEventMergeGroup(merge_group) matches GithubEventMergeGroup(merge_group) with types and branches",
+			triggedEvent: webhook_module.HookEventMergeGroup,
+			payload:      &api.MergeGroupPayload{Action: api.HookMergeGroupChecksRequested, MergeGroup: &api.MergeGroup{BaseRef: "refs/heads/main"}},
+			yamlOn:       "on:\n  merge_group:\n    types: [checks_requested]\n    branches: [main]",
+			expected:     true,
+		},
+		{
+			desc:         "HookEventMergeGroup(merge_group) doesn't match GithubEventMergeGroup(merge_group) of other branches",
+			triggedEvent: webhook_module.HookEventMergeGroup,
+			payload:      &api.MergeGroupPayload{Action: api.HookMergeGroupChecksRequested, MergeGroup: &api.MergeGroup{BaseRef: "refs/heads/main"}},
+			yamlOn:       "on:\n  merge_group:\n    branches: [release/*]",
+			expected:     false,
+		},
+		{
+			desc:         "HookEventMergeGroup(merge_group) doesn't match GithubEventPush(push)",
+			triggedEvent: webhook_module.HookEventMergeGroup,
+			payload:      &api.MergeGroupPayload{Action: api.HookMergeGroupChecksRequested, MergeGroup: &api.MergeGroup{BaseRef: "refs/heads/main"}},
+			yamlOn:       "on: push",
+			expected:     false,
+		},
 	}
 
 	for _, tc := range testCases {
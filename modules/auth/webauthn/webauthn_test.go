@@ -23,3 +23,16 @@ func TestInit(t *testing.T) {
 	assert.Equal(t, setting.AppName, WebAuthn.Config.RPDisplayName)
 	assert.Equal(t, rpOrigin, WebAuthn.Config.RPOrigins)
 }
+
+func TestUserIDFromWebAuthnID(t *testing.T) {
+	for _, id := range []int64{1, 2, 1000, 1 << 40} {
+		uid, err := UserIDFromWebAuthnID((&User{ID: id}).WebAuthnID())
+		assert.NoError(t, err)
+		assert.Equal(t, id, uid)
+	}
+
+	_, err := UserIDFromWebAuthnID(nil)
+	assert.Error(t, err)
+	_, err = UserIDFromWebAuthnID([]byte{0x80})
+	assert.Error(t, err)
+}
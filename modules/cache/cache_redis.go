@@ -4,6 +4,7 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -116,18 +117,27 @@ func (c *RedisCacher) IsExist(key string) bool {
 
 // Flush deletes all cached data.
 func (c *RedisCacher) Flush() error {
+	ctx := graceful.GetManager().HammerContext()
 	if c.occupyMode {
-		return c.c.FlushDB(graceful.GetManager().HammerContext()).Err()
+		return nosql.ForEachRedisMaster(ctx, c.c, func(ctx context.Context, client redis.UniversalClient) error {
+			return client.FlushDB(ctx).Err()
+		})
 	}
 
-	keys, err := c.c.HKeys(graceful.GetManager().HammerContext(), c.hsetName).Result()
+	keys, err := c.c.HKeys(ctx, c.hsetName).Result()
 	if err != nil {
 		return err
 	}
-	if err = c.c.Del(graceful.GetManager().HammerContext(), keys...).Err(); err != nil {
+	// the keys are deleted one by one, because a Redis Cluster can't delete keys of different slots at once
+	if _, err = c.c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	}); err != nil {
 		return err
 	}
-	return c.c.Del(graceful.GetManager().HammerContext(), c.hsetName).Err()
+	return c.c.Del(ctx, c.hsetName).Err()
 }
 
 // StartAndGC starts GC routine based on config string settings.
@@ -13,6 +13,8 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -298,7 +300,10 @@ func syncGitConfig() (err error) {
 		if err = configSet("uploadpack.allowfilter", "true"); err != nil {
 			return err
 		}
-		err = configSet("uploadpack.allowAnySHA1InWant", "true")
+		if err = configSet("uploadpack.allowAnySHA1InWant", "true"); err != nil {
+			return err
+		}
+		err = syncPartialCloneFilters()
 	} else {
 		if err = configUnsetAll("uploadpack.allowfilter", "true"); err != nil {
 			return err
@@ -309,6 +314,31 @@ func syncGitConfig() (err error) {
 	return err
 }
 
+// partialCloneFilters are the object filters of partial clones which are allowed or denied by the settings
+var partialCloneFilters = []string{"blob:none", "blob:limit", "tree", "object:type", "sparse:oid", "combine"}
+
+// syncPartialCloneFilters only allows the object filters of the settings for partial clones, from git v2.28
+func syncPartialCloneFilters() error {
+	if CheckGitVersionAtLeast("2.28") != nil {
+		return nil
+	}
+
+	if err := configSet("uploadpackfilter.allow", "false"); err != nil {
+		return err
+	}
+	for _, filter := range partialCloneFilters {
+		allow := slices.Contains(setting.Git.PartialCloneFilters, filter)
+		if err := configSet("uploadpackfilter."+filter+".allow", strconv.FormatBool(allow)); err != nil {
+			return err
+		}
+	}
+
+	if setting.Git.PartialCloneMaxTreeDepth >= 0 {
+		return configSet("uploadpackfilter.tree.maxDepth", strconv.Itoa(setting.Git.PartialCloneMaxTreeDepth))
+	}
+	return configUnset("uploadpackfilter.tree.maxDepth")
+}
+
 // CheckGitVersionAtLeast check git version is at least the constraint version
 func CheckGitVersionAtLeast(atLeast string) error {
 	if err := loadGitVersion(); err != nil {
@@ -410,6 +440,23 @@ func configUnsetAll(key, value string) error {
 	return fmt.Errorf("failed to get git config %s, err: %w", key, err)
 }
 
+func configUnset(key string) error {
+	_, _, err := NewCommand(DefaultContext, "config", "--global", "--get-all").AddDynamicArguments(key).RunStdString(nil)
+	if err == nil {
+		// exist, need to remove
+		_, _, err = NewCommand(DefaultContext, "config", "--global", "--unset-all").AddDynamicArguments(key).RunStdString(nil)
+		if err != nil {
+			return fmt.Errorf("failed to unset git global config %s, err: %w", key, err)
+		}
+		return nil
+	}
+	if IsErrorExitCode(err, 1) {
+		// not exist
+		return nil
+	}
+	return fmt.Errorf("failed to get git config %s, err: %w", key, err)
+}
+
 // Fsck verifies the connectivity and validity of the objects in the database
 func Fsck(ctx context.Context, repoPath string, timeout time.Duration, args TrustedCmdArgs) error {
 	return NewCommand(ctx, "fsck").AddArguments(args...).Run(&RunOpts{Timeout: timeout, Dir: repoPath})
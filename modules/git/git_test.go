@@ -11,9 +11,11 @@ import (
 	"testing"
 
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func testRun(m *testing.M) error {
@@ -93,3 +95,28 @@ func TestSyncConfig(t *testing.T) {
 	assert.True(t, gitConfigContains("[sync-test]"))
 	assert.True(t, gitConfigContains("cfg-key-a = CfgValA"))
 }
+
+func TestSyncPartialCloneFilters(t *testing.T) {
+	if CheckGitVersionAtLeast("2.28") != nil {
+		t.Skip("object filters of partial clones are configured from git v2.28")
+	}
+	defer test.MockVariableValue(&setting.Git.PartialCloneFilters, []string{"blob:none", "tree"})()
+	defer test.MockVariableValue(&setting.Git.PartialCloneMaxTreeDepth, 0)()
+
+	require.NoError(t, syncPartialCloneFilters())
+	assert.True(t, gitConfigContains("[uploadpackfilter]"))
+	assert.True(t, gitConfigContains("allow = false"))
+	assert.True(t, gitConfigContains(`[uploadpackfilter "blob:none"]`))
+	assert.True(t, gitConfigContains("maxDepth = 0"))
+
+	value, _, err := NewCommand(DefaultContext, "config", "--global", "--get", "uploadpackfilter.blob:limit.allow").RunStdString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "false", strings.TrimSpace(value))
+	value, _, err = NewCommand(DefaultContext, "config", "--global", "--get", "uploadpackfilter.tree.allow").RunStdString(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "true", strings.TrimSpace(value))
+
+	setting.Git.PartialCloneMaxTreeDepth = -1
+	require.NoError(t, syncPartialCloneFilters())
+	assert.False(t, gitConfigContains("maxDepth"))
+}
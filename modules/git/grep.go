@@ -31,6 +31,8 @@ type GrepOptions struct {
 	ContextLineNumber int
 	IsFuzzy           bool
 	PathSpec          []setting.Glob
+	// PathspecList are passed to git as they are, so they may use pathspec magic like ":(exclude)"
+	PathspecList []string
 }
 
 func GrepSearch(ctx context.Context, repo *Repository, search string, opts GrepOptions) ([]*GrepResult, error) {
@@ -71,13 +73,15 @@ func GrepSearch(ctx context.Context, repo *Repository, search string, opts GrepO
 	files := make([]string, 0,
 		len(setting.Indexer.IncludePatterns)+
 			len(setting.Indexer.ExcludePatterns)+
-			len(opts.PathSpec))
+			len(opts.PathSpec)+
+			len(opts.PathspecList))
 	for _, expr := range append(setting.Indexer.IncludePatterns, opts.PathSpec...) {
 		files = append(files, ":"+expr.Pattern())
 	}
 	for _, expr := range setting.Indexer.ExcludePatterns {
 		files = append(files, ":^"+expr.Pattern())
 	}
+	files = append(files, opts.PathspecList...)
 	cmd.AddDynamicArguments(cmp.Or(opts.RefName, "HEAD")).AddDashesAndList(files...)
 
 	opts.MaxResultLimit = cmp.Or(opts.MaxResultLimit, 50)
@@ -131,3 +131,19 @@ func TestGrepRefs(t *testing.T) {
 	assert.Len(t, res, 1)
 	assert.Equal(t, res[0].LineCodes[0], "A")
 }
+
+func TestGrepPathspecList(t *testing.T) {
+	repo, err := openRepositoryWithDefaultContext(filepath.Join(testReposDir, "language_stats_repo"))
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	res, err := GrepSearch(context.Background(), repo, "void", GrepOptions{PathspecList: []string{":(icase)*HELLO*"}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "java-hello/main.java", res[0].Filename)
+
+	res, err = GrepSearch(context.Background(), repo, "world", GrepOptions{PathspecList: []string{":(exclude,glob)**/*.java", ":(exclude)*.p"}})
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "python-hello/hello.py", res[0].Filename)
+}
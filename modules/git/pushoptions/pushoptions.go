@@ -19,6 +19,13 @@ const (
 	AgitForcePush   = Key("force-push")
 	AgitTitle       = Key("title")
 	AgitDescription = Key("description")
+	AgitDraft       = Key("draft")
+	AgitReviewer    = Key("reviewer")
+	AgitLabel       = Key("label")
+	AgitMilestone   = Key("milestone")
+	AgitAssignee    = Key("assignee")
+
+	AgitMergeWhenChecksSucceed = Key("merge-when-checks-succeed")
 
 	envPrefix = "GIT_PUSH_OPTION"
 	EnvCount  = envPrefix + "_COUNT"
@@ -36,6 +43,7 @@ type Interface interface {
 
 	GetBool(key Key, def bool) bool
 	GetString(key Key) (val string, ok bool)
+	GetStrings(key Key) []string
 }
 
 type gitPushOptions map[string]string
@@ -70,6 +78,14 @@ func (o *gitPushOptions) Parse(data string) bool {
 	case AgitForcePush:
 	case AgitTitle:
 	case AgitDescription:
+	case AgitDraft:
+	case AgitMilestone:
+	case AgitMergeWhenChecksSucceed:
+	case AgitReviewer, AgitLabel, AgitAssignee:
+		// these keys may be given more than once, a push option cannot contain a newline
+		if previous, ok := (*o)[key]; ok {
+			value = previous + "\n" + value
+		}
 	default:
 		return false
 	}
@@ -111,3 +127,12 @@ func (o gitPushOptions) GetString(key Key) (string, bool) {
 	val, ok := o[string(key)]
 	return val, ok
 }
+
+// GetStrings returns all the values of a key which may be given more than once
+func (o gitPushOptions) GetStrings(key Key) []string {
+	val, ok := o[string(key)]
+	if !ok {
+		return nil
+	}
+	return strings.Split(val, "\n")
+}
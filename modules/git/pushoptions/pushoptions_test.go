@@ -85,6 +85,20 @@ func TestParse(t *testing.T) {
 		assert.True(t, options.GetBool(RepoPrivate, false))
 	})
 
+	t.Run("key repeated", func(t *testing.T) {
+		options := New()
+
+		assert.Nil(t, options.GetStrings(AgitReviewer))
+		assert.True(t, options.Parse(fmt.Sprintf("%v=alice", AgitReviewer)))
+		assert.Equal(t, []string{"alice"}, options.GetStrings(AgitReviewer))
+		assert.True(t, options.Parse(fmt.Sprintf("%v=bob", AgitReviewer)))
+		assert.Equal(t, []string{"alice", "bob"}, options.GetStrings(AgitReviewer))
+
+		assert.True(t, options.Parse(fmt.Sprintf("%v=first", AgitMilestone)))
+		assert.True(t, options.Parse(fmt.Sprintf("%v=second", AgitMilestone)))
+		assert.Equal(t, []string{"second"}, options.GetStrings(AgitMilestone))
+	})
+
 	t.Run("unknown keys are ignored", func(t *testing.T) {
 		options := New()
 
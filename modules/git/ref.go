@@ -5,6 +5,7 @@ package git
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/modules/util"
@@ -15,6 +16,9 @@ const (
 	RemotePrefix = "refs/remotes/"
 	// PullPrefix is the base directory of the pull information of git.
 	PullPrefix = "refs/pull/"
+	// MergeQueuePrefix is the base directory of the speculative merge commits of merge queues,
+	// refs/merge-queue/<branch>/<pull index>
+	MergeQueuePrefix = "refs/merge-queue/"
 )
 
 // refNamePatternInvalid is regular expression with unallowed characters in git reference name
@@ -80,6 +84,11 @@ func RefNameFromTag(shortName string) RefName {
 	return RefName(TagPrefix + shortName)
 }
 
+// RefNameFromMergeQueue returns the ref of the speculative merge commit of a pull request in the merge queue of a branch
+func RefNameFromMergeQueue(branchName string, pullIndex int64) RefName {
+	return RefName(MergeQueuePrefix + branchName + "/" + strconv.FormatInt(pullIndex, 10))
+}
+
 func (ref RefName) String() string {
 	return string(ref)
 }
@@ -104,6 +113,10 @@ func (ref RefName) IsFor() bool {
 	return strings.HasPrefix(string(ref), ForPrefix)
 }
 
+func (ref RefName) IsMergeQueue() bool {
+	return strings.HasPrefix(string(ref), MergeQueuePrefix) && strings.IndexByte(string(ref)[len(MergeQueuePrefix):], '/') > -1
+}
+
 func (ref RefName) nameWithoutPrefix(prefix string) string {
 	if strings.HasPrefix(string(ref), prefix) {
 		return strings.TrimPrefix(string(ref), prefix)
@@ -131,6 +144,15 @@ func (ref RefName) PullName() string {
 	return ""
 }
 
+// MergeQueueBranchName returns the branch name part of refs like refs/merge-queue/<branch_name>/<pull index>
+func (ref RefName) MergeQueueBranchName() string {
+	if !ref.IsMergeQueue() {
+		return ""
+	}
+	refName := string(ref)
+	return refName[len(MergeQueuePrefix):strings.LastIndexByte(refName, '/')]
+}
+
 // ForBranchName returns the branch name part of refs like refs/for/<branch_name>
 func (ref RefName) ForBranchName() string {
 	return ref.nameWithoutPrefix(ForPrefix)
@@ -180,6 +202,9 @@ func (ref RefName) RefGroup() string {
 	if ref.IsFor() {
 		return "for"
 	}
+	if ref.IsMergeQueue() {
+		return "merge-queue"
+	}
 	return ""
 }
 
@@ -26,6 +26,12 @@ func TestRefName(t *testing.T) {
 	assert.Equal(t, "main", RefName("refs/for/main").ForBranchName())
 	assert.Equal(t, "my/branch", RefName("refs/for/my/branch").ForBranchName())
 
+	// Test merge queue names
+	assert.Equal(t, "main", RefName("refs/merge-queue/main/1").MergeQueueBranchName())
+	assert.Equal(t, "release/v1", RefName("refs/merge-queue/release/v1/12").MergeQueueBranchName())
+	assert.Equal(t, "", RefName("refs/merge-queue/main").MergeQueueBranchName())
+	assert.Equal(t, RefName("refs/merge-queue/release/v1/12"), RefNameFromMergeQueue("release/v1", 12))
+
 	// Test commit hashes.
 	assert.Equal(t, "c0ffee", RefName("c0ffee").ShortName())
 }
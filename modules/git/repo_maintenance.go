@@ -0,0 +1,43 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// RunMaintenance runs the incremental maintenance of git on a repository: it packs the loose objects, updates the
+// commit-graph and repacks the packs incrementally into a multi-pack-index with a reachability bitmap.
+// This requires git v2.29, the bitmap of the multi-pack-index is only written from git v2.34.
+func RunMaintenance(ctx context.Context, repoPath string, timeout time.Duration) error {
+	if err := CheckGitVersionAtLeast("2.29"); err != nil {
+		return err
+	}
+
+	opts := &RunOpts{Timeout: timeout, Dir: repoPath}
+	if _, _, err := NewCommand(ctx, "maintenance", "run", "--task=loose-objects", "--task=commit-graph").RunStdString(opts); err != nil {
+		return fmt.Errorf("maintenance of the loose objects and the commit-graph of %s: %w", repoPath, err)
+	}
+
+	// the incremental repack fails in a repository without any pack
+	packs, err := filepath.Glob(filepath.Join(repoPath, "objects", "pack", "*.pack"))
+	if err != nil {
+		return err
+	} else if len(packs) == 0 {
+		return nil
+	}
+	if _, _, err := NewCommand(ctx, "maintenance", "run", "--task=incremental-repack").RunStdString(opts); err != nil {
+		return fmt.Errorf("incremental repack of %s: %w", repoPath, err)
+	}
+
+	if CheckGitVersionAtLeast("2.34") == nil {
+		if _, _, err := NewCommand(ctx, "multi-pack-index", "write", "--bitmap").RunStdString(opts); err != nil {
+			return fmt.Errorf("writing the multi-pack-index bitmap of %s: %w", repoPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMaintenance(t *testing.T) {
+	if CheckGitVersionAtLeast("2.29") != nil {
+		t.Skip("git maintenance requires git v2.29")
+	}
+
+	bareRepo1Path, err := filepath.Abs(filepath.Join(testReposDir, "repo1_bare"))
+	require.NoError(t, err)
+	repoPath := t.TempDir()
+	require.NoError(t, InitRepository(DefaultContext, repoPath, true, Sha1ObjectFormat.Name()))
+	_, _, runErr := NewCommand(DefaultContext, "fetch", "--quiet").AddDynamicArguments(bareRepo1Path, "+refs/heads/*:refs/heads/*").RunStdString(&RunOpts{Dir: repoPath})
+	require.NoError(t, runErr)
+
+	require.NoError(t, RunMaintenance(DefaultContext, repoPath, 0))
+	assert.FileExists(t, filepath.Join(repoPath, "objects", "pack", "multi-pack-index"))
+	_, err = os.Stat(filepath.Join(repoPath, "objects", "info", "commit-graph"))
+	if os.IsNotExist(err) {
+		assert.DirExists(t, filepath.Join(repoPath, "objects", "info", "commit-graphs"))
+	} else {
+		assert.NoError(t, err)
+	}
+
+	// the repository is maintained again without any error
+	require.NoError(t, RunMaintenance(DefaultContext, repoPath, 0))
+}
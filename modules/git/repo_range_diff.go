@@ -0,0 +1,90 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RangeDiffStatus describes how a commit of the old range relates to a commit of the new range
+type RangeDiffStatus string
+
+const (
+	RangeDiffStatusUnchanged RangeDiffStatus = "="
+	RangeDiffStatusChanged   RangeDiffStatus = "!"
+	RangeDiffStatusRemoved   RangeDiffStatus = "<"
+	RangeDiffStatusAdded     RangeDiffStatus = ">"
+)
+
+// RangeDiffPair is a commit of the old range paired with the matching commit of the new range. A removed commit
+// has no new commit and an added commit has no old commit, their index is 0 and their commit ID is empty.
+type RangeDiffPair struct {
+	Status      RangeDiffStatus
+	OldIndex    int
+	OldCommitID string
+	NewIndex    int
+	NewCommitID string
+	Subject     string
+	// Interdiff is the diff between the patches of a changed pair, the first column tells if a line of the patch was
+	// removed or added and the second column is the line of the patch itself
+	Interdiff []string
+}
+
+var rangeDiffPairPattern = regexp.MustCompile(`^(-|\d+):\s+(-+|[0-9a-f]+) ([=!<>]) (-|\d+):\s+(-+|[0-9a-f]+) ?(.*)$`)
+
+// GetRangeDiff compares the commits of the range oldBase..oldHead with the commits of the range newBase..newHead.
+// This requires git v2.31 to show the full commit IDs.
+func (repo *Repository) GetRangeDiff(oldBase, oldHead, newBase, newHead string) ([]*RangeDiffPair, error) {
+	if err := CheckGitVersionAtLeast("2.31"); err != nil {
+		return nil, err
+	}
+
+	stdout, _, err := NewCommand(repo.Ctx, "-c", "core.abbrev=no", "range-diff", "--no-color").
+		AddDynamicArguments(oldBase + ".." + oldHead).
+		AddDynamicArguments(newBase + ".." + newHead).
+		RunStdString(&RunOpts{Dir: repo.Path})
+	if err != nil {
+		return nil, fmt.Errorf("range-diff %s..%s %s..%s: %w", oldBase, oldHead, newBase, newHead, err)
+	}
+	return parseRangeDiff(stdout)
+}
+
+// parseRangeDiff parses the output of git range-diff, each pair is followed by its interdiff indented by four spaces
+func parseRangeDiff(output string) ([]*RangeDiffPair, error) {
+	var pairs []*RangeDiffPair
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "    "); ok && len(pairs) > 0 {
+			pair := pairs[len(pairs)-1]
+			pair.Interdiff = append(pair.Interdiff, rest)
+			continue
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		match := rangeDiffPairPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("unexpected range-diff line: %q", line)
+		}
+		pair := &RangeDiffPair{Status: RangeDiffStatus(match[3]), Subject: match[6]}
+		if match[1] != "-" {
+			pair.OldIndex, _ = strconv.Atoi(match[1])
+			pair.OldCommitID = match[2]
+		}
+		if match[4] != "-" {
+			pair.NewIndex, _ = strconv.Atoi(match[4])
+			pair.NewCommitID = match[5]
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, scanner.Err()
+}
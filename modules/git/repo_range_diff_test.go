@@ -0,0 +1,60 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRangeDiff(t *testing.T) {
+	output := "1:  5c146cde319a151a05455239ca2e8ad4d8b1ba6d ! 1:  0fd6c434a62683006161d6a01543b1fbbf62f8c5 one\n" +
+		"    @@ a\n" +
+		"     -6\n" +
+		"    -+six\n" +
+		"    ++SIX\n" +
+		"      7\n" +
+		"2:  246dd9b87925117f3b149c1b7e209e26b0cfedba = 2:  77d9af985d509c4bd5f481c65ba086d28e32efff two\n" +
+		"3:  1111111111111111111111111111111111111111 < -:  ---------------------------------------- removed\n" +
+		"-:  ---------------------------------------- > 3:  2cb57bd63f3d7566912c58a5d55d003d60afcec9 three\n"
+
+	pairs, err := parseRangeDiff(output)
+	require.NoError(t, err)
+	assert.Equal(t, []*RangeDiffPair{
+		{
+			Status:      RangeDiffStatusChanged,
+			OldIndex:    1,
+			OldCommitID: "5c146cde319a151a05455239ca2e8ad4d8b1ba6d",
+			NewIndex:    1,
+			NewCommitID: "0fd6c434a62683006161d6a01543b1fbbf62f8c5",
+			Subject:     "one",
+			Interdiff:   []string{"@@ a", " -6", "-+six", "++SIX", "  7"},
+		},
+		{
+			Status:      RangeDiffStatusUnchanged,
+			OldIndex:    2,
+			OldCommitID: "246dd9b87925117f3b149c1b7e209e26b0cfedba",
+			NewIndex:    2,
+			NewCommitID: "77d9af985d509c4bd5f481c65ba086d28e32efff",
+			Subject:     "two",
+		},
+		{
+			Status:      RangeDiffStatusRemoved,
+			OldIndex:    3,
+			OldCommitID: "1111111111111111111111111111111111111111",
+			Subject:     "removed",
+		},
+		{
+			Status:      RangeDiffStatusAdded,
+			NewIndex:    3,
+			NewCommitID: "2cb57bd63f3d7566912c58a5d55d003d60afcec9",
+			Subject:     "three",
+		},
+	}, pairs)
+
+	_, err = parseRangeDiff("not a range-diff\n")
+	assert.Error(t, err)
+}
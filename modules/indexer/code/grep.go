@@ -0,0 +1,229 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/go-enry/go-enry/v2"
+	"xorm.io/builder"
+)
+
+const (
+	// maxGrepSearchRepos limits the number of repositories PerformGrepSearch runs git grep in
+	maxGrepSearchRepos = 50
+	// maxGrepResultsPerRepo limits the number of files git grep returns for one repository
+	maxGrepResultsPerRepo = 50
+)
+
+// GrepSearch searches a ref of a repository with "git grep", it is used when the code indexer is disabled.
+// The keyword is parsed as a Query like in PerformSearch.
+func GrepSearch(ctx context.Context, repo *repo_model.Repository, gitRepo *git.Repository, refName string, opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, error) {
+	if opts == nil || len(opts.Keyword) == 0 {
+		return 0, nil, nil, nil
+	}
+
+	query, err := ParseQuery(opts.Keyword)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	keyword, err := query.SearchKeyword()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	results, err := grepRepo(ctx, query, keyword, opts, repo, gitRepo, refName)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return paginateGrepResults(results, opts)
+}
+
+// PerformGrepSearch searches the default branches of the given repositories with "git grep",
+// it is used when the code indexer is disabled. Only the most recently updated repositories
+// matching the repo terms of the query are searched.
+func PerformGrepSearch(ctx context.Context, repoIDs []int64, opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, error) {
+	if opts == nil || len(opts.Keyword) == 0 || len(repoIDs) == 0 {
+		return 0, nil, nil, nil
+	}
+
+	query, err := ParseQuery(opts.Keyword)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	keyword, err := query.SearchKeyword()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// the most recently updated repositories are picked page by page, as the repo terms of the query are globs which
+	// are matched against the names of the repositories
+	cond := builder.In("id", repoIDs).
+		And(builder.Eq{"is_empty": false}).
+		And(builder.Neq{"status": repo_model.RepositoryBroken})
+	repos := make([]*repo_model.Repository, 0, maxGrepSearchRepos)
+	for page := 1; len(repos) < maxGrepSearchRepos; page++ {
+		pageRepos, _, err := repo_model.SearchRepositoryByCondition(ctx, &repo_model.SearchRepoOptions{
+			ListOptions: db.ListOptions{Page: page, PageSize: maxGrepSearchRepos},
+			OrderBy:     db.SearchOrderBy("updated_unix DESC, id ASC"),
+		}, cond, false)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		for _, repo := range pageRepos {
+			if len(repos) < maxGrepSearchRepos && query.MatchRepo(repo.FullName()) {
+				repos = append(repos, repo)
+			}
+		}
+		if len(pageRepos) < maxGrepSearchRepos {
+			break
+		}
+	}
+
+	var results []*Result
+	for _, repo := range repos {
+		repoResults, err := grepDefaultBranch(ctx, query, keyword, opts, repo)
+		if err != nil {
+			log.Warn("Unable to grep %-v: %v", repo, err)
+			continue
+		}
+		results = append(results, repoResults...)
+	}
+	return paginateGrepResults(results, opts)
+}
+
+func grepDefaultBranch(ctx context.Context, query *Query, keyword string, opts *SearchOptions, repo *repo_model.Repository) ([]*Result, error) {
+	gitRepo, err := gitrepo.OpenRepository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	return grepRepo(ctx, query, keyword, opts, repo, gitRepo, repo.DefaultBranch)
+}
+
+// grepRepo runs git grep for the keyword and applies the other terms of the query to the matched files.
+// If the query has content terms, the blobs are read to verify them. The results are not filtered by
+// opts.Language yet, so the language facets can be computed from them.
+func grepRepo(ctx context.Context, query *Query, keyword string, opts *SearchOptions, repo *repo_model.Repository, gitRepo *git.Repository, refName string) ([]*Result, error) {
+	if !query.MatchRepo(repo.FullName()) {
+		return nil, nil
+	}
+
+	commit, err := gitRepo.GetCommit(refName)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := git.GrepSearch(ctx, gitRepo, keyword, git.GrepOptions{
+		ContextLineNumber: 1,
+		IsFuzzy:           opts.IsKeywordFuzzy,
+		RefName:           commit.ID.String(),
+		MaxResultLimit:    maxGrepResultsPerRepo,
+		PathspecList:      query.grepPathspecs(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Result, 0, len(res))
+	for _, r := range res {
+		if !query.MatchPath(r.Filename) {
+			continue
+		}
+
+		var content string
+		contentLoaded := false
+		loadContent := func() error {
+			if contentLoaded {
+				return nil
+			}
+			contentLoaded = true
+			entry, err := commit.GetTreeEntryByPath(r.Filename)
+			if err != nil {
+				return err
+			}
+			content, err = entry.Blob().GetBlobContent(setting.Indexer.MaxIndexerFileSize)
+			return err
+		}
+		if query.HasContentFilters() {
+			if err := loadContent(); err != nil {
+				return nil, err
+			}
+		}
+
+		// like the indexers, fall back to detect the language by the content if the filename is ambiguous
+		language := analyze.GetCodeLanguage(r.Filename, nil)
+		if language == enry.OtherLanguage {
+			if err := loadContent(); err != nil {
+				return nil, err
+			}
+			language = analyze.GetCodeLanguage(r.Filename, []byte(content))
+		}
+		if !query.MatchLanguage(language) {
+			continue
+		}
+
+		lineNums, lineCodes := r.LineNumbers, r.LineCodes
+		if query.HasContentFilters() {
+			start, end, ok := query.MatchContent(content)
+			if !ok {
+				continue
+			}
+			if start >= 0 {
+				lineNums, lineCodes = contentLines(content, start, end)
+			}
+		}
+
+		results = append(results, &Result{
+			RepoID:   repo.ID,
+			Filename: r.Filename,
+			CommitID: commit.ID.String(),
+			// UpdatedUnix: not supported yet
+			Language: language,
+			Color:    enry.GetColor(language),
+			Lines:    HighlightSearchResultCode(r.Filename, lineNums, strings.Join(lineCodes, "\n")),
+		})
+	}
+	return results, nil
+}
+
+// contentLines returns the lines around the byte range of the content, like the indexer results show them
+func contentLines(content string, start, end int) ([]int, []string) {
+	startIndex, endIndex := indices(content, start, end)
+	startLineNum := 1 + strings.Count(content[:startIndex], "\n")
+	lineCodes := strings.Split(strings.TrimSuffix(content[startIndex:endIndex], "\n"), "\n")
+	lineNums := make([]int, len(lineCodes))
+	for i := range lineCodes {
+		lineNums[i] = startLineNum + i
+	}
+	return lineNums, lineCodes
+}
+
+func paginateGrepResults(results []*Result, opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, error) {
+	languages := make(map[string]*SearchResultLanguages)
+	filtered := results[:0]
+	for _, result := range results {
+		countResultLanguage(languages, result.Language)
+		if opts.Language == "" || strings.EqualFold(opts.Language, result.Language) {
+			filtered = append(filtered, result)
+		}
+	}
+
+	skip, take := 0, len(filtered)
+	if opts.Paginator != nil {
+		skip, take = opts.GetSkipTake()
+	}
+	skip = min(skip, len(filtered))
+	return len(filtered), filtered[skip:min(skip+take, len(filtered))], sortResultLanguages(languages), nil
+}
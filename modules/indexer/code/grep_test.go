@@ -0,0 +1,68 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"context"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerformGrepSearch(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	queries := []struct {
+		Query string
+		Total int
+	}{
+		{Query: "Description", Total: 1},
+		{Query: "Description path:readme", Total: 1},
+		{Query: "Description -path:*.md", Total: 0},
+		{Query: "Description lang:markdown", Total: 1},
+		{Query: "Description lang:go", Total: 0},
+		{Query: "Description repo:user2/*", Total: 1},
+		{Query: "Description -repo:repo1", Total: 0},
+		{Query: `/Desc\w+ for repo\d/`, Total: 1},
+		{Query: "Description /for repo2/", Total: 0},
+		{Query: "Description -for", Total: 0},
+	}
+	for _, q := range queries {
+		t.Run(q.Query, func(t *testing.T) {
+			total, res, langs, err := PerformGrepSearch(context.Background(), []int64{1, 2}, &SearchOptions{
+				Keyword:        q.Query,
+				IsKeywordFuzzy: true,
+				Paginator: &db.ListOptions{
+					Page:     1,
+					PageSize: 10,
+				},
+			})
+			assert.NoError(t, err)
+			assert.EqualValues(t, q.Total, total)
+			if assert.Len(t, res, q.Total) && q.Total > 0 {
+				assert.EqualValues(t, 1, res[0].RepoID)
+				assert.Equal(t, "README.md", res[0].Filename)
+				assert.Equal(t, "Markdown", res[0].Language)
+				assert.Len(t, langs, 1)
+			}
+		})
+	}
+
+	t.Run("Regexp lines", func(t *testing.T) {
+		_, res, _, err := PerformGrepSearch(context.Background(), []int64{1}, &SearchOptions{Keyword: "/for repo1$/"})
+		assert.NoError(t, err)
+		if assert.Len(t, res, 1) && assert.NotEmpty(t, res[0].Lines) {
+			last := res[0].Lines[len(res[0].Lines)-1]
+			assert.Equal(t, 3, last.Num)
+			assert.Equal(t, "Description for repo1", last.RawContent)
+		}
+	})
+
+	_, _, _, err := PerformGrepSearch(context.Background(), []int64{1}, &SearchOptions{Keyword: "/.*/"})
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/indexer/code/bleve"
 	"code.gitea.io/gitea/modules/indexer/code/elasticsearch"
 	"code.gitea.io/gitea/modules/indexer/code/internal"
+	"code.gitea.io/gitea/modules/util"
 
 	_ "code.gitea.io/gitea/models"
 	_ "code.gitea.io/gitea/models/actions"
@@ -99,6 +100,46 @@ func testIndexer(name string, t *testing.T, indexer internal.Indexer) {
 			})
 		}
 
+		t.Run("Query", func(t *testing.T) {
+			globalIndexer.Store(&indexer)
+			defer globalIndexer.Store(dummyIndexer)
+
+			queries := []struct {
+				Query string
+				Total int
+			}{
+				{Query: "Description path:README.md", Total: 1},
+				{Query: "Description path:*.go", Total: 0},
+				{Query: "Description -path:readme", Total: 0},
+				{Query: "Description lang:markdown", Total: 1},
+				{Query: "Description -lang:Markdown", Total: 0},
+				{Query: "Description repo:user2/repo1", Total: 1},
+				{Query: "Description repo:org3/*", Total: 0},
+				{Query: "Description -repo1", Total: 0},
+				{Query: `/Desc\w+ for repo\d/`, Total: 1},
+				{Query: "Description /for repo2/", Total: 0},
+				{Query: "Description -/repo\\d/", Total: 0},
+			}
+			for _, q := range queries {
+				t.Run(q.Query, func(t *testing.T) {
+					total, res, _, err := PerformSearch(context.Background(), &SearchOptions{
+						Keyword:        q.Query,
+						IsKeywordFuzzy: true,
+						Paginator: &db.ListOptions{
+							Page:     1,
+							PageSize: 10,
+						},
+					})
+					assert.NoError(t, err)
+					assert.EqualValues(t, q.Total, total)
+					assert.Len(t, res, q.Total)
+				})
+			}
+
+			_, _, _, err := PerformSearch(context.Background(), &SearchOptions{Keyword: "/[a-z]+/"})
+			assert.ErrorIs(t, err, util.ErrInvalidArgument)
+		})
+
 		assert.NoError(t, indexer.Delete(context.Background(), repoID))
 	})
 }
@@ -0,0 +1,384 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-enry/go-enry/v2"
+	"github.com/gobwas/glob"
+)
+
+// Query is a parsed code search query.
+//
+// A query is a list of space separated terms:
+//
+//	foo           keyword, matched by the indexer (or git grep)
+//	"foo bar"     keyword containing spaces
+//	/fo+\.bar/    regular expression, verified against the file content
+//	/foo/i        case-insensitive regular expression
+//	path:src/*.go only files whose path matches the glob (or contains the text if it is no glob)
+//	lang:go       only files of the given language
+//	repo:owner/*  only repositories whose full name matches the glob (or contains the text)
+//
+// Every term can be negated with a leading "-", e.g. "-path:vendor" or "-/TODO/".
+type Query struct {
+	Keywords         []string
+	ExcludeKeywords  []string
+	Regexps          []*regexp.Regexp
+	ExcludeRegexps   []*regexp.Regexp
+	Paths            []string
+	ExcludePaths     []string
+	Languages        []string
+	ExcludeLanguages []string
+	Repos            []string
+	ExcludeRepos     []string
+
+	paths, excludePaths []glob.Glob
+	repos, excludeRepos []glob.Glob
+}
+
+// ParseQuery parses a code search query, see Query for the syntax
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{}
+	for _, term := range splitQueryTerms(s) {
+		negate := false
+		if len(term) > 1 && term[0] == '-' {
+			negate = true
+			term = term[1:]
+		}
+
+		if isQueryRegexp(term) {
+			re, err := parseQueryRegexp(term)
+			if err != nil {
+				return nil, err
+			}
+			if negate {
+				q.ExcludeRegexps = append(q.ExcludeRegexps, re)
+			} else {
+				q.Regexps = append(q.Regexps, re)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(term, ":")
+		value = unquoteQueryTerm(value)
+		if ok && value != "" {
+			switch strings.ToLower(key) {
+			case "path", "file":
+				g, err := compileQueryGlob(value)
+				if err != nil {
+					return nil, util.NewInvalidArgumentErrorf("invalid path pattern %q: %v", value, err)
+				}
+				if negate {
+					q.ExcludePaths = append(q.ExcludePaths, value)
+					q.excludePaths = append(q.excludePaths, g)
+				} else {
+					q.Paths = append(q.Paths, value)
+					q.paths = append(q.paths, g)
+				}
+				continue
+			case "lang", "language":
+				// use the name of the language as the indexers store it, e.g. "golang" and "go" become "Go"
+				if language, ok := enry.GetLanguageByAlias(value); ok {
+					value = language
+				}
+				if negate {
+					q.ExcludeLanguages = append(q.ExcludeLanguages, value)
+				} else {
+					q.Languages = append(q.Languages, value)
+				}
+				continue
+			case "repo":
+				g, err := compileQueryGlob(value)
+				if err != nil {
+					return nil, util.NewInvalidArgumentErrorf("invalid repository pattern %q: %v", value, err)
+				}
+				if negate {
+					q.ExcludeRepos = append(q.ExcludeRepos, value)
+					q.excludeRepos = append(q.excludeRepos, g)
+				} else {
+					q.Repos = append(q.Repos, value)
+					q.repos = append(q.repos, g)
+				}
+				continue
+			}
+		}
+
+		term = unquoteQueryTerm(term)
+		if term == "" {
+			continue
+		}
+		if negate {
+			q.ExcludeKeywords = append(q.ExcludeKeywords, term)
+		} else {
+			q.Keywords = append(q.Keywords, term)
+		}
+	}
+	return q, nil
+}
+
+// splitQueryTerms splits the query by spaces, but keeps quoted strings and regular expressions together
+func splitQueryTerms(s string) []string {
+	var (
+		terms   []string
+		current strings.Builder
+		quote   rune // the character which closes the current quoted section, 0 if none
+		escaped bool
+	)
+	flush := func() {
+		if current.Len() > 0 {
+			terms = append(terms, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && r == '"':
+			quote = '"'
+		case quote == 0 && r == '/' && (current.Len() == 0 || current.String() == "-"):
+			quote = '/'
+		case quote == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	flush()
+	return terms
+}
+
+// unquoteQueryTerm removes the surrounding double quotes of a term
+func unquoteQueryTerm(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `\"`, `"`)
+	}
+	return s
+}
+
+// isQueryRegexp returns whether the term has the "/pattern/" or "/pattern/i" form,
+// other terms starting with a slash (like "/etc/hosts") are keywords
+func isQueryRegexp(term string) bool {
+	return len(term) > 2 && term[0] == '/' &&
+		(strings.HasSuffix(term, "/") || (len(term) > 3 && strings.HasSuffix(term, "/i")))
+}
+
+// parseQueryRegexp parses a "/pattern/flags" term
+func parseQueryRegexp(term string) (*regexp.Regexp, error) {
+	end := strings.LastIndexByte(term, '/')
+	pattern, flags := term[1:end], term[end+1:]
+	pattern = strings.ReplaceAll(pattern, `\/`, "/")
+	if flags == "i" {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile("(?m)" + pattern)
+	if err != nil {
+		return nil, util.NewInvalidArgumentErrorf("invalid regular expression %q: %v", term, err)
+	}
+	return re, nil
+}
+
+// compileQueryGlob compiles a path or repository pattern, a pattern without any glob characters matches as substring
+func compileQueryGlob(pattern string) (glob.Glob, error) {
+	pattern = strings.ToLower(pattern)
+	if !strings.ContainsAny(pattern, "*?[{") {
+		pattern = "**" + glob.QuoteMeta(pattern) + "**"
+	}
+	return glob.Compile(pattern, '/')
+}
+
+// HasFilters returns whether the query contains terms which the indexer can't handle by itself
+func (q *Query) HasFilters() bool {
+	return len(q.ExcludeKeywords) > 0 || len(q.Regexps) > 0 || len(q.ExcludeRegexps) > 0 ||
+		len(q.Paths) > 0 || len(q.ExcludePaths) > 0 || len(q.Languages) > 0 || len(q.ExcludeLanguages) > 0 ||
+		len(q.Repos) > 0 || len(q.ExcludeRepos) > 0
+}
+
+// HasContentFilters returns whether matching the query requires the file content
+func (q *Query) HasContentFilters() bool {
+	return len(q.ExcludeKeywords) > 0 || len(q.Regexps) > 0 || len(q.ExcludeRegexps) > 0
+}
+
+// SearchKeyword returns the text that is passed to the indexer or git grep to find candidate files.
+// If the query only has regular expressions, the longest literal of the first one is used.
+func (q *Query) SearchKeyword() (string, error) {
+	if len(q.Keywords) > 0 {
+		return strings.Join(q.Keywords, " "), nil
+	}
+	for _, re := range q.Regexps {
+		if literal := regexpLiteral(re); literal != "" {
+			return literal, nil
+		}
+	}
+	if len(q.Regexps) > 0 {
+		return "", util.NewInvalidArgumentErrorf("regular expression %q has no literal text to search for, please add a keyword", strings.TrimPrefix(q.Regexps[0].String(), "(?m)"))
+	}
+	return "", util.NewInvalidArgumentErrorf("query has no keyword to search for")
+}
+
+// regexpLiteral returns the longest literal string every match of the regular expression must contain
+func regexpLiteral(re *regexp.Regexp) string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return longestLiteral(parsed.Simplify())
+}
+
+func longestLiteral(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return literalString(re)
+	case syntax.OpCapture:
+		return longestLiteral(re.Sub[0])
+	case syntax.OpPlus:
+		return longestLiteral(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return longestLiteral(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var longest, current string
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				current += literalString(sub)
+			} else {
+				current = longestLiteral(sub)
+				if utf8.RuneCountInString(current) > utf8.RuneCountInString(longest) {
+					longest = current
+				}
+				current = ""
+				continue
+			}
+			if utf8.RuneCountInString(current) > utf8.RuneCountInString(longest) {
+				longest = current
+			}
+		}
+		return longest
+	}
+	return ""
+}
+
+// literalString returns the text of a literal, the indexers and git grep search case-insensitively,
+// so case folded literals can be searched for in lower case
+func literalString(re *syntax.Regexp) string {
+	if re.Flags&syntax.FoldCase != 0 {
+		return strings.ToLower(string(re.Rune))
+	}
+	return string(re.Rune)
+}
+
+// MatchPath returns whether the file path is accepted by the path terms of the query
+func (q *Query) MatchPath(filename string) bool {
+	return matchQueryGlobs(strings.ToLower(filename), q.paths, q.excludePaths)
+}
+
+// MatchRepo returns whether the repository full name is accepted by the repo terms of the query
+func (q *Query) MatchRepo(fullName string) bool {
+	return matchQueryGlobs(strings.ToLower(fullName), q.repos, q.excludeRepos)
+}
+
+func matchQueryGlobs(s string, includes, excludes []glob.Glob) bool {
+	for _, g := range excludes {
+		if g.Match(s) {
+			return false
+		}
+	}
+	if len(includes) == 0 {
+		return true
+	}
+	for _, g := range includes {
+		if g.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchLanguage returns whether the language is accepted by the lang terms of the query
+func (q *Query) MatchLanguage(language string) bool {
+	for _, l := range q.ExcludeLanguages {
+		if strings.EqualFold(l, language) {
+			return false
+		}
+	}
+	if len(q.Languages) == 0 {
+		return true
+	}
+	for _, l := range q.Languages {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchContent verifies the content terms of the query against the file content.
+// If the query has regular expressions, the byte range of the first match is returned,
+// otherwise start and end are -1.
+func (q *Query) MatchContent(content string) (start, end int, ok bool) {
+	start, end = -1, -1
+	lowerContent := ""
+	for _, keyword := range q.ExcludeKeywords {
+		if lowerContent == "" {
+			lowerContent = strings.ToLower(content)
+		}
+		if strings.Contains(lowerContent, strings.ToLower(keyword)) {
+			return -1, -1, false
+		}
+	}
+	for _, re := range q.ExcludeRegexps {
+		if re.MatchString(content) {
+			return -1, -1, false
+		}
+	}
+	for i, re := range q.Regexps {
+		loc := re.FindStringIndex(content)
+		if loc == nil {
+			return -1, -1, false
+		}
+		if i == 0 {
+			start, end = loc[0], loc[1]
+		}
+	}
+	return start, end, true
+}
+
+// grepPathspecs converts the path terms of the query into git pathspecs
+func (q *Query) grepPathspecs() []string {
+	pathspecs := make([]string, 0, len(q.Paths)+len(q.ExcludePaths))
+	for _, p := range q.Paths {
+		if strings.Contains(p, "{") {
+			// git's glob magic doesn't support alternatives, all paths are grepped and MatchPath filters them
+			return nil
+		}
+		pathspecs = append(pathspecs, grepPathspec(p, "icase"))
+	}
+	for _, p := range q.ExcludePaths {
+		if !strings.Contains(p, "{") {
+			pathspecs = append(pathspecs, grepPathspec(p, "icase,exclude"))
+		}
+	}
+	return pathspecs
+}
+
+func grepPathspec(pattern, magic string) string {
+	if strings.ContainsAny(pattern, "*?[") {
+		return fmt.Sprintf(":(%s,glob)%s", magic, pattern)
+	}
+	// without glob magic, "*" also matches "/", which makes it a substring match
+	return fmt.Sprintf(":(%s)*%s*", magic, pattern)
+}
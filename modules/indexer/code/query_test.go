@@ -0,0 +1,123 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package code
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`foo "bar baz" -qux path:src/*.go -path:vendor lang:Go -lang:C repo:owner/* -repo:fork /fo+\.bar/i -/TODO/ /etc/hosts`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar baz", "/etc/hosts"}, q.Keywords)
+	assert.Equal(t, []string{"qux"}, q.ExcludeKeywords)
+	assert.Equal(t, []string{"src/*.go"}, q.Paths)
+	assert.Equal(t, []string{"vendor"}, q.ExcludePaths)
+	assert.Equal(t, []string{"Go"}, q.Languages)
+	assert.Equal(t, []string{"C"}, q.ExcludeLanguages)
+	assert.Equal(t, []string{"owner/*"}, q.Repos)
+	assert.Equal(t, []string{"fork"}, q.ExcludeRepos)
+	if assert.Len(t, q.Regexps, 1) {
+		assert.True(t, q.Regexps[0].MatchString("FOOO.bar"))
+	}
+	assert.Len(t, q.ExcludeRegexps, 1)
+	assert.True(t, q.HasFilters())
+
+	q, err = ParseQuery(`path:"with space" "quoted \"word\"" -`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"with space"}, q.Paths)
+	assert.Equal(t, []string{`quoted "word"`, "-"}, q.Keywords)
+	assert.False(t, q.HasContentFilters())
+
+	q, err = ParseQuery("x lang:golang lang:markdown lang:unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Go", "Markdown", "unknown"}, q.Languages)
+
+	q, err = ParseQuery("foo bar")
+	assert.NoError(t, err)
+	assert.False(t, q.HasFilters())
+
+	_, err = ParseQuery("/fo(o/")
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+}
+
+func TestQuerySearchKeyword(t *testing.T) {
+	cases := map[string]string{
+		"foo bar":                "foo bar",
+		"foo /ba[rz]/":           "foo",
+		`/func \w+Search\(/`:     "Search(",
+		`/(?i)hello\s+world/`:    "hello",
+		`/(interface)+ \{/`:      "interface",
+		"/a/ /longer literal/":   "a",
+		`/x[0-9]*y/ /abcdef/`:    "x",
+		`path:*.go /[a-z]+/ /z/`: "z",
+	}
+	for query, expected := range cases {
+		q, err := ParseQuery(query)
+		assert.NoError(t, err, query)
+		keyword, err := q.SearchKeyword()
+		assert.NoError(t, err, query)
+		assert.Equal(t, expected, keyword, query)
+	}
+
+	for _, query := range []string{"/[a-z]+/", "path:*.go", "-foo"} {
+		q, err := ParseQuery(query)
+		assert.NoError(t, err, query)
+		_, err = q.SearchKeyword()
+		assert.ErrorIs(t, err, util.ErrInvalidArgument, query)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	q, err := ParseQuery("x path:src/*.go -path:vendor repo:owner/* -repo:fork")
+	assert.NoError(t, err)
+	assert.True(t, q.MatchPath("src/main.go"))
+	assert.True(t, q.MatchPath("SRC/Main.go"))
+	assert.False(t, q.MatchPath("src/sub/main.go"))
+	assert.False(t, q.MatchPath("src/main.c"))
+	assert.False(t, q.MatchPath("src/vendor.go"))
+	assert.True(t, q.MatchRepo("owner/repo"))
+	assert.False(t, q.MatchRepo("other/repo"))
+	assert.False(t, q.MatchRepo("owner/forked"))
+
+	q, err = ParseQuery("x path:src/**.go lang:go lang:c -lang:c++")
+	assert.NoError(t, err)
+	assert.True(t, q.MatchPath("src/sub/main.go"))
+	assert.True(t, q.MatchPath("src/main.go"))
+	assert.True(t, q.MatchLanguage("Go"))
+	assert.True(t, q.MatchLanguage("C"))
+	assert.False(t, q.MatchLanguage("C++"))
+	assert.False(t, q.MatchLanguage("Python"))
+
+	q, err = ParseQuery(`x /b(a+)r/ -/bar/ -skip`)
+	assert.NoError(t, err)
+	start, end, ok := q.MatchContent("foo\nbaaar\n")
+	assert.True(t, ok)
+	assert.Equal(t, 4, start)
+	assert.Equal(t, 9, end)
+	_, _, ok = q.MatchContent("foo\nbar\nbaar")
+	assert.False(t, ok)
+	_, _, ok = q.MatchContent("baar SKIP")
+	assert.False(t, ok)
+	_, _, ok = q.MatchContent("no match")
+	assert.False(t, ok)
+}
+
+func TestQueryGrepPathspecs(t *testing.T) {
+	q, err := ParseQuery("x path:src path:*.go -path:vendor/**")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{":(icase)*src*", ":(icase,glob)*.go", ":(icase,exclude,glob)vendor/**"}, q.grepPathspecs())
+
+	q, err = ParseQuery("x path:*.{go,c} -path:{a,b}/* -path:test")
+	assert.NoError(t, err)
+	assert.Nil(t, q.grepPathspecs())
+
+	q, err = ParseQuery("x -path:{a,b}/* -path:test")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{":(icase,exclude)*test*"}, q.grepPathspecs())
+}
@@ -5,13 +5,20 @@ package code
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"html/template"
+	"slices"
 	"strings"
 
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/indexer/code/internal"
 	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/go-enry/go-enry/v2"
 )
 
 // Result a search result to display
@@ -27,6 +34,7 @@ type Result struct {
 
 type ResultLine struct {
 	Num              int
+	RawContent       string
 	FormattedContent template.HTML
 }
 
@@ -74,11 +82,15 @@ func HighlightSearchResultCode(filename string, lineNums []int, code string) []R
 	// we should highlight the whole code block first, otherwise it doesn't work well with multiple line highlighting
 	hl, _ := highlight.Code(filename, "", code)
 	highlightedLines := strings.Split(string(hl), "\n")
+	rawLines := strings.Split(code, "\n")
 
 	// The lineNums outputted by highlight.Code might not match the original lineNums, because "highlight" removes the last `\n`
 	lines := make([]ResultLine, min(len(highlightedLines), len(lineNums)))
 	for i := 0; i < len(lines); i++ {
 		lines[i].Num = lineNums[i]
+		if i < len(rawLines) {
+			lines[i].RawContent = strings.TrimSuffix(rawLines[i], "\r")
+		}
 		lines[i].FormattedContent = template.HTML(highlightedLines[i])
 	}
 	return lines
@@ -126,14 +138,42 @@ func searchResult(result *internal.SearchResult, startIndex, endIndex int) (*Res
 	}, nil
 }
 
+const (
+	// filteredSearchBatchSize is the number of indexer results fetched at once when the query has filters
+	filteredSearchBatchSize = 100
+	// maxFilteredSearchCandidates limits the number of indexer results a filtered query looks at
+	maxFilteredSearchCandidates = 1000
+)
+
 // PerformSearch perform a search on a repository
 // if isFuzzy is true set the Damerau-Levenshtein distance from 0 to 2
+// The keyword is parsed as a Query, terms the indexer can't handle are applied to its results.
 func PerformSearch(ctx context.Context, opts *SearchOptions) (int, []*Result, []*SearchResultLanguages, error) {
 	if opts == nil || len(opts.Keyword) == 0 {
 		return 0, nil, nil, nil
 	}
 
-	total, results, resultLanguages, err := (*globalIndexer.Load()).Search(ctx, opts)
+	query, err := ParseQuery(opts.Keyword)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	keyword, err := query.SearchKeyword()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	indexerOpts := *opts
+	indexerOpts.Keyword = keyword
+
+	var (
+		total           int64
+		results         []*internal.SearchResult
+		resultLanguages []*SearchResultLanguages
+	)
+	if query.HasFilters() {
+		total, results, resultLanguages, err = performFilteredSearch(ctx, query, &indexerOpts)
+	} else {
+		total, results, resultLanguages, err = (*globalIndexer.Load()).Search(ctx, &indexerOpts)
+	}
 	if err != nil {
 		return 0, nil, nil, err
 	}
@@ -149,3 +189,103 @@ func PerformSearch(ctx context.Context, opts *SearchOptions) (int, []*Result, []
 	}
 	return int(total), displayResults, resultLanguages, nil
 }
+
+// performFilteredSearch pages through the indexer results and applies the terms of the query
+// the indexer can't handle. The content of the results is the indexed blob content, so regular
+// expressions are verified against the file itself and not against the tokens of the index.
+func performFilteredSearch(ctx context.Context, query *Query, opts *SearchOptions) (int64, []*internal.SearchResult, []*SearchResultLanguages, error) {
+	language := opts.Language
+	batchOpts := *opts
+	// the language facets are computed from the filtered results, so the language is filtered here as well
+	batchOpts.Language = ""
+	if len(query.Languages) == 1 && len(query.ExcludeLanguages) == 0 {
+		batchOpts.Language = query.Languages[0]
+	}
+
+	repos := make(map[int64]*repo_model.Repository)
+	languages := make(map[string]*SearchResultLanguages)
+	var matched []*internal.SearchResult
+	for page := 1; page*filteredSearchBatchSize <= maxFilteredSearchCandidates; page++ {
+		batchOpts.Paginator = &db.ListOptions{Page: page, PageSize: filteredSearchBatchSize}
+		total, results, _, err := (*globalIndexer.Load()).Search(ctx, &batchOpts)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		if len(query.Repos) > 0 || len(query.ExcludeRepos) > 0 {
+			missingRepoIDs := make(container.Set[int64])
+			for _, result := range results {
+				if _, ok := repos[result.RepoID]; !ok {
+					missingRepoIDs.Add(result.RepoID)
+				}
+			}
+			if len(missingRepoIDs) > 0 {
+				if err := repo_model.FindReposMapByIDs(ctx, missingRepoIDs.Values(), repos); err != nil {
+					return 0, nil, nil, err
+				}
+			}
+		}
+
+		for _, result := range results {
+			if !query.MatchPath(result.Filename) || !query.MatchLanguage(result.Language) {
+				continue
+			}
+			if len(query.Repos) > 0 || len(query.ExcludeRepos) > 0 {
+				repo, ok := repos[result.RepoID]
+				if !ok || !query.MatchRepo(repo.FullName()) {
+					continue
+				}
+			}
+			start, end, ok := query.MatchContent(result.Content)
+			if !ok {
+				continue
+			}
+			if start >= 0 {
+				result.StartIndex, result.EndIndex = start, end
+			}
+
+			countResultLanguage(languages, result.Language)
+			if language == "" || strings.EqualFold(language, result.Language) {
+				matched = append(matched, result)
+			}
+		}
+
+		if int64(page*filteredSearchBatchSize) >= total {
+			break
+		}
+	}
+
+	skip, take := 0, len(matched)
+	if opts.Paginator != nil {
+		skip, take = opts.GetSkipTake()
+	}
+	skip = min(skip, len(matched))
+	return int64(len(matched)), matched[skip:min(skip+take, len(matched))], sortResultLanguages(languages), nil
+}
+
+func countResultLanguage(languages map[string]*SearchResultLanguages, language string) {
+	if language == "" {
+		return
+	}
+	if l, ok := languages[language]; ok {
+		l.Count++
+		return
+	}
+	languages[language] = &SearchResultLanguages{
+		Language: language,
+		Color:    enry.GetColor(language),
+		Count:    1,
+	}
+}
+
+// sortResultLanguages returns the ten most frequent languages, like the facets of the indexers
+func sortResultLanguages(languages map[string]*SearchResultLanguages) []*SearchResultLanguages {
+	sorted := make([]*SearchResultLanguages, 0, len(languages))
+	for _, l := range languages {
+		sorted = append(sorted, l)
+	}
+	slices.SortFunc(sorted, func(a, b *SearchResultLanguages) int {
+		return cmp.Or(b.Count-a.Count, strings.Compare(a.Language, b.Language))
+	})
+	return sorted[:min(len(sorted), 10)]
+}
@@ -0,0 +1,255 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package bleve
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/indexer/commits/internal"
+	indexer_internal "code.gitea.io/gitea/modules/indexer/internal"
+	inner_bleve "code.gitea.io/gitea/modules/indexer/internal/bleve"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	analyzer_keyword "github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/camelcase"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+const (
+	commitIndexerAnalyzer      = "commitIndexer"
+	commitIndexerDocType       = "commitIndexerDocType"
+	commitIndexerLatestVersion = 1
+)
+
+const unicodeNormalizeName = "unicodeNormalize"
+
+func addUnicodeNormalizeTokenFilter(m *mapping.IndexMappingImpl) error {
+	return m.AddCustomTokenFilter(unicodeNormalizeName, map[string]any{
+		"type": unicodenorm.Name,
+		"form": unicodenorm.NFC,
+	})
+}
+
+const (
+	maxBatchSize = 16
+	// fuzzyDenominator determines the levenshtein distance per each character of a keyword
+	fuzzyDenominator = 4
+	// see https://github.com/blevesearch/bleve/issues/1563#issuecomment-786822311
+	maxFuzziness = 2
+	// minCommitIDPrefixLength is the minimum length of a keyword to be searched as a commit id prefix,
+	// the sha256 pattern accepts sha1 ids too
+	minCommitIDPrefixLength = 7
+)
+
+// IndexerData an update to the commit indexer
+type IndexerData internal.IndexerData
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (i *IndexerData) Type() string {
+	return commitIndexerDocType
+}
+
+// generateCommitIndexMapping generates the bleve index mapping for commits
+func generateCommitIndexMapping() (mapping.IndexMapping, error) {
+	mapping := bleve.NewIndexMapping()
+	docMapping := bleve.NewDocumentMapping()
+
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.Store = false
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("repo_id", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("authored_unix", numericFieldMapping)
+
+	storedNumericFieldMapping := bleve.NewNumericFieldMapping()
+	storedNumericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("committed_unix", storedNumericFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.Store = false
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("committer_name", textFieldMapping)
+	docMapping.AddFieldMappingsAt("committer_email", textFieldMapping)
+	docMapping.AddFieldMappingsAt("files", textFieldMapping)
+	docMapping.AddFieldMappingsAt("diff", textFieldMapping)
+
+	// the fields shown in the search results are stored to avoid reading every commit from git
+	storedTextFieldMapping := bleve.NewTextFieldMapping()
+	storedTextFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("message", storedTextFieldMapping)
+	docMapping.AddFieldMappingsAt("author_name", storedTextFieldMapping)
+	docMapping.AddFieldMappingsAt("author_email", storedTextFieldMapping)
+
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.Store = false
+	keywordFieldMapping.IncludeInAll = false
+	keywordFieldMapping.Analyzer = analyzer_keyword.Name
+	docMapping.AddFieldMappingsAt("commit_id", keywordFieldMapping)
+
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(commitIndexerAnalyzer, map[string]any{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, camelcase.Name, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+
+	mapping.DefaultAnalyzer = commitIndexerAnalyzer
+	mapping.AddDocumentMapping(commitIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+	mapping.DefaultMapping = bleve.NewDocumentDisabledMapping() // disable default mapping, avoid indexing unexpected structs
+
+	return mapping, nil
+}
+
+var _ internal.Indexer = &Indexer{}
+
+// Indexer implements Indexer interface
+type Indexer struct {
+	inner                    *inner_bleve.Indexer
+	indexer_internal.Indexer // do not composite inner_bleve.Indexer directly to avoid exposing too much
+}
+
+// NewIndexer creates a new bleve local indexer
+func NewIndexer(indexDir string) *Indexer {
+	inner := inner_bleve.NewIndexer(indexDir, commitIndexerLatestVersion, generateCommitIndexMapping)
+	return &Indexer{
+		Indexer: inner,
+		inner:   inner,
+	}
+}
+
+// Index will save the index data
+func (b *Indexer) Index(_ context.Context, commits ...*internal.IndexerData) error {
+	batch := inner_bleve.NewFlushingBatch(b.inner.Indexer, maxBatchSize)
+	for _, commit := range commits {
+		if err := batch.Index(internal.IndexerID(commit.RepoID, commit.CommitID), (*IndexerData)(commit)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete deletes all commits of a repository
+func (b *Indexer) Delete(ctx context.Context, repoID int64) error {
+	query := inner_bleve.NumericEqualityQuery(repoID, "repo_id")
+	for {
+		// deleting changes the result, so always search the first page again
+		searchRequest := bleve.NewSearchRequestOptions(query, 1000, 0, false)
+		result, err := b.inner.Indexer.SearchInContext(ctx, searchRequest)
+		if err != nil {
+			return err
+		}
+		if len(result.Hits) == 0 {
+			return nil
+		}
+		batch := inner_bleve.NewFlushingBatch(b.inner.Indexer, maxBatchSize)
+		for _, hit := range result.Hits {
+			if err = batch.Delete(hit.ID); err != nil {
+				return err
+			}
+		}
+		if err := batch.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// Search searches for commits by given conditions.
+func (b *Indexer) Search(ctx context.Context, options *internal.SearchOptions) (*internal.SearchResult, error) {
+	var queries []query.Query
+
+	if options.Keyword != "" {
+		fuzziness := 0
+		if options.IsFuzzyKeyword {
+			fuzziness = min(maxFuzziness, len(options.Keyword)/fuzzyDenominator)
+		}
+
+		keywordQueries := []query.Query{
+			inner_bleve.MatchPhraseQuery(options.Keyword, "message", commitIndexerAnalyzer, fuzziness),
+			inner_bleve.MatchPhraseQuery(options.Keyword, "files", commitIndexerAnalyzer, fuzziness),
+			inner_bleve.MatchPhraseQuery(options.Keyword, "diff", commitIndexerAnalyzer, fuzziness),
+		}
+		if commitID := strings.ToLower(options.Keyword); len(commitID) >= minCommitIDPrefixLength && git.Sha256ObjectFormat.IsValid(commitID) {
+			prefixQuery := bleve.NewPrefixQuery(commitID)
+			prefixQuery.SetField("commit_id")
+			keywordQueries = append(keywordQueries, prefixQuery)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(keywordQueries...))
+	}
+
+	if len(options.RepoIDs) > 0 {
+		repoQueries := make([]query.Query, 0, len(options.RepoIDs))
+		for _, repoID := range options.RepoIDs {
+			repoQueries = append(repoQueries, inner_bleve.NumericEqualityQuery(repoID, "repo_id"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+
+	if options.Author != "" {
+		queries = append(queries, bleve.NewDisjunctionQuery(
+			inner_bleve.MatchPhraseQuery(options.Author, "author_name", commitIndexerAnalyzer, 0),
+			inner_bleve.MatchPhraseQuery(options.Author, "author_email", commitIndexerAnalyzer, 0),
+		))
+	}
+
+	if options.CommittedAfterUnix.Has() || options.CommittedBeforeUnix.Has() {
+		queries = append(queries, inner_bleve.NumericRangeInclusiveQuery(
+			options.CommittedAfterUnix,
+			options.CommittedBeforeUnix,
+			"committed_unix"))
+	}
+
+	var indexerQuery query.Query = bleve.NewConjunctionQuery(queries...)
+	if len(queries) == 0 {
+		indexerQuery = bleve.NewMatchAllQuery()
+	}
+
+	skip, limit := indexer_internal.ParsePaginator(options.Paginator)
+	search := bleve.NewSearchRequestOptions(indexerQuery, limit, skip, false)
+	search.Fields = []string{"message", "author_name", "author_email", "committed_unix"}
+
+	if options.SortBy == "" {
+		options.SortBy = internal.SortByCommittedDesc
+	}
+	search.SortBy([]string{string(options.SortBy), "-_id"})
+
+	result, err := b.inner.Indexer.SearchInContext(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &internal.SearchResult{
+		Total: int64(result.Total),
+		Hits:  make([]*internal.Match, 0, len(result.Hits)),
+	}
+	for _, hit := range result.Hits {
+		repoID, commitID, err := internal.ParseIndexerID(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+		match := &internal.Match{
+			RepoID:   repoID,
+			CommitID: commitID,
+		}
+		match.Message, _ = hit.Fields["message"].(string)
+		match.AuthorName, _ = hit.Fields["author_name"].(string)
+		match.AuthorEmail, _ = hit.Fields["author_email"].(string)
+		if committed, ok := hit.Fields["committed_unix"].(float64); ok {
+			match.CommittedUnix = timeutil.TimeStamp(committed)
+		}
+		ret.Hits = append(ret.Hits, match)
+	}
+	return ret, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/indexer/commits/internal"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+const (
+	// commitLogFormat prints a record separator before every commit and a unit separator after every field,
+	// the files printed by --name-only follow the last separator
+	commitLogFormat = "--format=%x1e%H%x1f%an%x1f%ae%x1f%at%x1f%cn%x1f%ce%x1f%ct%x1f%B%x1f"
+	commitLogFields = 8
+	// commitBatchSize is the number of commits passed to the indexer at once
+	commitBatchSize = 100
+)
+
+func getDefaultBranchSha(ctx context.Context, repo *repo_model.Repository) (string, error) {
+	stdout, _, err := git.NewCommand(ctx, "show-ref", "-s").AddDynamicArguments(git.BranchPrefix + repo.DefaultBranch).RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// isAncestor returns whether the commit is an ancestor of the revision,
+// it is false if the commit doesn't exist anymore
+func isAncestor(ctx context.Context, repo *repo_model.Repository, commitID, revision string) bool {
+	_, _, err := git.NewCommand(ctx, "merge-base", "--is-ancestor").AddDynamicArguments(commitID, revision).RunStdString(&git.RunOpts{Dir: repo.RepoPath()})
+	return err == nil
+}
+
+// walkCommits calls fn with batches of the commits reachable from revision,
+// the commits reachable from since are skipped if it is not empty
+func walkCommits(ctx context.Context, repo *repo_model.Repository, revision, since string, fn func([]*internal.IndexerData) error) error {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stdoutReader.Close()
+		_ = stdoutWriter.Close()
+	}()
+
+	cmd := git.NewCommand(ctx, "log", "--no-color", commitLogFormat)
+	if setting.Indexer.CommitIndexerIncludeFiles {
+		cmd.AddArguments("--name-only", "--no-renames")
+	}
+	cmd.AddDynamicArguments(revision)
+	if since != "" {
+		cmd.AddDynamicArguments("^" + since)
+	}
+	cmd.AddDashesAndList()
+
+	return cmd.Run(&git.RunOpts{
+		Dir:               repo.RepoPath(),
+		Stdout:            stdoutWriter,
+		UseContextTimeout: true,
+		PipelineFunc: func(ctx context.Context, cancel context.CancelFunc) error {
+			_ = stdoutWriter.Close()
+			defer func() {
+				_ = stdoutReader.Close()
+			}()
+
+			reader := bufio.NewReader(stdoutReader)
+			batch := make([]*internal.IndexerData, 0, commitBatchSize)
+			for {
+				record, err := reader.ReadString('\x1e')
+				if err != nil && err != io.EOF {
+					return err
+				}
+				if record = strings.TrimSuffix(record, "\x1e"); strings.TrimSpace(record) != "" {
+					commit, parseErr := parseCommitRecord(repo.ID, record)
+					if parseErr != nil {
+						return parseErr
+					}
+					if setting.Indexer.CommitIndexerIncludeDiff {
+						if commit.Diff, parseErr = getCommitDiff(ctx, repo, commit.CommitID); parseErr != nil {
+							return parseErr
+						}
+					}
+					batch = append(batch, commit)
+				}
+				if len(batch) > 0 && (len(batch) == commitBatchSize || err == io.EOF) {
+					if err := fn(batch); err != nil {
+						return err
+					}
+					batch = batch[:0]
+				}
+				if err == io.EOF {
+					return nil
+				}
+			}
+		},
+	})
+}
+
+// parseCommitRecord parses the output of commitLogFormat for one commit
+func parseCommitRecord(repoID int64, record string) (*internal.IndexerData, error) {
+	fields := strings.SplitN(record, "\x1f", commitLogFields+1)
+	if len(fields) != commitLogFields+1 {
+		return nil, fmt.Errorf("misformatted git log output: %q", record)
+	}
+	authored, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("misformatted git log output: %w", err)
+	}
+	committed, err := strconv.ParseInt(fields[6], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("misformatted git log output: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(fields[8], "\n") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return &internal.IndexerData{
+		RepoID:         repoID,
+		CommitID:       fields[0],
+		AuthorName:     fields[1],
+		AuthorEmail:    fields[2],
+		AuthoredUnix:   timeutil.TimeStamp(authored),
+		CommitterName:  fields[4],
+		CommitterEmail: fields[5],
+		CommittedUnix:  timeutil.TimeStamp(committed),
+		Message:        strings.TrimSpace(fields[7]),
+		Files:          files,
+	}, nil
+}
+
+// getCommitDiff returns the added and removed lines of a commit, truncated to COMMIT_INDEXER_MAX_DIFF_SIZE.
+// Merge commits have no diff.
+func getCommitDiff(ctx context.Context, repo *repo_model.Repository, commitID string) (string, error) {
+	stdout := &limitedBuffer{limit: int(setting.Indexer.MaxCommitIndexerDiffSize)}
+	err := git.NewCommand(ctx, "diff-tree", "-p", "-r", "--root", "--unified=0", "--no-color", "--no-ext-diff", "--no-commit-id").
+		AddDynamicArguments(commitID).
+		Run(&git.RunOpts{Dir: repo.RepoPath(), Stdout: stdout})
+	if err != nil {
+		return "", err
+	}
+
+	var diff strings.Builder
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+			continue
+		}
+		if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			diff.WriteString(line[1:])
+			diff.WriteByte('\n')
+		}
+	}
+	return diff.String(), nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest,
+// unlike io.LimitReader it doesn't make the writing command fail
+type limitedBuffer struct {
+	strings.Builder
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining > 0 {
+		_, _ = b.Builder.Write(p[:min(len(p), remaining)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+	"os"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/indexer/commits/bleve"
+	"code.gitea.io/gitea/modules/indexer/commits/internal"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// IndexerQueueData is the data pushed to the commit indexer queue
+type IndexerQueueData struct {
+	RepoID int64
+}
+
+var (
+	indexerQueue *queue.WorkerPoolQueue[*IndexerQueueData]
+	// globalIndexer is the global indexer, it cannot be nil.
+	// When the real indexer is not ready, it will be a dummy indexer which will return error to explain it's not ready.
+	// So it's always safe use it as *globalIndexer.Load() and call its methods.
+	globalIndexer atomic.Pointer[internal.Indexer]
+	dummyIndexer  *internal.Indexer
+)
+
+func init() {
+	i := internal.NewDummyIndexer()
+	dummyIndexer = &i
+	globalIndexer.Store(dummyIndexer)
+}
+
+// index indexes the commits of the default branch which have been added since the last run
+func index(ctx context.Context, indexer internal.Indexer, repoID int64) error {
+	repo, err := repo_model.GetRepositoryByID(ctx, repoID)
+	if repo_model.IsErrRepoNotExist(err) {
+		return indexer.Delete(ctx, repoID)
+	}
+	if err != nil {
+		return err
+	}
+	if repo.IsEmpty {
+		return nil
+	}
+
+	sha, err := getDefaultBranchSha(ctx, repo)
+	if err != nil {
+		return err
+	}
+	status, err := repo_model.GetIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCommits)
+	if err != nil {
+		return err
+	}
+	if status.CommitSha == sha {
+		return nil
+	}
+
+	since := status.CommitSha
+	if since != "" && !isAncestor(ctx, repo, since, sha) {
+		// the default branch has been changed or force pushed, its commits are indexed again
+		if err := indexer.Delete(ctx, repo.ID); err != nil {
+			return err
+		}
+		since = ""
+	}
+
+	if err := walkCommits(ctx, repo, sha, since, func(commits []*internal.IndexerData) error {
+		return indexer.Index(ctx, commits...)
+	}); err != nil {
+		return err
+	}
+
+	return repo_model.UpdateIndexerStatus(ctx, repo, repo_model.RepoIndexerTypeCommits, sha)
+}
+
+// Init initialize the commit indexer
+func Init() {
+	if !setting.Indexer.CommitIndexerEnabled {
+		(*globalIndexer.Load()).Close()
+		return
+	}
+
+	ctx, cancel, finished := process.GetManager().AddTypedContext(context.Background(), "Service: CommitIndexer", process.SystemProcessType, false)
+
+	graceful.GetManager().RunAtTerminate(func() {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		cancel()
+		log.Debug("Closing commit indexer")
+		(*globalIndexer.Load()).Close()
+		log.Info("PID: %d Commit Indexer closed", os.Getpid())
+		finished()
+	})
+
+	waitChannel := make(chan time.Duration, 1)
+
+	// Create the Queue
+	switch setting.Indexer.CommitType {
+	case "bleve":
+		handler := func(items ...*IndexerQueueData) (unhandled []*IndexerQueueData) {
+			indexer := *globalIndexer.Load()
+			// make it a process to allow for cancellation (especially during integration tests where no global shutdown happens)
+			batchCtx, _, finished := process.GetManager().AddContext(ctx, "CommitIndexer batch")
+			defer finished()
+			for _, item := range items {
+				log.Trace("CommitIndexer Process Repo: %d", item.RepoID)
+				if err := index(batchCtx, indexer, item.RepoID); err != nil {
+					unhandled = append(unhandled, item)
+					if !setting.IsInTesting {
+						log.Error("Commit indexer handler: index error for repo %v: %v", item.RepoID, err)
+					}
+				}
+			}
+			return unhandled
+		}
+
+		indexerQueue = queue.CreateUniqueQueue(ctx, "commit_indexer", handler)
+		if indexerQueue == nil {
+			log.Fatal("Unable to create commit indexer queue")
+		}
+	default:
+		log.Fatal("Unknown commit indexer type; %s", setting.Indexer.CommitType)
+	}
+
+	go func() {
+		pprof.SetGoroutineLabels(ctx)
+		start := time.Now()
+		log.Info("PID: %d Initializing Commit Indexer at: %s", os.Getpid(), setting.Indexer.CommitPath)
+		defer func() {
+			if err := recover(); err != nil {
+				log.Error("PANIC whilst initializing commit indexer: %v\nStacktrace: %s", err, log.Stack(2))
+				log.Error("The indexer files are likely corrupted and may need to be deleted")
+				log.Error("You can completely remove the \"%s\" directory to make Forgejo recreate the indexes", setting.Indexer.CommitPath)
+			}
+		}()
+
+		var cIndexer internal.Indexer = bleve.NewIndexer(setting.Indexer.CommitPath)
+		existed, err := cIndexer.Init(ctx)
+		if err != nil {
+			cancel()
+			(*globalIndexer.Load()).Close()
+			close(waitChannel)
+			log.Fatal("PID: %d Unable to initialize the bleve Commit Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.CommitPath, err)
+		}
+
+		globalIndexer.Store(&cIndexer)
+
+		// Start processing the queue
+		go graceful.GetManager().RunWithCancel(indexerQueue)
+
+		if !existed { // populate the index because it's created for the first time
+			go graceful.GetManager().RunWithShutdownContext(populateCommitIndexer)
+		}
+		select {
+		case waitChannel <- time.Since(start):
+		case <-graceful.GetManager().IsShutdown():
+		}
+
+		close(waitChannel)
+	}()
+
+	if setting.Indexer.StartupTimeout > 0 {
+		go func() {
+			pprof.SetGoroutineLabels(ctx)
+			timeout := setting.Indexer.StartupTimeout
+			if graceful.GetManager().IsChild() && setting.GracefulHammerTime > 0 {
+				timeout += setting.GracefulHammerTime
+			}
+			select {
+			case <-graceful.GetManager().IsShutdown():
+				log.Warn("Shutdown before Commit Indexer completed initialization")
+				cancel()
+				(*globalIndexer.Load()).Close()
+			case duration, ok := <-waitChannel:
+				if !ok {
+					log.Warn("Commit Indexer Initialization failed")
+					cancel()
+					(*globalIndexer.Load()).Close()
+					return
+				}
+				log.Info("Commit Indexer Initialization took %v", duration)
+			case <-time.After(timeout):
+				cancel()
+				(*globalIndexer.Load()).Close()
+				log.Fatal("Commit Indexer Initialization Timed-Out after: %v", timeout)
+			}
+		}()
+	}
+}
+
+// UpdateRepoIndexer queues the new commits of a repository's default branch to be indexed,
+// or the removal of its commits if the repository has been deleted
+func UpdateRepoIndexer(repo *repo_model.Repository) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		return
+	}
+	data := &IndexerQueueData{RepoID: repo.ID}
+	if err := indexerQueue.Push(data); err != nil {
+		log.Error("Update commit index data %v failed: %v", data, err)
+	}
+}
+
+// IsAvailable checks if commit indexer is available
+func IsAvailable(ctx context.Context) bool {
+	return (*globalIndexer.Load()).Ping(ctx) == nil
+}
+
+// populateCommitIndexer populates the commit indexer with the commits of the existing repositories.
+// It should only be run when the indexer is created for the first time.
+func populateCommitIndexer(ctx context.Context) {
+	log.Info("Populating the commit indexer with existing repositories")
+
+	// the statuses of an older index are invalid, only the ones of the commit indexer are deleted
+	// because the other indexers keep their data
+	if _, err := db.GetEngine(ctx).Where("indexer_type = ?", repo_model.RepoIndexerTypeCommits).Delete(&repo_model.RepoIndexerStatus{}); err != nil {
+		log.Error("Unable to delete commit indexer statuses: %v", err)
+		return
+	}
+
+	maxRepoID, err := db.GetMaxID("repository")
+	if err != nil {
+		log.Error("Unable to get max repository id: %v", err)
+		return
+	}
+
+	// start with the maximum existing repo ID and work backwards, so that we
+	// don't include repos that are created after forgejo starts; such repos will
+	// already be added to the indexer, and we don't need to add them again.
+	for maxRepoID > 0 {
+		select {
+		case <-ctx.Done():
+			log.Info("Commit Indexer population shutdown before completion")
+			return
+		default:
+		}
+		ids, err := repo_model.GetUnindexedRepos(ctx, repo_model.RepoIndexerTypeCommits, maxRepoID, 0, 50)
+		if err != nil {
+			log.Error("populateCommitIndexer: %v", err)
+			return
+		} else if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if err := indexerQueue.Push(&IndexerQueueData{RepoID: id}); err != nil {
+				log.Error("indexerQueue.Push: %v", err)
+				return
+			}
+			maxRepoID = id - 1
+		}
+	}
+	log.Info("Done populating the commit indexer with existing repositories")
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/indexer/commits/bleve"
+	"code.gitea.io/gitea/modules/indexer/commits/internal"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	_ "code.gitea.io/gitea/models"
+	_ "code.gitea.io/gitea/models/actions"
+	_ "code.gitea.io/gitea/models/activities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	unittest.MainTest(m)
+}
+
+func searchCommitIDs(t *testing.T, opts *SearchOptions) []string {
+	t.Helper()
+	opts.Paginator = &db.ListOptions{Page: 1, PageSize: 10}
+	matches, total, err := SearchCommits(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, matches, int(total))
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.CommitID[:7])
+	}
+	return ids
+}
+
+func TestBleveIndexAndSearch(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.Indexer.CommitIndexerIncludeDiff, true)()
+
+	indexer := bleve.NewIndexer(t.TempDir())
+	_, err := indexer.Init(context.Background())
+	require.NoError(t, err)
+	defer indexer.Close()
+
+	var i internal.Indexer = indexer
+	globalIndexer.Store(&i)
+	defer globalIndexer.Store(dummyIndexer)
+
+	require.NoError(t, index(context.Background(), indexer, 2))
+	require.NoError(t, index(context.Background(), indexer, 16))
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+	status, err := repo_model.GetIndexerStatus(context.Background(), repo, repo_model.RepoIndexerTypeCommits)
+	require.NoError(t, err)
+	assert.Equal(t, "1032bbf17fbc0d9c95bb5418dabe8f8c99278700", status.CommitSha)
+
+	t.Run("Message", func(t *testing.T) {
+		assert.Equal(t, []string{"205ac76"}, searchCommitIDs(t, &SearchOptions{Keyword: "svg image"}))
+		assert.Equal(t, []string{"69554a6", "27566bd", "5099b81"}, searchCommitIDs(t, &SearchOptions{Keyword: "signed commit"}))
+		assert.Equal(t, []string{"5099b81", "27566bd", "69554a6"}, searchCommitIDs(t, &SearchOptions{Keyword: "signed commit", SortBy: SortByCommittedAsc}))
+	})
+
+	t.Run("Files", func(t *testing.T) {
+		assert.Equal(t, []string{"2c54fae"}, searchCommitIDs(t, &SearchOptions{Keyword: "Home.md"}))
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		assert.Equal(t, []string{"1032bbf"}, searchCommitIDs(t, &SearchOptions{Keyword: "This is XML"}))
+	})
+
+	t.Run("CommitID", func(t *testing.T) {
+		assert.Equal(t, []string{"205ac76"}, searchCommitIDs(t, &SearchOptions{Keyword: "205AC761F3"}))
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		assert.Equal(t, []string{"1032bbf"}, searchCommitIDs(t, &SearchOptions{Author: "jimmy"}))
+		assert.Equal(t, []string{"27566bd"}, searchCommitIDs(t, &SearchOptions{Author: "user21@example.com"}))
+		assert.Equal(t, []string{"69554a6", "27566bd", "5099b81"}, searchCommitIDs(t, &SearchOptions{RepoIDs: []int64{16}}))
+		assert.Equal(t, []string{"1032bbf", "205ac76"}, searchCommitIDs(t, &SearchOptions{
+			RepoIDs:            []int64{2},
+			CommittedAfterUnix: optional.Some[int64](1600000000),
+		}))
+		assert.Equal(t, []string{"2c54fae"}, searchCommitIDs(t, &SearchOptions{
+			RepoIDs:             []int64{2},
+			CommittedBeforeUnix: optional.Some[int64](1600000000),
+		}))
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		// forget the newest commit, it is indexed again from the last status
+		require.NoError(t, indexer.Delete(context.Background(), 2))
+		require.NoError(t, repo_model.UpdateIndexerStatus(context.Background(), repo, repo_model.RepoIndexerTypeCommits, "205ac761f3326a7ebe416e8673760016450b5cec"))
+		require.NoError(t, index(context.Background(), indexer, 2))
+		assert.Equal(t, []string{"1032bbf"}, searchCommitIDs(t, &SearchOptions{RepoIDs: []int64{2}}))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, indexer.Delete(context.Background(), 16))
+		assert.Empty(t, searchCommitIDs(t, &SearchOptions{RepoIDs: []int64{16}}))
+	})
+}
+
+func TestParseCommitRecord(t *testing.T) {
+	commit, err := parseCommitRecord(1, "abc\x1fAlice\x1falice@example.com\x1f100\x1fBob\x1fbob@example.com\x1f200\x1fsubject\n\nbody\n\x1f\n\ndir/a.go\nb.go\n")
+	require.NoError(t, err)
+	assert.Equal(t, &internal.IndexerData{
+		RepoID:         1,
+		CommitID:       "abc",
+		Message:        "subject\n\nbody",
+		AuthorName:     "Alice",
+		AuthorEmail:    "alice@example.com",
+		CommitterName:  "Bob",
+		CommitterEmail: "bob@example.com",
+		AuthoredUnix:   100,
+		CommittedUnix:  200,
+		Files:          []string{"dir/a.go", "b.go"},
+	}, commit)
+
+	_, err = parseCommitRecord(1, "abc\x1fAlice")
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// IndexerData data stored in the commit indexer
+type IndexerData struct {
+	RepoID         int64              `json:"repo_id"`
+	CommitID       string             `json:"commit_id"`
+	Message        string             `json:"message"`
+	AuthorName     string             `json:"author_name"`
+	AuthorEmail    string             `json:"author_email"`
+	CommitterName  string             `json:"committer_name"`
+	CommitterEmail string             `json:"committer_email"`
+	AuthoredUnix   timeutil.TimeStamp `json:"authored_unix"`
+	CommittedUnix  timeutil.TimeStamp `json:"committed_unix"`
+
+	// Files are the paths changed by the commit, empty if COMMIT_INDEXER_INCLUDE_FILES is disabled
+	Files []string `json:"files"`
+	// Diff are the changed lines of the commit, empty if COMMIT_INDEXER_INCLUDE_DIFF is disabled
+	Diff string `json:"diff"`
+}
+
+// IndexerID returns the document id of a commit, the commit id is not unique across forks
+func IndexerID(repoID int64, commitID string) string {
+	return strconv.FormatInt(repoID, 36) + "_" + commitID
+}
+
+// ParseIndexerID parses the document id of a commit
+func ParseIndexerID(id string) (int64, string, error) {
+	repoID, commitID, ok := strings.Cut(id, "_")
+	if !ok {
+		return 0, "", fmt.Errorf("invalid commit indexer id %q", id)
+	}
+	n, err := strconv.ParseInt(repoID, 36, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid commit indexer id %q: %w", id, err)
+	}
+	return n, commitID, nil
+}
+
+type SortBy string
+
+const (
+	SortByCommittedDesc SortBy = "-committed_unix"
+	SortByCommittedAsc  SortBy = "committed_unix"
+	SortByScore         SortBy = "-_score"
+)
+
+// SearchOptions represents search options
+type SearchOptions struct {
+	Keyword        string
+	IsFuzzyKeyword bool
+
+	RepoIDs []int64 // repository IDs which the commits belong to, empty means all repositories
+
+	Author string // matches the author name or email
+
+	CommittedAfterUnix  optional.Option[int64]
+	CommittedBeforeUnix optional.Option[int64]
+
+	Paginator *db.ListOptions
+
+	SortBy SortBy // sort by field, defaults to SortByCommittedDesc
+}
+
+// Match represents a commit matched by a search
+type Match struct {
+	RepoID        int64
+	CommitID      string
+	Message       string
+	AuthorName    string
+	AuthorEmail   string
+	CommittedUnix timeutil.TimeStamp
+}
+
+// SearchResult represents search results
+type SearchResult struct {
+	Total int64
+	Hits  []*Match
+}
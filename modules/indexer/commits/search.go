@@ -0,0 +1,31 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commits
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/modules/indexer/commits/internal"
+)
+
+// SearchOptions represents the options of a commit search
+type SearchOptions = internal.SearchOptions
+
+// Match represents a commit found by a search
+type Match = internal.Match
+
+const (
+	SortByCommittedDesc = internal.SortByCommittedDesc
+	SortByCommittedAsc  = internal.SortByCommittedAsc
+	SortByScore         = internal.SortByScore
+)
+
+// SearchCommits searches the indexed commits, the total count of matching commits is returned too
+func SearchCommits(ctx context.Context, opts *SearchOptions) ([]*Match, int64, error) {
+	result, err := (*globalIndexer.Load()).Search(ctx, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.Hits, result.Total, nil
+}
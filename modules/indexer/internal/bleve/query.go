@@ -28,6 +28,13 @@ func MatchPhraseQuery(matchPhrase, field, analyzer string, fuzziness int) *query
 	return q
 }
 
+// TermQuery generates a query which matches the exact value of a keyword field
+func TermQuery(value, field string) *query.TermQuery {
+	q := bleve.NewTermQuery(value)
+	q.SetField(field)
+	return q
+}
+
 // BoolFieldQuery generates a bool field query for the given value and field
 func BoolFieldQuery(value bool, field string) *query.BoolFieldQuery {
 	q := bleve.NewBoolFieldQuery(value)
@@ -80,6 +80,18 @@ func NewFilterEq[T bool | int64](field string, value T) FilterEq {
 	return FilterEq(fmt.Sprintf("%s = %v", field, value))
 }
 
+// NewFilterEqString creates a new FilterEq for a string value, the value is quoted and its quotes and backslashes are
+// escaped.
+func NewFilterEqString(field, value string) FilterEq {
+	return FilterEq(fmt.Sprintf("%s = %s", field, quoteFilterString(value)))
+}
+
+var filterStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func quoteFilterString(value string) string {
+	return `"` + filterStringEscaper.Replace(value) + `"`
+}
+
 func (f FilterEq) Statement() string {
 	return string(f)
 }
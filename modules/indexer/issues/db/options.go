@@ -71,6 +71,7 @@ func ToDBOptions(ctx context.Context, options *internal.SearchOptions) (*issue_m
 		IssueIDs:           nil,
 		UpdatedAfterUnix:   options.UpdatedAfterUnix.Value(),
 		UpdatedBeforeUnix:  options.UpdatedBeforeUnix.Value(),
+		FieldValues:        options.FieldValues,
 		PriorityRepoID:     0,
 		IsArchived:         optional.None[bool](),
 		Org:                nil,
@@ -70,6 +70,8 @@ func ToSearchOptions(keyword string, opts *issues_model.IssuesOptions) *SearchOp
 		searchOpt.UpdatedBeforeUnix = optional.Some(opts.UpdatedBeforeUnix)
 	}
 
+	searchOpt.FieldValues = opts.FieldValues
+
 	searchOpt.Paginator = opts.Paginator
 
 	switch opts.SortType {
@@ -34,6 +34,7 @@ type IndexerData struct {
 	ReviewedIDs        []int64            `json:"reviewed_ids"`
 	ReviewRequestedIDs []int64            `json:"review_requested_ids"`
 	SubscriberIDs      []int64            `json:"subscriber_ids"`
+	FieldValues        []string           `json:"field_values"` // values of custom fields, formatted as "<field id>:<value>"
 	UpdatedUnix        timeutil.TimeStamp `json:"updated_unix"`
 
 	// Fields used for sorting
@@ -103,6 +104,8 @@ type SearchOptions struct {
 
 	SubscriberID optional.Option[int64] // subscriber of the issues
 
+	FieldValues []string // values of custom fields the issues have, formatted as "<field id>:<value>"
+
 	UpdatedAfterUnix  optional.Option[int64]
 	UpdatedBeforeUnix optional.Option[int64]
 
@@ -513,6 +513,25 @@ var cases = []*testIndexerCase{
 			}), result.Total)
 		},
 	},
+	{
+		Name: "FieldValues",
+		SearchOptions: &internal.SearchOptions{
+			Paginator: &db.ListOptions{
+				PageSize: 5,
+			},
+			FieldValues: []string{"1:high", "2:v1.0"},
+		},
+		Expected: func(t *testing.T, data map[int64]*internal.IndexerData, result *internal.SearchResult) {
+			assert.Equal(t, 5, len(result.Hits))
+			for _, v := range result.Hits {
+				assert.Contains(t, data[v.ID].FieldValues, "1:high")
+				assert.Contains(t, data[v.ID].FieldValues, "2:v1.0")
+			}
+			assert.Equal(t, countIndexerData(data, func(v *internal.IndexerData) bool {
+				return slices.Contains(v.FieldValues, "1:high") && slices.Contains(v.FieldValues, "2:v1.0")
+			}), result.Total)
+		},
+	},
 	{
 		Name: "updated",
 		SearchOptions: &internal.SearchOptions{
@@ -706,6 +725,10 @@ func generateDefaultIndexerData() []*internal.IndexerData {
 			for i := range subscriberIDs {
 				subscriberIDs[i] = int64(i) + 1 // SubscriberID should not be 0
 			}
+			fieldValues := []string{
+				fmt.Sprintf("1:%s", []string{"low", "medium", "high"}[id%3]),
+				fmt.Sprintf("2:v1.%d", issueIndex%2),
+			}
 
 			data = append(data, &internal.IndexerData{
 				ID:                 id,
@@ -727,6 +750,7 @@ func generateDefaultIndexerData() []*internal.IndexerData {
 				ReviewedIDs:        reviewedIDs,
 				ReviewRequestedIDs: reviewRequestedIDs,
 				SubscriberIDs:      subscriberIDs,
+				FieldValues:        fieldValues,
 				UpdatedUnix:        timeutil.TimeStamp(id + issueIndex),
 				CreatedUnix:        timeutil.TimeStamp(id),
 				DeadlineUnix:       timeutil.TimeStamp(id + issueIndex + repoID),
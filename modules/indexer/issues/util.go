@@ -92,6 +92,19 @@ func getIssueIndexerData(ctx context.Context, issueID int64) (*internal.IndexerD
 		projectID = issue.Project.ID
 	}
 
+	var fieldValues []string
+	{
+		values, err := issue_model.GetIssueFieldValues(ctx, issue.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		for fieldID, v := range values[issue.ID] {
+			for _, value := range v {
+				fieldValues = append(fieldValues, issue_model.FormatFieldValue(fieldID, value))
+			}
+		}
+	}
+
 	return &internal.IndexerData{
 		ID:                 issue.ID,
 		RepoID:             issue.RepoID,
@@ -112,6 +125,7 @@ func getIssueIndexerData(ctx context.Context, issueID int64) (*internal.IndexerD
 		ReviewedIDs:        reviewedIDs,
 		ReviewRequestedIDs: reviewRequestedIDs,
 		SubscriberIDs:      subscriberIDs,
+		FieldValues:        fieldValues,
 		UpdatedUnix:        issue.UpdatedUnix,
 		CreatedUnix:        issue.CreatedUnix,
 		DeadlineUnix:       issue.DeadlineUnix,
@@ -4,6 +4,7 @@
 package nosql
 
 import (
+	"context"
 	"crypto/tls"
 	"net/url"
 	"path"
@@ -102,13 +103,16 @@ func (m *Manager) getRedisClient(connection string) redis.UniversalClient {
 		opts.TLSConfig = tlsConfig
 		fallthrough
 	case "redis+sentinel":
-		client.UniversalClient = redis.NewFailoverClient(opts.Failover())
+		client.UniversalClient = newRedisFailoverClient(uri, opts)
 	case "redis+clusters":
 		fallthrough
 	case "rediss+cluster":
 		opts.TLSConfig = tlsConfig
 		fallthrough
 	case "redis+cluster":
+		if opts.DB != 0 {
+			log.Warn("Redis Cluster only supports database 0, the database %d of %s is ignored", opts.DB, uri.Redacted())
+		}
 		client.UniversalClient = redis.NewClusterClient(opts.Cluster())
 	case "redis+socket":
 		simpleOpts := opts.Simple()
@@ -133,6 +137,36 @@ func (m *Manager) getRedisClient(connection string) redis.UniversalClient {
 	return client
 }
 
+// newRedisFailoverClient creates a client for the master monitored by the Redis Sentinels of the uri.
+// If read-only commands may be routed to the replicas, a cluster client which knows all nodes of the master is needed.
+func newRedisFailoverClient(uri *url.URL, opts *redis.UniversalOptions) redis.UniversalClient {
+	if opts.MasterName == "" {
+		log.Error("Redis Sentinel connection %s has no master name, please add the masterName option", uri.Redacted())
+	}
+
+	failoverOpts := opts.Failover()
+	failoverOpts.RouteByLatency = opts.RouteByLatency
+	failoverOpts.RouteRandomly = opts.RouteRandomly
+	if opts.RouteByLatency || opts.RouteRandomly {
+		return redis.NewFailoverClusterClient(failoverOpts)
+	}
+	return redis.NewFailoverClient(failoverOpts)
+}
+
+// ForEachRedisMaster calls fn for every master of a Redis Cluster, other clients are passed to fn as they are.
+// It is needed for commands like FLUSHDB or DBSIZE which only affect the node they are sent to.
+func ForEachRedisMaster(ctx context.Context, client redis.UniversalClient, fn func(context.Context, redis.UniversalClient) error) error {
+	if holder, ok := client.(*redisClientHolder); ok {
+		client = holder.UniversalClient
+	}
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+			return fn(ctx, master)
+		})
+	}
+	return fn(ctx, client)
+}
+
 // getRedisOptions pulls various configuration options based on the RedisUri format and converts them to go-redis's
 // UniversalOptions fields. This function explicitly excludes fields related to TLS configuration, which is
 // conditionally attached to this options struct before being converted to the specific type for the redis scheme being
@@ -4,8 +4,15 @@
 package nosql
 
 import (
+	"context"
 	"net/url"
+	"sync/atomic"
 	"testing"
+
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRedisUsernameOpt(t *testing.T) {
@@ -79,3 +86,48 @@ func TestRedisDatabaseIndexUnix(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestRedisSentinelRouting(t *testing.T) {
+	uri, _ := url.Parse("redis+sentinel://127.0.0.1:26379/0?mastername=mymaster")
+	client := newRedisFailoverClient(uri, getRedisOptions(uri))
+	defer client.Close()
+	if _, ok := client.(*redis.Client); !ok {
+		t.Errorf("expected a failover client, got %T", client)
+	}
+
+	uri, _ = url.Parse("redis+sentinel://127.0.0.1:26379/0?mastername=mymaster&routebylatency=true")
+	client = newRedisFailoverClient(uri, getRedisOptions(uri))
+	defer client.Close()
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		t.Errorf("expected a failover cluster client, got %T", client)
+	}
+}
+
+func testRedisClient(t *testing.T, connection string) {
+	client := GetManager().GetRedisClient(connection)
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, client.Set(ctx, "test:"+key, key, 0).Err())
+	}
+	value, err := client.Get(ctx, "test:b").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", value)
+
+	var size atomic.Int64
+	assert.NoError(t, ForEachRedisMaster(ctx, client, func(ctx context.Context, client redis.UniversalClient) error {
+		n, err := client.DBSize(ctx).Result()
+		size.Add(n)
+		return err
+	}))
+	assert.EqualValues(t, 4, size.Load())
+}
+
+func TestRedisSentinelWithServer(t *testing.T) {
+	testRedisClient(t, test.StartRedisSentinel(t, 6390, 26390))
+}
+
+func TestRedisClusterWithServer(t *testing.T) {
+	testRedisClient(t, test.StartRedisCluster(t, 7101, 7102, 7103))
+}
@@ -21,6 +21,10 @@ import (
 // redis+sentinel://[password@]host1 [: port1][, host2 [:port2]][, hostN [:portN]][/ database][?[option=value]*]
 // redis+cluster://[password@]host1 [: port1][, host2 [:port2]][, hostN [:portN]][/ database][?[option=value]*]
 //
+// For redis+sentinel the hosts are the sentinels, the masterName option is required and the sentinelPassword option
+// is used to authenticate against the sentinels, the password of the URI is the one of the master. A Redis Cluster
+// only supports database 0.
+//
 // We have previously used a URI like:
 // addrs=127.0.0.1:6379 db=0
 // network=tcp,addr=127.0.0.1:6379,password=macaron,db=0,pool_size=100,idle_timeout=180
@@ -98,3 +102,19 @@ func ToRedisURI(connection string) *url.URL {
 
 	return uri
 }
+
+// IsRedisClusterURI returns whether the connection string is for a Redis Cluster
+func IsRedisClusterURI(connection string) bool {
+	scheme := ToRedisURI(connection).Scheme
+	return strings.HasSuffix(scheme, "+cluster") || strings.HasSuffix(scheme, "+clusters")
+}
+
+// HasRedisHashTag returns whether the key contains a hash tag, a non-empty part between the first "{" and the following "}".
+// A Redis Cluster only hashes the hash tag of such keys to find their slot, so keys with the same hash tag are in the same slot.
+func HasRedisHashTag(key string) bool {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return false
+	}
+	return strings.IndexByte(key[start+1:], '}') > 0
+}
@@ -32,3 +32,32 @@ func TestToRedisURI(t *testing.T) {
 		})
 	}
 }
+
+func TestIsRedisClusterURI(t *testing.T) {
+	for connection, want := range map[string]bool{
+		"redis://127.0.0.1:6379/0":                        false,
+		"redis+sentinel://127.0.0.1:26379/0?mastername=m": false,
+		"redis+cluster://127.0.0.1:7001,127.0.0.1:7002":   true,
+		"rediss+cluster://127.0.0.1:7001":                 true,
+		"redis+clusters://127.0.0.1:7001":                 true,
+	} {
+		if got := IsRedisClusterURI(connection); got != want {
+			t.Errorf(`IsRedisClusterURI(%q) = %v, want %v`, connection, got, want)
+		}
+	}
+}
+
+func TestHasRedisHashTag(t *testing.T) {
+	for key, want := range map[string]bool{
+		"queue":           false,
+		"{queue}":         true,
+		"prefix:{tag}:":   true,
+		"prefix:{}:{tag}": false,
+		"prefix:{":        false,
+		"}{tag":           false,
+	} {
+		if got := HasRedisHashTag(key); got != want {
+			t.Errorf(`HasRedisHashTag(%q) = %v, want %v`, key, got, want)
+		}
+	}
+}
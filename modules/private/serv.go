@@ -20,9 +20,10 @@ type KeyAndOwner struct {
 	Owner *user_model.User         `json:"user"`
 }
 
-// ServNoCommand returns information about the provided key
-func ServNoCommand(ctx context.Context, keyID int64) (*asymkey_model.PublicKey, *user_model.User, error) {
-	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/none/%d", keyID)
+// ServNoCommand returns information about the provided key, or about the provided user if keyID is 0 and the user
+// authenticated with a certificate of a trusted certificate authority
+func ServNoCommand(ctx context.Context, keyID, userID int64) (*asymkey_model.PublicKey, *user_model.User, error) {
+	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/none/%d?user=%d", keyID, userID)
 	req := newInternalRequest(ctx, reqURL, "GET")
 	keyAndOwner, extra := requestJSONResp(req, &KeyAndOwner{})
 	if extra.HasError() {
@@ -45,13 +46,14 @@ type ServCommandResults struct {
 	RepoID      int64
 }
 
-// ServCommand preps for a serv call
-func ServCommand(ctx context.Context, keyID int64, ownerName, repoName string, mode perm.AccessMode, verbs ...string) (*ServCommandResults, ResponseExtra) {
-	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/command/%d/%s/%s?mode=%d",
+// ServCommand preps for a serv call, userID is only used if keyID is 0 as in ServNoCommand
+func ServCommand(ctx context.Context, keyID, userID int64, ownerName, repoName string, mode perm.AccessMode, verbs ...string) (*ServCommandResults, ResponseExtra) {
+	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/command/%d/%s/%s?mode=%d&user=%d",
 		keyID,
 		url.PathEscape(ownerName),
 		url.PathEscape(repoName),
 		mode,
+		userID,
 	)
 	for _, verb := range verbs {
 		if verb != "" {
@@ -5,6 +5,7 @@ package queue
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,7 +20,8 @@ type baseRedis struct {
 	client   redis.UniversalClient
 	isUnique bool
 	cfg      *BaseConfig
-	prefix   string
+	listKey  string
+	setKey   string
 
 	mu sync.Mutex // the old implementation is not thread-safe, the queue operation and set operation should be protected together
 }
@@ -61,7 +63,78 @@ func newBaseRedisGeneric(cfg *BaseConfig, unique bool, client redis.UniversalCli
 		return nil, err
 	}
 
-	return &baseRedis{cfg: cfg, client: client, isUnique: unique, prefix: prefix}, nil
+	listKey, setKey := redisQueueKeys(prefix, cfg, nosql.IsRedisClusterURI(cfg.ConnStr))
+	oldListKey, oldSetKey := redisQueueKeys(prefix, cfg, false)
+	if listKey != oldListKey {
+		if err := migrateRedisQueueKeys(graceful.GetManager().ShutdownContext(), client, oldListKey, oldSetKey, listKey, setKey); err != nil {
+			return nil, err
+		}
+	}
+	return &baseRedis{cfg: cfg, client: client, isUnique: unique, listKey: listKey, setKey: setKey}, nil
+}
+
+// redisQueueKeys returns the keys of the list and the set of a queue. Commands on several keys only work
+// in a Redis Cluster if the keys are in the same hash slot, so the queue name is used as hash tag for both
+// keys, unless the prefix already contains one. The items of queues of a Redis Cluster which were created
+// without hash tag are moved by migrateRedisQueueKeys, the keys of other setups are kept for existing queues.
+func redisQueueKeys(prefix string, cfg *BaseConfig, cluster bool) (listKey, setKey string) {
+	if !cluster || nosql.HasRedisHashTag(prefix) {
+		return prefix + cfg.QueueFullName, prefix + cfg.SetFullName
+	}
+	hashTag := "{" + cfg.QueueFullName + "}"
+	return prefix + hashTag, prefix + hashTag + strings.TrimPrefix(cfg.SetFullName, cfg.QueueFullName)
+}
+
+// redisQueueMigrationBatch is the number of items which are moved at once from the keys without hash tag
+const redisQueueMigrationBatch = 100
+
+// migrateRedisQueueKeys moves the items of a queue of a Redis Cluster which was created before the keys had a
+// hash tag. The keys are in different hash slots, so the items are moved in batches: they are added to the new
+// list before they are removed from the old one, so an interruption may duplicate a batch but does not lose it.
+func migrateRedisQueueKeys(ctx context.Context, client redis.UniversalClient, oldListKey, oldSetKey, listKey, setKey string) error {
+	moved := 0
+	for {
+		items, err := client.LRange(ctx, oldListKey, 0, redisQueueMigrationBatch-1).Result()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+		values := make([]any, 0, len(items))
+		for _, item := range items {
+			values = append(values, item)
+		}
+		if err := client.RPush(ctx, listKey, values...).Err(); err != nil {
+			return err
+		}
+		if err := client.LTrim(ctx, oldListKey, int64(len(items)), -1).Err(); err != nil {
+			return err
+		}
+		moved += len(items)
+	}
+
+	members, err := client.SMembers(ctx, oldSetKey).Result()
+	if err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		values := make([]any, 0, len(members))
+		for _, member := range members {
+			values = append(values, member)
+		}
+		if err := client.SAdd(ctx, setKey, values...).Err(); err != nil {
+			return err
+		}
+		if err := client.Del(ctx, oldSetKey).Err(); err != nil {
+			return err
+		}
+	}
+
+	if moved > 0 {
+		log.Info("Moved %d items of the queue %q to the key %q", moved, oldListKey, listKey)
+	}
+	return nil
 }
 
 func newBaseRedisSimple(cfg *BaseConfig) (baseQueue, error) {
@@ -72,16 +145,12 @@ func newBaseRedisUnique(cfg *BaseConfig) (baseQueue, error) {
 	return newBaseRedisGeneric(cfg, true, nil)
 }
 
-func (q *baseRedis) prefixedName(name string) string {
-	return q.prefix + name
-}
-
 func (q *baseRedis) PushItem(ctx context.Context, data []byte) error {
 	return backoffErr(ctx, backoffBegin, backoffUpper, time.After(pushBlockTime), func() (retry bool, err error) {
 		q.mu.Lock()
 		defer q.mu.Unlock()
 
-		cnt, err := q.client.LLen(ctx, q.prefixedName(q.cfg.QueueFullName)).Result()
+		cnt, err := q.client.LLen(ctx, q.listKey).Result()
 		if err != nil {
 			return false, err
 		}
@@ -90,7 +159,7 @@ func (q *baseRedis) PushItem(ctx context.Context, data []byte) error {
 		}
 
 		if q.isUnique {
-			added, err := q.client.SAdd(ctx, q.prefixedName(q.cfg.SetFullName), data).Result()
+			added, err := q.client.SAdd(ctx, q.setKey, data).Result()
 			if err != nil {
 				return false, err
 			}
@@ -98,7 +167,7 @@ func (q *baseRedis) PushItem(ctx context.Context, data []byte) error {
 				return false, ErrAlreadyInQueue
 			}
 		}
-		return false, q.client.RPush(ctx, q.prefixedName(q.cfg.QueueFullName), data).Err()
+		return false, q.client.RPush(ctx, q.listKey, data).Err()
 	})
 }
 
@@ -107,7 +176,7 @@ func (q *baseRedis) PopItem(ctx context.Context) ([]byte, error) {
 		q.mu.Lock()
 		defer q.mu.Unlock()
 
-		data, err = q.client.LPop(ctx, q.prefixedName(q.cfg.QueueFullName)).Bytes()
+		data, err = q.client.LPop(ctx, q.listKey).Bytes()
 		if err == redis.Nil {
 			return true, nil, nil
 		}
@@ -116,7 +185,7 @@ func (q *baseRedis) PopItem(ctx context.Context) ([]byte, error) {
 		}
 		if q.isUnique {
 			// the data has been popped, even if there is any error we can't do anything
-			_ = q.client.SRem(ctx, q.prefixedName(q.cfg.SetFullName), data).Err()
+			_ = q.client.SRem(ctx, q.setKey, data).Err()
 		}
 		return false, data, err
 	})
@@ -128,13 +197,13 @@ func (q *baseRedis) HasItem(ctx context.Context, data []byte) (bool, error) {
 	if !q.isUnique {
 		return false, nil
 	}
-	return q.client.SIsMember(ctx, q.prefixedName(q.cfg.SetFullName), data).Result()
+	return q.client.SIsMember(ctx, q.setKey, data).Result()
 }
 
 func (q *baseRedis) Len(ctx context.Context) (int, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
-	cnt, err := q.client.LLen(ctx, q.prefixedName(q.cfg.QueueFullName)).Result()
+	cnt, err := q.client.LLen(ctx, q.listKey).Result()
 	return int(cnt), err
 }
 
@@ -148,15 +217,6 @@ func (q *baseRedis) RemoveAll(ctx context.Context) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	c1 := q.client.Del(ctx, q.prefixedName(q.cfg.QueueFullName))
-	// the "set" must be cleared after the "list" because there is no transaction.
-	// it's better to have duplicate items than losing items.
-	c2 := q.client.Del(ctx, q.prefixedName(q.cfg.SetFullName))
-	if c1.Err() != nil {
-		return c1.Err()
-	}
-	if c2.Err() != nil {
-		return c2.Err()
-	}
-	return nil // actually, checking errors doesn't make sense here because the state could be out-of-sync
+	// both keys are deleted at once, they are in the same hash slot of a Redis Cluster
+	return q.client.Del(ctx, q.listKey, q.setKey).Err()
 }
@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 )
@@ -136,3 +137,24 @@ func (suite *baseRedisUnitTestSuite) TestBasic() {
 		})
 	}
 }
+
+func TestRedisQueueKeys(t *testing.T) {
+	cfg := toBaseConfig("test-queue", setting.QueueSettings{})
+	testCases := []struct {
+		prefix  string
+		cluster bool
+		list    string
+		set     string
+	}{
+		{prefix: "", cluster: false, list: "test-queue", set: "test-queue_unique"},
+		{prefix: "forgejo:", cluster: false, list: "forgejo:test-queue", set: "forgejo:test-queue_unique"},
+		{prefix: "", cluster: true, list: "{test-queue}", set: "{test-queue}_unique"},
+		{prefix: "forgejo:", cluster: true, list: "forgejo:{test-queue}", set: "forgejo:{test-queue}_unique"},
+		{prefix: "{forgejo}:", cluster: true, list: "{forgejo}:test-queue", set: "{forgejo}:test-queue_unique"},
+	}
+	for _, testCase := range testCases {
+		list, set := redisQueueKeys(testCase.prefix, cfg, testCase.cluster)
+		assert.Equal(t, testCase.list, list)
+		assert.Equal(t, testCase.set, set)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/modules/nosql"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -79,6 +80,38 @@ func (suite *baseRedisWithServerTestSuite) TestWithPrefix() {
 	testQueueBasic(suite.T(), newBaseRedisUnique, toBaseConfig("baseRedisUnique", queueSettings), true)
 }
 
+func (suite *baseRedisWithServerTestSuite) TestCluster() {
+	queueSettings := setting.QueueSettings{
+		Length:  10,
+		ConnStr: test.StartRedisCluster(suite.T(), 7201, 7202, 7203),
+	}
+
+	testQueueBasic(suite.T(), newBaseRedisSimple, toBaseConfig("baseRedis", queueSettings), false)
+	testQueueBasic(suite.T(), newBaseRedisUnique, toBaseConfig("baseRedisUnique", queueSettings), true)
+
+	// the items of a queue created before the keys had a hash tag are kept
+	ctx := context.Background()
+	cfg := toBaseConfig("baseRedisLegacy", queueSettings)
+	client := nosql.GetManager().GetRedisClient(queueSettings.ConnStr)
+	suite.Require().NoError(client.RPush(ctx, cfg.QueueFullName, "item-1", "item-2").Err())
+	suite.Require().NoError(client.SAdd(ctx, cfg.SetFullName, "item-1", "item-2").Err())
+
+	q, err := newBaseRedisUnique(cfg)
+	suite.Require().NoError(err)
+	has, err := q.HasItem(ctx, []byte("item-2"))
+	suite.Require().NoError(err)
+	suite.True(has)
+	data, err := q.PopItem(ctx)
+	suite.Require().NoError(err)
+	suite.Equal([]byte("item-1"), data)
+	for _, key := range []string{cfg.QueueFullName, cfg.SetFullName} {
+		cnt, err := client.Exists(ctx, key).Result()
+		suite.Require().NoError(err)
+		suite.Zero(cnt, key)
+	}
+	suite.Require().NoError(q.RemoveAll(ctx))
+}
+
 func (suite *baseRedisWithServerTestSuite) startRedisServer(address string) (*exec.Cmd, bool) {
 	var redisServer *exec.Cmd
 
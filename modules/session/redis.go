@@ -18,8 +18,10 @@
 package session
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.gitea.io/gitea/modules/graceful"
@@ -212,8 +214,13 @@ func (p *RedisProvider) Regenerate(oldsid, sid string) (_ session.RawStore, err
 
 // Count counts and returns number of sessions.
 func (p *RedisProvider) Count() int {
-	size, err := p.c.DBSize(graceful.GetManager().HammerContext()).Result()
-	if err != nil {
+	// the masters of a Redis Cluster are counted concurrently
+	var size int64
+	if err := nosql.ForEachRedisMaster(graceful.GetManager().HammerContext(), p.c, func(ctx context.Context, client redis.UniversalClient) error {
+		n, err := client.DBSize(ctx).Result()
+		atomic.AddInt64(&size, n)
+		return err
+	}); err != nil {
 		return 0
 	}
 	return int(size)
@@ -0,0 +1,25 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import "time"
+
+// Audit settings
+var Audit = struct {
+	Enabled        bool
+	WebhookURL     string
+	WebhookSecret  string
+	DeliverTimeout time.Duration
+}{
+	Enabled:        true,
+	DeliverTimeout: 5 * time.Second,
+}
+
+func loadAuditFrom(rootCfg ConfigProvider) {
+	sec := rootCfg.Section("audit")
+	Audit.Enabled = sec.Key("ENABLED").MustBool(true)
+	Audit.WebhookURL = sec.Key("WEBHOOK_URL").MustString("")
+	Audit.WebhookSecret = sec.Key("WEBHOOK_SECRET").MustString("")
+	Audit.DeliverTimeout = sec.Key("DELIVER_TIMEOUT").MustDuration(5 * time.Second)
+}
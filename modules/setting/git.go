@@ -30,6 +30,8 @@ var Git = struct {
 	LargeObjectThreshold      int64
 	DisableCoreProtectNTFS    bool
 	DisablePartialClone       bool
+	PartialCloneFilters       []string `ini:"PARTIAL_CLONE_FILTERS" delim:","`
+	PartialCloneMaxTreeDepth  int
 	Timeout                   struct {
 		Default int
 		Migrate int
@@ -52,6 +54,8 @@ var Git = struct {
 	PullRequestPushMessage:    true,
 	LargeObjectThreshold:      1024 * 1024,
 	DisablePartialClone:       false,
+	PartialCloneFilters:       []string{"blob:none", "blob:limit", "tree", "object:type", "sparse:oid", "combine"},
+	PartialCloneMaxTreeDepth:  -1,
 	Timeout: struct {
 		Default int
 		Migrate int
@@ -33,6 +33,13 @@ var Indexer = struct {
 	IncludePatterns      []Glob
 	ExcludePatterns      []Glob
 	ExcludeVendored      bool
+
+	CommitIndexerEnabled      bool
+	CommitType                string
+	CommitPath                string
+	CommitIndexerIncludeFiles bool
+	CommitIndexerIncludeDiff  bool
+	MaxCommitIndexerDiffSize  int64
 }{
 	IssueType:        "bleve",
 	IssuePath:        "indexers/issues.bleve",
@@ -48,6 +55,13 @@ var Indexer = struct {
 	RepoIndexerName:      "gitea_codes",
 	MaxIndexerFileSize:   1024 * 1024,
 	ExcludeVendored:      true,
+
+	CommitIndexerEnabled:      false,
+	CommitType:                "bleve",
+	CommitPath:                "indexers/commits.bleve",
+	CommitIndexerIncludeFiles: true,
+	CommitIndexerIncludeDiff:  false,
+	MaxCommitIndexerDiffSize:  64 * 1024,
 }
 
 type Glob struct {
@@ -99,6 +113,17 @@ func loadIndexerFrom(rootCfg ConfigProvider) {
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
 	Indexer.ExcludeVendored = sec.Key("REPO_INDEXER_EXCLUDE_VENDORED").MustBool(true)
 	Indexer.MaxIndexerFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(1024 * 1024)
+
+	Indexer.CommitIndexerEnabled = sec.Key("COMMIT_INDEXER_ENABLED").MustBool(false)
+	Indexer.CommitType = sec.Key("COMMIT_INDEXER_TYPE").MustString("bleve")
+	Indexer.CommitPath = filepath.ToSlash(sec.Key("COMMIT_INDEXER_PATH").MustString(filepath.ToSlash(filepath.Join(AppDataPath, "indexers/commits.bleve"))))
+	if !filepath.IsAbs(Indexer.CommitPath) {
+		Indexer.CommitPath = filepath.ToSlash(filepath.Join(AppWorkPath, Indexer.CommitPath))
+	}
+	Indexer.CommitIndexerIncludeFiles = sec.Key("COMMIT_INDEXER_INCLUDE_FILES").MustBool(true)
+	Indexer.CommitIndexerIncludeDiff = sec.Key("COMMIT_INDEXER_INCLUDE_DIFF").MustBool(false)
+	Indexer.MaxCommitIndexerDiffSize = sec.Key("COMMIT_INDEXER_MAX_DIFF_SIZE").MustInt64(64 * 1024)
+
 	Indexer.StartupTimeout = sec.Key("STARTUP_TIMEOUT").MustDuration(30 * time.Second)
 }
 
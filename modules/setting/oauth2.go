@@ -100,6 +100,8 @@ var OAuth2 = struct {
 	JWTSigningPrivateKeyFile   string `ini:"JWT_SIGNING_PRIVATE_KEY_FILE"`
 	MaxTokenLength             int
 	DefaultApplications        []string
+	DeviceCodeExpirationTime   int64
+	DeviceCodePollingInterval  int64
 }{
 	Enabled:                    true,
 	AccessTokenExpirationTime:  3600,
@@ -109,6 +111,8 @@ var OAuth2 = struct {
 	JWTSigningPrivateKeyFile:   "jwt/private.pem",
 	MaxTokenLength:             math.MaxInt16,
 	DefaultApplications:        []string{"git-credential-oauth", "git-credential-manager", "tea"},
+	DeviceCodeExpirationTime:   900,
+	DeviceCodePollingInterval:  5,
 }
 
 func loadOAuth2From(rootCfg ConfigProvider) {
@@ -73,6 +73,7 @@ var Service = struct {
 	DefaultEnableTimetracking               bool
 	DefaultEnableDependencies               bool
 	AllowCrossRepositoryDependencies        bool
+	MaxSubIssueDepth                        int
 	DefaultAllowOnlyContributorsToTrackTime bool
 	NoReplyAddress                          string
 	UserLocationMapURL                      string
@@ -96,6 +97,7 @@ var Service = struct {
 	} `ini:"service.explore"`
 }{
 	AllowedUserVisibilityModesSlice: []bool{true, true, true},
+	MaxSubIssueDepth:                5,
 }
 
 // AllowedVisibility store in a 3 item bool array what is allowed
@@ -188,6 +190,7 @@ func loadServiceFrom(rootCfg ConfigProvider) {
 	}
 	Service.DefaultEnableDependencies = sec.Key("DEFAULT_ENABLE_DEPENDENCIES").MustBool(true)
 	Service.AllowCrossRepositoryDependencies = sec.Key("ALLOW_CROSS_REPOSITORY_DEPENDENCIES").MustBool(true)
+	Service.MaxSubIssueDepth = sec.Key("MAX_SUB_ISSUE_DEPTH").MustInt(5)
 	Service.DefaultAllowOnlyContributorsToTrackTime = sec.Key("DEFAULT_ALLOW_ONLY_CONTRIBUTORS_TO_TRACK_TIME").MustBool(true)
 	Service.NoReplyAddress = sec.Key("NO_REPLY_ADDRESS").MustString("noreply." + Domain)
 	Service.UserLocationMapURL = sec.Key("USER_LOCATION_MAP_URL").MustString("https://www.openstreetmap.org/search?query=")
@@ -48,6 +48,8 @@ var SSH = struct {
 	TrustedUserCAKeys                     []string           `ini:"SSH_TRUSTED_USER_CA_KEYS"`
 	TrustedUserCAKeysFile                 string             `ini:"SSH_TRUSTED_USER_CA_KEYS_FILENAME"`
 	TrustedUserCAKeysParsed               []gossh.PublicKey  `ini:"-"`
+	TrustedUserCAMapping                  string             `ini:"SSH_TRUSTED_USER_CA_MAPPING"`
+	TrustedUserCAMappingTemplate          string             `ini:"SSH_TRUSTED_USER_CA_MAPPING_TEMPLATE"`
 	PerWriteTimeout                       time.Duration      `ini:"SSH_PER_WRITE_TIMEOUT"`
 	PerWritePerKbTimeout                  time.Duration      `ini:"SSH_PER_WRITE_PER_KB_TIMEOUT"`
 }{
@@ -63,6 +65,8 @@ var SSH = struct {
 	MinimumKeySizes:               map[string]int{"ed25519": 256, "ed25519-sk": 256, "ecdsa": 256, "ecdsa-sk": 256, "rsa": 3071},
 	ServerHostKeys:                []string{"ssh/gitea.rsa", "ssh/gogs.rsa"},
 	AuthorizedKeysCommandTemplate: "{{.AppPath}} --config={{.CustomConf}} serv key-{{.Key.ID}}",
+	TrustedUserCAMapping:          "off",
+	TrustedUserCAMappingTemplate:  "{username}",
 	PerWriteTimeout:               PerWriteTimeout,
 	PerWritePerKbTimeout:          PerWritePerKbTimeout,
 }
@@ -163,6 +167,15 @@ func loadSSHFrom(rootCfg ConfigProvider) {
 
 	SSH.AuthorizedPrincipalsAllow, SSH.AuthorizedPrincipalsEnabled = parseAuthorizedPrincipalsAllow(sec.Key("SSH_AUTHORIZED_PRINCIPALS_ALLOW").Strings(","))
 
+	switch SSH.TrustedUserCAMapping {
+	case "off", "principal", "key_id":
+	default:
+		log.Fatal("Invalid SSH_TRUSTED_USER_CA_MAPPING: %q, it must be one of off, principal or key_id", SSH.TrustedUserCAMapping)
+	}
+	if strings.Count(SSH.TrustedUserCAMappingTemplate, "{username}") != 1 {
+		log.Fatal("Invalid SSH_TRUSTED_USER_CA_MAPPING_TEMPLATE: %q, it must contain {username} once", SSH.TrustedUserCAMappingTemplate)
+	}
+
 	SSH.MinimumKeySizeCheck = sec.Key("MINIMUM_KEY_SIZE_CHECK").MustBool(SSH.MinimumKeySizeCheck)
 	minimumKeySizes := rootCfg.Section("ssh.minimum_key_sizes").Keys()
 	for _, key := range minimumKeySizes {
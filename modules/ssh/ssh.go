@@ -4,7 +4,6 @@
 package ssh
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -23,6 +22,7 @@ import (
 	"syscall"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
@@ -35,7 +35,10 @@ import (
 
 type contextKey string
 
-const giteaKeyID = contextKey("gitea-key-id")
+const (
+	giteaKeyID  = contextKey("gitea-key-id")
+	giteaUserID = contextKey("gitea-user-id")
+)
 
 func getExitStatusFromError(err error) int {
 	if err == nil {
@@ -61,14 +64,21 @@ func getExitStatusFromError(err error) int {
 	return waitStatus.ExitStatus()
 }
 
-func sessionHandler(session ssh.Session) {
-	keyID := fmt.Sprintf("%d", session.Context().Value(giteaKeyID).(int64))
+// servArgument returns the argument of the serv command identifying how the session authenticated: with a public key
+// or a registered principal, or with a certificate mapped to a user
+func servArgument(ctx ssh.Context) string {
+	if userID, ok := ctx.Value(giteaUserID).(int64); ok {
+		return fmt.Sprintf("user-%d", userID)
+	}
+	return fmt.Sprintf("key-%d", ctx.Value(giteaKeyID).(int64))
+}
 
+func sessionHandler(session ssh.Session) {
 	command := session.RawCommand()
 
 	log.Trace("SSH: Payload: %v", command)
 
-	args := []string{"--config=" + setting.CustomConf, "serv", "key-" + keyID}
+	args := []string{"--config=" + setting.CustomConf, "serv", servArgument(session.Context())}
 	log.Trace("SSH: Arguments: %v", args)
 
 	ctx, cancel := context.WithCancel(session.Context())
@@ -192,6 +202,15 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 			return false
 		}
 
+		// check the CA of the cert
+		if !asymkey_model.IsTrustedUserCA(cert.SignatureKey) {
+			if log.IsDebug() {
+				log.Debug("Certificate Rejected: %s Untrusted Authority Signature Fingerprint %s", ctx.RemoteAddr(), gossh.FingerprintSHA256(cert.SignatureKey))
+			}
+			log.Warn("Failed authentication attempt from %s", ctx.RemoteAddr())
+			return false
+		}
+
 		// look for the exact principal
 	principalLoop:
 		for _, principal := range cert.ValidPrincipals {
@@ -205,31 +224,10 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 				return false
 			}
 
-			c := &gossh.CertChecker{
-				IsUserAuthority: func(auth gossh.PublicKey) bool {
-					marshaled := auth.Marshal()
-					for _, k := range setting.SSH.TrustedUserCAKeysParsed {
-						if bytes.Equal(marshaled, k.Marshal()) {
-							return true
-						}
-					}
-
-					return false
-				},
-			}
-
-			// check the CA of the cert
-			if !c.IsUserAuthority(cert.SignatureKey) {
-				if log.IsDebug() {
-					log.Debug("Principal Rejected: %s Untrusted Authority Signature Fingerprint %s for Principal: %s", ctx.RemoteAddr(), gossh.FingerprintSHA256(cert.SignatureKey), principal)
-				}
-				continue principalLoop
-			}
-
 			// validate the cert for this principal
-			if err := c.CheckCert(principal, cert); err != nil {
+			if err := asymkey_model.CheckUserCertificate(cert, principal, ctx.RemoteAddr()); err != nil {
 				// User is presenting an invalid certificate - STOP any further processing
-				log.Error("Invalid Certificate KeyID %s with Signature Fingerprint %s presented for Principal: %s from %s", cert.KeyId, gossh.FingerprintSHA256(cert.SignatureKey), principal, ctx.RemoteAddr())
+				log.Error("Invalid Certificate KeyID %s with Signature Fingerprint %s presented for Principal: %s from %s: %v", cert.KeyId, gossh.FingerprintSHA256(cert.SignatureKey), principal, ctx.RemoteAddr(), err)
 				log.Warn("Failed authentication attempt from %s", ctx.RemoteAddr())
 
 				return false
@@ -243,6 +241,33 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 			return true
 		}
 
+		// map the cert to a user when none of its principals is registered
+		if setting.SSH.TrustedUserCAMapping != "off" {
+			user, principals, err := asymkey_model.GetUserByCertificate(ctx, cert)
+			if err != nil && !user_model.IsErrUserNotExist(err) {
+				log.Error("GetUserByCertificate: %v", err)
+				return false
+			}
+			if user != nil {
+				principal := ""
+				if len(principals) > 0 {
+					principal = principals[0]
+				}
+				if err := asymkey_model.CheckUserCertificate(cert, principal, ctx.RemoteAddr()); err != nil {
+					log.Error("Invalid Certificate KeyID %s with Signature Fingerprint %s presented for User: %s from %s: %v", cert.KeyId, gossh.FingerprintSHA256(cert.SignatureKey), user.Name, ctx.RemoteAddr(), err)
+					log.Warn("Failed authentication attempt from %s", ctx.RemoteAddr())
+					return false
+				}
+
+				if log.IsDebug() { // <- FingerprintSHA256 is kinda expensive so only calculate it if necessary
+					log.Debug("Successfully authenticated: %s Certificate Fingerprint: %s KeyID: %s User: %s", ctx.RemoteAddr(), gossh.FingerprintSHA256(key), cert.KeyId, user.Name)
+				}
+				ctx.SetValue(giteaUserID, user.ID)
+
+				return true
+			}
+		}
+
 		log.Warn("From %s Fingerprint: %s is a certificate, but no valid principals found", ctx.RemoteAddr(), gossh.FingerprintSHA256(key))
 		log.Warn("Failed authentication attempt from %s", ctx.RemoteAddr())
 		return false
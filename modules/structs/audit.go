@@ -0,0 +1,34 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import (
+	"time"
+)
+
+// AuditEvent represents a security-relevant action recorded in the audit log
+type AuditEvent struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// the user who performed the action, 0 if unknown like for a failed sign-in
+	ActorID   int64  `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	ActorIP   string `json:"actor_ip"`
+	// the user or organization the action applies to, 0 for the actions on the instance
+	OwnerID int64 `json:"owner_id"`
+	// the repository the action applies to, 0 if none
+	RepoID     int64  `json:"repo_id"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	TargetName string `json:"target_name"`
+	// the state of the target before the action
+	Before map[string]any `json:"before"`
+	// the state of the target after the action
+	After map[string]any `json:"after"`
+	// the hash of the previous event of the log this one is chained to
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
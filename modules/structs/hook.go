@@ -416,6 +416,36 @@ type SchedulePayload struct {
 	Action HookScheduleAction `json:"action"`
 }
 
+// HookMergeGroupAction represents the action of a merge group event
+type HookMergeGroupAction string
+
+const (
+	// HookMergeGroupChecksRequested a speculative merge commit of a merge queue needs to be checked
+	HookMergeGroupChecksRequested HookMergeGroupAction = "checks_requested"
+)
+
+// MergeGroup represents the speculative merge commit of a pull request in a merge queue
+type MergeGroup struct {
+	HeadSHA string `json:"head_sha"`
+	HeadRef string `json:"head_ref"`
+	BaseSHA string `json:"base_sha"`
+	BaseRef string `json:"base_ref"`
+}
+
+// MergeGroupPayload represents a payload information of merge group event.
+type MergeGroupPayload struct {
+	Action      HookMergeGroupAction `json:"action"`
+	MergeGroup  *MergeGroup          `json:"merge_group"`
+	PullRequest *PullRequest         `json:"pull_request"`
+	Repository  *Repository          `json:"repository"`
+	Sender      *User                `json:"sender"`
+}
+
+// JSONPayload implements Payload
+func (p *MergeGroupPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
 type WorkflowDispatchPayload struct {
 	Inputs     map[string]string `json:"inputs"`
 	Ref        string            `json:"ref"`
@@ -80,6 +80,11 @@ type Issue struct {
 	Repo        *RepositoryMeta  `json:"repository"`
 
 	PinOrder int `json:"pin_order"`
+
+	// whether the issue is closed automatically when all its sub-issues are closed
+	CloseWithSubIssues bool `json:"close_with_sub_issues"`
+	// the progress of the sub-issues of the issue, it is null if the issue has no sub-issues
+	SubIssues *SubIssueProgress `json:"sub_issues"`
 }
 
 // CreateIssueOption options to create one issue
@@ -98,6 +103,8 @@ type CreateIssueOption struct {
 	// list of label ids
 	Labels []int64 `json:"labels"`
 	Closed bool    `json:"closed"`
+	// values of custom fields by field name
+	Fields map[string][]string `json:"fields"`
 }
 
 // EditIssueOption options for editing an issue
@@ -115,6 +122,8 @@ type EditIssueOption struct {
 	RemoveDeadline *bool      `json:"unset_due_date"`
 	// swagger:strfmt date-time
 	Updated *time.Time `json:"updated_at"`
+	// whether the issue is closed automatically when all its sub-issues are closed
+	CloseWithSubIssues *bool `json:"close_with_sub_issues"`
 }
 
 // EditDeadlineOption options for creating a deadline
@@ -187,6 +196,8 @@ type IssueTemplate struct {
 	Content  string              `json:"content" yaml:"-"`
 	Fields   []*IssueFormField   `json:"body" yaml:"body"`
 	FileName string              `json:"file_name" yaml:"-"`
+	// values of the custom issue fields by field name
+	IssueFields map[string]IssueTemplateFieldValues `json:"fields" yaml:"fields"`
 }
 
 type IssueTemplateLabels []string
@@ -222,6 +233,36 @@ func (l *IssueTemplateLabels) UnmarshalYAML(value *yaml.Node) error {
 	return fmt.Errorf("line %d: cannot unmarshal %s into IssueTemplateLabels", value.Line, value.ShortTag())
 }
 
+// IssueTemplateFieldValues are the values of a custom issue field, a single value can be given as a scalar
+type IssueTemplateFieldValues []string
+
+func (v *IssueTemplateFieldValues) UnmarshalYAML(value *yaml.Node) error {
+	var values []string
+	if value.IsZero() {
+		*v = values
+		return nil
+	}
+	switch value.Kind {
+	case yaml.ScalarNode:
+		str := ""
+		if err := value.Decode(&str); err != nil {
+			return err
+		}
+		if str = strings.TrimSpace(str); str != "" {
+			values = append(values, str)
+		}
+		*v = values
+		return nil
+	case yaml.SequenceNode:
+		if err := value.Decode(&values); err != nil {
+			return err
+		}
+		*v = values
+		return nil
+	}
+	return fmt.Errorf("line %d: cannot unmarshal %s into IssueTemplateFieldValues", value.Line, value.ShortTag())
+}
+
 type IssueConfigContactLink struct {
 	Name  string `json:"name" yaml:"name"`
 	URL   string `json:"url" yaml:"url"`
@@ -267,3 +308,16 @@ type IssueMeta struct {
 	Owner string `json:"owner"`
 	Name  string `json:"repo"`
 }
+
+// SubIssueProgress the number of sub-issues of an issue and how many of them are closed
+type SubIssueProgress struct {
+	Total  int64 `json:"total"`
+	Closed int64 `json:"closed"`
+}
+
+// MoveSubIssueOption options for moving a sub-issue to another position
+type MoveSubIssueOption struct {
+	SubIssue IssueMeta `json:"sub_issue"`
+	// the new 0-based position of the sub-issue among the sub-issues of the issue
+	Position int `json:"position"`
+}
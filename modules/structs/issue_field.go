@@ -0,0 +1,58 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+// IssueField a custom field of the issues of a repository or an organization
+// swagger:model
+type IssueField struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// enum: text,number,date,single_select,multi_select,user
+	Type string `json:"type"`
+	// the values a select field can have
+	Options []string `json:"options"`
+	// example: false
+	IsOrgField bool `json:"is_org_field"`
+	SortOrder  int  `json:"sort_order"`
+}
+
+// CreateIssueFieldOption options for creating a custom issue field
+type CreateIssueFieldOption struct {
+	// required:true
+	Name        string `json:"name" binding:"Required"`
+	Description string `json:"description"`
+	// required:true
+	// enum: text,number,date,single_select,multi_select,user
+	Type string `json:"type" binding:"Required"`
+	// the values a select field can have
+	Options   []string `json:"options"`
+	SortOrder int      `json:"sort_order"`
+}
+
+// EditIssueFieldOption options for editing a custom issue field, its type cannot be changed
+type EditIssueFieldOption struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	// the values of the issues which are not an option anymore are removed
+	Options   []string `json:"options"`
+	SortOrder *int     `json:"sort_order"`
+}
+
+// IssueFieldValue the values of a custom field of an issue
+// swagger:model
+type IssueFieldValue struct {
+	FieldID int64  `json:"field_id"`
+	Name    string `json:"name"`
+	// enum: text,number,date,single_select,multi_select,user
+	Type string `json:"type"`
+	// dates are formatted as YYYY-MM-DD, users are given by their names
+	Values []string `json:"values"`
+}
+
+// SetIssueFieldValueOption options for setting the values of a custom field of an issue
+type SetIssueFieldValueOption struct {
+	// dates are formatted as YYYY-MM-DD, users are given by their names, an empty list removes the values
+	Values []string `json:"values"`
+}
@@ -103,3 +103,62 @@ labels:
 		})
 	}
 }
+
+func TestIssueTemplateFieldValues_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *IssueTemplate
+		wantErr string
+	}{
+		{
+			name: "scalar and array",
+			content: `
+fields:
+  Severity: high
+  Versions: ["v1.0", "v1.1"]
+  Customer: "Foo, Inc."
+`,
+			want: &IssueTemplate{
+				IssueFields: map[string]IssueTemplateFieldValues{
+					"Severity": {"high"},
+					"Versions": {"v1.0", "v1.1"},
+					"Customer": {"Foo, Inc."},
+				},
+			},
+		},
+		{
+			name: "empty",
+			content: `
+fields:
+  Severity:
+`,
+			want: &IssueTemplate{
+				IssueFields: map[string]IssueTemplateFieldValues{
+					"Severity": nil,
+				},
+			},
+		},
+		{
+			name: "error",
+			content: `
+fields:
+  Severity:
+    a: aa
+`,
+			wantErr: "line 4: cannot unmarshal !!map into IssueTemplateFieldValues",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := &IssueTemplate{}
+			err := yaml.Unmarshal([]byte(tt.content), tmpl)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, tmpl)
+			}
+		})
+	}
+}
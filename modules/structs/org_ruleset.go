@@ -0,0 +1,89 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import (
+	"time"
+)
+
+// OrgRuleset represents branch or tag protection settings an organization applies to its repositories
+type OrgRuleset struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// glob matching the names of the repositories the ruleset applies to, all repositories if empty
+	RepoNamePattern string `json:"repo_name_pattern"`
+	// topic the repositories the ruleset applies to must have, any if empty
+	RepoTopic string `json:"repo_topic"`
+	// enum: branch,tag
+	Target string `json:"target"`
+	// glob matching the names of the branches or tags the ruleset applies to
+	RefPattern             string   `json:"ref_pattern"`
+	EnablePush             bool     `json:"enable_push"`
+	EnablePushWhitelist    bool     `json:"enable_push_whitelist"`
+	PushWhitelistUsernames []string `json:"push_whitelist_usernames"`
+	PushWhitelistTeams     []string `json:"push_whitelist_teams"`
+	RequiredApprovals      int64    `json:"required_approvals"`
+	EnableStatusCheck      bool     `json:"enable_status_check"`
+	StatusCheckContexts    []string `json:"status_check_contexts"`
+	RequireSignedCommits   bool     `json:"require_signed_commits"`
+	ProtectedFilePatterns  string   `json:"protected_file_patterns"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+}
+
+// CreateOrgRulesetOption options for creating an organization ruleset
+type CreateOrgRulesetOption struct {
+	// required: true
+	Name            string `json:"name" binding:"Required"`
+	RepoNamePattern string `json:"repo_name_pattern"`
+	RepoTopic       string `json:"repo_topic"`
+	// enum: branch,tag
+	Target string `json:"target"`
+	// required: true
+	RefPattern             string   `json:"ref_pattern" binding:"Required"`
+	EnablePush             bool     `json:"enable_push"`
+	EnablePushWhitelist    bool     `json:"enable_push_whitelist"`
+	PushWhitelistUsernames []string `json:"push_whitelist_usernames"`
+	PushWhitelistTeams     []string `json:"push_whitelist_teams"`
+	RequiredApprovals      int64    `json:"required_approvals"`
+	EnableStatusCheck      bool     `json:"enable_status_check"`
+	StatusCheckContexts    []string `json:"status_check_contexts"`
+	RequireSignedCommits   bool     `json:"require_signed_commits"`
+	ProtectedFilePatterns  string   `json:"protected_file_patterns"`
+}
+
+// EditOrgRulesetOption options for editing an organization ruleset
+type EditOrgRulesetOption struct {
+	Name            *string `json:"name"`
+	RepoNamePattern *string `json:"repo_name_pattern"`
+	RepoTopic       *string `json:"repo_topic"`
+	// enum: branch,tag
+	Target                 *string  `json:"target"`
+	RefPattern             *string  `json:"ref_pattern"`
+	EnablePush             *bool    `json:"enable_push"`
+	EnablePushWhitelist    *bool    `json:"enable_push_whitelist"`
+	PushWhitelistUsernames []string `json:"push_whitelist_usernames"`
+	PushWhitelistTeams     []string `json:"push_whitelist_teams"`
+	RequiredApprovals      *int64   `json:"required_approvals"`
+	EnableStatusCheck      *bool    `json:"enable_status_check"`
+	StatusCheckContexts    []string `json:"status_check_contexts"`
+	RequireSignedCommits   *bool    `json:"require_signed_commits"`
+	ProtectedFilePatterns  *string  `json:"protected_file_patterns"`
+}
+
+// RefProtection represents the protection rules applying to a branch or tag of a repository
+type RefProtection struct {
+	// the full name of the ref
+	Ref string `json:"ref"`
+	// the rule of the repository protecting the ref, if it is a branch
+	BranchProtection *BranchProtection `json:"branch_protection"`
+	// the rules of the repository protecting the ref, if it is a tag
+	TagProtections []*TagProtection `json:"tag_protections"`
+	// the rulesets of the organization owning the repository which apply to the ref
+	OrgRulesets []*OrgRuleset `json:"org_rulesets"`
+	// the protection of the branch combining the rule of the repository and the rulesets, the most restrictive setting wins
+	EffectiveBranchProtection *BranchProtection `json:"effective_branch_protection"`
+}
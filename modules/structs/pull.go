@@ -116,3 +116,99 @@ type ChangedFile struct {
 	ContentsURL      string `json:"contents_url,omitempty"`
 	RawURL           string `json:"raw_url,omitempty"`
 }
+
+// MergeQueueEntry represents a pull request in the merge queue of its base branch
+type MergeQueueEntry struct {
+	// position of the pull request in the merge queue, starting at 1
+	Position   int64  `json:"position"`
+	Number     int64  `json:"number"`
+	Title      string `json:"title"`
+	HTMLURL    string `json:"html_url"`
+	BaseBranch string `json:"base_branch"`
+	MergeStyle string `json:"merge_style"`
+	AddedBy    *User  `json:"added_by"`
+	// head commit of the pull request the merge commit was created from
+	HeadSHA string `json:"head_sha"`
+	// commit the merge commit was created on
+	BaseSHA string `json:"base_sha"`
+	// speculative merge commit that is tested, empty if it has not been created yet
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	// combined state of the commit statuses of the merge commit
+	State CommitStatusState `json:"state"`
+	// swagger:strfmt date-time
+	Added time.Time `json:"added_at"`
+}
+
+// PullRequestConflicts represents the files of a pull request which conflict with its base branch
+type PullRequestConflicts struct {
+	// head commit of the pull request the conflicts have been computed for
+	HeadSHA string                     `json:"head_sha"`
+	Files   []*PullRequestConflictFile `json:"files"`
+}
+
+// PullRequestConflictFile represents a file which can't be merged automatically
+type PullRequestConflictFile struct {
+	Path string `json:"path"`
+	// whether the file can only be resolved by picking a whole version or giving its content
+	Binary bool `json:"binary"`
+	// version in the merge base, null if the file was added
+	Ancestor *PullRequestConflictFileVersion `json:"ancestor"`
+	// version in the head branch, null if the file was deleted
+	Head *PullRequestConflictFileVersion `json:"head"`
+	// version in the base branch, null if the file was deleted
+	Base *PullRequestConflictFileVersion `json:"base"`
+	// parts of the file, null if the file can't be merged line by line
+	Hunks []*PullRequestConflictHunk `json:"hunks"`
+}
+
+// PullRequestConflictFileVersion represents the version of a conflicting file in one of the merged commits
+type PullRequestConflictFileVersion struct {
+	Mode string `json:"mode"`
+	SHA  string `json:"sha"`
+	// empty if the file is binary or too large
+	Content string `json:"content"`
+}
+
+// PullRequestConflictHunk represents a part of a conflicting file, either merged cleanly or conflicting
+type PullRequestConflictHunk struct {
+	Conflict bool `json:"conflict"`
+	// merged content of a hunk without conflict
+	Content  string `json:"content,omitempty"`
+	Head     string `json:"head,omitempty"`
+	Ancestor string `json:"ancestor,omitempty"`
+	Base     string `json:"base,omitempty"`
+}
+
+// ResolvePullRequestConflictsOption options for resolving the conflicts of a pull request
+type ResolvePullRequestConflictsOption struct {
+	// resolutions of all conflicting files
+	Files []*PullRequestConflictResolution `json:"files"`
+	// message of the merge commit
+	Message string `json:"message"`
+	// head commit the resolutions have been made for, the resolution fails if the head branch changed in between
+	HeadSHA string `json:"head_sha"`
+	// commit the resolution on this new branch of the head repository instead of the head branch
+	NewBranch string `json:"new_branch"`
+}
+
+// PullRequestConflictResolution represents the resolution of a conflicting file,
+// exactly one of content, delete, side and hunks has to be given
+type PullRequestConflictResolution struct {
+	Path string `json:"path" binding:"Required"`
+	// resolved content of the file
+	Content *string `json:"content"`
+	// delete the file
+	Delete bool `json:"delete"`
+	// use the whole version of the file of one side, deleting it if it doesn't exist there
+	// enum: head,base
+	Side string `json:"side"`
+	// side picked for every conflicting hunk in order, "both" keeps the head version followed by the base version
+	Hunks []string `json:"hunks"`
+}
+
+// PullRequestConflictsResolved represents the merge commit resolving the conflicts of a pull request
+type PullRequestConflictsResolved struct {
+	// branch the merge commit has been pushed to
+	Branch string `json:"branch"`
+	SHA    string `json:"sha"`
+}
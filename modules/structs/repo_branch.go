@@ -48,6 +48,12 @@ type BranchProtection struct {
 	ProtectedFilePatterns         string   `json:"protected_file_patterns"`
 	UnprotectedFilePatterns       string   `json:"unprotected_file_patterns"`
 	ApplyToAdmins                 bool     `json:"apply_to_admins"`
+	EnableMergeQueue              bool     `json:"enable_merge_queue"`
+	RequireCodeOwnerApproval      bool     `json:"require_code_owner_approval"`
+	RequireLinearHistory          bool     `json:"require_linear_history"`
+	RequireSignoff                bool     `json:"require_signoff"`
+	// regular expression the messages of the pushed commits must match
+	CommitMessagePattern string `json:"commit_message_pattern"`
 	// swagger:strfmt date-time
 	Created time.Time `json:"created_at"`
 	// swagger:strfmt date-time
@@ -82,6 +88,12 @@ type CreateBranchProtectionOption struct {
 	ProtectedFilePatterns         string   `json:"protected_file_patterns"`
 	UnprotectedFilePatterns       string   `json:"unprotected_file_patterns"`
 	ApplyToAdmins                 bool     `json:"apply_to_admins"`
+	EnableMergeQueue              bool     `json:"enable_merge_queue"`
+	RequireCodeOwnerApproval      bool     `json:"require_code_owner_approval"`
+	RequireLinearHistory          bool     `json:"require_linear_history"`
+	RequireSignoff                bool     `json:"require_signoff"`
+	// regular expression the messages of the pushed commits must match
+	CommitMessagePattern string `json:"commit_message_pattern"`
 }
 
 // EditBranchProtectionOption options for editing a branch protection
@@ -109,4 +121,10 @@ type EditBranchProtectionOption struct {
 	ProtectedFilePatterns         *string  `json:"protected_file_patterns"`
 	UnprotectedFilePatterns       *string  `json:"unprotected_file_patterns"`
 	ApplyToAdmins                 *bool    `json:"apply_to_admins"`
+	EnableMergeQueue              *bool    `json:"enable_merge_queue"`
+	RequireCodeOwnerApproval      *bool    `json:"require_code_owner_approval"`
+	RequireLinearHistory          *bool    `json:"require_linear_history"`
+	RequireSignoff                *bool    `json:"require_signoff"`
+	// regular expression the messages of the pushed commits must match
+	CommitMessagePattern *string `json:"commit_message_pattern"`
 }
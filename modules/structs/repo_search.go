@@ -0,0 +1,49 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// CodeSearchResultLine represents a line of a code search result
+type CodeSearchResultLine struct {
+	Num     int    `json:"num"`
+	Content string `json:"content"`
+}
+
+// CodeSearchResult represents a file matching a code search query
+type CodeSearchResult struct {
+	RepoID       int64                   `json:"repo_id"`
+	RepoFullName string                  `json:"repo_full_name"`
+	Filename     string                  `json:"filename"`
+	CommitID     string                  `json:"commit_id"`
+	Language     string                  `json:"language"`
+	HTMLURL      string                  `json:"html_url"`
+	Lines        []*CodeSearchResultLine `json:"lines"`
+}
+
+// CodeSearchResultLanguage represents the number of matching files of a language
+type CodeSearchResultLanguage struct {
+	Language string `json:"language"`
+	Color    string `json:"color"`
+	Count    int    `json:"count"`
+}
+
+// CodeSearchResults represents the results of a code search
+type CodeSearchResults struct {
+	Languages []*CodeSearchResultLanguage `json:"languages"`
+	Data      []*CodeSearchResult         `json:"data"`
+}
+
+// CommitSearchResult represents a commit matching a commit search query
+type CommitSearchResult struct {
+	RepoID       int64  `json:"repo_id"`
+	RepoFullName string `json:"repo_full_name"`
+	SHA          string `json:"sha"`
+	Message      string `json:"message"`
+	AuthorName   string `json:"author_name"`
+	AuthorEmail  string `json:"author_email"`
+	// swagger:strfmt date-time
+	Committed time.Time `json:"committed"`
+	HTMLURL   string    `json:"html_url"`
+}
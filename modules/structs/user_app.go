@@ -16,6 +16,12 @@ type AccessToken struct {
 	Token          string   `json:"sha1"`
 	TokenLastEight string   `json:"token_last_eight"`
 	Scopes         []string `json:"scopes"`
+	// Organization the token is restricted to
+	Organization string `json:"organization,omitempty"`
+	// Repositories the token is restricted to, in the form owner/name
+	Repositories []string `json:"repositories,omitempty"`
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -27,6 +33,13 @@ type CreateAccessTokenOption struct {
 	// required: true
 	Name   string   `json:"name" binding:"Required"`
 	Scopes []string `json:"scopes"`
+	// Restrict the token to the organization and its repositories
+	Organization string `json:"organization"`
+	// Restrict the token to these repositories, in the form owner/name
+	Repositories []string `json:"repositories"`
+	// The date and time after which the token cannot be used anymore
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 // CreateOAuth2ApplicationOptions holds options to create an oauth2 application
@@ -166,6 +166,9 @@ func NewFuncMap() template.FuncMap {
 		"FederationEnabled": func() bool {
 			return setting.Federation.Enabled
 		},
+		"CommitIndexerEnabled": func() bool {
+			return setting.Indexer.CommitIndexerEnabled
+		},
 
 		// -----------------------------------------------------------------
 		// render
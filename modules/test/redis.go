@@ -0,0 +1,138 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStartTimeout is how long to wait for spawned redis instances to become ready
+const redisStartTimeout = 10 * time.Second
+
+// startRedisServer spawns a redis-server with the given arguments, it is stopped when the test finishes.
+// The test is skipped if redis-server is not installed.
+func startRedisServer(t testing.TB, dir string, args ...string) {
+	t.Helper()
+	prog, err := exec.LookPath("redis-server")
+	if err != nil {
+		t.Skip("redis-server not found")
+	}
+
+	cmd := exec.Command(prog, args...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unable to start redis-server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Signal(os.Interrupt)
+		_ = cmd.Wait()
+	})
+}
+
+// waitRedis calls check until it succeeds or redisStartTimeout is reached
+func waitRedis(t testing.TB, what string, check func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), redisStartTimeout)
+	defer cancel()
+	for {
+		err := check(ctx)
+		if err == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("%s is not ready: %v", what, err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func pingRedis(t testing.TB, addr string) {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+	waitRedis(t, "redis-server "+addr, func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	})
+}
+
+// StartRedisCluster spawns a Redis Cluster with one master on each of the given ports and returns its connection string.
+// The test is skipped if redis-server or redis-cli are not installed.
+func StartRedisCluster(t testing.TB, ports ...int) string {
+	t.Helper()
+	cli, err := exec.LookPath("redis-cli")
+	if err != nil {
+		t.Skip("redis-cli not found")
+	}
+
+	addrs := make([]string, 0, len(ports))
+	for _, port := range ports {
+		dir := t.TempDir()
+		startRedisServer(t, dir, "--bind", "127.0.0.1", "--port", strconv.Itoa(port),
+			"--cluster-enabled", "yes", "--cluster-config-file", filepath.Join(dir, "nodes.conf"), "--save", "")
+		addr := fmt.Sprintf("127.0.0.1:%d", port)
+		pingRedis(t, addr)
+		addrs = append(addrs, addr)
+	}
+
+	args := append([]string{"--cluster", "create"}, addrs...)
+	args = append(args, "--cluster-replicas", "0", "--cluster-yes")
+	if out, err := exec.Command(cli, args...).CombinedOutput(); err != nil {
+		t.Fatalf("unable to create redis cluster: %v\n%s", err, out)
+	}
+
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: addrs})
+	defer client.Close()
+	waitRedis(t, "redis cluster", func(ctx context.Context) error {
+		return client.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			info, err := shard.ClusterInfo(ctx).Result()
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(info, "cluster_state:ok") {
+				return fmt.Errorf("cluster state of %s is not ok", shard.Options().Addr)
+			}
+			return nil
+		})
+	})
+
+	return "redis+cluster://" + strings.Join(addrs, ",")
+}
+
+// StartRedisSentinel spawns a redis-server as master and a Redis Sentinel monitoring it as "mymaster",
+// it returns the connection string of the sentinel. The test is skipped if redis-server is not installed.
+func StartRedisSentinel(t testing.TB, masterPort, sentinelPort int) string {
+	t.Helper()
+	masterAddr := fmt.Sprintf("127.0.0.1:%d", masterPort)
+	startRedisServer(t, t.TempDir(), "--bind", "127.0.0.1", "--port", strconv.Itoa(masterPort), "--save", "")
+	pingRedis(t, masterAddr)
+
+	// sentinel rewrites its configuration file, so it has to be a writable file
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "sentinel.conf")
+	if err := os.WriteFile(conf, []byte(fmt.Sprintf("bind 127.0.0.1\nport %d\nsentinel monitor mymaster 127.0.0.1 %d 1\n", sentinelPort, masterPort)), 0o600); err != nil {
+		t.Fatalf("unable to write sentinel configuration: %v", err)
+	}
+	startRedisServer(t, dir, conf, "--sentinel")
+
+	sentinel := redis.NewSentinelClient(&redis.Options{Addr: fmt.Sprintf("127.0.0.1:%d", sentinelPort)})
+	defer sentinel.Close()
+	waitRedis(t, "redis sentinel", func(ctx context.Context) error {
+		return sentinel.GetMasterAddrByName(ctx, "mymaster").Err()
+	})
+
+	return fmt.Sprintf("redis+sentinel://127.0.0.1:%d/0?mastername=mymaster", sentinelPort)
+}
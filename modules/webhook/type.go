@@ -33,6 +33,7 @@ const (
 	HookEventPackage                   HookEventType = "package"
 	HookEventSchedule                  HookEventType = "schedule"
 	HookEventWorkflowDispatch          HookEventType = "workflow_dispatch"
+	HookEventMergeGroup                HookEventType = "merge_group"
 )
 
 // Event returns the HookEventType as an event string
@@ -20,5 +20,9 @@ func Routes(prefix string) *web.Route {
 	path, handler = runner.NewRunnerServiceHandler()
 	m.Post(path+"*", http.StripPrefix(prefix, handler).ServeHTTP)
 
+	m.Get("/.well-known/openid-configuration", idTokenDiscovery)
+	m.Get("/.well-known/jwks", idTokenKeys)
+	m.Get("/_apis/pipelines/workflows/{run_id}/idtoken", idToken)
+
 	return m
 }
@@ -0,0 +1,114 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+// OpenID Connect ID tokens of the jobs (workload identity)
+//
+// The jobs with the `id-token: write` permission get the ACTIONS_ID_TOKEN_REQUEST_URL and
+// ACTIONS_ID_TOKEN_REQUEST_TOKEN environment variables from the runner, like in GitHub Actions:
+//
+// GET {ACTIONS_ID_TOKEN_REQUEST_URL}&audience={audience}
+// Authorization: Bearer {ACTIONS_ID_TOKEN_REQUEST_TOKEN}
+//
+// Response:
+// {"value": "{ID token}"}
+//
+// The ID tokens are signed with the JWT signing key of the instance, relying parties discover it through
+// {AppURL}api/actions/.well-known/openid-configuration
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/context"
+)
+
+type idTokenResponse struct {
+	Value string `json:"value"`
+}
+
+func idToken(resp http.ResponseWriter, req *http.Request) {
+	ctx, cleanUp := context.NewBaseContext(resp, req)
+	defer cleanUp()
+
+	taskID, err := actions_service.ParseIDTokenRequestToken(req)
+	if err != nil {
+		ctx.Error(http.StatusUnauthorized, "Bad authorization token")
+		return
+	}
+	task, err := actions_model.GetTaskByID(ctx, taskID)
+	if err != nil {
+		log.Error("Error getting task by ID: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error getting task by ID")
+		return
+	}
+	if err := task.LoadJob(ctx); err != nil {
+		log.Error("Error loading the job of the task: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error loading the job of the task")
+		return
+	}
+	if task.Job.RunID != ctx.ParamsInt64("run_id") {
+		ctx.Error(http.StatusBadRequest, "run-id does not match")
+		return
+	}
+
+	token, err := actions_service.CreateIDToken(ctx, task, ctx.Req.URL.Query().Get("audience"))
+	if err != nil {
+		if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.Error(http.StatusForbidden, err.Error())
+			return
+		}
+		log.Error("Error creating the ID token: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error creating the ID token")
+		return
+	}
+	ctx.JSON(http.StatusOK, &idTokenResponse{Value: token})
+}
+
+// idTokenDiscovery publishes the OpenID Connect discovery document of the ID tokens
+func idTokenDiscovery(resp http.ResponseWriter, req *http.Request) {
+	ctx, cleanUp := context.NewBaseContext(resp, req)
+	defer cleanUp()
+
+	issuer := actions_service.IDTokenIssuer()
+	ctx.JSON(http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"jwks_uri":                              issuer + "/.well-known/jwks",
+		"subject_types_supported":               []string{"public"},
+		"response_types_supported":              []string{"id_token"},
+		"scopes_supported":                      []string{"openid"},
+		"id_token_signing_alg_values_supported": []string{oauth2.DefaultSigningKey.SigningMethod().Alg()},
+		"claims_supported": []string{
+			"iss", "sub", "aud", "exp", "nbf", "iat", "jti",
+			"repository", "repository_id", "repository_owner", "repository_owner_id", "repository_visibility",
+			"ref", "ref_type", "sha", "workflow", "event_name", "run_id", "run_number", "run_attempt", "job",
+			"environment", "actor", "actor_id",
+		},
+	})
+}
+
+// idTokenKeys publishes the key with which the ID tokens are signed
+func idTokenKeys(resp http.ResponseWriter, req *http.Request) {
+	ctx, cleanUp := context.NewBaseContext(resp, req)
+	defer cleanUp()
+
+	keys := []map[string]string{}
+	// the symmetric keys are secret and cannot sign ID tokens
+	if !oauth2.DefaultSigningKey.IsSymmetric() {
+		jwk, err := oauth2.DefaultSigningKey.ToJWK()
+		if err != nil {
+			log.Error("Error converting signing key to JWK: %v", err)
+			ctx.Error(http.StatusInternalServerError)
+			return
+		}
+		jwk["use"] = "sig"
+		keys = append(keys, jwk)
+	}
+	ctx.JSON(http.StatusOK, map[string]any{"keys": keys})
+}
@@ -19,6 +19,7 @@ import (
 	"code.gitea.io/gitea/services/actions"
 
 	runnerv1 "code.gitea.io/actions-proto-go/runner/v1"
+	"github.com/nektos/act/pkg/jobparser"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -41,11 +42,18 @@ func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv
 		return nil, false, fmt.Errorf("GetVariablesOfRun: %w", err)
 	}
 
+	payload := t.Job.WorkflowPayload
+	if t.Job.IDTokenPermission && !t.Job.IsForkPullRequest {
+		if payload, err = addIDTokenRequest(t, secrets); err != nil {
+			return nil, false, fmt.Errorf("addIDTokenRequest: %w", err)
+		}
+	}
+
 	actions.CreateCommitStatus(ctx, t.Job)
 
 	task := &runnerv1.Task{
 		Id:              t.ID,
-		WorkflowPayload: t.Job.WorkflowPayload,
+		WorkflowPayload: payload,
 		Context:         generateTaskContext(t),
 		Secrets:         secrets,
 		Vars:            vars,
@@ -64,6 +72,35 @@ func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv
 	return task, true, nil
 }
 
+// idTokenRequestTokenSecret is the secret which holds the token with which the job requests ID tokens, the runner
+// masks it in the logs like the other secrets
+const idTokenRequestTokenSecret = "FORGEJO_ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+// addIDTokenRequest returns the workflow payload of the job of the task with the ACTIONS_ID_TOKEN_REQUEST_URL and
+// ACTIONS_ID_TOKEN_REQUEST_TOKEN environment variables, like in GitHub Actions. The runner only sets the environment
+// variables of the workflow, so they are added to its env and the token is passed as a secret.
+func addIDTokenRequest(t *actions_model.ActionTask, secrets map[string]string) ([]byte, error) {
+	token, err := actions.CreateIDTokenRequestToken(t.ID, t.Job.RunID, t.JobID)
+	if err != nil {
+		return nil, err
+	}
+	workflows, err := jobparser.Parse(t.Job.WorkflowPayload)
+	if err != nil {
+		return nil, err
+	}
+	if len(workflows) != 1 {
+		return nil, fmt.Errorf("the payload of job %d has %d workflows", t.JobID, len(workflows))
+	}
+	workflow := workflows[0]
+	if workflow.Env == nil {
+		workflow.Env = make(map[string]string, 2)
+	}
+	workflow.Env["ACTIONS_ID_TOKEN_REQUEST_URL"] = actions.IDTokenRequestURL(t.Job.RunID)
+	workflow.Env["ACTIONS_ID_TOKEN_REQUEST_TOKEN"] = "${{ secrets." + idTokenRequestTokenSecret + " }}"
+	secrets[idTokenRequestTokenSecret] = token
+	return workflow.Marshal()
+}
+
 func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 	event := map[string]any{}
 	_ = json.Unmarshal([]byte(t.Job.Run.EventPayload), &event)
@@ -100,7 +137,7 @@ func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 		log.Error("actions.CreateAuthorizationToken failed: %v", err)
 	}
 
-	taskContext, err := structpb.NewStruct(map[string]any{
+	contexts := map[string]any{
 		// standard contexts, see https://docs.github.com/en/actions/learn-github-actions/contexts#github-context
 		"action":            "",                                                   // string, The name of the action currently running, or the id of a step. GitHub removes special characters, and uses the name __run when the current step runs a script without an id. If you use the same action more than once in the same job, the name will include a suffix with the sequence number with underscore before it. For example, the first script you run will have the name __run, and the second script will be named __run_2. Similarly, the second invocation of actions/checkout will be actionscheckout2.
 		"action_path":       "",                                                   // string, The path where an action is located. This property is only supported in composite actions. You can use this path to access files located in the same repository as the action.
@@ -140,7 +177,9 @@ func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 		// additional contexts
 		"gitea_default_actions_url": setting.Actions.DefaultActionsURL.URL(),
 		"gitea_runtime_token":       giteaRuntimeToken,
-	})
+	}
+
+	taskContext, err := structpb.NewStruct(contexts)
 	if err != nil {
 		log.Error("structpb.NewStruct failed: %v", err)
 	}
@@ -64,6 +64,11 @@ func reqPackageAccess(accessMode perm.AccessMode) func(ctx *context.Context) {
 					return
 				}
 			}
+			if !ctx.AccessTokenAllowsOwner(ctx.Package.Owner) {
+				ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package API"`)
+				ctx.Error(http.StatusUnauthorized, "reqPackageAccess", "token is restricted to another organization or to repositories")
+				return
+			}
 		}
 
 		if ctx.Package.AccessMode < accessMode && !ctx.IsUserSiteAdmin() {
@@ -664,7 +669,7 @@ func ContainerRoutes() *web.Route {
 	r.Get("", container.ReqContainerAccess, container.DetermineSupport)
 	r.Group("/token", func() {
 		r.Get("", container.Authenticate)
-		r.Post("", container.AuthenticateNotImplemented)
+		r.Post("", container.AuthenticateOAuth2)
 	})
 	r.Get("/_catalog", container.ReqContainerAccess, container.GetRepositoryList)
 	r.Group("/{username}", func() {
@@ -709,13 +714,13 @@ func ContainerRoutes() *web.Route {
 			isDelete := ctx.Req.Method == "DELETE"
 
 			if isPost && strings.HasSuffix(path, "/blobs/uploads") {
-				reqPackageAccess(perm.AccessModeWrite)(ctx)
+				ctx.SetParams("image", path[:len(path)-14])
+				container.VerifyImageName(ctx)
 				if ctx.Written() {
 					return
 				}
 
-				ctx.SetParams("image", path[:len(path)-14])
-				container.VerifyImageName(ctx)
+				reqPackageAccess(perm.AccessModeWrite)(ctx)
 				if ctx.Written() {
 					return
 				}
@@ -736,13 +741,13 @@ func ContainerRoutes() *web.Route {
 
 			m := blobsUploadsPattern.FindStringSubmatch(path)
 			if len(m) == 3 && (isGet || isPut || isPatch || isDelete) {
-				reqPackageAccess(perm.AccessModeWrite)(ctx)
+				ctx.SetParams("image", m[1])
+				container.VerifyImageName(ctx)
 				if ctx.Written() {
 					return
 				}
 
-				ctx.SetParams("image", m[1])
-				container.VerifyImageName(ctx)
+				reqPackageAccess(perm.AccessModeWrite)(ctx)
 				if ctx.Written() {
 					return
 				}
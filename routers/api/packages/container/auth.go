@@ -4,8 +4,13 @@
 package container
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/services/auth"
@@ -23,17 +28,36 @@ func (a *Auth) Name() string {
 // Verify extracts the user from the Bearer token
 // If it's an anonymous session a ghost user is returned
 func (a *Auth) Verify(req *http.Request, w http.ResponseWriter, store auth.DataStore, sess auth.SessionStore) (*user_model.User, error) {
-	uid, err := packages.ParseAuthorizationToken(req)
+	token, err := packages.ParseContainerToken(req)
 	if err != nil {
-		log.Trace("ParseAuthorizationToken: %v", err)
+		log.Trace("ParseContainerToken: %v", err)
 		return nil, err
 	}
 
-	if uid == 0 {
+	if token == nil || token.UserID == 0 {
 		return nil, nil
 	}
 
-	u, err := user_model.GetPossibleUserByID(req.Context(), uid)
+	if token.ActionsTaskID != 0 {
+		task, err := actions_model.GetTaskByID(req.Context(), token.ActionsTaskID)
+		if err != nil {
+			log.Error("GetTaskByID:  %v", err)
+			return nil, err
+		}
+		if task.Status.IsDone() {
+			return nil, fmt.Errorf("task %d is done", task.ID)
+		}
+		store.GetData()["IsActionsToken"] = true
+		store.GetData()["ActionsTaskID"] = task.ID
+	}
+	if token.Scope != "" || token.AccessTokenID != 0 {
+		if err := applyAccessTokenLimits(req.Context(), store, token); err != nil {
+			return nil, err
+		}
+	}
+	store.GetData()["ContainerToken"] = token
+
+	u, err := user_model.GetPossibleUserByID(req.Context(), token.UserID)
 	if err != nil {
 		log.Error("GetPossibleUserByID:  %v", err)
 		return nil, err
@@ -41,3 +65,25 @@ func (a *Auth) Verify(req *http.Request, w http.ResponseWriter, store auth.DataS
 
 	return u, nil
 }
+
+// applyAccessTokenLimits limits the request like the API token the container registry token was exchanged for, the
+// token is revoked if the personal access token has been deleted or has expired
+func applyAccessTokenLimits(ctx context.Context, store auth.DataStore, token *packages.ContainerToken) error {
+	store.GetData()["IsApiToken"] = true
+	store.GetData()["ApiTokenScope"] = token.Scope
+	if token.AccessTokenID == 0 {
+		return nil
+	}
+
+	t, exist, err := db.GetByID[auth_model.AccessToken](ctx, token.AccessTokenID)
+	if err != nil {
+		log.Error("GetByID: %v", err)
+		return err
+	}
+	if !exist || t.UID != token.UserID || t.IsExpired() {
+		return fmt.Errorf("access token %d has been revoked", token.AccessTokenID)
+	}
+	store.GetData()["ApiTokenScope"] = t.Scope
+	store.GetData()["ApiAccessToken"] = t
+	return nil
+}
@@ -11,11 +11,15 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	packages_model "code.gitea.io/gitea/models/packages"
 	container_model "code.gitea.io/gitea/models/packages/container"
+	"code.gitea.io/gitea/models/perm"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
@@ -24,6 +28,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/packages/helper"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
 	container_service "code.gitea.io/gitea/services/packages/container"
@@ -126,13 +131,47 @@ func ReqContainerAccess(ctx *context.Context) {
 	}
 }
 
-// VerifyImageName is a middleware which checks if the image name is allowed
+// VerifyImageName is a middleware which checks if the image name is allowed and limits the access to the image to
+// the scope of the token
 func VerifyImageName(ctx *context.Context) {
 	if !imageNamePattern.MatchString(ctx.Params("image")) {
 		apiErrorDefined(ctx, errNameInvalid)
+		return
+	}
+
+	token, ok := ctx.Data["ContainerToken"].(*packages_service.ContainerToken)
+	if !ok || token.Access == nil {
+		return
+	}
+	name := ctx.Package.Owner.LowerName + "/" + ctx.Params("image")
+	switch token.AccessMode(name) {
+	case perm.AccessModeNone:
+		apiInsufficientScopeError(ctx, "repository:"+name+":pull")
+		return
+	case perm.AccessModeRead:
+		if ctx.Req.Method != http.MethodGet && ctx.Req.Method != http.MethodHead {
+			apiInsufficientScopeError(ctx, "repository:"+name+":pull,push")
+			return
+		}
+		// deny the write access like for a personal access token which can only read packages, the scope of the API
+		// token the container registry token was exchanged for is kept
+		if ctx.Data["IsApiToken"] != true {
+			ctx.Data["IsApiToken"] = true
+			ctx.Data["ApiTokenScope"] = auth_model.AccessTokenScopeReadPackage
+		}
+		ctx.Package.AccessMode = min(ctx.Package.AccessMode, perm.AccessModeRead)
 	}
 }
 
+// canMountFrom returns true if the token may pull the image a blob is mounted from
+func canMountFrom(ctx *context.Context, from string) bool {
+	token, ok := ctx.Data["ContainerToken"].(*packages_service.ContainerToken)
+	if !ok || token.Access == nil {
+		return true
+	}
+	return from != "" && token.AccessMode(from) >= perm.AccessModeRead
+}
+
 // DetermineSupport is used to test if the registry supports OCI
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#determining-support
 func DetermineSupport(ctx *context.Context) {
@@ -141,8 +180,85 @@ func DetermineSupport(ctx *context.Context) {
 	})
 }
 
+// apiInsufficientScopeError asks the client for a token whose scope includes scope
+// https://distribution.github.io/distribution/spec/auth/token/#how-to-authenticate
+func apiInsufficientScopeError(ctx *context.Context, scope string) {
+	ctx.Resp.Header().Add("WWW-Authenticate", `Bearer realm="`+setting.AppURL+`v2/token",service="container_registry",scope="`+scope+`",error="insufficient_scope"`)
+	apiErrorDefined(ctx, errUnauthorized)
+}
+
+// tokenResponse is the response of the token endpoint
+// https://distribution.github.io/distribution/spec/auth/token/#token-response-fields
+// https://distribution.github.io/distribution/spec/auth/oauth/#token-response-fields
+type tokenResponse struct {
+	Token        string `json:"token,omitempty"`
+	AccessToken  string `json:"access_token"`
+	Scope        string `json:"scope,omitempty"`
+	ExpiresIn    int64  `json:"expires_in"`
+	IssuedAt     string `json:"issued_at"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// createToken creates a token for the user limited to the requested scopes, and a refresh token if offline is true
+func createToken(ctx *context.Context, u *user_model.User, scopes []string, offline bool) (*tokenResponse, error) {
+	access := packages_service.ParseContainerScope(scopes...)
+
+	// a personal access token without the permission to write packages can only be exchanged for pull access
+	if scope, ok := ctx.Data["ApiTokenScope"].(auth_model.AccessTokenScope); ok && access != nil {
+		canWrite, err := scope.HasScope(auth_model.AccessTokenScopeWritePackage)
+		if err != nil {
+			return nil, err
+		}
+		if !canWrite {
+			for _, a := range access {
+				a.Actions = slices.DeleteFunc(a.Actions, func(action string) bool { return action != "pull" })
+			}
+		}
+	}
+
+	// the token is limited like the API token it is exchanged for, whether or not it is limited to some images
+	t := &packages_service.ContainerToken{
+		UserID: u.ID,
+		Access: access,
+	}
+	if ctx.Data["IsActionsToken"] == true {
+		t.ActionsTaskID = ctx.Data["ActionsTaskID"].(int64)
+	}
+	if ctx.Data["IsApiToken"] == true {
+		t.Scope, _ = ctx.Data["ApiTokenScope"].(auth_model.AccessTokenScope)
+		if pat, ok := ctx.Data["ApiAccessToken"].(*auth_model.AccessToken); ok {
+			t.AccessTokenID = pat.ID
+		}
+	}
+
+	token, err := packages_service.CreateContainerToken(t)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &tokenResponse{
+		AccessToken: token,
+		ExpiresIn:   int64(packages_service.ContainerTokenLifetime.Seconds()),
+		IssuedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	scope := make([]string, 0, len(access))
+	for _, a := range access {
+		scope = append(scope, a.String())
+	}
+	resp.Scope = strings.Join(scope, " ")
+
+	if offline && !u.IsGhost() && t.ActionsTaskID == 0 {
+		resp.RefreshToken, err = packages_service.CreateContainerRefreshToken(u, t)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
 // Authenticate creates a token for the current user
 // If the current user is anonymous, the ghost user is used unless RequireSignInView is enabled.
+// https://distribution.github.io/distribution/spec/auth/token/
 func Authenticate(ctx *context.Context) {
 	u := ctx.Doer
 	if u == nil {
@@ -154,30 +270,86 @@ func Authenticate(ctx *context.Context) {
 		u = user_model.NewGhostUser()
 	}
 
-	token, err := packages_service.CreateAuthorizationToken(u)
+	resp, err := createToken(ctx, u, ctx.FormStrings("scope"), ctx.FormBool("offline_token"))
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
+	resp.Token = resp.AccessToken
 
-	ctx.JSON(http.StatusOK, map[string]string{
-		"token": token,
-	})
+	ctx.JSON(http.StatusOK, resp)
 }
 
+// AuthenticateOAuth2 creates a token for the credentials or the refresh token of the request
 // https://distribution.github.io/distribution/spec/auth/oauth/
-func AuthenticateNotImplemented(ctx *context.Context) {
-	// This optional endpoint can be used to authenticate a client.
-	// It must implement the specification described in:
-	// https://datatracker.ietf.org/doc/html/rfc6749
-	// https://distribution.github.io/distribution/spec/auth/oauth/
-	// Purpose of this stub is to respond with 404 Not Found instead of 405 Method Not Allowed.
+func AuthenticateOAuth2(ctx *context.Context) {
+	oauth2Error := func(status int, code, description string) {
+		ctx.JSON(status, map[string]string{
+			"error":             code,
+			"error_description": description,
+		})
+	}
+
+	var u *user_model.User
+	switch ctx.FormString("grant_type") {
+	case "password":
+		var err error
+		u, err = (&auth_service.Basic{}).VerifyCredentials(ctx.Req, ctx, ctx.FormString("username"), ctx.FormString("password"))
+		if err != nil || u == nil {
+			oauth2Error(http.StatusUnauthorized, "invalid_grant", "invalid username or password")
+			return
+		}
+	case "refresh_token":
+		refreshToken, err := packages_service.ParseContainerRefreshToken(ctx.FormString("refresh_token"))
+		if err != nil {
+			oauth2Error(http.StatusUnauthorized, "invalid_grant", "invalid refresh token")
+			return
+		}
+		u, err = user_model.GetUserByID(ctx, refreshToken.UserID)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				oauth2Error(http.StatusUnauthorized, "invalid_grant", "invalid refresh token")
+				return
+			}
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if !u.IsActive || u.ProhibitLogin {
+			oauth2Error(http.StatusUnauthorized, "invalid_grant", "the user is not allowed to sign in")
+			return
+		}
+		// the refresh token is revoked by a password change and with the personal access token it was exchanged for
+		if !refreshToken.IsBoundTo(u) {
+			oauth2Error(http.StatusUnauthorized, "invalid_grant", "invalid refresh token")
+			return
+		}
+		if refreshToken.Scope != "" || refreshToken.AccessTokenID != 0 {
+			if err := applyAccessTokenLimits(ctx, ctx, refreshToken); err != nil {
+				oauth2Error(http.StatusUnauthorized, "invalid_grant", "invalid refresh token")
+				return
+			}
+		}
+	default:
+		oauth2Error(http.StatusBadRequest, "unsupported_grant_type", "the grant type must be password or refresh_token")
+		return
+	}
 
-	ctx.Status(http.StatusNotFound)
+	resp, err := createToken(ctx, u, ctx.FormStrings("scope"), ctx.FormString("access_type") == "offline")
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
 }
 
 // https://docs.docker.com/registry/spec/api/#listing-repositories
 func GetRepositoryList(ctx *context.Context) {
+	if token, ok := ctx.Data["ContainerToken"].(*packages_service.ContainerToken); ok && !token.CanListCatalog() {
+		apiInsufficientScopeError(ctx, "registry:catalog:*")
+		return
+	}
+
 	n := ctx.FormInt("n")
 	if n <= 0 || n > 100 {
 		n = 100
@@ -217,7 +389,7 @@ func InitiateUploadBlob(ctx *context.Context) {
 
 	mount := ctx.FormTrim("mount")
 	from := ctx.FormTrim("from")
-	if mount != "" {
+	if mount != "" && canMountFrom(ctx, from) {
 		blob, _ := workaroundGetContainerBlob(ctx, &container_model.BlobSearchOptions{
 			Repository: from,
 			Digest:     mount,
@@ -0,0 +1,140 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+// Package scim implements the SCIM 2.0 API with which the identity providers of authentication sources provision
+// users and groups, see https://datatracker.ietf.org/doc/html/rfc7644
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	scim_service "code.gitea.io/gitea/services/scim"
+)
+
+// maxRequestSize limits the bodies of requests, groups with many members are the largest
+const maxRequestSize = 10 << 20
+
+func writeJSON(ctx *context.APIContext, status int, obj any) {
+	ctx.Resp.Header().Set("Content-Type", scim_service.ContentType)
+	ctx.Resp.WriteHeader(status)
+	if err := json.NewEncoder(ctx.Resp).Encode(obj); err != nil {
+		log.Error("JSON encode: %v", err)
+	}
+}
+
+// apiError responds with the SCIM error, errors which are no SCIM errors are internal server errors
+func apiError(ctx *context.APIContext, err error) {
+	scimErr, ok := scim_service.AsError(err)
+	if !ok {
+		log.ErrorWithSkip(1, "SCIM: %v", err)
+		scimErr = scim_service.NewError(http.StatusInternalServerError, "", "internal server error")
+	}
+	writeJSON(ctx, scimErr.StatusCode(), scimErr)
+}
+
+// source returns the authentication source which the token of the request is scoped to
+func source(ctx *context.APIContext) *auth_model.Source {
+	return ctx.Data["SCIMSource"].(*auth_model.Source)
+}
+
+// verifyToken authenticates the request with the SCIM token of an active authentication source
+func verifyToken(ctx *context.APIContext) {
+	auth, token, _ := strings.Cut(ctx.Req.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(auth, "Bearer") || token == "" {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="SCIM"`)
+		apiError(ctx, scim_service.NewError(http.StatusUnauthorized, "", "a bearer token is required"))
+		return
+	}
+	t, err := auth_model.GetSCIMTokenByToken(ctx, strings.TrimSpace(token))
+	if err != nil {
+		if !auth_model.IsErrSCIMTokenNotExist(err) {
+			apiError(ctx, err)
+			return
+		}
+		log.Warn("SCIM: invalid token from %s", ctx.RemoteAddr())
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="SCIM", error="invalid_token"`)
+		apiError(ctx, scim_service.NewError(http.StatusUnauthorized, "", "invalid token"))
+		return
+	}
+	authSource, err := auth_model.GetSourceByID(ctx, t.SourceID)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	if !authSource.IsActive {
+		apiError(ctx, scim_service.NewError(http.StatusForbidden, "", "the authentication source is not active"))
+		return
+	}
+	ctx.Data["SCIMSource"] = authSource
+	ctx.Req.Body = http.MaxBytesReader(ctx.Resp, ctx.Req.Body, maxRequestSize)
+}
+
+// decodeBody decodes the JSON body of the request
+func decodeBody(ctx *context.APIContext, obj any) bool {
+	if err := json.NewDecoder(ctx.Req.Body).Decode(obj); err != nil {
+		apiError(ctx, scim_service.NewError(http.StatusBadRequest, scim_service.ErrorTypeInvalidSyntax, "invalid JSON: %v", err))
+		return false
+	}
+	return true
+}
+
+// listOptions returns the options of a query from the query string
+func listOptions(ctx *context.APIContext) scim_service.ListOptions {
+	opts := scim_service.ListOptions{
+		Filter:     ctx.FormString("filter"),
+		StartIndex: ctx.FormInt("startIndex"),
+		Count:      scim_service.MaxResults,
+	}
+	if count := ctx.FormString("count"); count != "" {
+		opts.Count, _ = strconv.Atoi(count)
+	}
+	for _, attribute := range strings.Split(ctx.FormString("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			opts.ExcludeMembers = true
+		}
+	}
+	return opts
+}
+
+// ServiceProviderConfig describes the features of the SCIM API
+func ServiceProviderConfig(ctx *context.APIContext) {
+	writeJSON(ctx, http.StatusOK, scim_service.NewServiceProviderConfig(scim_service.BaseURL()+"/ServiceProviderConfig"))
+}
+
+// ResourceTypes lists the resource types of the SCIM API
+func ResourceTypes(ctx *context.APIContext) {
+	resources := scim_service.NewResourceTypes(scim_service.BaseURL())
+	writeJSON(ctx, http.StatusOK, scim_service.NewListResponse(int64(len(resources)), 1, resources))
+}
+
+// Routes are the routes of the SCIM API, which is mounted on `/scim/v2`
+func Routes() *web.Route {
+	m := web.NewRoute()
+	m.Use(context.APIContexter())
+	m.Use(verifyToken)
+
+	m.Get("/ServiceProviderConfig", ServiceProviderConfig)
+	m.Get("/ResourceTypes", ResourceTypes)
+	m.Group("/Users", func() {
+		m.Combo("").Get(ListUsers).Post(CreateUser)
+		m.Combo("/{id}").Get(GetUser).Put(ReplaceUser).Patch(PatchUser).Delete(DeleteUser)
+	})
+	m.Group("/Groups", func() {
+		m.Combo("").Get(ListGroups).Post(CreateGroup)
+		m.Combo("/{id}").Get(GetGroup).Put(ReplaceGroup).Patch(PatchGroup).Delete(DeleteGroup)
+	})
+	m.NotFound(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", scim_service.ContentType)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(scim_service.NewError(http.StatusNotFound, "", "not found"))
+	})
+
+	return m
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/services/context"
+	scim_service "code.gitea.io/gitea/services/scim"
+)
+
+// ListGroups lists the groups of the source which match the filter
+func ListGroups(ctx *context.APIContext) {
+	resp, err := scim_service.ListGroups(ctx, source(ctx), listOptions(ctx))
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, resp)
+}
+
+// GetGroup returns a group of the source
+func GetGroup(ctx *context.APIContext) {
+	group, err := scim_service.GetGroup(ctx, source(ctx), ctx.Params("id"), listOptions(ctx).ExcludeMembers)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, group)
+}
+
+// CreateGroup provisions a group of the source
+func CreateGroup(ctx *context.APIContext) {
+	group := &scim_service.Group{}
+	if !decodeBody(ctx, group) {
+		return
+	}
+	group, err := scim_service.CreateGroup(ctx, source(ctx), group)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	ctx.Resp.Header().Set("Location", group.Meta.Location)
+	writeJSON(ctx, http.StatusCreated, group)
+}
+
+// ReplaceGroup replaces the attributes and the members of a group of the source
+func ReplaceGroup(ctx *context.APIContext) {
+	group := &scim_service.Group{}
+	if !decodeBody(ctx, group) {
+		return
+	}
+	group, err := scim_service.ReplaceGroup(ctx, source(ctx), ctx.Params("id"), group)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, group)
+}
+
+// PatchGroup modifies a group of the source
+func PatchGroup(ctx *context.APIContext) {
+	patch := &scim_service.PatchOp{}
+	if !decodeBody(ctx, patch) {
+		return
+	}
+	group, err := scim_service.PatchGroup(ctx, source(ctx), ctx.Params("id"), patch)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, group)
+}
+
+// DeleteGroup deletes a group of the source
+func DeleteGroup(ctx *context.APIContext) {
+	if err := scim_service.DeleteGroup(ctx, source(ctx), ctx.Params("id")); err != nil {
+		apiError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
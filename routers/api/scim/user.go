@@ -0,0 +1,83 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/services/context"
+	scim_service "code.gitea.io/gitea/services/scim"
+)
+
+// ListUsers lists the users of the source which match the filter
+func ListUsers(ctx *context.APIContext) {
+	resp, err := scim_service.ListUsers(ctx, source(ctx), listOptions(ctx))
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, resp)
+}
+
+// GetUser returns a user of the source
+func GetUser(ctx *context.APIContext) {
+	user, err := scim_service.GetUser(ctx, source(ctx), ctx.Params("id"))
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, user)
+}
+
+// CreateUser provisions a user of the source
+func CreateUser(ctx *context.APIContext) {
+	user := &scim_service.User{}
+	if !decodeBody(ctx, user) {
+		return
+	}
+	user, err := scim_service.CreateUser(ctx, source(ctx), user)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	ctx.Resp.Header().Set("Location", user.Meta.Location)
+	writeJSON(ctx, http.StatusCreated, user)
+}
+
+// ReplaceUser replaces the attributes of a user of the source
+func ReplaceUser(ctx *context.APIContext) {
+	user := &scim_service.User{}
+	if !decodeBody(ctx, user) {
+		return
+	}
+	user, err := scim_service.ReplaceUser(ctx, source(ctx), ctx.Params("id"), user)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, user)
+}
+
+// PatchUser modifies a user of the source
+func PatchUser(ctx *context.APIContext) {
+	patch := &scim_service.PatchOp{}
+	if !decodeBody(ctx, patch) {
+		return
+	}
+	user, err := scim_service.PatchUser(ctx, source(ctx), ctx.Params("id"), patch)
+	if err != nil {
+		apiError(ctx, err)
+		return
+	}
+	writeJSON(ctx, http.StatusOK, user)
+}
+
+// DeleteUser deprovisions a user of the source
+func DeleteUser(ctx *context.APIContext) {
+	if err := scim_service.DeleteUser(ctx, source(ctx), ctx.Params("id")); err != nil {
+		apiError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -7,12 +7,14 @@ import (
 	"errors"
 	"net/http"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/webhook"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	webhook_service "code.gitea.io/gitea/services/webhook"
 )
@@ -163,14 +165,19 @@ func DeleteHook(ctx *context.APIContext) {
 	//   "204":
 	//     "$ref": "#/responses/empty"
 
-	hookID := ctx.ParamsInt64(":id")
-	if err := webhook.DeleteDefaultSystemWebhook(ctx, hookID); err != nil {
+	w, err := webhook.GetSystemOrDefaultWebhook(ctx, ctx.ParamsInt64(":id"))
+	if err != nil {
 		if errors.Is(err, util.ErrNotExist) {
 			ctx.NotFound()
 		} else {
-			ctx.Error(http.StatusInternalServerError, "DeleteDefaultSystemWebhook", err)
+			ctx.Error(http.StatusInternalServerError, "GetSystemOrDefaultWebhook", err)
 		}
 		return
 	}
+	if err := webhook.DeleteDefaultSystemWebhook(ctx, w.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteDefaultSystemWebhook", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookDelete, audit_service.WebhookTarget(ctx, w), audit_service.WebhookState(w), nil)
 	ctx.Status(http.StatusNoContent)
 }
@@ -11,6 +11,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
@@ -24,6 +25,7 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/user"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/mailer"
@@ -153,6 +155,7 @@ func CreateUser(ctx *context.APIContext) {
 	}
 
 	log.Trace("Account created by admin (%s): %s", ctx.Doer.Name, u.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserCreate, audit_service.InstanceTarget("user", u.ID, u.Name), nil, audit_service.UserState(u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -199,6 +202,7 @@ func EditUser(ctx *context.APIContext) {
 			return
 		}
 	}
+	before := audit_service.UserState(ctx.ContextUser)
 
 	authOpts := &user_service.UpdateAuthOptions{
 		LoginSource:        optional.FromPtr(form.SourceID),
@@ -265,6 +269,9 @@ func EditUser(ctx *context.APIContext) {
 	}
 
 	log.Trace("Account profile updated by admin (%s): %s", ctx.Doer.Name, ctx.ContextUser.Name)
+	after := audit_service.UserState(ctx.ContextUser)
+	after["password_changed"] = form.Password != ""
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserUpdate, audit_service.InstanceTarget("user", ctx.ContextUser.ID, ctx.ContextUser.Name), before, after)
 
 	ctx.JSON(http.StatusOK, convert.ToUser(ctx, ctx.ContextUser, ctx.Doer))
 }
@@ -319,6 +326,8 @@ func DeleteUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account deleted by admin(%s): %s", ctx.Doer.Name, ctx.ContextUser.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserDelete, audit_service.InstanceTarget("user", ctx.ContextUser.ID, ctx.ContextUser.Name),
+		audit_service.UserState(ctx.ContextUser), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -382,7 +391,17 @@ func DeleteUserPublicKey(ctx *context.APIContext) {
 	//   "404":
 	//     "$ref": "#/responses/notFound"
 
-	if err := asymkey_service.DeletePublicKey(ctx, ctx.ContextUser, ctx.ParamsInt64(":id")); err != nil {
+	key, err := asymkey_model.GetPublicKeyByID(ctx, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if asymkey_model.IsErrKeyNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPublicKeyByID", err)
+		}
+		return
+	}
+
+	if err := asymkey_service.DeletePublicKey(ctx, ctx.ContextUser, key.ID); err != nil {
 		if asymkey_model.IsErrKeyNotExist(err) {
 			ctx.NotFound()
 		} else if asymkey_model.IsErrKeyAccessDenied(err) {
@@ -393,6 +412,7 @@ func DeleteUserPublicKey(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Key deleted by admin(%s): %s", ctx.Doer.Name, ctx.ContextUser.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionPublicKeyRemove, audit_service.PublicKeyTarget(key), audit_service.PublicKeyState(key), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
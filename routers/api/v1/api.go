@@ -103,6 +103,7 @@ import (
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
 
 	"gitea.com/go-chi/binding"
+	"github.com/go-chi/chi/v5"
 )
 
 func sudo() func(ctx *context.APIContext) {
@@ -189,6 +190,15 @@ func repoAssignment() func(ctx *context.APIContext) {
 		repo.Owner = owner
 		ctx.Repo.Repository = repo
 
+		if !ctx.AccessTokenAllowsRepo(repo) {
+			ctx.NotFound()
+			return
+		}
+		ctx.CheckOrgAuthPolicy(owner)
+		if ctx.Written() {
+			return
+		}
+
 		if ctx.Doer != nil && ctx.Doer.ID == user_model.ActionsUserID {
 			taskID := ctx.Data["ActionsTaskID"].(int64)
 			task, err := actions_model.GetTaskByID(ctx, taskID)
@@ -367,6 +377,59 @@ func reqExploreSignIn() func(ctx *context.APIContext) {
 	}
 }
 
+// restrictedTokenRoutes are the routes scoped to a repository or to an organization, which check that a personal access
+// token restricted to an organization or to repositories allows them
+var restrictedTokenRoutes = []string{
+	"/repos/{username}/{reponame}",
+	"/repos/migrate",
+	"/repositories/{id}",
+	"/user/repos",
+	"/user/starred/{username}/{reponame}",
+	"/users/{username}/repos",
+	"/org/{org}/repos",
+	"/orgs/{org}",
+	"/teams/{teamid}",
+	"/packages/{username}",
+}
+
+// checkRestrictedToken rejects the personal access tokens which are restricted to an organization or to repositories
+// on all the routes but restrictedTokenRoutes, so that a route which does not check the restriction cannot be used
+// to reach the other repositories of the user, e.g. by adding an SSH key
+func checkRestrictedToken() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if !ctx.AccessTokenIsRestricted() {
+			return
+		}
+		_, pattern, _ := strings.Cut(chi.RouteContext(ctx.Req.Context()).RoutePattern(), "/api/v1")
+		for _, route := range restrictedTokenRoutes {
+			if pattern == route || strings.HasPrefix(pattern, route+"/") {
+				return
+			}
+		}
+		ctx.Error(http.StatusForbidden, "checkRestrictedToken", "token is restricted to an organization or to repositories")
+	}
+}
+
+// reqUnrestrictedToken rejects the personal access tokens which are restricted to an organization or to repositories,
+// the routes using it list data across all the repositories of the user
+func reqUnrestrictedToken() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if ctx.AccessTokenIsRestricted() {
+			ctx.Error(http.StatusForbidden, "reqUnrestrictedToken", "token is restricted to an organization or to repositories")
+		}
+	}
+}
+
+// reqTokenAllowsContextUser rejects the personal access tokens which are restricted to another organization or to
+// repositories, the routes using it list data across all the repositories of the context user
+func reqTokenAllowsContextUser() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if !ctx.AccessTokenAllowsOwner(ctx.ContextUser) {
+			ctx.Error(http.StatusForbidden, "reqTokenAllowsContextUser", "token is restricted to another organization or to repositories")
+		}
+	}
+}
+
 func reqBasicOrRevProxyAuth() func(ctx *context.APIContext) {
 	return func(ctx *context.APIContext) {
 		if ctx.IsSigned && setting.Service.EnableReverseProxyAuthAPI && ctx.Data["AuthedMethod"].(string) == auth.ReverseProxyMethodName {
@@ -610,6 +673,14 @@ func orgAssignment(args ...bool) func(ctx *context.APIContext) {
 				return
 			}
 			ctx.ContextUser = ctx.Org.Organization.AsUser()
+			if !ctx.AccessTokenAllowsOwner(ctx.ContextUser) {
+				ctx.NotFound()
+				return
+			}
+			ctx.CheckOrgAuthPolicy(ctx.ContextUser)
+			if ctx.Written() {
+				return
+			}
 		}
 
 		if assignTeam {
@@ -622,6 +693,10 @@ func orgAssignment(args ...bool) func(ctx *context.APIContext) {
 				}
 				return
 			}
+			if !ctx.AccessTokenAllowsOwner(&user_model.User{ID: ctx.Org.Team.OrgID}) {
+				ctx.NotFound()
+				return
+			}
 		}
 	}
 }
@@ -858,7 +933,7 @@ func Routes() *web.Route {
 					m.Get("/heatmap", user.GetUserHeatmapData)
 				}
 
-				m.Get("/repos", tokenRequiresScopes(auth_model.AccessTokenScopeCategoryRepository), reqExploreSignIn(), user.ListUserRepos)
+				m.Get("/repos", tokenRequiresScopes(auth_model.AccessTokenScopeCategoryRepository), reqExploreSignIn(), reqTokenAllowsContextUser(), user.ListUserRepos)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), reqToken(), user.CreateAccessToken)
@@ -963,7 +1038,7 @@ func Routes() *web.Route {
 			m.Post("/gpg_key_verify", bind(api.VerifyGPGKeyOption{}), user.VerifyUserGPGKey)
 
 			// (repo scope)
-			m.Combo("/repos", tokenRequiresScopes(auth_model.AccessTokenScopeCategoryRepository)).Get(user.ListMyRepos).
+			m.Combo("/repos", tokenRequiresScopes(auth_model.AccessTokenScopeCategoryRepository)).Get(reqUnrestrictedToken(), user.ListMyRepos).
 				Post(bind(api.CreateRepoOption{}), repo.Create)
 
 			// (repo scope)
@@ -1016,6 +1091,8 @@ func Routes() *web.Route {
 		// Repos (requires repo scope)
 		m.Group("/repos", func() {
 			m.Get("/search", repo.Search)
+			m.Get("/search/code", repo.SearchCode)
+			m.Get("/search/commits", repo.SearchCommits)
 
 			// (repo scope)
 			m.Post("/migrate", reqToken(), bind(api.MigrateRepoOptions{}), repo.Migrate)
@@ -1121,6 +1198,7 @@ func Routes() *web.Route {
 							Delete(repo.DeleteTagProtection)
 					})
 				}, reqToken(), reqAdmin())
+				m.Get("/ref_protection", reqToken(), reqAdmin(), repo.GetRefProtection)
 				m.Group("/actions", func() {
 					m.Get("/tasks", repo.ListActionTasks)
 
@@ -1136,6 +1214,7 @@ func Routes() *web.Route {
 					m.Combo("/{id}").Get(repo.GetDeployKey).
 						Delete(repo.DeleteDeploykey)
 				}, reqToken(), reqAdmin())
+				m.Get("/audit", reqToken(), reqAdmin(), repo.ListAuditEvents)
 				m.Group("/times", func() {
 					m.Combo("").Get(repo.ListTrackedTimesByRepository)
 					m.Combo("/{timetrackingusername}").Get(repo.ListTrackedTimesByUser)
@@ -1198,16 +1277,21 @@ func Routes() *web.Route {
 					m.Combo("").Get(repo.ListPullRequests).
 						Post(reqToken(), mustNotBeArchived, bind(api.CreatePullRequestOption{}), repo.CreatePullRequest)
 					m.Get("/pinned", repo.ListPinnedPullRequests)
+					m.Get("/merge_queue", repo.ListMergeQueue)
 					m.Group("/{index}", func() {
 						m.Combo("").Get(repo.GetPullRequest).
 							Patch(reqToken(), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Get(".{diffType:diff|patch}", repo.DownloadPullDiffOrPatch)
 						m.Post("/update", reqToken(), repo.UpdatePullRequest)
+						m.Combo("/conflicts", reqToken()).Get(repo.GetPullRequestConflicts).
+							Post(mustNotBeArchived, bind(api.ResolvePullRequestConflictsOption{}), repo.ResolvePullRequestConflicts)
 						m.Get("/commits", repo.GetPullRequestCommits)
 						m.Get("/files", repo.GetPullRequestFiles)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
 							Post(reqToken(), mustNotBeArchived, bind(forms.MergePullRequestForm{}), repo.MergePullRequest).
 							Delete(reqToken(), mustNotBeArchived, repo.CancelScheduledAutoMerge)
+						m.Combo("/merge_queue").Get(repo.GetPullRequestMergeQueueEntry).
+							Delete(reqToken(), mustNotBeArchived, repo.RemovePullRequestFromMergeQueue)
 						m.Group("/reviews", func() {
 							m.Combo("").
 								Get(repo.ListPullReviews).
@@ -1352,6 +1436,12 @@ func Routes() *web.Route {
 								Delete(reqToken(), bind(api.DeleteLabelsOption{}), repo.ClearIssueLabels)
 							m.Delete("/{id}", reqToken(), bind(api.DeleteLabelsOption{}), repo.DeleteIssueLabel)
 						})
+						m.Group("/fields", func() {
+							m.Get("", repo.ListIssueFieldValues)
+							m.Combo("/{id}").
+								Put(reqToken(), mustNotBeArchived, bind(api.SetIssueFieldValueOption{}), repo.SetIssueFieldValue).
+								Delete(reqToken(), mustNotBeArchived, repo.DeleteIssueFieldValue)
+						})
 						m.Group("/times", func() {
 							m.Combo("").
 								Get(repo.ListTrackedTimes).
@@ -1392,6 +1482,12 @@ func Routes() *web.Route {
 							Get(repo.GetIssueBlocks).
 							Post(reqToken(), bind(api.IssueMeta{}), repo.CreateIssueBlocking).
 							Delete(reqToken(), bind(api.IssueMeta{}), repo.RemoveIssueBlocking)
+						m.Combo("/sub_issues").
+							Get(repo.ListSubIssues).
+							Post(reqToken(), mustNotBeArchived, bind(api.IssueMeta{}), repo.AddSubIssue).
+							Patch(reqToken(), mustNotBeArchived, bind(api.MoveSubIssueOption{}), repo.MoveSubIssue).
+							Delete(reqToken(), mustNotBeArchived, bind(api.IssueMeta{}), repo.RemoveSubIssue)
+						m.Get("/parent", repo.GetParentIssue)
 						m.Group("/pin", func() {
 							m.Combo("").
 								Post(reqToken(), reqAdmin(), repo.PinIssue).
@@ -1407,6 +1503,13 @@ func Routes() *web.Route {
 						Patch(reqToken(), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.EditLabelOption{}), repo.EditLabel).
 						Delete(reqToken(), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), repo.DeleteLabel)
 				})
+				m.Group("/issue_fields", func() {
+					m.Combo("").Get(repo.ListIssueFields).
+						Post(reqToken(), reqAdmin(), bind(api.CreateIssueFieldOption{}), repo.CreateIssueField)
+					m.Combo("/{id}").Get(repo.GetIssueField).
+						Patch(reqToken(), reqAdmin(), bind(api.EditIssueFieldOption{}), repo.EditIssueField).
+						Delete(reqToken(), reqAdmin(), repo.DeleteIssueField)
+				}, mustEnableIssuesOrPulls)
 				m.Group("/milestones", func() {
 					m.Combo("").Get(repo.ListMilestones).
 						Post(reqToken(), reqRepoWriter(unit.TypeIssues, unit.TypePullRequests), bind(api.CreateMilestoneOption{}), repo.CreateMilestone)
@@ -1425,7 +1528,7 @@ func Routes() *web.Route {
 				m.Get("/files", reqToken(), packages.ListPackageFiles)
 			})
 			m.Get("/", reqToken(), packages.ListPackages)
-		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryPackage), context.UserAssignmentAPI(), context.PackageAssignmentAPI(), reqPackageAccess(perm.AccessModeRead))
+		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryPackage), context.UserAssignmentAPI(), reqTokenAllowsContextUser(), context.PackageAssignmentAPI(), reqPackageAccess(perm.AccessModeRead))
 
 		// Organizations
 		m.Get("/user/orgs", reqToken(), tokenRequiresScopes(auth_model.AccessTokenScopeCategoryUser, auth_model.AccessTokenScopeCategoryOrganization), org.ListMyOrgs)
@@ -1469,6 +1572,13 @@ func Routes() *web.Route {
 					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
 					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
 			})
+			m.Group("/issue_fields", func() {
+				m.Get("", org.ListIssueFields)
+				m.Post("", reqToken(), reqOrgOwnership(), bind(api.CreateIssueFieldOption{}), org.CreateIssueField)
+				m.Combo("/{id}").Get(org.GetIssueField).
+					Patch(reqToken(), reqOrgOwnership(), bind(api.EditIssueFieldOption{}), org.EditIssueField).
+					Delete(reqToken(), reqOrgOwnership(), org.DeleteIssueField)
+			})
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
@@ -1476,6 +1586,14 @@ func Routes() *web.Route {
 					Patch(bind(api.EditHookOption{}), org.EditHook).
 					Delete(org.DeleteHook)
 			}, reqToken(), reqOrgOwnership(), reqWebhooksEnabled())
+			m.Group("/rulesets", func() {
+				m.Combo("").Get(org.ListRulesets).
+					Post(bind(api.CreateOrgRulesetOption{}), org.CreateRuleset)
+				m.Combo("/{id}").Get(org.GetRuleset).
+					Patch(bind(api.EditOrgRulesetOption{}), org.EditRuleset).
+					Delete(org.DeleteRuleset)
+			}, reqToken(), reqOrgOwnership())
+			m.Get("/audit", reqToken(), reqOrgOwnership(), org.ListAuditEvents)
 			m.Group("/avatar", func() {
 				m.Post("", bind(api.UpdateUserAvatarOption{}), org.UpdateAvatar)
 				m.Delete("", org.DeleteAvatar)
@@ -1552,12 +1670,13 @@ func Routes() *web.Route {
 			m.Group("/runners", func() {
 				m.Get("/registration-token", admin.GetRegistrationToken)
 			})
+			m.Get("/audit", admin.ListAuditEvents)
 		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryAdmin), reqToken(), reqSiteAdmin())
 
 		m.Group("/topics", func() {
 			m.Get("/search", repo.TopicSearch)
 		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryRepository))
-	}, sudo())
+	}, sudo(), checkRestrictedToken())
 
 	return m
 }
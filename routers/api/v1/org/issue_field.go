@@ -0,0 +1,250 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"net/http"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// ListIssueFields list the custom issue fields of an organization
+func ListIssueFields(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/issue_fields organization orgListIssueFields
+	// ---
+	// summary: List the custom issue fields of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueFieldList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	fields, err := issues_model.GetIssueFieldsByOrgID(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldsByOrgID", err)
+		return
+	}
+
+	ctx.SetTotalCountHeader(int64(len(fields)))
+	ctx.JSON(http.StatusOK, convert.ToIssueFieldList(fields))
+}
+
+// GetIssueField get a custom issue field of an organization
+func GetIssueField(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/issue_fields/{id} organization orgGetIssueField
+	// ---
+	// summary: Get a custom issue field of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueField"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	f := getIssueFieldByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueField(f))
+}
+
+// CreateIssueField create a custom issue field for an organization
+func CreateIssueField(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/issue_fields organization orgCreateIssueField
+	// ---
+	// summary: Create a custom issue field for the repositories of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateIssueFieldOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/IssueField"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateIssueFieldOption)
+	f := &issues_model.IssueField{
+		OrgID:       ctx.Org.Organization.ID,
+		Name:        form.Name,
+		Description: form.Description,
+		Type:        issues_model.IssueFieldType(form.Type),
+		Options:     form.Options,
+		SortOrder:   form.SortOrder,
+	}
+	if err := issues_model.NewIssueField(ctx, f); err != nil {
+		handleIssueFieldError(ctx, "NewIssueField", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToIssueField(f))
+}
+
+// EditIssueField modify a custom issue field of an organization
+func EditIssueField(ctx *context.APIContext) {
+	// swagger:operation PATCH /orgs/{org}/issue_fields/{id} organization orgEditIssueField
+	// ---
+	// summary: Update a custom issue field of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to edit
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditIssueFieldOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueField"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditIssueFieldOption)
+	f := getIssueFieldByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Name != nil {
+		f.Name = *form.Name
+	}
+	if form.Description != nil {
+		f.Description = *form.Description
+	}
+	if form.Options != nil {
+		f.Options = form.Options
+	}
+	if form.SortOrder != nil {
+		f.SortOrder = *form.SortOrder
+	}
+	if err := issue_service.UpdateField(ctx, f); err != nil {
+		handleIssueFieldError(ctx, "UpdateField", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueField(f))
+}
+
+// DeleteIssueField delete a custom issue field of an organization
+func DeleteIssueField(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/issue_fields/{id} organization orgDeleteIssueField
+	// ---
+	// summary: Delete a custom issue field of an organization and its values
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	f := getIssueFieldByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := issue_service.DeleteField(ctx, f); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteField", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func getIssueFieldByParams(ctx *context.APIContext) *issues_model.IssueField {
+	f, err := issues_model.GetIssueFieldInOrgByID(ctx, ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueFieldInOrgByID", err)
+		}
+		return nil
+	}
+	return f
+}
+
+func handleIssueFieldError(ctx *context.APIContext, name string, err error) {
+	switch {
+	case issues_model.IsErrIssueFieldAlreadyExist(err):
+		ctx.Error(http.StatusConflict, name, err)
+	case issues_model.IsErrIssueFieldInvalid(err):
+		ctx.Error(http.StatusUnprocessableEntity, name, err)
+	default:
+		ctx.Error(http.StatusInternalServerError, name, err)
+	}
+}
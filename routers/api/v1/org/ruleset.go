@@ -0,0 +1,345 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"errors"
+	"net/http"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	audit_service "code.gitea.io/gitea/services/audit"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListRulesets list the rulesets of an organization
+func ListRulesets(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/rulesets organization orgListRulesets
+	// ---
+	// summary: List an organization's branch and tag protection rulesets
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/OrgRulesetList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	rulesets, err := git_model.GetOrgRulesets(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrgRulesets", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToOrgRulesetList(ctx, rulesets))
+}
+
+// GetRuleset get a ruleset of an organization
+func GetRuleset(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/rulesets/{id} organization orgGetRuleset
+	// ---
+	// summary: Get a branch or tag protection ruleset of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the ruleset to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/OrgRuleset"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	ruleset := getRulesetByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToOrgRuleset(ctx, ruleset))
+}
+
+// CreateRuleset create a ruleset for an organization
+func CreateRuleset(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/rulesets organization orgCreateRuleset
+	// ---
+	// summary: Create a branch or tag protection ruleset for an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateOrgRulesetOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/OrgRuleset"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateOrgRulesetOption)
+
+	target, err := git_model.ParseOrgRulesetTarget(form.Target)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ParseOrgRulesetTarget", err)
+		return
+	}
+
+	ruleset := &git_model.OrgRuleset{
+		OrgID:                 ctx.Org.Organization.ID,
+		Name:                  form.Name,
+		RepoNamePattern:       form.RepoNamePattern,
+		RepoTopic:             form.RepoTopic,
+		Target:                target,
+		RefPattern:            form.RefPattern,
+		CanPush:               form.EnablePush,
+		EnableWhitelist:       form.EnablePush && form.EnablePushWhitelist,
+		RequiredApprovals:     form.RequiredApprovals,
+		EnableStatusCheck:     form.EnableStatusCheck,
+		StatusCheckContexts:   form.StatusCheckContexts,
+		RequireSignedCommits:  form.RequireSignedCommits,
+		ProtectedFilePatterns: form.ProtectedFilePatterns,
+	}
+	if ruleset.EnableWhitelist {
+		if !setRulesetWhitelist(ctx, ruleset, form.PushWhitelistUsernames, form.PushWhitelistTeams) {
+			return
+		}
+	}
+
+	if err := git_model.UpdateOrgRuleset(ctx, ruleset); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "UpdateOrgRuleset", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "UpdateOrgRuleset", err)
+		return
+	}
+
+	apiRuleset := convert.ToOrgRuleset(ctx, ruleset)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionRulesetUpdate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "ruleset", ruleset.ID, ruleset.Name), nil, apiRuleset)
+	ctx.JSON(http.StatusCreated, apiRuleset)
+}
+
+// EditRuleset edit a ruleset of an organization
+func EditRuleset(ctx *context.APIContext) {
+	// swagger:operation PATCH /orgs/{org}/rulesets/{id} organization orgEditRuleset
+	// ---
+	// summary: Edit a branch or tag protection ruleset of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the ruleset to edit
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditOrgRulesetOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/OrgRuleset"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditOrgRulesetOption)
+
+	ruleset := getRulesetByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	before := convert.ToOrgRuleset(ctx, ruleset)
+
+	if form.Name != nil {
+		ruleset.Name = *form.Name
+	}
+	if form.RepoNamePattern != nil {
+		ruleset.RepoNamePattern = *form.RepoNamePattern
+	}
+	if form.RepoTopic != nil {
+		ruleset.RepoTopic = *form.RepoTopic
+	}
+	if form.Target != nil {
+		target, err := git_model.ParseOrgRulesetTarget(*form.Target)
+		if err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "ParseOrgRulesetTarget", err)
+			return
+		}
+		ruleset.Target = target
+	}
+	if form.RefPattern != nil {
+		ruleset.RefPattern = *form.RefPattern
+	}
+	if form.EnablePush != nil {
+		ruleset.CanPush = *form.EnablePush
+		if !ruleset.CanPush {
+			ruleset.EnableWhitelist = false
+		}
+	}
+	if form.EnablePushWhitelist != nil {
+		ruleset.EnableWhitelist = ruleset.CanPush && *form.EnablePushWhitelist
+	}
+	if form.PushWhitelistUsernames != nil || form.PushWhitelistTeams != nil {
+		usernames, teams := form.PushWhitelistUsernames, form.PushWhitelistTeams
+		current := convert.ToOrgRuleset(ctx, ruleset)
+		if usernames == nil {
+			usernames = current.PushWhitelistUsernames
+		}
+		if teams == nil {
+			teams = current.PushWhitelistTeams
+		}
+		if !setRulesetWhitelist(ctx, ruleset, usernames, teams) {
+			return
+		}
+	}
+	if !ruleset.EnableWhitelist {
+		ruleset.WhitelistUserIDs = nil
+		ruleset.WhitelistTeamIDs = nil
+	}
+	if form.RequiredApprovals != nil {
+		ruleset.RequiredApprovals = *form.RequiredApprovals
+	}
+	if form.EnableStatusCheck != nil {
+		ruleset.EnableStatusCheck = *form.EnableStatusCheck
+	}
+	if form.StatusCheckContexts != nil {
+		ruleset.StatusCheckContexts = form.StatusCheckContexts
+	}
+	if form.RequireSignedCommits != nil {
+		ruleset.RequireSignedCommits = *form.RequireSignedCommits
+	}
+	if form.ProtectedFilePatterns != nil {
+		ruleset.ProtectedFilePatterns = *form.ProtectedFilePatterns
+	}
+
+	if err := git_model.UpdateOrgRuleset(ctx, ruleset); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "UpdateOrgRuleset", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "UpdateOrgRuleset", err)
+		return
+	}
+
+	apiRuleset := convert.ToOrgRuleset(ctx, ruleset)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionRulesetUpdate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "ruleset", ruleset.ID, ruleset.Name), before, apiRuleset)
+	ctx.JSON(http.StatusOK, apiRuleset)
+}
+
+// DeleteRuleset delete a ruleset of an organization
+func DeleteRuleset(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/rulesets/{id} organization orgDeleteRuleset
+	// ---
+	// summary: Delete a branch or tag protection ruleset of an organization
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the ruleset to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	ruleset := getRulesetByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := git_model.DeleteOrgRuleset(ctx, ruleset.OrgID, ruleset.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteOrgRuleset", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionRulesetDelete, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "ruleset", ruleset.ID, ruleset.Name),
+		convert.ToOrgRuleset(ctx, ruleset), nil)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func getRulesetByParams(ctx *context.APIContext) *git_model.OrgRuleset {
+	ruleset, err := git_model.GetOrgRulesetByID(ctx, ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetOrgRulesetByID", err)
+		return nil
+	}
+	if ruleset == nil {
+		ctx.NotFound()
+		return nil
+	}
+	return ruleset
+}
+
+// setRulesetWhitelist sets the push whitelist of a ruleset from user and team names, it returns false if one of them
+// does not exist
+func setRulesetWhitelist(ctx *context.APIContext, ruleset *git_model.OrgRuleset, usernames, teams []string) bool {
+	userIDs, err := user_model.GetUserIDsByNames(ctx, usernames, false)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "User does not exist", err)
+			return false
+		}
+		ctx.Error(http.StatusInternalServerError, "GetUserIDsByNames", err)
+		return false
+	}
+	teamIDs, err := organization.GetTeamIDsByNames(ctx, ruleset.OrgID, teams, false)
+	if err != nil {
+		if organization.IsErrTeamNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "Team does not exist", err)
+			return false
+		}
+		ctx.Error(http.StatusInternalServerError, "GetTeamIDsByNames", err)
+		return false
+	}
+	ruleset.WhitelistUserIDs = userIDs
+	ruleset.WhitelistTeamIDs = teamIDs
+	return true
+}
@@ -10,6 +10,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	activities_model "code.gitea.io/gitea/models/activities"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/organization"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -20,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/user"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	org_service "code.gitea.io/gitea/services/org"
@@ -247,6 +249,8 @@ func CreateTeam(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamCreate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "team", team.ID, team.Name),
+		nil, audit_service.TeamState(team))
 
 	apiTeam, err := convert.ToTeam(ctx, team, true)
 	if err != nil {
@@ -287,6 +291,7 @@ func EditTeam(ctx *context.APIContext) {
 		ctx.InternalServerError(err)
 		return
 	}
+	before := audit_service.TeamState(team)
 
 	if form.CanCreateOrgRepo != nil {
 		team.CanCreateOrgRepo = team.IsOwnerTeam() || *form.CanCreateOrgRepo
@@ -334,6 +339,8 @@ func EditTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "EditTeam", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamUpdate, teamTarget(ctx, "team", team.ID, team.Name),
+		before, audit_service.TeamState(team))
 
 	apiTeam, err := convert.ToTeam(ctx, team)
 	if err != nil {
@@ -365,9 +372,15 @@ func DeleteTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteTeam", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamDelete, teamTarget(ctx, "team", ctx.Org.Team.ID, ctx.Org.Team.Name), nil, nil)
 	ctx.Status(http.StatusNoContent)
 }
 
+// teamTarget returns the target of an action in the organization of the team of the request
+func teamTarget(ctx *context.APIContext, typ string, id int64, name string) audit_service.Target {
+	return audit_service.Target{OwnerID: ctx.Org.Team.OrgID, Type: typ, ID: id, Name: name}
+}
+
 // GetTeamMembers api for get a team's members
 func GetTeamMembers(ctx *context.APIContext) {
 	// swagger:operation GET /teams/{id}/members organization orgListTeamMembers
@@ -497,6 +510,8 @@ func AddTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddMember", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamMemberAdd, teamTarget(ctx, "user", u.ID, u.Name),
+		nil, audit_service.State{"team": ctx.Org.Team.Name})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -534,6 +549,8 @@ func RemoveTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveTeamMember", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamMemberRemove, teamTarget(ctx, "user", u.ID, u.Name),
+		nil, audit_service.State{"team": ctx.Org.Team.Name})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -697,6 +714,8 @@ func AddTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "TeamAddRepository", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorAdd, audit_service.RepoTarget(repo, "team", ctx.Org.Team.ID, ctx.Org.Team.Name),
+		nil, audit_service.State{"access_mode": ctx.Org.Team.AccessMode.String()})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -749,6 +768,7 @@ func RemoveTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveRepository", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorRemove, audit_service.RepoTarget(repo, "team", ctx.Org.Team.ID, ctx.Org.Team.Name), nil, nil)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	actions_model "code.gitea.io/gitea/models/actions"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	secret_model "code.gitea.io/gitea/models/secret"
 	api "code.gitea.io/gitea/modules/structs"
@@ -16,6 +17,7 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/shared"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	actions_service "code.gitea.io/gitea/services/actions"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	secret_service "code.gitea.io/gitea/services/secrets"
@@ -122,7 +124,8 @@ func (Action) CreateOrUpdateSecret(ctx *context.APIContext) {
 
 	opt := web.GetForm(ctx).(*api.CreateOrUpdateSecretOption)
 
-	_, created, err := secret_service.CreateOrUpdateSecret(ctx, owner.ID, repo.ID, ctx.Params("secretname"), opt.Data)
+	name := ctx.Params("secretname")
+	_, created, err := secret_service.CreateOrUpdateSecret(ctx, owner.ID, repo.ID, name, opt.Data)
 	if err != nil {
 		if errors.Is(err, util.ErrInvalidArgument) {
 			ctx.Error(http.StatusBadRequest, "CreateOrUpdateSecret", err)
@@ -133,6 +136,8 @@ func (Action) CreateOrUpdateSecret(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionSecretUpdate, audit_service.SecretTarget(ctx, owner.ID, repo.ID, name),
+		audit_service.SecretState(name, !created), audit_service.SecretState(name, true))
 
 	if created {
 		ctx.Status(http.StatusCreated)
@@ -177,7 +182,8 @@ func (Action) DeleteSecret(ctx *context.APIContext) {
 	owner := ctx.Repo.Owner
 	repo := ctx.Repo.Repository
 
-	err := secret_service.DeleteSecretByName(ctx, owner.ID, repo.ID, ctx.Params("secretname"))
+	name := ctx.Params("secretname")
+	err := secret_service.DeleteSecretByName(ctx, owner.ID, repo.ID, name)
 	if err != nil {
 		if errors.Is(err, util.ErrInvalidArgument) {
 			ctx.Error(http.StatusBadRequest, "DeleteSecret", err)
@@ -188,6 +194,8 @@ func (Action) DeleteSecret(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionSecretDelete, audit_service.SecretTarget(ctx, owner.ID, repo.ID, name),
+		audit_service.SecretState(name, true), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
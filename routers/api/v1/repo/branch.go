@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	git_model "code.gitea.io/gitea/models/git"
 	"code.gitea.io/gitea/models/organization"
@@ -21,6 +22,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	pull_service "code.gitea.io/gitea/services/pull"
@@ -622,6 +624,15 @@ func CreateBranchProtection(ctx *context.APIContext) {
 		UnprotectedFilePatterns:       form.UnprotectedFilePatterns,
 		BlockOnOutdatedBranch:         form.BlockOnOutdatedBranch,
 		ApplyToAdmins:                 form.ApplyToAdmins,
+		EnableMergeQueue:              form.EnableMergeQueue,
+		RequireCodeOwnerApproval:      form.RequireCodeOwnerApproval,
+		RequireLinearHistory:          form.RequireLinearHistory,
+		RequireSignoff:                form.RequireSignoff,
+		CommitMessagePattern:          form.CommitMessagePattern,
+	}
+	if _, err := protectBranch.GetCommitMessageRegexp(); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetCommitMessageRegexp", err)
+		return
 	}
 
 	err = git_model.UpdateProtectBranch(ctx, ctx.Repo.Repository, protectBranch, git_model.WhitelistOptions{
@@ -682,7 +693,9 @@ func CreateBranchProtection(ctx *context.APIContext) {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, convert.ToBranchProtection(ctx, bp, repo))
+	apiBranchProtection := convert.ToBranchProtection(ctx, bp, repo)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionBranchProtectionUpdate, audit_service.RepoTarget(repo, "branch_protection", bp.ID, bp.RuleName), nil, apiBranchProtection)
+	ctx.JSON(http.StatusCreated, apiBranchProtection)
 }
 
 // EditBranchProtection edits a branch protection for a repo
@@ -735,6 +748,7 @@ func EditBranchProtection(ctx *context.APIContext) {
 		ctx.NotFound()
 		return
 	}
+	before := convert.ToBranchProtection(ctx, protectBranch, repo)
 
 	if form.EnablePush != nil {
 		if !*form.EnablePush {
@@ -813,6 +827,30 @@ func EditBranchProtection(ctx *context.APIContext) {
 		protectBranch.ApplyToAdmins = *form.ApplyToAdmins
 	}
 
+	if form.EnableMergeQueue != nil {
+		protectBranch.EnableMergeQueue = *form.EnableMergeQueue
+	}
+
+	if form.RequireCodeOwnerApproval != nil {
+		protectBranch.RequireCodeOwnerApproval = *form.RequireCodeOwnerApproval
+	}
+
+	if form.RequireLinearHistory != nil {
+		protectBranch.RequireLinearHistory = *form.RequireLinearHistory
+	}
+
+	if form.RequireSignoff != nil {
+		protectBranch.RequireSignoff = *form.RequireSignoff
+	}
+
+	if form.CommitMessagePattern != nil {
+		protectBranch.CommitMessagePattern = *form.CommitMessagePattern
+		if _, err := protectBranch.GetCommitMessageRegexp(); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "GetCommitMessageRegexp", err)
+			return
+		}
+	}
+
 	var whitelistUsers []int64
 	if form.PushWhitelistUsernames != nil {
 		whitelistUsers, err = user_model.GetUserIDsByNames(ctx, form.PushWhitelistUsernames, false)
@@ -964,7 +1002,9 @@ func EditBranchProtection(ctx *context.APIContext) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, convert.ToBranchProtection(ctx, bp, repo))
+	apiBranchProtection := convert.ToBranchProtection(ctx, bp, repo)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionBranchProtectionUpdate, audit_service.RepoTarget(repo, "branch_protection", bp.ID, bp.RuleName), before, apiBranchProtection)
+	ctx.JSON(http.StatusOK, apiBranchProtection)
 }
 
 // DeleteBranchProtection deletes a branch protection for a repo
@@ -1012,6 +1052,8 @@ func DeleteBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionBranchProtectionDelete, audit_service.RepoTarget(repo, "branch_protection", bp.ID, bp.RuleName),
+		convert.ToBranchProtection(ctx, bp, repo), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
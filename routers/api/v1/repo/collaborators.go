@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net/http"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -17,6 +18,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	repo_service "code.gitea.io/gitea/services/repository"
@@ -183,6 +185,12 @@ func AddCollaborator(ctx *context.APIContext) {
 		return
 	}
 
+	before, err := repo_model.GetCollaboration(ctx, ctx.Repo.Repository.ID, collaborator.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCollaboration", err)
+		return
+	}
+
 	if err := repo_module.AddCollaborator(ctx, ctx.Repo.Repository, collaborator); err != nil {
 		if errors.Is(err, user_model.ErrBlockedByUser) {
 			ctx.Error(http.StatusForbidden, "AddCollaborator", err)
@@ -199,6 +207,19 @@ func AddCollaborator(ctx *context.APIContext) {
 		}
 	}
 
+	after, err := repo_model.GetCollaboration(ctx, ctx.Repo.Repository.ID, collaborator.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetCollaboration", err)
+		return
+	}
+	target := audit_service.RepoTarget(ctx.Repo.Repository, "user", collaborator.ID, collaborator.Name)
+	if before == nil {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorAdd, target, nil, audit_service.State{"access_mode": after.Mode.String()})
+	} else if before.Mode != after.Mode {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorUpdate, target,
+			audit_service.State{"access_mode": before.Mode.String()}, audit_service.State{"access_mode": after.Mode.String()})
+	}
+
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -247,6 +268,7 @@ func DeleteCollaborator(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteCollaboration", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorRemove, audit_service.RepoTarget(ctx.Repo.Repository, "user", collaborator.ID, collaborator.Name), nil, nil)
 	ctx.Status(http.StatusNoContent)
 }
 
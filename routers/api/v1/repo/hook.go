@@ -7,6 +7,7 @@ package repo
 import (
 	"net/http"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -17,6 +18,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	webhook_service "code.gitea.io/gitea/services/webhook"
@@ -296,13 +298,19 @@ func DeleteHook(ctx *context.APIContext) {
 	//     "$ref": "#/responses/empty"
 	//   "404":
 	//     "$ref": "#/responses/notFound"
-	if err := webhook.DeleteWebhookByRepoID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id")); err != nil {
+	w, err := webhook.GetWebhookByRepoID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
 		if webhook.IsErrWebhookNotExist(err) {
 			ctx.NotFound()
 		} else {
-			ctx.Error(http.StatusInternalServerError, "DeleteWebhookByRepoID", err)
+			ctx.Error(http.StatusInternalServerError, "GetWebhookByRepoID", err)
 		}
 		return
 	}
+	if err := webhook.DeleteWebhookByRepoID(ctx, ctx.Repo.Repository.ID, w.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteWebhookByRepoID", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookDelete, audit_service.WebhookTarget(ctx, w), audit_service.WebhookState(w), nil)
 	ctx.Status(http.StatusNoContent)
 }
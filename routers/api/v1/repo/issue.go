@@ -24,6 +24,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	"code.gitea.io/gitea/services/context"
@@ -352,6 +353,13 @@ func ListIssues(ctx *context.APIContext) {
 	//   in: query
 	//   description: comma separated list of milestone names or ids. It uses names and fall back to ids. Fetch only issues that have any of this milestones. Non existent milestones are discarded
 	//   type: string
+	// - name: field
+	//   in: query
+	//   description: 'values of custom fields formatted as "<field id or name>:<value>", fetch only issues that have all of these values. Users are given by their names'
+	//   type: array
+	//   items:
+	//     type: string
+	//   collectionFormat: multi
 	// - name: since
 	//   in: query
 	//   description: Only show items updated after the given time. This is a timestamp in RFC 3339 format
@@ -449,6 +457,16 @@ func ListIssues(ctx *context.APIContext) {
 		}
 	}
 
+	fieldValues, err := issue_service.ParseFieldFilters(ctx, ctx.Repo.Repository, ctx.FormStrings("field"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) || errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "ParseFieldFilters", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ParseFieldFilters", err)
+		}
+		return
+	}
+
 	listOptions := utils.GetListOptions(ctx)
 
 	isPull := optional.None[bool]()
@@ -522,6 +540,7 @@ func ListIssues(ctx *context.APIContext) {
 	} else {
 		searchOpt.MilestoneIDs = mileIDs
 	}
+	searchOpt.FieldValues = fieldValues
 
 	if createdByID > 0 {
 		searchOpt.PosterID = optional.Some(createdByID)
@@ -701,9 +720,19 @@ func CreateIssue(ctx *context.APIContext) {
 				return
 			}
 		}
+
+		if err := issue_service.ValidateFieldValuesByName(ctx, ctx.Repo.Repository, form.Fields); err != nil {
+			if issues_model.IsErrIssueFieldNotExist(err) || issues_model.IsErrIssueFieldInvalidValue(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "ValidateFieldValuesByName", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "ValidateFieldValuesByName", err)
+			}
+			return
+		}
 	} else {
-		// setting labels is not allowed if user is not a writer
+		// setting labels and custom fields is not allowed if user is not a writer
 		form.Labels = make([]int64, 0)
+		form.Fields = nil
 	}
 
 	if err := issue_service.NewIssue(ctx, ctx.Repo.Repository, issue, form.Labels, nil, assigneeIDs); err != nil {
@@ -718,6 +747,11 @@ func CreateIssue(ctx *context.APIContext) {
 		return
 	}
 
+	if err := issue_service.SetFieldValuesByName(ctx, issue, ctx.Doer, form.Fields); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetFieldValuesByName", err)
+		return
+	}
+
 	if form.Closed {
 		if err := issue_service.ChangeStatus(ctx, issue, ctx.Doer, "", true); err != nil {
 			if issues_model.IsErrDependenciesLeft(err) {
@@ -882,6 +916,12 @@ func EditIssue(ctx *context.APIContext) {
 			return
 		}
 	}
+	if canWrite && form.CloseWithSubIssues != nil && !issue.IsPull {
+		if err := issues_model.SetCloseWithSubIssues(ctx, issue, *form.CloseWithSubIssues); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SetCloseWithSubIssues", err)
+			return
+		}
+	}
 	if form.State != nil {
 		if issue.IsPull {
 			if err := issue.LoadPullRequest(ctx); err != nil {
@@ -113,12 +113,11 @@ func GetIssueDependencies(ctx *context.APIContext) {
 		if ok {
 			perm = existPerm
 		} else {
-			var err error
-			perm, err = access_model.GetUserRepoPermission(ctx, &blocker.Repository, ctx.Doer)
-			if err != nil {
-				ctx.ServerError("GetUserRepoPermission", err)
+			p := getPermissionForRepo(ctx, &blocker.Repository)
+			if ctx.Written() {
 				return
 			}
+			perm = *p
 			repoPerms[blocker.RepoID] = perm
 		}
 
@@ -364,12 +363,11 @@ func GetIssueBlocks(ctx *context.APIContext) {
 		if ok {
 			perm = existPerm
 		} else {
-			var err error
-			perm, err = access_model.GetUserRepoPermission(ctx, &depMeta.Repository, ctx.Doer)
-			if err != nil {
-				ctx.ServerError("GetUserRepoPermission", err)
+			p := getPermissionForRepo(ctx, &depMeta.Repository)
+			if ctx.Written() {
 				return
 			}
+			perm = *p
 			repoPerms[depMeta.RepoID] = perm
 		}
 
@@ -550,6 +548,10 @@ func getPermissionForRepo(ctx *context.APIContext, repo *repo_model.Repository)
 	if repo.ID == ctx.Repo.Repository.ID {
 		return &ctx.Repo.Permission
 	}
+	// a personal access token restricted to other repositories has no access to the repository
+	if !ctx.AccessTokenAllowsRepo(repo) {
+		return &access_model.Permission{}
+	}
 
 	perm, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 	if err != nil {
@@ -0,0 +1,479 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// ListIssueFields list the custom issue fields of a repository
+func ListIssueFields(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issue_fields issue issueListIssueFields
+	// ---
+	// summary: Get the custom issue fields of a repository, including the fields of its organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueFieldList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	fields, err := issues_model.GetIssueFieldsForRepo(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldsForRepo", err)
+		return
+	}
+
+	ctx.SetTotalCountHeader(int64(len(fields)))
+	ctx.JSON(http.StatusOK, convert.ToIssueFieldList(fields))
+}
+
+// GetIssueField get a custom issue field of a repository
+func GetIssueField(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issue_fields/{id} issue issueGetIssueField
+	// ---
+	// summary: Get a custom issue field of a repository or of its organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueField"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	f, err := issues_model.GetIssueFieldForRepoByID(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueFieldForRepoByID", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueField(f))
+}
+
+// CreateIssueField create a custom issue field for a repository
+func CreateIssueField(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/issue_fields issue issueCreateIssueField
+	// ---
+	// summary: Create a custom issue field
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateIssueFieldOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/IssueField"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateIssueFieldOption)
+	f := &issues_model.IssueField{
+		RepoID:      ctx.Repo.Repository.ID,
+		Name:        form.Name,
+		Description: form.Description,
+		Type:        issues_model.IssueFieldType(form.Type),
+		Options:     form.Options,
+		SortOrder:   form.SortOrder,
+	}
+	if err := issues_model.NewIssueField(ctx, f); err != nil {
+		handleIssueFieldError(ctx, "NewIssueField", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToIssueField(f))
+}
+
+// EditIssueField modify a custom issue field of a repository
+func EditIssueField(ctx *context.APIContext) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/issue_fields/{id} issue issueEditIssueField
+	// ---
+	// summary: Update a custom issue field
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to edit
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditIssueFieldOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueField"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditIssueFieldOption)
+	f, err := issues_model.GetIssueFieldInRepoByID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueFieldInRepoByID", err)
+		}
+		return
+	}
+
+	applyEditIssueFieldOption(f, form)
+	if err := issue_service.UpdateField(ctx, f); err != nil {
+		handleIssueFieldError(ctx, "UpdateField", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueField(f))
+}
+
+// DeleteIssueField delete a custom issue field of a repository
+func DeleteIssueField(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/issue_fields/{id} issue issueDeleteIssueField
+	// ---
+	// summary: Delete a custom issue field and its values
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	f, err := issues_model.GetIssueFieldInRepoByID(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueFieldInRepoByID", err)
+		}
+		return
+	}
+
+	if err := issue_service.DeleteField(ctx, f); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteField", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListIssueFieldValues list the values of the custom fields of an issue
+func ListIssueFieldValues(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/{index}/fields issue issueListFieldValues
+	// ---
+	// summary: Get the values of the custom fields of an issue
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueFieldValueList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	issue, err := issues_model.GetIssueByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return
+	}
+
+	fields, err := issues_model.GetIssueFieldsForRepo(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldsForRepo", err)
+		return
+	}
+	values, err := issues_model.GetIssueFieldDisplayValues(ctx, fields, issue.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldDisplayValues", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueFieldValues(fields, values[issue.ID]))
+}
+
+// SetIssueFieldValue set the values of a custom field of an issue
+func SetIssueFieldValue(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/issues/{index}/fields/{id} issue issueSetFieldValue
+	// ---
+	// summary: Set the values of a custom field of an issue
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetIssueFieldValueOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueFieldValueList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.SetIssueFieldValueOption)
+	setIssueFieldValue(ctx, form.Values)
+}
+
+// DeleteIssueFieldValue remove the values of a custom field of an issue
+func DeleteIssueFieldValue(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/issues/{index}/fields/{id} issue issueDeleteFieldValue
+	// ---
+	// summary: Remove the values of a custom field of an issue
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the field
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if setIssueFieldValue(ctx, nil) {
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// setIssueFieldValue sets the values of the field of the issue from the request, the values of the fields of the issue
+// are written as response if values is not empty. It returns false if an error has been written.
+func setIssueFieldValue(ctx *context.APIContext, values []string) bool {
+	issue, err := issues_model.GetIssueByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return false
+	}
+
+	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Status(http.StatusForbidden)
+		return false
+	}
+
+	field, err := issues_model.GetIssueFieldForRepoByID(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueFieldForRepoByID", err)
+		}
+		return false
+	}
+
+	if err := issue_service.SetFieldValues(ctx, issue, ctx.Doer, field, values); err != nil {
+		handleIssueFieldError(ctx, "SetFieldValues", err)
+		return false
+	}
+	if len(values) == 0 {
+		return true
+	}
+
+	fields, err := issues_model.GetIssueFieldsForRepo(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldsForRepo", err)
+		return false
+	}
+	display, err := issues_model.GetIssueFieldDisplayValues(ctx, fields, issue.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueFieldDisplayValues", err)
+		return false
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToIssueFieldValues(fields, display[issue.ID]))
+	return true
+}
+
+// applyEditIssueFieldOption applies the changes of an EditIssueFieldOption to a field
+func applyEditIssueFieldOption(f *issues_model.IssueField, form *api.EditIssueFieldOption) {
+	if form.Name != nil {
+		f.Name = *form.Name
+	}
+	if form.Description != nil {
+		f.Description = *form.Description
+	}
+	if form.Options != nil {
+		f.Options = form.Options
+	}
+	if form.SortOrder != nil {
+		f.SortOrder = *form.SortOrder
+	}
+}
+
+// handleIssueFieldError writes the response for an error of creating or changing a field or the values of a field
+func handleIssueFieldError(ctx *context.APIContext, name string, err error) {
+	switch {
+	case issues_model.IsErrIssueFieldAlreadyExist(err):
+		ctx.Error(http.StatusConflict, name, err)
+	case issues_model.IsErrIssueFieldInvalid(err), issues_model.IsErrIssueFieldInvalidValue(err), issues_model.IsErrIssueFieldNotExist(err):
+		ctx.Error(http.StatusUnprocessableEntity, name, err)
+	default:
+		ctx.Error(http.StatusInternalServerError, name, err)
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListSubIssues list the sub-issues of an issue
+func ListSubIssues(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/{index}/sub_issues issue issueListSubIssues
+	// ---
+	// summary: List the sub-issues of an issue in their order
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	parent := getParamsIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	subIssues := listReadableSubIssues(ctx, parent)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToAPIIssueList(ctx, ctx.Doer, subIssues))
+}
+
+// GetParentIssue get the issue an issue is a sub-issue of
+func GetParentIssue(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/{index}/parent issue issueGetParentIssue
+	// ---
+	// summary: Get the issue an issue is a sub-issue of
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Issue"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	issue := getParamsIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	parent, err := issues_model.GetParentIssue(ctx, issue.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetParentIssue", err)
+		return
+	}
+	if parent == nil {
+		ctx.NotFound()
+		return
+	}
+	if err := parent.LoadRepo(ctx); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadRepo", err)
+		return
+	}
+	perm := getPermissionForRepo(ctx, parent.Repo)
+	if ctx.Written() {
+		return
+	}
+	if !perm.CanReadIssuesOrPulls(parent.IsPull) {
+		ctx.NotFound()
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToAPIIssue(ctx, ctx.Doer, parent))
+}
+
+// AddSubIssue make an issue a sub-issue of another issue
+func AddSubIssue(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/{index}/sub_issues issue issueAddSubIssue
+	// ---
+	// summary: Make the issue in the form a sub-issue of the issue in the url
+	// description: The sub-issue must be an issue of a repository of the same owner, it is added after the other sub-issues.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/IssueMeta"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Issue"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	//   "423":
+	//     "$ref": "#/responses/repoArchivedError"
+
+	parent, subIssue := getSubIssueParams(ctx, web.GetForm(ctx).(*api.IssueMeta))
+	if ctx.Written() {
+		return
+	}
+
+	if err := issues_model.AddSubIssue(ctx, ctx.Doer, parent, subIssue); err != nil {
+		handleSubIssueError(ctx, "AddSubIssue", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToAPIIssue(ctx, ctx.Doer, subIssue))
+}
+
+// RemoveSubIssue remove a sub-issue of an issue
+func RemoveSubIssue(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/issues/{index}/sub_issues issue issueRemoveSubIssue
+	// ---
+	// summary: Remove the issue in the form from the sub-issues of the issue in the url
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/IssueMeta"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "423":
+	//     "$ref": "#/responses/repoArchivedError"
+
+	parent, subIssue := getSubIssueParams(ctx, web.GetForm(ctx).(*api.IssueMeta))
+	if ctx.Written() {
+		return
+	}
+
+	if err := issues_model.RemoveSubIssue(ctx, ctx.Doer, parent, subIssue); err != nil {
+		handleSubIssueError(ctx, "RemoveSubIssue", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// MoveSubIssue move a sub-issue of an issue to another position
+func MoveSubIssue(ctx *context.APIContext) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/issues/{index}/sub_issues issue issueMoveSubIssue
+	// ---
+	// summary: Move a sub-issue of the issue in the url to another position
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveSubIssueOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "423":
+	//     "$ref": "#/responses/repoArchivedError"
+
+	form := web.GetForm(ctx).(*api.MoveSubIssueOption)
+	parent, subIssue := getSubIssueParams(ctx, &form.SubIssue)
+	if ctx.Written() {
+		return
+	}
+
+	if err := issues_model.MoveSubIssue(ctx, parent, subIssue, form.Position); err != nil {
+		handleSubIssueError(ctx, "MoveSubIssue", err)
+		return
+	}
+
+	subIssues := listReadableSubIssues(ctx, parent)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToAPIIssueList(ctx, ctx.Doer, subIssues))
+}
+
+// listReadableSubIssues returns the sub-issues of the issue which the doer can read
+func listReadableSubIssues(ctx *context.APIContext, parent *issues_model.Issue) issues_model.IssueList {
+	subIssues, err := issues_model.GetSubIssues(ctx, parent.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetSubIssues", err)
+		return nil
+	}
+	if _, err := subIssues.LoadRepositories(ctx); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadRepositories", err)
+		return nil
+	}
+
+	readable := make(issues_model.IssueList, 0, len(subIssues))
+	repoPerms := make(map[int64]*access_model.Permission)
+	for _, subIssue := range subIssues {
+		perm, ok := repoPerms[subIssue.RepoID]
+		if !ok {
+			perm = getPermissionForRepo(ctx, subIssue.Repo)
+			if ctx.Written() {
+				return nil
+			}
+			repoPerms[subIssue.RepoID] = perm
+		}
+		if perm.CanReadIssuesOrPulls(subIssue.IsPull) {
+			readable = append(readable, subIssue)
+		}
+	}
+	return readable
+}
+
+// getSubIssueParams returns the issue in the url and the issue in the form, if the doer can modify the sub-issues of
+// the first and the parent of the second
+func getSubIssueParams(ctx *context.APIContext, form *api.IssueMeta) (*issues_model.Issue, *issues_model.Issue) {
+	parent := getParamsIssue(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+	if !ctx.Repo.CanWriteIssuesOrPulls(parent.IsPull) {
+		ctx.Error(http.StatusForbidden, "CanWriteIssuesOrPulls", "user should have permission to write issues")
+		return nil, nil
+	}
+
+	repo := ctx.Repo.Repository
+	if form.Owner != ctx.Repo.Repository.OwnerName || form.Name != ctx.Repo.Repository.Name {
+		var err error
+		repo, err = repo_model.GetRepositoryByOwnerAndName(ctx, form.Owner, form.Name)
+		if err != nil {
+			if repo_model.IsErrRepoNotExist(err) {
+				ctx.NotFound("IsErrRepoNotExist", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetRepositoryByOwnerAndName", err)
+			}
+			return nil, nil
+		}
+	}
+
+	subIssue, err := issues_model.GetIssueByIndex(ctx, repo.ID, form.Index)
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.NotFound("IsErrIssueNotExist", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return nil, nil
+	}
+	subIssue.Repo = repo
+
+	perm := getPermissionForRepo(ctx, repo)
+	if ctx.Written() {
+		return nil, nil
+	}
+	if !perm.CanReadIssuesOrPulls(subIssue.IsPull) {
+		ctx.NotFound()
+		return nil, nil
+	}
+	if !perm.CanWriteIssuesOrPulls(subIssue.IsPull) || repo.IsArchived {
+		ctx.Error(http.StatusForbidden, "CanWriteIssuesOrPulls", "user should have permission to write the issues of the sub-issue")
+		return nil, nil
+	}
+
+	return parent, subIssue
+}
+
+func handleSubIssueError(ctx *context.APIContext, name string, err error) {
+	switch {
+	case issues_model.IsErrSubIssueNotExist(err):
+		ctx.NotFound(name, err)
+	case issues_model.IsErrSubIssueExists(err), issues_model.IsErrSubIssueHasParent(err):
+		ctx.Error(http.StatusConflict, name, err)
+	case issues_model.IsErrCircularSubIssue(err), issues_model.IsErrSubIssueTooDeep(err), issues_model.IsErrSubIssueNotAllowed(err):
+		ctx.Error(http.StatusUnprocessableEntity, name, err)
+	default:
+		ctx.Error(http.StatusInternalServerError, name, err)
+	}
+}
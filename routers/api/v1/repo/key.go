@@ -11,6 +11,7 @@ import (
 	"net/url"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -20,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 )
@@ -244,6 +246,9 @@ func CreateDeployKey(ctx *context.APIContext) {
 		return
 	}
 
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionDeployKeyAdd, audit_service.RepoTarget(ctx.Repo.Repository, "deploy_key", key.ID, key.Name),
+		nil, audit_service.DeployKeyState(key))
+
 	key.Content = content
 	apiLink := composeDeployKeysAPILink(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
 	ctx.JSON(http.StatusCreated, convert.ToDeployKey(apiLink, key))
@@ -279,7 +284,14 @@ func DeleteDeploykey(ctx *context.APIContext) {
 	//   "404":
 	//     "$ref": "#/responses/notFound"
 
-	if err := asymkey_service.DeleteDeployKey(ctx, ctx.Doer, ctx.ParamsInt64(":id")); err != nil {
+	id := ctx.ParamsInt64(":id")
+	key, err := asymkey_model.GetDeployKeyByID(ctx, id)
+	if err != nil && !asymkey_model.IsErrDeployKeyNotExist(err) {
+		ctx.Error(http.StatusInternalServerError, "GetDeployKeyByID", err)
+		return
+	}
+
+	if err := asymkey_service.DeleteDeployKey(ctx, ctx.Doer, id); err != nil {
 		if asymkey_model.IsErrKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
 		} else {
@@ -287,6 +299,10 @@ func DeleteDeploykey(ctx *context.APIContext) {
 		}
 		return
 	}
+	if key != nil {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionDeployKeyRemove, audit_service.RepoTarget(ctx.Repo.Repository, "deploy_key", key.ID, key.Name),
+			audit_service.DeployKeyState(key), nil)
+	}
 
 	ctx.Status(http.StatusNoContent)
 }
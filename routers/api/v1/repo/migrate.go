@@ -85,6 +85,11 @@ func Migrate(ctx *context.APIContext) {
 		return
 	}
 
+	if !ctx.AccessTokenAllowsOwner(repoOwner) {
+		ctx.Error(http.StatusForbidden, "", "the access token is restricted to other organizations or repositories")
+		return
+	}
+
 	if !ctx.Doer.IsAdmin {
 		if !repoOwner.IsOrganization() && ctx.Doer.ID != repoOwner.ID {
 			ctx.Error(http.StatusForbidden, "", "Given user is not an organization.")
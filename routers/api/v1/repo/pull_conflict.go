@@ -0,0 +1,205 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// getOpenPullRequestByParams gets the open pull request of the index in the URL and writes an error response otherwise
+func getOpenPullRequestByParams(ctx *context.APIContext) *issues_model.PullRequest {
+	pr, err := issues_model.GetPullRequestByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if issues_model.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return nil
+	}
+	if err := pr.LoadIssue(ctx); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadIssue", err)
+		return nil
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		ctx.Error(http.StatusUnprocessableEntity, "", "pull request is closed")
+		return nil
+	}
+	return pr
+}
+
+// GetPullRequestConflicts gets the files of a pull request which conflict with its base branch
+func GetPullRequestConflicts(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/conflicts repository repoGetPullRequestConflicts
+	// ---
+	// summary: Get the files of a pull request which conflict with its base branch
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullRequestConflicts"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	pr := getOpenPullRequestByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	// like in the web editor, only the users who can push a resolution can see the conflicts
+	allowed, err := pull_service.IsUserAllowedToResolveConflicts(ctx, pr, ctx.Doer, false)
+	if err == nil && !allowed {
+		allowed, err = pull_service.IsUserAllowedToResolveConflicts(ctx, pr, ctx.Doer, true)
+	}
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "IsUserAllowedToResolveConflicts", err)
+		return
+	}
+	if !allowed {
+		ctx.NotFound()
+		return
+	}
+
+	headCommitID, files, err := pull_service.GetConflictFiles(ctx, pr, ctx.Doer)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "GetConflictFiles", err)
+		} else if repo_model.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else if models.IsErrMergeUnrelatedHistories(err) {
+			ctx.Error(http.StatusConflict, "GetConflictFiles", "the branches have no common history")
+		} else if git_model.IsErrBranchNotExist(err) {
+			ctx.Error(http.StatusConflict, "GetConflictFiles", "the head branch does not exist")
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetConflictFiles", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convert.ToPullRequestConflicts(headCommitID, files))
+}
+
+// ResolvePullRequestConflicts merges the base branch into the head branch of a pull request resolving the conflicts
+func ResolvePullRequestConflicts(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/conflicts repository repoResolvePullRequestConflicts
+	// ---
+	// summary: Merge the base branch of a pull request into its head branch or a new branch resolving the conflicts
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/ResolvePullRequestConflictsOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullRequestConflictsResolved"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.ResolvePullRequestConflictsOption)
+	pr := getOpenPullRequestByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	allowed, err := pull_service.IsUserAllowedToResolveConflicts(ctx, pr, ctx.Doer, form.NewBranch != "")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "IsUserAllowedToResolveConflicts", err)
+		return
+	}
+	if !allowed {
+		ctx.Error(http.StatusForbidden, "", "not allowed to push the resolution")
+		return
+	}
+
+	commitID, err := pull_service.ResolveConflicts(ctx, pr, ctx.Doer, &pull_service.ResolveConflictsOptions{
+		Resolutions:          convert.ToConflictResolutions(form.Files),
+		Message:              form.Message,
+		ExpectedHeadCommitID: form.HeadSHA,
+		NewBranch:            form.NewBranch,
+	})
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "ResolveConflicts", err)
+		} else if git_model.IsErrBranchAlreadyExists(err) {
+			ctx.Error(http.StatusConflict, "ResolveConflicts", "the new branch already exists")
+		} else if models.IsErrSHADoesNotMatch(err) {
+			ctx.Error(http.StatusConflict, "ResolveConflicts", "head out of date")
+		} else if models.IsErrMergeUnrelatedHistories(err) {
+			ctx.Error(http.StatusConflict, "ResolveConflicts", "the branches have no common history")
+		} else if git.IsErrPushOutOfDate(err) {
+			ctx.Error(http.StatusConflict, "ResolveConflicts", "push out of date")
+		} else if git.IsErrPushRejected(err) {
+			ctx.Error(http.StatusConflict, "ResolveConflicts", "PushRejected with remote message: "+err.(*git.ErrPushRejected).Message)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ResolveConflicts", err)
+		}
+		return
+	}
+
+	branch := pr.HeadBranch
+	if form.NewBranch != "" {
+		branch = form.NewBranch
+	}
+	ctx.JSON(http.StatusCreated, &api.PullRequestConflictsResolved{Branch: branch, SHA: commitID})
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	"code.gitea.io/gitea/services/mergequeue"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// ListMergeQueue lists the merge queues of a repository
+func ListMergeQueue(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/merge_queue repository repoListMergeQueue
+	// ---
+	// summary: List the pull requests in the merge queues of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: branch
+	//   in: query
+	//   description: only list the merge queue of this base branch
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/MergeQueueEntryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	var (
+		entries []*pull_model.MergeQueueEntry
+		err     error
+	)
+	if branch := ctx.FormString("branch"); branch != "" {
+		entries, err = pull_model.GetMergeQueue(ctx, ctx.Repo.Repository.ID, branch)
+	} else {
+		entries, err = pull_model.FindMergeQueueEntries(ctx, ctx.Repo.Repository.ID)
+	}
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindMergeQueueEntries", err)
+		return
+	}
+
+	apiEntries, err := convert.ToMergeQueueEntries(ctx, entries, ctx.Doer)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToMergeQueueEntries", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiEntries)
+}
+
+// GetPullRequestMergeQueueEntry gets the merge queue entry of a pull request
+func GetPullRequestMergeQueueEntry(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/merge_queue repository repoGetPullRequestMergeQueueEntry
+	// ---
+	// summary: Get the merge queue entry of a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/MergeQueueEntry"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, entry := getPullRequestMergeQueueEntry(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	position, err := pull_model.GetMergeQueuePosition(ctx, entry)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetMergeQueuePosition", err)
+		return
+	}
+
+	apiEntry, err := convert.ToMergeQueueEntry(ctx, entry, position, ctx.Doer)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToMergeQueueEntry", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiEntry)
+}
+
+// RemovePullRequestFromMergeQueue removes a pull request from the merge queue of its base branch
+func RemovePullRequestFromMergeQueue(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/merge_queue repository repoRemovePullRequestFromMergeQueue
+	// ---
+	// summary: Remove a pull request from the merge queue of its base branch
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "423":
+	//     "$ref": "#/responses/repoArchivedError"
+
+	pr, _ := getPullRequestMergeQueueEntry(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := pr.LoadIssue(ctx); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	if ctx.Doer.ID != pr.Issue.PosterID {
+		allowed, err := pull_service.IsUserAllowedToMerge(ctx, pr, ctx.Repo.Permission, ctx.Doer)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		if !allowed {
+			ctx.Error(http.StatusForbidden, "No permission to remove", "user has no permission to remove the pull request from the merge queue")
+			return
+		}
+	}
+
+	if err := mergequeue.RemoveFromMergeQueue(ctx, ctx.Doer, pr, ""); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func getPullRequestMergeQueueEntry(ctx *context.APIContext) (*issues_model.PullRequest, *pull_model.MergeQueueEntry) {
+	pr, err := issues_model.GetPullRequestByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if issues_model.IsErrPullRequestNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil, nil
+	}
+
+	exists, entry, err := pull_model.GetMergeQueueEntryByPullID(ctx, pr.ID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return nil, nil
+	}
+	if !exists {
+		ctx.NotFound()
+		return nil, nil
+	}
+	return pr, entry
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+	"strings"
+
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// GetRefProtection get the protection rules applying to a branch or tag
+func GetRefProtection(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/ref_protection repository repoGetRefProtection
+	// ---
+	// summary: Get the repository rules and organization rulesets protecting a branch or tag
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: ref
+	//   in: query
+	//   description: "full name of the ref (refs/heads/... or refs/tags/...), a plain name is treated as a branch"
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RefProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	repo := ctx.Repo.Repository
+	refName := git.RefName(ctx.FormTrim("ref"))
+	if refName == "" {
+		ctx.Error(http.StatusUnprocessableEntity, "", "ref is required")
+		return
+	}
+	if !refName.IsBranch() && !refName.IsTag() {
+		if strings.HasPrefix(refName.String(), "refs/") {
+			ctx.Error(http.StatusUnprocessableEntity, "", "only branches and tags can be protected")
+			return
+		}
+		refName = git.RefNameFromBranch(refName.String())
+	}
+
+	result := &api.RefProtection{Ref: refName.String()}
+
+	if refName.IsTag() {
+		tagName := refName.TagName()
+		pts, err := git_model.GetProtectedTags(ctx, repo.ID)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetProtectedTags", err)
+			return
+		}
+		pts, err = git_model.GetMatchingProtectedTags(pts, tagName)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetMatchingProtectedTags", err)
+			return
+		}
+		result.TagProtections = make([]*api.TagProtection, 0, len(pts))
+		for _, pt := range pts {
+			result.TagProtections = append(result.TagProtections, convert.ToTagProtection(ctx, pt, repo))
+		}
+
+		rulesets, err := git_model.FindRefOrgRulesets(ctx, repo.ID, git_model.OrgRulesetTargetTag, tagName)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "FindRefOrgRulesets", err)
+			return
+		}
+		result.OrgRulesets = convert.ToOrgRulesetList(ctx, rulesets)
+
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+
+	branchName := refName.BranchName()
+	rules, err := git_model.FindRepoProtectedBranchRules(ctx, repo.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindRepoProtectedBranchRules", err)
+		return
+	}
+	if rule := rules.GetFirstMatched(branchName); rule != nil {
+		result.BranchProtection = convert.ToBranchProtection(ctx, rule, repo)
+	}
+
+	effective, err := git_model.GetFirstMatchProtectedBranchRule(ctx, repo.ID, branchName)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetFirstMatchProtectedBranchRule", err)
+		return
+	}
+	var rulesets []*git_model.OrgRuleset
+	if effective != nil {
+		rulesets = effective.OrgRulesets
+		result.EffectiveBranchProtection = convert.ToBranchProtection(ctx, effective, repo)
+	}
+	result.OrgRulesets = convert.ToOrgRulesetList(ctx, rulesets)
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -235,6 +235,10 @@ func Search(ctx *context.APIContext) {
 
 // CreateUserRepo create a repository for a user
 func CreateUserRepo(ctx *context.APIContext, owner *user_model.User, opt api.CreateRepoOption) {
+	if !ctx.AccessTokenAllowsOwner(owner) {
+		ctx.Error(http.StatusForbidden, "", "the access token is restricted to other organizations or repositories")
+		return
+	}
 	if opt.AutoInit && opt.Readme == "" {
 		opt.Readme = "Default"
 	}
@@ -411,6 +415,10 @@ func Generate(ctx *context.APIContext) {
 			}
 		}
 	}
+	if !ctx.AccessTokenAllowsOwner(ctxUser) {
+		ctx.Error(http.StatusForbidden, "", "the access token is restricted to other organizations or repositories")
+		return
+	}
 
 	repo, err := repo_service.GenerateRepository(ctx, ctx.Doer, ctxUser, ctx.Repo.Repository, opts)
 	if err != nil {
@@ -580,6 +588,10 @@ func GetByID(ctx *context.APIContext) {
 		}
 		return
 	}
+	if !ctx.AccessTokenAllowsRepo(repo) {
+		ctx.NotFound()
+		return
+	}
 
 	permission, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 	if err != nil {
@@ -965,6 +977,9 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 			if opts.DefaultAllowMaintainerEdit != nil {
 				config.DefaultAllowMaintainerEdit = *opts.DefaultAllowMaintainerEdit
 			}
+			if opts.RebaseStackedPullsOnMerge != nil {
+				config.RebaseStackedPullsOnMerge = *opts.RebaseStackedPullsOnMerge
+			}
 
 			units = append(units, repo_model.RepoUnit{
 				RepoID: repo.ID,
@@ -0,0 +1,309 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/context"
+)
+
+// SearchCode searches the code of the repositories the doer can read
+func SearchCode(ctx *context.APIContext) {
+	// swagger:operation GET /repos/search/code repository repoSearchCode
+	// ---
+	// summary: Search for code in the repositories the user has access to
+	// description: The query supports "path:", "lang:" and "repo:" terms, /regular expressions/
+	//              and excluding terms with a leading "-". Without a code indexer the default branches
+	//              of the most recently updated repositories are searched with git grep.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: search query
+	//   type: string
+	//   required: true
+	// - name: fuzzy
+	//   in: query
+	//   description: whether keywords also match closely related words (defaults to true)
+	//   type: boolean
+	// - name: language
+	//   in: query
+	//   description: only return files of this language
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CodeSearchResults"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	keyword := ctx.FormTrim("q")
+	if keyword == "" {
+		ctx.Error(http.StatusUnprocessableEntity, "", "q is required")
+		return
+	}
+	listOptions := utils.GetListOptions(ctx)
+
+	var (
+		repoIDs []int64
+		err     error
+	)
+	// admins can search all repositories through the indexer, git grep needs the repositories to search in
+	if ctx.Doer == nil || !ctx.Doer.IsAdmin || !setting.Indexer.RepoIndexerEnabled {
+		repoIDs, err = repo_model.FindUserCodeAccessibleRepoIDs(ctx, ctx.Doer)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		if len(repoIDs) == 0 {
+			ctx.SetTotalCountHeader(0)
+			ctx.JSON(http.StatusOK, api.CodeSearchResults{Languages: []*api.CodeSearchResultLanguage{}, Data: []*api.CodeSearchResult{}})
+			return
+		}
+	}
+
+	opts := &code_indexer.SearchOptions{
+		RepoIDs:        repoIDs,
+		Keyword:        keyword,
+		IsKeywordFuzzy: ctx.FormOptionalBool("fuzzy").ValueOrDefault(true),
+		Language:       ctx.FormTrim("language"),
+		Paginator:      &listOptions,
+	}
+	var (
+		total           int
+		results         []*code_indexer.Result
+		resultLanguages []*code_indexer.SearchResultLanguages
+	)
+	if setting.Indexer.RepoIndexerEnabled {
+		total, results, resultLanguages, err = code_indexer.PerformSearch(ctx, opts)
+	} else {
+		total, results, resultLanguages, err = code_indexer.PerformGrepSearch(ctx, repoIDs, opts)
+	}
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		ctx.InternalServerError(err)
+		return
+	}
+
+	loadRepoIDs := make([]int64, 0, len(results))
+	for _, result := range results {
+		loadRepoIDs = append(loadRepoIDs, result.RepoID)
+	}
+	repos, err := repo_model.GetRepositoriesMapByIDs(ctx, loadRepoIDs)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiResults := make([]*api.CodeSearchResult, 0, len(results))
+	for _, result := range results {
+		repo, ok := repos[result.RepoID]
+		if !ok {
+			// the repository has been deleted but the indexer has not been updated yet
+			continue
+		}
+		lines := make([]*api.CodeSearchResultLine, 0, len(result.Lines))
+		for _, line := range result.Lines {
+			lines = append(lines, &api.CodeSearchResultLine{
+				Num:     line.Num,
+				Content: line.RawContent,
+			})
+		}
+		apiResults = append(apiResults, &api.CodeSearchResult{
+			RepoID:       repo.ID,
+			RepoFullName: repo.FullName(),
+			Filename:     result.Filename,
+			CommitID:     result.CommitID,
+			Language:     result.Language,
+			HTMLURL:      repo.HTMLURL() + "/src/commit/" + util.PathEscapeSegments(result.CommitID) + "/" + util.PathEscapeSegments(result.Filename),
+			Lines:        lines,
+		})
+	}
+
+	apiLanguages := make([]*api.CodeSearchResultLanguage, 0, len(resultLanguages))
+	for _, language := range resultLanguages {
+		apiLanguages = append(apiLanguages, &api.CodeSearchResultLanguage{
+			Language: language.Language,
+			Color:    language.Color,
+			Count:    language.Count,
+		})
+	}
+
+	ctx.SetLinkHeader(total, listOptions.PageSize)
+	ctx.SetTotalCountHeader(int64(total))
+	ctx.JSON(http.StatusOK, api.CodeSearchResults{
+		Languages: apiLanguages,
+		Data:      apiResults,
+	})
+}
+
+// SearchCommits searches the indexed commits of the repositories the doer can read
+func SearchCommits(ctx *context.APIContext) {
+	// swagger:operation GET /repos/search/commits repository repoSearchCommits
+	// ---
+	// summary: Search for commits in the repositories the user has access to
+	// description: The commits of the default branches are searched by their message, changed file paths
+	//              and diff, depending on the configuration of the commit indexer.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: keyword to search for, a commit SHA prefix of at least 7 characters matches the commit
+	//   type: string
+	// - name: fuzzy
+	//   in: query
+	//   description: whether the keyword also matches closely related words (defaults to true)
+	//   type: boolean
+	// - name: author
+	//   in: query
+	//   description: only return commits whose author name or email matches
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: Only show commits committed after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show commits committed before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: sort
+	//   in: query
+	//   description: sort order of the results, "newest" (default), "oldest" or "relevance"
+	//   type: string
+	//   enum: [newest, oldest, relevance]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CommitSearchResultList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.NotFound()
+		return
+	}
+
+	opts := &commit_indexer.SearchOptions{
+		Keyword:        ctx.FormTrim("q"),
+		IsFuzzyKeyword: ctx.FormOptionalBool("fuzzy").ValueOrDefault(true),
+		Author:         ctx.FormTrim("author"),
+	}
+	if opts.Keyword == "" && opts.Author == "" {
+		ctx.Error(http.StatusUnprocessableEntity, "", "q or author is required")
+		return
+	}
+
+	before, since, err := context.GetQueryBeforeSince(ctx.Base)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return
+	}
+	if since != 0 {
+		opts.CommittedAfterUnix = optional.Some(since)
+	}
+	if before != 0 {
+		opts.CommittedBeforeUnix = optional.Some(before)
+	}
+
+	switch ctx.FormTrim("sort") {
+	case "", "newest":
+		opts.SortBy = commit_indexer.SortByCommittedDesc
+	case "oldest":
+		opts.SortBy = commit_indexer.SortByCommittedAsc
+	case "relevance":
+		opts.SortBy = commit_indexer.SortByScore
+	default:
+		ctx.Error(http.StatusUnprocessableEntity, "", "invalid sort order")
+		return
+	}
+
+	listOptions := utils.GetListOptions(ctx)
+	opts.Paginator = &listOptions
+
+	// admins can search all repositories
+	if ctx.Doer == nil || !ctx.Doer.IsAdmin {
+		opts.RepoIDs, err = repo_model.FindUserCodeAccessibleRepoIDs(ctx, ctx.Doer)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		if len(opts.RepoIDs) == 0 {
+			ctx.SetTotalCountHeader(0)
+			ctx.JSON(http.StatusOK, []*api.CommitSearchResult{})
+			return
+		}
+	}
+
+	matches, total, err := commit_indexer.SearchCommits(ctx, opts)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	loadRepoIDs := make([]int64, 0, len(matches))
+	for _, match := range matches {
+		loadRepoIDs = append(loadRepoIDs, match.RepoID)
+	}
+	repos, err := repo_model.GetRepositoriesMapByIDs(ctx, loadRepoIDs)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiResults := make([]*api.CommitSearchResult, 0, len(matches))
+	for _, match := range matches {
+		repo, ok := repos[match.RepoID]
+		if !ok {
+			// the repository has been deleted but the indexer has not been updated yet
+			continue
+		}
+		apiResults = append(apiResults, &api.CommitSearchResult{
+			RepoID:       repo.ID,
+			RepoFullName: repo.FullName(),
+			SHA:          match.CommitID,
+			Message:      match.Message,
+			AuthorName:   match.AuthorName,
+			AuthorEmail:  match.AuthorEmail,
+			Committed:    match.CommittedUnix.AsTime(),
+			HTMLURL:      repo.HTMLURL() + "/commit/" + util.PathEscapeSegments(match.CommitID),
+		})
+	}
+
+	ctx.SetLinkHeader(int(total), listOptions.PageSize)
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, apiResults)
+}
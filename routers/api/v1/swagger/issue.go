@@ -56,6 +56,27 @@ type swaggerResponseLabelList struct {
 	Body []api.Label `json:"body"`
 }
 
+// IssueField
+// swagger:response IssueField
+type swaggerResponseIssueField struct {
+	// in:body
+	Body api.IssueField `json:"body"`
+}
+
+// IssueFieldList
+// swagger:response IssueFieldList
+type swaggerResponseIssueFieldList struct {
+	// in:body
+	Body []api.IssueField `json:"body"`
+}
+
+// IssueFieldValueList
+// swagger:response IssueFieldValueList
+type swaggerResponseIssueFieldValueList struct {
+	// in:body
+	Body []api.IssueFieldValue `json:"body"`
+}
+
 // Milestone
 // swagger:response Milestone
 type swaggerResponseMilestone struct {
@@ -51,6 +51,8 @@ type swaggerParameterBodies struct {
 	EditIssueCommentOption api.EditIssueCommentOption
 	// in:body
 	IssueMeta api.IssueMeta
+	// in:body
+	MoveSubIssueOption api.MoveSubIssueOption
 
 	// in:body
 	IssueLabelsOption api.IssueLabelsOption
@@ -69,6 +71,13 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditLabelOption api.EditLabelOption
 
+	// in:body
+	CreateIssueFieldOption api.CreateIssueFieldOption
+	// in:body
+	EditIssueFieldOption api.EditIssueFieldOption
+	// in:body
+	SetIssueFieldValueOption api.SetIssueFieldValueOption
+
 	// in:body
 	MarkupOption api.MarkupOption
 	// in:body
@@ -90,6 +99,8 @@ type swaggerParameterBodies struct {
 	EditPullRequestOption api.EditPullRequestOption
 	// in:body
 	MergePullRequestOption forms.MergePullRequestForm
+	// in:body
+	ResolvePullRequestConflictsOption api.ResolvePullRequestConflictsOption
 
 	// in:body
 	CreateReleaseOption api.CreateReleaseOption
@@ -190,6 +201,12 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditTagProtectionOption api.EditTagProtectionOption
 
+	// in:body
+	CreateOrgRulesetOption api.CreateOrgRulesetOption
+
+	// in:body
+	EditOrgRulesetOption api.EditOrgRulesetOption
+
 	// in:body
 	CreateAccessTokenOption api.CreateAccessTokenOption
 
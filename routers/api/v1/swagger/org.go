@@ -41,3 +41,17 @@ type swaggerResponseOrganizationPermissions struct {
 	// in:body
 	Body api.OrganizationPermissions `json:"body"`
 }
+
+// OrgRuleset
+// swagger:response OrgRuleset
+type swaggerResponseOrgRuleset struct {
+	// in:body
+	Body api.OrgRuleset `json:"body"`
+}
+
+// OrgRulesetList
+// swagger:response OrgRulesetList
+type swaggerResponseOrgRulesetList struct {
+	// in:body
+	Body []api.OrgRuleset `json:"body"`
+}
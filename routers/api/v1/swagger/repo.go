@@ -84,6 +84,13 @@ type swaggerResponseTagProtection struct {
 	Body api.TagProtection `json:"body"`
 }
 
+// RefProtection
+// swagger:response RefProtection
+type swaggerResponseRefProtection struct {
+	// in:body
+	Body api.RefProtection `json:"body"`
+}
+
 // Reference
 // swagger:response Reference
 type swaggerResponseReference struct {
@@ -168,6 +175,34 @@ type swaggerResponsePullReviewList struct {
 	Body []api.PullReview `json:"body"`
 }
 
+// MergeQueueEntry
+// swagger:response MergeQueueEntry
+type swaggerResponseMergeQueueEntry struct {
+	// in:body
+	Body api.MergeQueueEntry `json:"body"`
+}
+
+// MergeQueueEntryList
+// swagger:response MergeQueueEntryList
+type swaggerResponseMergeQueueEntryList struct {
+	// in:body
+	Body []api.MergeQueueEntry `json:"body"`
+}
+
+// PullRequestConflicts
+// swagger:response PullRequestConflicts
+type swaggerResponsePullRequestConflicts struct {
+	// in:body
+	Body api.PullRequestConflicts `json:"body"`
+}
+
+// PullRequestConflictsResolved
+// swagger:response PullRequestConflictsResolved
+type swaggerResponsePullRequestConflictsResolved struct {
+	// in:body
+	Body api.PullRequestConflictsResolved `json:"body"`
+}
+
 // PullComment
 // swagger:response PullReviewComment
 type swaggerPullReviewComment struct {
@@ -210,6 +245,20 @@ type swaggerResponseSearchResults struct {
 	Body api.SearchResults `json:"body"`
 }
 
+// CodeSearchResults
+// swagger:response CodeSearchResults
+type swaggerResponseCodeSearchResults struct {
+	// in:body
+	Body api.CodeSearchResults `json:"body"`
+}
+
+// CommitSearchResultList
+// swagger:response CommitSearchResultList
+type swaggerResponseCommitSearchResultList struct {
+	// in:body
+	Body []api.CommitSearchResult `json:"body"`
+}
+
 // AttachmentList
 // swagger:response AttachmentList
 type swaggerResponseAttachmentList struct {
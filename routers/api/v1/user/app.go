@@ -10,12 +10,18 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	audit_service "code.gitea.io/gitea/services/audit"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 )
@@ -57,11 +63,10 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
-			Scopes:         tokens[i].Scope.StringSlice(),
+		apiTokens[i], err = toAccessToken(ctx, tokens[i])
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
 		}
 	}
 
@@ -120,16 +125,59 @@ func CreateAccessToken(ctx *context.APIContext) {
 	}
 	t.Scope = scope
 
+	if err := auth_service.SetAccessTokenResources(ctx, t, form.Organization, form.Repositories); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "SetAccessTokenResources", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(time.Now()) {
+			ctx.Error(http.StatusBadRequest, "ExpiresAt", errors.New("the expiry date of the access token must be in the future"))
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(form.ExpiresAt.Unix())
+	}
+
 	if err := auth_model.NewAccessToken(ctx, t); err != nil {
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(http.StatusCreated, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAccessTokenCreate, audit_service.AccessTokenTarget(t), nil, audit_service.AccessTokenState(t))
+	apiToken, err := toAccessToken(ctx, t)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	apiToken.Token = t.Token
+	ctx.JSON(http.StatusCreated, apiToken)
+}
+
+func toAccessToken(ctx *context.APIContext, t *auth_model.AccessToken) (*api.AccessToken, error) {
+	apiToken := &api.AccessToken{
 		ID:             t.ID,
+		Name:           t.Name,
 		TokenLastEight: t.TokenLastEight,
-	})
+		Scopes:         t.Scope.StringSlice(),
+	}
+	if t.IsRestricted() {
+		resources, err := auth_service.GetAccessTokenResources(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		if resources.Organization != nil {
+			apiToken.Organization = resources.Organization.Name
+		} else {
+			apiToken.Repositories = resources.FullNames()
+		}
+	}
+	if t.ExpiresUnix != 0 {
+		expiresAt := t.ExpiresUnix.AsTime()
+		apiToken.ExpiresAt = &expiresAt
+	}
+	return apiToken, nil
 }
 
 // DeleteAccessToken delete access tokens
@@ -189,6 +237,12 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		return
 	}
 
+	t, _, err := db.GetByID[auth_model.AccessToken](ctx, tokenID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetByID", err)
+		return
+	}
+
 	if err := auth_model.DeleteAccessTokenByID(ctx, tokenID, ctx.ContextUser.ID); err != nil {
 		if auth_model.IsErrAccessTokenNotExist(err) {
 			ctx.NotFound()
@@ -197,6 +251,7 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAccessTokenDelete, audit_service.AccessTokenTarget(t), audit_service.AccessTokenState(t), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
 	user_model "code.gitea.io/gitea/models/user"
@@ -18,6 +19,7 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 )
@@ -215,6 +217,8 @@ func CreateUserPublicKey(ctx *context.APIContext, form api.CreateKeyOption, uid
 		repo.HandleAddKeyError(ctx, err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionPublicKeyAdd, audit_service.PublicKeyTarget(key),
+		nil, audit_service.PublicKeyState(key))
 	apiLink := composePublicKeysAPILink()
 	apiKey := convert.ToPublicKey(apiLink, key)
 	if ctx.Doer.IsAdmin || ctx.Doer.ID == key.OwnerID {
@@ -290,6 +294,12 @@ func DeletePublicKey(ctx *context.APIContext) {
 		return
 	}
 
+	key, err := asymkey_model.GetPublicKeyByID(ctx, id)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPublicKeyByID", err)
+		return
+	}
+
 	if err := asymkey_service.DeletePublicKey(ctx, ctx.Doer, id); err != nil {
 		if asymkey_model.IsErrKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
@@ -298,6 +308,8 @@ func DeletePublicKey(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionPublicKeyRemove, audit_service.PublicKeyTarget(key),
+		audit_service.PublicKeyState(key), nil)
 
 	ctx.Status(http.StatusNoContent)
 }
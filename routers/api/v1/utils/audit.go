@@ -0,0 +1,69 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package utils
+
+import (
+	"fmt"
+	"net/http"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListAuditEvents lists the audit events of an owner or a repository, or of the instance if both are 0, filtered by
+// the action, actor, since and before query parameters
+func ListAuditEvents(ctx *context.APIContext, ownerID, repoID int64) {
+	before, since, err := context.GetQueryBeforeSince(ctx.Base)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return
+	}
+	opts := audit_model.FindEventsOptions{
+		ListOptions: GetListOptions(ctx),
+		OwnerID:     ownerID,
+		RepoID:      repoID,
+		Since:       timeutil.TimeStamp(since),
+		Before:      timeutil.TimeStamp(before),
+	}
+
+	if action := audit_model.Action(ctx.FormString("action")); action != "" {
+		if !action.IsValid() {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown action %q", action))
+			return
+		}
+		opts.Action = action
+	}
+
+	if actor := ctx.FormTrim("actor"); actor != "" {
+		u, err := user_model.GetUserByName(ctx, actor)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+		opts.ActorID = u.ID
+	}
+
+	events, count, err := db.FindAndCount[audit_model.Event](ctx, opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, len(events))
+	for i, e := range events {
+		apiEvents[i] = convert.ToAuditEvent(e)
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiEvents)
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/models/webhook"
@@ -17,6 +18,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	webhook_service "code.gitea.io/gitea/services/webhook"
 )
@@ -249,6 +251,7 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, ownerID, repoI
 		ctx.Error(http.StatusInternalServerError, "CreateWebhook", err)
 		return nil, false
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookCreate, audit_service.WebhookTarget(ctx, w), nil, audit_service.WebhookState(w))
 	return w, true
 }
 
@@ -320,6 +323,7 @@ func EditRepoHook(ctx *context.APIContext, form *api.EditHookOption, hookID int6
 // editHook edit the webhook `w` according to `form`. If an error occurs, write
 // to `ctx` accordingly and return the error. Return whether successful
 func editHook(ctx *context.APIContext, form *api.EditHookOption, w *webhook.Webhook) bool {
+	before := audit_service.WebhookState(w)
 	if form.Config != nil {
 		if url, ok := form.Config["url"]; ok {
 			w.URL = url
@@ -402,18 +406,25 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *webhook.Webh
 		ctx.Error(http.StatusInternalServerError, "UpdateWebhook", err)
 		return false
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookUpdate, audit_service.WebhookTarget(ctx, w), before, audit_service.WebhookState(w))
 	return true
 }
 
 // DeleteOwnerHook deletes the hook owned by the owner.
 func DeleteOwnerHook(ctx *context.APIContext, owner *user_model.User, hookID int64) {
-	if err := webhook.DeleteWebhookByOwnerID(ctx, owner.ID, hookID); err != nil {
+	w, err := webhook.GetWebhookByOwnerID(ctx, owner.ID, hookID)
+	if err != nil {
 		if webhook.IsErrWebhookNotExist(err) {
 			ctx.NotFound()
 		} else {
-			ctx.Error(http.StatusInternalServerError, "DeleteWebhookByOwnerID", err)
+			ctx.Error(http.StatusInternalServerError, "GetWebhookByOwnerID", err)
 		}
 		return
 	}
+	if err := webhook.DeleteWebhookByOwnerID(ctx, owner.ID, w.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteWebhookByOwnerID", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookDelete, audit_service.WebhookTarget(ctx, w), audit_service.WebhookState(w), nil)
 	ctx.Status(http.StatusNoContent)
 }
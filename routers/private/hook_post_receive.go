@@ -163,7 +163,8 @@ func HookPostReceive(ctx *gitea_context.PrivateContext) {
 	}
 
 	// handle pull request merging, a pull request action should push at least 1 commit
-	if opts.PushTrigger == repo_module.PushTriggerPRMergeToBase {
+	if opts.PushTrigger == repo_module.PushTriggerPRMergeToBase ||
+		(opts.PushTrigger == repo_module.PushTriggerMergeQueue && opts.PullRequestID > 0) {
 		handlePullRequestMerging(ctx, opts, ownerName, repoName, updates)
 		if ctx.Written() {
 			return
@@ -356,6 +357,10 @@ func handlePullRequestMerging(ctx *gitea_context.PrivateContext, opts *private.H
 		if err := pull_model.DeleteScheduledAutoMerge(ctx, pr.ID); err != nil && !db.IsErrNotExist(err) {
 			return fmt.Errorf("DeleteScheduledAutoMerge[%d]: %v", opts.PullRequestID, err)
 		}
+		// Removing the pull from the merge queue and ignore if not exist
+		if err := pull_model.RemoveFromMergeQueue(ctx, pr.ID); err != nil && !db.IsErrNotExist(err) {
+			return fmt.Errorf("RemoveFromMergeQueue[%d]: %v", opts.PullRequestID, err)
+		}
 		if _, err := pr.SetMerged(ctx); err != nil {
 			return fmt.Errorf("SetMerged failed: %s/%s Error: %v", ownerName, repoName, err)
 		}
@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
+	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/web"
 	gitea_context "code.gitea.io/gitea/services/context"
 	pull_service "code.gitea.io/gitea/services/pull"
@@ -169,6 +170,8 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 			preReceiveTag(ourCtx, oldCommitID, newCommitID, refFullName)
 		case git.SupportProcReceive && refFullName.IsFor():
 			preReceiveFor(ourCtx, oldCommitID, newCommitID, refFullName)
+		case refFullName.IsMergeQueue():
+			preReceiveMergeQueue(ourCtx, refFullName)
 		default:
 			ourCtx.AssertCanWriteCode()
 		}
@@ -266,9 +269,28 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 		}
 	}
 
+	// 4. Enforce linear history, sign-off and commit message pattern
+	if pull_service.HasCommitPolicy(protectBranch) {
+		violations, err := pull_service.CheckCommitPolicy(ctx, repo.RepoPath(), protectBranch, oldCommitID, newCommitID, ctx.env)
+		if err != nil {
+			log.Error("Unable to check commit policy for commits from %s to %s in %-v: %v", oldCommitID, newCommitID, repo, err)
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: fmt.Sprintf("Unable to check commit policy for commits from %s to %s: %v", oldCommitID, newCommitID, err),
+			})
+			return
+		}
+		if len(violations) > 0 {
+			log.Warn("Forbidden: Branch: %s in %-v is protected from commits violating its commit policy, first: %s", branchName, repo, violations[0])
+			ctx.JSON(http.StatusForbidden, private.Response{
+				UserMsg: pull_service.FormatCommitPolicyViolations(branchName, violations),
+			})
+			return
+		}
+	}
+
 	// Now there are several tests which can be overridden:
 	//
-	// 4. Check protected file patterns - this is overridable from the UI
+	// 5. Check protected file patterns - this is overridable from the UI
 	changedProtectedfiles := false
 	protectedFilePath := ""
 
@@ -289,10 +311,10 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 		}
 	}
 
-	// 5. Check if the doer is allowed to push
+	// 6. Check if the doer is allowed to push
 	var canPush bool
 	if ctx.opts.DeployKeyID != 0 {
-		canPush = !changedProtectedfiles && protectBranch.CanPush && (!protectBranch.EnableWhitelist || protectBranch.WhitelistDeployKeys)
+		canPush = !changedProtectedfiles && protectBranch.CanDeployKeyPush()
 	} else {
 		user, err := user_model.GetUserByID(ctx, ctx.opts.UserID)
 		if err != nil {
@@ -305,11 +327,11 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 		canPush = !changedProtectedfiles && protectBranch.CanUserPush(ctx, user)
 	}
 
-	// 6. If we're not allowed to push directly
+	// 7. If we're not allowed to push directly
 	if !canPush {
 		// Is this is a merge from the UI/API?
 		if ctx.opts.PullRequestID == 0 {
-			// 6a. If we're not merging from the UI/API then there are two ways we got here:
+			// 7a. If we're not merging from the UI/API then there are two ways we got here:
 			//
 			// We are changing a protected file and we're not allowed to do that
 			if changedProtectedfiles {
@@ -344,7 +366,7 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 			})
 			return
 		}
-		// 6b. Merge (from UI or API)
+		// 7b. Merge (from UI or API)
 
 		// Get the PR, user and permissions for the user in the repository
 		pr, err := issues_model.GetPullRequestByID(ctx, ctx.opts.PullRequestID)
@@ -381,6 +403,24 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 			return
 		}
 
+		// Fast-forwards by the merge queue have to push the tested merge queue commit of the PR
+		if ctx.opts.PushTrigger == repo_module.PushTriggerMergeQueue {
+			if err := pull_service.CheckMergeQueueCommit(ctx, pr, protectBranch, newCommitID); err != nil {
+				if models.IsErrDisallowedToMerge(err) {
+					log.Warn("Forbidden: User %d is not allowed to push to protected branch %s in %-v from the merge queue of pr #%d: %s", ctx.opts.UserID, branchName, repo, pr.Index, err.Error())
+					ctx.JSON(http.StatusForbidden, private.Response{
+						UserMsg: fmt.Sprintf("Not allowed to push to protected branch %s from the merge queue of pr #%d: %s", branchName, pr.Index, err.Error()),
+					})
+					return
+				}
+				log.Error("Unable to check merge queue commit %s of pr #%d in %-v: %v", newCommitID, pr.Index, repo, err)
+				ctx.JSON(http.StatusInternalServerError, private.Response{
+					Err: fmt.Sprintf("Unable to check merge queue commit of pull request %d. Error: %v", ctx.opts.PullRequestID, err),
+				})
+			}
+			return
+		}
+
 		// If we're an admin for the instance, we can ignore checks
 		if ctx.user.IsAdmin {
 			return
@@ -418,6 +458,18 @@ func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, r
 	}
 }
 
+func preReceiveMergeQueue(ctx *preReceiveContext, refFullName git.RefName) {
+	// Merge queue refs are managed by the merge queue only
+	if ctx.opts.PushTrigger != repo_module.PushTriggerMergeQueue {
+		log.Warn("Forbidden: User %d is not allowed to push to merge queue ref %s in %-v", ctx.opts.UserID, refFullName, ctx.Repo.Repository)
+		ctx.JSON(http.StatusForbidden, private.Response{
+			UserMsg: fmt.Sprintf("%s is reserved for the merge queue", refFullName),
+		})
+		return
+	}
+	ctx.AssertCanWriteCode()
+}
+
 func preReceiveTag(ctx *preReceiveContext, oldCommitID, newCommitID string, refFullName git.RefName) { //nolint:unparam
 	if !ctx.AssertCanWriteCode() {
 		return
@@ -439,6 +491,9 @@ func preReceiveTag(ctx *preReceiveContext, oldCommitID, newCommitID string, refF
 	}
 
 	isAllowed, err := git_model.IsUserAllowedToControlTag(ctx, ctx.protectedTags, tagName, ctx.opts.UserID)
+	if err == nil && isAllowed {
+		isAllowed, err = git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, ctx.Repo.Repository.ID, tagName, ctx.opts.UserID)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, private.Response{
 			Err: err.Error(),
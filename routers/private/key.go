@@ -4,12 +4,18 @@
 package private
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/private"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/services/context"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // UpdatePublicKeyInRepo update public key and deploy key updates
@@ -50,6 +56,13 @@ func UpdatePublicKeyInRepo(ctx *context.PrivateContext) {
 func AuthorizedPublicKeyByContent(ctx *context.PrivateContext) {
 	content := ctx.FormString("content")
 
+	if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content)); err == nil {
+		if cert, ok := key.(*ssh.Certificate); ok {
+			authorizedPrincipalsByCertificate(ctx, cert)
+			return
+		}
+	}
+
 	publicKey, err := asymkey_model.SearchPublicKeyByContent(ctx, content)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, private.Response{
@@ -59,3 +72,50 @@ func AuthorizedPublicKeyByContent(ctx *context.PrivateContext) {
 	}
 	ctx.PlainText(http.StatusOK, publicKey.AuthorizedString())
 }
+
+// authorizedPrincipalsByCertificate returns the authorized principals of a certificate of a trusted certificate
+// authority: the principals registered on an account and, if the certificate is mapped to a user, its principals
+// for this user
+func authorizedPrincipalsByCertificate(ctx *context.PrivateContext, cert *ssh.Certificate) {
+	sb := &strings.Builder{}
+	for _, principal := range cert.ValidPrincipals {
+		key, err := asymkey_model.SearchPublicKeyByContentExact(ctx, principal)
+		if err != nil {
+			if asymkey_model.IsErrKeyNotExist(err) {
+				continue
+			}
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: err.Error(),
+			})
+			return
+		}
+		if asymkey_model.CheckUserCertificate(cert, principal, nil) == nil {
+			sb.WriteString(key.AuthorizedString())
+		}
+	}
+
+	if sb.Len() == 0 && setting.SSH.TrustedUserCAMapping != "off" {
+		user, principals, err := asymkey_model.GetUserByCertificate(ctx, cert)
+		if err != nil && !user_model.IsErrUserNotExist(err) {
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: err.Error(),
+			})
+			return
+		}
+		if user != nil {
+			for _, principal := range principals {
+				if asymkey_model.CheckUserCertificate(cert, principal, nil) == nil {
+					sb.WriteString(asymkey_model.AuthorizedStringForCertificate(user.ID, principal))
+				}
+			}
+		}
+	}
+
+	if sb.Len() == 0 {
+		ctx.JSON(http.StatusNotFound, private.Response{
+			Err: fmt.Sprintf("no authorized principal for the certificate with key ID %q", cert.KeyId),
+		})
+		return
+	}
+	ctx.PlainText(http.StatusOK, sb.String())
+}
@@ -23,29 +23,57 @@ import (
 	wiki_service "code.gitea.io/gitea/services/wiki"
 )
 
-// ServNoCommand returns information about the provided keyid
+// certificateKey returns the key standing for a certificate of a trusted certificate authority that was mapped to the
+// user, it is not stored in the database
+func certificateKey(ctx *context.PrivateContext, userID int64) (*asymkey_model.PublicKey, bool) {
+	if setting.SSH.TrustedUserCAMapping == "off" {
+		ctx.JSON(http.StatusUnauthorized, private.Response{
+			UserMsg: "Certificates are not mapped to users",
+		})
+		return nil, false
+	}
+	return &asymkey_model.PublicKey{
+		OwnerID: userID,
+		Name:    "SSH certificate",
+		Mode:    perm.AccessModeWrite,
+		Type:    asymkey_model.KeyTypeUser,
+	}, true
+}
+
+// ServNoCommand returns information about the provided keyid, or the provided user if it authenticated with a certificate
 func ServNoCommand(ctx *context.PrivateContext) {
 	keyID := ctx.ParamsInt64(":keyid")
-	if keyID <= 0 {
+	userID := ctx.FormInt64("user")
+	if keyID <= 0 && userID <= 0 {
 		ctx.JSON(http.StatusBadRequest, private.Response{
 			UserMsg: fmt.Sprintf("Bad key id: %d", keyID),
 		})
+		return
 	}
 	results := private.KeyAndOwner{}
 
-	key, err := asymkey_model.GetPublicKeyByID(ctx, keyID)
-	if err != nil {
-		if asymkey_model.IsErrKeyNotExist(err) {
-			ctx.JSON(http.StatusUnauthorized, private.Response{
-				UserMsg: fmt.Sprintf("Cannot find key: %d", keyID),
+	var key *asymkey_model.PublicKey
+	if keyID > 0 {
+		var err error
+		key, err = asymkey_model.GetPublicKeyByID(ctx, keyID)
+		if err != nil {
+			if asymkey_model.IsErrKeyNotExist(err) {
+				ctx.JSON(http.StatusUnauthorized, private.Response{
+					UserMsg: fmt.Sprintf("Cannot find key: %d", keyID),
+				})
+				return
+			}
+			log.Error("Unable to get public key: %d Error: %v", keyID, err)
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: err.Error(),
 			})
 			return
 		}
-		log.Error("Unable to get public key: %d Error: %v", keyID, err)
-		ctx.JSON(http.StatusInternalServerError, private.Response{
-			Err: err.Error(),
-		})
-		return
+	} else {
+		var ok bool
+		if key, ok = certificateKey(ctx, userID); !ok {
+			return
+		}
 	}
 	results.Key = key
 
@@ -183,20 +211,28 @@ func ServCommand(ctx *context.PrivateContext) {
 		}
 	}
 
-	// Get the Public Key represented by the keyID
-	key, err := asymkey_model.GetPublicKeyByID(ctx, keyID)
-	if err != nil {
-		if asymkey_model.IsErrKeyNotExist(err) {
-			ctx.JSON(http.StatusNotFound, private.Response{
-				UserMsg: fmt.Sprintf("Cannot find key: %d", keyID),
+	// Get the Public Key represented by the keyID, or by the certificate of the user
+	var key *asymkey_model.PublicKey
+	if keyID > 0 {
+		key, err = asymkey_model.GetPublicKeyByID(ctx, keyID)
+		if err != nil {
+			if asymkey_model.IsErrKeyNotExist(err) {
+				ctx.JSON(http.StatusNotFound, private.Response{
+					UserMsg: fmt.Sprintf("Cannot find key: %d", keyID),
+				})
+				return
+			}
+			log.Error("Unable to get public key: %d Error: %v", keyID, err)
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: fmt.Sprintf("Unable to get key: %d  Error: %v", keyID, err),
 			})
 			return
 		}
-		log.Error("Unable to get public key: %d Error: %v", keyID, err)
-		ctx.JSON(http.StatusInternalServerError, private.Response{
-			Err: fmt.Sprintf("Unable to get key: %d  Error: %v", keyID, err),
-		})
-		return
+	} else {
+		var ok bool
+		if key, ok = certificateKey(ctx, ctx.FormInt64("user")); !ok {
+			return
+		}
 	}
 	results.KeyName = key.Name
 	results.KeyID = key.ID
@@ -267,6 +303,21 @@ func ServCommand(ctx *context.PrivateContext) {
 			return
 		}
 
+		policy, violation, err := context.OrgAuthPolicyViolation(ctx, owner, user, nil)
+		if err != nil {
+			log.Error("Unable to check the authentication policy of %s for %s: %v", owner.Name, user.Name, err)
+			ctx.JSON(http.StatusInternalServerError, private.Response{
+				Err: fmt.Sprintf("Unable to check the authentication policy of %s: %v", owner.Name, err),
+			})
+			return
+		}
+		if violation != nil && !policy.InGracePeriod() {
+			ctx.JSON(http.StatusForbidden, private.Response{
+				UserMsg: context.OrgAuthPolicyMessage(owner, violation),
+			})
+			return
+		}
+
 		results.UserName = user.Name
 		if !user.KeepEmailPrivate {
 			results.UserEmail = user.Email
@@ -0,0 +1,48 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"net/http"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/setting"
+	shared_audit "code.gitea.io/gitea/routers/web/shared/audit"
+	"code.gitea.io/gitea/services/context"
+)
+
+const tplAudit base.TplName = "admin/audit"
+
+// Audit renders the audit log of the instance
+func Audit(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("audit.title")
+	ctx.Data["PageIsAdminAudit"] = true
+
+	shared_audit.SetEventsContext(ctx, 0, 0)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplAudit)
+}
+
+// AuditExport exports the audit log of the instance
+func AuditExport(ctx *context.Context) {
+	shared_audit.ExportEvents(ctx, 0, 0)
+}
+
+// AuditVerify checks that no event of the audit log was modified or removed
+func AuditVerify(ctx *context.Context) {
+	brokenID, err := audit_model.VerifyChain(ctx)
+	if err != nil {
+		ctx.ServerError("VerifyChain", err)
+		return
+	}
+	if brokenID > 0 {
+		ctx.Flash.Error(ctx.Tr("audit.verify.broken", brokenID))
+	} else {
+		ctx.Flash.Success(ctx.Tr("audit.verify.intact"))
+	}
+	ctx.Redirect(setting.AppSubURL + "/admin/audit")
+}
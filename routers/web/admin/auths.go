@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/auth/pam"
@@ -20,14 +21,17 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	audit_service "code.gitea.io/gitea/services/audit"
 	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/ldap"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
 	pam_service "code.gitea.io/gitea/services/auth/source/pam"
+	"code.gitea.io/gitea/services/auth/source/saml"
 	"code.gitea.io/gitea/services/auth/source/smtp"
 	"code.gitea.io/gitea/services/auth/source/sspi"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
+	"code.gitea.io/gitea/services/scim"
 
 	"xorm.io/xorm/convert"
 )
@@ -71,6 +75,7 @@ var (
 			{auth.SMTP.String(), auth.SMTP},
 			{auth.OAuth2.String(), auth.OAuth2},
 			{auth.SSPI.String(), auth.SSPI},
+			{auth.SAML.String(), auth.SAML},
 		}
 		if pam.Supported {
 			items = append(items, dropdownItem{auth.Names[auth.PAM], auth.PAM})
@@ -232,6 +237,70 @@ func parseSSPIConfig(ctx *context.Context, form forms.AuthenticationForm) (*sspi
 	}, nil
 }
 
+// parseSAMLConfig imports the metadata of the identity provider from the URL, or else from the XML of the form, and
+// keeps the key pair of the existing source unless another one is given, a new source gets a generated key pair
+func parseSAMLConfig(ctx *context.Context, form forms.AuthenticationForm, existing *saml.Source) (*saml.Source, error) {
+	config := &saml.Source{
+		IdentityProviderMetadataURL: strings.TrimSpace(form.SAMLIdentityProviderMetadataURL),
+		IdentityProviderMetadata:    strings.TrimSpace(form.SAMLIdentityProviderMetadata),
+		ServiceProviderEntityID:     strings.TrimSpace(form.SAMLServiceProviderEntityID),
+		ServiceProviderCertificate:  strings.TrimSpace(form.SAMLServiceProviderCertificate),
+		ServiceProviderPrivateKey:   strings.TrimSpace(form.SAMLServiceProviderPrivateKey),
+		SignRequests:                form.SAMLSignRequests,
+		NameIDFormat:                strings.TrimSpace(form.SAMLNameIDFormat),
+		UsernameAttribute:           form.SAMLUsernameAttribute,
+		EmailAttribute:              form.SAMLEmailAttribute,
+		FullNameAttribute:           form.SAMLFullNameAttribute,
+		GroupsAttribute:             form.SAMLGroupsAttribute,
+		AdminGroup:                  form.SAMLAdminGroup,
+		RestrictedGroup:             form.SAMLRestrictedGroup,
+		GroupTeamMap:                form.SAMLGroupTeamMap,
+		GroupTeamMapRemoval:         form.SAMLGroupTeamMapRemoval,
+		IconURL:                     form.SAMLIconURL,
+		SkipLocalTwoFA:              form.SkipLocalTwoFA,
+	}
+
+	if config.IdentityProviderMetadataURL != "" {
+		metadataURL, err := url.Parse(config.IdentityProviderMetadataURL)
+		if err != nil || (metadataURL.Scheme != "http" && metadataURL.Scheme != "https") {
+			ctx.Data["Err_SAMLMetadata"] = true
+			return nil, errors.New(ctx.Locale.TrString("admin.auths.saml_invalid_metadata_url"))
+		}
+		metadata, err := saml.FetchIdentityProviderMetadata(ctx, config.IdentityProviderMetadataURL)
+		if err != nil {
+			ctx.Data["Err_SAMLMetadata"] = true
+			return nil, errors.New(ctx.Locale.TrString("admin.auths.saml_invalid_metadata", err.Error()))
+		}
+		config.IdentityProviderMetadata = string(metadata)
+	} else if config.IdentityProviderMetadata == "" {
+		ctx.Data["Err_SAMLMetadata"] = true
+		return nil, errors.New(ctx.Locale.TrString("admin.auths.saml_metadata_required"))
+	} else if _, err := saml.ParseIdentityProviderMetadata([]byte(config.IdentityProviderMetadata)); err != nil {
+		ctx.Data["Err_SAMLMetadata"] = true
+		return nil, errors.New(ctx.Locale.TrString("admin.auths.saml_invalid_metadata", err.Error()))
+	}
+
+	if config.ServiceProviderPrivateKey == "" && existing != nil {
+		config.ServiceProviderPrivateKey = existing.ServiceProviderPrivateKey
+		if config.ServiceProviderCertificate == "" {
+			config.ServiceProviderCertificate = existing.ServiceProviderCertificate
+		}
+	}
+	if config.ServiceProviderCertificate == "" && config.ServiceProviderPrivateKey == "" {
+		var err error
+		config.ServiceProviderCertificate, config.ServiceProviderPrivateKey, err = saml.GenerateServiceProviderKeyPair(setting.Domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := config.ValidateKeyPair(); err != nil {
+		ctx.Data["Err_SAMLKeyPair"] = true
+		return nil, errors.New(ctx.Locale.TrString("admin.auths.saml_invalid_key_pair", err.Error()))
+	}
+
+	return config, nil
+}
+
 // NewAuthSourcePost response for adding an auth source
 func NewAuthSourcePost(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AuthenticationForm)
@@ -291,6 +360,13 @@ func NewAuthSourcePost(ctx *context.Context) {
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_of_type_exist"), tplAuthNew, form)
 			return
 		}
+	case auth.SAML:
+		var err error
+		config, err = parseSAMLConfig(ctx, form, nil)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthNew, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
@@ -302,13 +378,14 @@ func NewAuthSourcePost(ctx *context.Context) {
 		return
 	}
 
-	if err := auth.CreateSource(ctx, &auth.Source{
+	source := &auth.Source{
 		Type:          auth.Type(form.Type),
 		Name:          form.Name,
 		IsActive:      form.IsActive,
 		IsSyncEnabled: form.IsSyncEnabled,
 		Cfg:           config,
-	}); err != nil {
+	}
+	if err := auth.CreateSource(ctx, source); err != nil {
 		if auth.IsErrSourceAlreadyExist(err) {
 			ctx.Data["Err_Name"] = true
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_exist", err.(auth.ErrSourceAlreadyExist).Name), tplAuthNew, form)
@@ -323,6 +400,8 @@ func NewAuthSourcePost(ctx *context.Context) {
 	}
 
 	log.Trace("Authentication created by admin(%s): %s", ctx.Doer.Name, form.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminAuthSourceCreate, audit_service.InstanceTarget("auth_source", source.ID, source.Name),
+		nil, audit_service.AuthSourceState(source))
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.new_success", form.Name))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths")
@@ -345,6 +424,9 @@ func EditAuthSource(ctx *context.Context) {
 	}
 	ctx.Data["Source"] = source
 	ctx.Data["HasTLS"] = source.HasTLS()
+	if !loadSCIMToken(ctx, source) {
+		return
+	}
 
 	if source.IsOAuth2() {
 		type Named interface {
@@ -362,6 +444,21 @@ func EditAuthSource(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, tplAuthEdit)
 }
 
+// loadSCIMToken shows the SCIM provisioning of the sources whose users are provisioned by their identity provider
+func loadSCIMToken(ctx *context.Context, source *auth.Source) bool {
+	if !source.IsOAuth2() && !source.IsSAML() {
+		return true
+	}
+	token, err := auth.GetSCIMTokenBySourceID(ctx, source.ID)
+	if err != nil && !auth.IsErrSCIMTokenNotExist(err) {
+		ctx.ServerError("GetSCIMTokenBySourceID", err)
+		return false
+	}
+	ctx.Data["SCIMURL"] = scim.BaseURL()
+	ctx.Data["SCIMToken"] = token
+	return true
+}
+
 // EditAuthSourcePost response for editing auth source
 func EditAuthSourcePost(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AuthenticationForm)
@@ -379,6 +476,9 @@ func EditAuthSourcePost(ctx *context.Context) {
 	}
 	ctx.Data["Source"] = source
 	ctx.Data["HasTLS"] = source.HasTLS()
+	if !loadSCIMToken(ctx, source) {
+		return
+	}
 
 	if ctx.HasError() {
 		ctx.HTML(http.StatusOK, tplAuthEdit)
@@ -413,11 +513,19 @@ func EditAuthSourcePost(ctx *context.Context) {
 			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
 			return
 		}
+	case auth.SAML:
+		existing, _ := source.Cfg.(*saml.Source)
+		config, err = parseSAMLConfig(ctx, form, existing)
+		if err != nil {
+			ctx.RenderWithErr(err.Error(), tplAuthEdit, form)
+			return
+		}
 	default:
 		ctx.Error(http.StatusBadRequest)
 		return
 	}
 
+	before := audit_service.AuthSourceState(source)
 	source.Name = form.Name
 	source.IsActive = form.IsActive
 	source.IsSyncEnabled = form.IsSyncEnabled
@@ -436,6 +544,8 @@ func EditAuthSourcePost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Authentication changed by admin(%s): %d", ctx.Doer.Name, source.ID)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminAuthSourceUpdate, audit_service.InstanceTarget("auth_source", source.ID, source.Name),
+		before, audit_service.AuthSourceState(source))
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.update_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths/" + strconv.FormatInt(form.ID, 10))
@@ -459,7 +569,51 @@ func DeleteAuthSource(ctx *context.Context) {
 		return
 	}
 	log.Trace("Authentication deleted by admin(%s): %d", ctx.Doer.Name, source.ID)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminAuthSourceDelete, audit_service.InstanceTarget("auth_source", source.ID, source.Name),
+		audit_service.AuthSourceState(source), nil)
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.deletion_success"))
 	ctx.JSONRedirect(setting.AppSubURL + "/admin/auths")
 }
+
+// GenerateSCIMToken replaces the SCIM token of an auth source, the new token is only shown once
+func GenerateSCIMToken(ctx *context.Context) {
+	source, err := auth.GetSourceByID(ctx, ctx.ParamsInt64(":authid"))
+	if err != nil {
+		ctx.ServerError("auth.GetSourceByID", err)
+		return
+	}
+	if !source.IsOAuth2() && !source.IsSAML() {
+		ctx.NotFound("GenerateSCIMToken", nil)
+		return
+	}
+
+	token, err := auth.GenerateSCIMToken(ctx, source.ID)
+	if err != nil {
+		ctx.ServerError("GenerateSCIMToken", err)
+		return
+	}
+	log.Trace("SCIM token of authentication %d generated by admin(%s)", source.ID, ctx.Doer.Name)
+
+	ctx.Flash.Success(ctx.Tr("admin.auths.scim_token_success"))
+	ctx.Flash.Info(token.Token)
+	ctx.Redirect(setting.AppSubURL + "/admin/auths/" + strconv.FormatInt(source.ID, 10))
+}
+
+// DeleteSCIMToken deletes the SCIM token of an auth source, which disables its SCIM provisioning
+func DeleteSCIMToken(ctx *context.Context) {
+	source, err := auth.GetSourceByID(ctx, ctx.ParamsInt64(":authid"))
+	if err != nil {
+		ctx.ServerError("auth.GetSourceByID", err)
+		return
+	}
+
+	if err := auth.DeleteSCIMTokenBySourceID(ctx, source.ID); err != nil {
+		ctx.ServerError("DeleteSCIMTokenBySourceID", err)
+		return
+	}
+	log.Trace("SCIM token of authentication %d deleted by admin(%s)", source.ID, ctx.Doer.Name)
+
+	ctx.Flash.Success(ctx.Tr("admin.auths.scim_token_deletion_success"))
+	ctx.Redirect(setting.AppSubURL + "/admin/auths/" + strconv.FormatInt(source.ID, 10))
+}
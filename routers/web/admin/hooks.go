@@ -6,9 +6,11 @@ package admin
 import (
 	"net/http"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/webhook"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	webhook_service "code.gitea.io/gitea/services/webhook"
 )
@@ -63,9 +65,13 @@ func DefaultOrSystemWebhooks(ctx *context.Context) {
 
 // DeleteDefaultOrSystemWebhook handler to delete an admin-defined system or default webhook
 func DeleteDefaultOrSystemWebhook(ctx *context.Context) {
-	if err := webhook.DeleteDefaultSystemWebhook(ctx, ctx.FormInt64("id")); err != nil {
+	w, err := webhook.GetSystemOrDefaultWebhook(ctx, ctx.FormInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetSystemOrDefaultWebhook: " + err.Error())
+	} else if err := webhook.DeleteDefaultSystemWebhook(ctx, w.ID); err != nil {
 		ctx.Flash.Error("DeleteDefaultWebhook: " + err.Error())
 	} else {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookDelete, audit_service.WebhookTarget(ctx, w), audit_service.WebhookState(w), nil)
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
@@ -21,8 +21,9 @@ import (
 )
 
 const (
-	tplRepos          base.TplName = "admin/repo/list"
-	tplUnadoptedRepos base.TplName = "admin/repo/unadopted"
+	tplRepos           base.TplName = "admin/repo/list"
+	tplUnadoptedRepos  base.TplName = "admin/repo/unadopted"
+	tplRepoMaintenance base.TplName = "admin/repo/maintenance"
 )
 
 // Repos show all the repositories
@@ -161,3 +162,44 @@ func AdoptOrDeleteRepository(ctx *context.Context) {
 	}
 	ctx.Redirect(setting.AppSubURL + "/admin/repos/unadopted?search=true&q=" + url.QueryEscape(q) + "&page=" + url.QueryEscape(page))
 }
+
+// RepoMaintenance shows the state of the git maintenance of the repositories
+func RepoMaintenance(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.repos.maintenance")
+	ctx.Data["PageIsAdminRepositories"] = true
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	onlyFailed := ctx.FormBool("failed")
+
+	maintenances, count, err := db.FindAndCount[repo_model.RepoMaintenance](ctx, repo_model.FindRepoMaintenanceOptions{
+		ListOptions: db.ListOptions{
+			PageSize: setting.UI.Admin.RepoPagingNum,
+			Page:     page,
+		},
+		OnlyFailed: onlyFailed,
+	})
+	if err != nil {
+		ctx.ServerError("FindRepoMaintenance", err)
+		return
+	}
+	for _, m := range maintenances {
+		if err := m.LoadRepo(ctx); err != nil && !repo_model.IsErrRepoNotExist(err) {
+			ctx.ServerError("LoadRepo", err)
+			return
+		}
+	}
+
+	ctx.Data["Maintenances"] = maintenances
+	ctx.Data["Total"] = count
+	ctx.Data["OnlyFailed"] = onlyFailed
+	pager := context.NewPagination(int(count), setting.UI.Admin.RepoPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	if onlyFailed {
+		pager.AddParamString("failed", "true")
+	}
+	ctx.Data["Page"] = pager
+	ctx.HTML(http.StatusOK, tplRepoMaintenance)
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	org_model "code.gitea.io/gitea/models/organization"
@@ -26,6 +27,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/web/explore"
 	user_setting "code.gitea.io/gitea/routers/web/user/setting"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 	"code.gitea.io/gitea/services/mailer"
@@ -208,6 +210,7 @@ func NewUserPost(ctx *context.Context) {
 	}
 
 	log.Trace("Account created by admin (%s): %s", ctx.Doer.Name, u.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserCreate, audit_service.InstanceTarget("user", u.ID, u.Name), nil, audit_service.UserState(u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -340,6 +343,7 @@ func EditUserPost(ctx *context.Context) {
 	if ctx.Written() {
 		return
 	}
+	before := audit_service.UserState(u)
 
 	form := web.GetForm(ctx).(*forms.AdminEditUserForm)
 	if ctx.HasError() {
@@ -481,6 +485,11 @@ func EditUserPost(ctx *context.Context) {
 		}
 	}
 
+	after := audit_service.UserState(u)
+	after["password_changed"] = form.Password != ""
+	after["two_factor_reset"] = form.Reset2FA
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserUpdate, audit_service.InstanceTarget("user", u.ID, u.Name), before, after)
+
 	ctx.Flash.Success(ctx.Tr("admin.users.update_profile_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users/" + url.PathEscape(ctx.Params(":userid")))
 }
@@ -520,6 +529,7 @@ func DeleteUser(ctx *context.Context) {
 		return
 	}
 	log.Trace("Account deleted by admin (%s): %s", ctx.Doer.Name, u.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAdminUserDelete, audit_service.InstanceTarget("user", u.ID, u.Name), audit_service.UserState(u), nil)
 
 	ctx.Flash.Success(ctx.Tr("admin.users.deletion_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users")
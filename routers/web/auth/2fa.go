@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/externalaccount"
 	"code.gitea.io/gitea/services/forms"
@@ -36,9 +37,33 @@ func TwoFactor(ctx *context.Context) {
 		return
 	}
 
+	webAuthnRequired, err := isWebAuthnRequired(ctx, ctx.Session.Get("twofaUid").(int64))
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if webAuthnRequired {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
+		return
+	}
+
 	ctx.HTML(http.StatusOK, tplTwofa)
 }
 
+// isWebAuthnRequired returns whether the user has to use their security keys instead of a two-factor code, because
+// phishing-resistant MFA is required from them. The scratch code stays available to recover the account.
+func isWebAuthnRequired(ctx *context.Context, uid int64) (bool, error) {
+	u, err := user_model.GetUserByID(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	required, err := auth_service.RequiresPhishingResistantMFA(ctx, u)
+	if err != nil || !required {
+		return false, err
+	}
+	return auth.HasWebAuthnRegistrationsByUID(ctx, uid)
+}
+
 // TwoFactorPost validates a user's two-factor authentication token.
 func TwoFactorPost(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.TwoFactorAuthForm)
@@ -52,6 +77,16 @@ func TwoFactorPost(ctx *context.Context) {
 	}
 
 	id := idSess.(int64)
+	webAuthnRequired, err := isWebAuthnRequired(ctx, id)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	if webAuthnRequired {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
+		return
+	}
+
 	twofa, err := auth.GetTwoFactorByUID(ctx, id)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
@@ -123,6 +158,7 @@ func TwoFactorScratchPost(ctx *context.Context) {
 		return
 	}
 
+	// the scratch code recovers the account even when phishing-resistant MFA is required
 	id := idSess.(int64)
 	twofa, err := auth.GetTwoFactorByUID(ctx, id)
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"strings"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
@@ -27,8 +28,10 @@ import (
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/modules/web/middleware"
+	audit_service "code.gitea.io/gitea/services/audit"
 	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/auth/source/saml"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/externalaccount"
 	"code.gitea.io/gitea/services/forms"
@@ -186,6 +189,12 @@ func SignIn(ctx *context.Context) {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
+	samlProviders, err := saml.GetSAMLProviders(ctx, optional.Some(true))
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	ctx.Data["SAMLProviders"] = samlProviders
 	ctx.Data["OAuth2Providers"] = oauth2Providers
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
@@ -209,6 +218,12 @@ func SignInPost(ctx *context.Context) {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
+	samlProviders, err := saml.GetSAMLProviders(ctx, optional.Some(true))
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	ctx.Data["SAMLProviders"] = samlProviders
 	ctx.Data["OAuth2Providers"] = oauth2Providers
 	ctx.Data["Title"] = ctx.Tr("sign_in")
 	ctx.Data["SignInLink"] = setting.AppSubURL + "/user/login"
@@ -237,11 +252,13 @@ func SignInPost(ctx *context.Context) {
 		if errors.Is(err, util.ErrNotExist) || errors.Is(err, util.ErrInvalidArgument) {
 			ctx.RenderWithErr(ctx.Tr("form.username_password_incorrect"), tplSignIn, &form)
 			log.Info("Failed authentication attempt for %s from %s: %v", form.UserName, ctx.RemoteAddr(), err)
+			audit_service.Record(ctx, nil, audit_model.ActionUserSignInFailed, audit_service.InstanceTarget("user", 0, form.UserName), nil, nil)
 		} else if user_model.IsErrEmailAlreadyUsed(err) {
 			ctx.RenderWithErr(ctx.Tr("form.email_been_used"), tplSignIn, &form)
 			log.Info("Failed authentication attempt for %s from %s: %v", form.UserName, ctx.RemoteAddr(), err)
 		} else if user_model.IsErrUserProhibitLogin(err) {
 			log.Info("Failed authentication attempt for %s from %s: %v", form.UserName, ctx.RemoteAddr(), err)
+			audit_service.Record(ctx, nil, audit_model.ActionUserSignInFailed, audit_service.InstanceTarget("user", 0, form.UserName), nil, nil)
 			ctx.Data["Title"] = ctx.Tr("auth.prohibit_login")
 			ctx.HTML(http.StatusOK, "user/auth/prohibit_login")
 		} else if user_model.IsErrUserInactive(err) {
@@ -259,6 +276,12 @@ func SignInPost(ctx *context.Context) {
 		return
 	}
 
+	// Remember the source the user signed in with, organizations may require a specific one
+	if err := ctx.Session.Set(auth_service.SessionSignInSourceIDKey, source.ID); err != nil {
+		ctx.ServerError("UserSignIn: Unable to set session", err)
+		return
+	}
+
 	// Now handle 2FA:
 
 	// First of all if the source can skip local two fa we're done
@@ -372,6 +395,8 @@ func handleSignInFull(ctx *context.Context, u *user_model.User, remember, obeyRe
 		return setting.AppSubURL + "/"
 	}
 
+	audit_service.Record(ctx, u, audit_model.ActionUserSignIn, audit_service.OwnerTarget(u, "user", u.ID, u.Name), nil, nil)
+
 	redirectTo := ctx.GetSiteCookie("redirect_to")
 	if redirectTo != "" {
 		middleware.DeleteRedirectToCookie(ctx.Resp)
@@ -412,6 +437,7 @@ func SignOut(ctx *context.Context) {
 			Name: "logout",
 			Data: ctx.Session.ID(),
 		})
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionUserSignOut, audit_service.OwnerTarget(ctx.Doer, "user", ctx.Doer.ID, ctx.Doer.Name), nil, nil)
 	}
 	HandleSignOut(ctx)
 	ctx.JSONRedirect(setting.AppSubURL + "/")
@@ -428,6 +454,12 @@ func SignUp(ctx *context.Context) {
 		ctx.ServerError("UserSignUp", err)
 		return
 	}
+	samlProviders, err := saml.GetSAMLProviders(ctx, optional.Some(true))
+	if err != nil {
+		ctx.ServerError("UserSignUp", err)
+		return
+	}
+	ctx.Data["SAMLProviders"] = samlProviders
 
 	ctx.Data["OAuth2Providers"] = oauth2Providers
 	context.SetCaptchaData(ctx)
@@ -457,6 +489,12 @@ func SignUpPost(ctx *context.Context) {
 		ctx.ServerError("UserSignUp", err)
 		return
 	}
+	samlProviders, err := saml.GetSAMLProviders(ctx, optional.Some(true))
+	if err != nil {
+		ctx.ServerError("UserSignUp", err)
+		return
+	}
+	ctx.Data["SAMLProviders"] = samlProviders
 
 	ctx.Data["OAuth2Providers"] = oauth2Providers
 	context.SetCaptchaData(ctx)
@@ -876,6 +914,22 @@ func updateSession(ctx *context.Context, deletes []string, updates map[string]an
 			return fmt.Errorf("set %v in session[%s]: %w", k, sessID, err)
 		}
 	}
+	if uid, ok := updates["uid"].(int64); ok {
+		generation, err := auth_service.GetSessionsGeneration(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("get the generation of the sessions of user[%d]: %w", uid, err)
+		}
+		if err := sess.Set(auth_service.SessionGenerationKey, generation); err != nil {
+			return fmt.Errorf("set %v in session[%s]: %w", auth_service.SessionGenerationKey, sessID, err)
+		}
+		u, err := user_model.GetUserByID(ctx, uid)
+		if err != nil {
+			return fmt.Errorf("get user[%d]: %w", uid, err)
+		}
+		if err := auth_service.SetPhishingResistantMFASession(ctx, sess, u); err != nil {
+			return fmt.Errorf("set %v in session[%s]: %w", auth_service.MustEnrollPhishingResistantMFASessionKey, sessID, err)
+		}
+	}
 	if err := sess.Release(); err != nil {
 		return fmt.Errorf("store session[%s]: %w", sessID, err)
 	}
@@ -17,6 +17,7 @@ import (
 	"sort"
 	"strings"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/auth"
 	org_model "code.gitea.io/gitea/models/organization"
 	user_model "code.gitea.io/gitea/models/user"
@@ -31,9 +32,11 @@ import (
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/modules/web/middleware"
+	audit_service "code.gitea.io/gitea/services/audit"
 	auth_service "code.gitea.io/gitea/services/auth"
 	source_service "code.gitea.io/gitea/services/auth/source"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/auth/source/saml"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/externalaccount"
 	"code.gitea.io/gitea/services/forms"
@@ -108,6 +111,14 @@ const (
 	AccessTokenErrorCodeUnsupportedGrantType = "unsupported_grant_type"
 	// AccessTokenErrorCodeInvalidScope represents an error code specified in RFC 6749
 	AccessTokenErrorCodeInvalidScope = "invalid_scope"
+	// AccessTokenErrorCodeAuthorizationPending represents an error code specified in RFC 8628
+	AccessTokenErrorCodeAuthorizationPending = "authorization_pending"
+	// AccessTokenErrorCodeSlowDown represents an error code specified in RFC 8628
+	AccessTokenErrorCodeSlowDown = "slow_down"
+	// AccessTokenErrorCodeAccessDenied represents an error code specified in RFC 8628
+	AccessTokenErrorCodeAccessDenied = "access_denied"
+	// AccessTokenErrorCodeExpiredToken represents an error code specified in RFC 8628
+	AccessTokenErrorCodeExpiredToken = "expired_token"
 )
 
 // AccessTokenError represents an error response specified in RFC 6749
@@ -148,7 +159,7 @@ type AccessTokenResponse struct {
 	AccessToken  string    `json:"access_token"`
 	TokenType    TokenType `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
-	RefreshToken string    `json:"refresh_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
 	IDToken      string    `json:"id_token,omitempty"`
 }
 
@@ -163,19 +174,9 @@ func newAccessTokenResponse(ctx go_context.Context, grant *auth.OAuth2Grant, ser
 	}
 	// generate access token to access the API
 	expirationDate := timeutil.TimeStampNow().Add(setting.OAuth2.AccessTokenExpirationTime)
-	accessToken := &oauth2.Token{
-		GrantID: grant.ID,
-		Type:    oauth2.TypeAccessToken,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationDate.AsTime()),
-		},
-	}
-	signedAccessToken, err := accessToken.SignToken(serverKey)
-	if err != nil {
-		return nil, &AccessTokenError{
-			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-			ErrorDescription: "cannot sign token",
-		}
+	signedAccessToken, tokenErr := newSignedAccessToken(grant, expirationDate, serverKey)
+	if tokenErr != nil {
+		return nil, tokenErr
 	}
 
 	// generate refresh token to request an access token after it expired later
@@ -273,6 +274,24 @@ func newAccessTokenResponse(ctx go_context.Context, grant *auth.OAuth2Grant, ser
 	}, nil
 }
 
+func newSignedAccessToken(grant *auth.OAuth2Grant, expirationDate timeutil.TimeStamp, serverKey oauth2.JWTSigningKey) (string, *AccessTokenError) {
+	accessToken := &oauth2.Token{
+		GrantID: grant.ID,
+		Type:    oauth2.TypeAccessToken,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expirationDate.AsTime()),
+		},
+	}
+	signedAccessToken, err := accessToken.SignToken(serverKey)
+	if err != nil {
+		return "", &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot sign token",
+		}
+	}
+	return signedAccessToken, nil
+}
+
 type userInfoResponse struct {
 	Sub      string   `json:"sub"`
 	Name     string   `json:"name"`
@@ -639,47 +658,54 @@ func OIDCKeys(ctx *context.Context) {
 	}
 }
 
+// parseClientAuthorizationHeader fills the client ID and secret by the Authorization header if there is no client ID
+// or secret in the request body, and ensures that the provided fields match the Authorization header
+func parseClientAuthorizationHeader(ctx *context.Context, clientID, clientSecret *string) *AccessTokenError {
+	if *clientID != "" && *clientSecret != "" {
+		return nil
+	}
+	authHeader := ctx.Req.Header.Get("Authorization")
+	authContent := strings.SplitN(authHeader, " ", 2)
+	if len(authContent) != 2 || authContent[0] != "Basic" {
+		return nil
+	}
+	payload, err := base64.StdEncoding.DecodeString(authContent[1])
+	if err != nil {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	pair := strings.SplitN(string(payload), ":", 2)
+	if len(pair) != 2 {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot parse basic auth header",
+		}
+	}
+	if *clientID != "" && *clientID != pair[0] {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "client_id in request body inconsistent with Authorization header",
+		}
+	}
+	*clientID = pair[0]
+	if *clientSecret != "" && *clientSecret != pair[1] {
+		return &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "client_secret in request body inconsistent with Authorization header",
+		}
+	}
+	*clientSecret = pair[1]
+	return nil
+}
+
 // AccessTokenOAuth manages all access token requests by the client
 func AccessTokenOAuth(ctx *context.Context) {
 	form := *web.GetForm(ctx).(*forms.AccessTokenForm)
-	// if there is no ClientID or ClientSecret in the request body, fill these fields by the Authorization header and ensure the provided field matches the Authorization header
-	if form.ClientID == "" || form.ClientSecret == "" {
-		authHeader := ctx.Req.Header.Get("Authorization")
-		authContent := strings.SplitN(authHeader, " ", 2)
-		if len(authContent) == 2 && authContent[0] == "Basic" {
-			payload, err := base64.StdEncoding.DecodeString(authContent[1])
-			if err != nil {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			pair := strings.SplitN(string(payload), ":", 2)
-			if len(pair) != 2 {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "cannot parse basic auth header",
-				})
-				return
-			}
-			if form.ClientID != "" && form.ClientID != pair[0] {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "client_id in request body inconsistent with Authorization header",
-				})
-				return
-			}
-			form.ClientID = pair[0]
-			if form.ClientSecret != "" && form.ClientSecret != pair[1] {
-				handleAccessTokenError(ctx, AccessTokenError{
-					ErrorCode:        AccessTokenErrorCodeInvalidRequest,
-					ErrorDescription: "client_secret in request body inconsistent with Authorization header",
-				})
-				return
-			}
-			form.ClientSecret = pair[1]
-		}
+	if acErr := parseClientAuthorizationHeader(ctx, &form.ClientID, &form.ClientSecret); acErr != nil {
+		handleAccessTokenError(ctx, *acErr)
+		return
 	}
 
 	serverKey := oauth2.DefaultSigningKey
@@ -701,10 +727,14 @@ func AccessTokenOAuth(ctx *context.Context) {
 		handleRefreshToken(ctx, form, serverKey, clientKey)
 	case "authorization_code":
 		handleAuthorizationCode(ctx, form, serverKey, clientKey)
+	case grantTypeDeviceCode:
+		handleDeviceCode(ctx, form, serverKey, clientKey)
+	case "client_credentials":
+		handleClientCredentials(ctx, form, serverKey)
 	default:
 		handleAccessTokenError(ctx, AccessTokenError{
 			ErrorCode:        AccessTokenErrorCodeUnsupportedGrantType,
-			ErrorDescription: "Only refresh_token or authorization_code grant type is supported",
+			ErrorDescription: "Only refresh_token, authorization_code, " + grantTypeDeviceCode + " or client_credentials grant type is supported",
 		})
 	}
 }
@@ -836,6 +866,102 @@ func handleAuthorizationCode(ctx *context.Context, form forms.AccessTokenForm, s
 	ctx.JSON(http.StatusOK, resp)
 }
 
+func handleClientCredentials(ctx *context.Context, form forms.AccessTokenForm, serverKey oauth2.JWTSigningKey) {
+	app, err := auth.GetOAuth2ApplicationByClientID(ctx, form.ClientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: %q", form.ClientID),
+		})
+		return
+	}
+	// "The client credentials grant type MUST only be used by confidential clients"
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+	if !app.ConfidentialClient {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "the client credentials grant requires a confidential client",
+		})
+		return
+	}
+	if !app.ValidateClientSecret([]byte(form.ClientSecret)) {
+		errorDescription := "invalid client secret"
+		if form.ClientSecret == "" {
+			errorDescription = "invalid empty client secret"
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: errorDescription,
+		})
+		return
+	}
+	if app.ClientCredentialsUserID == 0 {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnauthorizedClient,
+			ErrorDescription: "the client credentials grant is not enabled for this client",
+		})
+		return
+	}
+	user, err := user_model.GetUserByID(ctx, app.ClientCredentialsUserID)
+	if err != nil && !user_model.IsErrUserNotExist(err) {
+		log.Error("Error loading user: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "server error",
+		})
+		return
+	}
+	if user == nil || !user.IsActive || user.ProhibitLogin {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "the user of the client cannot sign in",
+		})
+		return
+	}
+	// the tokens act on behalf of a user who did not consent to anything, so only the scopes which restrict
+	// them are meaningful
+	scope := strings.Join(strings.Fields(form.Scope), " ")
+	for _, item := range strings.Fields(scope) {
+		if !auth.IsAccessTokenScope(item) {
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidScope,
+				ErrorDescription: fmt.Sprintf("unsupported scope: %q", item),
+			})
+			return
+		}
+	}
+
+	grant, err := app.GetGrantByUserID(ctx, user.ID)
+	if err == nil {
+		if grant == nil {
+			grant, err = app.CreateGrant(ctx, user.ID, scope)
+		} else if grant.Scope != scope {
+			err = grant.SetScope(ctx, scope)
+		}
+	}
+	if err != nil {
+		log.Error("Error creating the grant of the client credentials: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot create grant for user",
+		})
+		return
+	}
+
+	// "A refresh token SHOULD NOT be included"
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-4.4.3
+	signedAccessToken, tokenErr := newSignedAccessToken(grant, timeutil.TimeStampNow().Add(setting.OAuth2.AccessTokenExpirationTime), serverKey)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, &AccessTokenResponse{
+		AccessToken: signedAccessToken,
+		TokenType:   TokenTypeBearer,
+		ExpiresIn:   setting.OAuth2.AccessTokenExpirationTime,
+	})
+}
+
 func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
 	ctx.JSON(http.StatusBadRequest, acErr)
 }
@@ -969,11 +1095,16 @@ func SignInOAuthCallback(ctx *context.Context) {
 		return
 	}
 
+	handleExternalSignIn(ctx, authSource, u, gothUser)
+}
+
+// handleExternalSignIn signs in the user of an OAuth2 or SAML source, the user is nil if the external account is
+// not linked yet, then it is either linked to the signed in user, registered or linked through the link account page
+func handleExternalSignIn(ctx *context.Context, authSource *auth.Source, u *user_model.User, gothUser goth.User) {
 	if u == nil {
 		if ctx.Doer != nil {
 			// attach user to already logged in user
-			err = externalaccount.LinkAccountToUser(ctx, ctx.Doer, gothUser)
-			if err != nil {
+			if err := externalaccount.LinkAccountToUser(ctx, ctx.Doer, gothUser); err != nil {
 				ctx.ServerError("UserLinkAccount", err)
 				return
 			}
@@ -987,8 +1118,7 @@ func SignInOAuthCallback(ctx *context.Context) {
 				if authSource.IsOAuth2() && authSource.Cfg.(*oauth2.Source).Provider == "openidConnect" {
 					log.Error("You may need to change the 'OPENID_CONNECT_SCOPES' setting to request all required fields")
 				}
-				err = fmt.Errorf("OAuth2 Provider %s returned empty or missing field: UserID", authSource.Name)
-				ctx.ServerError("CreateUser", err)
+				ctx.ServerError("CreateUser", fmt.Errorf("OAuth2 Provider %s returned empty or missing field: UserID", authSource.Name))
 				return
 			}
 			var missingFields []string
@@ -1014,7 +1144,7 @@ func SignInOAuthCallback(ctx *context.Context) {
 				Name:        uname,
 				FullName:    gothUser.Name,
 				Email:       gothUser.Email,
-				LoginType:   auth.OAuth2,
+				LoginType:   authSource.Type,
 				LoginSource: authSource.ID,
 				LoginName:   gothUser.UserID,
 			}
@@ -1023,7 +1153,7 @@ func SignInOAuthCallback(ctx *context.Context) {
 				IsActive: optional.Some(!setting.OAuth2Client.RegisterEmailConfirm && !setting.Service.RegisterManualConfirm),
 			}
 
-			source := authSource.Cfg.(*oauth2.Source)
+			source := getExternalSourceSettings(authSource)
 
 			isAdmin, isRestricted := getUserAdminAndRestrictedFromGroupClaims(source, &gothUser)
 			u.IsAdmin = isAdmin.ValueOrDefault(false)
@@ -1065,7 +1195,42 @@ func claimValueToStringSet(claimValue any) container.Set[string] {
 	return container.SetOf(groups...)
 }
 
-func syncGroupsToTeams(ctx *context.Context, source *oauth2.Source, gothUser *goth.User, u *user_model.User) error {
+// externalSourceSettings are the settings shared by the sources which sign in users through an external identity
+// provider, that is OAuth2 and SAML
+type externalSourceSettings struct {
+	SkipLocalTwoFA      bool
+	GroupClaimName      string
+	AdminGroup          string
+	RestrictedGroup     string
+	GroupTeamMap        string
+	GroupTeamMapRemoval bool
+}
+
+func getExternalSourceSettings(source *auth.Source) *externalSourceSettings {
+	switch cfg := source.Cfg.(type) {
+	case *oauth2.Source:
+		return &externalSourceSettings{
+			SkipLocalTwoFA:      cfg.SkipLocalTwoFA,
+			GroupClaimName:      cfg.GroupClaimName,
+			AdminGroup:          cfg.AdminGroup,
+			RestrictedGroup:     cfg.RestrictedGroup,
+			GroupTeamMap:        cfg.GroupTeamMap,
+			GroupTeamMapRemoval: cfg.GroupTeamMapRemoval,
+		}
+	case *saml.Source:
+		return &externalSourceSettings{
+			SkipLocalTwoFA:      cfg.SkipLocalTwoFA,
+			GroupClaimName:      cfg.GroupsAttribute,
+			AdminGroup:          cfg.AdminGroup,
+			RestrictedGroup:     cfg.RestrictedGroup,
+			GroupTeamMap:        cfg.GroupTeamMap,
+			GroupTeamMapRemoval: cfg.GroupTeamMapRemoval,
+		}
+	}
+	return &externalSourceSettings{}
+}
+
+func syncGroupsToTeams(ctx *context.Context, source *externalSourceSettings, gothUser *goth.User, u *user_model.User) error {
 	if source.GroupTeamMap != "" || source.GroupTeamMapRemoval {
 		groupTeamMapping, err := auth_module.UnmarshalGroupTeamMapping(source.GroupTeamMap)
 		if err != nil {
@@ -1082,7 +1247,7 @@ func syncGroupsToTeams(ctx *context.Context, source *oauth2.Source, gothUser *go
 	return nil
 }
 
-func getClaimedGroups(source *oauth2.Source, gothUser *goth.User) container.Set[string] {
+func getClaimedGroups(source *externalSourceSettings, gothUser *goth.User) container.Set[string] {
 	groupClaims, has := gothUser.RawData[source.GroupClaimName]
 	if !has {
 		return nil
@@ -1091,7 +1256,7 @@ func getClaimedGroups(source *oauth2.Source, gothUser *goth.User) container.Set[
 	return claimValueToStringSet(groupClaims)
 }
 
-func getUserAdminAndRestrictedFromGroupClaims(source *oauth2.Source, gothUser *goth.User) (isAdmin, isRestricted optional.Option[bool]) {
+func getUserAdminAndRestrictedFromGroupClaims(source *externalSourceSettings, gothUser *goth.User) (isAdmin, isRestricted optional.Option[bool]) {
 	groups := getClaimedGroups(source, gothUser)
 
 	if source.AdminGroup != "" {
@@ -1135,8 +1300,10 @@ func updateAvatarIfNeed(ctx *context.Context, url string, u *user_model.User) {
 func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model.User, gothUser goth.User) {
 	updateAvatarIfNeed(ctx, gothUser.AvatarURL, u)
 
+	externalSource := getExternalSourceSettings(source)
+
 	needs2FA := false
-	if !source.Cfg.(*oauth2.Source).SkipLocalTwoFA {
+	if !externalSource.SkipLocalTwoFA {
 		_, err := auth.GetTwoFactorByUID(ctx, u.ID)
 		if err != nil && !auth.IsErrTwoFactorNotEnrolled(err) {
 			ctx.ServerError("UserSignIn", err)
@@ -1145,20 +1312,20 @@ func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model
 		needs2FA = err == nil
 	}
 
-	oauth2Source := source.Cfg.(*oauth2.Source)
-	groupTeamMapping, err := auth_module.UnmarshalGroupTeamMapping(oauth2Source.GroupTeamMap)
+	groupTeamMapping, err := auth_module.UnmarshalGroupTeamMapping(externalSource.GroupTeamMap)
 	if err != nil {
 		ctx.ServerError("UnmarshalGroupTeamMapping", err)
 		return
 	}
 
-	groups := getClaimedGroups(oauth2Source, &gothUser)
+	groups := getClaimedGroups(externalSource, &gothUser)
 
 	// If this user is enrolled in 2FA and this source doesn't override it,
 	// we can't sign the user in just yet. Instead, redirect them to the 2FA authentication page.
 	if !needs2FA {
 		if err := updateSession(ctx, nil, map[string]any{
-			"uid": u.ID,
+			"uid":                                 u.ID,
+			auth_service.SessionSignInSourceIDKey: source.ID,
 		}); err != nil {
 			ctx.ServerError("updateSession", err)
 			return
@@ -1167,17 +1334,19 @@ func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model
 		// Clear whatever CSRF cookie has right now, force to generate a new one
 		ctx.Csrf.DeleteCookie(ctx)
 
+		audit_service.Record(ctx, u, audit_model.ActionUserSignIn, audit_service.OwnerTarget(u, "auth_source", source.ID, source.Name), nil, nil)
+
 		opts := &user_service.UpdateOptions{
 			SetLastLogin: true,
 		}
-		opts.IsAdmin, opts.IsRestricted = getUserAdminAndRestrictedFromGroupClaims(oauth2Source, &gothUser)
+		opts.IsAdmin, opts.IsRestricted = getUserAdminAndRestrictedFromGroupClaims(externalSource, &gothUser)
 		if err := user_service.UpdateUser(ctx, u, opts); err != nil {
 			ctx.ServerError("UpdateUser", err)
 			return
 		}
 
-		if oauth2Source.GroupTeamMap != "" || oauth2Source.GroupTeamMapRemoval {
-			if err := source_service.SyncGroupsToTeams(ctx, u, groups, groupTeamMapping, oauth2Source.GroupTeamMapRemoval); err != nil {
+		if externalSource.GroupTeamMap != "" || externalSource.GroupTeamMapRemoval {
+			if err := source_service.SyncGroupsToTeams(ctx, u, groups, groupTeamMapping, externalSource.GroupTeamMapRemoval); err != nil {
 				ctx.ServerError("SyncGroupsToTeams", err)
 				return
 			}
@@ -1206,7 +1375,7 @@ func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model
 	}
 
 	opts := &user_service.UpdateOptions{}
-	opts.IsAdmin, opts.IsRestricted = getUserAdminAndRestrictedFromGroupClaims(oauth2Source, &gothUser)
+	opts.IsAdmin, opts.IsRestricted = getUserAdminAndRestrictedFromGroupClaims(externalSource, &gothUser)
 	if opts.IsAdmin.Has() || opts.IsRestricted.Has() {
 		if err := user_service.UpdateUser(ctx, u, opts); err != nil {
 			ctx.ServerError("UpdateUser", err)
@@ -1214,8 +1383,8 @@ func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model
 		}
 	}
 
-	if oauth2Source.GroupTeamMap != "" || oauth2Source.GroupTeamMapRemoval {
-		if err := source_service.SyncGroupsToTeams(ctx, u, groups, groupTeamMapping, oauth2Source.GroupTeamMapRemoval); err != nil {
+	if externalSource.GroupTeamMap != "" || externalSource.GroupTeamMapRemoval {
+		if err := source_service.SyncGroupsToTeams(ctx, u, groups, groupTeamMapping, externalSource.GroupTeamMapRemoval); err != nil {
 			ctx.ServerError("SyncGroupsToTeams", err)
 			return
 		}
@@ -1223,8 +1392,9 @@ func handleOAuth2SignIn(ctx *context.Context, source *auth.Source, u *user_model
 
 	if err := updateSession(ctx, nil, map[string]any{
 		// User needs to use 2FA, save data and redirect to 2FA page.
-		"twofaUid":      u.ID,
-		"twofaRemember": false,
+		"twofaUid":                            u.ID,
+		"twofaRemember":                       false,
+		auth_service.SessionSignInSourceIDKey: source.ID,
 	}); err != nil {
 		ctx.ServerError("updateSession", err)
 		return
@@ -1343,7 +1513,7 @@ func oAuth2FetchUser(ctx *context.Context, authSource *auth.Source, request *htt
 func oAuth2GothUserToUser(ctx go_context.Context, authSource *auth.Source, gothUser goth.User) (*user_model.User, error) {
 	user := &user_model.User{
 		LoginName:   gothUser.UserID,
-		LoginType:   auth.OAuth2,
+		LoginType:   authSource.Type,
 		LoginSource: authSource.ID,
 	}
 
@@ -0,0 +1,305 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	go_context "context"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const tplDeviceAuthorization base.TplName = "user/auth/device"
+
+// grantTypeDeviceCode is the grant type of the device authorization grant
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+const grantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// deviceOpenIDScopes are the OpenID Connect scopes which devices can request besides the access token scopes
+var deviceOpenIDScopes = []string{"openid", "profile", "email", "groups"}
+
+// DeviceAuthorizationResponse represents a successful device authorization response
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceAuthorizationOAuth manages the device authorization requests of the clients
+func DeviceAuthorizationOAuth(ctx *context.Context) {
+	form := *web.GetForm(ctx).(*forms.DeviceAuthorizationForm)
+	if acErr := parseClientAuthorizationHeader(ctx, &form.ClientID, &form.ClientSecret); acErr != nil {
+		handleAccessTokenError(ctx, *acErr)
+		return
+	}
+
+	app, err := auth.GetOAuth2ApplicationByClientID(ctx, form.ClientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: %q", form.ClientID),
+		})
+		return
+	}
+	if app.ConfidentialClient && !app.ValidateClientSecret([]byte(form.ClientSecret)) {
+		errorDescription := "invalid client secret"
+		if form.ClientSecret == "" {
+			errorDescription = "invalid empty client secret"
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: errorDescription,
+		})
+		return
+	}
+
+	scope := strings.Join(strings.Fields(form.Scope), " ")
+	for _, item := range strings.Fields(scope) {
+		if !auth.IsAccessTokenScope(item) && !slices.Contains(deviceOpenIDScopes, item) {
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidScope,
+				ErrorDescription: fmt.Sprintf("unsupported scope: %q", item),
+			})
+			return
+		}
+	}
+
+	d, err := auth.CreateOAuth2DeviceAuthorization(ctx, app, scope)
+	if err != nil {
+		log.Error("Error creating the device authorization: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "server error",
+		})
+		return
+	}
+
+	verificationURI := setting.AppURL + "login/device"
+	ctx.JSON(http.StatusOK, &DeviceAuthorizationResponse{
+		DeviceCode:              d.DeviceCode,
+		UserCode:                d.FormattedUserCode(),
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?user_code=" + url.QueryEscape(d.FormattedUserCode()),
+		ExpiresIn:               setting.OAuth2.DeviceCodeExpirationTime,
+		Interval:                d.PollingInterval,
+	})
+}
+
+func handleDeviceCode(ctx *context.Context, form forms.AccessTokenForm, serverKey, clientKey oauth2.JWTSigningKey) {
+	app, err := auth.GetOAuth2ApplicationByClientID(ctx, form.ClientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: %q", form.ClientID),
+		})
+		return
+	}
+	if app.ConfidentialClient && !app.ValidateClientSecret([]byte(form.ClientSecret)) {
+		errorDescription := "invalid client secret"
+		if form.ClientSecret == "" {
+			errorDescription = "invalid empty client secret"
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: errorDescription,
+		})
+		return
+	}
+
+	d, err := auth.GetOAuth2DeviceAuthorizationByDeviceCode(ctx, form.DeviceCode)
+	if err != nil {
+		log.Error("Error loading the device authorization: %v", err)
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "server error",
+		})
+		return
+	}
+	if d == nil || d.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid device code",
+		})
+		return
+	}
+	if d.IsExpired() {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeExpiredToken,
+			ErrorDescription: "the device code expired",
+		})
+		return
+	}
+
+	switch d.Status {
+	case auth.OAuth2DeviceAuthorizationPending:
+		inInterval, err := d.Poll(ctx)
+		if err != nil {
+			log.Error("Error polling the device authorization: %v", err)
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "server error",
+			})
+			return
+		}
+		if !inInterval {
+			handleAccessTokenError(ctx, AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeSlowDown,
+				ErrorDescription: fmt.Sprintf("the polling interval is %d seconds", d.PollingInterval),
+			})
+			return
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAuthorizationPending,
+			ErrorDescription: "the user did not authorize the device yet",
+		})
+		return
+	case auth.OAuth2DeviceAuthorizationDenied:
+		if _, err := d.Consume(ctx); err != nil {
+			log.Error("Error deleting the device authorization: %v", err)
+		}
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeAccessDenied,
+			ErrorDescription: "the user denied the authorization",
+		})
+		return
+	}
+
+	// the device code can only be exchanged once
+	consumed, err := d.Consume(ctx)
+	if err != nil || !consumed {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid device code",
+		})
+		return
+	}
+	grant, err := app.GetGrantByUserID(ctx, d.UserID)
+	if err != nil || grant == nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "grant does not exist",
+		})
+		return
+	}
+	resp, tokenErr := newAccessTokenResponse(ctx, grant, serverKey, clientKey)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// loadDeviceAuthorization loads the pending device authorization request of the user code and its application
+func loadDeviceAuthorization(ctx *context.Context, userCode string) (*auth.OAuth2DeviceAuthorization, *auth.OAuth2Application) {
+	d, err := auth.GetPendingOAuth2DeviceAuthorizationByUserCode(ctx, userCode)
+	if err != nil {
+		ctx.ServerError("GetPendingOAuth2DeviceAuthorizationByUserCode", err)
+		return nil, nil
+	}
+	if d == nil {
+		ctx.Data["UserCode"] = userCode
+		ctx.Flash.Error(ctx.Tr("auth.device_code_invalid"), true)
+		ctx.HTML(http.StatusOK, tplDeviceAuthorization)
+		return nil, nil
+	}
+	app, err := auth.GetOAuth2ApplicationByID(ctx, d.ApplicationID)
+	if err != nil {
+		ctx.ServerError("GetOAuth2ApplicationByID", err)
+		return nil, nil
+	}
+	return d, app
+}
+
+// DeviceOAuth shows the page where users enter the user codes of the devices to authorize
+func DeviceOAuth(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("auth.device_title")
+	userCode := ctx.FormTrim("user_code")
+	if userCode == "" {
+		ctx.HTML(http.StatusOK, tplDeviceAuthorization)
+		return
+	}
+	d, app := loadDeviceAuthorization(ctx, userCode)
+	if d == nil {
+		return
+	}
+
+	ctx.Data["Application"] = app
+	ctx.Data["DeviceAuthorization"] = d
+	if app.UID != 0 {
+		user, err := user_model.GetUserByID(ctx, app.UID)
+		if err != nil {
+			ctx.ServerError("GetUserByID", err)
+			return
+		}
+		ctx.Data["ApplicationCreatorLinkHTML"] = template.HTML(fmt.Sprintf(`<a href="%s">@%s</a>`, html.EscapeString(user.HomeLink()), html.EscapeString(user.Name)))
+	} else {
+		ctx.Data["ApplicationCreatorLinkHTML"] = template.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(setting.AppSubURL+"/"), html.EscapeString(setting.AppName)))
+	}
+	ctx.HTML(http.StatusOK, tplDeviceAuthorization)
+}
+
+// DeviceOAuthPost approves or denies the authorization of a device
+func DeviceOAuthPost(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("auth.device_title")
+	form := web.GetForm(ctx).(*forms.GrantDeviceForm)
+	d, app := loadDeviceAuthorization(ctx, form.UserCode)
+	if d == nil {
+		return
+	}
+
+	status := auth.OAuth2DeviceAuthorizationDenied
+	if form.Granted {
+		status = auth.OAuth2DeviceAuthorizationApproved
+	}
+	doerID := ctx.Doer.ID
+	if err := db.WithTx(ctx, func(ctx go_context.Context) error {
+		updated, err := d.SetStatus(ctx, status, doerID)
+		if err != nil || !updated || !form.Granted {
+			return err
+		}
+		grant, err := app.GetGrantByUserID(ctx, doerID)
+		if err != nil {
+			return err
+		}
+		if grant == nil {
+			_, err = app.CreateGrant(ctx, doerID, d.Scope)
+			return err
+		}
+		// the user consented to the scope requested by the device in addition to the one of the existing grant
+		if scope := grant.MergedScope(d.Scope); scope != grant.Scope {
+			return grant.SetScope(ctx, scope)
+		}
+		return nil
+	}); err != nil {
+		ctx.ServerError("SetStatus", err)
+		return
+	}
+
+	if form.Granted {
+		ctx.Flash.Success(ctx.Tr("auth.device_authorized", app.Name), true)
+	} else {
+		ctx.Flash.Info(ctx.Tr("auth.device_denied", app.Name), true)
+	}
+	ctx.Data["Done"] = true
+	ctx.HTML(http.StatusOK, tplDeviceAuthorization)
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web/middleware"
+	"code.gitea.io/gitea/services/auth/source/saml"
+	"code.gitea.io/gitea/services/context"
+)
+
+const tplSAMLPost base.TplName = "user/auth/saml_post"
+
+// samlAssertionTimeout is how long a validated assertion waits in the cache for the callback, in seconds
+const samlAssertionTimeout = 5 * 60
+
+// getActiveSAMLSource loads the active SAML source of the provider in the path
+func getActiveSAMLSource(ctx *context.Context) (*auth.Source, *saml.Source) {
+	authSource, err := auth.GetActiveExternalSourceByName(ctx, ctx.Params(":provider"))
+	if err != nil || !authSource.IsSAML() {
+		ctx.NotFound("GetActiveExternalSourceByName", err)
+		return nil, nil
+	}
+	return authSource, authSource.Cfg.(*saml.Source)
+}
+
+func samlAssertionCacheKey(state string) string {
+	return "saml_assertion:" + state
+}
+
+// SignInSAML sends the user with an AuthnRequest to the identity provider of the SAML source
+func SignInSAML(ctx *context.Context) {
+	authSource, source := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	redirectTo := ctx.FormString("redirect_to")
+	if len(redirectTo) > 0 {
+		middleware.SetRedirectToCookie(ctx.Resp, redirectTo)
+	}
+
+	request, err := source.NewAuthnRequest()
+	if err != nil {
+		ctx.ServerError("NewAuthnRequest", err)
+		return
+	}
+	// the response must be in response to this request, which binds it to the session of the user
+	if err := ctx.Session.Set("samlRequestID", request.ID); err != nil {
+		ctx.ServerError("Session.Set", err)
+		return
+	}
+
+	if request.Binding == saml.BindingHTTPPost {
+		ctx.Data["Title"] = ctx.Tr("sign_in")
+		ctx.Data["SAMLProviderName"] = authSource.Name
+		ctx.Data["SAMLURL"] = request.URL
+		ctx.Data["SAMLRequest"] = request.SAMLRequest
+		ctx.HTML(http.StatusOK, tplSAMLPost)
+		return
+	}
+	ctx.Redirect(request.URL)
+}
+
+// SAMLMetadata serves the metadata of the service provider of the SAML source
+func SAMLMetadata(ctx *context.Context) {
+	_, source := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	metadata, err := source.Metadata()
+	if err != nil {
+		ctx.ServerError("Metadata", err)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/samlmetadata+xml")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(metadata)
+}
+
+// SAMLAssertionConsumerService receives the response of the identity provider. The browser doesn't send the session
+// cookie with this cross-site POST, so the validated assertion is kept in the cache until the browser is redirected
+// to the callback, which checks that the assertion is in response to the request of the session.
+func SAMLAssertionConsumerService(ctx *context.Context) {
+	authSource, source := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	assertion, err := source.ParseResponse(ctx.Req.PostFormValue("SAMLResponse"), time.Now())
+	if err != nil {
+		if saml.IsErrResponseStatus(err) {
+			log.Info("SAML source %s: %v", authSource.Name, err)
+		} else {
+			log.Warn("SAML source %s received an invalid response from %s: %v", authSource.Name, ctx.RemoteAddr(), err)
+		}
+		ctx.Flash.Error(ctx.Tr("auth.saml.signin.error"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	state, err := util.CryptoRandomString(32)
+	if err != nil {
+		ctx.ServerError("CryptoRandomString", err)
+		return
+	}
+	data, err := json.Marshal(assertion)
+	if err != nil {
+		ctx.ServerError("Marshal", err)
+		return
+	}
+	if err := cache.GetCache().Put(samlAssertionCacheKey(state), string(data), samlAssertionTimeout); err != nil {
+		ctx.ServerError("Put", err)
+		return
+	}
+	ctx.Redirect(setting.AppSubURL + "/user/saml/" + url.PathEscape(authSource.Name) + "/callback?state=" + url.QueryEscape(state))
+}
+
+// SignInSAMLCallback signs in the user of the assertion which the assertion consumer service received, the same way
+// as the users of the OAuth2 sources are signed in
+func SignInSAMLCallback(ctx *context.Context) {
+	authSource, source := getActiveSAMLSource(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	key := samlAssertionCacheKey(ctx.FormString("state"))
+	data, _ := cache.GetCache().Get(key).(string)
+	_ = cache.GetCache().Delete(key)
+	requestID, _ := ctx.Session.Get("samlRequestID").(string)
+	_ = ctx.Session.Delete("samlRequestID")
+
+	var assertion saml.Assertion
+	if data == "" || json.Unmarshal([]byte(data), &assertion) != nil || requestID == "" || assertion.InResponseTo != requestID {
+		log.Warn("SAML source %s: the assertion is expired or not in response to the request of the session", authSource.Name)
+		ctx.Flash.Error(ctx.Tr("auth.saml.signin.error"))
+		ctx.Redirect(setting.AppSubURL + "/user/login")
+		return
+	}
+
+	gothUser := source.GothUser(&assertion)
+	u, err := oAuth2GothUserToUser(ctx, authSource, gothUser)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+
+	handleExternalSignIn(ctx, authSource, u, gothUser)
+}
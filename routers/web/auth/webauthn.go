@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/externalaccount"
 
@@ -41,8 +42,13 @@ func WebAuthn(ctx *context.Context) {
 		ctx.ServerError("HasTwoFactorByUID", err)
 		return
 	}
+	webAuthnRequired, err := isWebAuthnRequired(ctx, ctx.Session.Get("twofaUid").(int64))
+	if err != nil {
+		ctx.ServerError("isWebAuthnRequired", err)
+		return
+	}
 
-	ctx.Data["HasTwoFactor"] = hasTwoFactor
+	ctx.Data["HasTwoFactor"] = hasTwoFactor && !webAuthnRequired
 
 	ctx.HTML(http.StatusOK, tplWebAuthn)
 }
@@ -163,3 +169,100 @@ func WebAuthnLoginAssertionPost(ctx *context.Context) {
 
 	ctx.JSONRedirect(redirect)
 }
+
+// PasskeyLoginAssertion submits a WebAuthn challenge for the discoverable credentials to the browser, the user is
+// not known until the response is received
+func PasskeyLoginAssertion(ctx *context.Context) {
+	assertion, sessionData, err := wa.WebAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		ctx.ServerError("webauthn.BeginDiscoverableLogin", err)
+		return
+	}
+
+	if err := ctx.Session.Set("webauthnPasskeyAssertion", sessionData); err != nil {
+		ctx.ServerError("Session.Set", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, assertion)
+}
+
+// PasskeyLoginAssertionPost validates the signature of a passkey and logs its user in, a passkey replaces both the
+// password and the second factor
+func PasskeyLoginAssertionPost(ctx *context.Context) {
+	sessionData, ok := ctx.Session.Get("webauthnPasskeyAssertion").(*webauthn.SessionData)
+	if !ok || sessionData == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in passkey session"))
+		return
+	}
+	defer func() {
+		_ = ctx.Session.Delete("webauthnPasskeyAssertion")
+	}()
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponse(ctx.Req)
+	if err != nil {
+		log.Info("Failed passkey authentication attempt from %s: %v", ctx.RemoteAddr(), err)
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	var user *user_model.User
+	cred, err := wa.WebAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		uid, err := wa.UserIDFromWebAuthnID(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		user, err = user_model.GetUserByID(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		return (*wa.User)(user), nil
+	}, *sessionData, parsedResponse)
+	if err != nil {
+		log.Info("Failed passkey authentication attempt from %s: %v", ctx.RemoteAddr(), err)
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	// Ensure that the credential wasn't cloned by checking if CloneWarning is set.
+	// (This is set if the sign counter is less than the one we have stored.)
+	if cred.Authenticator.CloneWarning {
+		log.Info("Failed authentication attempt for %s from %s: cloned credential", user.Name, ctx.RemoteAddr())
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	dbCred, err := auth.GetWebAuthnCredentialByCredID(ctx, user.ID, cred.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialByCredID", err)
+		return
+	}
+	// Security keys registered for two-factor authentication only cannot replace the password
+	if !dbCred.Passkey {
+		log.Info("Failed authentication attempt for %s from %s: not a passkey", user.Name, ctx.RemoteAddr())
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+	if !user.IsActive || user.ProhibitLogin || user.Type != user_model.UserTypeIndividual {
+		log.Info("Failed authentication attempt for %s from %s: login prohibited", user.Name, ctx.RemoteAddr())
+		ctx.Status(http.StatusForbidden)
+		return
+	}
+
+	dbCred.SignCount = cred.Authenticator.SignCount
+	if err := dbCred.UpdateSignCount(ctx); err != nil {
+		ctx.ServerError("UpdateSignCount", err)
+		return
+	}
+
+	// passkeys are local credentials
+	if err := ctx.Session.Set(auth_service.SessionSignInSourceIDKey, int64(0)); err != nil {
+		ctx.ServerError("Session.Set", err)
+		return
+	}
+
+	redirect := handleSignInFull(ctx, user, ctx.FormBool("remember"), false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
+	}
+	ctx.JSONRedirect(redirect)
+}
@@ -4,6 +4,7 @@
 package explore
 
 import (
+	"errors"
 	"net/http"
 
 	"code.gitea.io/gitea/models/db"
@@ -11,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/services/context"
 )
 
@@ -21,11 +23,6 @@ const (
 
 // Code render explore code page
 func Code(ctx *context.Context) {
-	if !setting.Indexer.RepoIndexerEnabled {
-		ctx.Redirect(setting.AppSubURL + "/explore")
-		return
-	}
-
 	ctx.Data["UsersIsDisabled"] = setting.Service.Explore.DisableUsersPage
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
 	ctx.Data["Title"] = ctx.Tr("explore")
@@ -61,8 +58,8 @@ func Code(ctx *context.Context) {
 		isAdmin = ctx.Doer.IsAdmin
 	}
 
-	// guest user or non-admin user
-	if ctx.Doer == nil || !isAdmin {
+	// guest user or non-admin user, git grep needs the repositories to search in even for admins
+	if ctx.Doer == nil || !isAdmin || !setting.Indexer.RepoIndexerEnabled {
 		repoIDs, err = repo_model.FindUserCodeAccessibleRepoIDs(ctx, ctx.Doer)
 		if err != nil {
 			ctx.ServerError("FindUserCodeAccessibleRepoIDs", err)
@@ -77,7 +74,7 @@ func Code(ctx *context.Context) {
 	)
 
 	if (len(repoIDs) > 0) || isAdmin {
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+		opts := &code_indexer.SearchOptions{
 			RepoIDs:        repoIDs,
 			Keyword:        keyword,
 			IsKeywordFuzzy: isFuzzy,
@@ -86,14 +83,21 @@ func Code(ctx *context.Context) {
 				Page:     page,
 				PageSize: setting.UI.RepoSearchPagingNum,
 			},
-		})
-		if err != nil {
-			if code_indexer.IsAvailable(ctx) {
+		}
+		if setting.Indexer.RepoIndexerEnabled {
+			total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, opts)
+		} else {
+			total, searchResults, searchResultLanguages, err = code_indexer.PerformGrepSearch(ctx, repoIDs, opts)
+		}
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Data["CodeSearchError"] = err.Error()
+		} else if err != nil {
+			if !setting.Indexer.RepoIndexerEnabled || code_indexer.IsAvailable(ctx) {
 				ctx.ServerError("SearchResults", err)
 				return
 			}
 			ctx.Data["CodeIndexerUnavailable"] = true
-		} else {
+		} else if setting.Indexer.RepoIndexerEnabled {
 			ctx.Data["CodeIndexerUnavailable"] = !code_indexer.IsAvailable(ctx)
 		}
 
@@ -132,6 +136,7 @@ func Code(ctx *context.Context) {
 		}
 	}
 
+	ctx.Data["CodeIndexerDisabled"] = !setting.Indexer.RepoIndexerEnabled
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["SearchResultLanguages"] = searchResultLanguages
 
@@ -0,0 +1,137 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package explore
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/base"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+)
+
+const (
+	// tplExploreCommits explore commits page template
+	tplExploreCommits base.TplName = "explore/commits"
+)
+
+// CommitSearchResult is a commit found by the commit indexer and the repository it belongs to
+type CommitSearchResult struct {
+	*commit_indexer.Match
+	Repo *repo_model.Repository
+}
+
+// Summary returns the first line of the commit message
+func (r *CommitSearchResult) Summary() string {
+	summary, _, _ := strings.Cut(r.Message, "\n")
+	return summary
+}
+
+// Commits render explore commits page
+func Commits(ctx *context.Context) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.NotFound("CommitIndexerEnabled", nil)
+		return
+	}
+
+	ctx.Data["UsersIsDisabled"] = setting.Service.Explore.DisableUsersPage
+	ctx.Data["Title"] = ctx.Tr("explore")
+	ctx.Data["PageIsExplore"] = true
+	ctx.Data["PageIsExploreCommits"] = true
+
+	keyword := ctx.FormTrim("q")
+	author := ctx.FormTrim("author")
+	isFuzzy := ctx.FormOptionalBool("fuzzy").ValueOrDefault(true)
+
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["Author"] = author
+	ctx.Data["IsFuzzy"] = isFuzzy
+
+	if keyword == "" && author == "" {
+		ctx.HTML(http.StatusOK, tplExploreCommits)
+		return
+	}
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	var (
+		repoIDs []int64
+		err     error
+	)
+	// admins can search all repositories
+	if ctx.Doer == nil || !ctx.Doer.IsAdmin {
+		repoIDs, err = repo_model.FindUserCodeAccessibleRepoIDs(ctx, ctx.Doer)
+		if err != nil {
+			ctx.ServerError("FindUserCodeAccessibleRepoIDs", err)
+			return
+		}
+	}
+
+	var (
+		total   int64
+		results []*CommitSearchResult
+	)
+	if len(repoIDs) > 0 || (ctx.Doer != nil && ctx.Doer.IsAdmin) {
+		var matches []*commit_indexer.Match
+		matches, total, err = commit_indexer.SearchCommits(ctx, &commit_indexer.SearchOptions{
+			Keyword:        keyword,
+			IsFuzzyKeyword: isFuzzy,
+			RepoIDs:        repoIDs,
+			Author:         author,
+			Paginator: &db.ListOptions{
+				Page:     page,
+				PageSize: setting.UI.RepoSearchPagingNum,
+			},
+		})
+		if err != nil {
+			if commit_indexer.IsAvailable(ctx) {
+				ctx.ServerError("SearchCommits", err)
+				return
+			}
+			ctx.Data["CommitIndexerUnavailable"] = true
+		}
+
+		results, err = loadCommitSearchResults(ctx, matches)
+		if err != nil {
+			ctx.ServerError("loadCommitSearchResults", err)
+			return
+		}
+	}
+
+	ctx.Data["SearchResults"] = results
+
+	pager := context.NewPagination(int(total), setting.UI.RepoSearchPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "author", "Author")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplExploreCommits)
+}
+
+// loadCommitSearchResults loads the repositories of the matches, the matches of deleted repositories are skipped
+func loadCommitSearchResults(ctx *context.Context, matches []*commit_indexer.Match) ([]*CommitSearchResult, error) {
+	repoIDs := make([]int64, 0, len(matches))
+	for _, match := range matches {
+		repoIDs = append(repoIDs, match.RepoID)
+	}
+	repos, err := repo_model.GetRepositoriesMapByIDs(ctx, repoIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*CommitSearchResult, 0, len(matches))
+	for _, match := range matches {
+		if repo, ok := repos[match.RepoID]; ok {
+			results = append(results, &CommitSearchResult{Match: match, Repo: repo})
+		}
+	}
+	return results, nil
+}
@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/web"
+	shared_project "code.gitea.io/gitea/routers/web/shared/project"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
@@ -371,6 +372,18 @@ func ViewProject(ctx *context.Context) {
 		}
 	}
 
+	if ctx.ContextUser.IsOrganization() {
+		issueFields, err := issues_model.GetIssueFieldsByOrgID(ctx, ctx.ContextUser.ID)
+		if err != nil {
+			ctx.ServerError("GetIssueFieldsByOrgID", err)
+			return
+		}
+		shared_project.SetIssueFieldsContext(ctx, issueFields, columns, issuesMap)
+		if ctx.Written() {
+			return
+		}
+	}
+
 	project.RenderedContent = templates.RenderMarkdownToHtml(ctx, project.Description)
 	ctx.Data["LinkedPRs"] = linkedPrsMap
 	ctx.Data["PageIsViewProjects"] = true
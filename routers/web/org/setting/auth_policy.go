@@ -0,0 +1,124 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"net/http"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/web"
+	shared_user "code.gitea.io/gitea/routers/web/shared/user"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const tplAuthPolicy base.TplName = "org/settings/auth_policy"
+
+// AuthPolicy renders the authentication policy of an organization and the members who do not fulfil it
+func AuthPolicy(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("org.settings.auth_policy")
+	ctx.Data["PageIsSettingsAuthPolicy"] = true
+
+	policy, err := organization.GetAuthPolicy(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.ServerError("GetAuthPolicy", err)
+		return
+	}
+	nonCompliant, err := organization.FindNonCompliantMembers(ctx, policy)
+	if err != nil {
+		ctx.ServerError("FindNonCompliantMembers", err)
+		return
+	}
+	sources, err := db.Find[auth_model.Source](ctx, auth_model.FindSourcesOptions{
+		IsActive: optional.Some(true),
+	})
+	if err != nil {
+		ctx.ServerError("FindSources", err)
+		return
+	}
+
+	ctx.Data["AuthPolicy"] = policy
+	ctx.Data["NonCompliantMembers"] = nonCompliant
+	ctx.Data["AuthSources"] = sources
+
+	if err := shared_user.LoadHeaderCount(ctx); err != nil {
+		ctx.ServerError("LoadHeaderCount", err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplAuthPolicy)
+}
+
+// AuthPolicyPost updates the authentication policy of an organization
+func AuthPolicyPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.OrgAuthPolicyForm)
+	link := ctx.Org.OrgLink + "/settings/auth_policy"
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(link)
+		return
+	}
+
+	if form.RequiredAuthSourceID != 0 {
+		source, err := auth_model.GetSourceByID(ctx, form.RequiredAuthSourceID)
+		if err != nil {
+			if auth_model.IsErrSourceNotExist(err) {
+				ctx.Flash.Error(ctx.Tr("org.settings.auth_policy.invalid_auth_source"))
+				ctx.Redirect(link)
+				return
+			}
+			ctx.ServerError("GetSourceByID", err)
+			return
+		}
+		if !source.IsActive {
+			ctx.Flash.Error(ctx.Tr("org.settings.auth_policy.invalid_auth_source"))
+			ctx.Redirect(link)
+			return
+		}
+	}
+
+	policy, err := organization.GetAuthPolicy(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.ServerError("GetAuthPolicy", err)
+		return
+	}
+	policy.RequireTwoFactor = form.RequireTwoFactor
+	policy.RequiredAuthSourceID = form.RequiredAuthSourceID
+	policy.GracePeriodEndUnix = 0
+	if form.GracePeriodDays > 0 {
+		policy.GracePeriodEndUnix = timeutil.TimeStampNow().Add(form.GracePeriodDays * timeutil.Day)
+	}
+
+	// the owner must not lock themselves out of the organization
+	if !ctx.Doer.IsAdmin {
+		signInSourceID := optional.None[int64]()
+		if id, ok := ctx.Data["SignInSourceID"].(int64); ok {
+			signInSourceID = optional.Some(id)
+		}
+		v, err := policy.CheckMember(ctx, ctx.Doer, signInSourceID)
+		if err != nil {
+			ctx.ServerError("CheckMember", err)
+			return
+		}
+		if v.IsViolated() {
+			ctx.Flash.Error(ctx.Tr("org.settings.auth_policy.doer_not_compliant"))
+			ctx.Redirect(link)
+			return
+		}
+	}
+
+	if err := organization.UpdateAuthPolicy(ctx, policy); err != nil {
+		ctx.ServerError("UpdateAuthPolicy", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("org.settings.auth_policy.update_success"))
+	ctx.Redirect(link)
+}
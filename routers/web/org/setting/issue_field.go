@@ -0,0 +1,65 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	shared_issuefield "code.gitea.io/gitea/routers/web/shared/issuefield"
+	shared_user "code.gitea.io/gitea/routers/web/shared/user"
+	"code.gitea.io/gitea/services/context"
+)
+
+const (
+	tplIssueFields     base.TplName = "org/settings/issue_fields"
+	tplIssueFieldsEdit base.TplName = "org/settings/issue_fields_edit"
+)
+
+// IssueFields renders the custom issue fields of an organization
+func IssueFields(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.issues.fields")
+	ctx.Data["PageIsOrgSettings"] = true
+	ctx.Data["PageIsSettingsIssueFields"] = true
+	ctx.Data["IssueFieldsLink"] = ctx.Org.OrgLink + "/settings/issue_fields"
+
+	if err := shared_user.LoadHeaderCount(ctx); err != nil {
+		ctx.ServerError("LoadHeaderCount", err)
+		return
+	}
+
+	shared_issuefield.SetIssueFieldsContext(ctx, ctx.Org.Organization.ID, 0)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplIssueFields)
+}
+
+// IssueFieldEdit renders the page to create or edit a custom issue field of an organization
+func IssueFieldEdit(ctx *context.Context) {
+	ctx.Data["PageIsOrgSettings"] = true
+	ctx.Data["PageIsSettingsIssueFields"] = true
+	ctx.Data["IssueFieldsLink"] = ctx.Org.OrgLink + "/settings/issue_fields"
+
+	if err := shared_user.LoadHeaderCount(ctx); err != nil {
+		ctx.ServerError("LoadHeaderCount", err)
+		return
+	}
+
+	shared_issuefield.SetIssueFieldEditContext(ctx, ctx.Org.Organization.ID, 0)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplIssueFieldsEdit)
+}
+
+// IssueFieldEditPost creates or updates a custom issue field of an organization
+func IssueFieldEditPost(ctx *context.Context) {
+	shared_issuefield.PerformIssueFieldPost(ctx, ctx.Org.Organization.ID, 0, ctx.Org.OrgLink+"/settings/issue_fields")
+}
+
+// IssueFieldDelete deletes a custom issue field of an organization
+func IssueFieldDelete(ctx *context.Context) {
+	shared_issuefield.PerformIssueFieldDelete(ctx, ctx.Org.Organization.ID, 0, ctx.Org.OrgLink+"/settings/issue_fields")
+}
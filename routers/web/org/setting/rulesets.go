@@ -0,0 +1,216 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/modules/base"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	shared_user "code.gitea.io/gitea/routers/web/shared/user"
+	audit_service "code.gitea.io/gitea/services/audit"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	"code.gitea.io/gitea/services/forms"
+)
+
+const (
+	tplRulesets     base.TplName = "org/settings/rulesets"
+	tplRulesetsEdit base.TplName = "org/settings/rulesets_edit"
+)
+
+// Rulesets renders the list of the branch and tag protection rulesets of an organization
+func Rulesets(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("org.settings.rulesets")
+	ctx.Data["PageIsSettingsRulesets"] = true
+
+	rulesets, err := git_model.GetOrgRulesets(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.ServerError("GetOrgRulesets", err)
+		return
+	}
+	ctx.Data["Rulesets"] = rulesets
+
+	if err := shared_user.LoadHeaderCount(ctx); err != nil {
+		ctx.ServerError("LoadHeaderCount", err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplRulesets)
+}
+
+// RulesetEdit renders the page to create or edit a ruleset
+func RulesetEdit(ctx *context.Context) {
+	ctx.Data["PageIsSettingsRulesets"] = true
+
+	ruleset := &git_model.OrgRuleset{CanPush: true}
+	if id := ctx.FormInt64("id"); id > 0 {
+		var err error
+		ruleset, err = git_model.GetOrgRulesetByID(ctx, ctx.Org.Organization.ID, id)
+		if err != nil {
+			ctx.ServerError("GetOrgRulesetByID", err)
+			return
+		}
+		if ruleset == nil {
+			ctx.NotFound("GetOrgRulesetByID", nil)
+			return
+		}
+		ctx.Data["Title"] = ctx.Locale.TrString("org.settings.rulesets") + " - " + ruleset.Name
+	} else {
+		ctx.Data["Title"] = ctx.Tr("org.settings.rulesets.add")
+	}
+
+	users, _, err := organization.FindOrgMembers(ctx, &organization.FindOrgMembersOpts{OrgID: ctx.Org.Organization.ID})
+	if err != nil {
+		ctx.ServerError("FindOrgMembers", err)
+		return
+	}
+	teams, err := organization.FindOrgTeams(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.ServerError("FindOrgTeams", err)
+		return
+	}
+	ctx.Data["Users"] = users
+	ctx.Data["Teams"] = teams
+	ctx.Data["whitelist_users"] = strings.Join(base.Int64sToStrings(ruleset.WhitelistUserIDs), ",")
+	ctx.Data["whitelist_teams"] = strings.Join(base.Int64sToStrings(ruleset.WhitelistTeamIDs), ",")
+	ctx.Data["status_check_contexts"] = strings.Join(ruleset.StatusCheckContexts, "\n")
+	ctx.Data["Ruleset"] = ruleset
+
+	if err := shared_user.LoadHeaderCount(ctx); err != nil {
+		ctx.ServerError("LoadHeaderCount", err)
+		return
+	}
+
+	ctx.HTML(http.StatusOK, tplRulesetsEdit)
+}
+
+// RulesetEditPost creates or updates a ruleset
+func RulesetEditPost(ctx *context.Context) {
+	form := web.GetForm(ctx).(*forms.OrgRulesetForm)
+	rulesetsLink := ctx.Org.OrgLink + "/settings/rulesets"
+
+	id := ctx.FormInt64("id")
+	editLink := rulesetsLink + "/edit"
+	if id > 0 {
+		editLink = fmt.Sprintf("%s?id=%d", editLink, id)
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(editLink)
+		return
+	}
+
+	ruleset := &git_model.OrgRuleset{OrgID: ctx.Org.Organization.ID}
+	var before *api.OrgRuleset
+	if id > 0 {
+		var err error
+		ruleset, err = git_model.GetOrgRulesetByID(ctx, ctx.Org.Organization.ID, id)
+		if err != nil {
+			ctx.ServerError("GetOrgRulesetByID", err)
+			return
+		}
+		if ruleset == nil {
+			ctx.NotFound("GetOrgRulesetByID", nil)
+			return
+		}
+		before = convert.ToOrgRuleset(ctx, ruleset)
+	}
+
+	target, err := git_model.ParseOrgRulesetTarget(form.Target)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("org.settings.rulesets.invalid", err.Error()))
+		ctx.Redirect(editLink)
+		return
+	}
+
+	ruleset.Name = form.Name
+	ruleset.RepoNamePattern = strings.TrimSpace(form.RepoNamePattern)
+	ruleset.RepoTopic = strings.ToLower(strings.TrimSpace(form.RepoTopic))
+	ruleset.Target = target
+	ruleset.RefPattern = strings.TrimSpace(form.RefPattern)
+	ruleset.RequiredApprovals = form.RequiredApprovals
+	ruleset.RequireSignedCommits = form.RequireSignedCommits
+	ruleset.ProtectedFilePatterns = strings.TrimSpace(form.ProtectedFilePatterns)
+
+	ruleset.WhitelistUserIDs = nil
+	ruleset.WhitelistTeamIDs = nil
+	switch form.EnablePush {
+	case "all":
+		ruleset.CanPush = true
+		ruleset.EnableWhitelist = false
+	case "whitelist":
+		ruleset.CanPush = true
+		ruleset.EnableWhitelist = true
+		if strings.TrimSpace(form.WhitelistUsers) != "" {
+			ruleset.WhitelistUserIDs, _ = base.StringsToInt64s(strings.Split(form.WhitelistUsers, ","))
+		}
+		if strings.TrimSpace(form.WhitelistTeams) != "" {
+			ruleset.WhitelistTeamIDs, _ = base.StringsToInt64s(strings.Split(form.WhitelistTeams, ","))
+		}
+	default:
+		ruleset.CanPush = false
+		ruleset.EnableWhitelist = false
+	}
+
+	ruleset.EnableStatusCheck = form.EnableStatusCheck
+	ruleset.StatusCheckContexts = nil
+	if form.EnableStatusCheck {
+		for _, pattern := range strings.Split(strings.ReplaceAll(form.StatusCheckContexts, "\r", "\n"), "\n") {
+			if trimmed := strings.TrimSpace(pattern); trimmed != "" {
+				ruleset.StatusCheckContexts = append(ruleset.StatusCheckContexts, trimmed)
+			}
+		}
+	}
+
+	if err := git_model.UpdateOrgRuleset(ctx, ruleset); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Flash.Error(ctx.Tr("org.settings.rulesets.invalid", err.Error()))
+			ctx.Redirect(editLink)
+			return
+		}
+		ctx.ServerError("UpdateOrgRuleset", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionRulesetUpdate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "ruleset", ruleset.ID, ruleset.Name),
+		before, convert.ToOrgRuleset(ctx, ruleset))
+
+	ctx.Flash.Success(ctx.Tr("org.settings.rulesets.update_success", ruleset.Name))
+	ctx.Redirect(rulesetsLink)
+}
+
+// RulesetDelete deletes a ruleset
+func RulesetDelete(ctx *context.Context) {
+	rulesetsLink := ctx.Org.OrgLink + "/settings/rulesets"
+
+	ruleset, err := git_model.GetOrgRulesetByID(ctx, ctx.Org.Organization.ID, ctx.ParamsInt64("id"))
+	if err != nil {
+		ctx.ServerError("GetOrgRulesetByID", err)
+		return
+	}
+	if ruleset == nil {
+		ctx.Flash.Error(ctx.Tr("org.settings.rulesets.delete_failed"))
+		ctx.JSONRedirect(rulesetsLink)
+		return
+	}
+
+	if err := git_model.DeleteOrgRuleset(ctx, ruleset.OrgID, ruleset.ID); err != nil {
+		ctx.ServerError("DeleteOrgRuleset", err)
+		return
+	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionRulesetDelete, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "ruleset", ruleset.ID, ruleset.Name),
+		convert.ToOrgRuleset(ctx, ruleset), nil)
+
+	ctx.Flash.Success(ctx.Tr("org.settings.rulesets.delete_success", ruleset.Name))
+	ctx.JSONRedirect(rulesetsLink)
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	org_model "code.gitea.io/gitea/models/organization"
 	"code.gitea.io/gitea/models/perm"
@@ -24,6 +25,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/forms"
@@ -78,6 +80,9 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = models.AddTeamMember(ctx, ctx.Org.Team, ctx.Doer.ID)
+		if err == nil {
+			recordTeamMember(ctx, audit_model.ActionTeamMemberAdd, ctx.Doer.ID)
+		}
 	case "leave":
 		err = models.RemoveTeamMember(ctx, ctx.Org.Team, ctx.Doer.ID)
 		if err != nil {
@@ -91,6 +96,8 @@ func TeamsAction(ctx *context.Context) {
 				})
 				return
 			}
+		} else {
+			recordTeamMember(ctx, audit_model.ActionTeamMemberRemove, ctx.Doer.ID)
 		}
 		checkIsOrgMemberAndRedirect(ctx, ctx.Org.OrgLink+"/teams/")
 		return
@@ -118,6 +125,8 @@ func TeamsAction(ctx *context.Context) {
 				})
 				return
 			}
+		} else {
+			recordTeamMember(ctx, audit_model.ActionTeamMemberRemove, uid)
 		}
 		checkIsOrgMemberAndRedirect(ctx, ctx.Org.OrgLink+"/teams/"+url.PathEscape(ctx.Org.Team.LowerName))
 		return
@@ -162,6 +171,9 @@ func TeamsAction(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("org.teams.add_duplicate_users"))
 		} else {
 			err = models.AddTeamMember(ctx, ctx.Org.Team, u.ID)
+			if err == nil {
+				recordTeamMember(ctx, audit_model.ActionTeamMemberAdd, u.ID)
+			}
 		}
 
 		page = "team"
@@ -209,6 +221,15 @@ func TeamsAction(ctx *context.Context) {
 	}
 }
 
+func recordTeamMember(ctx *context.Context, action audit_model.Action, uid int64) {
+	var name string
+	if u, err := user_model.GetUserByID(ctx, uid); err == nil {
+		name = u.Name
+	}
+	audit_service.Record(ctx, ctx.Doer, action, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "user", uid, name),
+		nil, audit_service.State{"team": ctx.Org.Team.Name})
+}
+
 func checkIsOrgMemberAndRedirect(ctx *context.Context, defaultRedirect string) {
 	if isOrgMember, err := org_model.IsOrganizationMember(ctx, ctx.Org.Organization.ID, ctx.Doer.ID); err != nil {
 		ctx.ServerError("IsOrganizationMember", err)
@@ -366,6 +387,8 @@ func NewTeamPost(ctx *context.Context) {
 		return
 	}
 	log.Trace("Team created: %s/%s", ctx.Org.Organization.Name, t.Name)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamCreate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "team", t.ID, t.Name),
+		nil, audit_service.TeamState(t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + url.PathEscape(t.LowerName))
 }
 
@@ -495,6 +518,12 @@ func EditTeamPost(ctx *context.Context) {
 	ctx.Data["Team"] = t
 	ctx.Data["Units"] = unit_model.Units
 
+	if err := t.LoadUnits(ctx); err != nil {
+		ctx.ServerError("LoadUnits", err)
+		return
+	}
+	before := audit_service.TeamState(t)
+
 	if !t.IsOwnerTeam() {
 		t.Name = form.TeamName
 		if t.AccessMode != newAccessMode {
@@ -543,6 +572,8 @@ func EditTeamPost(ctx *context.Context) {
 		}
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamUpdate, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "team", t.ID, t.Name),
+		before, audit_service.TeamState(t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + url.PathEscape(t.LowerName))
 }
 
@@ -551,6 +582,7 @@ func DeleteTeam(ctx *context.Context) {
 	if err := models.DeleteTeam(ctx, ctx.Org.Team); err != nil {
 		ctx.Flash.Error("DeleteTeam: " + err.Error())
 	} else {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamDelete, audit_service.OwnerTarget(ctx.Org.Organization.AsUser(), "team", ctx.Org.Team.ID, ctx.Org.Team.Name), nil, nil)
 		ctx.Flash.Success(ctx.Tr("org.teams.delete_team_success"))
 	}
 
@@ -594,6 +626,8 @@ func TeamInvitePost(ctx *context.Context) {
 		ctx.ServerError("AddTeamMember", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionTeamMemberAdd, audit_service.OwnerTarget(org.AsUser(), "user", ctx.Doer.ID, ctx.Doer.Name),
+		nil, audit_service.State{"team": team.Name})
 
 	if err := org_model.RemoveInviteByID(ctx, invite.ID, team.ID); err != nil {
 		log.Error("RemoveInviteByID: %v", err)
@@ -173,6 +173,16 @@ func httpBase(ctx *context.Context) *serviceHandler {
 			return nil
 		}
 
+		policy, violation, err := context.OrgAuthPolicyViolation(ctx, owner, ctx.Doer, ctx.Data)
+		if err != nil {
+			ctx.ServerError("OrgAuthPolicyViolation", err)
+			return nil
+		}
+		if violation != nil && !policy.InGracePeriod() {
+			ctx.PlainText(http.StatusForbidden, context.OrgAuthPolicyMessage(owner, violation))
+			return nil
+		}
+
 		environ = []string{
 			repo_module.EnvRepoUsername + "=" + username,
 			repo_module.EnvRepoName + "=" + reponame,
@@ -55,8 +55,10 @@ import (
 	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/forms"
 	issue_service "code.gitea.io/gitea/services/issue"
+	"code.gitea.io/gitea/services/mergequeue"
 	pull_service "code.gitea.io/gitea/services/pull"
 	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
 const (
@@ -196,6 +198,21 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 		}
 	}
 
+	// "<field id>:<value>" means issues which have the value of a custom field
+	var fieldValues []string
+	selectField := ctx.FormString("field")
+	if len(selectField) > 0 {
+		fieldValues, err = issue_service.ParseFieldFilters(ctx, repo, []string{selectField})
+		if err != nil {
+			if !issues_model.IsErrIssueFieldNotExist(err) && !errors.Is(err, util.ErrInvalidArgument) {
+				ctx.ServerError("ParseFieldFilters", err)
+				return
+			}
+			ctx.Flash.Error(ctx.Tr("invalid_data", selectField), true)
+			selectField = ""
+		}
+	}
+
 	keyword := strings.Trim(ctx.FormString("q"), " ")
 	if bytes.Contains([]byte(keyword), []byte{0x00}) {
 		keyword = ""
@@ -212,6 +229,7 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 	statsOpts := &issues_model.IssuesOptions{
 		RepoIDs:           []int64{repo.ID},
 		LabelIDs:          labelIDs,
+		FieldValues:       fieldValues,
 		MilestoneIDs:      mileIDs,
 		ProjectID:         projectID,
 		AssigneeID:        assigneeID,
@@ -307,6 +325,7 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 			IsClosed:          isShowClosed,
 			IsPull:            isPullOption,
 			LabelIDs:          labelIDs,
+			FieldValues:       fieldValues,
 			SortType:          sortType,
 		})
 		if err != nil {
@@ -352,9 +371,20 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 		return
 	}
 
+	issueIDs := make([]int64, 0, len(issues))
+	for _, issue := range issues {
+		issueIDs = append(issueIDs, issue.ID)
+	}
+	subIssueProgress, err := issues_model.GetSubIssueProgress(ctx, ctx.Doer, issueIDs...)
+	if err != nil {
+		ctx.ServerError("GetSubIssueProgress", err)
+		return
+	}
+
 	ctx.Data["Issues"] = issues
 	ctx.Data["CommitLastStatus"] = lastStatus
 	ctx.Data["CommitStatuses"] = commitStatuses
+	ctx.Data["SubIssueProgress"] = subIssueProgress
 
 	// Get assignees.
 	assigneeUsers, err := repo_model.GetRepoAssignees(ctx, repo)
@@ -449,18 +479,19 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 	ctx.Data["IssueStats"] = issueStats
 	ctx.Data["OpenCount"] = issueStats.OpenCount
 	ctx.Data["ClosedCount"] = issueStats.ClosedCount
-	linkStr := "%s?q=%s&type=%s&sort=%s&state=%s&labels=%s&milestone=%d&project=%d&assignee=%d&poster=%d&archived=%t"
+	linkStr := "%s?q=%s&type=%s&sort=%s&state=%s&labels=%s&field=%s&milestone=%d&project=%d&assignee=%d&poster=%d&archived=%t"
 	ctx.Data["AllStatesLink"] = fmt.Sprintf(linkStr, ctx.Link,
-		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "all", url.QueryEscape(selectLabels),
+		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "all", url.QueryEscape(selectLabels), url.QueryEscape(selectField),
 		milestoneID, projectID, assigneeID, posterID, archived)
 	ctx.Data["OpenLink"] = fmt.Sprintf(linkStr, ctx.Link,
-		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "open", url.QueryEscape(selectLabels),
+		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "open", url.QueryEscape(selectLabels), url.QueryEscape(selectField),
 		milestoneID, projectID, assigneeID, posterID, archived)
 	ctx.Data["ClosedLink"] = fmt.Sprintf(linkStr, ctx.Link,
-		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "closed", url.QueryEscape(selectLabels),
+		url.QueryEscape(keyword), url.QueryEscape(viewType), url.QueryEscape(sortType), "closed", url.QueryEscape(selectLabels), url.QueryEscape(selectField),
 		milestoneID, projectID, assigneeID, posterID, archived)
 	ctx.Data["SelLabelIDs"] = labelIDs
 	ctx.Data["SelectLabels"] = selectLabels
+	ctx.Data["SelectField"] = selectField
 	ctx.Data["ViewType"] = viewType
 	ctx.Data["SortType"] = sortType
 	ctx.Data["MilestoneID"] = milestoneID
@@ -484,6 +515,7 @@ func issues(ctx *context.Context, milestoneID, projectID int64, isPullOption opt
 	pager.AddParam(ctx, "sort", "SortType")
 	pager.AddParam(ctx, "state", "State")
 	pager.AddParam(ctx, "labels", "SelectLabels")
+	pager.AddParam(ctx, "field", "SelectField")
 	pager.AddParam(ctx, "milestone", "MilestoneID")
 	pager.AddParam(ctx, "project", "ProjectID")
 	pager.AddParam(ctx, "assignee", "AssigneeID")
@@ -862,6 +894,13 @@ func RetrieveRepoMetas(ctx *context.Context, repo *repo_model.Repository, isPull
 		return nil
 	}
 
+	issueFields, err := issues_model.GetIssueFieldsForRepo(ctx, repo.ID, repo.OwnerID)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldsForRepo", err)
+		return nil
+	}
+	ctx.Data["IssueFields"] = issueFields
+
 	retrieveProjects(ctx, repo)
 	if ctx.Written() {
 		return nil
@@ -937,6 +976,22 @@ func setTemplateIfExists(ctx *context.Context, ctxDataKey string, possibleFiles
 			}
 		}
 
+		issueFieldValues := make(map[int64][]string, len(template.IssueFields))
+		for name, values := range template.IssueFields {
+			field, err := issues_model.GetIssueFieldForRepoByName(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID, name)
+			if err != nil {
+				continue
+			}
+			if field.Type != issues_model.IssueFieldTypeUser {
+				// use the spelling of the options for select fields, the values of user fields stay user names
+				if values, err = field.NormalizeValues(ctx, ctx.Repo.Repository, values); err != nil {
+					continue
+				}
+			}
+			issueFieldValues[field.ID] = values
+		}
+		ctx.Data["IssueFieldValues"] = issueFieldValues
+
 		if template.Ref != "" && !strings.HasPrefix(template.Ref, "refs/") { // Assume that the ref intended is always a branch - for tags users should use refs/tags/<ref>
 			template.Ref = git.BranchPrefix + template.Ref
 		}
@@ -1204,6 +1259,35 @@ func ValidateRepoMetas(ctx *context.Context, form forms.CreateIssueForm, isPull
 	return labelIDs, assigneeIDs, milestoneID, form.ProjectID
 }
 
+// validateIssueFieldValues returns the custom issue fields of the repository and the values of the new issue form for
+// them, the values are only taken into account if the user can write issues
+func validateIssueFieldValues(ctx *context.Context, repo *repo_model.Repository) ([]*issues_model.IssueField, map[int64][]string) {
+	if !ctx.Repo.CanWrite(unit.TypeIssues) {
+		return nil, nil
+	}
+
+	fields, err := issues_model.GetIssueFieldsForRepo(ctx, repo.ID, repo.OwnerID)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldsForRepo", err)
+		return nil, nil
+	}
+
+	values := make(map[int64][]string, len(fields))
+	for _, field := range fields {
+		v := ctx.FormStrings(fmt.Sprintf("issue_field_%d", field.ID))
+		if _, err := field.NormalizeValues(ctx, repo, v); err != nil {
+			if issues_model.IsErrIssueFieldInvalidValue(err) {
+				ctx.JSONError(ctx.Tr("repo.issues.fields.invalid_value", field.Name))
+			} else {
+				ctx.ServerError("NormalizeValues", err)
+			}
+			return nil, nil
+		}
+		values[field.ID] = v
+	}
+	return fields, values
+}
+
 // NewIssuePost response for creating new issue
 func NewIssuePost(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.CreateIssueForm)
@@ -1224,6 +1308,11 @@ func NewIssuePost(ctx *context.Context) {
 		return
 	}
 
+	issueFields, issueFieldValues := validateIssueFieldValues(ctx, repo)
+	if ctx.Written() {
+		return
+	}
+
 	if setting.Attachment.Enabled {
 		attachments = form.Files
 	}
@@ -1268,6 +1357,15 @@ func NewIssuePost(ctx *context.Context) {
 		return
 	}
 
+	for _, field := range issueFields {
+		if values := issueFieldValues[field.ID]; len(values) > 0 {
+			if err := issue_service.SetFieldValues(ctx, issue, ctx.Doer, field, values); err != nil {
+				ctx.ServerError("SetFieldValues", err)
+				return
+			}
+		}
+	}
+
 	if projectID > 0 {
 		if !ctx.Repo.CanRead(unit.TypeProjects) {
 			// User must also be able to see the project.
@@ -1840,6 +1938,10 @@ func ViewIssue(ctx *context.Context) {
 				ctx.ServerError("CanMarkConversation", err)
 				return
 			}
+			if ctx.Data["CanApplyCodeSuggestions"], err = files_service.CanApplyCodeSuggestions(ctx, pull, ctx.Doer); err != nil {
+				ctx.ServerError("CanApplyCodeSuggestions", err)
+				return
+			}
 		}
 
 		ctx.Data["AllowMerge"] = allowMerge
@@ -1908,6 +2010,13 @@ func ViewIssue(ctx *context.Context) {
 			ctx.Data["IsBlockedByRejection"] = issues_model.MergeBlockedByRejectedReview(ctx, pb, pull)
 			ctx.Data["IsBlockedByOfficialReviewRequests"] = issues_model.MergeBlockedByOfficialReviewRequests(ctx, pb, pull)
 			ctx.Data["IsBlockedByOutdatedBranch"] = issues_model.MergeBlockedByOutdatedBranch(pb, pull)
+			missingCodeOwnerApprovals, err := pull_service.GetMissingCodeOwnerApprovals(ctx, pb, pull)
+			if err != nil {
+				ctx.ServerError("GetMissingCodeOwnerApprovals", err)
+				return
+			}
+			ctx.Data["IsBlockedByCodeOwners"] = len(missingCodeOwnerApprovals) > 0
+			ctx.Data["MissingCodeOwnerApprovals"] = missingCodeOwnerApprovals
 			ctx.Data["GrantedApprovals"] = issues_model.GetGrantedApprovalsCount(ctx, pb, pull)
 			ctx.Data["RequireSigned"] = pb.RequireSignedCommits
 			ctx.Data["ChangedProtectedFiles"] = pull.ChangedProtectedFiles
@@ -1970,6 +2079,45 @@ func ViewIssue(ctx *context.Context) {
 			ctx.ServerError("GetScheduledMergeByPullID", err)
 			return
 		}
+
+		// Check if the pr is in the merge queue of its base branch
+		ctx.Data["IsMergeQueueEnabled"] = pb != nil && pb.EnableMergeQueue
+		inMergeQueue, mergeQueueEntry, err := pull_model.GetMergeQueueEntryByPullID(ctx, pull.ID)
+		if err != nil {
+			ctx.ServerError("GetMergeQueueEntryByPullID", err)
+			return
+		}
+		if inMergeQueue {
+			if err := mergeQueueEntry.LoadDoer(ctx); err != nil {
+				ctx.ServerError("LoadDoer", err)
+				return
+			}
+			ctx.Data["MergeQueueEntry"] = mergeQueueEntry
+			ctx.Data["MergeQueuePosition"], err = pull_model.GetMergeQueuePosition(ctx, mergeQueueEntry)
+			if err != nil {
+				ctx.ServerError("GetMergeQueuePosition", err)
+				return
+			}
+		}
+
+		// Get the stack of pull requests the pr belongs to
+		if !pull.HasMerged && !issue.IsClosed {
+			stack, err := pull_service.GetPullRequestStack(ctx, pull)
+			if err != nil {
+				ctx.ServerError("GetPullRequestStack", err)
+				return
+			}
+			if len(stack) > 1 {
+				ctx.Data["PullRequestStack"] = stack
+				// pull requests into a branch with a merge queue are merged one by one by the queue
+				queued, err := mergequeue.IsMergeQueueEnabled(ctx, stack[0])
+				if err != nil {
+					ctx.ServerError("IsMergeQueueEnabled", err)
+					return
+				}
+				ctx.Data["CanMergeStack"] = allowMerge && stack[0].ID != pull.ID && !queued
+			}
+		}
 	}
 
 	// Get Dependencies
@@ -2005,6 +2153,26 @@ func ViewIssue(ctx *context.Context) {
 		pinAllowed = true
 	}
 
+	issueFields, err := issues_model.GetIssueFieldsForRepo(ctx, repo.ID, repo.OwnerID)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldsForRepo", err)
+		return
+	}
+	issueFieldValues, err := issues_model.GetIssueFieldDisplayValues(ctx, issueFields, issue.ID)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldDisplayValues", err)
+		return
+	}
+	ctx.Data["IssueFields"] = issueFields
+	ctx.Data["IssueFieldValues"] = issueFieldValues[issue.ID]
+
+	if !issue.IsPull {
+		prepareSubIssues(ctx, issue)
+		if ctx.Written() {
+			return
+		}
+	}
+
 	ctx.Data["Participants"] = participants
 	ctx.Data["NumParticipants"] = len(participants)
 	ctx.Data["Issue"] = issue
@@ -2322,6 +2490,44 @@ func UpdateIssueDeadline(ctx *context.Context) {
 	ctx.JSON(http.StatusCreated, api.IssueDeadline{Deadline: &deadline})
 }
 
+// UpdateIssueFieldValues sets the values of a custom field of an issue
+func UpdateIssueFieldValues(ctx *context.Context) {
+	issue, err := issues_model.GetIssueByIndex(ctx, ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.NotFound("GetIssueByIndex", err)
+		} else {
+			ctx.ServerError("GetIssueByIndex", err)
+		}
+		return
+	}
+
+	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Error(http.StatusForbidden, "", "Not repo writer")
+		return
+	}
+
+	field, err := issues_model.GetIssueFieldForRepoByID(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound("GetIssueFieldForRepoByID", err)
+		} else {
+			ctx.ServerError("GetIssueFieldForRepoByID", err)
+		}
+		return
+	}
+
+	if err := issue_service.SetFieldValues(ctx, issue, ctx.Doer, field, ctx.FormStrings("value")); err != nil {
+		if !issues_model.IsErrIssueFieldInvalidValue(err) {
+			ctx.ServerError("SetFieldValues", err)
+			return
+		}
+		ctx.Flash.Error(ctx.Tr("repo.issues.fields.invalid_value", field.Name))
+	}
+
+	ctx.Redirect(issue.Link())
+}
+
 // UpdateIssueMilestone change issue's milestone
 func UpdateIssueMilestone(ctx *context.Context) {
 	issues := getActionIssues(ctx)
@@ -0,0 +1,263 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+)
+
+// prepareSubIssues sets the sub-issues of the issue, their progress and the parent of the issue which the doer can read
+func prepareSubIssues(ctx *context.Context, issue *issues_model.Issue) {
+	subIssues, err := issues_model.GetSubIssues(ctx, issue.ID)
+	if err != nil {
+		ctx.ServerError("GetSubIssues", err)
+		return
+	}
+	if _, err := subIssues.LoadRepositories(ctx); err != nil {
+		ctx.ServerError("LoadRepositories", err)
+		return
+	}
+
+	readable := make(issues_model.IssueList, 0, len(subIssues))
+	issueIDs := make([]int64, 0, len(subIssues)+1)
+	issueIDs = append(issueIDs, issue.ID)
+	for _, subIssue := range subIssues {
+		canRead, err := canReadIssue(ctx, subIssue)
+		if err != nil {
+			ctx.ServerError("canReadIssue", err)
+			return
+		}
+		if canRead {
+			readable = append(readable, subIssue)
+			issueIDs = append(issueIDs, subIssue.ID)
+		}
+	}
+
+	progress, err := issues_model.GetSubIssueProgress(ctx, ctx.Doer, issueIDs...)
+	if err != nil {
+		ctx.ServerError("GetSubIssueProgress", err)
+		return
+	}
+
+	parent, err := issues_model.GetParentIssue(ctx, issue.ID)
+	if err != nil {
+		ctx.ServerError("GetParentIssue", err)
+		return
+	}
+	if parent != nil {
+		if err := parent.LoadRepo(ctx); err != nil {
+			ctx.ServerError("LoadRepo", err)
+			return
+		}
+		canRead, err := canReadIssue(ctx, parent)
+		if err != nil {
+			ctx.ServerError("canReadIssue", err)
+			return
+		}
+		if canRead {
+			ctx.Data["ParentIssue"] = parent
+		}
+	}
+
+	ctx.Data["SubIssues"] = readable
+	ctx.Data["SubIssueProgress"] = progress
+	ctx.Data["CanEditSubIssues"] = ctx.Repo.CanWriteIssuesOrPulls(false) && !ctx.Repo.Repository.IsArchived
+}
+
+// canReadIssue returns true if the doer can read the issue, which may belong to another repository
+func canReadIssue(ctx *context.Context, issue *issues_model.Issue) (bool, error) {
+	if issue.RepoID == ctx.Repo.Repository.ID {
+		return ctx.Repo.CanReadIssuesOrPulls(issue.IsPull), nil
+	}
+	perm, err := access_model.GetUserRepoPermission(ctx, issue.Repo, ctx.Doer)
+	if err != nil {
+		return false, err
+	}
+	return perm.CanReadIssuesOrPulls(issue.IsPull), nil
+}
+
+// getSubIssueParent returns the issue of the request, if the doer can modify its sub-issues
+func getSubIssueParent(ctx *context.Context) *issues_model.Issue {
+	issue := GetActionIssue(ctx)
+	if ctx.Written() {
+		return nil
+	}
+	if issue.IsPull || !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Error(http.StatusForbidden)
+		return nil
+	}
+	return issue
+}
+
+// getSubIssue returns the issue which is referenced as "#<index>" or "<owner>/<repo>#<index>", or given by its ID,
+// if the doer can change its parent. It flashes an error and returns nil if there is no such issue.
+func getSubIssue(ctx *context.Context, ref string, id int64) *issues_model.Issue {
+	var issue *issues_model.Issue
+	var err error
+	if id > 0 {
+		issue, err = issues_model.GetIssueByID(ctx, id)
+	} else {
+		repo := ctx.Repo.Repository
+		fullName, index, ok := strings.Cut(strings.TrimSpace(ref), "#")
+		if !ok {
+			index = fullName
+		} else if fullName != "" && fullName != repo.FullName() {
+			ownerName, repoName, _ := strings.Cut(fullName, "/")
+			repo, err = repo_model.GetRepositoryByOwnerAndName(ctx, ownerName, repoName)
+			if err != nil {
+				if repo_model.IsErrRepoNotExist(err) {
+					ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_not_exist"))
+					return nil
+				}
+				ctx.ServerError("GetRepositoryByOwnerAndName", err)
+				return nil
+			}
+		}
+		n, parseErr := strconv.ParseInt(index, 10, 64)
+		if parseErr != nil {
+			ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_not_exist"))
+			return nil
+		}
+		issue, err = issues_model.GetIssueByIndex(ctx, repo.ID, n)
+	}
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_not_exist"))
+			return nil
+		}
+		ctx.ServerError("GetIssue", err)
+		return nil
+	}
+
+	if err := issue.LoadRepo(ctx); err != nil {
+		ctx.ServerError("LoadRepo", err)
+		return nil
+	}
+	perm := ctx.Repo.Permission
+	if issue.RepoID != ctx.Repo.Repository.ID {
+		if perm, err = access_model.GetUserRepoPermission(ctx, issue.Repo, ctx.Doer); err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return nil
+		}
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_not_exist"))
+		return nil
+	}
+	if !perm.CanWriteIssuesOrPulls(issue.IsPull) || issue.Repo.IsArchived {
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.error_no_permission"))
+		return nil
+	}
+	return issue
+}
+
+// flashSubIssueError flashes the error of a change of sub-issues, it returns false if the error is unexpected
+func flashSubIssueError(ctx *context.Context, err error) bool {
+	switch {
+	case issues_model.IsErrSubIssueExists(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_exists"))
+	case issues_model.IsErrSubIssueHasParent(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_has_parent"))
+	case issues_model.IsErrCircularSubIssue(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_circular"))
+	case issues_model.IsErrSubIssueTooDeep(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_too_deep", setting.Service.MaxSubIssueDepth))
+	case issues_model.IsErrSubIssueNotAllowed(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.add_error_not_allowed"))
+	case issues_model.IsErrSubIssueNotExist(err):
+		ctx.Flash.Error(ctx.Tr("repo.issues.sub_issues.error_not_sub_issue"))
+	default:
+		return false
+	}
+	return true
+}
+
+// AddSubIssue makes an issue a sub-issue of the issue of the request
+func AddSubIssue(ctx *context.Context) {
+	parent := getSubIssueParent(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	subIssue := getSubIssue(ctx, ctx.FormString("sub_issue"), 0)
+	if ctx.Written() {
+		return
+	}
+	if subIssue != nil {
+		if err := issues_model.AddSubIssue(ctx, ctx.Doer, parent, subIssue); err != nil && !flashSubIssueError(ctx, err) {
+			ctx.ServerError("AddSubIssue", err)
+			return
+		}
+	}
+
+	ctx.Redirect(parent.Link())
+}
+
+// RemoveSubIssue removes a sub-issue of the issue of the request
+func RemoveSubIssue(ctx *context.Context) {
+	parent := getSubIssueParent(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	subIssue := getSubIssue(ctx, "", ctx.FormInt64("sub_issue_id"))
+	if ctx.Written() {
+		return
+	}
+	if subIssue != nil {
+		if err := issues_model.RemoveSubIssue(ctx, ctx.Doer, parent, subIssue); err != nil && !flashSubIssueError(ctx, err) {
+			ctx.ServerError("RemoveSubIssue", err)
+			return
+		}
+	}
+
+	ctx.Redirect(parent.Link())
+}
+
+// MoveSubIssue moves a sub-issue of the issue of the request to another position
+func MoveSubIssue(ctx *context.Context) {
+	parent := getSubIssueParent(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	subIssue, err := issues_model.GetIssueByID(ctx, ctx.FormInt64("sub_issue_id"))
+	if err != nil {
+		if issues_model.IsErrIssueNotExist(err) {
+			ctx.NotFound("GetIssueByID", err)
+		} else {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return
+	}
+	if err := issues_model.MoveSubIssue(ctx, parent, subIssue, ctx.FormInt("position")); err != nil && !flashSubIssueError(ctx, err) {
+		ctx.ServerError("MoveSubIssue", err)
+		return
+	}
+
+	ctx.Redirect(parent.Link())
+}
+
+// UpdateCloseWithSubIssues sets whether the issue of the request is closed when all its sub-issues are closed
+func UpdateCloseWithSubIssues(ctx *context.Context) {
+	issue := getSubIssueParent(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := issues_model.SetCloseWithSubIssues(ctx, issue, ctx.FormBool("close_with_sub_issues")); err != nil {
+		ctx.ServerError("SetCloseWithSubIssues", err)
+		return
+	}
+
+	ctx.Redirect(issue.Link())
+}
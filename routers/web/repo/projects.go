@@ -23,6 +23,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	shared_project "code.gitea.io/gitea/routers/web/shared/project"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 )
@@ -363,6 +364,16 @@ func ViewProject(ctx *context.Context) {
 		return
 	}
 
+	issueFields, err := issues_model.GetIssueFieldsForRepo(ctx, ctx.Repo.Repository.ID, ctx.Repo.Repository.OwnerID)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldsForRepo", err)
+		return
+	}
+	shared_project.SetIssueFieldsContext(ctx, issueFields, columns, issuesMap)
+	if ctx.Written() {
+		return
+	}
+
 	ctx.Data["IsProjectsPage"] = true
 	ctx.Data["CanWriteProjects"] = ctx.Repo.Permission.CanWrite(unit.TypeProjects)
 	ctx.Data["Project"] = project
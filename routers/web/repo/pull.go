@@ -45,9 +45,11 @@ import (
 	"code.gitea.io/gitea/services/context/upload"
 	"code.gitea.io/gitea/services/forms"
 	"code.gitea.io/gitea/services/gitdiff"
+	"code.gitea.io/gitea/services/mergequeue"
 	notify_service "code.gitea.io/gitea/services/notify"
 	pull_service "code.gitea.io/gitea/services/pull"
 	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
 
 	"github.com/gobwas/glob"
 )
@@ -58,6 +60,8 @@ const (
 	tplPullCommits base.TplName = "repo/pulls/commits"
 	tplPullFiles   base.TplName = "repo/pulls/files"
 
+	tplPullMergeQueue base.TplName = "repo/pulls/merge_queue"
+
 	pullRequestTemplateKey = "PullRequestTemplate"
 )
 
@@ -1045,6 +1049,10 @@ func viewPullFiles(ctx *context.Context, specifiedStartCommit, specifiedEndCommi
 			ctx.ServerError("CanMarkConversation", err)
 			return
 		}
+		if ctx.Data["CanApplyCodeSuggestions"], err = files_service.CanApplyCodeSuggestions(ctx, pull, ctx.Doer); err != nil {
+			ctx.ServerError("CanApplyCodeSuggestions", err)
+			return
+		}
 	}
 
 	setCompareContext(ctx, baseCommit, commit, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
@@ -1297,6 +1305,25 @@ func MergePullRequest(ctx *context.Context) {
 		}
 	}
 
+	// pull requests into a branch with a merge queue are merged by the merge queue, unless the doer forces the merge
+	if !form.ForceMerge {
+		added, err := mergequeue.AddToMergeQueue(ctx, ctx.Doer, pr, repo_model.MergeStyle(form.Do), message)
+		if err != nil {
+			if pull_model.IsErrAlreadyInMergeQueue(err) {
+				ctx.JSONError(ctx.Tr("repo.pulls.merge_queue.already_queued"))
+			} else if models.IsErrInvalidMergeStyle(err) {
+				ctx.JSONError(ctx.Tr("repo.pulls.invalid_merge_option"))
+			} else {
+				ctx.ServerError("AddToMergeQueue", err)
+			}
+			return
+		} else if added {
+			ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.added"))
+			ctx.JSONRedirect(issue.Link())
+			return
+		}
+	}
+
 	if err := pull_service.Merge(ctx, pr, ctx.Doer, ctx.Repo.GitRepo, repo_model.MergeStyle(form.Do), form.HeadCommitID, message, false); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.JSONError(ctx.Tr("repo.pulls.invalid_merge_option"))
@@ -1338,6 +1365,19 @@ func MergePullRequest(ctx *context.Context) {
 			log.Debug("MergeHeadOutOfDate error: %v", err)
 			ctx.Flash.Error(ctx.Tr("repo.pulls.head_out_of_date"))
 			ctx.JSONRedirect(issue.Link())
+		} else if models.IsErrDisallowedToMerge(err) {
+			log.Debug("MergeDisallowed error: %v", err)
+			flashError, err := ctx.RenderToHTML(tplAlertDetails, map[string]any{
+				"Message": ctx.Tr("repo.pulls.commit_policy_violated"),
+				"Summary": ctx.Tr("repo.pulls.commit_policy_violated_summary"),
+				"Details": utils.SanitizeFlashErrorString(err.(models.ErrDisallowedToMerge).Reason),
+			})
+			if err != nil {
+				ctx.ServerError("MergePullRequest.HTMLString", err)
+				return
+			}
+			ctx.Flash.Error(flashError)
+			ctx.JSONRedirect(issue.Link())
 		} else if git.IsErrPushRejected(err) {
 			log.Debug("MergePushRejected error: %v", err)
 			pushrejErr := err.(*git.ErrPushRejected)
@@ -1400,6 +1440,50 @@ func MergePullRequest(ctx *context.Context) {
 	ctx.JSONRedirect(issue.Link())
 }
 
+// MergePullRequestStack merges the pull requests of a stack in order, from the bottom of the stack up to the pull request
+func MergePullRequestStack(ctx *context.Context) {
+	issue, ok := getPullInfo(ctx)
+	if !ok {
+		return
+	}
+	pr := issue.PullRequest
+
+	stack, err := pull_service.GetPullRequestStack(ctx, pr)
+	if err != nil {
+		ctx.ServerError("GetPullRequestStack", err)
+		return
+	}
+	if len(stack) < 2 || stack[0].ID == pr.ID {
+		ctx.NotFound("MergePullRequestStack", nil)
+		return
+	}
+
+	// pull requests into a branch with a merge queue are merged one by one by the queue
+	if queued, err := mergequeue.IsMergeQueueEnabled(ctx, stack[0]); err != nil {
+		ctx.ServerError("IsMergeQueueEnabled", err)
+		return
+	} else if queued {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.stack.merge_queue_enabled"))
+		ctx.Redirect(issue.Link())
+		return
+	}
+
+	failed, err := pull_service.MergeStack(ctx, ctx.Doer, &ctx.Repo.Permission, ctx.Repo.GitRepo, pr, repo_model.MergeStyle(ctx.FormString("merge_style")))
+	if err != nil {
+		if failed == nil {
+			ctx.ServerError("MergeStack", err)
+			return
+		}
+		log.Debug("Merging the stack of %-v stopped at %-v: %v", pr, failed, err)
+		ctx.Flash.Error(ctx.Tr("repo.pulls.stack.merge_failed", failed.Index, utils.SanitizeFlashErrorString(err.Error())))
+		ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, failed.Index))
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.stack.merged"))
+	ctx.Redirect(issue.Link())
+}
+
 // CancelAutoMergePullRequest cancels a scheduled pr
 func CancelAutoMergePullRequest(ctx *context.Context) {
 	issue, ok := getPullInfo(ctx)
@@ -1420,6 +1504,101 @@ func CancelAutoMergePullRequest(ctx *context.Context) {
 	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index))
 }
 
+// RemoveFromMergeQueue removes a pr from the merge queue of its base branch
+func RemoveFromMergeQueue(ctx *context.Context) {
+	issue, ok := getPullInfo(ctx)
+	if !ok {
+		return
+	}
+
+	pr := issue.PullRequest
+	if ctx.Doer.ID != issue.PosterID {
+		allowed, err := pull_service.IsUserAllowedToMerge(ctx, pr, ctx.Repo.Permission, ctx.Doer)
+		if err != nil {
+			ctx.ServerError("IsUserAllowedToMerge", err)
+			return
+		}
+		if !allowed {
+			ctx.NotFound("RemoveFromMergeQueue", nil)
+			return
+		}
+	}
+
+	if err := mergequeue.RemoveFromMergeQueue(ctx, ctx.Doer, pr, ""); err != nil {
+		if db.IsErrNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue.not_queued"))
+			ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index))
+			return
+		}
+		ctx.ServerError("RemoveFromMergeQueue", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.removed"))
+	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index))
+}
+
+// mergeQueueView represents the merge queue of a branch
+type mergeQueueView struct {
+	Branch  string
+	Entries []*mergeQueueEntryView
+}
+
+// mergeQueueEntryView represents an entry of a merge queue
+type mergeQueueEntryView struct {
+	*pull_model.MergeQueueEntry
+	Pull         *issues_model.PullRequest
+	CommitStatus *git_model.CommitStatus
+}
+
+// MergeQueue renders the merge queues of all branches of a repository
+func MergeQueue(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.pulls.merge_queue.title")
+	ctx.Data["PageIsPullList"] = true
+	ctx.Data["PageIsMergeQueue"] = true
+
+	entries, err := pull_model.FindMergeQueueEntries(ctx, ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("FindMergeQueueEntries", err)
+		return
+	}
+
+	queues := make([]*mergeQueueView, 0, 2)
+	for _, entry := range entries {
+		if err := entry.LoadDoer(ctx); err != nil {
+			ctx.ServerError("LoadDoer", err)
+			return
+		}
+		pr, err := issues_model.GetPullRequestByID(ctx, entry.PullID)
+		if err != nil {
+			ctx.ServerError("GetPullRequestByID", err)
+			return
+		}
+		if err := pr.LoadIssue(ctx); err != nil {
+			ctx.ServerError("LoadIssue", err)
+			return
+		}
+
+		view := &mergeQueueEntryView{MergeQueueEntry: entry, Pull: pr}
+		if entry.IsTesting() {
+			statuses, _, err := git_model.GetLatestCommitStatus(ctx, ctx.Repo.Repository.ID, entry.CommitID, db.ListOptionsAll)
+			if err != nil {
+				ctx.ServerError("GetLatestCommitStatus", err)
+				return
+			}
+			view.CommitStatus = git_model.CalcCommitStatus(statuses)
+		}
+
+		if len(queues) == 0 || queues[len(queues)-1].Branch != entry.BaseBranch {
+			queues = append(queues, &mergeQueueView{Branch: entry.BaseBranch})
+		}
+		queue := queues[len(queues)-1]
+		queue.Entries = append(queue.Entries, view)
+	}
+	ctx.Data["MergeQueues"] = queues
+
+	ctx.HTML(http.StatusOK, tplPullMergeQueue)
+}
+
 func stopTimerIfAvailable(ctx *context.Context, user *user_model.User, issue *issues_model.Issue) error {
 	if issues_model.StopwatchExists(ctx, user.ID, issue.ID) {
 		if err := issues_model.CreateOrStopIssueStopwatch(ctx, user, issue); err != nil {
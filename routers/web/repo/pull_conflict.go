@@ -0,0 +1,202 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/utils"
+	"code.gitea.io/gitea/services/context"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+const tplPullConflicts base.TplName = "repo/pulls/conflicts"
+
+// getConflictsPullInfo gets the open pull request and checks if the doer can push a resolution of its conflicts
+func getConflictsPullInfo(ctx *context.Context) (issue *issues_model.Issue, canResolveOnHeadBranch, canResolveOnNewBranch, ok bool) {
+	issue, ok = getPullInfo(ctx)
+	if !ok {
+		return nil, false, false, false
+	}
+	if issue.IsClosed || issue.PullRequest.HasMerged {
+		ctx.NotFound("PullConflicts", nil)
+		return nil, false, false, false
+	}
+
+	var err error
+	canResolveOnHeadBranch, err = pull_service.IsUserAllowedToResolveConflicts(ctx, issue.PullRequest, ctx.Doer, false)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToResolveConflicts", err)
+		return nil, false, false, false
+	}
+	canResolveOnNewBranch, err = pull_service.IsUserAllowedToResolveConflicts(ctx, issue.PullRequest, ctx.Doer, true)
+	if err != nil {
+		ctx.ServerError("IsUserAllowedToResolveConflicts", err)
+		return nil, false, false, false
+	}
+	if !canResolveOnHeadBranch && !canResolveOnNewBranch {
+		ctx.NotFound("IsUserAllowedToResolveConflicts", nil)
+		return nil, false, false, false
+	}
+	return issue, canResolveOnHeadBranch, canResolveOnNewBranch, true
+}
+
+// PullConflicts shows the editor to resolve the conflicts between the base and the head branch of a pull request
+func PullConflicts(ctx *context.Context) {
+	issue, canResolveOnHeadBranch, canResolveOnNewBranch, ok := getConflictsPullInfo(ctx)
+	if !ok {
+		return
+	}
+	pr := issue.PullRequest
+
+	headCommitID, files, err := pull_service.GetConflictFiles(ctx, pr, ctx.Doer)
+	if err != nil {
+		if models.IsErrMergeUnrelatedHistories(err) || git_model.IsErrBranchNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.unavailable"))
+			ctx.Redirect(issue.Link())
+			return
+		}
+		ctx.ServerError("GetConflictFiles", err)
+		return
+	}
+
+	ctx.Data["PageIsPullConflicts"] = true
+	ctx.Data["ConflictFiles"] = files
+	ctx.Data["HeadCommitID"] = headCommitID
+	ctx.Data["CanResolveOnHeadBranch"] = canResolveOnHeadBranch
+	ctx.Data["CanResolveOnNewBranch"] = canResolveOnNewBranch
+	ctx.Data["DefaultMessage"] = fmt.Sprintf("Merge branch '%s' into %s", pr.BaseBranch, pr.HeadBranch)
+	ctx.HTML(http.StatusOK, tplPullConflicts)
+}
+
+// PullConflictsPost commits the resolution of the conflicts of a pull request
+func PullConflictsPost(ctx *context.Context) {
+	issue, canResolveOnHeadBranch, canResolveOnNewBranch, ok := getConflictsPullInfo(ctx)
+	if !ok {
+		return
+	}
+	pr := issue.PullRequest
+	conflictsLink := issue.Link() + "/conflicts"
+
+	newBranch := ""
+	if ctx.FormString("target") == "new" {
+		newBranch = ctx.FormTrim("new_branch")
+		if newBranch == "" || !canResolveOnNewBranch {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.new_branch_invalid"))
+			ctx.Redirect(conflictsLink)
+			return
+		}
+	} else if !canResolveOnHeadBranch {
+		ctx.NotFound("IsUserAllowedToResolveConflicts", nil)
+		return
+	}
+
+	commitID, err := pull_service.ResolveConflicts(ctx, pr, ctx.Doer, &pull_service.ResolveConflictsOptions{
+		Resolutions:          parseConflictResolutions(ctx),
+		Message:              ctx.FormString("message"),
+		ExpectedHeadCommitID: ctx.FormString("head_commit_id"),
+		NewBranch:            newBranch,
+	})
+	if err != nil {
+		switch {
+		case pull_service.IsErrInvalidConflictResolution(err):
+			resolutionErr := err.(pull_service.ErrInvalidConflictResolution)
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.invalid_resolution", resolutionErr.Path, resolutionErr.Reason))
+		case errors.Is(err, util.ErrInvalidArgument):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.new_branch_invalid"))
+		case git_model.IsErrBranchAlreadyExists(err):
+			ctx.Flash.Error(ctx.Tr("repo.branch.branch_already_exists", newBranch))
+		case models.IsErrSHADoesNotMatch(err), git.IsErrPushOutOfDate(err):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.outdated"))
+		case models.IsErrMergeUnrelatedHistories(err):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.conflicts.unavailable"))
+		case git.IsErrPushRejected(err):
+			pushrejErr := err.(*git.ErrPushRejected)
+			if len(pushrejErr.Message) == 0 {
+				ctx.Flash.Error(ctx.Tr("repo.pulls.push_rejected_no_message"))
+				break
+			}
+			flashError, err := ctx.RenderToHTML(tplAlertDetails, map[string]any{
+				"Message": ctx.Tr("repo.pulls.push_rejected"),
+				"Summary": ctx.Tr("repo.pulls.push_rejected_summary"),
+				"Details": utils.SanitizeFlashErrorString(pushrejErr.Message),
+			})
+			if err != nil {
+				ctx.ServerError("PullConflictsPost.HTMLString", err)
+				return
+			}
+			ctx.Flash.Error(flashError)
+		default:
+			ctx.ServerError("ResolveConflicts", err)
+			return
+		}
+		ctx.Redirect(conflictsLink)
+		return
+	}
+
+	if newBranch != "" {
+		ctx.Flash.Success(ctx.Tr("repo.pulls.conflicts.resolved_new_branch", base.ShortSha(commitID), newBranch))
+		ctx.Redirect(ctx.Repo.RepoLink + "/compare/" + util.PathEscapeSegments(pr.BaseBranch) + "..." +
+			url.PathEscape(pr.HeadRepo.OwnerName) + ":" + util.PathEscapeSegments(newBranch))
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("repo.pulls.conflicts.resolved", base.ShortSha(commitID)))
+	ctx.Redirect(issue.Link())
+}
+
+// parseConflictResolutions reads the resolutions of the conflict editor. The i-th file has the fields "path_i" and
+// "resolution_i", which is either "hunks", "content", "delete" or a side. The hunks are resolved by the sides picked
+// in the fields "hunk_i_j" and the content is given by the field "content_i".
+func parseConflictResolutions(ctx *context.Context) []*pull_service.ConflictResolution {
+	if err := ctx.Req.ParseForm(); err != nil {
+		return nil
+	}
+
+	var resolutions []*pull_service.ConflictResolution
+	for i := 0; ; i++ {
+		path := ctx.Req.PostFormValue(fmt.Sprintf("path_%d", i))
+		if path == "" {
+			break
+		}
+		res := &pull_service.ConflictResolution{Path: path}
+
+		switch resolution := ctx.Req.PostFormValue(fmt.Sprintf("resolution_%d", i)); resolution {
+		case "hunks":
+			prefix := fmt.Sprintf("hunk_%d_", i)
+			positions := make([]int, 0, 4)
+			for key := range ctx.Req.PostForm {
+				if position, err := strconv.Atoi(strings.TrimPrefix(key, prefix)); strings.HasPrefix(key, prefix) && err == nil {
+					positions = append(positions, position)
+				}
+			}
+			sort.Ints(positions)
+			res.Hunks = make([]pull_service.ConflictSide, 0, len(positions))
+			for _, position := range positions {
+				res.Hunks = append(res.Hunks, pull_service.ConflictSide(ctx.Req.PostFormValue(prefix+strconv.Itoa(position))))
+			}
+		case "content":
+			// browsers submit the lines of a textarea separated by CRLF
+			content := strings.ReplaceAll(ctx.Req.PostFormValue(fmt.Sprintf("content_%d", i)), "\r\n", "\n")
+			res.Content = &content
+		case "delete":
+			res.Delete = true
+		default:
+			res.Side = pull_service.ConflictSide(resolution)
+		}
+		resolutions = append(resolutions, res)
+	}
+	return resolutions
+}
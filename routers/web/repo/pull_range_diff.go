@@ -0,0 +1,98 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/services/context"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+const tplPullRangeDiff base.TplName = "repo/pulls/range_diff"
+
+// ViewPullRangeDiff compares the commits of two versions of a pull request, which are given by their head commits.
+// Without them, the version reviewed last by the doer is compared with the current version.
+func ViewPullRangeDiff(ctx *context.Context) {
+	ctx.Data["PageIsPullList"] = true
+
+	issue, ok := getPullInfo(ctx)
+	if !ok {
+		return
+	}
+	pull := issue.PullRequest
+
+	var prInfo *git.CompareInfo
+	if pull.HasMerged {
+		prInfo = PrepareMergedViewPullInfo(ctx, issue)
+	} else {
+		prInfo = PrepareViewPullInfo(ctx, issue)
+	}
+	if ctx.Written() {
+		return
+	} else if prInfo == nil {
+		ctx.NotFound("ViewPullRangeDiff", nil)
+		return
+	}
+
+	var lastReviewCommitID string
+	if ctx.IsSigned {
+		var err error
+		lastReviewCommitID, err = pull_service.GetLastReviewCommitID(ctx, issue, ctx.Doer)
+		if err != nil {
+			ctx.ServerError("GetLastReviewCommitID", err)
+			return
+		}
+	}
+
+	oldCommitID, newCommitID := ctx.Params("shaFrom"), ctx.Params("shaTo")
+	if oldCommitID == "" {
+		if lastReviewCommitID == "" {
+			ctx.NotFound("GetLastReviewCommitID", nil)
+			return
+		}
+		oldCommitID, newCommitID = lastReviewCommitID, prInfo.HeadCommitID
+	}
+
+	oldCommit, err := ctx.Repo.GitRepo.GetCommit(oldCommitID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetCommit", err)
+		} else {
+			ctx.ServerError("GetCommit", err)
+		}
+		return
+	}
+	newCommit, err := ctx.Repo.GitRepo.GetCommit(newCommitID)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.NotFound("GetCommit", err)
+		} else {
+			ctx.ServerError("GetCommit", err)
+		}
+		return
+	}
+
+	pairs, err := pull_service.GetRangeDiff(ctx.Repo.GitRepo, pull, oldCommit.ID.String(), newCommit.ID.String())
+	if err != nil {
+		ctx.ServerError("GetRangeDiff", err)
+		return
+	}
+
+	ctx.Data["RangeDiff"] = pairs
+	ctx.Data["OldCommitID"] = oldCommit.ID.String()
+	ctx.Data["NewCommitID"] = newCommit.ID.String()
+	if lastReviewCommitID != "" && lastReviewCommitID != prInfo.HeadCommitID {
+		ctx.Data["LastReviewCommitID"] = lastReviewCommitID
+	}
+
+	PrepareBranchList(ctx)
+	if ctx.Written() {
+		return
+	}
+	getBranchData(ctx, issue)
+	ctx.HTML(http.StatusOK, tplPullRangeDiff)
+}
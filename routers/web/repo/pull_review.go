@@ -8,17 +8,20 @@ import (
 	"fmt"
 	"net/http"
 
+	"code.gitea.io/gitea/models"
 	issues_model "code.gitea.io/gitea/models/issues"
 	pull_model "code.gitea.io/gitea/models/pull"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/context/upload"
 	"code.gitea.io/gitea/services/forms"
 	pull_service "code.gitea.io/gitea/services/pull"
+	files_service "code.gitea.io/gitea/services/repository/files"
 )
 
 const (
@@ -187,6 +190,10 @@ func renderConversation(ctx *context.Context, comment *issues_model.Comment, ori
 		ctx.ServerError("comment.Issue.LoadPullRequest", err)
 		return
 	}
+	if ctx.Data["CanApplyCodeSuggestions"], err = files_service.CanApplyCodeSuggestions(ctx, comment.Issue.PullRequest, ctx.Doer); err != nil {
+		ctx.ServerError("CanApplyCodeSuggestions", err)
+		return
+	}
 	pullHeadCommitID, err := ctx.Repo.GitRepo.GetRefCommitID(comment.Issue.PullRequest.GetGitRefName())
 	if err != nil {
 		ctx.ServerError("GetRefCommitID", err)
@@ -274,6 +281,58 @@ func DismissReview(ctx *context.Context) {
 	ctx.Redirect(fmt.Sprintf("%s/pulls/%d#%s", ctx.Repo.RepoLink, comm.Issue.Index, comm.HashTag()))
 }
 
+// ApplyCodeSuggestions applies the suggestions of the selected code comments as a single commit on the head branch
+func ApplyCodeSuggestions(ctx *context.Context) {
+	issue, ok := getPullInfo(ctx)
+	if !ok {
+		return
+	}
+	pr := issue.PullRequest
+
+	canApply, err := files_service.CanApplyCodeSuggestions(ctx, pr, ctx.Doer)
+	if err != nil {
+		ctx.ServerError("CanApplyCodeSuggestions", err)
+		return
+	}
+	if !canApply {
+		ctx.NotFound("CanApplyCodeSuggestions", nil)
+		return
+	}
+
+	redirect := fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index)
+	if ctx.FormString("origin") == "timeline" {
+		redirect = issue.Link()
+	}
+
+	commentIDs, err := base.StringsToInt64s(ctx.FormStrings("comment_ids"))
+	if err != nil || len(commentIDs) == 0 {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.none_selected"))
+		ctx.Redirect(redirect)
+		return
+	}
+
+	if _, err := files_service.ApplyCodeSuggestions(ctx, ctx.Doer, pr, commentIDs, ctx.FormString("commit_message")); err != nil {
+		switch {
+		case files_service.IsErrCodeSuggestionOutdated(err), models.IsErrCommitIDDoesNotMatch(err), models.IsErrSHADoesNotMatch(err):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.outdated"))
+		case files_service.IsErrCodeSuggestionConflict(err):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.conflict"))
+		case models.IsErrUserCannotCommit(err), models.IsErrFilePathProtected(err):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.not_allowed"))
+		case issues_model.IsErrCommentNotExist(err), errors.Is(err, util.ErrInvalidArgument):
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.none_selected"))
+		default:
+			ctx.ServerError("ApplyCodeSuggestions", err)
+			return
+		}
+		ctx.Redirect(redirect)
+		return
+	}
+
+	ctx.Flash.Success(ctx.TrN(len(commentIDs), "repo.pulls.suggestion.applied_1", "repo.pulls.suggestion.applied_n", len(commentIDs)))
+	ctx.Redirect(redirect)
+}
+
 // viewedFilesUpdate Struct to parse the body of a request to update the reviewed files of a PR
 // If you want to implement an API to update the review, simply move this struct into modules.
 type viewedFilesUpdate struct {
@@ -4,14 +4,14 @@
 package repo
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/base"
-	"code.gitea.io/gitea/modules/git"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/services/context"
 )
 
@@ -42,19 +42,22 @@ func Search(ctx *context.Context) {
 	var total int
 	var searchResults []*code_indexer.Result
 	var searchResultLanguages []*code_indexer.SearchResultLanguages
+	var err error
+	opts := &code_indexer.SearchOptions{
+		RepoIDs:        []int64{ctx.Repo.Repository.ID},
+		Keyword:        keyword,
+		IsKeywordFuzzy: isFuzzy,
+		Language:       language,
+		Paginator: &db.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.RepoSearchPagingNum,
+		},
+	}
 	if setting.Indexer.RepoIndexerEnabled {
-		var err error
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
-			RepoIDs:        []int64{ctx.Repo.Repository.ID},
-			Keyword:        keyword,
-			IsKeywordFuzzy: isFuzzy,
-			Language:       language,
-			Paginator: &db.ListOptions{
-				Page:     page,
-				PageSize: setting.UI.RepoSearchPagingNum,
-			},
-		})
-		if err != nil {
+		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, opts)
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Data["CodeSearchError"] = err.Error()
+		} else if err != nil {
 			if code_indexer.IsAvailable(ctx) {
 				ctx.ServerError("SearchResults", err)
 				return
@@ -64,30 +67,13 @@ func Search(ctx *context.Context) {
 			ctx.Data["CodeIndexerUnavailable"] = !code_indexer.IsAvailable(ctx)
 		}
 	} else {
-		res, err := git.GrepSearch(ctx, ctx.Repo.GitRepo, keyword, git.GrepOptions{
-			ContextLineNumber: 1,
-			IsFuzzy:           isFuzzy,
-			RefName:           ctx.Repo.RefName,
-		})
-		if err != nil {
+		total, searchResults, searchResultLanguages, err = code_indexer.GrepSearch(ctx, ctx.Repo.Repository, ctx.Repo.GitRepo, ctx.Repo.RefName, opts)
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Data["CodeSearchError"] = err.Error()
+		} else if err != nil {
 			ctx.ServerError("GrepSearch", err)
 			return
 		}
-		total = len(res)
-		pageStart := min((page-1)*setting.UI.RepoSearchPagingNum, len(res))
-		pageEnd := min(page*setting.UI.RepoSearchPagingNum, len(res))
-		res = res[pageStart:pageEnd]
-		for _, r := range res {
-			searchResults = append(searchResults, &code_indexer.Result{
-				RepoID:   ctx.Repo.Repository.ID,
-				Filename: r.Filename,
-				CommitID: ctx.Repo.CommitID,
-				// UpdatedUnix: not supported yet
-				// Language:    not supported yet
-				// Color:       not supported yet
-				Lines: code_indexer.HighlightSearchResultCode(r.Filename, r.LineNumbers, strings.Join(r.LineCodes, "\n")),
-			})
-		}
 	}
 
 	ctx.Data["CodeIndexerDisabled"] = !setting.Indexer.RepoIndexerEnabled
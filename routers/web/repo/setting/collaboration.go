@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/organization"
 	"code.gitea.io/gitea/models/perm"
@@ -17,6 +18,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/mailer"
 	org_service "code.gitea.io/gitea/services/org"
@@ -117,6 +119,9 @@ func CollaborationPost(ctx *context.Context) {
 		return
 	}
 
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorAdd, audit_service.RepoTarget(ctx.Repo.Repository, "user", u.ID, u.Name),
+		nil, audit_service.State{"access_mode": perm.AccessModeWrite.String()})
+
 	if setting.Service.EnableNotifyMail {
 		mailer.SendCollaboratorMail(u, ctx.Doer, ctx.Repo.Repository)
 	}
@@ -127,13 +132,29 @@ func CollaborationPost(ctx *context.Context) {
 
 // ChangeCollaborationAccessMode response for changing access of a collaboration
 func ChangeCollaborationAccessMode(ctx *context.Context) {
-	if err := repo_model.ChangeCollaborationAccessMode(
-		ctx,
-		ctx.Repo.Repository,
-		ctx.FormInt64("uid"),
-		perm.AccessMode(ctx.FormInt("mode"))); err != nil {
+	uid := ctx.FormInt64("uid")
+	mode := perm.AccessMode(ctx.FormInt("mode"))
+	collaboration, err := repo_model.GetCollaboration(ctx, ctx.Repo.Repository.ID, uid)
+	if err != nil {
+		log.Error("GetCollaboration: %v", err)
+		return
+	}
+	if err := repo_model.ChangeCollaborationAccessMode(ctx, ctx.Repo.Repository, uid, mode); err != nil {
 		log.Error("ChangeCollaborationAccessMode: %v", err)
+		return
+	}
+	if collaboration != nil && collaboration.Mode != mode {
+		recordCollaborator(ctx, audit_model.ActionCollaboratorUpdate, uid,
+			audit_service.State{"access_mode": collaboration.Mode.String()}, audit_service.State{"access_mode": mode.String()})
+	}
+}
+
+func recordCollaborator(ctx *context.Context, action audit_model.Action, uid int64, before, after any) {
+	var name string
+	if u, err := user_model.GetUserByID(ctx, uid); err == nil {
+		name = u.Name
 	}
+	audit_service.Record(ctx, ctx.Doer, action, audit_service.RepoTarget(ctx.Repo.Repository, "user", uid, name), before, after)
 }
 
 // DeleteCollaboration delete a collaboration for a repository
@@ -141,6 +162,7 @@ func DeleteCollaboration(ctx *context.Context) {
 	if err := repo_service.DeleteCollaboration(ctx, ctx.Repo.Repository, ctx.FormInt64("id")); err != nil {
 		ctx.Flash.Error("DeleteCollaboration: " + err.Error())
 	} else {
+		recordCollaborator(ctx, audit_model.ActionCollaboratorRemove, ctx.FormInt64("id"), nil, nil)
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_collaborator_success"))
 	}
 
@@ -188,6 +210,8 @@ func AddTeamPost(ctx *context.Context) {
 		ctx.ServerError("TeamAddRepository", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorAdd, audit_service.RepoTarget(ctx.Repo.Repository, "team", team.ID, team.Name),
+		nil, audit_service.State{"access_mode": team.AccessMode.String()})
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_team_success"))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
@@ -211,6 +235,7 @@ func DeleteTeam(ctx *context.Context) {
 		ctx.ServerError("team.RemoveRepositorys", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionCollaboratorRemove, audit_service.RepoTarget(ctx.Repo.Repository, "team", team.ID, team.Name), nil, nil)
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.remove_team_success"))
 	ctx.JSONRedirect(ctx.Repo.RepoLink + "/settings/collaboration")
@@ -7,11 +7,13 @@ import (
 	"net/http"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 )
@@ -93,15 +95,25 @@ func DeployKeysPost(ctx *context.Context) {
 	}
 
 	log.Trace("Deploy key added: %d", ctx.Repo.Repository.ID)
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionDeployKeyAdd, audit_service.RepoTarget(ctx.Repo.Repository, "deploy_key", key.ID, key.Name),
+		nil, audit_service.DeployKeyState(key))
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_key_success", key.Name))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
 }
 
 // DeleteDeployKey response for deleting a deploy key
 func DeleteDeployKey(ctx *context.Context) {
-	if err := asymkey_service.DeleteDeployKey(ctx, ctx.Doer, ctx.FormInt64("id")); err != nil {
+	id := ctx.FormInt64("id")
+	key, err := asymkey_model.GetDeployKeyByID(ctx, id)
+	if err != nil && !asymkey_model.IsErrDeployKeyNotExist(err) {
+		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
+	} else if err := asymkey_service.DeleteDeployKey(ctx, ctx.Doer, id); err != nil {
 		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
 	} else {
+		if key != nil {
+			audit_service.Record(ctx, ctx.Doer, audit_model.ActionDeployKeyRemove, audit_service.RepoTarget(ctx.Repo.Repository, "deploy_key", key.ID, key.Name),
+				audit_service.DeployKeyState(key), nil)
+		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.deploy_key_deletion_success"))
 	}
 
@@ -0,0 +1,52 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/base"
+	shared_issuefield "code.gitea.io/gitea/routers/web/shared/issuefield"
+	"code.gitea.io/gitea/services/context"
+)
+
+const (
+	tplIssueFields     base.TplName = "repo/settings/issue_fields"
+	tplIssueFieldsEdit base.TplName = "repo/settings/issue_fields_edit"
+)
+
+// IssueFields renders the custom issue fields of a repository
+func IssueFields(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.issues.fields")
+	ctx.Data["PageIsSettingsIssueFields"] = true
+	ctx.Data["IssueFieldsLink"] = ctx.Repo.RepoLink + "/settings/issue_fields"
+
+	shared_issuefield.SetIssueFieldsContext(ctx, 0, ctx.Repo.Repository.ID)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplIssueFields)
+}
+
+// IssueFieldEdit renders the page to create or edit a custom issue field of a repository
+func IssueFieldEdit(ctx *context.Context) {
+	ctx.Data["PageIsSettingsIssueFields"] = true
+	ctx.Data["IssueFieldsLink"] = ctx.Repo.RepoLink + "/settings/issue_fields"
+
+	shared_issuefield.SetIssueFieldEditContext(ctx, 0, ctx.Repo.Repository.ID)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(http.StatusOK, tplIssueFieldsEdit)
+}
+
+// IssueFieldEditPost creates or updates a custom issue field of a repository
+func IssueFieldEditPost(ctx *context.Context) {
+	shared_issuefield.PerformIssueFieldPost(ctx, 0, ctx.Repo.Repository.ID, ctx.Repo.RepoLink+"/settings/issue_fields")
+}
+
+// IssueFieldDelete deletes a custom issue field of a repository
+func IssueFieldDelete(ctx *context.Context) {
+	shared_issuefield.PerformIssueFieldDelete(ctx, 0, ctx.Repo.Repository.ID, ctx.Repo.RepoLink+"/settings/issue_fields")
+}
@@ -11,14 +11,18 @@ import (
 	"strings"
 	"time"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	git_model "code.gitea.io/gitea/models/git"
 	"code.gitea.io/gitea/models/organization"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
 	"code.gitea.io/gitea/modules/base"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/web/repo"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/forms"
 	pull_service "code.gitea.io/gitea/services/pull"
 	"code.gitea.io/gitea/services/repository"
@@ -42,6 +46,13 @@ func ProtectedBranchRules(ctx *context.Context) {
 	}
 	ctx.Data["ProtectedBranches"] = rules
 
+	rulesets, err := git_model.FindRepoOrgRulesets(ctx, ctx.Repo.Repository, git_model.OrgRulesetTargetBranch)
+	if err != nil {
+		ctx.ServerError("FindRepoOrgRulesets", err)
+		return
+	}
+	ctx.Data["OrgRulesets"] = rulesets
+
 	repo.PrepareBranchList(ctx)
 	if ctx.Written() {
 		return
@@ -145,6 +156,10 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 			return
 		}
 	}
+	var before *api.BranchProtection
+	if protectBranch != nil {
+		before = convert.ToBranchProtection(ctx, protectBranch, ctx.Repo.Repository)
+	}
 	if protectBranch == nil {
 		// No options found, create defaults.
 		protectBranch = &git_model.ProtectedBranch{
@@ -238,6 +253,16 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 	protectBranch.UnprotectedFilePatterns = f.UnprotectedFilePatterns
 	protectBranch.BlockOnOutdatedBranch = f.BlockOnOutdatedBranch
 	protectBranch.ApplyToAdmins = f.ApplyToAdmins
+	protectBranch.EnableMergeQueue = f.EnableMergeQueue
+	protectBranch.RequireCodeOwnerApproval = f.RequireCodeOwnerApproval
+	protectBranch.RequireLinearHistory = f.RequireLinearHistory
+	protectBranch.RequireSignoff = f.RequireSignoff
+	protectBranch.CommitMessagePattern = strings.TrimSpace(f.CommitMessagePattern)
+	if _, err := protectBranch.GetCommitMessageRegexp(); err != nil {
+		ctx.Flash.Error(ctx.Tr("repo.settings.protect_invalid_commit_message_pattern", protectBranch.CommitMessagePattern))
+		ctx.Redirect(fmt.Sprintf("%s/settings/branches/edit?rule_name=%s", ctx.Repo.RepoLink, url.QueryEscape(protectBranch.RuleName)))
+		return
+	}
 
 	err = git_model.UpdateProtectBranch(ctx, ctx.Repo.Repository, protectBranch, git_model.WhitelistOptions{
 		UserIDs:          whitelistUsers,
@@ -251,6 +276,8 @@ func SettingsProtectedBranchPost(ctx *context.Context) {
 		ctx.ServerError("UpdateProtectBranch", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionBranchProtectionUpdate, audit_service.RepoTarget(ctx.Repo.Repository, "branch_protection", protectBranch.ID, protectBranch.RuleName),
+		before, convert.ToBranchProtection(ctx, protectBranch, ctx.Repo.Repository))
 
 	// FIXME: since we only need to recheck files protected rules, we could improve this
 	matchedBranches, err := git_model.FindAllMatchedBranches(ctx, ctx.Repo.Repository.ID, protectBranch.RuleName)
@@ -297,6 +324,8 @@ func DeleteProtectedBranchRulePost(ctx *context.Context) {
 		return
 	}
 
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionBranchProtectionDelete, audit_service.RepoTarget(ctx.Repo.Repository, "branch_protection", rule.ID, rule.RuleName),
+		convert.ToBranchProtection(ctx, rule, ctx.Repo.Repository), nil)
 	ctx.Flash.Success(ctx.Tr("repo.settings.remove_protected_branch_success", rule.RuleName))
 	ctx.JSONRedirect(fmt.Sprintf("%s/settings/branches", ctx.Repo.RepoLink))
 }
@@ -147,6 +147,13 @@ func setTagsContext(ctx *context.Context) error {
 	}
 	ctx.Data["ProtectedTags"] = protectedTags
 
+	rulesets, err := git_model.FindRepoOrgRulesets(ctx, ctx.Repo.Repository, git_model.OrgRulesetTargetTag)
+	if err != nil {
+		ctx.ServerError("FindRepoOrgRulesets", err)
+		return err
+	}
+	ctx.Data["OrgRulesets"] = rulesets
+
 	users, err := access_model.GetRepoReaders(ctx, ctx.Repo.Repository)
 	if err != nil {
 		ctx.ServerError("Repo.Repository.GetReaders", err)
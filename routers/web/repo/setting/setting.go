@@ -261,6 +261,7 @@ func UnitsPost(ctx *context.Context) {
 				DefaultDeleteBranchAfterMerge: form.DefaultDeleteBranchAfterMerge,
 				DefaultMergeStyle:             repo_model.MergeStyle(form.PullsDefaultMergeStyle),
 				DefaultAllowMaintainerEdit:    form.DefaultAllowMaintainerEdit,
+				RebaseStackedPullsOnMerge:     form.RebaseStackedPullsOnMerge,
 			},
 		})
 	} else if !unit_model.TypePullRequests.UnitGlobalDisabled() {
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"path"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
@@ -23,6 +24,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web/middleware"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 	"code.gitea.io/gitea/services/forms"
@@ -264,6 +266,7 @@ func WebhookCreate(ctx *context.Context) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookCreate, audit_service.WebhookTarget(ctx, w), nil, audit_service.WebhookState(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -279,6 +282,7 @@ func WebhookUpdate(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Webhook"] = w
+	before := audit_service.WebhookState(w)
 
 	handler := webhook_service.GetWebhookHandler(w.Type)
 	if handler == nil {
@@ -329,6 +333,7 @@ func WebhookUpdate(ctx *context.Context) {
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookUpdate, audit_service.WebhookTarget(ctx, w), before, audit_service.WebhookState(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -475,9 +480,13 @@ func WebhookReplay(ctx *context.Context) {
 
 // WebhookDelete delete a webhook
 func WebhookDelete(ctx *context.Context) {
-	if err := webhook.DeleteWebhookByRepoID(ctx, ctx.Repo.Repository.ID, ctx.FormInt64("id")); err != nil {
+	w, err := webhook.GetWebhookByRepoID(ctx, ctx.Repo.Repository.ID, ctx.FormInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetWebhookByRepoID: " + err.Error())
+	} else if err := webhook.DeleteWebhookByRepoID(ctx, ctx.Repo.Repository.ID, w.ID); err != nil {
 		ctx.Flash.Error("DeleteWebhookByRepoID: " + err.Error())
 	} else {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionWebhookDelete, audit_service.WebhookTarget(ctx, w), audit_service.WebhookState(w), nil)
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
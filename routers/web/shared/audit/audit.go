@@ -0,0 +1,161 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+const eventsPagingNum = 50
+
+// findEventsOptions returns the options to find the audit events of an owner or a repository, or of the instance if
+// both are 0, filtered by the action and the actor of the request. It returns false if no event can match the filters.
+func findEventsOptions(ctx *context.Context, ownerID, repoID int64) (audit_model.FindEventsOptions, bool) {
+	opts := audit_model.FindEventsOptions{
+		OwnerID: ownerID,
+		RepoID:  repoID,
+	}
+
+	if action := audit_model.Action(ctx.FormString("action")); action.IsValid() {
+		opts.Action = action
+		ctx.Data["AuditAction"] = action
+	}
+
+	if actor := ctx.FormTrim("actor"); actor != "" {
+		ctx.Data["AuditActor"] = actor
+		u, err := user_model.GetUserByName(ctx, actor)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				return opts, false
+			}
+			ctx.ServerError("GetUserByName", err)
+			return opts, false
+		}
+		opts.ActorID = u.ID
+	}
+	return opts, true
+}
+
+// SetEventsContext loads a page of the audit events of an owner or a repository, or of the instance if both are 0
+func SetEventsContext(ctx *context.Context, ownerID, repoID int64) {
+	ctx.Data["AuditEnabled"] = setting.Audit.Enabled
+	ctx.Data["AuditActions"] = audit_model.Actions
+
+	page := ctx.FormInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var events []*audit_model.Event
+	var total int64
+	opts, ok := findEventsOptions(ctx, ownerID, repoID)
+	if ctx.Written() {
+		return
+	}
+	if ok {
+		opts.ListOptions = db.ListOptions{Page: page, PageSize: eventsPagingNum}
+		var err error
+		events, total, err = db.FindAndCount[audit_model.Event](ctx, opts)
+		if err != nil {
+			ctx.ServerError("FindEvents", err)
+			return
+		}
+	}
+	ctx.Data["AuditEvents"] = events
+	ctx.Data["Total"] = total
+
+	pager := context.NewPagination(int(total), eventsPagingNum, page, 5)
+	pager.AddParam(ctx, "action", "AuditAction")
+	pager.AddParam(ctx, "actor", "AuditActor")
+	ctx.Data["Page"] = pager
+}
+
+// ExportEvents writes all the audit events of an owner or a repository, or of the instance if both are 0, matching the
+// filters of the request as CSV or, by default, as JSON lines
+func ExportEvents(ctx *context.Context, ownerID, repoID int64) {
+	opts, ok := findEventsOptions(ctx, ownerID, repoID)
+	if ctx.Written() {
+		return
+	}
+
+	format := ctx.FormString("format")
+	var write func(e *audit_model.Event) error
+	var flush func() error
+	switch format {
+	case "csv":
+		w := csv.NewWriter(ctx.Resp)
+		write = func(e *audit_model.Event) error {
+			return w.Write([]string{
+				strconv.FormatInt(e.ID, 10), e.CreatedUnix.AsTime().UTC().Format("2006-01-02T15:04:05Z"), string(e.Action),
+				strconv.FormatInt(e.ActorID, 10), e.ActorName, e.ActorIP,
+				strconv.FormatInt(e.OwnerID, 10), strconv.FormatInt(e.RepoID, 10),
+				e.TargetType, strconv.FormatInt(e.TargetID, 10), e.TargetName,
+				e.Before, e.After, e.PrevHash, e.Hash,
+			})
+		}
+		flush = func() error {
+			w.Flush()
+			return w.Error()
+		}
+		ctx.Resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		ctx.Resp.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+		ctx.Resp.WriteHeader(http.StatusOK)
+		if err := w.Write([]string{
+			"id", "created_at", "action", "actor_id", "actor_name", "actor_ip", "owner_id", "repo_id",
+			"target_type", "target_id", "target_name", "before", "after", "prev_hash", "hash",
+		}); err != nil {
+			log.Error("Unable to export the audit log: %v", err)
+			return
+		}
+	default:
+		enc := json.NewEncoder(ctx.Resp)
+		write = func(e *audit_model.Event) error {
+			return enc.Encode(convert.ToAuditEvent(e))
+		}
+		flush = func() error { return nil }
+		ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+		ctx.Resp.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+		ctx.Resp.WriteHeader(http.StatusOK)
+	}
+	if !ok {
+		return
+	}
+
+	opts.PageSize = setting.Database.IterateBufferSize
+	for page := 1; ; page++ {
+		opts.Page = page
+		events, err := db.Find[audit_model.Event](ctx, opts)
+		if err != nil {
+			log.Error("Unable to export the audit log: %v", err)
+			return
+		}
+		if page == 1 && len(events) > 0 {
+			opts.UntilID = events[0].ID
+		}
+		for _, e := range events {
+			if err := write(e); err != nil {
+				log.Error("Unable to export the audit log: %v", err)
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			log.Error("Unable to export the audit log: %v", err)
+			return
+		}
+		if len(events) < opts.PageSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issuefield
+
+import (
+	"fmt"
+	"strings"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/forms"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// getIssueField returns the field of the organization or of the repository, it renders a 404 page if it does not exist
+func getIssueField(ctx *context.Context, orgID, repoID, id int64) *issues_model.IssueField {
+	var (
+		f   *issues_model.IssueField
+		err error
+	)
+	if orgID > 0 {
+		f, err = issues_model.GetIssueFieldInOrgByID(ctx, orgID, id)
+	} else {
+		f, err = issues_model.GetIssueFieldInRepoByID(ctx, repoID, id)
+	}
+	if err != nil {
+		if issues_model.IsErrIssueFieldNotExist(err) {
+			ctx.NotFound("GetIssueField", err)
+		} else {
+			ctx.ServerError("GetIssueField", err)
+		}
+		return nil
+	}
+	return f
+}
+
+// SetIssueFieldsContext sets the custom issue fields of an organization or of a repository
+func SetIssueFieldsContext(ctx *context.Context, orgID, repoID int64) {
+	var (
+		fields []*issues_model.IssueField
+		err    error
+	)
+	if orgID > 0 {
+		fields, err = issues_model.GetIssueFieldsByOrgID(ctx, orgID)
+	} else {
+		fields, err = issues_model.GetIssueFieldsByRepoID(ctx, repoID)
+	}
+	if err != nil {
+		ctx.ServerError("GetIssueFields", err)
+		return
+	}
+
+	ctx.Data["IssueFields"] = fields
+}
+
+// SetIssueFieldEditContext sets the field to edit, or a new field if there is no id in the request
+func SetIssueFieldEditContext(ctx *context.Context, orgID, repoID int64) {
+	f := &issues_model.IssueField{Type: issues_model.IssueFieldTypeText}
+	if id := ctx.FormInt64("id"); id > 0 {
+		f = getIssueField(ctx, orgID, repoID, id)
+		if ctx.Written() {
+			return
+		}
+		ctx.Data["Title"] = ctx.Locale.TrString("repo.issues.fields") + " - " + f.Name
+	} else {
+		ctx.Data["Title"] = ctx.Tr("repo.issues.fields.add")
+	}
+
+	ctx.Data["IssueField"] = f
+	ctx.Data["IssueFieldTypes"] = issues_model.IssueFieldTypes
+	ctx.Data["issue_field_options"] = strings.Join(f.Options, "\n")
+}
+
+// PerformIssueFieldPost creates or updates a custom issue field of an organization or of a repository
+func PerformIssueFieldPost(ctx *context.Context, orgID, repoID int64, redirectURL string) {
+	form := web.GetForm(ctx).(*forms.IssueFieldForm)
+
+	editLink := redirectURL + "/edit"
+	if form.ID > 0 {
+		editLink = fmt.Sprintf("%s?id=%d", editLink, form.ID)
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(editLink)
+		return
+	}
+
+	var options []string
+	for _, option := range strings.Split(strings.ReplaceAll(form.Options, "\r", "\n"), "\n") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+
+	var err error
+	f := &issues_model.IssueField{OrgID: orgID, Type: issues_model.IssueFieldType(form.Type)}
+	if orgID == 0 {
+		f.RepoID = repoID
+	}
+	if form.ID > 0 {
+		f = getIssueField(ctx, orgID, repoID, form.ID)
+		if ctx.Written() {
+			return
+		}
+	}
+	f.Name = form.Name
+	f.Description = strings.TrimSpace(form.Description)
+	f.Options = options
+	f.SortOrder = form.SortOrder
+
+	if form.ID > 0 {
+		err = issue_service.UpdateField(ctx, f)
+	} else {
+		err = issues_model.NewIssueField(ctx, f)
+	}
+	if err != nil {
+		switch {
+		case issues_model.IsErrIssueFieldAlreadyExist(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.fields.already_exist", f.Name))
+		case issues_model.IsErrIssueFieldInvalid(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.fields.invalid", err.(issues_model.ErrIssueFieldInvalid).Reason))
+		default:
+			ctx.ServerError("SaveIssueField", err)
+			return
+		}
+		ctx.Redirect(editLink)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.issues.fields.update_success", f.Name))
+	ctx.Redirect(redirectURL)
+}
+
+// PerformIssueFieldDelete deletes a custom issue field of an organization or of a repository and its values
+func PerformIssueFieldDelete(ctx *context.Context, orgID, repoID int64, redirectURL string) {
+	f := getIssueField(ctx, orgID, repoID, ctx.FormInt64("id"))
+	if ctx.Written() {
+		return
+	}
+
+	if err := issue_service.DeleteField(ctx, f); err != nil {
+		ctx.ServerError("DeleteField", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.issues.fields.delete_success", f.Name))
+	ctx.JSONRedirect(redirectURL)
+}
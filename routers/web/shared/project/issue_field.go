@@ -0,0 +1,54 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package project
+
+import (
+	issues_model "code.gitea.io/gitea/models/issues"
+	project_model "code.gitea.io/gitea/models/project"
+	"code.gitea.io/gitea/services/context"
+)
+
+// SetIssueFieldsContext sets the values of the custom fields of the issues of a project board, and groups the issues by
+// the values of a field if the group_by parameter of the request is the ID of one of the fields
+func SetIssueFieldsContext(ctx *context.Context, fields []*issues_model.IssueField, columns project_model.ColumnList, issuesMap map[int64]issues_model.IssueList) {
+	if len(fields) == 0 {
+		return
+	}
+
+	var issues issues_model.IssueList
+	issueIDs := make([]int64, 0, len(issuesMap))
+	for _, column := range columns {
+		for _, issue := range issuesMap[column.ID] {
+			issues = append(issues, issue)
+			issueIDs = append(issueIDs, issue.ID)
+		}
+	}
+
+	values, err := issues_model.GetIssueFieldDisplayValues(ctx, fields, issueIDs...)
+	if err != nil {
+		ctx.ServerError("GetIssueFieldDisplayValues", err)
+		return
+	}
+	ctx.Data["IssueFields"] = fields
+	ctx.Data["ProjectIssueFieldValues"] = values
+
+	groupBy := ctx.FormInt64("group_by")
+	if groupBy == 0 {
+		return
+	}
+	for _, field := range fields {
+		if field.ID != groupBy {
+			continue
+		}
+
+		groups, err := issues_model.GroupIssuesByField(ctx, field, issues)
+		if err != nil {
+			ctx.ServerError("GroupIssuesByField", err)
+			return
+		}
+		ctx.Data["GroupByField"] = field
+		ctx.Data["IssueFieldGroups"] = groups
+		return
+	}
+}
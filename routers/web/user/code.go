@@ -4,6 +4,7 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"code.gitea.io/gitea/models/db"
@@ -11,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
 	"code.gitea.io/gitea/services/context"
 )
@@ -21,10 +23,6 @@ const (
 
 // CodeSearch render user/organization code search page
 func CodeSearch(ctx *context.Context) {
-	if !setting.Indexer.RepoIndexerEnabled {
-		ctx.Redirect(ctx.ContextUser.HomeLink())
-		return
-	}
 	shared_user.PrepareContextForProfileBigAvatar(ctx)
 	shared_user.RenderUserHeader(ctx)
 
@@ -75,7 +73,7 @@ func CodeSearch(ctx *context.Context) {
 	)
 
 	if len(repoIDs) > 0 {
-		total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, &code_indexer.SearchOptions{
+		opts := &code_indexer.SearchOptions{
 			RepoIDs:        repoIDs,
 			Keyword:        keyword,
 			IsKeywordFuzzy: isFuzzy,
@@ -84,14 +82,21 @@ func CodeSearch(ctx *context.Context) {
 				Page:     page,
 				PageSize: setting.UI.RepoSearchPagingNum,
 			},
-		})
-		if err != nil {
-			if code_indexer.IsAvailable(ctx) {
+		}
+		if setting.Indexer.RepoIndexerEnabled {
+			total, searchResults, searchResultLanguages, err = code_indexer.PerformSearch(ctx, opts)
+		} else {
+			total, searchResults, searchResultLanguages, err = code_indexer.PerformGrepSearch(ctx, repoIDs, opts)
+		}
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Data["CodeSearchError"] = err.Error()
+		} else if err != nil {
+			if !setting.Indexer.RepoIndexerEnabled || code_indexer.IsAvailable(ctx) {
 				ctx.ServerError("SearchResults", err)
 				return
 			}
 			ctx.Data["CodeIndexerUnavailable"] = true
-		} else {
+		} else if setting.Indexer.RepoIndexerEnabled {
 			ctx.Data["CodeIndexerUnavailable"] = !code_indexer.IsAvailable(ctx)
 		}
 
@@ -117,6 +122,7 @@ func CodeSearch(ctx *context.Context) {
 
 		ctx.Data["RepoMaps"] = repoMaps
 	}
+	ctx.Data["CodeIndexerDisabled"] = !setting.Indexer.RepoIndexerEnabled
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["SearchResultLanguages"] = searchResultLanguages
 
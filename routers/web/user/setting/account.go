@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	audit_model "code.gitea.io/gitea/models/audit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/auth/password"
 	"code.gitea.io/gitea/modules/base"
@@ -18,6 +19,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/web"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/auth/source/db"
 	"code.gitea.io/gitea/services/auth/source/smtp"
@@ -89,6 +91,7 @@ func AccountPost(ctx *context.Context) {
 			}
 
 			log.Trace("User password updated: %s", ctx.Doer.Name)
+			audit_service.Record(ctx, ctx.Doer, audit_model.ActionUserPassword, audit_service.OwnerTarget(ctx.Doer, "user", ctx.Doer.ID, ctx.Doer.Name), nil, nil)
 			ctx.Flash.Success(ctx.Tr("settings.change_password_success"))
 		}
 	}
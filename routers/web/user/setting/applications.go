@@ -5,13 +5,20 @@
 package setting
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	audit_model "code.gitea.io/gitea/models/audit"
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	audit_service "code.gitea.io/gitea/services/audit"
+	auth_service "code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 )
@@ -53,6 +60,25 @@ func ApplicationsPost(ctx *context.Context) {
 		Name:  form.Name,
 		Scope: scope,
 	}
+	if err := auth_service.SetAccessTokenResources(ctx, t, form.Organization, form.GetRepositories()); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Flash.Error(ctx.Tr("settings.token_resources_invalid", err.Error()))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		ctx.ServerError("SetAccessTokenResources", err)
+		return
+	}
+	if form.ExpiresAt != "" {
+		// the token can be used until the end of the expiry date
+		expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, setting.DefaultUILocation)
+		if err != nil || !expiresAt.AddDate(0, 0, 1).After(time.Now()) {
+			ctx.Flash.Error(ctx.Tr("settings.token_expires_at_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(expiresAt.AddDate(0, 0, 1).Unix())
+	}
 
 	exist, err := auth_model.AccessTokenByNameExists(ctx, t)
 	if err != nil {
@@ -69,6 +95,7 @@ func ApplicationsPost(ctx *context.Context) {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
+	audit_service.Record(ctx, ctx.Doer, audit_model.ActionAccessTokenCreate, audit_service.AccessTokenTarget(t), nil, audit_service.AccessTokenState(t))
 
 	ctx.Flash.Success(ctx.Tr("settings.generate_token_success"))
 	ctx.Flash.Info(t.Token)
@@ -78,9 +105,17 @@ func ApplicationsPost(ctx *context.Context) {
 
 // DeleteApplication response for delete user access token
 func DeleteApplication(ctx *context.Context) {
-	if err := auth_model.DeleteAccessTokenByID(ctx, ctx.FormInt64("id"), ctx.Doer.ID); err != nil {
+	id := ctx.FormInt64("id")
+	t, _, err := db.GetByID[auth_model.AccessToken](ctx, id)
+	if err != nil {
+		ctx.ServerError("GetByID", err)
+		return
+	}
+
+	if err := auth_model.DeleteAccessTokenByID(ctx, id, ctx.Doer.ID); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
+		audit_service.Record(ctx, ctx.Doer, audit_model.ActionAccessTokenDelete, audit_service.AccessTokenTarget(t), audit_service.AccessTokenState(t), nil)
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
 	}
 
@@ -95,6 +130,17 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	tokenResources := make(map[int64]*auth_service.AccessTokenResources)
+	for _, t := range tokens {
+		if !t.IsRestricted() {
+			continue
+		}
+		if tokenResources[t.ID], err = auth_service.GetAccessTokenResources(ctx, t); err != nil {
+			ctx.ServerError("GetAccessTokenResources", err)
+			return
+		}
+	}
+	ctx.Data["TokenResources"] = tokenResources
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enabled
 	ctx.Data["IsAdmin"] = ctx.Doer.IsAdmin
 	if setting.OAuth2.Enabled {
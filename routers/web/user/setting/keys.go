@@ -9,12 +9,14 @@ import (
 	"net/http"
 
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
 	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
+	audit_service "code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 )
@@ -63,7 +65,8 @@ func KeysPost(ctx *context.Context) {
 			ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 			return
 		}
-		if _, err = asymkey_model.AddPrincipalKey(ctx, ctx.Doer.ID, content, 0); err != nil {
+		key, err := asymkey_model.AddPrincipalKey(ctx, ctx.Doer.ID, content, 0)
+		if err != nil {
 			ctx.Data["HasPrincipalError"] = true
 			switch {
 			case asymkey_model.IsErrKeyAlreadyExist(err), asymkey_model.IsErrKeyNameAlreadyUsed(err):
@@ -76,6 +79,7 @@ func KeysPost(ctx *context.Context) {
 			}
 			return
 		}
+		recordPublicKey(ctx, audit_model.ActionPublicKeyAdd, key, nil, audit_service.PublicKeyState(key))
 		ctx.Flash.Success(ctx.Tr("settings.add_principal_success", form.Content))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 	case "gpg":
@@ -180,7 +184,8 @@ func KeysPost(ctx *context.Context) {
 			return
 		}
 
-		if _, err = asymkey_model.AddPublicKey(ctx, ctx.Doer.ID, form.Title, content, 0); err != nil {
+		key, err := asymkey_model.AddPublicKey(ctx, ctx.Doer.ID, form.Title, content, 0)
+		if err != nil {
 			ctx.Data["HasSSHError"] = true
 			switch {
 			case asymkey_model.IsErrKeyAlreadyExist(err):
@@ -201,6 +206,7 @@ func KeysPost(ctx *context.Context) {
 			}
 			return
 		}
+		recordPublicKey(ctx, audit_model.ActionPublicKeyAdd, key, nil, audit_service.PublicKeyState(key))
 		ctx.Flash.Success(ctx.Tr("settings.add_key_success", form.Title))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 	case "verify_ssh":
@@ -267,13 +273,13 @@ func DeleteKey(ctx *context.Context) {
 			ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 			return
 		}
-		if err := asymkey_service.DeletePublicKey(ctx, ctx.Doer, keyID); err != nil {
+		if err := deletePublicKey(ctx, keyID); err != nil {
 			ctx.Flash.Error("DeletePublicKey: " + err.Error())
 		} else {
 			ctx.Flash.Success(ctx.Tr("settings.ssh_key_deletion_success"))
 		}
 	case "principal":
-		if err := asymkey_service.DeletePublicKey(ctx, ctx.Doer, ctx.FormInt64("id")); err != nil {
+		if err := deletePublicKey(ctx, ctx.FormInt64("id")); err != nil {
 			ctx.Flash.Error("DeletePublicKey: " + err.Error())
 		} else {
 			ctx.Flash.Success(ctx.Tr("settings.ssh_principal_deletion_success"))
@@ -285,6 +291,23 @@ func DeleteKey(ctx *context.Context) {
 	ctx.JSONRedirect(setting.AppSubURL + "/user/settings/keys")
 }
 
+// deletePublicKey deletes a SSH key or principal and records it in the audit log
+func deletePublicKey(ctx *context.Context, id int64) error {
+	key, err := asymkey_model.GetPublicKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := asymkey_service.DeletePublicKey(ctx, ctx.Doer, id); err != nil {
+		return err
+	}
+	recordPublicKey(ctx, audit_model.ActionPublicKeyRemove, key, audit_service.PublicKeyState(key), nil)
+	return nil
+}
+
+func recordPublicKey(ctx *context.Context, action audit_model.Action, key *asymkey_model.PublicKey, before, after any) {
+	audit_service.Record(ctx, ctx.Doer, action, audit_service.PublicKeyTarget(key), before, after)
+}
+
 func loadKeysData(ctx *context.Context) {
 	keys, err := db.Find[asymkey_model.PublicKey](ctx, asymkey_model.FindPublicKeyOptions{
 		OwnerID:    ctx.Doer.ID,
@@ -8,7 +8,9 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models/auth"
+	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
@@ -27,6 +29,18 @@ func (oa *OAuth2CommonHandlers) renderEditPage(ctx *context.Context) {
 	app := ctx.Data["App"].(*auth.OAuth2Application)
 	ctx.Data["FormActionPath"] = fmt.Sprintf("%s/%d", oa.BasePathEditPrefix, app.ID)
 
+	ctx.Data["CanSetClientCredentialsUser"] = oa.canSetClientCredentialsUser(ctx)
+	if app.ClientCredentialsUserID != 0 {
+		u, err := user_model.GetPossibleUserByID(ctx, app.ClientCredentialsUserID)
+		if err != nil && !user_model.IsErrUserNotExist(err) {
+			ctx.ServerError("GetPossibleUserByID", err)
+			return
+		}
+		if u != nil {
+			ctx.Data["ClientCredentialsUser"] = u.Name
+		}
+	}
+
 	if ctx.ContextUser != nil && ctx.ContextUser.IsOrganization() {
 		if err := shared_user.LoadHeaderCount(ctx); err != nil {
 			ctx.ServerError("LoadHeaderCount", err)
@@ -37,6 +51,28 @@ func (oa *OAuth2CommonHandlers) renderEditPage(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, oa.TplAppEdit)
 }
 
+// canSetClientCredentialsUser returns true if the applications can act as a user with the client credentials grant,
+// which is not supported for the applications of organizations
+func (oa *OAuth2CommonHandlers) canSetClientCredentialsUser(ctx *context.Context) bool {
+	return ctx.ContextUser == nil || !ctx.ContextUser.IsOrganization()
+}
+
+// clientCredentialsUserID returns the ID of the user which the application acts as with the client credentials
+// grant, instance-wide applications can act as any user while the applications of users can only act as their owners
+func (oa *OAuth2CommonHandlers) clientCredentialsUserID(ctx *context.Context, name string) (int64, error) {
+	if name == "" || !oa.canSetClientCredentialsUser(ctx) {
+		return 0, nil
+	}
+	u, err := user_model.GetUserByName(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if u.IsOrganization() || (oa.OwnerID != 0 && u.ID != oa.OwnerID) {
+		return 0, user_model.ErrUserNotExist{Name: name}
+	}
+	return u.ID, nil
+}
+
 // AddApp adds an oauth2 application
 func (oa *OAuth2CommonHandlers) AddApp(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.EditOAuth2ApplicationForm)
@@ -99,14 +135,25 @@ func (oa *OAuth2CommonHandlers) EditSave(ctx *context.Context) {
 		return
 	}
 
+	clientCredentialsUserID, err := oa.clientCredentialsUserID(ctx, form.ClientCredentialsUser)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("settings.oauth2_client_credentials_user_invalid", form.ClientCredentialsUser))
+			ctx.Redirect(fmt.Sprintf("%s/%d", oa.BasePathEditPrefix, ctx.ParamsInt64("id")))
+			return
+		}
+		ctx.ServerError("GetUserByName", err)
+		return
+	}
+
 	// TODO validate redirect URI
-	var err error
 	if ctx.Data["App"], err = auth.UpdateOAuth2Application(ctx, auth.UpdateOAuth2ApplicationOptions{
-		ID:                 ctx.ParamsInt64("id"),
-		Name:               form.Name,
-		RedirectURIs:       util.SplitTrimSpace(form.RedirectURIs, "\n"),
-		UserID:             oa.OwnerID,
-		ConfidentialClient: form.ConfidentialClient,
+		ID:                      ctx.ParamsInt64("id"),
+		Name:                    form.Name,
+		RedirectURIs:            util.SplitTrimSpace(form.RedirectURIs, "\n"),
+		UserID:                  oa.OwnerID,
+		ConfidentialClient:      form.ConfidentialClient,
+		ClientCredentialsUserID: optional.Some(clientCredentialsUserID),
 	}); err != nil {
 		ctx.ServerError("UpdateOAuth2Application", err)
 		return
@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/auth/source/saml"
 	"code.gitea.io/gitea/services/context"
 )
 
@@ -69,6 +70,14 @@ func loadSecurityData(ctx *context.Context) {
 	}
 	ctx.Data["WebAuthnCredentials"] = credentials
 
+	hasPasskeys, err := auth_model.HasPasskeysByUID(ctx, ctx.Doer.ID)
+	if err != nil {
+		ctx.ServerError("HasPasskeysByUID", err)
+		return
+	}
+	ctx.Data["CanDisablePassword"] = hasPasskeys && ctx.Doer.IsLocal() && ctx.Doer.IsPasswordSet()
+	ctx.Data["PasswordDisabled"] = ctx.Doer.IsLocal() && !ctx.Doer.IsPasswordSet()
+
 	tokens, err := db.Find[auth_model.AccessToken](ctx, auth_model.ListAccessTokensOptions{UserID: ctx.Doer.ID})
 	if err != nil {
 		ctx.ServerError("ListAccessTokens", err)
@@ -139,6 +148,34 @@ func loadSecurityData(ctx *context.Context) {
 	ctx.Data["OrderedOAuth2Names"] = orderedOAuth2Names
 	ctx.Data["OAuth2Providers"] = oauth2Providers
 
+	samlSources, err := db.Find[auth_model.Source](ctx, auth_model.FindSourcesOptions{
+		IsActive:  optional.None[bool](),
+		LoginType: auth_model.SAML,
+	})
+	if err != nil {
+		ctx.ServerError("FindSources", err)
+		return
+	}
+
+	var orderedSAMLNames []string
+	samlProviders := make(map[string]*saml.Provider)
+	for _, source := range samlSources {
+		provider, err := saml.CreateProviderFromSource(source)
+		if err != nil {
+			ctx.ServerError("CreateProviderFromSource", err)
+			return
+		}
+		samlProviders[source.Name] = provider
+		if source.IsActive {
+			orderedSAMLNames = append(orderedSAMLNames, source.Name)
+		}
+	}
+
+	sort.Strings(orderedSAMLNames)
+
+	ctx.Data["OrderedSAMLNames"] = orderedSAMLNames
+	ctx.Data["SAMLProviders"] = samlProviders
+
 	openid, err := user_model.GetUserOpenIDs(ctx, ctx.Doer.ID)
 	if err != nil {
 		ctx.ServerError("GetUserOpenIDs", err)
@@ -13,9 +13,11 @@ import (
 	wa "code.gitea.io/gitea/modules/auth/webauthn"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
+	user_service "code.gitea.io/gitea/services/user"
 
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
@@ -44,8 +46,23 @@ func WebAuthnRegister(ctx *context.Context) {
 		ctx.ServerError("Unable to set session key for webauthnName", err)
 		return
 	}
+	if err := ctx.Session.Set("webauthnPasskey", form.Passkey); err != nil {
+		ctx.ServerError("Unable to set session key for webauthnPasskey", err)
+		return
+	}
+
+	var opts []webauthn.RegistrationOption
+	if form.Passkey {
+		// Passkeys are discoverable so that the user can sign in without a username, and the authenticator verifies
+		// the user so that they can replace the password
+		opts = append(opts, webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		}))
+	}
 
-	credentialOptions, sessionData, err := wa.WebAuthn.BeginRegistration((*wa.User)(ctx.Doer))
+	credentialOptions, sessionData, err := wa.WebAuthn.BeginRegistration((*wa.User)(ctx.Doer), opts...)
 	if err != nil {
 		ctx.ServerError("Unable to BeginRegistration", err)
 		return
@@ -98,13 +115,17 @@ func WebauthnRegisterPost(ctx *context.Context) {
 		return
 	}
 
+	// The user verification of passkeys was checked by FinishRegistration
+	passkey, _ := ctx.Session.Get("webauthnPasskey").(bool)
+
 	// Create the credential
-	_, err = auth.CreateCredential(ctx, ctx.Doer.ID, name, cred)
+	_, err = auth.CreateCredential(ctx, ctx.Doer.ID, name, cred, passkey && cred.Flags.UserVerified)
 	if err != nil {
 		ctx.ServerError("CreateCredential", err)
 		return
 	}
 	_ = ctx.Session.Delete("webauthnName")
+	_ = ctx.Session.Delete("webauthnPasskey")
 
 	ctx.JSON(http.StatusCreated, cred)
 }
@@ -112,9 +133,48 @@ func WebauthnRegisterPost(ctx *context.Context) {
 // WebauthnDelete deletes an security key by id
 func WebauthnDelete(ctx *context.Context) {
 	form := web.GetForm(ctx).(*forms.WebauthnDeleteForm)
+
+	// Without a password, the last passkey is the only way to sign in
+	if ctx.Doer.IsLocal() && !ctx.Doer.IsPasswordSet() {
+		cred, err := auth.GetWebAuthnCredentialByID(ctx, form.ID)
+		if err != nil && !auth.IsErrWebAuthnCredentialNotExist(err) {
+			ctx.ServerError("GetWebAuthnCredentialByID", err)
+			return
+		}
+		if cred != nil && cred.UserID == ctx.Doer.ID && cred.Passkey {
+			count, err := auth.CountPasskeysByUID(ctx, ctx.Doer.ID)
+			if err != nil {
+				ctx.ServerError("CountPasskeysByUID", err)
+				return
+			}
+			if count <= 1 {
+				ctx.Flash.Error(ctx.Tr("settings.passkey_delete_last"))
+				ctx.JSONRedirect(setting.AppSubURL + "/user/settings/security")
+				return
+			}
+		}
+	}
+
 	if _, err := auth.DeleteCredential(ctx, form.ID, ctx.Doer.ID); err != nil {
 		ctx.ServerError("GetWebAuthnCredentialByID", err)
 		return
 	}
 	ctx.JSONRedirect(setting.AppSubURL + "/user/settings/security")
 }
+
+// DisablePassword disables the password of the user, who signs in with their passkeys from then on
+func DisablePassword(ctx *context.Context) {
+	if err := user_service.DisablePassword(ctx, ctx.Doer); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Flash.Error(ctx.Tr("settings.passkey_disable_password_failed"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+			return
+		}
+		ctx.ServerError("DisablePassword", err)
+		return
+	}
+
+	log.Trace("User disabled their password: %s", ctx.Doer.Name)
+	ctx.Flash.Success(ctx.Tr("settings.passkey_disable_password_success"))
+	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+}
@@ -170,6 +170,26 @@ func verifyAuthWithOptions(options *common.VerifyOptions) func(ctx *context.Cont
 				ctx.Redirect(setting.AppSubURL + "/")
 				return
 			}
+
+			// the policy only applies to the web sign-in, the git clients and the tokens are not affected. It is
+			// evaluated when the user signs in and only checked again until they registered a security key.
+			if setting.RequirePhishingResistantMFA && !ctx.IsBasicAuth && ctx.Session.Get(auth_service.MustEnrollPhishingResistantMFASessionKey) == true &&
+				!isPhishingResistantMFAEnrollmentPath(ctx.Req.URL.Path) {
+				mustEnroll, err := auth_service.MustEnrollPhishingResistantMFA(ctx, ctx.Doer)
+				if err != nil {
+					ctx.ServerError("MustEnrollPhishingResistantMFA", err)
+					return
+				}
+				if mustEnroll {
+					ctx.Flash.Warning(ctx.Tr("auth.phishing_resistant_mfa_required"))
+					ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+					return
+				}
+				if err := ctx.Session.Delete(auth_service.MustEnrollPhishingResistantMFASessionKey); err != nil {
+					ctx.ServerError("Session.Delete", err)
+					return
+				}
+			}
 		}
 
 		// Redirect to dashboard (or alternate location) if user tries to visit any non-login page.
@@ -219,6 +239,13 @@ func verifyAuthWithOptions(options *common.VerifyOptions) func(ctx *context.Cont
 	}
 }
 
+// isPhishingResistantMFAEnrollmentPath returns whether the path stays available to the users who must register a
+// security key or a passkey before they can use the instance
+func isPhishingResistantMFAEnrollmentPath(path string) bool {
+	return path == "/user/settings/security" || strings.HasPrefix(path, "/user/settings/security/") ||
+		path == "/user/logout" || path == "/user/events"
+}
+
 func ctxDataSet(args ...any) func(ctx *context.Context) {
 	return func(ctx *context.Context) {
 		for i := 0; i < len(args); i += 2 {
@@ -462,6 +489,7 @@ func registerRoutes(m *web.Route) {
 				return
 			}
 		}, explore.Code)
+		m.Get("/commits", explore.Commits)
 		m.Get("/topics/search", explore.TopicSearch)
 	}, ignExploreSignIn)
 	m.Group("/issues", func() {
@@ -507,6 +535,8 @@ func registerRoutes(m *web.Route) {
 			m.Get("", auth.WebAuthn)
 			m.Get("/assertion", auth.WebAuthnLoginAssertion)
 			m.Post("/assertion", auth.WebAuthnLoginAssertionPost)
+			m.Get("/passkey", auth.PasskeyLoginAssertion)
+			m.Post("/passkey", auth.PasskeyLoginAssertionPost)
 		})
 	}, reqSignOut)
 
@@ -518,9 +548,14 @@ func registerRoutes(m *web.Route) {
 		// TODO manage redirection
 		m.Post("/authorize", web.Bind(forms.AuthorizationForm{}), auth.AuthorizeOAuth)
 	}, ignSignInAndCsrf, reqSignIn)
+	m.Group("/login/device", func() {
+		m.Get("", auth.DeviceOAuth)
+		m.Post("", web.Bind(forms.GrantDeviceForm{}), auth.DeviceOAuthPost)
+	}, reqSignIn)
 
 	m.Methods("GET, OPTIONS", "/login/oauth/userinfo", optionsCorsHandler(), ignSignInAndCsrf, auth.InfoOAuth)
 	m.Methods("POST, OPTIONS", "/login/oauth/access_token", optionsCorsHandler(), web.Bind(forms.AccessTokenForm{}), ignSignInAndCsrf, auth.AccessTokenOAuth)
+	m.Methods("POST, OPTIONS", "/login/oauth/device_authorization", optionsCorsHandler(), web.Bind(forms.DeviceAuthorizationForm{}), ignSignInAndCsrf, auth.DeviceAuthorizationOAuth)
 	m.Methods("GET, OPTIONS", "/login/oauth/keys", optionsCorsHandler(), ignSignInAndCsrf, auth.OIDCKeys)
 	m.Methods("POST, OPTIONS", "/login/oauth/introspect", optionsCorsHandler(), web.Bind(forms.IntrospectTokenForm{}), ignSignInAndCsrf, auth.IntrospectOAuth)
 
@@ -557,6 +592,7 @@ func registerRoutes(m *web.Route) {
 				m.Post("/register", security.WebauthnRegisterPost)
 				m.Post("/delete", web.Bind(forms.WebauthnDeleteForm{}), security.WebauthnDelete)
 			})
+			m.Post("/disable_password", security.DisablePassword)
 			m.Group("/openid", func() {
 				m.Post("", web.Bind(forms.AddOpenIDForm{}), security.OpenIDPost)
 				m.Post("/delete", security.DeleteOpenID)
@@ -644,6 +680,12 @@ func registerRoutes(m *web.Route) {
 			m.Get("/{provider}", auth.SignInOAuth)
 			m.Get("/{provider}/callback", auth.SignInOAuthCallback)
 		})
+		m.Group("/saml/{provider}", func() {
+			m.Get("", auth.SignInSAML)
+			m.Get("/metadata", auth.SAMLMetadata)
+			m.Post("/acs", auth.SAMLAssertionConsumerService)
+			m.Get("/callback", auth.SignInSAMLCallback)
+		})
 	})
 	// ***** END: User *****
 
@@ -705,6 +747,7 @@ func registerRoutes(m *web.Route) {
 		m.Group("/repos", func() {
 			m.Get("", admin.Repos)
 			m.Combo("/unadopted").Get(admin.UnadoptedRepos).Post(admin.AdoptOrDeleteRepository)
+			m.Get("/maintenance", admin.RepoMaintenance)
 			m.Post("/delete", admin.DeleteRepo)
 		})
 
@@ -735,6 +778,8 @@ func registerRoutes(m *web.Route) {
 			m.Combo("/{authid}").Get(admin.EditAuthSource).
 				Post(web.Bind(forms.AuthenticationForm{}), admin.EditAuthSourcePost)
 			m.Post("/{authid}/delete", admin.DeleteAuthSource)
+			m.Post("/{authid}/scim_token", admin.GenerateSCIMToken)
+			m.Post("/{authid}/scim_token/delete", admin.DeleteSCIMToken)
 		})
 
 		m.Group("/notices", func() {
@@ -743,6 +788,12 @@ func registerRoutes(m *web.Route) {
 			m.Post("/empty", admin.EmptyNotices)
 		})
 
+		m.Group("/audit", func() {
+			m.Get("", admin.Audit)
+			m.Get("/export", admin.AuditExport)
+			m.Post("/verify", admin.AuditVerify)
+		})
+
 		m.Group("/applications", func() {
 			m.Get("", admin.Applications)
 			m.Post("/oauth2", web.Bind(forms.EditOAuth2ApplicationForm{}), admin.ApplicationsPost)
@@ -910,6 +961,28 @@ func registerRoutes(m *web.Route) {
 
 				m.Methods("GET,POST", "/delete", org.SettingsDelete)
 
+				m.Group("/rulesets", func() {
+					m.Get("", org_setting.Rulesets)
+					m.Combo("/edit").Get(org_setting.RulesetEdit).
+						Post(web.Bind(forms.OrgRulesetForm{}), org_setting.RulesetEditPost)
+					m.Post("/{id}/delete", org_setting.RulesetDelete)
+				})
+
+				m.Group("/issue_fields", func() {
+					m.Get("", org_setting.IssueFields)
+					m.Combo("/edit").Get(org_setting.IssueFieldEdit).
+						Post(web.Bind(forms.IssueFieldForm{}), org_setting.IssueFieldEditPost)
+					m.Post("/delete", org_setting.IssueFieldDelete)
+				})
+
+				m.Combo("/auth_policy").Get(org_setting.AuthPolicy).
+					Post(web.Bind(forms.OrgAuthPolicyForm{}), org_setting.AuthPolicyPost)
+
+				m.Group("/audit", func() {
+					m.Get("", org_setting.Audit)
+					m.Get("/export", org_setting.AuditExport)
+				})
+
 				m.Group("/blocked_users", func() {
 					m.Get("", org_setting.BlockedUsers)
 					m.Post("/block", org_setting.BlockedUsersBlock)
@@ -1075,6 +1148,18 @@ func registerRoutes(m *web.Route) {
 				m.Post("/delete", repo_setting.DeleteDeployKey)
 			})
 
+			m.Group("/issue_fields", func() {
+				m.Get("", repo_setting.IssueFields)
+				m.Combo("/edit").Get(repo_setting.IssueFieldEdit).
+					Post(web.Bind(forms.IssueFieldForm{}), repo_setting.IssueFieldEditPost)
+				m.Post("/delete", repo_setting.IssueFieldDelete)
+			})
+
+			m.Group("/audit", func() {
+				m.Get("", repo_setting.Audit)
+				m.Get("/export", repo_setting.AuditExport)
+			})
+
 			m.Group("/lfs", func() {
 				m.Get("/", repo_setting.LFSFiles)
 				m.Get("/show/{oid}", repo_setting.LFSFileGet)
@@ -1156,6 +1241,7 @@ func registerRoutes(m *web.Route) {
 				m.Post("/title", repo.UpdateIssueTitle)
 				m.Post("/content", repo.UpdateIssueContent)
 				m.Post("/deadline", web.Bind(structs.EditDeadlineOption{}), repo.UpdateIssueDeadline)
+				m.Post("/fields/{id}", repo.UpdateIssueFieldValues)
 				m.Post("/watch", repo.IssueWatch)
 				m.Post("/ref", repo.UpdateIssueRef)
 				m.Post("/pin", reqRepoAdmin, repo.IssuePinOrUnpin)
@@ -1164,6 +1250,12 @@ func registerRoutes(m *web.Route) {
 					m.Post("/add", repo.AddDependency)
 					m.Post("/delete", repo.RemoveDependency)
 				})
+				m.Group("/sub_issues", func() {
+					m.Post("/add", repo.AddSubIssue)
+					m.Post("/delete", repo.RemoveSubIssue)
+					m.Post("/move", repo.MoveSubIssue)
+					m.Post("/close_with_sub_issues", repo.UpdateCloseWithSubIssues)
+				})
 				m.Combo("/comments").Post(repo.MustAllowUserComment, web.Bind(forms.CreateCommentForm{}), repo.NewComment)
 				m.Group("/times", func() {
 					m.Post("/add", web.Bind(forms.AddTimeManuallyForm{}), repo.AddTimeManually)
@@ -1480,6 +1572,7 @@ func registerRoutes(m *web.Route) {
 		})
 
 		m.Get("/pulls/posters", repo.PullPosters)
+		m.Get("/pulls/merge_queue", repo.MergeQueue)
 		m.Group("/pulls/{index}", func() {
 			m.Get("", repo.SetWhitespaceBehavior, repo.GetPullDiffStats, repo.ViewIssue)
 			m.Get(".diff", repo.DownloadPullDiff)
@@ -1489,9 +1582,18 @@ func registerRoutes(m *web.Route) {
 				m.Get("/list", context.RepoRef(), repo.GetPullCommits)
 				m.Get("/{sha:[a-f0-9]{4,40}}", context.RepoRef(), repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.SetShowOutdatedComments, repo.ViewPullFilesForSingleCommit)
 			})
+			m.Group("/range-diff", func() {
+				m.Get("", repo.ViewPullRangeDiff)
+				m.Get("/{shaFrom:[a-f0-9]{4,64}}..{shaTo:[a-f0-9]{4,64}}", repo.ViewPullRangeDiff)
+			}, context.RepoRef(), repo.SetWhitespaceBehavior, repo.GetPullDiffStats)
 			m.Post("/merge", context.RepoMustNotBeArchived(), web.Bind(forms.MergePullRequestForm{}), repo.MergePullRequest)
 			m.Post("/cancel_auto_merge", context.RepoMustNotBeArchived(), repo.CancelAutoMergePullRequest)
+			m.Post("/merge_queue/remove", context.RepoMustNotBeArchived(), repo.RemoveFromMergeQueue)
+			m.Post("/merge_stack", context.RepoMustNotBeArchived(), repo.MergePullRequestStack)
 			m.Post("/update", repo.UpdatePullRequest)
+			m.Combo("/conflicts").Get(repo.PullConflicts).
+				Post(context.RepoMustNotBeArchived(), repo.PullConflictsPost)
+			m.Post("/suggestions/apply", context.RepoMustNotBeArchived(), repo.ApplyCodeSuggestions)
 			m.Post("/set_allow_maintainer_edit", web.Bind(forms.UpdateAllowEditsForm{}), repo.SetAllowEdits)
 			m.Post("/cleanup", context.RepoMustNotBeArchived(), context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
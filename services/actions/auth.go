@@ -72,15 +72,29 @@ func CreateAuthorizationToken(taskID, runID, jobID int64) (string, error) {
 }
 
 func ParseAuthorizationToken(req *http.Request) (int64, error) {
+	c, err := parseActionsClaims(req)
+	if c == nil || err != nil {
+		return 0, err
+	}
+	// the tokens to request ID tokens cannot access the other services of the runtime
+	if strings.HasPrefix(c.Scp, idTokenRequestScopePrefix) {
+		return 0, fmt.Errorf("invalid token scope")
+	}
+
+	return c.TaskID, nil
+}
+
+// parseActionsClaims returns the claims of the token of the Authorization header, or nil if there is none
+func parseActionsClaims(req *http.Request) (*actionsClaims, error) {
 	h := req.Header.Get("Authorization")
 	if h == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	parts := strings.SplitN(h, " ", 2)
 	if len(parts) != 2 {
 		log.Error("split token failed: %s", h)
-		return 0, fmt.Errorf("split token failed")
+		return nil, fmt.Errorf("split token failed")
 	}
 
 	token, err := jwt.ParseWithClaims(parts[1], &actionsClaims{}, func(t *jwt.Token) (any, error) {
@@ -90,13 +104,13 @@ func ParseAuthorizationToken(req *http.Request) (int64, error) {
 		return setting.GetGeneralTokenSigningSecret(), nil
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	c, ok := token.Claims.(*actionsClaims)
 	if !token.Valid || !ok {
-		return 0, fmt.Errorf("invalid token claim")
+		return nil, fmt.Errorf("invalid token claim")
 	}
 
-	return c.TaskID, nil
+	return c, nil
 }
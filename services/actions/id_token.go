@@ -0,0 +1,165 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenRequestScopePrefix is the prefix of the scope of the tokens with which jobs request ID tokens
+const idTokenRequestScopePrefix = "Actions.IDToken:"
+
+// idTokenExpiration is the lifetime of the ID tokens, they are meant to be exchanged right away
+const idTokenExpiration = 5 * time.Minute
+
+// IDTokenClaims are the claims of the OpenID Connect ID tokens of the jobs, they are named like the ones of GitHub
+// Actions so that the trust policies of cloud providers can be reused
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Repository           string `json:"repository"`
+	RepositoryID         string `json:"repository_id"`
+	RepositoryOwner      string `json:"repository_owner"`
+	RepositoryOwnerID    string `json:"repository_owner_id"`
+	RepositoryVisibility string `json:"repository_visibility"`
+	Ref                  string `json:"ref"`
+	RefType              string `json:"ref_type"`
+	SHA                  string `json:"sha"`
+	Workflow             string `json:"workflow"`
+	EventName            string `json:"event_name"`
+	RunID                string `json:"run_id"`
+	RunNumber            string `json:"run_number"`
+	RunAttempt           string `json:"run_attempt"`
+	Job                  string `json:"job"`
+	Environment          string `json:"environment,omitempty"`
+	Actor                string `json:"actor"`
+	ActorID              string `json:"actor_id"`
+}
+
+// IDTokenIssuer returns the issuer of the ID tokens, the discovery document is published below it
+func IDTokenIssuer() string {
+	return setting.AppURL + "api/actions"
+}
+
+// IDTokenRequestURL returns the URL with which the jobs of the run request ID tokens
+func IDTokenRequestURL(runID int64) string {
+	return fmt.Sprintf("%s/_apis/pipelines/workflows/%d/idtoken?api-version=2.0", IDTokenIssuer(), runID)
+}
+
+// CreateIDTokenRequestToken creates the token with which the job of the task requests ID tokens
+func CreateIDTokenRequestToken(taskID, runID, jobID int64) (string, error) {
+	now := time.Now()
+	claims := actionsClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		Scp:    fmt.Sprintf("%s%d:%d", idTokenRequestScopePrefix, runID, jobID),
+		TaskID: taskID,
+		RunID:  runID,
+		JobID:  jobID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(setting.GetGeneralTokenSigningSecret())
+}
+
+// ParseIDTokenRequestToken returns the task id of the token with which a job requests ID tokens
+func ParseIDTokenRequestToken(req *http.Request) (int64, error) {
+	c, err := parseActionsClaims(req)
+	if err != nil {
+		return 0, err
+	}
+	if c == nil || c.Scp != fmt.Sprintf("%s%d:%d", idTokenRequestScopePrefix, c.RunID, c.JobID) {
+		return 0, fmt.Errorf("invalid token scope")
+	}
+	return c.TaskID, nil
+}
+
+// CreateIDToken creates an OpenID Connect ID token for the running task with the audience, it is signed with the
+// signing key of the instance which must be asymmetric
+func CreateIDToken(ctx context.Context, task *actions_model.ActionTask, audience string) (string, error) {
+	if oauth2.DefaultSigningKey.IsSymmetric() {
+		return "", errors.New("ID tokens cannot be signed with a symmetric key")
+	}
+	if err := task.LoadAttributes(ctx); err != nil {
+		return "", err
+	}
+	job := task.Job
+	if task.Status != actions_model.StatusRunning {
+		return "", util.NewPermissionDeniedErrorf("the task is not running")
+	}
+	if !job.IDTokenPermission || job.IsForkPullRequest {
+		return "", util.NewPermissionDeniedErrorf("the job does not have the id-token: write permission")
+	}
+
+	run := job.Run
+	repo := run.Repo
+	if audience == "" {
+		audience = repo.Owner.HTMLURL()
+	}
+
+	// the environment is declared by the workflow and nothing restricts which refs deploy to it, so unlike in GitHub
+	// Actions it is not part of the subject which trust policies match
+	ref := git.RefName(run.Ref)
+	subject := "repo:" + repo.FullName() + ":"
+	if run.TriggerEvent == actions_module.GithubEventPullRequest {
+		subject += "pull_request"
+	} else {
+		subject += "ref:" + ref.String()
+	}
+
+	visibility := "public"
+	if repo.IsPrivate {
+		visibility = "private"
+	} else if !repo.Owner.Visibility.IsPublic() {
+		visibility = "internal"
+	}
+
+	now := time.Now()
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    IDTokenIssuer(),
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(idTokenExpiration)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        fmt.Sprintf("%d-%d", task.ID, now.UnixNano()),
+		},
+		Repository:           repo.FullName(),
+		RepositoryID:         strconv.FormatInt(repo.ID, 10),
+		RepositoryOwner:      repo.OwnerName,
+		RepositoryOwnerID:    strconv.FormatInt(repo.OwnerID, 10),
+		RepositoryVisibility: visibility,
+		Ref:                  ref.String(),
+		RefType:              ref.RefType(),
+		SHA:                  run.CommitSHA,
+		Workflow:             run.WorkflowID,
+		EventName:            run.TriggerEvent,
+		RunID:                strconv.FormatInt(run.ID, 10),
+		RunNumber:            strconv.FormatInt(run.Index, 10),
+		RunAttempt:           strconv.FormatInt(job.Attempt, 10),
+		Job:                  job.JobID,
+		Environment:          job.Environment,
+		Actor:                run.TriggerUser.Name,
+		ActorID:              strconv.FormatInt(run.TriggerUserID, 10),
+	}
+
+	token := jwt.NewWithClaims(oauth2.DefaultSigningKey.SigningMethod(), claims)
+	oauth2.DefaultSigningKey.PreProcessToken(token)
+	return token.SignedString(oauth2.DefaultSigningKey.SignKey())
+}
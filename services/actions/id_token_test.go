@@ -0,0 +1,84 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIDTokenRequestToken(t *testing.T) {
+	token, err := CreateIDTokenRequestToken(23, 1, 2)
+	require.NoError(t, err)
+	headers := http.Header{}
+	headers.Set("Authorization", "Bearer "+token)
+	req := &http.Request{Header: headers}
+
+	taskID, err := ParseIDTokenRequestToken(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 23, taskID)
+
+	// the tokens to request ID tokens and the runtime tokens are not interchangeable
+	_, err = ParseAuthorizationToken(req)
+	assert.Error(t, err)
+	token, err = CreateAuthorizationToken(23, 1, 2)
+	require.NoError(t, err)
+	headers.Set("Authorization", "Bearer "+token)
+	_, err = ParseIDTokenRequestToken(req)
+	assert.Error(t, err)
+}
+
+func TestCreateIDToken(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.OAuth2.JWTSigningAlgorithm, "ES256")()
+	defer test.MockVariableValue(&setting.OAuth2.JWTSigningPrivateKeyFile, filepath.Join(t.TempDir(), "jwt.pem"))()
+	signingKey := oauth2.DefaultSigningKey
+	defer func() { oauth2.DefaultSigningKey = signingKey }()
+	require.NoError(t, oauth2.InitSigningKey())
+
+	task := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTask{ID: 47})
+	_, err := CreateIDToken(db.DefaultContext, task, "")
+	assert.ErrorIs(t, err, util.ErrPermissionDenied)
+
+	task.Job.IDTokenPermission = true
+	task.Job.Environment = "production"
+	_, err = db.GetEngine(db.DefaultContext).ID(task.Job.ID).Cols("id_token_permission", "environment").Update(task.Job)
+	require.NoError(t, err)
+
+	token, err := CreateIDToken(db.DefaultContext, task, "sts.example.com")
+	require.NoError(t, err)
+
+	claims := &IDTokenClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return oauth2.DefaultSigningKey.VerifyKey(), nil
+	})
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+	assert.Equal(t, setting.AppURL+"api/actions", claims.Issuer)
+	// the environment is not checked, so the subject keeps the ref
+	assert.Equal(t, "repo:user5/repo4:ref:refs/heads/master", claims.Subject)
+	assert.Equal(t, jwt.ClaimStrings{"sts.example.com"}, claims.Audience)
+	assert.Equal(t, "user5/repo4", claims.Repository)
+	assert.Equal(t, "refs/heads/master", claims.Ref)
+	assert.Equal(t, "branch", claims.RefType)
+	assert.Equal(t, "artifact.yaml", claims.Workflow)
+	assert.Equal(t, "791", claims.RunID)
+	assert.Equal(t, "187", claims.RunNumber)
+	assert.Equal(t, "job_2", claims.Job)
+	assert.Equal(t, "production", claims.Environment)
+	assert.Equal(t, "user1", claims.Actor)
+}
@@ -707,6 +707,31 @@ func (n *actionsNotifier) PullRequestChangeTargetBranch(ctx context.Context, doe
 		Notify(ctx)
 }
 
+func (n *actionsNotifier) MergeQueueChecksRequested(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, refFullName git.RefName, baseCommitID, commitID string) {
+	ctx = withMethod(ctx, "MergeQueueChecksRequested")
+
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		log.Error("pr.LoadBaseRepo: %v", err)
+		return
+	}
+
+	newNotifyInput(pr.BaseRepo, doer, webhook_module.HookEventMergeGroup).
+		WithRef(refFullName.String()).
+		WithPayload(&api.MergeGroupPayload{
+			Action: api.HookMergeGroupChecksRequested,
+			MergeGroup: &api.MergeGroup{
+				HeadSHA: commitID,
+				HeadRef: refFullName.String(),
+				BaseSHA: baseCommitID,
+				BaseRef: git.BranchPrefix + pr.BaseBranch,
+			},
+			PullRequest: convert.ToAPIPullRequest(ctx, pr, nil),
+			Repository:  convert.ToRepo(ctx, pr.BaseRepo, access_model.Permission{AccessMode: perm_model.AccessModeOwner}),
+			Sender:      convert.ToUser(ctx, doer, nil),
+		}).
+		Notify(ctx)
+}
+
 func (n *actionsNotifier) NewWikiPage(ctx context.Context, doer *user_model.User, repo *repo_model.Repository, page, comment string) {
 	ctx = withMethod(ctx, "NewWikiPage")
 
@@ -354,6 +354,11 @@ func handleWorkflows(
 			log.Error("jobparser.Parse: %v", err)
 			continue
 		}
+		jobSettings, err := actions_model.ParseRunJobSettings(dwf.Content)
+		if err != nil {
+			log.Error("ParseRunJobSettings: %v", err)
+			continue
+		}
 
 		// cancel running jobs if the event is push or pull_request_sync
 		if run.Event == webhook_module.HookEventPush ||
@@ -369,7 +374,7 @@ func handleWorkflows(
 			}
 		}
 
-		if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
+		if err := actions_model.InsertRun(ctx, run, jobs, jobSettings); err != nil {
 			log.Error("InsertRun: %v", err)
 			continue
 		}
@@ -143,9 +143,13 @@ func CreateScheduleTask(ctx context.Context, cron *actions_model.ActionSchedule)
 	if err != nil {
 		return err
 	}
+	jobSettings, err := actions_model.ParseRunJobSettings(cron.Content)
+	if err != nil {
+		return err
+	}
 
 	// Insert the action run and its associated jobs into the database
-	if err := actions_model.InsertRun(ctx, run, workflows); err != nil {
+	if err := actions_model.InsertRun(ctx, run, workflows, jobSettings); err != nil {
 		return err
 	}
 
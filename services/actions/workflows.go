@@ -136,8 +136,12 @@ func (entry *Workflow) Dispatch(ctx context.Context, inputGetter InputValueGette
 	if err != nil {
 		return err
 	}
+	jobSettings, err := actions_model.ParseRunJobSettings(content)
+	if err != nil {
+		return err
+	}
 
-	return actions_model.InsertRun(ctx, run, jobs)
+	return actions_model.InsertRun(ctx, run, jobs, jobSettings)
 }
 
 func GetWorkflowFromCommit(gitRepo *git.Repository, ref, workflowID string) (*Workflow, error) {
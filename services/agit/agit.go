@@ -10,12 +10,19 @@ import (
 	"strings"
 
 	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	pull_model "code.gitea.io/gitea/models/pull"
 	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/git/pushoptions"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/private"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/automerge"
+	issue_service "code.gitea.io/gitea/services/issue"
 	notify_service "code.gitea.io/gitea/services/notify"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
@@ -24,10 +31,13 @@ import (
 func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.Repository, opts *private.HookOptions) ([]private.HookProcReceiveRefResult, error) {
 	results := make([]private.HookProcReceiveRefResult, 0, len(opts.OldCommitIDs))
 
-	topicBranch, _ := opts.GetGitPushOptions().GetString(pushoptions.AgitTopic)
-	_, forcePush := opts.GetGitPushOptions().GetString(pushoptions.AgitForcePush)
-	title, hasTitle := opts.GetGitPushOptions().GetString(pushoptions.AgitTitle)
-	description, hasDesc := opts.GetGitPushOptions().GetString(pushoptions.AgitDescription)
+	pushOptions := opts.GetGitPushOptions()
+	topicBranch, _ := pushOptions.GetString(pushoptions.AgitTopic)
+	// any value other than false forces the push, as the option used to be a flag whose value was ignored
+	_, hasForcePush := pushOptions.GetString(pushoptions.AgitForcePush)
+	forcePush := hasForcePush && pushOptions.GetBool(pushoptions.AgitForcePush, true)
+	title, hasTitle := pushOptions.GetString(pushoptions.AgitTitle)
+	description, hasDesc := pushOptions.GetString(pushoptions.AgitDescription)
 
 	objectFormat := git.ObjectFormatFromName(repo.ObjectFormatName)
 
@@ -36,6 +46,11 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 		return nil, fmt.Errorf("failed to get user[%d]: %w", opts.UserID, err)
 	}
 
+	prOpts, prOptsErr, err := getPullRequestOptions(ctx, repo, pusher, pushOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the pull request options: %w", err)
+	}
+
 	for i := range opts.OldCommitIDs {
 		// Avoid processing this change if the new commit is empty.
 		if opts.NewCommitIDs[i] == objectFormat.EmptyObjectID().String() {
@@ -84,6 +99,16 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 			continue
 		}
 
+		if len(prOptsErr) > 0 {
+			results = append(results, private.HookProcReceiveRefResult{
+				OriginalRef: opts.RefFullNames[i],
+				OldOID:      opts.OldCommitIDs[i],
+				NewOID:      opts.NewCommitIDs[i],
+				Err:         prOptsErr,
+			})
+			continue
+		}
+
 		// Include the user's name in the head branch, to avoid conflicts
 		// with other users.
 		headBranch := curentTopicBranch
@@ -124,20 +149,27 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 					return nil, fmt.Errorf("failed to get commit %s in repository %q: %w", opts.NewCommitIDs[i], repo.FullName(), err)
 				}
 			}
+			newDescription := description
+			if !hasDesc || len(description) == 0 {
+				_, newDescription, _ = strings.Cut(commit.CommitMessage, "\n\n")
+			}
+			newTitle := title
 			if !hasTitle || len(title) == 0 {
-				title = strings.Split(commit.CommitMessage, "\n")[0]
+				newTitle = strings.Split(commit.CommitMessage, "\n")[0]
 			}
-			if !hasDesc || len(description) == 0 {
-				_, description, _ = strings.Cut(commit.CommitMessage, "\n\n")
+			if prOpts.draft.Has() {
+				newTitle = setWorkInProgress(newTitle, prOpts.draft.Value())
 			}
 
 			prIssue := &issues_model.Issue{
-				RepoID:   repo.ID,
-				Title:    title,
-				PosterID: pusher.ID,
-				Poster:   pusher,
-				IsPull:   true,
-				Content:  description,
+				RepoID:      repo.ID,
+				Repo:        repo,
+				Title:       newTitle,
+				PosterID:    pusher.ID,
+				Poster:      pusher,
+				IsPull:      true,
+				Content:     newDescription,
+				MilestoneID: prOpts.milestone.ValueOrDefault(0),
 			}
 
 			pr := &issues_model.PullRequest{
@@ -153,12 +185,37 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 				Flow:         issues_model.PullRequestFlowAGit,
 			}
 
-			if err := pull_service.NewPullRequest(ctx, repo, prIssue, []int64{}, []string{}, pr, []int64{}); err != nil {
+			if msg, err := checkPullRequestOptions(ctx, pr, prIssue, pusher, prOpts); err != nil {
+				return nil, fmt.Errorf("failed to check the pull request options: %w", err)
+			} else if len(msg) > 0 {
+				results = append(results, private.HookProcReceiveRefResult{
+					OriginalRef: opts.RefFullNames[i],
+					OldOID:      opts.OldCommitIDs[i],
+					NewOID:      opts.NewCommitIDs[i],
+					Err:         msg,
+				})
+				continue
+			}
+
+			labelIDs := make([]int64, 0, len(prOpts.labels))
+			for _, label := range prOpts.labels {
+				labelIDs = append(labelIDs, label.ID)
+			}
+			assigneeIDs := make([]int64, 0, len(prOpts.assignees))
+			for _, assignee := range prOpts.assignees {
+				assigneeIDs = append(assigneeIDs, assignee.ID)
+			}
+
+			if err := pull_service.NewPullRequest(ctx, repo, prIssue, labelIDs, []string{}, pr, assigneeIDs); err != nil {
 				return nil, fmt.Errorf("unable to create new pull request: %w", err)
 			}
 
 			log.Trace("Pull request created: %d/%d", repo.ID, prIssue.ID)
 
+			if err := requestReviewsAndScheduleAutoMerge(ctx, gitRepo, pr, pusher, prOpts); err != nil {
+				return nil, err
+			}
+
 			results = append(results, private.HookProcReceiveRefResult{
 				Ref:         pr.GetGitRefName(),
 				OriginalRef: opts.RefFullNames[i],
@@ -205,6 +262,41 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 			}
 		}
 
+		if err := pr.LoadIssue(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load the issue of the pull request: %w", err)
+		}
+		pr.Issue.Repo = repo
+
+		newTitle := pr.Issue.Title
+		if hasTitle && len(title) > 0 {
+			newTitle = title
+		}
+		if prOpts.draft.Has() {
+			newTitle = setWorkInProgress(newTitle, prOpts.draft.Value())
+		}
+		changeDescription := hasDesc && description != pr.Issue.Content
+
+		// Everything is checked before the reference is updated, so that the push is not only partially applied.
+		msg, err := checkPullRequestOptions(ctx, pr, pr.Issue, pusher, prOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check the pull request options: %w", err)
+		}
+		if len(msg) == 0 {
+			msg, err = checkPullRequestUpdate(ctx, pr, pusher, newTitle, changeDescription, prOpts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check the pull request update: %w", err)
+			}
+		}
+		if len(msg) > 0 {
+			results = append(results, private.HookProcReceiveRefResult{
+				OriginalRef: opts.RefFullNames[i],
+				OldOID:      opts.OldCommitIDs[i],
+				NewOID:      opts.NewCommitIDs[i],
+				Err:         msg,
+			})
+			continue
+		}
+
 		// Set the new commit as reference of the pull request.
 		pr.HeadCommitID = opts.NewCommitIDs[i]
 		if err = pull_service.UpdateRef(ctx, pr); err != nil {
@@ -214,10 +306,6 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 		// Add the pull request to the merge conflicting checker queue.
 		pull_service.AddToTaskQueue(ctx, pr)
 
-		if err := pr.LoadIssue(ctx); err != nil {
-			return nil, fmt.Errorf("failed to load the issue of the pull request: %w", err)
-		}
-
 		// Create and notify about the new commits.
 		comment, err := pull_service.CreatePushPullComment(ctx, pusher, pr, oldCommitID, opts.NewCommitIDs[i])
 		if err == nil && comment != nil {
@@ -226,6 +314,23 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 		notify_service.PullRequestSynchronized(ctx, pusher, pr)
 		isForcePush := comment != nil && comment.IsForcePush
 
+		if newTitle != pr.Issue.Title {
+			if err := issue_service.ChangeTitle(ctx, pr.Issue, pusher, newTitle); err != nil {
+				return nil, fmt.Errorf("failed to change the title of the pull request: %w", err)
+			}
+		}
+		if changeDescription {
+			if err := issue_service.ChangeContent(ctx, pr.Issue, pusher, description, pr.Issue.ContentVersion); err != nil {
+				return nil, fmt.Errorf("failed to change the description of the pull request: %w", err)
+			}
+		}
+		if err := updatePullRequestMetadata(ctx, pr, pusher, prOpts); err != nil {
+			return nil, err
+		}
+		if err := requestReviewsAndScheduleAutoMerge(ctx, gitRepo, pr, pusher, prOpts); err != nil {
+			return nil, err
+		}
+
 		results = append(results, private.HookProcReceiveRefResult{
 			OldOID:      oldCommitID,
 			NewOID:      opts.NewCommitIDs[i],
@@ -238,6 +343,224 @@ func ProcReceive(ctx context.Context, repo *repo_model.Repository, gitRepo *git.
 	return results, nil
 }
 
+// pullRequestOptions are the properties of an AGit pull request given by the push options
+type pullRequestOptions struct {
+	draft                  optional.Option[bool]
+	labels                 []*issues_model.Label
+	milestone              optional.Option[int64]
+	assignees              []*user_model.User
+	reviewers              []*user_model.User
+	mergeWhenChecksSucceed bool
+
+	perm access_model.Permission
+}
+
+// getPullRequestOptions looks up the labels, milestone and users given by the push options. If the pusher has
+// to fix the push options, the reason is returned as message.
+func getPullRequestOptions(ctx context.Context, repo *repo_model.Repository, pusher *user_model.User, pushOptions pushoptions.Interface) (*pullRequestOptions, string, error) {
+	prOpts := &pullRequestOptions{
+		mergeWhenChecksSucceed: pushOptions.GetBool(pushoptions.AgitMergeWhenChecksSucceed, false),
+	}
+	if _, ok := pushOptions.GetString(pushoptions.AgitDraft); ok {
+		prOpts.draft = optional.Some(pushOptions.GetBool(pushoptions.AgitDraft, true))
+	}
+
+	if err := repo.LoadOwner(ctx); err != nil {
+		return nil, "", err
+	}
+	var err error
+	prOpts.perm, err = access_model.GetUserRepoPermission(ctx, repo, pusher)
+	if err != nil {
+		return nil, "", err
+	}
+
+	labelNames := pushOptions.GetStrings(pushoptions.AgitLabel)
+	milestoneName, hasMilestone := pushOptions.GetString(pushoptions.AgitMilestone)
+	assigneeNames := pushOptions.GetStrings(pushoptions.AgitAssignee)
+	if (len(labelNames) > 0 || hasMilestone || len(assigneeNames) > 0) && !prOpts.perm.CanWrite(unit.TypePullRequests) {
+		return nil, "You are not allowed to set the labels, the milestone or the assignees of pull requests.", nil
+	}
+
+	for _, name := range labelNames {
+		label, err := issues_model.GetLabelInRepoByName(ctx, repo.ID, name)
+		if issues_model.IsErrRepoLabelNotExist(err) && repo.Owner.IsOrganization() {
+			label, err = issues_model.GetLabelInOrgByName(ctx, repo.OwnerID, name)
+		}
+		if issues_model.IsErrRepoLabelNotExist(err) || issues_model.IsErrOrgLabelNotExist(err) {
+			return nil, fmt.Sprintf("The label %q does not exist.", name), nil
+		} else if err != nil {
+			return nil, "", err
+		}
+		prOpts.labels = append(prOpts.labels, label)
+	}
+
+	if hasMilestone {
+		// an empty milestone removes the milestone of an existing pull request
+		prOpts.milestone = optional.Some[int64](0)
+		if len(milestoneName) > 0 {
+			milestone, err := issues_model.GetMilestoneByRepoIDANDName(ctx, repo.ID, milestoneName)
+			if issues_model.IsErrMilestoneNotExist(err) {
+				return nil, fmt.Sprintf("The milestone %q does not exist.", milestoneName), nil
+			} else if err != nil {
+				return nil, "", err
+			}
+			prOpts.milestone = optional.Some(milestone.ID)
+		}
+	}
+
+	for _, name := range assigneeNames {
+		assignee, err := user_model.GetUserByName(ctx, name)
+		if user_model.IsErrUserNotExist(err) {
+			return nil, fmt.Sprintf("The user %q does not exist.", name), nil
+		} else if err != nil {
+			return nil, "", err
+		}
+		if assignee.IsOrganization() {
+			return nil, fmt.Sprintf("The user %q cannot be assigned to pull requests.", name), nil
+		}
+		canBeAssigned, err := access_model.CanBeAssigned(ctx, assignee, repo, true)
+		if err != nil {
+			return nil, "", err
+		} else if !canBeAssigned {
+			return nil, fmt.Sprintf("The user %q cannot be assigned to pull requests.", name), nil
+		}
+		prOpts.assignees = append(prOpts.assignees, assignee)
+	}
+
+	for _, name := range pushOptions.GetStrings(pushoptions.AgitReviewer) {
+		reviewer, err := user_model.GetUserByName(ctx, name)
+		if user_model.IsErrUserNotExist(err) {
+			return nil, fmt.Sprintf("The user %q does not exist.", name), nil
+		} else if err != nil {
+			return nil, "", err
+		}
+		prOpts.reviewers = append(prOpts.reviewers, reviewer)
+	}
+
+	return prOpts, "", nil
+}
+
+// checkPullRequestOptions checks if the pusher may request the reviews and schedule the auto merge of the pull
+// request. The issue of a new pull request is checked before it is created.
+func checkPullRequestOptions(ctx context.Context, pr *issues_model.PullRequest, issue *issues_model.Issue, pusher *user_model.User, prOpts *pullRequestOptions) (string, error) {
+	for _, reviewer := range prOpts.reviewers {
+		if err := issue_service.IsValidReviewRequest(ctx, reviewer, pusher, true, issue, &prOpts.perm); err != nil {
+			if issues_model.IsErrNotValidReviewRequest(err) {
+				return fmt.Sprintf("A review cannot be requested from %q.", reviewer.Name), nil
+			}
+			return "", err
+		}
+	}
+
+	if prOpts.mergeWhenChecksSucceed {
+		allowed, err := pull_service.IsUserAllowedToMerge(ctx, pr, prOpts.perm, pusher)
+		if err != nil {
+			return "", err
+		} else if !allowed {
+			return fmt.Sprintf("You are not allowed to merge pull requests into %q.", pr.BaseBranch), nil
+		}
+	}
+
+	return "", nil
+}
+
+// checkPullRequestUpdate checks if the title, the description and the metadata of an existing pull request can be
+// changed as requested by the push options.
+func checkPullRequestUpdate(ctx context.Context, pr *issues_model.PullRequest, pusher *user_model.User, newTitle string, changeDescription bool, prOpts *pullRequestOptions) (string, error) {
+	if len(strings.TrimSpace(newTitle)) == 0 {
+		return "The title of the pull request must not be empty.", nil
+	}
+
+	changeMetadata := len(prOpts.labels) > 0 || len(prOpts.assignees) > 0 ||
+		(prOpts.milestone.Has() && prOpts.milestone.Value() != pr.Issue.MilestoneID)
+	if newTitle == pr.Issue.Title && !changeDescription && !changeMetadata {
+		return "", nil
+	}
+	if err := pr.Issue.LoadRepo(ctx); err != nil {
+		return "", err
+	}
+	if user_model.IsBlockedMultiple(ctx, []int64{pr.Issue.PosterID, pr.Issue.Repo.OwnerID}, pusher.ID) {
+		return "You are not allowed to change the title, the description or the metadata of this pull request.", nil
+	}
+	return "", nil
+}
+
+// updatePullRequestMetadata adds the labels and assignees of the push options to an existing pull request and
+// changes its milestone.
+func updatePullRequestMetadata(ctx context.Context, pr *issues_model.PullRequest, pusher *user_model.User, prOpts *pullRequestOptions) error {
+	if len(prOpts.labels) > 0 {
+		if err := issue_service.AddLabels(ctx, pr.Issue, pusher, prOpts.labels); err != nil {
+			return fmt.Errorf("failed to add the labels to the pull request: %w", err)
+		}
+	}
+
+	if prOpts.milestone.Has() && prOpts.milestone.Value() != pr.Issue.MilestoneID {
+		oldMilestoneID := pr.Issue.MilestoneID
+		pr.Issue.MilestoneID = prOpts.milestone.Value()
+		if err := issue_service.ChangeMilestoneAssign(ctx, pr.Issue, pusher, oldMilestoneID); err != nil {
+			return fmt.Errorf("failed to change the milestone of the pull request: %w", err)
+		}
+	}
+
+	for _, assignee := range prOpts.assignees {
+		if _, err := issue_service.AddAssigneeIfNotAssigned(ctx, pr.Issue, pusher, assignee.ID, true); err != nil {
+			return fmt.Errorf("failed to assign %q to the pull request: %w", assignee.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// requestReviewsAndScheduleAutoMerge requests the reviews of the push options and schedules the pull request to be
+// merged with the default merge style of the repository once its checks succeed.
+func requestReviewsAndScheduleAutoMerge(ctx context.Context, gitRepo *git.Repository, pr *issues_model.PullRequest, pusher *user_model.User, prOpts *pullRequestOptions) error {
+	for _, reviewer := range prOpts.reviewers {
+		if _, err := issue_service.ReviewRequest(ctx, pr.Issue, pusher, reviewer, true); err != nil {
+			return fmt.Errorf("failed to request a review from %q: %w", reviewer.Name, err)
+		}
+	}
+
+	if !prOpts.mergeWhenChecksSucceed {
+		return nil
+	}
+
+	prUnit, err := pr.BaseRepo.GetUnit(ctx, unit.TypePullRequests)
+	if err != nil {
+		return fmt.Errorf("failed to get the pull request settings: %w", err)
+	}
+	style := prUnit.PullRequestsConfig().GetDefaultMergeStyle()
+
+	message, body, err := pull_service.GetDefaultMergeMessage(ctx, gitRepo, pr, style)
+	if err != nil {
+		return fmt.Errorf("failed to get the default merge message: %w", err)
+	}
+	if len(body) > 0 {
+		message += "\n\n" + body
+	}
+
+	if _, err := automerge.ScheduleAutoMerge(ctx, pusher, pr, style, message); err != nil && !pull_model.IsErrAlreadyScheduledToAutoMerge(err) {
+		return fmt.Errorf("failed to schedule the auto merge of the pull request: %w", err)
+	}
+	return nil
+}
+
+// setWorkInProgress adds or removes the work in progress prefix of a pull request title
+func setWorkInProgress(title string, wip bool) string {
+	prefixes := setting.Repository.PullRequest.WorkInProgressPrefixes
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(strings.ToUpper(title), strings.ToUpper(prefix)) {
+			if wip {
+				return title
+			}
+			return strings.TrimSpace(title[len(prefix):])
+		}
+	}
+	if !wip || len(prefixes) == 0 {
+		return title
+	}
+	return prefixes[0] + " " + title
+}
+
 // UserNameChanged handle user name change for agit flow pull
 func UserNameChanged(ctx context.Context, user *user_model.User, newName string) error {
 	pulls, err := issues_model.GetAllUnmergedAgitPullRequestByPoster(ctx, user.ID)
@@ -0,0 +1,234 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	audit_model "code.gitea.io/gitea/models/audit"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	webhook_model "code.gitea.io/gitea/models/webhook"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// Target is the object an audited action applies to, its owner and repository tell in which audit logs the event
+// is visible besides the one of the instance
+type Target struct {
+	OwnerID int64
+	RepoID  int64
+	Type    string
+	ID      int64
+	Name    string
+}
+
+// InstanceTarget returns a target only visible in the audit log of the instance
+func InstanceTarget(typ string, id int64, name string) Target {
+	return Target{Type: typ, ID: id, Name: name}
+}
+
+// OwnerTarget returns a target visible in the audit log of a user or an organization
+func OwnerTarget(owner *user_model.User, typ string, id int64, name string) Target {
+	return Target{OwnerID: owner.ID, Type: typ, ID: id, Name: name}
+}
+
+// RepoTarget returns a target visible in the audit logs of a repository and of its owner
+func RepoTarget(repo *repo_model.Repository, typ string, id int64, name string) Target {
+	return Target{OwnerID: repo.OwnerID, RepoID: repo.ID, Type: typ, ID: id, Name: name}
+}
+
+// PublicKeyTarget returns a public key as a target visible in the audit log of its owner
+func PublicKeyTarget(key *asymkey_model.PublicKey) Target {
+	return Target{OwnerID: key.OwnerID, Type: "public_key", ID: key.ID, Name: key.Name}
+}
+
+// AccessTokenTarget returns an access token as a target visible in the audit log of its owner
+func AccessTokenTarget(t *auth_model.AccessToken) Target {
+	return Target{OwnerID: t.UID, Type: "access_token", ID: t.ID, Name: t.Name}
+}
+
+// WebhookTarget returns a webhook as a target visible in the audit logs of its repository or owner, system and default
+// webhooks are only visible in the audit log of the instance
+func WebhookTarget(ctx context.Context, w *webhook_model.Webhook) Target {
+	return withRepoOwner(ctx, Target{OwnerID: w.OwnerID, RepoID: w.RepoID, Type: "webhook", ID: w.ID, Name: webhookHost(w.URL)})
+}
+
+// SecretTarget returns an Actions secret as a target visible in the audit logs of its repository or owner
+func SecretTarget(ctx context.Context, ownerID, repoID int64, name string) Target {
+	return withRepoOwner(ctx, Target{OwnerID: ownerID, RepoID: repoID, Type: "secret", Name: name})
+}
+
+// withRepoOwner sets the owner of the target to the owner of its repository, which is not stored along with the
+// webhooks and secrets of a repository
+func withRepoOwner(ctx context.Context, t Target) Target {
+	if t.RepoID == 0 {
+		return t
+	}
+	repo, err := repo_model.GetRepositoryByID(ctx, t.RepoID)
+	if err != nil {
+		log.Error("Unable to get the repository %d of the %s %d: %v", t.RepoID, t.Type, t.ID, err)
+		return t
+	}
+	t.OwnerID = repo.OwnerID
+	return t
+}
+
+// webhookHost returns the host of the URL of a webhook, the rest of the URL may contain a secret
+func webhookHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+var webhookQueue *queue.WorkerPoolQueue[*api.AuditEvent]
+
+// Init starts the queue streaming the audit log to the webhook
+func Init() error {
+	if !setting.Audit.Enabled || setting.Audit.WebhookURL == "" {
+		return nil
+	}
+	webhookQueue = queue.CreateSimpleQueue(graceful.GetManager().ShutdownContext(), "audit_webhook", webhookHandler)
+	if webhookQueue == nil {
+		return fmt.Errorf("unable to create audit_webhook queue")
+	}
+	go graceful.GetManager().RunWithCancel(webhookQueue)
+	return nil
+}
+
+// Record adds an action of the doer to the audit log and streams it to the audit logger and the webhook. before and
+// after are the states of the target around the action, they are stored as JSON and must not contain any secret.
+// Failing to record the action is logged and does not fail the action itself.
+func Record(ctx context.Context, doer *user_model.User, action audit_model.Action, target Target, before, after any) {
+	if !setting.Audit.Enabled {
+		return
+	}
+
+	e := &audit_model.Event{
+		Action:     action,
+		ActorIP:    remoteAddr(ctx),
+		OwnerID:    target.OwnerID,
+		RepoID:     target.RepoID,
+		TargetType: target.Type,
+		TargetID:   target.ID,
+		TargetName: target.Name,
+	}
+	if doer != nil {
+		e.ActorID = doer.ID
+		e.ActorName = doer.Name
+	}
+	var err error
+	if e.Before, err = marshalState(before); err != nil {
+		log.Error("Unable to marshal the state before %s: %v", action, err)
+	}
+	if e.After, err = marshalState(after); err != nil {
+		log.Error("Unable to marshal the state after %s: %v", action, err)
+	}
+
+	// the action is recorded even if the request is cancelled right after it
+	if err := audit_model.InsertEvent(db.DefaultContext, e); err != nil {
+		log.Error("Unable to record %s of %s in the audit log: %v", action, e.ActorName, err)
+		return
+	}
+
+	apiEvent := convert.ToAuditEvent(e)
+	if setting.IsAuditLogEnabled() {
+		if line, err := json.Marshal(apiEvent); err == nil {
+			log.GetLogger("audit").Info("%s", line)
+		}
+	}
+	if webhookQueue != nil {
+		if err := webhookQueue.Push(apiEvent); err != nil {
+			log.Error("Unable to push the audit event %d to the webhook queue: %v", e.ID, err)
+		}
+	}
+}
+
+func marshalState(state any) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil || string(b) == "null" {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// remoteAddr returns the address of the client of the request the context belongs to
+func remoteAddr(ctx context.Context) string {
+	r, ok := ctx.(interface{ RemoteAddr() string })
+	if !ok {
+		return ""
+	}
+	addr := r.RemoteAddr()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+func webhookHandler(items ...*api.AuditEvent) []*api.AuditEvent {
+	client := &http.Client{
+		Timeout: setting.Audit.DeliverTimeout,
+		Transport: &http.Transport{
+			Proxy: proxy.Proxy(),
+		},
+	}
+	var unhandled []*api.AuditEvent
+	for _, e := range items {
+		if err := deliver(client, e); err != nil {
+			log.Error("Unable to deliver the audit event %d to the webhook: %v", e.ID, err)
+			unhandled = append(unhandled, e)
+		}
+	}
+	return unhandled
+}
+
+func deliver(client *http.Client, e *api.AuditEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, setting.Audit.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forgejo-Event", "audit")
+	if setting.Audit.WebhookSecret != "" {
+		sig := hmac.New(sha256.New, []byte(setting.Audit.WebhookSecret))
+		_, _ = sig.Write(body)
+		req.Header.Set("X-Forgejo-Signature", hex.EncodeToString(sig.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
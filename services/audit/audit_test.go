@@ -0,0 +1,93 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+
+	t.Run("Disabled", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Audit.Enabled, false)()
+
+		Record(db.DefaultContext, doer, audit_model.ActionDeployKeyAdd, RepoTarget(repo, "deploy_key", 1, "disabled"), nil, State{"name": "disabled"})
+		unittest.AssertNotExistsBean(t, &audit_model.Event{TargetName: "disabled"})
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		defer test.MockVariableValue(&setting.Audit.Enabled, true)()
+
+		Record(db.DefaultContext, doer, audit_model.ActionDeployKeyAdd, RepoTarget(repo, "deploy_key", 1, "first"), nil, State{"name": "first"})
+		Record(db.DefaultContext, doer, audit_model.ActionDeployKeyRemove, RepoTarget(repo, "deploy_key", 1, "first"), State{"name": "first"}, nil)
+
+		added := unittest.AssertExistsAndLoadBean(t, &audit_model.Event{Action: audit_model.ActionDeployKeyAdd, TargetName: "first"})
+		assert.EqualValues(t, 2, added.ActorID)
+		assert.EqualValues(t, "user2", added.ActorName)
+		assert.EqualValues(t, repo.OwnerID, added.OwnerID)
+		assert.EqualValues(t, repo.ID, added.RepoID)
+		assert.Empty(t, added.Before)
+		assert.JSONEq(t, `{"name":"first"}`, added.After)
+
+		removed := unittest.AssertExistsAndLoadBean(t, &audit_model.Event{Action: audit_model.ActionDeployKeyRemove, TargetName: "first"})
+		assert.JSONEq(t, `{"name":"first"}`, removed.Before)
+		assert.Empty(t, removed.After)
+		assert.Equal(t, added.Hash, removed.PrevHash)
+
+		brokenID, err := audit_model.VerifyChain(db.DefaultContext)
+		require.NoError(t, err)
+		assert.Zero(t, brokenID)
+	})
+}
+
+func TestDeliver(t *testing.T) {
+	var body []byte
+	var header http.Header
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	defer test.MockVariableValue(&setting.Audit.WebhookURL, server.URL)()
+	defer test.MockVariableValue(&setting.Audit.WebhookSecret, "secret")()
+
+	require.NoError(t, deliver(server.Client(), &api.AuditEvent{ID: 1, Action: string(audit_model.ActionUserSignIn)}))
+	assert.Contains(t, string(body), `"action":"user_signin"`)
+	assert.Equal(t, "audit", header.Get("X-Forgejo-Event"))
+	sig := hmac.New(sha256.New, []byte("secret"))
+	sig.Write(body)
+	assert.Equal(t, hex.EncodeToString(sig.Sum(nil)), header.Get("X-Forgejo-Signature"))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, deliver(server.Client(), &api.AuditEvent{ID: 2}))
+}
+
+func TestWebhookHost(t *testing.T) {
+	assert.Equal(t, "hooks.slack.com", webhookHost("https://hooks.slack.com/services/T000/B000/XXXX"))
+	assert.Equal(t, "example.com:8080", webhookHost("http://example.com:8080/hook?token=secret"))
+	assert.Empty(t, webhookHost("://invalid"))
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package audit
+
+import (
+	asymkey_model "code.gitea.io/gitea/models/asymkey"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	webhook_model "code.gitea.io/gitea/models/webhook"
+)
+
+// State is the recorded state of the target of an action, it must not contain any secret
+type State map[string]any
+
+// TeamState returns the permissions of a team, its units must be loaded
+func TeamState(t *organization.Team) State {
+	return State{
+		"name":                      t.Name,
+		"description":               t.Description,
+		"access_mode":               t.AccessMode.String(),
+		"includes_all_repositories": t.IncludesAllRepositories,
+		"can_create_org_repo":       t.CanCreateOrgRepo,
+		"units":                     t.GetUnitsMap(),
+	}
+}
+
+// DeployKeyState returns the state of a deploy key
+func DeployKeyState(key *asymkey_model.DeployKey) State {
+	return State{
+		"name":        key.Name,
+		"fingerprint": key.Fingerprint,
+		"mode":        key.Mode.String(),
+	}
+}
+
+// PublicKeyState returns the state of a public key of a user
+func PublicKeyState(key *asymkey_model.PublicKey) State {
+	return State{
+		"name":        key.Name,
+		"fingerprint": key.Fingerprint,
+		"type":        key.Type,
+	}
+}
+
+// AccessTokenState returns the permissions of an access token, never the token itself
+func AccessTokenState(t *auth_model.AccessToken) State {
+	return State{
+		"name":         t.Name,
+		"last_eight":   t.TokenLastEight,
+		"scope":        string(t.Scope),
+		"org_id":       t.OrgID,
+		"repo_ids":     t.RepoIDs,
+		"expires_unix": t.ExpiresUnix,
+	}
+}
+
+// WebhookState returns the configuration of a webhook, without its secret, authorization header or the path of its
+// URL which may contain a secret
+func WebhookState(w *webhook_model.Webhook) State {
+	return State{
+		"type":              w.Type,
+		"host":              webhookHost(w.URL),
+		"http_method":       w.HTTPMethod,
+		"content_type":      w.ContentType.Name(),
+		"is_active":         w.IsActive,
+		"is_system_webhook": w.IsSystemWebhook,
+		"events":            w.EventsArray(),
+		"branch_filter":     w.BranchFilter,
+	}
+}
+
+// SecretState returns the state of an Actions secret, only its name since its value must never be recorded, or nil if
+// it does not exist
+func SecretState(name string, exists bool) State {
+	if !exists {
+		return nil
+	}
+	return State{"name": name}
+}
+
+// UserState returns the account settings of a user an administrator can change
+func UserState(u *user_model.User) State {
+	return State{
+		"name":                      u.Name,
+		"email":                     u.Email,
+		"is_admin":                  u.IsAdmin,
+		"is_active":                 u.IsActive,
+		"is_restricted":             u.IsRestricted,
+		"prohibit_login":            u.ProhibitLogin,
+		"login_type":                u.LoginType.String(),
+		"login_source":              u.LoginSource,
+		"login_name":                u.LoginName,
+		"visibility":                u.Visibility.String(),
+		"allow_git_hook":            u.AllowGitHook,
+		"allow_import_local":        u.AllowImportLocal,
+		"allow_create_organization": u.AllowCreateOrganization,
+		"max_repo_creation":         u.MaxRepoCreation,
+		"must_change_password":      u.MustChangePassword,
+	}
+}
+
+// AuthSourceState returns the state of an authentication source, not its configuration which holds its credentials
+func AuthSourceState(source *auth_model.Source) State {
+	return State{
+		"name":            source.Name,
+		"type":            source.Type.String(),
+		"is_active":       source.IsActive,
+		"is_sync_enabled": source.IsSyncEnabled,
+	}
+}
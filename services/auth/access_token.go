@@ -0,0 +1,104 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/organization"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// AccessTokenResources are the organization or the repositories an access token is restricted to
+type AccessTokenResources struct {
+	Organization *organization.Organization
+	Repositories []*repo_model.Repository
+}
+
+// SetAccessTokenResources restricts the access token to the organization or to the repositories, which are given by
+// their names and full names
+func SetAccessTokenResources(ctx context.Context, t *auth_model.AccessToken, orgName string, repoNames []string) error {
+	t.OrgID = 0
+	t.RepoIDs = nil
+	if orgName != "" && len(repoNames) > 0 {
+		return util.NewInvalidArgumentErrorf("a token can either be restricted to an organization or to repositories")
+	}
+
+	if orgName != "" {
+		org, err := organization.GetOrgByName(ctx, orgName)
+		if err != nil {
+			if organization.IsErrOrgNotExist(err) {
+				return util.NewInvalidArgumentErrorf("organization %q does not exist", orgName)
+			}
+			return err
+		}
+		t.OrgID = org.ID
+		return nil
+	}
+
+	for _, repoName := range repoNames {
+		ownerName, name, ok := strings.Cut(repoName, "/")
+		if !ok {
+			return util.NewInvalidArgumentErrorf("repository %q is not of the form owner/name", repoName)
+		}
+		repo, err := repo_model.GetRepositoryByOwnerAndName(ctx, ownerName, name)
+		if err != nil {
+			if repo_model.IsErrRepoNotExist(err) {
+				return util.NewInvalidArgumentErrorf("repository %q does not exist", repoName)
+			}
+			return err
+		}
+		if !slices.Contains(t.RepoIDs, repo.ID) {
+			t.RepoIDs = append(t.RepoIDs, repo.ID)
+		}
+	}
+	return nil
+}
+
+// GetAccessTokenResources returns the organization or the repositories the access token is restricted to, the ones
+// which were deleted since are omitted
+func GetAccessTokenResources(ctx context.Context, t *auth_model.AccessToken) (*AccessTokenResources, error) {
+	resources := &AccessTokenResources{}
+	if t.OrgID != 0 {
+		org, err := organization.GetOrgByID(ctx, t.OrgID)
+		if err != nil && !user_model.IsErrUserNotExist(err) {
+			return nil, err
+		}
+		resources.Organization = org
+	}
+	if len(t.RepoIDs) > 0 {
+		repos, err := repo_model.GetRepositoriesMapByIDs(ctx, t.RepoIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range t.RepoIDs {
+			repo, ok := repos[id]
+			if !ok {
+				continue
+			}
+			if err := repo.LoadOwner(ctx); err != nil {
+				return nil, err
+			}
+			resources.Repositories = append(resources.Repositories, repo)
+		}
+	}
+	return resources, nil
+}
+
+// FullNames returns the names of the organization or the full names of the repositories
+func (r *AccessTokenResources) FullNames() []string {
+	if r.Organization != nil {
+		return []string{r.Organization.Name}
+	}
+	names := make([]string, 0, len(r.Repositories))
+	for _, repo := range r.Repositories {
+		names = append(names, repo.FullName())
+	}
+	return names
+}
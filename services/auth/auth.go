@@ -83,6 +83,18 @@ func handleSignIn(resp http.ResponseWriter, req *http.Request, sess SessionStore
 	if err != nil {
 		log.Error(fmt.Sprintf("Error setting session: %v", err))
 	}
+	generation, err := GetSessionsGeneration(req.Context(), user.ID)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error getting the generation of the sessions: %v", err))
+	}
+	err = sess.Set(SessionGenerationKey, generation)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error setting session: %v", err))
+	}
+	err = SetPhishingResistantMFASession(req.Context(), sess, user)
+	if err != nil {
+		log.Error(fmt.Sprintf("Error setting session: %v", err))
+	}
 
 	// Language setting of the user overwrites the one previously set
 	// If the user does not have a locale set, we save the current one.
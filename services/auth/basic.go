@@ -59,6 +59,13 @@ func (b *Basic) Verify(req *http.Request, w http.ResponseWriter, store DataStore
 
 	uname, passwd, _ := base.BasicAuthDecode(auths[1])
 
+	return b.VerifyCredentials(req, store, uname, passwd)
+}
+
+// VerifyCredentials returns the user of a username and a password, either of which can be a token, like they would
+// have been provided with Basic authentication.
+// Returns nil if validation fails.
+func (b *Basic) VerifyCredentials(req *http.Request, store DataStore, uname, passwd string) (*user_model.User, error) {
 	// Check if username or password is a token
 	isUsernameToken := len(passwd) == 0 || passwd == "x-oauth-basic"
 	// Assume username is token
@@ -72,7 +79,7 @@ func (b *Basic) Verify(req *http.Request, w http.ResponseWriter, store DataStore
 	}
 
 	// check oauth2 token
-	uid := CheckOAuthAccessToken(req.Context(), authToken)
+	uid, scope := CheckOAuthAccessToken(req.Context(), authToken)
 	if uid != 0 {
 		log.Trace("Basic Authorization: Valid OAuthAccessToken for user[%d]", uid)
 
@@ -83,6 +90,7 @@ func (b *Basic) Verify(req *http.Request, w http.ResponseWriter, store DataStore
 		}
 
 		store.GetData()["IsApiToken"] = true
+		store.GetData()["ApiTokenScope"] = scope
 		return u, nil
 	}
 
@@ -103,6 +111,7 @@ func (b *Basic) Verify(req *http.Request, w http.ResponseWriter, store DataStore
 
 		store.GetData()["IsApiToken"] = true
 		store.GetData()["ApiTokenScope"] = token.Scope
+		store.GetData()["ApiAccessToken"] = token
 		return u, nil
 	} else if !auth_model.IsErrAccessTokenNotExist(err) && !auth_model.IsErrAccessTokenEmpty(err) {
 		log.Error("GetAccessTokenBySha: %v", err)
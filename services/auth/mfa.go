@@ -0,0 +1,53 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"context"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// MustEnrollPhishingResistantMFASessionKey is the key of the session which is set when the user who signed in must
+// register a security key or a passkey, so that the other requests do not evaluate the policy
+const MustEnrollPhishingResistantMFASessionKey = "mustEnrollPhishingResistantMFA"
+
+// RequiresPhishingResistantMFA returns whether the user must sign in with WebAuthn, which is the case for the site
+// administrators and the organization owners when [security].REQUIRE_PHISHING_RESISTANT_MFA is enabled
+func RequiresPhishingResistantMFA(ctx context.Context, u *user_model.User) (bool, error) {
+	if !setting.RequirePhishingResistantMFA {
+		return false, nil
+	}
+	if u.IsAdmin {
+		return true, nil
+	}
+	return organization.IsOwnerOfAnyOrganization(ctx, u.ID)
+}
+
+// MustEnrollPhishingResistantMFA returns whether the user must sign in with WebAuthn but did not register a security
+// key or a passkey yet
+func MustEnrollPhishingResistantMFA(ctx context.Context, u *user_model.User) (bool, error) {
+	required, err := RequiresPhishingResistantMFA(ctx, u)
+	if err != nil || !required {
+		return false, err
+	}
+	enrolled, err := auth_model.HasWebAuthnRegistrationsByUID(ctx, u.ID)
+	return !enrolled, err
+}
+
+// SetPhishingResistantMFASession records in the session of the user who signs in whether they must register a security
+// key or a passkey
+func SetPhishingResistantMFASession(ctx context.Context, sess SessionStore, u *user_model.User) error {
+	mustEnroll, err := MustEnrollPhishingResistantMFA(ctx, u)
+	if err != nil {
+		return err
+	}
+	if mustEnroll {
+		return sess.Set(MustEnrollPhishingResistantMFASessionKey, true)
+	}
+	return sess.Delete(MustEnrollPhishingResistantMFASessionKey)
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package auth
+
+import (
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiresPhishingResistantMFA(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	admin := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 1})
+	orgOwner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	member := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+
+	for _, u := range []*user_model.User{admin, orgOwner, member} {
+		required, err := RequiresPhishingResistantMFA(db.DefaultContext, u)
+		require.NoError(t, err)
+		assert.False(t, required)
+	}
+
+	defer test.MockVariableValue(&setting.RequirePhishingResistantMFA, true)()
+
+	for u, expected := range map[*user_model.User]bool{admin: true, orgOwner: true, member: false} {
+		required, err := RequiresPhishingResistantMFA(db.DefaultContext, u)
+		require.NoError(t, err)
+		assert.Equal(t, expected, required, u.Name)
+	}
+}
+
+func TestMustEnrollPhishingResistantMFA(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.RequirePhishingResistantMFA, true)()
+
+	admin := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 1})
+	mustEnroll, err := MustEnrollPhishingResistantMFA(db.DefaultContext, admin)
+	require.NoError(t, err)
+	assert.True(t, mustEnroll)
+
+	_, err = auth_model.CreateCredential(db.DefaultContext, admin.ID, "Security key", &webauthn.Credential{ID: []byte("Security key")}, false)
+	require.NoError(t, err)
+	mustEnroll, err = MustEnrollPhishingResistantMFA(db.DefaultContext, admin)
+	require.NoError(t, err)
+	assert.False(t, mustEnroll)
+}
@@ -4,12 +4,29 @@
 package auth
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 
+	"code.gitea.io/gitea/models/db"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/log"
 )
 
+// SessionGenerationKey is the key of the session which holds the generation of the sessions of the user when they
+// signed in
+const SessionGenerationKey = "sessionsGeneration"
+
+// SessionSignInSourceIDKey is the key of the session which holds the authentication source the user signed in with,
+// 0 for the local sign-in
+const SessionSignInSourceIDKey = "signInSourceID"
+
+// UnknownSignInSourceID is the authentication source of the sessions which do not hold one, like the ones restored from
+// a remember-me cookie or created before it was recorded, it never fulfils the source required by an organization
+const UnknownSignInSourceID int64 = -1
+
 // Ensure the struct implements the interface.
 var (
 	_ Method = &Session{}
@@ -55,6 +72,64 @@ func (s *Session) Verify(req *http.Request, w http.ResponseWriter, store DataSto
 		return nil, nil
 	}
 
+	if generation, err := GetSessionsGeneration(req.Context(), user.ID); err != nil {
+		log.Error("GetSessionsGeneration: %v", err)
+		return nil, err
+	} else if sessionGeneration, _ := sess.Get(SessionGenerationKey).(int64); sessionGeneration < generation {
+		log.Trace("Session Authorization: Sessions of user %-v were revoked", user)
+		return nil, nil
+	}
+
+	// the organizations may require their members to sign in with a specific source
+	signInSourceID, ok := sess.Get(SessionSignInSourceIDKey).(int64)
+	if !ok {
+		signInSourceID = UnknownSignInSourceID
+	}
+	store.GetData()["SignInSourceID"] = signInSourceID
+
 	log.Trace("Session Authorization: Logged in user %-v", user)
 	return user, nil
 }
+
+func sessionsGenerationCacheKey(userID int64) string {
+	return fmt.Sprintf("user_%d.sessions_generation", userID)
+}
+
+// GetSessionsGeneration returns the generation of the sessions of a user, which is stored in the session when the user
+// signs in and incremented when the sessions are revoked
+func GetSessionsGeneration(ctx context.Context, userID int64) (int64, error) {
+	return cache.GetInt64(sessionsGenerationCacheKey(userID), func() (int64, error) {
+		generation, err := user_model.GetUserSetting(ctx, userID, user_model.SettingsKeySessionsGeneration, "0")
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(generation, 10, 64)
+	})
+}
+
+// ForgetSessionsGeneration removes the cached generation of the sessions of a user, it must be called once the
+// transaction in which RevokeSessions was called is committed
+func ForgetSessionsGeneration(userID int64) {
+	cache.Remove(sessionsGenerationCacheKey(userID))
+}
+
+// RevokeSessions invalidates all sessions in which the user is signed in. When it is called in a transaction, the
+// caller must call ForgetSessionsGeneration after committing it, otherwise the generation could be cached again before
+// the new one is visible.
+func RevokeSessions(ctx context.Context, userID int64) error {
+	inTx := db.InTransaction(ctx)
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		generation, err := user_model.GetUserSetting(ctx, userID, user_model.SettingsKeySessionsGeneration, "0")
+		if err != nil {
+			return err
+		}
+		next, _ := strconv.ParseInt(generation, 10, 64)
+		return user_model.SetUserSetting(ctx, userID, user_model.SettingsKeySessionsGeneration, strconv.FormatInt(next+1, 10))
+	}); err != nil {
+		return err
+	}
+	if !inTx {
+		ForgetSessionsGeneration(userID)
+	}
+	return nil
+}
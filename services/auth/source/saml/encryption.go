@@ -0,0 +1,155 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+const (
+	nsXMLEnc   = "http://www.w3.org/2001/04/xmlenc#"
+	nsXMLEnc11 = "http://www.w3.org/2009/xmlenc11#"
+
+	algAES128CBC   = "http://www.w3.org/2001/04/xmlenc#aes128-cbc"
+	algAES192CBC   = "http://www.w3.org/2001/04/xmlenc#aes192-cbc"
+	algAES256CBC   = "http://www.w3.org/2001/04/xmlenc#aes256-cbc"
+	algAES128GCM   = "http://www.w3.org/2009/xmlenc11#aes128-gcm"
+	algAES192GCM   = "http://www.w3.org/2009/xmlenc11#aes192-gcm"
+	algAES256GCM   = "http://www.w3.org/2009/xmlenc11#aes256-gcm"
+	algRSAOAEPMGF1 = "http://www.w3.org/2001/04/xmlenc#rsa-oaep-mgf1p"
+	algRSAOAEP     = "http://www.w3.org/2009/xmlenc11#rsa-oaep"
+
+	algMGF1SHA1   = "http://www.w3.org/2009/xmlenc11#mgf1sha1"
+	algMGF1SHA256 = "http://www.w3.org/2009/xmlenc11#mgf1sha256"
+	algMGF1SHA512 = "http://www.w3.org/2009/xmlenc11#mgf1sha512"
+)
+
+var mgfAlgorithms = map[string]crypto.Hash{
+	algMGF1SHA1:   crypto.SHA1,
+	algMGF1SHA256: crypto.SHA256,
+	algMGF1SHA512: crypto.SHA512,
+}
+
+// decryptElement decrypts the EncryptedData of an encrypted element, like an EncryptedAssertion, into the XML of the
+// element. The key is encrypted by RSA-OAEP for the service provider, the key transport by RSA PKCS #1 v1.5 is not
+// supported as it is vulnerable. AES-CBC does not authenticate the encrypted data, so that its padding could be used
+// as an oracle to decrypt it: it is only accepted if the encrypted element is signed, which has been verified before.
+func decryptElement(encrypted *element, key *rsa.PrivateKey, signed bool) ([]byte, error) {
+	encryptedData := encrypted.child(nsXMLEnc, "EncryptedData")
+	if encryptedData == nil {
+		return nil, errors.New("no EncryptedData")
+	}
+
+	// the EncryptedKey is either in the KeyInfo of the EncryptedData or a sibling of it
+	var encryptedKey *element
+	if keyInfo := encryptedData.child(nsDSig, "KeyInfo"); keyInfo != nil {
+		encryptedKey = keyInfo.child(nsXMLEnc, "EncryptedKey")
+	}
+	if encryptedKey == nil {
+		encryptedKey = encrypted.child(nsXMLEnc, "EncryptedKey")
+	}
+	if encryptedKey == nil {
+		return nil, errors.New("no EncryptedKey")
+	}
+
+	sessionKey, err := decryptKey(encryptedKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting the key: %w", err)
+	}
+
+	method := encryptedData.child(nsXMLEnc, "EncryptionMethod")
+	if method == nil {
+		return nil, errors.New("no EncryptionMethod of the EncryptedData")
+	}
+	data, err := getCipherValue(encryptedData)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := method.attr("Algorithm")
+	if !signed && (algorithm == algAES128CBC || algorithm == algAES192CBC || algorithm == algAES256CBC) {
+		return nil, errors.New("data encrypted by AES-CBC must be signed")
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	switch algorithm {
+	case algAES128CBC, algAES192CBC, algAES256CBC:
+		if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+			return nil, errors.New("invalid length of the encrypted data")
+		}
+		plaintext := make([]byte, len(data)-aes.BlockSize)
+		cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plaintext, data[aes.BlockSize:])
+		// the last byte is the length of the padding
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, errors.New("invalid padding of the encrypted data")
+		}
+		return plaintext[:len(plaintext)-padding], nil
+	case algAES128GCM, algAES192GCM, algAES256GCM:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < gcm.NonceSize()+gcm.Overhead() {
+			return nil, errors.New("invalid length of the encrypted data")
+		}
+		return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	}
+	return nil, fmt.Errorf("unsupported encryption method %q", algorithm)
+}
+
+func decryptKey(encryptedKey *element, key *rsa.PrivateKey) ([]byte, error) {
+	method := encryptedKey.child(nsXMLEnc, "EncryptionMethod")
+	if method == nil {
+		return nil, errors.New("no EncryptionMethod of the EncryptedKey")
+	}
+	data, err := getCipherValue(encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &rsa.OAEPOptions{Hash: crypto.SHA1, MGFHash: crypto.SHA1}
+	if digestMethod := method.child(nsDSig, "DigestMethod"); digestMethod != nil {
+		hash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported digest method %q", digestMethod.attr("Algorithm"))
+		}
+		opts.Hash = hash
+	}
+	switch method.attr("Algorithm") {
+	case algRSAOAEPMGF1:
+	case algRSAOAEP:
+		if mgf := method.child(nsXMLEnc11, "MGF"); mgf != nil {
+			hash, ok := mgfAlgorithms[mgf.attr("Algorithm")]
+			if !ok {
+				return nil, fmt.Errorf("unsupported mask generation function %q", mgf.attr("Algorithm"))
+			}
+			opts.MGFHash = hash
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key transport %q", method.attr("Algorithm"))
+	}
+	return key.Decrypt(rand.Reader, data, opts)
+}
+
+func getCipherValue(el *element) ([]byte, error) {
+	cipherData := el.child(nsXMLEnc, "CipherData")
+	if cipherData == nil {
+		return nil, errors.New("no CipherData")
+	}
+	cipherValue := cipherData.child(nsXMLEnc, "CipherValue")
+	if cipherValue == nil {
+		return nil, errors.New("no CipherValue")
+	}
+	return decodeBase64(cipherValue.text())
+}
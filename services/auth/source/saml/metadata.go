@@ -0,0 +1,240 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	nsMetadata = "urn:oasis:names:tc:SAML:2.0:metadata"
+	nsProtocol = "urn:oasis:names:tc:SAML:2.0:protocol"
+
+	// BindingHTTPRedirect sends the message in the query of a redirect
+	BindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	// BindingHTTPPost sends the message in a form posted by the browser
+	BindingHTTPPost = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	// NameIDFormatUnspecified lets the identity provider choose the format of the name ID
+	NameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	// NameIDFormatPersistent is an opaque identifier which never changes
+	NameIDFormatPersistent = "urn:oasis:names:tc:SAML:2.0:nameid-format:persistent"
+	// NameIDFormatEmailAddress is the email address of the user
+	NameIDFormatEmailAddress = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+)
+
+// maxMetadataSize is the maximum size of the imported metadata of an identity provider
+const maxMetadataSize = 1 << 20
+
+// IdentityProvider is the part of the metadata of an identity provider which the service provider needs
+type IdentityProvider struct {
+	EntityID string
+	// SingleSignOnURL is where the AuthnRequest is sent by SingleSignOnBinding
+	SingleSignOnURL     string
+	SingleSignOnBinding string
+	// Certificates are the certificates which sign the responses and the assertions
+	Certificates []*x509.Certificate
+}
+
+// ParseIdentityProviderMetadata parses the metadata of an identity provider, which is either an EntityDescriptor or
+// the first EntityDescriptor of an EntitiesDescriptor which describes an identity provider
+func ParseIdentityProviderMetadata(data []byte) (*IdentityProvider, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata of the identity provider: %w", err)
+	}
+
+	var descriptors []*element
+	switch {
+	case root.is(nsMetadata, "EntityDescriptor"):
+		descriptors = []*element{root}
+	case root.is(nsMetadata, "EntitiesDescriptor"):
+		descriptors = root.childrenNamed(nsMetadata, "EntityDescriptor")
+	default:
+		return nil, errors.New("the metadata of the identity provider has no EntityDescriptor")
+	}
+
+	for _, descriptor := range descriptors {
+		idpDescriptor := descriptor.child(nsMetadata, "IDPSSODescriptor")
+		if idpDescriptor == nil {
+			continue
+		}
+		idp := &IdentityProvider{EntityID: descriptor.attr("entityID")}
+
+		// the redirect binding is preferred as it doesn't need a page which posts the request
+		for _, binding := range []string{BindingHTTPRedirect, BindingHTTPPost} {
+			for _, service := range idpDescriptor.childrenNamed(nsMetadata, "SingleSignOnService") {
+				if service.attr("Binding") == binding && idp.SingleSignOnURL == "" {
+					idp.SingleSignOnURL = service.attr("Location")
+					idp.SingleSignOnBinding = binding
+				}
+			}
+		}
+		if idp.SingleSignOnURL == "" {
+			return nil, errors.New("the identity provider has no single sign-on service with a supported binding")
+		}
+
+		for _, keyDescriptor := range idpDescriptor.childrenNamed(nsMetadata, "KeyDescriptor") {
+			if use := keyDescriptor.attr("use"); use != "" && use != "signing" {
+				continue
+			}
+			keyInfo := keyDescriptor.child(nsDSig, "KeyInfo")
+			if keyInfo == nil {
+				continue
+			}
+			for _, x509Data := range keyInfo.childrenNamed(nsDSig, "X509Data") {
+				for _, certElement := range x509Data.childrenNamed(nsDSig, "X509Certificate") {
+					der, err := decodeBase64(certElement.text())
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate of the identity provider: %w", err)
+					}
+					cert, err := x509.ParseCertificate(der)
+					if err != nil {
+						return nil, fmt.Errorf("invalid certificate of the identity provider: %w", err)
+					}
+					if !isSupportedPublicKey(cert.PublicKey) {
+						return nil, errors.New("the certificate of the identity provider has an unsupported public key")
+					}
+					idp.Certificates = append(idp.Certificates, cert)
+				}
+			}
+		}
+		if len(idp.Certificates) == 0 {
+			return nil, errors.New("the identity provider has no signing certificate")
+		}
+		return idp, nil
+	}
+	return nil, errors.New("the metadata has no identity provider")
+}
+
+// FetchIdentityProviderMetadata downloads the metadata of an identity provider
+func FetchIdentityProviderMetadata(ctx context.Context, metadataURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s of the metadata", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize+1))
+	if err != nil {
+		return nil, err
+	} else if len(data) > maxMetadataSize {
+		return nil, errors.New("the metadata is too large")
+	}
+	if _, err := ParseIdentityProviderMetadata(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// identityProvider parses the imported metadata of the identity provider of the source
+func (source *Source) identityProvider() (*IdentityProvider, error) {
+	return ParseIdentityProviderMetadata([]byte(source.IdentityProviderMetadata))
+}
+
+// Metadata generates the metadata of the service provider, its certificate is used to sign the requests and to
+// encrypt the assertions
+func (source *Source) Metadata() ([]byte, error) {
+	cert, _, err := source.keyPair()
+	if err != nil {
+		return nil, err
+	}
+	certificate := base64.StdEncoding.EncodeToString(cert.Raw)
+
+	descriptor := newElement(nil, "md", "EntityDescriptor", nsMetadata).
+		setAttr("entityID", source.EntityID()).
+		setAttr("validUntil", cert.NotAfter.UTC().Format(time.RFC3339))
+	spDescriptor := descriptor.addChild("md", "SPSSODescriptor", nsMetadata).
+		setAttr("AuthnRequestsSigned", strconv.FormatBool(source.SignRequests)).
+		setAttr("WantAssertionsSigned", "true").
+		setAttr("protocolSupportEnumeration", nsProtocol)
+	for _, use := range []string{"signing", "encryption"} {
+		keyDescriptor := spDescriptor.addChild("md", "KeyDescriptor", nsMetadata).setAttr("use", use)
+		keyDescriptor.addChild("ds", "KeyInfo", nsDSig).
+			addChild("ds", "X509Data", nsDSig).
+			addChild("ds", "X509Certificate", nsDSig).setText(certificate)
+		if use == "encryption" {
+			keyDescriptor.addChild("md", "EncryptionMethod", nsMetadata).setAttr("Algorithm", algAES256GCM)
+			keyDescriptor.addChild("md", "EncryptionMethod", nsMetadata).setAttr("Algorithm", algAES128CBC)
+			keyDescriptor.addChild("md", "EncryptionMethod", nsMetadata).setAttr("Algorithm", algRSAOAEPMGF1)
+		}
+	}
+	if source.NameIDFormat != "" {
+		spDescriptor.addChild("md", "NameIDFormat", nsMetadata).setText(source.NameIDFormat)
+	}
+	spDescriptor.addChild("md", "AssertionConsumerService", nsMetadata).
+		setAttr("Binding", BindingHTTPPost).
+		setAttr("Location", source.AssertionConsumerServiceURL()).
+		setAttr("index", "0").
+		setAttr("isDefault", "true")
+
+	return append([]byte(`<?xml version="1.0" encoding="UTF-8"?>`+"\n"), canonicalize(descriptor, nil, nil)...), nil
+}
+
+// GenerateServiceProviderKeyPair generates a self-signed certificate with an RSA key for a service provider, the
+// certificate and the key are PEM encoded
+func GenerateServiceProviderKeyPair(commonName string) (certPEM, keyPEM string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM, nil
+}
+
+// isSupportedPublicKey returns true if the signatures of the key can be verified
+func isSupportedPublicKey(key any) bool {
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return true
+	}
+	return false
+}
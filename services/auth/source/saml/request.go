@@ -0,0 +1,112 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+// AuthnRequest is an authentication request which is sent through the browser of the user to the identity provider
+type AuthnRequest struct {
+	// ID identifies the request, the response must be InResponseTo it
+	ID string
+	// Binding is either BindingHTTPRedirect, then URL is where to redirect the user, or BindingHTTPPost, then the
+	// SAMLRequest must be posted to URL
+	Binding     string
+	URL         string
+	SAMLRequest string
+}
+
+// NewAuthnRequest creates an authentication request for the identity provider of the source
+func (source *Source) NewAuthnRequest() (*AuthnRequest, error) {
+	idp, err := source.identityProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 20)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	// an ID must not start with a digit
+	id := "id-" + hex.EncodeToString(random)
+
+	request := newElement(nil, "samlp", "AuthnRequest", nsProtocol).
+		setAttr("ID", id).
+		setAttr("Version", "2.0").
+		setAttr("IssueInstant", time.Now().UTC().Format(time.RFC3339)).
+		setAttr("Destination", idp.SingleSignOnURL).
+		setAttr("AssertionConsumerServiceURL", source.AssertionConsumerServiceURL()).
+		setAttr("ProtocolBinding", BindingHTTPPost)
+	request.addChild("saml", "Issuer", nsAssertion).setText(source.EntityID())
+	nameIDPolicy := request.addChild("samlp", "NameIDPolicy", nsProtocol).setAttr("AllowCreate", "true")
+	if source.NameIDFormat != "" {
+		nameIDPolicy.setAttr("Format", source.NameIDFormat)
+	}
+
+	authnRequest := &AuthnRequest{ID: id, Binding: idp.SingleSignOnBinding}
+
+	if idp.SingleSignOnBinding == BindingHTTPPost {
+		if source.SignRequests {
+			cert, key, err := source.keyPair()
+			if err != nil {
+				return nil, err
+			}
+			if err := signElement(request, key, cert); err != nil {
+				return nil, err
+			}
+		}
+		authnRequest.URL = idp.SingleSignOnURL
+		authnRequest.SAMLRequest = base64.StdEncoding.EncodeToString(canonicalize(request, nil, nil))
+		return authnRequest, nil
+	}
+
+	// the redirect binding deflates the request and signs the query instead of the XML
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(canonicalize(request, nil, nil)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	authnRequest.SAMLRequest = base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	query := "SAMLRequest=" + url.QueryEscape(authnRequest.SAMLRequest)
+	if source.SignRequests {
+		_, key, err := source.keyPair()
+		if err != nil {
+			return nil, err
+		}
+		query += "&SigAlg=" + url.QueryEscape(algSignatureRSASHA256)
+		hashed := crypto.SHA256.New()
+		hashed.Write([]byte(query))
+		sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(sig))
+	}
+
+	separator := "?"
+	if strings.Contains(idp.SingleSignOnURL, "?") {
+		separator = "&"
+	}
+	authnRequest.URL = idp.SingleSignOnURL + separator + query
+	return authnRequest, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markbates/goth"
+)
+
+const (
+	statusSuccess             = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	subjectConfirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	// clockSkew is the tolerated difference between the clocks of the identity provider and of the service provider
+	clockSkew = 3 * time.Minute
+)
+
+// ErrResponseStatus is returned if the identity provider responded with an error
+type ErrResponseStatus struct {
+	Code    string
+	Message string
+}
+
+// IsErrResponseStatus checks if an error is a ErrResponseStatus
+func IsErrResponseStatus(err error) bool {
+	return errors.As(err, &ErrResponseStatus{})
+}
+
+func (err ErrResponseStatus) Error() string {
+	return fmt.Sprintf("the identity provider responded with status %s: %s", err.Code, err.Message)
+}
+
+// Assertion is the validated content of an assertion of the identity provider about the user
+type Assertion struct {
+	// InResponseTo is the ID of the AuthnRequest, the caller must check that it has been issued for the user
+	InResponseTo string
+	NameID       string
+	NameIDFormat string
+	SessionIndex string
+	// Attributes are the values of the attributes by their Name and by their FriendlyName
+	Attributes map[string][]string
+}
+
+// ParseResponse decodes the SAMLResponse which is posted to the assertion consumer service and validates it and its
+// assertion. The response or the assertion must be signed by the identity provider, an encrypted assertion is
+// decrypted by the key of the service provider. Unsolicited responses are rejected.
+func (source *Source) ParseResponse(samlResponse string, now time.Time) (*Assertion, error) {
+	idp, err := source.identityProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAMLResponse: %w", err)
+	}
+	response, err := parseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAMLResponse: %w", err)
+	}
+
+	if !response.is(nsProtocol, "Response") {
+		return nil, errors.New("the SAMLResponse is not a Response")
+	}
+	if response.attr("Version") != "2.0" {
+		return nil, errors.New("unsupported version of the response")
+	}
+	if destination := response.attr("Destination"); destination != "" && destination != source.AssertionConsumerServiceURL() {
+		return nil, fmt.Errorf("the response is destined to %q", destination)
+	}
+	inResponseTo := response.attr("InResponseTo")
+	if inResponseTo == "" {
+		return nil, errors.New("unsolicited responses are not supported")
+	}
+	if issuer := response.child(nsAssertion, "Issuer"); issuer != nil && issuer.text() != idp.EntityID {
+		return nil, fmt.Errorf("the response is issued by %q", issuer.text())
+	}
+
+	status := response.child(nsProtocol, "Status")
+	if status == nil {
+		return nil, errors.New("the response has no status")
+	}
+	if statusCode := status.child(nsProtocol, "StatusCode"); statusCode == nil || statusCode.attr("Value") != statusSuccess {
+		statusErr := ErrResponseStatus{}
+		if statusCode != nil {
+			statusErr.Code = statusCode.attr("Value")
+			// the second-level status code is more specific, like AuthnFailed
+			if subCode := statusCode.child(nsProtocol, "StatusCode"); subCode != nil {
+				statusErr.Code = subCode.attr("Value")
+			}
+		}
+		if message := status.child(nsProtocol, "StatusMessage"); message != nil {
+			statusErr.Message = message.text()
+		}
+		return nil, statusErr
+	}
+
+	responseSigned := true
+	if err := verifySignature(response, idp.Certificates); errors.Is(err, ErrMissingSignature) {
+		responseSigned = false
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid signature of the response: %w", err)
+	}
+
+	assertions := response.childrenNamed(nsAssertion, "Assertion")
+	encryptedAssertions := response.childrenNamed(nsAssertion, "EncryptedAssertion")
+	if len(assertions)+len(encryptedAssertions) != 1 {
+		return nil, errors.New("the response must have exactly one assertion")
+	}
+
+	var assertion *element
+	if len(assertions) == 1 {
+		assertion = assertions[0]
+	} else {
+		_, key, err := source.keyPair()
+		if err != nil {
+			return nil, err
+		}
+		decrypted, err := decryptElement(encryptedAssertions[0], key, responseSigned)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting the assertion: %w", err)
+		}
+		assertion, err = parseXML(decrypted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decrypted assertion: %w", err)
+		}
+		// the decrypted assertion may use the namespaces which are declared by the response
+		assertion.parent = encryptedAssertions[0]
+		if !assertion.is(nsAssertion, "Assertion") {
+			return nil, errors.New("the decrypted element is not an Assertion")
+		}
+	}
+
+	if err := verifySignature(assertion, idp.Certificates); errors.Is(err, ErrMissingSignature) {
+		if !responseSigned {
+			return nil, errors.New("neither the response nor the assertion is signed")
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid signature of the assertion: %w", err)
+	}
+
+	return source.parseAssertion(assertion, idp, inResponseTo, now)
+}
+
+func (source *Source) parseAssertion(assertion *element, idp *IdentityProvider, inResponseTo string, now time.Time) (*Assertion, error) {
+	if assertion.attr("Version") != "2.0" {
+		return nil, errors.New("unsupported version of the assertion")
+	}
+	if issuer := assertion.child(nsAssertion, "Issuer"); issuer == nil || issuer.text() != idp.EntityID {
+		return nil, errors.New("the assertion is not issued by the identity provider")
+	}
+
+	if conditions := assertion.child(nsAssertion, "Conditions"); conditions != nil {
+		if err := checkTimeRange(conditions, now); err != nil {
+			return nil, fmt.Errorf("the conditions of the assertion are not met: %w", err)
+		}
+		for _, restriction := range conditions.childrenNamed(nsAssertion, "AudienceRestriction") {
+			found := false
+			for _, audience := range restriction.childrenNamed(nsAssertion, "Audience") {
+				if audience.text() == source.EntityID() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, errors.New("the assertion is not intended for this service provider")
+			}
+		}
+	}
+
+	subject := assertion.child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, errors.New("the assertion has no subject")
+	}
+	nameID := subject.child(nsAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.New("the subject has no NameID")
+	}
+
+	confirmed := false
+	for _, confirmation := range subject.childrenNamed(nsAssertion, "SubjectConfirmation") {
+		if confirmation.attr("Method") != subjectConfirmationBearer {
+			continue
+		}
+		data := confirmation.child(nsAssertion, "SubjectConfirmationData")
+		if data == nil || data.attr("NotOnOrAfter") == "" ||
+			data.attr("Recipient") != source.AssertionConsumerServiceURL() ||
+			data.attr("InResponseTo") != inResponseTo {
+			continue
+		}
+		if checkTimeRange(data, now) == nil {
+			confirmed = true
+			break
+		}
+	}
+	if !confirmed {
+		return nil, errors.New("the subject has no valid bearer confirmation")
+	}
+
+	result := &Assertion{
+		InResponseTo: inResponseTo,
+		NameID:       nameID.text(),
+		NameIDFormat: nameID.attr("Format"),
+		Attributes:   map[string][]string{},
+	}
+	if authnStatement := assertion.child(nsAssertion, "AuthnStatement"); authnStatement != nil {
+		result.SessionIndex = authnStatement.attr("SessionIndex")
+	}
+	for _, statement := range assertion.childrenNamed(nsAssertion, "AttributeStatement") {
+		for _, attribute := range statement.childrenNamed(nsAssertion, "Attribute") {
+			var values []string
+			for _, value := range attribute.childrenNamed(nsAssertion, "AttributeValue") {
+				values = append(values, value.text())
+			}
+			for _, name := range []string{attribute.attr("Name"), attribute.attr("FriendlyName")} {
+				if name != "" {
+					result.Attributes[name] = append(result.Attributes[name], values...)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// checkTimeRange checks the NotBefore and NotOnOrAfter attributes of the element
+func checkTimeRange(el *element, now time.Time) error {
+	if notBefore := el.attr("NotBefore"); notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return fmt.Errorf("invalid NotBefore: %w", err)
+		}
+		if now.Add(clockSkew).Before(t) {
+			return errors.New("not yet valid")
+		}
+	}
+	if notOnOrAfter := el.attr("NotOnOrAfter"); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil {
+			return fmt.Errorf("invalid NotOnOrAfter: %w", err)
+		}
+		if !now.Add(-clockSkew).Before(t) {
+			return errors.New("expired")
+		}
+	}
+	return nil
+}
+
+// attribute returns the first value of the attribute
+func (assertion *Assertion) attribute(name string) string {
+	if values := assertion.Attributes[name]; name != "" && len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// GothUser maps the assertion to the user of the account linking, which is shared with the OAuth2 sources. The
+// NameID identifies the user and the attributes of the source provide the username, the email, the full name and
+// the groups.
+func (source *Source) GothUser(assertion *Assertion) goth.User {
+	rawData := make(map[string]any, len(assertion.Attributes))
+	for name, values := range assertion.Attributes {
+		rawData[name] = values
+	}
+
+	email := assertion.attribute(source.EmailAttribute)
+	if email == "" && assertion.NameIDFormat == NameIDFormatEmailAddress {
+		email = assertion.NameID
+	}
+
+	return goth.User{
+		Provider: source.authSource.Name,
+		UserID:   assertion.NameID,
+		NickName: assertion.attribute(source.UsernameAttribute),
+		Email:    email,
+		Name:     assertion.attribute(source.FullNameAttribute),
+		RawData:  rawData,
+	}
+}
@@ -0,0 +1,499 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIdPEntityID = "https://idp.example.com/metadata"
+
+type testIdentityProvider struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestKeyPair(t testing.TB) (*rsa.PrivateKey, *x509.Certificate, string, string) {
+	certPEM, keyPEM, err := GenerateServiceProviderKeyPair("test")
+	require.NoError(t, err)
+	block, _ := pem.Decode([]byte(certPEM))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	block, _ = pem.Decode([]byte(keyPEM))
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	require.NoError(t, err)
+	return key.(*rsa.PrivateKey), cert, certPEM, keyPEM
+}
+
+func newTestSource(t testing.TB, binding string) (*Source, *testIdentityProvider) {
+	t.Cleanup(test.MockVariableValue(&setting.AppURL, "https://forgejo.example.com/"))
+
+	idpKey, idpCert, _, _ := newTestKeyPair(t)
+	_, _, spCert, spKey := newTestKeyPair(t)
+
+	metadata := `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + testIdPEntityID + `">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data>
+          <ds:X509Certificate>` + base64.StdEncoding.EncodeToString(idpCert.Raw) + `</ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="` + binding + `" Location="https://idp.example.com/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+
+	source := &Source{
+		IdentityProviderMetadata:   metadata,
+		ServiceProviderCertificate: spCert,
+		ServiceProviderPrivateKey:  spKey,
+		NameIDFormat:               NameIDFormatPersistent,
+		UsernameAttribute:          "uid",
+		EmailAttribute:             "mail",
+		FullNameAttribute:          "displayName",
+		GroupsAttribute:            "groups",
+	}
+	source.SetAuthSource(&auth.Source{Name: "test-saml", Type: auth.SAML})
+	return source, &testIdentityProvider{key: idpKey, cert: idpCert}
+}
+
+type testResponseOptions struct {
+	signResponse, signAssertion, encrypt bool
+	// cbc encrypts the assertion by AES-CBC instead of AES-GCM
+	cbc                 bool
+	audience, recipient string
+	notOnOrAfter        time.Time
+	modify              func(response *element)
+}
+
+// response builds a SAMLResponse of the identity provider
+func (idp *testIdentityProvider) response(t testing.TB, source *Source, opts testResponseOptions) string {
+	now := time.Now().UTC()
+	if opts.audience == "" {
+		opts.audience = source.EntityID()
+	}
+	if opts.recipient == "" {
+		opts.recipient = source.AssertionConsumerServiceURL()
+	}
+	if opts.notOnOrAfter.IsZero() {
+		opts.notOnOrAfter = now.Add(5 * time.Minute)
+	}
+
+	response := newElement(nil, "samlp", "Response", nsProtocol).
+		setAttr("ID", "_response").
+		setAttr("Version", "2.0").
+		setAttr("IssueInstant", now.Format(time.RFC3339)).
+		setAttr("Destination", source.AssertionConsumerServiceURL()).
+		setAttr("InResponseTo", "id-request")
+	response.Namespaces = append(response.Namespaces, newElement(nil, "saml", "Assertion", nsAssertion).Namespaces...)
+	response.addChild("saml", "Issuer", nsAssertion).setText(testIdPEntityID)
+	response.addChild("samlp", "Status", nsProtocol).
+		addChild("samlp", "StatusCode", nsProtocol).setAttr("Value", statusSuccess)
+
+	assertion := newElement(nil, "saml", "Assertion", nsAssertion).
+		setAttr("ID", "_assertion").
+		setAttr("Version", "2.0").
+		setAttr("IssueInstant", now.Format(time.RFC3339))
+	assertion.addChild("saml", "Issuer", nsAssertion).setText(testIdPEntityID)
+	subject := assertion.addChild("saml", "Subject", nsAssertion)
+	subject.addChild("saml", "NameID", nsAssertion).setAttr("Format", NameIDFormatPersistent).setText("user-1234")
+	subject.addChild("saml", "SubjectConfirmation", nsAssertion).
+		setAttr("Method", subjectConfirmationBearer).
+		addChild("saml", "SubjectConfirmationData", nsAssertion).
+		setAttr("InResponseTo", "id-request").
+		setAttr("NotOnOrAfter", opts.notOnOrAfter.Format(time.RFC3339)).
+		setAttr("Recipient", opts.recipient)
+	conditions := assertion.addChild("saml", "Conditions", nsAssertion).
+		setAttr("NotBefore", now.Add(-time.Minute).Format(time.RFC3339)).
+		setAttr("NotOnOrAfter", opts.notOnOrAfter.Format(time.RFC3339))
+	conditions.addChild("saml", "AudienceRestriction", nsAssertion).
+		addChild("saml", "Audience", nsAssertion).setText(opts.audience)
+	assertion.addChild("saml", "AuthnStatement", nsAssertion).
+		setAttr("AuthnInstant", now.Format(time.RFC3339)).
+		setAttr("SessionIndex", "_session")
+	attributes := assertion.addChild("saml", "AttributeStatement", nsAssertion)
+	for _, attribute := range []struct {
+		name, friendlyName string
+		values             []string
+	}{
+		{"urn:oid:0.9.2342.19200300.100.1.1", "uid", []string{"alice"}},
+		{"urn:oid:0.9.2342.19200300.100.1.3", "mail", []string{"alice@example.com"}},
+		{"displayName", "", []string{"Alice Liddell"}},
+		{"groups", "", []string{"admins", "developers"}},
+	} {
+		attr := attributes.addChild("saml", "Attribute", nsAssertion).setAttr("Name", attribute.name)
+		if attribute.friendlyName != "" {
+			attr.setAttr("FriendlyName", attribute.friendlyName)
+		}
+		for _, value := range attribute.values {
+			attr.addChild("saml", "AttributeValue", nsAssertion).setText(value)
+		}
+	}
+
+	if opts.signAssertion {
+		require.NoError(t, signElement(assertion, idp.key, idp.cert))
+	}
+	if opts.encrypt {
+		spCert, _, err := source.keyPair()
+		require.NoError(t, err)
+		encryptedAssertion := response.addChild("saml", "EncryptedAssertion", nsAssertion)
+		encryptAssertion(t, encryptedAssertion, canonicalize(assertion, nil, nil), spCert, opts.cbc)
+	} else {
+		assertion.parent = response
+		response.Children = append(response.Children, assertion)
+	}
+	if opts.modify != nil {
+		opts.modify(response)
+	}
+	if opts.signResponse {
+		require.NoError(t, signElement(response, idp.key, idp.cert))
+	}
+	return base64.StdEncoding.EncodeToString(canonicalize(response, nil, nil))
+}
+
+// encryptAssertion encrypts the assertion by AES-256-GCM, or AES-256-CBC, with a key transported by RSA-OAEP
+func encryptAssertion(t testing.TB, encryptedAssertion *element, assertion []byte, cert *x509.Certificate, cbc bool) {
+	sessionKey := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, sessionKey)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(sessionKey)
+	require.NoError(t, err)
+
+	algorithm := algAES256GCM
+	var data []byte
+	if cbc {
+		algorithm = algAES256CBC
+		padding := aes.BlockSize - len(assertion)%aes.BlockSize
+		plaintext := append(bytes.Clone(assertion), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		data = make([]byte, aes.BlockSize+len(plaintext))
+		_, err = io.ReadFull(rand.Reader, data[:aes.BlockSize])
+		require.NoError(t, err)
+		cipher.NewCBCEncrypter(block, data[:aes.BlockSize]).CryptBlocks(data[aes.BlockSize:], plaintext)
+	} else {
+		gcm, err := cipher.NewGCM(block)
+		require.NoError(t, err)
+		nonce := make([]byte, gcm.NonceSize())
+		_, err = io.ReadFull(rand.Reader, nonce)
+		require.NoError(t, err)
+		data = gcm.Seal(nonce, nonce, assertion, nil)
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, cert.PublicKey.(*rsa.PublicKey), sessionKey, nil)
+	require.NoError(t, err)
+
+	encryptedData := encryptedAssertion.addChild("xenc", "EncryptedData", nsXMLEnc).setAttr("Type", nsXMLEnc+"Element")
+	encryptedData.addChild("xenc", "EncryptionMethod", nsXMLEnc).setAttr("Algorithm", algorithm)
+	keyInfo := encryptedData.addChild("ds", "KeyInfo", nsDSig)
+	key := keyInfo.addChild("xenc", "EncryptedKey", nsXMLEnc)
+	key.addChild("xenc", "EncryptionMethod", nsXMLEnc).setAttr("Algorithm", algRSAOAEPMGF1).
+		addChild("ds", "DigestMethod", nsDSig).setAttr("Algorithm", algDigestSHA1)
+	key.addChild("xenc", "CipherData", nsXMLEnc).addChild("xenc", "CipherValue", nsXMLEnc).
+		setText(base64.StdEncoding.EncodeToString(encryptedKey))
+	encryptedData.addChild("xenc", "CipherData", nsXMLEnc).addChild("xenc", "CipherValue", nsXMLEnc).
+		setText(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestParseResponse(t *testing.T) {
+	source, idp := newTestSource(t, BindingHTTPRedirect)
+	now := time.Now()
+
+	expected := &Assertion{
+		InResponseTo: "id-request",
+		NameID:       "user-1234",
+		NameIDFormat: NameIDFormatPersistent,
+		SessionIndex: "_session",
+		Attributes: map[string][]string{
+			"urn:oid:0.9.2342.19200300.100.1.1": {"alice"},
+			"uid":                               {"alice"},
+			"urn:oid:0.9.2342.19200300.100.1.3": {"alice@example.com"},
+			"mail":                              {"alice@example.com"},
+			"displayName":                       {"Alice Liddell"},
+			"groups":                            {"admins", "developers"},
+		},
+	}
+
+	for name, opts := range map[string]testResponseOptions{
+		"SignedResponse":             {signResponse: true},
+		"SignedAssertion":            {signAssertion: true},
+		"SignedResponseAndAssertion": {signResponse: true, signAssertion: true},
+		"EncryptedSignedAssertion":   {signAssertion: true, encrypt: true},
+		"SignedResponseEncrypted":    {signResponse: true, encrypt: true},
+		"SignedResponseEncryptedCBC": {signResponse: true, encrypt: true, cbc: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assertion, err := source.ParseResponse(idp.response(t, source, opts), now)
+			require.NoError(t, err)
+			assert.Equal(t, expected, assertion)
+		})
+	}
+
+	t.Run("GothUser", func(t *testing.T) {
+		assertion, err := source.ParseResponse(idp.response(t, source, testResponseOptions{signAssertion: true}), now)
+		require.NoError(t, err)
+		gothUser := source.GothUser(assertion)
+		assert.Equal(t, "test-saml", gothUser.Provider)
+		assert.Equal(t, "user-1234", gothUser.UserID)
+		assert.Equal(t, "alice", gothUser.NickName)
+		assert.Equal(t, "alice@example.com", gothUser.Email)
+		assert.Equal(t, "Alice Liddell", gothUser.Name)
+		assert.Equal(t, []string{"admins", "developers"}, gothUser.RawData["groups"])
+	})
+
+	for name, opts := range map[string]testResponseOptions{
+		"Unsigned":          {},
+		"UnsignedEncrypted": {encrypt: true},
+		// the padding of AES-CBC could be used as an oracle before the signature of the assertion is verified
+		"UnsignedResponseEncryptedCBC": {signAssertion: true, encrypt: true, cbc: true},
+		"Audience":                     {signResponse: true, audience: "https://other.example.com/metadata"},
+		"Recipient":                    {signResponse: true, recipient: "https://other.example.com/acs"},
+		"Expired":                      {signResponse: true, notOnOrAfter: now.Add(-time.Hour)},
+		"TamperedAssertion": {
+			signAssertion: true,
+			modify: func(response *element) {
+				nameID := response.child(nsAssertion, "Assertion").child(nsAssertion, "Subject").child(nsAssertion, "NameID")
+				nameID.setText("admin")
+			},
+		},
+		"WrappedAssertion": {
+			signResponse: false,
+			modify: func(response *element) {
+				// a signed assertion is moved into an unsigned one with the same ID
+				signed := response.child(nsAssertion, "Assertion")
+				require.NoError(t, signElement(signed, idp.key, idp.cert))
+				forged := newElement(response, "saml", "Assertion", nsAssertion)
+				*forged = *signed
+				forged.Children = nil
+				forged.Children = append(forged.Children, signed.Children...)
+				forged.removeChild(forged.child(nsDSig, "Signature"))
+				forged.child(nsAssertion, "Subject").child(nsAssertion, "NameID").setText("admin")
+				response.removeChild(signed)
+				signed.parent = forged
+				forged.Children = append(forged.Children, signed)
+			},
+		},
+		"DuplicatedAssertion": {
+			modify: func(response *element) {
+				// an unsigned assertion is added next to the signed one
+				signed := response.child(nsAssertion, "Assertion")
+				require.NoError(t, signElement(signed, idp.key, idp.cert))
+				forged := newElement(response, "saml", "Assertion", nsAssertion)
+				forged.Attrs = signed.Attrs
+				for _, child := range signed.childElements() {
+					if !child.is(nsDSig, "Signature") {
+						forged.Children = append(forged.Children, child)
+					}
+				}
+			},
+		},
+		"AssertionInExtensions": {
+			modify: func(response *element) {
+				// the signed assertion is hidden in the extensions and replaced by an unsigned one with the same ID
+				signed := response.child(nsAssertion, "Assertion")
+				require.NoError(t, signElement(signed, idp.key, idp.cert))
+				response.removeChild(signed)
+				extensions := response.addChild("samlp", "Extensions", nsProtocol)
+				extensions.Children = append(extensions.Children, signed)
+				forged := newElement(response, "saml", "Assertion", nsAssertion)
+				forged.Attrs = signed.Attrs
+				forged.Children = signed.Children[:0:0]
+				for _, child := range signed.childElements() {
+					if !child.is(nsDSig, "Signature") {
+						forged.Children = append(forged.Children, child)
+					}
+				}
+			},
+		},
+		"SignatureOfOtherElement": {
+			modify: func(response *element) {
+				// the signature of the assertion references another element
+				signed := response.child(nsAssertion, "Assertion")
+				require.NoError(t, signElement(signed, idp.key, idp.cert))
+				signed.setAttr("ID", "_other")
+			},
+		},
+		"SignatureNotEnveloped": {
+			modify: func(response *element) {
+				// the signature of the assertion is moved into its subject
+				signed := response.child(nsAssertion, "Assertion")
+				require.NoError(t, signElement(signed, idp.key, idp.cert))
+				signature := signed.child(nsDSig, "Signature")
+				signed.removeChild(signature)
+				subject := signed.child(nsAssertion, "Subject")
+				signature.parent = subject
+				subject.Children = append(subject.Children, signature)
+			},
+		},
+		"Status": {
+			signResponse: true,
+			modify: func(response *element) {
+				response.child(nsProtocol, "Status").child(nsProtocol, "StatusCode").
+					setAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Responder")
+			},
+		},
+	} {
+		t.Run("Invalid"+name, func(t *testing.T) {
+			_, err := source.ParseResponse(idp.response(t, source, opts), now)
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("OtherIdentityProvider", func(t *testing.T) {
+		_, other := newTestSource(t, BindingHTTPRedirect)
+		_, err := source.ParseResponse(other.response(t, source, testResponseOptions{signResponse: true}), now)
+		require.Error(t, err)
+	})
+
+	t.Run("ErrResponseStatus", func(t *testing.T) {
+		_, err := source.ParseResponse(idp.response(t, source, testResponseOptions{
+			modify: func(response *element) {
+				response.child(nsProtocol, "Status").child(nsProtocol, "StatusCode").
+					setAttr("Value", "urn:oasis:names:tc:SAML:2.0:status:Requester")
+			},
+		}), now)
+		assert.True(t, IsErrResponseStatus(err))
+	})
+}
+
+// FuzzParseResponse checks that no change of a signed response is accepted, except of its insignificant parts. The
+// responses are signed by the keys of the fuzzing process, so the fuzzer replaces a part of one of them.
+func FuzzParseResponse(f *testing.F) {
+	source, idp := newTestSource(f, BindingHTTPRedirect)
+	now := time.Now()
+	var responses [][]byte
+	for _, opts := range []testResponseOptions{
+		{signResponse: true},
+		{signAssertion: true},
+		{signAssertion: true, encrypt: true},
+		{signResponse: true, encrypt: true, cbc: true},
+	} {
+		data, err := base64.StdEncoding.DecodeString(idp.response(f, source, opts))
+		require.NoError(f, err)
+		responses = append(responses, data)
+	}
+	for index := range responses {
+		f.Add(uint8(index), uint16(0), uint8(0), []byte{})
+	}
+	f.Add(uint8(1), uint16(0), uint8(0), []byte(`<!-- -->`))
+	f.Add(uint8(1), uint16(1000), uint8(9), []byte(`user-1234`))
+
+	f.Fuzz(func(t *testing.T, index uint8, offset uint16, length uint8, replacement []byte) {
+		data := responses[int(index)%len(responses)]
+		start := min(int(offset), len(data))
+		end := min(start+int(length), len(data))
+		mutated := append(append(bytes.Clone(data[:start]), replacement...), data[end:]...)
+
+		assertion, err := source.ParseResponse(base64.StdEncoding.EncodeToString(mutated), now)
+		if err != nil {
+			return
+		}
+		assert.Equal(t, "user-1234", assertion.NameID)
+		assert.Equal(t, []string{"alice"}, assertion.Attributes["uid"])
+		assert.Equal(t, []string{"alice@example.com"}, assertion.Attributes["mail"])
+		assert.Equal(t, []string{"admins", "developers"}, assertion.Attributes["groups"])
+	})
+}
+
+func TestMetadata(t *testing.T) {
+	source, _ := newTestSource(t, BindingHTTPPost)
+
+	data, err := source.Metadata()
+	require.NoError(t, err)
+	metadata, err := parseXML(data)
+	require.NoError(t, err)
+	assert.True(t, metadata.is(nsMetadata, "EntityDescriptor"))
+	assert.Equal(t, "https://forgejo.example.com/user/saml/test-saml/metadata", metadata.attr("entityID"))
+	spDescriptor := metadata.child(nsMetadata, "SPSSODescriptor")
+	require.NotNil(t, spDescriptor)
+	assert.Len(t, spDescriptor.childrenNamed(nsMetadata, "KeyDescriptor"), 2)
+	assert.Equal(t, NameIDFormatPersistent, spDescriptor.child(nsMetadata, "NameIDFormat").text())
+	assert.Equal(t, "https://forgejo.example.com/user/saml/test-saml/acs",
+		spDescriptor.child(nsMetadata, "AssertionConsumerService").attr("Location"))
+
+	idp, err := source.identityProvider()
+	require.NoError(t, err)
+	assert.Equal(t, testIdPEntityID, idp.EntityID)
+	assert.Equal(t, BindingHTTPPost, idp.SingleSignOnBinding)
+	assert.Equal(t, "https://idp.example.com/sso", idp.SingleSignOnURL)
+	assert.Len(t, idp.Certificates, 1)
+
+	_, err = ParseIdentityProviderMetadata(data)
+	require.Error(t, err, "the metadata of a service provider has no identity provider")
+}
+
+func TestNewAuthnRequest(t *testing.T) {
+	t.Run("Redirect", func(t *testing.T) {
+		source, _ := newTestSource(t, BindingHTTPRedirect)
+		source.SignRequests = true
+
+		request, err := source.NewAuthnRequest()
+		require.NoError(t, err)
+		assert.Equal(t, BindingHTTPRedirect, request.Binding)
+
+		u, err := url.Parse(request.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "idp.example.com", u.Host)
+		assert.Equal(t, algSignatureRSASHA256, u.Query().Get("SigAlg"))
+
+		// the signature covers the query in the order of the parameters
+		cert, _, err := source.keyPair()
+		require.NoError(t, err)
+		signed := "SAMLRequest=" + url.QueryEscape(u.Query().Get("SAMLRequest")) + "&SigAlg=" + url.QueryEscape(algSignatureRSASHA256)
+		sig, err := base64.StdEncoding.DecodeString(u.Query().Get("Signature"))
+		require.NoError(t, err)
+		hashed := crypto.SHA256.New()
+		hashed.Write([]byte(signed))
+		require.NoError(t, rsa.VerifyPKCS1v15(cert.PublicKey.(*rsa.PublicKey), crypto.SHA256, hashed.Sum(nil), sig))
+
+		deflated, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+		require.NoError(t, err)
+		inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+		require.NoError(t, err)
+		authnRequest, err := parseXML(inflated)
+		require.NoError(t, err)
+		assert.True(t, authnRequest.is(nsProtocol, "AuthnRequest"))
+		assert.Equal(t, request.ID, authnRequest.attr("ID"))
+		assert.Equal(t, source.AssertionConsumerServiceURL(), authnRequest.attr("AssertionConsumerServiceURL"))
+		assert.Equal(t, source.EntityID(), authnRequest.child(nsAssertion, "Issuer").text())
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		source, _ := newTestSource(t, BindingHTTPPost)
+		source.SignRequests = true
+
+		request, err := source.NewAuthnRequest()
+		require.NoError(t, err)
+		assert.Equal(t, BindingHTTPPost, request.Binding)
+		assert.Equal(t, "https://idp.example.com/sso", request.URL)
+
+		data, err := base64.StdEncoding.DecodeString(request.SAMLRequest)
+		require.NoError(t, err)
+		authnRequest, err := parseXML(data)
+		require.NoError(t, err)
+		cert, _, err := source.keyPair()
+		require.NoError(t, err)
+		require.NoError(t, verifySignature(authnRequest, []*x509.Certificate{cert}))
+	})
+}
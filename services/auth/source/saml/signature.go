@@ -0,0 +1,222 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	// the digests of the signatures
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	nsDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	algExcC14N              = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnvelopedSignature   = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algDigestSHA1           = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algDigestSHA256         = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algDigestSHA512         = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algSignatureRSASHA1     = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algSignatureRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algSignatureRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algSignatureECDSASHA1   = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha1"
+	algSignatureECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	algSignatureECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+)
+
+var digestAlgorithms = map[string]crypto.Hash{
+	algDigestSHA1:   crypto.SHA1,
+	algDigestSHA256: crypto.SHA256,
+	algDigestSHA512: crypto.SHA512,
+}
+
+var signatureAlgorithms = map[string]crypto.Hash{
+	algSignatureRSASHA1:     crypto.SHA1,
+	algSignatureRSASHA256:   crypto.SHA256,
+	algSignatureRSASHA512:   crypto.SHA512,
+	algSignatureECDSASHA1:   crypto.SHA1,
+	algSignatureECDSASHA256: crypto.SHA256,
+	algSignatureECDSASHA512: crypto.SHA512,
+}
+
+// ErrMissingSignature is returned if a signed element is expected but it has no signature
+var ErrMissingSignature = errors.New("the element is not signed")
+
+// verifySignature verifies the enveloped signature of the element by one of the certificates. The signature must be
+// a direct child of the element and reference the element by its ID, so the verified element is the one which is
+// used afterwards and no other element of the document with the same ID.
+func verifySignature(el *element, certs []*x509.Certificate) error {
+	signature := el.child(nsDSig, "Signature")
+	if signature == nil {
+		return ErrMissingSignature
+	}
+	signedInfo := signature.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("the signature has no SignedInfo")
+	}
+
+	c14nMethod := signedInfo.child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != algExcC14N {
+		return errors.New("unsupported canonicalization method of the signature")
+	}
+	signatureMethod := signedInfo.child(nsDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return errors.New("the signature has no SignatureMethod")
+	}
+	signatureHash, ok := signatureAlgorithms[signatureMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported signature method %q", signatureMethod.attr("Algorithm"))
+	}
+
+	references := signedInfo.childrenNamed(nsDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("the signature must have exactly one reference")
+	}
+	reference := references[0]
+	if id := el.attr("ID"); id == "" || reference.attr("URI") != "#"+id {
+		return errors.New("the signature does not reference the signed element")
+	}
+
+	enveloped := false
+	var inclusivePrefixes []string
+	if transforms := reference.child(nsDSig, "Transforms"); transforms != nil {
+		for _, transform := range transforms.childrenNamed(nsDSig, "Transform") {
+			switch transform.attr("Algorithm") {
+			case algEnvelopedSignature:
+				enveloped = true
+			case algExcC14N:
+				inclusivePrefixes = getInclusivePrefixes(transform)
+			default:
+				return fmt.Errorf("unsupported transform %q", transform.attr("Algorithm"))
+			}
+		}
+	}
+	if !enveloped {
+		return errors.New("the signature is not an enveloped signature")
+	}
+
+	digestMethod := reference.child(nsDSig, "DigestMethod")
+	digestValue := reference.child(nsDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return errors.New("the reference has no digest")
+	}
+	digestHash, ok := digestAlgorithms[digestMethod.attr("Algorithm")]
+	if !ok {
+		return fmt.Errorf("unsupported digest method %q", digestMethod.attr("Algorithm"))
+	}
+	expectedDigest, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid digest value: %w", err)
+	}
+	h := digestHash.New()
+	h.Write(canonicalize(el, signature, inclusivePrefixes))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expectedDigest) != 1 {
+		return errors.New("the digest of the signed element does not match")
+	}
+
+	signatureValue := signature.child(nsDSig, "SignatureValue")
+	if signatureValue == nil {
+		return errors.New("the signature has no SignatureValue")
+	}
+	sig, err := decodeBase64(signatureValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid signature value: %w", err)
+	}
+	h = signatureHash.New()
+	h.Write(canonicalize(signedInfo, nil, getInclusivePrefixes(c14nMethod)))
+	hashed := h.Sum(nil)
+
+	for _, cert := range certs {
+		if verifyHashSignature(cert.PublicKey, signatureHash, hashed, sig) == nil {
+			return nil
+		}
+	}
+	return errors.New("the signature is not valid for any certificate of the identity provider")
+}
+
+// getInclusivePrefixes returns the prefixes of the InclusiveNamespaces of an exclusive canonicalization
+func getInclusivePrefixes(method *element) []string {
+	if inclusive := method.child(algExcC14N, "InclusiveNamespaces"); inclusive != nil {
+		return strings.Fields(inclusive.attr("PrefixList"))
+	}
+	return nil
+}
+
+func verifyHashSignature(publicKey any, hash crypto.Hash, hashed, sig []byte) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, hash, hashed, sig)
+	case *ecdsa.PublicKey:
+		// the signature is the concatenation of r and s
+		if len(sig)%2 != 0 {
+			return errors.New("invalid ECDSA signature")
+		}
+		r := new(big.Int).SetBytes(sig[:len(sig)/2])
+		s := new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(key, hashed, r, s) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported public key")
+}
+
+// signElement adds an enveloped RSA-SHA256 signature to the element after its Issuer, the element must have an ID
+func signElement(el *element, key *rsa.PrivateKey, cert *x509.Certificate) error {
+	digest := crypto.SHA256.New()
+	digest.Write(canonicalize(el, nil, nil))
+
+	signature := newElement(nil, "ds", "Signature", nsDSig)
+	signature.parent = el
+	signedInfo := signature.addChild("ds", "SignedInfo", nsDSig)
+	signedInfo.addChild("ds", "CanonicalizationMethod", nsDSig).setAttr("Algorithm", algExcC14N)
+	signedInfo.addChild("ds", "SignatureMethod", nsDSig).setAttr("Algorithm", algSignatureRSASHA256)
+	reference := signedInfo.addChild("ds", "Reference", nsDSig).setAttr("URI", "#"+el.attr("ID"))
+	transforms := reference.addChild("ds", "Transforms", nsDSig)
+	transforms.addChild("ds", "Transform", nsDSig).setAttr("Algorithm", algEnvelopedSignature)
+	transforms.addChild("ds", "Transform", nsDSig).setAttr("Algorithm", algExcC14N)
+	reference.addChild("ds", "DigestMethod", nsDSig).setAttr("Algorithm", algDigestSHA256)
+	reference.addChild("ds", "DigestValue", nsDSig).setText(base64.StdEncoding.EncodeToString(digest.Sum(nil)))
+
+	// the signature is inserted after the Issuer, as required by the schemas of SAML
+	position := 0
+	for i, c := range el.Children {
+		if child, ok := c.(*element); ok && child.Local == "Issuer" {
+			position = i + 1
+			break
+		}
+	}
+	el.Children = append(el.Children[:position], append([]any{signature}, el.Children[position:]...)...)
+
+	hashed := crypto.SHA256.New()
+	hashed.Write(canonicalize(signedInfo, nil, nil))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed.Sum(nil))
+	if err != nil {
+		el.removeChild(signature)
+		return err
+	}
+	signature.addChild("ds", "SignatureValue", nsDSig).setText(base64.StdEncoding.EncodeToString(sig))
+	keyInfo := signature.addChild("ds", "KeyInfo", nsDSig)
+	keyInfo.addChild("ds", "X509Data", nsDSig).addChild("ds", "X509Certificate", nsDSig).
+		setText(base64.StdEncoding.EncodeToString(cert.Raw))
+	return nil
+}
+
+// decodeBase64 decodes base64 which may be wrapped over lines
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/url"
+
+	"code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Source holds configuration for the SAML login source.
+type Source struct {
+	// IdentityProviderMetadataURL is where the metadata of the identity provider is imported from
+	IdentityProviderMetadataURL string
+	// IdentityProviderMetadata is the XML of the metadata of the identity provider
+	IdentityProviderMetadata string
+
+	// ServiceProviderEntityID overrides the default entity ID, which is the URL of the metadata of the service provider
+	ServiceProviderEntityID    string `json:",omitempty"`
+	ServiceProviderCertificate string
+	ServiceProviderPrivateKey  string
+	SignRequests               bool
+	NameIDFormat               string
+
+	UsernameAttribute   string
+	EmailAttribute      string
+	FullNameAttribute   string
+	GroupsAttribute     string
+	AdminGroup          string
+	RestrictedGroup     string
+	GroupTeamMap        string
+	GroupTeamMapRemoval bool
+
+	IconURL        string
+	SkipLocalTwoFA bool `json:",omitempty"`
+
+	// reference to the authSource
+	authSource *auth.Source
+}
+
+// FromDB fills up a SAMLConfig from serialized format.
+func (source *Source) FromDB(bs []byte) error {
+	return json.UnmarshalHandleDoubleEncode(bs, &source)
+}
+
+// ToDB exports a SAMLConfig to a serialized format.
+func (source *Source) ToDB() ([]byte, error) {
+	return json.Marshal(source)
+}
+
+// SetAuthSource sets the related AuthSource
+func (source *Source) SetAuthSource(authSource *auth.Source) {
+	source.authSource = authSource
+}
+
+// baseURL is the URL of the endpoints of the service provider of the source
+func (source *Source) baseURL() string {
+	return setting.AppURL + "user/saml/" + url.PathEscape(source.authSource.Name)
+}
+
+// MetadataURL is the URL of the metadata of the service provider
+func (source *Source) MetadataURL() string {
+	return source.baseURL() + "/metadata"
+}
+
+// AssertionConsumerServiceURL is the URL where the identity provider posts its responses
+func (source *Source) AssertionConsumerServiceURL() string {
+	return source.baseURL() + "/acs"
+}
+
+// EntityID is the entity ID of the service provider
+func (source *Source) EntityID() string {
+	if source.ServiceProviderEntityID != "" {
+		return source.ServiceProviderEntityID
+	}
+	return source.MetadataURL()
+}
+
+// keyPair parses the certificate and the private key of the service provider
+func (source *Source) keyPair() (*x509.Certificate, *rsa.PrivateKey, error) {
+	pair, err := tls.X509KeyPair([]byte(source.ServiceProviderCertificate), []byte(source.ServiceProviderPrivateKey))
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("the private key of the service provider is not an RSA key")
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// ValidateKeyPair checks that the certificate and the private key of the service provider are an RSA key pair
+func (source *Source) ValidateKeyPair() error {
+	_, _, err := source.keyPair()
+	return err
+}
+
+func init() {
+	auth.RegisterTypeConfig(auth.SAML, &Source{})
+}
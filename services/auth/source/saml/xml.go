@@ -0,0 +1,345 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is an element of a parsed XML document. The names keep the prefixes as they are written in the document,
+// which the canonicalization of the signed elements needs, and the namespaces are resolved through the parents.
+//
+// The XML signatures are not verified by a general purpose library like goxmldsig: SAML only needs enveloped
+// signatures by exclusive canonicalization, and verifying the very element which is read afterwards, instead of
+// resolving references by ID over the document, rules out the signature wrapping attacks by construction. The
+// parsing, the canonicalization and the verification are covered by fuzz tests.
+type element struct {
+	Prefix string
+	Local  string
+	// Namespaces are the namespace declarations of the element, the name of the default namespace is empty
+	Namespaces []xml.Attr
+	// Attrs are the attributes of the element, Name.Space is the prefix of an attribute
+	Attrs []xml.Attr
+	// Children are either *element or string
+	Children []any
+
+	parent *element
+}
+
+// parseXML parses an XML document without DTD into its root element. Comments and processing instructions are dropped.
+func parseXML(data []byte) (*element, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *element
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, errors.New("more than one root element")
+			}
+			el := &element{Prefix: t.Name.Space, Local: t.Name.Local, parent: current}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					el.Namespaces = append(el.Namespaces, xml.Attr{Value: attr.Value})
+				case attr.Name.Space == "xmlns":
+					el.Namespaces = append(el.Namespaces, xml.Attr{Name: xml.Name{Local: attr.Name.Local}, Value: attr.Value})
+				default:
+					el.Attrs = append(el.Attrs, attr)
+				}
+			}
+			if current == nil {
+				root = el
+			} else {
+				current.Children = append(current.Children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || current.Prefix != t.Name.Space || current.Local != t.Name.Local {
+				return nil, fmt.Errorf("unexpected end element %s", t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.Children = append(current.Children, string(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, errors.New("character data outside of the root element")
+			}
+		case xml.Directive:
+			return nil, errors.New("XML directives are not allowed")
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	} else if current != nil {
+		return nil, errors.New("unexpected end of the document")
+	}
+	return root, nil
+}
+
+// newElement creates an element in the namespace of the prefix, which is declared if it isn't by the parent
+func newElement(parent *element, prefix, local, namespace string) *element {
+	el := &element{Prefix: prefix, Local: local, parent: parent}
+	if uri, _ := el.lookupNamespace(prefix); uri != namespace {
+		el.Namespaces = append(el.Namespaces, xml.Attr{Name: xml.Name{Local: prefix}, Value: namespace})
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, el)
+	}
+	return el
+}
+
+// addChild adds a child element in the namespace of the prefix
+func (el *element) addChild(prefix, local, namespace string) *element {
+	return newElement(el, prefix, local, namespace)
+}
+
+// setAttr sets an unprefixed attribute
+func (el *element) setAttr(name, value string) *element {
+	for i := range el.Attrs {
+		if el.Attrs[i].Name.Space == "" && el.Attrs[i].Name.Local == name {
+			el.Attrs[i].Value = value
+			return el
+		}
+	}
+	el.Attrs = append(el.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	return el
+}
+
+// setText replaces the children by the character data
+func (el *element) setText(text string) *element {
+	el.Children = []any{text}
+	return el
+}
+
+// lookupNamespace resolves the namespace of the prefix, the default namespace is empty if it isn't declared
+func (el *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for e := el; e != nil; e = e.parent {
+		for _, ns := range e.Namespaces {
+			if ns.Name.Local == prefix {
+				return ns.Value, true
+			}
+		}
+	}
+	return "", prefix == ""
+}
+
+// namespace returns the namespace of the element
+func (el *element) namespace() string {
+	ns, _ := el.lookupNamespace(el.Prefix)
+	return ns
+}
+
+// is returns true if the element has the namespace and the local name
+func (el *element) is(namespace, local string) bool {
+	return el != nil && el.Local == local && el.namespace() == namespace
+}
+
+// child returns the first child element with the namespace and the local name
+func (el *element) child(namespace, local string) *element {
+	for _, c := range el.Children {
+		if e, ok := c.(*element); ok && e.is(namespace, local) {
+			return e
+		}
+	}
+	return nil
+}
+
+// childrenNamed returns the child elements with the namespace and the local name
+func (el *element) childrenNamed(namespace, local string) []*element {
+	var children []*element
+	for _, c := range el.Children {
+		if e, ok := c.(*element); ok && e.is(namespace, local) {
+			children = append(children, e)
+		}
+	}
+	return children
+}
+
+// childElements returns all the child elements
+func (el *element) childElements() []*element {
+	var children []*element
+	for _, c := range el.Children {
+		if e, ok := c.(*element); ok {
+			children = append(children, e)
+		}
+	}
+	return children
+}
+
+// attr returns the value of an unprefixed attribute
+func (el *element) attr(name string) string {
+	for _, attr := range el.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// text returns the character data of the element without its child elements
+func (el *element) text() string {
+	var sb strings.Builder
+	for _, c := range el.Children {
+		if s, ok := c.(string); ok {
+			sb.WriteString(s)
+		}
+	}
+	return sb.String()
+}
+
+// removeChild removes a child element
+func (el *element) removeChild(child *element) {
+	for i, c := range el.Children {
+		if c == child {
+			el.Children = append(el.Children[:i], el.Children[i+1:]...)
+			return
+		}
+	}
+}
+
+// canonicalize serializes the element by the exclusive XML canonicalization without comments, see
+// https://www.w3.org/TR/xml-exc-c14n/. The excluded element is left out, which is the enveloped signature. The
+// inclusive prefixes are the namespaces which are rendered as by the inclusive canonicalization, "#default" is the
+// default namespace.
+func canonicalize(el, excluded *element, inclusivePrefixes []string) []byte {
+	inclusive := make(map[string]bool, len(inclusivePrefixes))
+	for _, prefix := range inclusivePrefixes {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		inclusive[prefix] = true
+	}
+
+	var buf bytes.Buffer
+	writeCanonical(&buf, el, excluded, inclusive, map[string]string{})
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, el, excluded *element, inclusive map[string]bool, rendered map[string]string) {
+	// the namespaces which are visibly utilized by the element and its attributes
+	utilized := map[string]bool{el.Prefix: true}
+	for _, attr := range el.Attrs {
+		if attr.Name.Space != "" {
+			utilized[attr.Name.Space] = true
+		}
+	}
+	for prefix := range inclusive {
+		utilized[prefix] = true
+	}
+
+	var namespaces []xml.Attr
+	childRendered := rendered
+	for prefix := range utilized {
+		if prefix == "xml" {
+			continue
+		}
+		uri, inScope := el.lookupNamespace(prefix)
+		if !inScope {
+			continue
+		}
+		if previous, ok := rendered[prefix]; ok && previous == uri || !ok && prefix == "" && uri == "" {
+			continue
+		}
+		if len(namespaces) == 0 {
+			childRendered = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				childRendered[k] = v
+			}
+		}
+		childRendered[prefix] = uri
+		namespaces = append(namespaces, xml.Attr{Name: xml.Name{Local: prefix}, Value: uri})
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name.Local < namespaces[j].Name.Local
+	})
+
+	attrs := make([]xml.Attr, len(el.Attrs))
+	copy(attrs, el.Attrs)
+	attrNamespace := func(attr xml.Attr) string {
+		if attr.Name.Space == "" {
+			return ""
+		}
+		ns, _ := el.lookupNamespace(attr.Name.Space)
+		return ns
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		nsI, nsJ := attrNamespace(attrs[i]), attrNamespace(attrs[j])
+		if nsI != nsJ {
+			return nsI < nsJ
+		}
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	name := el.Local
+	if el.Prefix != "" {
+		name = el.Prefix + ":" + el.Local
+	}
+	buf.WriteByte('<')
+	buf.WriteString(name)
+	for _, ns := range namespaces {
+		if ns.Name.Local == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + ns.Name.Local + `="`)
+		}
+		escapeAttr(buf, ns.Value)
+		buf.WriteByte('"')
+	}
+	for _, attr := range attrs {
+		buf.WriteByte(' ')
+		if attr.Name.Space != "" {
+			buf.WriteString(attr.Name.Space + ":")
+		}
+		buf.WriteString(attr.Name.Local + `="`)
+		escapeAttr(buf, attr.Value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, c := range el.Children {
+		switch child := c.(type) {
+		case *element:
+			if child != excluded {
+				writeCanonical(buf, child, excluded, inclusive, childRendered)
+			}
+		case string:
+			escapeText(buf, child)
+		}
+	}
+
+	buf.WriteString("</" + name + ">")
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(buf *bytes.Buffer, s string) {
+	_, _ = textEscaper.WriteString(buf, s)
+}
+
+func escapeAttr(buf *bytes.Buffer, s string) {
+	_, _ = attrEscaper.WriteString(buf, s)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package saml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	// the example of https://www.w3.org/TR/xml-exc-c14n/#sec-Enveloping
+	doc, err := parseXML([]byte(`<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org">
+   <n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+       <n3:stuff xmlns:n3="ftp://example.org"/>
+   </n1:elem2>
+</n0:local>`))
+	require.NoError(t, err)
+	elem2 := doc.childElements()[0]
+
+	assert.Equal(t, `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en">
+       <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+   </n1:elem2>`, string(canonicalize(elem2, nil, nil)))
+
+	// the inclusive namespaces are rendered even if they are not utilized
+	assert.Equal(t, `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xml:lang="en">
+       <n3:stuff xmlns:n3="ftp://example.org"></n3:stuff>
+   </n1:elem2>`, string(canonicalize(elem2, nil, []string{"n0"})))
+
+	t.Run("AttributesAndEscaping", func(t *testing.T) {
+		doc, err := parseXML([]byte(`<a xmlns="urn:a" xmlns:b="urn:b" z="1" b:y="&quot;2&quot;" a="&lt;3&gt;"><b:c/>x &amp; y &gt; z<unused xmlns:u="urn:u"/></a>`))
+		require.NoError(t, err)
+		assert.Equal(t, `<a xmlns="urn:a" xmlns:b="urn:b" a="&lt;3>" z="1" b:y="&quot;2&quot;"><b:c></b:c>x &amp; y &gt; z<unused></unused></a>`,
+			string(canonicalize(doc, nil, nil)))
+	})
+
+	t.Run("Excluded", func(t *testing.T) {
+		doc, err := parseXML([]byte(`<a><b/><c/></a>`))
+		require.NoError(t, err)
+		assert.Equal(t, `<a><c></c></a>`, string(canonicalize(doc, doc.childElements()[0], nil)))
+	})
+}
+
+func TestParseXML(t *testing.T) {
+	_, err := parseXML([]byte(`<!DOCTYPE a [<!ENTITY x "y">]><a>&x;</a>`))
+	require.Error(t, err)
+
+	_, err = parseXML([]byte(`<a></a><b></b>`))
+	require.Error(t, err)
+
+	_, err = parseXML([]byte(`<a><b></a>`))
+	require.Error(t, err)
+
+	doc, err := parseXML([]byte(`<?xml version="1.0"?><!-- comment --><p:a xmlns:p="urn:p"><p:b>x<!-- comment -->y</p:b></p:a>`))
+	require.NoError(t, err)
+	assert.True(t, doc.is("urn:p", "a"))
+	assert.Equal(t, "xy", doc.child("urn:p", "b").text())
+}
+
+// FuzzCanonicalize checks that the canonical form of any parsed document is a document with the same canonical form
+func FuzzCanonicalize(f *testing.F) {
+	for _, seed := range []string{
+		`<a xmlns="urn:a" xmlns:b="urn:b" z="1" b:y="&quot;2&quot;" a="&lt;3&gt;"><b:c/>x &amp; y &gt; z<unused xmlns:u="urn:u"/></a>`,
+		`<n0:local xmlns:n0="foo:bar" xmlns:n3="ftp://example.org"><n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/></n1:elem2></n0:local>`,
+		`<?xml version="1.0"?><!-- comment --><p:a xmlns:p="urn:p"><p:b>x<!-- comment -->y&#xD;</p:b></p:a>`,
+		`<a xmlns="urn:a"><b xmlns=""><c xmlns="urn:a" attr="&#x9;&#xA;"/></b></a>`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := parseXML(data)
+		if err != nil {
+			return
+		}
+		canonical := canonicalize(doc, nil, nil)
+		reparsed, err := parseXML(canonical)
+		require.NoError(t, err, "%s", canonical)
+		assert.Equal(t, string(canonical), string(canonicalize(reparsed, nil, nil)))
+	})
+}
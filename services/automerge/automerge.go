@@ -22,6 +22,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/services/mergequeue"
 	notify_service "code.gitea.io/gitea/services/notify"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
@@ -284,6 +285,17 @@ func handlePullRequestAutoMerge(pullID int64, sha string) {
 		return
 	}
 
+	// Pull requests into a branch with a merge queue are handed over to the merge queue
+	if added, err := mergequeue.AddToMergeQueue(ctx, doer, pr, scheduledPRM.MergeStyle, scheduledPRM.Message); err != nil {
+		log.Error("%-v AddToMergeQueue: %v", pr, err)
+		return
+	} else if added {
+		if err := pull_model.DeleteScheduledAutoMerge(ctx, pr.ID); err != nil && !db.IsErrNotExist(err) {
+			log.Error("%-v DeleteScheduledAutoMerge: %v", pr, err)
+		}
+		return
+	}
+
 	if err := pull_service.Merge(ctx, pr, doer, baseGitRepo, scheduledPRM.MergeStyle, "", scheduledPRM.Message, true); err != nil {
 		log.Error("pull_service.Merge: %v", err)
 		// FIXME: if merge failed, we should display some error message to the pull request page.
@@ -4,7 +4,10 @@
 package context
 
 import (
+	auth_model "code.gitea.io/gitea/models/auth"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
 )
 
 // IsUserSiteAdmin returns true if current user is a site admin
@@ -27,3 +30,30 @@ func (ctx *Context) IsUserRepoWriter(unitTypes []unit.Type) bool {
 
 	return false
 }
+
+// AccessTokenIsRestricted returns true if the current user signed in with a personal access token which is restricted
+// to an organization or to repositories
+func (b *Base) AccessTokenIsRestricted() bool {
+	t, ok := b.Data["ApiAccessToken"].(*auth_model.AccessToken)
+	return ok && t.IsRestricted()
+}
+
+// AccessTokenAllowsRepo returns false if the current user signed in with a personal access token which is restricted
+// to other repositories, restricted tokens cannot create repositories, which are passed as nil
+func (b *Base) AccessTokenAllowsRepo(repo *repo_model.Repository) bool {
+	t, ok := b.Data["ApiAccessToken"].(*auth_model.AccessToken)
+	if !ok {
+		return true
+	}
+	if repo == nil {
+		return !t.IsRestricted()
+	}
+	return t.AllowsRepo(repo.OwnerID, repo.ID)
+}
+
+// AccessTokenAllowsOwner returns false if the current user signed in with a personal access token which is restricted
+// to other organizations or to repositories
+func (b *Base) AccessTokenAllowsOwner(owner *user_model.User) bool {
+	t, ok := b.Data["ApiAccessToken"].(*auth_model.AccessToken)
+	return !ok || t.AllowsOwner(owner.ID)
+}
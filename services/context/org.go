@@ -162,6 +162,10 @@ func HandleOrgAssignment(ctx *Context, args ...bool) {
 		ctx.NotFound("OrgAssignment", err)
 		return
 	}
+	ctx.CheckOrgAuthPolicy(org.AsUser())
+	if ctx.Written() {
+		return
+	}
 	ctx.Data["IsOrganizationOwner"] = ctx.Org.IsOwner
 	ctx.Data["IsOrganizationMember"] = ctx.Org.IsMember
 	ctx.Data["IsPackageEnabled"] = setting.Packages.Enabled
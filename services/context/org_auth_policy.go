@@ -0,0 +1,100 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const tplOrgAuthPolicyViolation base.TplName = "org/auth_policy_violation"
+
+// OrgAuthPolicyViolation checks the doer against the authentication policy of the owner of a resource if it is an
+// organization, the violation is nil if the policy does not apply or is fulfilled. data is the data of the request,
+// it tells the authentication source of the web session.
+func OrgAuthPolicyViolation(ctx context.Context, owner, doer *user_model.User, data map[string]any) (*organization.AuthPolicy, *organization.AuthPolicyViolation, error) {
+	if owner == nil || doer == nil || !owner.IsOrganization() {
+		return nil, nil, nil
+	}
+	signInSourceID := optional.None[int64]()
+	if id, ok := data["SignInSourceID"].(int64); ok {
+		signInSourceID = optional.Some(id)
+	}
+	return organization.CheckAuthPolicy(ctx, owner.ID, doer, signInSourceID)
+}
+
+// OrgAuthPolicyMessage describes the requirements of the authentication policy of the organization which are not
+// fulfilled, for the API and the git clients
+func OrgAuthPolicyMessage(org *user_model.User, v *organization.AuthPolicyViolation) string {
+	var missing []string
+	if v.MissingTwoFactor {
+		missing = append(missing, "to enable two-factor authentication")
+	}
+	if v.MissingAuthSource {
+		missing = append(missing, "to sign in through its single sign-on provider")
+	}
+	return fmt.Sprintf("The organization %s requires its members %s.", org.Name, strings.Join(missing, " and "))
+}
+
+// CheckOrgAuthPolicy blocks the doer from the resources of the organization if they are a member who does not fulfil
+// its authentication policy, the members are only warned during the grace period
+func (ctx *Context) CheckOrgAuthPolicy(owner *user_model.User) {
+	p, v, err := OrgAuthPolicyViolation(ctx, owner, ctx.Doer, ctx.Data)
+	if err != nil {
+		ctx.ServerError("OrgAuthPolicyViolation", err)
+		return
+	}
+	if v == nil {
+		return
+	}
+
+	if p.InGracePeriod() {
+		var msgs []string
+		if v.MissingTwoFactor {
+			msgs = append(msgs, string(ctx.Tr("org.auth_policy.grace_period_two_factor", owner.DisplayName(), p.GracePeriodEndUnix.FormatDate(), setting.AppSubURL+"/user/settings/security")))
+		}
+		if v.MissingAuthSource {
+			msgs = append(msgs, string(ctx.Tr("org.auth_policy.grace_period_auth_source", owner.DisplayName(), p.GracePeriodEndUnix.FormatDate())))
+		}
+		ctx.Flash.Warning(template.HTML(strings.Join(msgs, "<br>")), true)
+		return
+	}
+
+	if v.MissingAuthSource {
+		source, err := auth_model.GetSourceByID(ctx, p.RequiredAuthSourceID)
+		if err != nil && !auth_model.IsErrSourceNotExist(err) {
+			ctx.ServerError("GetSourceByID", err)
+			return
+		}
+		ctx.Data["RequiredAuthSource"] = source
+	}
+	ctx.Data["Title"] = owner.DisplayName()
+	ctx.Data["PolicyOrg"] = owner
+	ctx.Data["AuthPolicyViolation"] = v
+	ctx.HTML(http.StatusForbidden, tplOrgAuthPolicyViolation)
+}
+
+// CheckOrgAuthPolicy blocks the doer from the resources of the organization if they are a member who does not fulfil
+// its authentication policy after the grace period
+func (ctx *APIContext) CheckOrgAuthPolicy(owner *user_model.User) {
+	p, v, err := OrgAuthPolicyViolation(ctx, owner, ctx.Doer, ctx.Data)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OrgAuthPolicyViolation", err)
+		return
+	}
+	if v == nil || p.InGracePeriod() {
+		return
+	}
+	ctx.Error(http.StatusForbidden, "OrgAuthPolicy", OrgAuthPolicyMessage(owner, v))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/organization"
 	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/models/perm"
@@ -101,7 +102,32 @@ func determineAccessMode(ctx *Base, pkg *Package, doer *user_model.User) (perm.A
 		return perm.AccessModeNone, nil
 	}
 
-	// TODO: ActionUser permission check
+	if doer != nil && doer.IsActions() {
+		// A task of Actions may access the packages of the owner of its repository, with write access unless it runs
+		// for a pull request from a fork
+		if taskID, ok := ctx.Data["ActionsTaskID"].(int64); ok {
+			task, err := actions_model.GetTaskByID(ctx, taskID)
+			if err != nil {
+				return perm.AccessModeNone, err
+			}
+			if task.OwnerID == pkg.Owner.ID {
+				if task.IsForkPullRequest {
+					return perm.AccessModeRead, nil
+				}
+				return perm.AccessModeWrite, nil
+			}
+		}
+	}
+
+	// the members who do not fulfil the authentication policy of the organization are blocked from its packages
+	policy, violation, err := OrgAuthPolicyViolation(ctx, pkg.Owner, doer, ctx.Data)
+	if err != nil {
+		return perm.AccessModeNone, err
+	}
+	if violation != nil && !policy.InGracePeriod() {
+		return perm.AccessModeNone, nil
+	}
+
 	accessMode := perm.AccessModeNone
 	if pkg.Owner.IsOrganization() {
 		org := organization.OrgFromUser(pkg.Owner)
@@ -117,6 +117,11 @@ func CheckRepoScopedToken(ctx *Context, repo *repo_model.Repository, level auth_
 		return
 	}
 
+	if !ctx.AccessTokenAllowsRepo(repo) {
+		ctx.Error(http.StatusForbidden)
+		return
+	}
+
 	scope, ok := ctx.Data["ApiTokenScope"].(auth_model.AccessTokenScope)
 	if ok { // it's a personal access token but not oauth2 token
 		var scopeMatched bool
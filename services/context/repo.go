@@ -368,6 +368,10 @@ func repoAssignment(ctx *Context, repo *repo_model.Repository) {
 		ctx.ServerError("LoadOwner", err)
 		return
 	}
+	ctx.CheckOrgAuthPolicy(repo.Owner)
+	if ctx.Written() {
+		return
+	}
 
 	ctx.Repo.Permission, err = access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 	if err != nil {
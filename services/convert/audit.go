@@ -0,0 +1,43 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	audit_model "code.gitea.io/gitea/models/audit"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToAuditEvent converts an audit_model.Event to an api.AuditEvent
+func ToAuditEvent(e *audit_model.Event) *api.AuditEvent {
+	return &api.AuditEvent{
+		ID:         e.ID,
+		Action:     string(e.Action),
+		ActorID:    e.ActorID,
+		ActorName:  e.ActorName,
+		ActorIP:    e.ActorIP,
+		OwnerID:    e.OwnerID,
+		RepoID:     e.RepoID,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		TargetName: e.TargetName,
+		Before:     unmarshalAuditState(e.ID, e.Before),
+		After:      unmarshalAuditState(e.ID, e.After),
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+		Created:    e.CreatedUnix.AsTime(),
+	}
+}
+
+func unmarshalAuditState(id int64, state string) map[string]any {
+	if state == "" {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(state), &m); err != nil {
+		log.Error("Unable to unmarshal the state of the audit event %d: %v", id, err)
+	}
+	return m
+}
@@ -179,6 +179,11 @@ func ToBranchProtection(ctx context.Context, bp *git_model.ProtectedBranch, repo
 		ProtectedFilePatterns:         bp.ProtectedFilePatterns,
 		UnprotectedFilePatterns:       bp.UnprotectedFilePatterns,
 		ApplyToAdmins:                 bp.ApplyToAdmins,
+		EnableMergeQueue:              bp.EnableMergeQueue,
+		RequireCodeOwnerApproval:      bp.RequireCodeOwnerApproval,
+		RequireLinearHistory:          bp.RequireLinearHistory,
+		RequireSignoff:                bp.RequireSignoff,
+		CommitMessagePattern:          bp.CommitMessagePattern,
 		Created:                       bp.CreatedUnix.AsTime(),
 		Updated:                       bp.UpdatedUnix.AsTime(),
 	}
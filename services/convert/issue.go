@@ -114,6 +114,16 @@ func toIssue(ctx context.Context, doer *user_model.User, issue *issues_model.Iss
 	if issue.DeadlineUnix != 0 {
 		apiIssue.Deadline = issue.DeadlineUnix.AsTimePtr()
 	}
+	if !issue.IsPull {
+		apiIssue.CloseWithSubIssues = issue.CloseWithSubIssues
+		progress, err := issues_model.GetSubIssueProgress(ctx, doer, issue.ID)
+		if err != nil {
+			return &api.Issue{}
+		}
+		if p := progress[issue.ID]; p != nil {
+			apiIssue.SubIssues = &api.SubIssueProgress{Total: p.Total, Closed: p.Closed}
+		}
+	}
 
 	return apiIssue
 }
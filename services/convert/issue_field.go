@@ -0,0 +1,54 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	issues_model "code.gitea.io/gitea/models/issues"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToIssueField converts an issues_model.IssueField to an api.IssueField
+func ToIssueField(f *issues_model.IssueField) *api.IssueField {
+	options := f.Options
+	if options == nil {
+		options = []string{}
+	}
+	return &api.IssueField{
+		ID:          f.ID,
+		Name:        f.Name,
+		Description: f.Description,
+		Type:        string(f.Type),
+		Options:     options,
+		IsOrgField:  f.BelongsToOrg(),
+		SortOrder:   f.SortOrder,
+	}
+}
+
+// ToIssueFieldList converts a list of issues_model.IssueField to a list of api.IssueField
+func ToIssueFieldList(fields []*issues_model.IssueField) []*api.IssueField {
+	result := make([]*api.IssueField, len(fields))
+	for i := range fields {
+		result[i] = ToIssueField(fields[i])
+	}
+	return result
+}
+
+// ToIssueFieldValues converts the display values of the fields of an issue, as returned by
+// issues_model.GetIssueFieldDisplayValues, to a list of api.IssueFieldValue. Fields without a value are omitted.
+func ToIssueFieldValues(fields []*issues_model.IssueField, values map[int64][]string) []*api.IssueFieldValue {
+	result := make([]*api.IssueFieldValue, 0, len(values))
+	for _, f := range fields {
+		v, ok := values[f.ID]
+		if !ok || len(v) == 0 {
+			continue
+		}
+		result = append(result, &api.IssueFieldValue{
+			FieldID: f.ID,
+			Name:    f.Name,
+			Type:    string(f.Type),
+			Values:  v,
+		})
+	}
+	return result
+}
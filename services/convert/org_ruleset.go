@@ -0,0 +1,55 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	"context"
+
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/models/organization"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToOrgRuleset converts a git_model.OrgRuleset to an api.OrgRuleset
+func ToOrgRuleset(ctx context.Context, ruleset *git_model.OrgRuleset) *api.OrgRuleset {
+	users, err := user_model.GetUsersByIDs(ctx, ruleset.WhitelistUserIDs)
+	if err != nil {
+		log.Error("GetUsersByIDs: %v", err)
+	}
+	teams, err := organization.FindOrgTeams(ctx, ruleset.OrgID)
+	if err != nil {
+		log.Error("FindOrgTeams: %v", err)
+	}
+
+	return &api.OrgRuleset{
+		ID:                     ruleset.ID,
+		Name:                   ruleset.Name,
+		RepoNamePattern:        ruleset.RepoNamePattern,
+		RepoTopic:              ruleset.RepoTopic,
+		Target:                 ruleset.Target.String(),
+		RefPattern:             ruleset.RefPattern,
+		EnablePush:             ruleset.CanPush,
+		EnablePushWhitelist:    ruleset.EnableWhitelist,
+		PushWhitelistUsernames: getWhitelistEntities(users, ruleset.WhitelistUserIDs),
+		PushWhitelistTeams:     getWhitelistEntities(teams, ruleset.WhitelistTeamIDs),
+		RequiredApprovals:      ruleset.RequiredApprovals,
+		EnableStatusCheck:      ruleset.EnableStatusCheck,
+		StatusCheckContexts:    ruleset.StatusCheckContexts,
+		RequireSignedCommits:   ruleset.RequireSignedCommits,
+		ProtectedFilePatterns:  ruleset.ProtectedFilePatterns,
+		Created:                ruleset.CreatedUnix.AsTime(),
+		Updated:                ruleset.UpdatedUnix.AsTime(),
+	}
+}
+
+// ToOrgRulesetList converts a list of git_model.OrgRuleset to a list of api.OrgRuleset
+func ToOrgRulesetList(ctx context.Context, rulesets []*git_model.OrgRuleset) []*api.OrgRuleset {
+	result := make([]*api.OrgRuleset, 0, len(rulesets))
+	for _, ruleset := range rulesets {
+		result = append(result, ToOrgRuleset(ctx, ruleset))
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	api "code.gitea.io/gitea/modules/structs"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// ToPullRequestConflicts converts the conflicting files of a pull request to api format
+func ToPullRequestConflicts(headCommitID string, files []*pull_service.ConflictFile) *api.PullRequestConflicts {
+	result := &api.PullRequestConflicts{
+		HeadSHA: headCommitID,
+		Files:   make([]*api.PullRequestConflictFile, 0, len(files)),
+	}
+	for _, file := range files {
+		apiFile := &api.PullRequestConflictFile{
+			Path:     file.Path,
+			Binary:   file.Binary,
+			Ancestor: toPullRequestConflictFileVersion(file.Ancestor),
+			Head:     toPullRequestConflictFileVersion(file.Head),
+			Base:     toPullRequestConflictFileVersion(file.Base),
+		}
+		if file.Hunks != nil {
+			apiFile.Hunks = make([]*api.PullRequestConflictHunk, 0, len(file.Hunks))
+			for _, hunk := range file.Hunks {
+				apiFile.Hunks = append(apiFile.Hunks, &api.PullRequestConflictHunk{
+					Conflict: hunk.Conflict,
+					Content:  hunk.Content,
+					Head:     hunk.Head,
+					Ancestor: hunk.Ancestor,
+					Base:     hunk.Base,
+				})
+			}
+		}
+		result.Files = append(result.Files, apiFile)
+	}
+	return result
+}
+
+func toPullRequestConflictFileVersion(version *pull_service.ConflictFileVersion) *api.PullRequestConflictFileVersion {
+	if version == nil {
+		return nil
+	}
+	return &api.PullRequestConflictFileVersion{
+		Mode:    version.Mode,
+		SHA:     version.SHA,
+		Content: version.Content,
+	}
+}
+
+// ToConflictResolutions converts the resolutions of conflicting files from api format
+func ToConflictResolutions(files []*api.PullRequestConflictResolution) []*pull_service.ConflictResolution {
+	resolutions := make([]*pull_service.ConflictResolution, 0, len(files))
+	for _, file := range files {
+		res := &pull_service.ConflictResolution{
+			Path:    file.Path,
+			Content: file.Content,
+			Delete:  file.Delete,
+			Side:    pull_service.ConflictSide(file.Side),
+		}
+		if file.Hunks != nil {
+			res.Hunks = make([]pull_service.ConflictSide, 0, len(file.Hunks))
+			for _, side := range file.Hunks {
+				res.Hunks = append(res.Hunks, pull_service.ConflictSide(side))
+			}
+		}
+		resolutions = append(resolutions, res)
+	}
+	return resolutions
+}
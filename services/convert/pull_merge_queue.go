@@ -0,0 +1,78 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToMergeQueueEntries converts the entries of merge queues to api format,
+// the entries have to be ordered by branch and position
+func ToMergeQueueEntries(ctx context.Context, entries []*pull_model.MergeQueueEntry, doer *user_model.User) ([]*api.MergeQueueEntry, error) {
+	result := make([]*api.MergeQueueEntry, 0, len(entries))
+	var position int64
+	for i, entry := range entries {
+		if i == 0 || entries[i-1].RepoID != entry.RepoID || entries[i-1].BaseBranch != entry.BaseBranch {
+			position = 0
+		}
+		position++
+
+		apiEntry, err := ToMergeQueueEntry(ctx, entry, position, doer)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, apiEntry)
+	}
+	return result, nil
+}
+
+// ToMergeQueueEntry converts an entry of a merge queue to api format
+func ToMergeQueueEntry(ctx context.Context, entry *pull_model.MergeQueueEntry, position int64, doer *user_model.User) (*api.MergeQueueEntry, error) {
+	if err := entry.LoadDoer(ctx); err != nil {
+		return nil, err
+	}
+	pr, err := issues_model.GetPullRequestByID(ctx, entry.PullID)
+	if err != nil {
+		return nil, err
+	}
+	if err := pr.LoadIssue(ctx); err != nil {
+		return nil, err
+	}
+	if err := pr.Issue.LoadRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &api.MergeQueueEntry{
+		Position:       position,
+		Number:         pr.Index,
+		Title:          pr.Issue.Title,
+		HTMLURL:        pr.Issue.HTMLURL(),
+		BaseBranch:     entry.BaseBranch,
+		MergeStyle:     string(entry.MergeStyle),
+		AddedBy:        ToUser(ctx, entry.Doer, doer),
+		HeadSHA:        entry.HeadCommitID,
+		BaseSHA:        entry.BaseCommitID,
+		MergeCommitSHA: entry.CommitID,
+		Added:          entry.CreatedUnix.AsTime(),
+	}
+
+	if entry.IsTesting() {
+		statuses, _, err := git_model.GetLatestCommitStatus(ctx, entry.RepoID, entry.CommitID, db.ListOptionsAll)
+		if err != nil {
+			return nil, err
+		}
+		if status := git_model.CalcCommitStatus(statuses); status != nil {
+			result.State = status.State
+		}
+	}
+
+	return result, nil
+}
@@ -65,6 +65,28 @@ func registerGarbageCollectRepositories() {
 	})
 }
 
+func registerMaintainRepositories() {
+	type RepoMaintenanceConfig struct {
+		BaseConfig
+		Timeout       time.Duration
+		PushThreshold int64
+		Interval      time.Duration
+	}
+	RegisterTaskFatal("git_maintenance", &RepoMaintenanceConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    false,
+			RunAtStart: false,
+			Schedule:   "@every 1h",
+		},
+		Timeout:       time.Duration(setting.Git.Timeout.GC) * time.Second,
+		PushThreshold: 50,
+		Interval:      24 * time.Hour,
+	}, func(ctx context.Context, _ *user_model.User, config Config) error {
+		rmConfig := config.(*RepoMaintenanceConfig)
+		return repo_service.MaintainRepositories(ctx, rmConfig.Timeout, rmConfig.PushThreshold, rmConfig.Interval)
+	})
+}
+
 func registerRewriteAllPublicKeys() {
 	RegisterTaskFatal("resync_all_sshkeys", &BaseConfig{
 		Enabled:    false,
@@ -229,6 +251,7 @@ func initExtendedTasks() {
 	registerDeleteInactiveUsers()
 	registerDeleteRepositoryArchives()
 	registerGarbageCollectRepositories()
+	registerMaintainRepositories()
 	registerRewriteAllPublicKeys()
 	registerRewriteAllPrincipalKeys()
 	registerRepositoryUpdateHook()
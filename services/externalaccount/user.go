@@ -18,7 +18,7 @@ import (
 )
 
 func toExternalLoginUser(ctx context.Context, user *user_model.User, gothUser goth.User) (*user_model.ExternalLoginUser, error) {
-	authSource, err := auth.GetActiveOAuth2SourceByName(ctx, gothUser.Provider)
+	authSource, err := auth.GetActiveExternalSourceByName(ctx, gothUser.Provider)
 	if err != nil {
 		return nil, err
 	}
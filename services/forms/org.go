@@ -74,3 +74,46 @@ func (f *CreateTeamForm) Validate(req *http.Request, errs binding.Errors) bindin
 	ctx := context.GetValidateContext(req)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+// __________      .__                        __
+// \______   \__ __|  |   ____   ______ _____/  |_  ______
+//  |       _/  |  \  | _/ __ \ /  ___// __ \   __\/  ___/
+//  |    |   \  |  /  |_\  ___/ \___ \\  ___/|  |  \___ \
+//  |____|_  /____/|____/\___  >____  >\___  >__| /____  >
+//         \/                \/     \/     \/          \/
+
+// OrgAuthPolicyForm form for updating the authentication policy of an organization
+type OrgAuthPolicyForm struct {
+	RequireTwoFactor     bool
+	RequiredAuthSourceID int64
+	GracePeriodDays      int64 `binding:"Range(0,365)"`
+}
+
+// Validate validates the fields
+func (f *OrgAuthPolicyForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// OrgRulesetForm form for creating or editing an organization ruleset
+type OrgRulesetForm struct {
+	Name                  string `binding:"Required;MaxSize(255)"`
+	RepoNamePattern       string
+	RepoTopic             string
+	Target                string
+	RefPattern            string `binding:"Required"`
+	EnablePush            string
+	WhitelistUsers        string
+	WhitelistTeams        string
+	RequiredApprovals     int64
+	EnableStatusCheck     bool
+	StatusCheckContexts   string
+	RequireSignedCommits  bool
+	ProtectedFilePatterns string
+}
+
+// Validate validates the fields
+func (f *OrgRulesetForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
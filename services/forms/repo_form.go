@@ -179,6 +179,7 @@ type RepoUnitSettingForm struct {
 	PullsAllowRebaseUpdate                bool
 	DefaultDeleteBranchAfterMerge         bool
 	DefaultAllowMaintainerEdit            bool
+	RebaseStackedPullsOnMerge             bool
 	EnableTimetracker                     bool
 	AllowOnlyContributorsToTrackTime      bool
 	EnableIssueDependencies               bool
@@ -223,6 +224,11 @@ type ProtectBranchForm struct {
 	ProtectedFilePatterns         string
 	UnprotectedFilePatterns       string
 	ApplyToAdmins                 bool
+	EnableMergeQueue              bool
+	RequireCodeOwnerApproval      bool
+	RequireLinearHistory          bool
+	RequireSignoff                bool
+	CommitMessagePattern          string
 }
 
 // Validate validates the fields
@@ -425,6 +431,22 @@ func (f *InitializeLabelsForm) Validate(req *http.Request, errs binding.Errors)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// IssueFieldForm form for creating or editing a custom issue field
+type IssueFieldForm struct {
+	ID          int64
+	Name        string `binding:"Required;MaxSize(255)" locale:"repo.issues.fields.name"`
+	Description string
+	Type        string
+	Options     string
+	SortOrder   int
+}
+
+// Validate validates the fields
+func (f *IssueFieldForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // MergePullRequestForm form for merging Pull Request
 // swagger:model MergePullRequestOption
 type MergePullRequestForm struct {
@@ -8,6 +8,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"unicode"
 
 	auth_model "code.gitea.io/gitea/models/auth"
 	user_model "code.gitea.io/gitea/models/user"
@@ -176,7 +177,7 @@ func (f *GrantApplicationForm) Validate(req *http.Request, errs binding.Errors)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
-// AccessTokenForm for issuing access tokens from authorization codes or refresh tokens
+// AccessTokenForm for issuing access tokens from authorization codes, refresh tokens, device codes or client credentials
 type AccessTokenForm struct {
 	GrantType    string `json:"grant_type"`
 	ClientID     string `json:"client_id"`
@@ -184,6 +185,8 @@ type AccessTokenForm struct {
 	RedirectURI  string `json:"redirect_uri"`
 	Code         string `json:"code"`
 	RefreshToken string `json:"refresh_token"`
+	DeviceCode   string `json:"device_code"`
+	Scope        string `json:"scope"`
 
 	// PKCE support
 	CodeVerifier string `json:"code_verifier"`
@@ -195,6 +198,31 @@ func (f *AccessTokenForm) Validate(req *http.Request, errs binding.Errors) bindi
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// DeviceAuthorizationForm for requesting the authorization of a device
+type DeviceAuthorizationForm struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// Validate validates the fields
+func (f *DeviceAuthorizationForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// GrantDeviceForm form for authorizing devices
+type GrantDeviceForm struct {
+	UserCode string `binding:"Required"`
+	Granted  bool
+}
+
+// Validate validates the fields
+func (f *GrantDeviceForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // IntrospectTokenForm for introspecting tokens
 type IntrospectTokenForm struct {
 	Token string `json:"token"`
@@ -369,8 +397,11 @@ func (f *EditVariableForm) Validate(req *http.Request, errs binding.Errors) bind
 
 // NewAccessTokenForm form for creating access token
 type NewAccessTokenForm struct {
-	Name  string `binding:"Required;MaxSize(255)" locale:"settings.token_name"`
-	Scope []string
+	Name         string `binding:"Required;MaxSize(255)" locale:"settings.token_name"`
+	Scope        []string
+	Organization string
+	Repositories string
+	ExpiresAt    string `form:"expires_at"`
 }
 
 // Validate validates the fields
@@ -385,11 +416,19 @@ func (f *NewAccessTokenForm) GetScope() (auth_model.AccessTokenScope, error) {
 	return s, err
 }
 
+// GetRepositories returns the full names of the repositories, which are separated by commas or new lines
+func (f *NewAccessTokenForm) GetRepositories() []string {
+	return strings.FieldsFunc(f.Repositories, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
+
 // EditOAuth2ApplicationForm form for editing oauth2 applications
 type EditOAuth2ApplicationForm struct {
-	Name               string `binding:"Required;MaxSize(255)" form:"application_name"`
-	RedirectURIs       string `binding:"Required" form:"redirect_uris"`
-	ConfidentialClient bool   `form:"confidential_client"`
+	Name                  string `binding:"Required;MaxSize(255)" form:"application_name"`
+	RedirectURIs          string `binding:"Required" form:"redirect_uris"`
+	ConfidentialClient    bool   `form:"confidential_client"`
+	ClientCredentialsUser string `form:"client_credentials_user"`
 }
 
 // Validate validates the fields
@@ -422,7 +461,8 @@ func (f *TwoFactorScratchAuthForm) Validate(req *http.Request, errs binding.Erro
 
 // WebauthnRegistrationForm for reserving an WebAuthn name
 type WebauthnRegistrationForm struct {
-	Name string `binding:"Required"`
+	Name    string `binding:"Required"`
+	Passkey bool
 }
 
 // Validate validates the fields
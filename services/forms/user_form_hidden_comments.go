@@ -53,6 +53,12 @@ var hiddenCommentTypeGroups = hiddenCommentTypeGroupsType{
 		/*19*/ issues_model.CommentTypeAddDependency,
 		/*20*/ issues_model.CommentTypeRemoveDependency,
 	},
+	"sub_issue": {
+		/*40*/ issues_model.CommentTypeAddSubIssue,
+		/*41*/ issues_model.CommentTypeRemoveSubIssue,
+		/*42*/ issues_model.CommentTypeAddParentIssue,
+		/*43*/ issues_model.CommentTypeRemoveParentIssue,
+	},
 	"lock": {
 		/*23*/ issues_model.CommentTypeLock,
 		/*24*/ issues_model.CommentTypeUnlock,
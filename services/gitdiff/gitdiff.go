@@ -487,6 +487,24 @@ func (diff *Diff) LoadComments(ctx context.Context, issue *issues_model.Issue, c
 	return nil
 }
 
+// HasCodeSuggestions returns whether a code comment loaded into the diff proposes a suggestion
+func (diff *Diff) HasCodeSuggestions() bool {
+	for _, file := range diff.Files {
+		for _, section := range file.Sections {
+			for _, line := range section.Lines {
+				for _, conversation := range line.Conversations {
+					for _, comment := range conversation {
+						if comment.Suggestion != nil && !comment.Invalidated {
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
 const cmdDiffHead = "diff --git "
 
 // ParsePatch builds a Diff object from a io.Reader and some parameters.
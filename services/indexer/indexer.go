@@ -5,6 +5,7 @@ package indexer
 
 import (
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	stats_indexer "code.gitea.io/gitea/modules/indexer/stats"
 	notify_service "code.gitea.io/gitea/services/notify"
@@ -16,5 +17,6 @@ func Init() error {
 
 	issue_indexer.InitIssueIndexer(false)
 	code_indexer.Init()
+	commit_indexer.Init()
 	return stats_indexer.Init()
 }
@@ -290,6 +290,7 @@ func deleteIssue(ctx context.Context, issue *issues_model.Issue) error {
 		&issues_model.ContentHistory{IssueID: issue.ID},
 		&issues_model.Comment{IssueID: issue.ID},
 		&issues_model.IssueLabel{IssueID: issue.ID},
+		&issues_model.IssueFieldValue{IssueID: issue.ID},
 		&issues_model.IssueDependency{IssueID: issue.ID},
 		&issues_model.IssueAssignees{IssueID: issue.ID},
 		&issues_model.IssueUser{IssueID: issue.ID},
@@ -303,6 +304,8 @@ func deleteIssue(ctx context.Context, issue *issues_model.Issue) error {
 		&issues_model.PullRequest{IssueID: issue.ID},
 		&issues_model.Comment{RefIssueID: issue.ID},
 		&issues_model.IssueDependency{DependencyID: issue.ID},
+		&issues_model.SubIssue{IssueID: issue.ID},
+		&issues_model.SubIssue{ParentID: issue.ID},
 		&issues_model.Comment{DependentIssueID: issue.ID},
 	); err != nil {
 		return err
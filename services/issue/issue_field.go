@@ -0,0 +1,135 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issue
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/util"
+	notify_service "code.gitea.io/gitea/services/notify"
+)
+
+// UpdateField updates a custom issue field and reindexes the issues which had a value of it
+func UpdateField(ctx context.Context, field *issues_model.IssueField) error {
+	issueIDs, err := issues_model.GetIssueIDsByFieldID(ctx, field.ID)
+	if err != nil {
+		return err
+	}
+	if err := issues_model.UpdateIssueField(ctx, field); err != nil {
+		return err
+	}
+
+	for _, issueID := range issueIDs {
+		issue_indexer.UpdateIssueIndexer(ctx, issueID)
+	}
+	return nil
+}
+
+// DeleteField deletes a custom issue field and reindexes the issues which had a value of it
+func DeleteField(ctx context.Context, field *issues_model.IssueField) error {
+	issueIDs, err := issues_model.GetIssueIDsByFieldID(ctx, field.ID)
+	if err != nil {
+		return err
+	}
+	if err := issues_model.DeleteIssueField(ctx, field); err != nil {
+		return err
+	}
+
+	for _, issueID := range issueIDs {
+		issue_indexer.UpdateIssueIndexer(ctx, issueID)
+	}
+	return nil
+}
+
+// SetFieldValues replaces the values of a custom field of an issue, the values are removed if values is empty
+func SetFieldValues(ctx context.Context, issue *issues_model.Issue, doer *user_model.User, field *issues_model.IssueField, values []string) error {
+	if err := issues_model.SetIssueFieldValues(ctx, issue, field, values); err != nil {
+		return err
+	}
+
+	notify_service.IssueChangeFields(ctx, doer, issue, field)
+	return nil
+}
+
+// ValidateFieldValuesByName checks that the values of custom fields given by the names of the fields are valid for the
+// issues of a repository
+func ValidateFieldValuesByName(ctx context.Context, repo *repo_model.Repository, values map[string][]string) error {
+	_, err := getFieldsByName(ctx, repo, values)
+	return err
+}
+
+func getFieldsByName(ctx context.Context, repo *repo_model.Repository, values map[string][]string) (map[*issues_model.IssueField][]string, error) {
+	fields := make(map[*issues_model.IssueField][]string, len(values))
+	for name, v := range values {
+		field, err := issues_model.GetIssueFieldForRepoByName(ctx, repo.ID, repo.OwnerID, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := field.NormalizeValues(ctx, repo, v); err != nil {
+			return nil, err
+		}
+		fields[field] = v
+	}
+	return fields, nil
+}
+
+// SetFieldValuesByName sets the values of the custom fields of an issue by the names of the fields, like they are
+// given by issue templates and when issues are created with the API. The values are validated before any is set.
+func SetFieldValuesByName(ctx context.Context, issue *issues_model.Issue, doer *user_model.User, values map[string][]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if err := issue.LoadRepo(ctx); err != nil {
+		return err
+	}
+
+	fields, err := getFieldsByName(ctx, issue.Repo, values)
+	if err != nil {
+		return err
+	}
+	for field, v := range fields {
+		if err := SetFieldValues(ctx, issue, doer, field, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseFieldFilters parses filters of issues by the values of their custom fields, formatted as
+// "<field id or name>:<value>", and returns them in the format the issue indexer uses
+func ParseFieldFilters(ctx context.Context, repo *repo_model.Repository, filters []string) ([]string, error) {
+	fieldValues := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, ":")
+		if !ok {
+			return nil, util.NewInvalidArgumentErrorf("invalid field filter %q", filter)
+		}
+
+		var field *issues_model.IssueField
+		var err error
+		if id, parseErr := strconv.ParseInt(key, 10, 64); parseErr == nil {
+			field, err = issues_model.GetIssueFieldForRepoByID(ctx, repo.ID, repo.OwnerID, id)
+		} else {
+			field, err = issues_model.GetIssueFieldForRepoByName(ctx, repo.ID, repo.OwnerID, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := field.NormalizeValues(ctx, nil, []string{value})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			fieldValues = append(fieldValues, issues_model.FormatFieldValue(field.ID, v))
+		}
+	}
+	return fieldValues, nil
+}
@@ -32,5 +32,11 @@ func ChangeStatus(ctx context.Context, issue *issues_model.Issue, doer *user_mod
 
 	notify_service.IssueChangeStatus(ctx, doer, commitID, issue, comment, closed)
 
+	if closed && !issue.IsPull {
+		if err := closeParentIfSubIssuesClosed(ctx, issue, doer); err != nil {
+			log.Error("Unable to close the parent of issue[%d]#%d: %v", issue.ID, issue.Index, err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,37 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package issue
+
+import (
+	"context"
+
+	issues_model "code.gitea.io/gitea/models/issues"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// closeParentIfSubIssuesClosed closes the parent of a closed issue if the parent is closed together with its
+// sub-issues and the issue was its last open sub-issue. Closing the parent may close its own parent in turn.
+func closeParentIfSubIssuesClosed(ctx context.Context, issue *issues_model.Issue, doer *user_model.User) error {
+	parent, err := issues_model.GetParentIssue(ctx, issue.ID)
+	if err != nil || parent == nil {
+		return err
+	}
+	if parent.IsClosed || !parent.CloseWithSubIssues {
+		return nil
+	}
+
+	if open, err := issues_model.HasOpenSubIssues(ctx, parent.ID); err != nil || open {
+		return err
+	}
+
+	if err := ChangeStatus(ctx, parent, doer, "", true); err != nil {
+		if issues_model.IsErrDependenciesLeft(err) {
+			log.Debug("Not closing issue[%d] with its sub-issues: it has open dependencies", parent.ID)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -526,6 +526,11 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 		return accessMode <= perm.AccessModeWrite
 	}
 
+	// a personal access token restricted to other repositories has no access to the repository
+	if !ctx.AccessTokenAllowsRepo(repository) {
+		return false
+	}
+
 	// ctx.IsSigned is unnecessary here, this will be checked in perm.CanAccess
 	perm, err := access_model.GetUserRepoPermission(ctx, repository, ctx.Doer)
 	if err != nil {
@@ -535,7 +540,7 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 
 	canRead := perm.CanAccess(accessMode, unit.TypeCode)
 	if canRead && (!requireSigned || ctx.IsSigned) {
-		return true
+		return orgAuthPolicyAllows(ctx, repository)
 	}
 
 	user, err := parseToken(ctx, authorization, repository, accessMode)
@@ -545,6 +550,25 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 		return false
 	}
 	ctx.Doer = user
+	return orgAuthPolicyAllows(ctx, repository)
+}
+
+// orgAuthPolicyAllows returns false if the doer is a member of the organization which owns the repository and does not
+// fulfil its authentication policy after the grace period
+func orgAuthPolicyAllows(ctx *context.Context, repository *repo_model.Repository) bool {
+	if err := repository.LoadOwner(ctx); err != nil {
+		log.Error("Unable to load the owner of repo %-v Error: %v", repository, err)
+		return false
+	}
+	policy, violation, err := context.OrgAuthPolicyViolation(ctx, repository.Owner, ctx.Doer, ctx.Data)
+	if err != nil {
+		log.Error("Unable to check the authentication policy of %-v for user %-v Error: %v", repository.Owner, ctx.Doer, err)
+		return false
+	}
+	if violation != nil && !policy.InGracePeriod() {
+		log.Warn("Authentication failure for user %-v: %s", ctx.Doer, context.OrgAuthPolicyMessage(repository.Owner, violation))
+		return false
+	}
 	return true
 }
 
@@ -0,0 +1,292 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package mergequeue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/sync"
+	notify_service "code.gitea.io/gitea/services/notify"
+	pull_service "code.gitea.io/gitea/services/pull"
+)
+
+// Reasons for removing a pull request from the merge queue, they are stored in the timeline comment
+const (
+	reasonMergeCommitFailed = "the merge commit could not be created"
+	reasonChecksFailed      = "the required status checks failed"
+	reasonMergeFailed       = "the base branch could not be fast-forwarded"
+	reasonQueueDisabled     = "the merge queue of the base branch was disabled"
+	reasonNewCommits        = "new commits were pushed"
+)
+
+// mergeQueue represents a queue of the base branches whose merge queue has to be processed
+var mergeQueue *queue.WorkerPoolQueue[string]
+
+// mergeQueueWorkingPool prevents the same merge queue from being processed concurrently
+var mergeQueueWorkingPool = sync.NewExclusivePool()
+
+// Init runs the task queue that processes the merge queues
+func Init() error {
+	notify_service.RegisterNotifier(NewNotifier())
+
+	mergeQueue = queue.CreateUniqueQueue(graceful.GetManager().ShutdownContext(), "pr_merge_queue", handler)
+	if mergeQueue == nil {
+		return fmt.Errorf("unable to create pr_merge_queue queue")
+	}
+	go graceful.GetManager().RunWithCancel(mergeQueue)
+	return nil
+}
+
+// handle passed repository IDs and branches and process their merge queue
+func handler(items ...string) []string {
+	for _, s := range items {
+		id, branch, ok := strings.Cut(s, ":")
+		repoID, err := strconv.ParseInt(id, 10, 64)
+		if !ok || err != nil {
+			log.Error("could not parse data from pr_merge_queue queue (%v)", s)
+			continue
+		}
+		handleMergeQueue(repoID, branch)
+	}
+	return nil
+}
+
+func addToQueue(repoID int64, branch string) {
+	log.Trace("Adding the merge queue of branch %s in repo %d to the merge queue processing queue", branch, repoID)
+	if err := mergeQueue.Push(fmt.Sprintf("%d:%s", repoID, branch)); err != nil {
+		log.Error("Error adding the merge queue of branch %s in repo %d to the merge queue processing queue: %v", branch, repoID, err)
+	}
+}
+
+// IsMergeQueueEnabled returns whether pull requests into the base branch of pr have to go through the merge queue
+func IsMergeQueueEnabled(ctx context.Context, pr *issues_model.PullRequest) (bool, error) {
+	pb, err := git_model.GetFirstMatchProtectedBranchRule(ctx, pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		return false, err
+	}
+	return pb != nil && pb.EnableMergeQueue, nil
+}
+
+// AddToMergeQueue adds a pull request to the merge queue of its base branch.
+// If added is false and no error is returned, the base branch has no merge queue and the pull request can be merged directly.
+func AddToMergeQueue(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, style repo_model.MergeStyle, message string) (added bool, err error) {
+	if enabled, err := IsMergeQueueEnabled(ctx, pr); err != nil || !enabled {
+		return false, err
+	}
+
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return false, err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(ctx, unit.TypePullRequests)
+	if err != nil {
+		return false, err
+	}
+	if style == repo_model.MergeStyleManuallyMerged || !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return false, models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := pull_model.AddToMergeQueue(ctx, doer, pr.BaseRepoID, pr.BaseBranch, pr.ID, style, message); err != nil {
+			return err
+		}
+
+		_, err := issues_model.CreateMergeQueueComment(ctx, issues_model.CommentTypePRAddedToMergeQueue, pr, doer, "")
+		return err
+	}); err != nil {
+		return false, err
+	}
+
+	addToQueue(pr.BaseRepoID, pr.BaseBranch)
+	return true, nil
+}
+
+// RemoveFromMergeQueue removes a pull request from the merge queue of its base branch,
+// the entries behind it are tested again without it.
+func RemoveFromMergeQueue(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, reason string) error {
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if err := pull_model.RemoveFromMergeQueue(ctx, pr.ID); err != nil {
+			return err
+		}
+
+		_, err := issues_model.CreateMergeQueueComment(ctx, issues_model.CommentTypePRRemovedFromMergeQueue, pr, doer, reason)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if err := pull_service.RemoveMergeQueueRef(ctx, pr); err != nil {
+		log.Error("RemoveMergeQueueRef[%-v]: %v", pr, err)
+	}
+
+	addToQueue(pr.BaseRepoID, pr.BaseBranch)
+	return nil
+}
+
+// StartMergeQueueCheckBySHA starts processing the merge queues that test the given commit
+func StartMergeQueueCheckBySHA(ctx context.Context, sha string, repo *repo_model.Repository) error {
+	entries, err := pull_model.GetMergeQueueEntriesByCommitID(ctx, repo.ID, sha)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		addToQueue(entry.RepoID, entry.BaseBranch)
+	}
+	return nil
+}
+
+// StartMergeQueueCheck starts processing the merge queue of a branch
+func StartMergeQueueCheck(repoID int64, branch string) {
+	addToQueue(repoID, branch)
+}
+
+func handleMergeQueue(repoID int64, branch string) {
+	ctx, _, finished := process.GetManager().AddContext(graceful.GetManager().HammerContext(),
+		fmt.Sprintf("Handle merge queue of branch %s in repo %d", branch, repoID))
+	defer finished()
+
+	key := fmt.Sprintf("%d:%s", repoID, branch)
+	mergeQueueWorkingPool.CheckIn(key)
+	defer mergeQueueWorkingPool.CheckOut(key)
+
+	if err := processMergeQueue(ctx, repoID, branch); err != nil {
+		log.Error("processMergeQueue[%d:%s]: %v", repoID, branch, err)
+	}
+}
+
+// processMergeQueue walks through the merge queue of a branch in order. Every entry is tested with a
+// speculative merge commit on top of the commit of the entry ahead of it, the first entry is tested on
+// top of the branch. Entries whose commit is outdated are rebuilt, entries whose required status checks
+// failed are ejected and the branch is fast-forwarded to the passing entries at the front of the queue.
+func processMergeQueue(ctx context.Context, repoID int64, branch string) error {
+	entries, err := pull_model.GetMergeQueue(ctx, repoID, branch)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	repo, err := repo_model.GetRepositoryByID(ctx, repoID)
+	if err != nil {
+		return err
+	}
+
+	pb, err := git_model.GetFirstMatchProtectedBranchRule(ctx, repoID, branch)
+	if err != nil {
+		return err
+	}
+
+	gitRepo, err := gitrepo.OpenRepository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	baseCommitID, err := gitRepo.GetBranchCommitID(branch)
+	if err != nil {
+		return err
+	}
+
+	// entries at the front of the queue are merged as soon as their checks passed
+	mergeable := true
+	for _, entry := range entries {
+		pr, err := issues_model.GetPullRequestByID(ctx, entry.PullID)
+		if err != nil {
+			return err
+		}
+		pr.BaseRepo = repo
+		if err := pr.LoadIssue(ctx); err != nil {
+			return err
+		}
+		if err := entry.LoadDoer(ctx); err != nil {
+			return err
+		}
+
+		if pr.HasMerged || pr.Issue.IsClosed || pr.BaseBranch != branch {
+			if err := pull_model.RemoveFromMergeQueue(ctx, pr.ID); err != nil && !db.IsErrNotExist(err) {
+				return err
+			}
+			if err := pull_service.RemoveMergeQueueRef(ctx, pr); err != nil {
+				log.Error("RemoveMergeQueueRef[%-v]: %v", pr, err)
+			}
+			continue
+		}
+
+		if pb == nil || !pb.EnableMergeQueue {
+			if err := RemoveFromMergeQueue(ctx, entry.Doer, pr, reasonQueueDisabled); err != nil && !db.IsErrNotExist(err) {
+				return err
+			}
+			continue
+		}
+
+		headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsTesting() || entry.BaseCommitID != baseCommitID || entry.HeadCommitID != headCommitID {
+			entry.CommitID, entry.HeadCommitID, err = pull_service.CreateMergeQueueCommit(ctx, pr, entry.Doer, entry.MergeStyle, entry.Message, baseCommitID)
+			if err != nil {
+				log.Warn("Unable to create the merge queue commit of %-v: %v", pr, err)
+				if err := RemoveFromMergeQueue(ctx, entry.Doer, pr, reasonMergeCommitFailed); err != nil && !db.IsErrNotExist(err) {
+					return err
+				}
+				continue
+			}
+			entry.BaseCommitID = baseCommitID
+			if err := pull_model.UpdateMergeQueueEntryCommits(ctx, entry); err != nil {
+				return err
+			}
+
+			notify_service.MergeQueueChecksRequested(ctx, entry.Doer, pr, git.RefNameFromMergeQueue(branch, pr.Index), entry.BaseCommitID, entry.CommitID)
+
+			mergeable = false
+			baseCommitID = entry.CommitID
+			continue
+		}
+
+		state, err := pull_service.GetMergeQueueCommitStatusState(ctx, pb, repoID, entry.CommitID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case state.IsFailure() || state.IsError():
+			if err := RemoveFromMergeQueue(ctx, entry.Doer, pr, reasonChecksFailed); err != nil && !db.IsErrNotExist(err) {
+				return err
+			}
+		case state.IsSuccess() && mergeable:
+			if err := pull_service.MergeQueueCommit(ctx, pr, entry.Doer, entry.CommitID); err != nil {
+				log.Warn("Unable to fast-forward %s in %-v to the merge queue commit %s of %-v: %v", branch, repo, entry.CommitID, pr, err)
+				if err := RemoveFromMergeQueue(ctx, entry.Doer, pr, reasonMergeFailed); err != nil && !db.IsErrNotExist(err) {
+					return err
+				}
+				continue
+			}
+			if err := pull_service.RemoveMergeQueueRef(ctx, pr); err != nil {
+				log.Error("RemoveMergeQueueRef[%-v]: %v", pr, err)
+			}
+			baseCommitID = entry.CommitID
+		default:
+			mergeable = false
+			baseCommitID = entry.CommitID
+		}
+	}
+	return nil
+}
@@ -152,6 +152,13 @@ func PullReviewDismiss(ctx context.Context, doer *user_model.User, review *issue
 	}
 }
 
+// MergeQueueChecksRequested notifies when the speculative merge commit of a pull request in a merge queue has been created
+func MergeQueueChecksRequested(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, refFullName git.RefName, baseCommitID, commitID string) {
+	for _, notifier := range notifiers {
+		notifier.MergeQueueChecksRequested(ctx, doer, pr, refFullName, baseCommitID, commitID)
+	}
+}
+
 // UpdateComment notifies update comment to notifiers
 func UpdateComment(ctx context.Context, doer *user_model.User, c *issues_model.Comment, oldContent string) {
 	for _, notifier := range notifiers {
@@ -249,6 +256,13 @@ func IssueChangeLabels(ctx context.Context, doer *user_model.User, issue *issues
 	}
 }
 
+// IssueChangeFields notifies change of the values of a custom field to notifiers
+func IssueChangeFields(ctx context.Context, doer *user_model.User, issue *issues_model.Issue, field *issues_model.IssueField) {
+	for _, notifier := range notifiers {
+		notifier.IssueChangeFields(ctx, doer, issue, field)
+	}
+}
+
 // CreateRepository notifies create repository to notifiers
 func CreateRepository(ctx context.Context, doer, u *user_model.User, repo *repo_model.Repository) {
 	for _, notifier := range notifiers {
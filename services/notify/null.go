@@ -76,6 +76,10 @@ func (*NullNotifier) PullRequestPushCommits(ctx context.Context, doer *user_mode
 func (*NullNotifier) PullReviewDismiss(ctx context.Context, doer *user_model.User, review *issues_model.Review, comment *issues_model.Comment) {
 }
 
+// MergeQueueChecksRequested places a place holder function
+func (*NullNotifier) MergeQueueChecksRequested(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, refFullName git.RefName, baseCommitID, commitID string) {
+}
+
 // UpdateComment places a place holder function
 func (*NullNotifier) UpdateComment(ctx context.Context, doer *user_model.User, c *issues_model.Comment, oldContent string) {
 }
@@ -141,6 +145,10 @@ func (*NullNotifier) IssueChangeLabels(ctx context.Context, doer *user_model.Use
 	addedLabels, removedLabels []*issues_model.Label) {
 }
 
+// IssueChangeFields places a place holder function
+func (*NullNotifier) IssueChangeFields(ctx context.Context, doer *user_model.User, issue *issues_model.Issue, field *issues_model.IssueField) {
+}
+
 // CreateRepository places a place holder function
 func (*NullNotifier) CreateRepository(ctx context.Context, doer, u *user_model.User, repo *repo_model.Repository) {
 }
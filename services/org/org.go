@@ -9,6 +9,8 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
 	org_model "code.gitea.io/gitea/models/organization"
 	packages_model "code.gitea.io/gitea/models/packages"
 	repo_model "code.gitea.io/gitea/models/repo"
@@ -48,6 +50,14 @@ func DeleteOrganization(ctx context.Context, org *org_model.Organization, purge
 		return models.ErrUserOwnPackages{UID: org.ID}
 	}
 
+	if _, err := db.DeleteByBean(ctx, &git_model.OrgRuleset{OrgID: org.ID}); err != nil {
+		return fmt.Errorf("DeleteOrgRulesets: %w", err)
+	}
+
+	if err := issues_model.DeleteIssueFieldsByOrgID(ctx, org.ID); err != nil {
+		return fmt.Errorf("DeleteIssueFieldsByOrgID: %w", err)
+	}
+
 	if err := org_model.DeleteOrganization(ctx, org); err != nil {
 		return fmt.Errorf("DeleteOrganization: %w", err)
 	}
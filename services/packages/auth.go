@@ -4,11 +4,17 @@
 package packages
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/perm"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -16,21 +22,126 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ContainerTokenLifetime is the lifetime of the access tokens of the container registry
+const ContainerTokenLifetime = 24 * time.Hour
+
 type packageClaims struct {
 	jwt.RegisteredClaims
 	UserID int64
+	// Access limits a container registry token to the images it lists, the token is not limited if it is nil
+	Access []*ContainerAccess `json:",omitempty"`
+	// ActionsTaskID is the task whose token was exchanged for a container registry token
+	ActionsTaskID int64 `json:",omitempty"`
+	// Scope is the scope of the API token which was exchanged for a container registry token
+	Scope auth_model.AccessTokenScope `json:",omitempty"`
+	// AccessTokenID is the personal access token which was exchanged for a container registry token, the token is
+	// revoked with it
+	AccessTokenID int64 `json:",omitempty"`
+	// Refresh is true for the refresh tokens of the container registry, which can only be exchanged for access tokens
+	Refresh bool `json:",omitempty"`
+	// Credential binds a refresh token to the password of the user, changing the password revokes the token
+	Credential string `json:",omitempty"`
 }
 
-func CreateAuthorizationToken(u *user_model.User) (string, error) {
-	now := time.Now()
+// ContainerAccess is an entry of the scope of a container registry token as described by the Docker token
+// specification, e.g. repository:owner/image:pull,push
+type ContainerAccess struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
 
-	claims := packageClaims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(24 * time.Hour)),
-			NotBefore: jwt.NewNumericDate(now),
-		},
-		UserID: u.ID,
+// ContainerToken is a parsed access token of the container registry
+type ContainerToken struct {
+	UserID        int64
+	Access        []*ContainerAccess
+	ActionsTaskID int64
+	// Scope and AccessTokenID are the scope and the ID of the API token the token was exchanged for, if any
+	Scope         auth_model.AccessTokenScope
+	AccessTokenID int64
+
+	credential string
+}
+
+// ParseContainerScope parses the space separated scopes of a token request, it ignores the scopes which are neither
+// repository scopes nor the registry:catalog:* scope, such as the "*" scope of the authentication challenge. It
+// returns nil if there is no such scope, in which case the token must not be limited.
+func ParseContainerScope(scopes ...string) []*ContainerAccess {
+	var access []*ContainerAccess
+	for _, scope := range scopes {
+		for _, s := range strings.Fields(scope) {
+			parts := strings.Split(s, ":")
+			if len(parts) != 3 || parts[1] == "" {
+				continue
+			}
+			if parts[0] == "registry" && parts[1] == "catalog" && parts[2] == "*" {
+				access = append(access, &ContainerAccess{Type: parts[0], Name: parts[1], Actions: []string{parts[2]}})
+				continue
+			}
+			if parts[0] != "repository" {
+				continue
+			}
+			a := &ContainerAccess{
+				Type: parts[0],
+				Name: strings.ToLower(parts[1]),
+			}
+			for _, action := range strings.Split(parts[2], ",") {
+				switch action {
+				case "pull", "push", "delete", "*":
+					if !slices.Contains(a.Actions, action) {
+						a.Actions = append(a.Actions, action)
+					}
+				}
+			}
+			access = append(access, a)
+		}
+	}
+	return access
+}
+
+// String returns the scope of the entry
+func (a *ContainerAccess) String() string {
+	return a.Type + ":" + a.Name + ":" + strings.Join(a.Actions, ",")
+}
+
+// AccessMode returns the access the actions of the entry grant
+func (a *ContainerAccess) AccessMode() perm.AccessMode {
+	mode := perm.AccessModeNone
+	for _, action := range a.Actions {
+		switch action {
+		case "pull":
+			mode = max(mode, perm.AccessModeRead)
+		case "push", "delete", "*":
+			mode = max(mode, perm.AccessModeWrite)
+		}
+	}
+	return mode
+}
+
+// AccessMode returns the access the token grants to an image named owner/image, it is the owner access for a token
+// which is not limited to some images
+func (t *ContainerToken) AccessMode(name string) perm.AccessMode {
+	if t.Access == nil {
+		return perm.AccessModeOwner
+	}
+	name = strings.ToLower(name)
+	mode := perm.AccessModeNone
+	for _, a := range t.Access {
+		if a.Type == "repository" && a.Name == name {
+			mode = max(mode, a.AccessMode())
+		}
 	}
+	return mode
+}
+
+// CanListCatalog returns true if the token may list the images of the registry
+func (t *ContainerToken) CanListCatalog() bool {
+	return t.Access == nil || slices.ContainsFunc(t.Access, func(a *ContainerAccess) bool {
+		return a.Type == "registry" && a.Name == "catalog"
+	})
+}
+
+func signPackageClaims(claims *packageClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	tokenString, err := token.SignedString(setting.GetGeneralTokenSigningSecret())
@@ -41,32 +152,136 @@ func CreateAuthorizationToken(u *user_model.User) (string, error) {
 	return tokenString, nil
 }
 
-func ParseAuthorizationToken(req *http.Request) (int64, error) {
+func parsePackageClaims(tokenString string) (*packageClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &packageClaims{}, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return setting.GetGeneralTokenSigningSecret(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := token.Claims.(*packageClaims)
+	if !token.Valid || !ok {
+		return nil, fmt.Errorf("invalid token claim")
+	}
+	return c, nil
+}
+
+func CreateAuthorizationToken(u *user_model.User) (string, error) {
+	return CreateContainerToken(&ContainerToken{UserID: u.ID})
+}
+
+// CreateContainerToken creates an access token of the container registry for the user or, if ActionsTaskID is not 0,
+// the Actions task, which is limited to Access if it is not nil and to the API token it was exchanged for
+func CreateContainerToken(t *ContainerToken) (string, error) {
+	now := time.Now()
+
+	return signPackageClaims(&packageClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ContainerTokenLifetime)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		UserID:        t.UserID,
+		Access:        t.Access,
+		ActionsTaskID: t.ActionsTaskID,
+		Scope:         t.Scope,
+		AccessTokenID: t.AccessTokenID,
+	})
+}
+
+// CreateContainerRefreshToken creates a refresh token of the container registry for the user, which is limited to the
+// API token it was exchanged for. It expires with the refresh tokens of OAuth2 or when the password of the user changes.
+func CreateContainerRefreshToken(u *user_model.User, t *ContainerToken) (string, error) {
+	now := time.Now()
+
+	return signPackageClaims(&packageClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(setting.OAuth2.RefreshTokenExpirationTime) * time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+		UserID:        u.ID,
+		Scope:         t.Scope,
+		AccessTokenID: t.AccessTokenID,
+		Refresh:       true,
+		Credential:    containerRefreshCredential(u),
+	})
+}
+
+// containerRefreshCredential derives the value binding a refresh token to the password of the user, the keyed hash
+// does not disclose anything about the password
+func containerRefreshCredential(u *user_model.User) string {
+	mac := hmac.New(sha256.New, setting.GetGeneralTokenSigningSecret())
+	_, _ = mac.Write([]byte(u.Passwd))
+	_, _ = mac.Write([]byte(u.Salt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseContainerRefreshToken parses a refresh token of the container registry, the caller has to check that it is
+// still bound to the credentials of the user with IsBoundTo
+func ParseContainerRefreshToken(tokenString string) (*ContainerToken, error) {
+	c, err := parsePackageClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Refresh {
+		return nil, fmt.Errorf("not a refresh token")
+	}
+	return &ContainerToken{
+		UserID:        c.UserID,
+		Scope:         c.Scope,
+		AccessTokenID: c.AccessTokenID,
+		credential:    c.Credential,
+	}, nil
+}
+
+// IsBoundTo returns true if the refresh token was issued to the user and the password of the user has not changed since
+func (t *ContainerToken) IsBoundTo(u *user_model.User) bool {
+	return t.UserID == u.ID && hmac.Equal([]byte(t.credential), []byte(containerRefreshCredential(u)))
+}
+
+// ParseContainerToken parses the access token of the container registry of the request, it returns nil if there is
+// none
+func ParseContainerToken(req *http.Request) (*ContainerToken, error) {
 	h := req.Header.Get("Authorization")
 	if h == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	parts := strings.SplitN(h, " ", 2)
 	if len(parts) != 2 {
 		log.Error("split token failed: %s", h)
-		return 0, fmt.Errorf("split token failed")
+		return nil, fmt.Errorf("split token failed")
 	}
 
-	token, err := jwt.ParseWithClaims(parts[1], &packageClaims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-		}
-		return setting.GetGeneralTokenSigningSecret(), nil
-	})
+	c, err := parsePackageClaims(parts[1])
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-
-	c, ok := token.Claims.(*packageClaims)
-	if !token.Valid || !ok {
-		return 0, fmt.Errorf("invalid token claim")
+	if c.Refresh {
+		return nil, fmt.Errorf("a refresh token is not an access token")
 	}
 
-	return c.UserID, nil
+	return &ContainerToken{
+		UserID:        c.UserID,
+		Access:        c.Access,
+		ActionsTaskID: c.ActionsTaskID,
+		Scope:         c.Scope,
+		AccessTokenID: c.AccessTokenID,
+	}, nil
+}
+
+// ParseAuthorizationToken returns the user of a package token, a container registry token which is limited to some
+// images or to an API token or was issued to an Actions task is not accepted
+func ParseAuthorizationToken(req *http.Request) (int64, error) {
+	t, err := ParseContainerToken(req)
+	if err != nil || t == nil {
+		return 0, err
+	}
+	if t.Access != nil || t.ActionsTaskID != 0 || t.Scope != "" || t.AccessTokenID != 0 {
+		return 0, fmt.Errorf("not a package token")
+	}
+	return t.UserID, nil
 }
@@ -400,5 +400,5 @@ func Init() error {
 
 	go graceful.GetManager().RunWithCancel(prPatchCheckerQueue)
 	go graceful.GetManager().RunWithShutdownContext(InitializePullRequests)
-	return nil
+	return initStackedPullRebaseQueue()
 }
@@ -0,0 +1,79 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"context"
+	"strings"
+
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+)
+
+// GetCodeOwnerApprovals returns the approval state of the code owner rules owning the files changed by a pull request.
+// The rules are read from the code owners file of the base branch of the pull request, as it is the branch protected.
+func GetCodeOwnerApprovals(ctx context.Context, pb *git_model.ProtectedBranch, pr *issues_model.PullRequest) ([]*issues_model.CodeOwnerApproval, error) {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := gitrepo.OpenRepository(ctx, pr.BaseRepo)
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	rules, _ := issues_model.GetCodeOwnersFromCommit(ctx, commit)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	// only the files changed by the pull request matter, not the ones changed on the base branch since it was created
+	mergeBase, _, err := gitRepo.GetMergeBase("", git.BranchPrefix+pr.BaseBranch, pr.GetGitRefName())
+	if err != nil {
+		return nil, err
+	}
+	changedFiles, err := gitRepo.GetFilesChangedBetween(mergeBase, pr.GetGitRefName())
+	if err != nil {
+		return nil, err
+	}
+
+	return issues_model.GetCodeOwnerApprovals(ctx, pb, pr, rules, changedFiles)
+}
+
+// GetMissingCodeOwnerApprovals returns the code owner rules owning files changed by a pull request that none of their
+// owners approved yet, if the protected branch requires code owner approval.
+func GetMissingCodeOwnerApprovals(ctx context.Context, pb *git_model.ProtectedBranch, pr *issues_model.PullRequest) ([]*issues_model.CodeOwnerApproval, error) {
+	if pb == nil || !pb.RequireCodeOwnerApproval {
+		return nil, nil
+	}
+
+	approvals, err := GetCodeOwnerApprovals(ctx, pb, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]*issues_model.CodeOwnerApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		if !approval.Approved {
+			missing = append(missing, approval)
+		}
+	}
+	return missing, nil
+}
+
+// formatMissingCodeOwnerApprovals lists the owner groups missing an approval, one of the owners of each group must approve
+func formatMissingCodeOwnerApprovals(missing []*issues_model.CodeOwnerApproval) string {
+	groups := make([]string, 0, len(missing))
+	for _, approval := range missing {
+		groups = append(groups, strings.Join(approval.Rule.Owners, " or "))
+	}
+	return strings.Join(groups, ", ")
+}
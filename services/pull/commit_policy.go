@@ -0,0 +1,120 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+)
+
+// commitPolicyViolationsLimit is the maximum number of violations reported for a single push
+const commitPolicyViolationsLimit = 10
+
+var signoffTrailerRegexp = regexp.MustCompile(`(?mi)^Signed-off-by:[^<\n]*<([^>\n]+)>\s*$`)
+
+// CommitPolicyViolation is a commit which does not comply with the commit policy of a protected branch
+type CommitPolicyViolation struct {
+	CommitID string
+	Reason   string
+}
+
+func (v *CommitPolicyViolation) String() string {
+	return fmt.Sprintf("%s: %s", base.ShortSha(v.CommitID), v.Reason)
+}
+
+// HasCommitPolicy returns true if the protected branch restricts the commits which can be pushed to it
+func HasCommitPolicy(pb *git_model.ProtectedBranch) bool {
+	return pb != nil && (pb.RequireLinearHistory || pb.RequireSignoff || pb.CommitMessagePattern != "")
+}
+
+// CheckCommitPolicy checks the commits from oldCommitID to newCommitID in the repository at repoPath against the
+// commit policy of the protected branch: no merge commits if a linear history is required, a Signed-off-by trailer of
+// the author (DCO) if sign-off is required, and messages matching the commit message pattern. If oldCommitID is empty
+// or the empty object ID, the commits of newCommitID which are not reachable from any other ref are checked.
+// At most commitPolicyViolationsLimit violations are returned.
+func CheckCommitPolicy(ctx context.Context, repoPath string, pb *git_model.ProtectedBranch, oldCommitID, newCommitID string, env []string) ([]*CommitPolicyViolation, error) {
+	if !HasCommitPolicy(pb) {
+		return nil, nil
+	}
+
+	messageRegexp, err := pb.GetCommitMessageRegexp()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := git.NewCommand(ctx, "log", "-z", "--format=%H%n%P%n%ae%n%B")
+	if git.IsEmptyCommitID(oldCommitID, nil) {
+		cmd.AddDynamicArguments(newCommitID).AddArguments("--not", "--all")
+	} else {
+		cmd.AddDynamicArguments(oldCommitID + ".." + newCommitID)
+	}
+	stdout, _, err := cmd.RunStdBytes(&git.RunOpts{Dir: repoPath, Env: env})
+	if err != nil {
+		return nil, fmt.Errorf("git log %s..%s: %w", oldCommitID, newCommitID, err)
+	}
+
+	var violations []*CommitPolicyViolation
+	for _, record := range bytes.Split(stdout, []byte{0}) {
+		fields := strings.SplitN(strings.TrimLeft(string(record), "\n"), "\n", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		commitID, parents, authorEmail := fields[0], strings.Fields(fields[1]), fields[2]
+		message := ""
+		if len(fields) == 4 {
+			message = strings.TrimSpace(fields[3])
+		}
+
+		if reason := checkCommitPolicy(pb, messageRegexp, parents, authorEmail, message); reason != "" {
+			violations = append(violations, &CommitPolicyViolation{CommitID: commitID, Reason: reason})
+			if len(violations) >= commitPolicyViolationsLimit {
+				break
+			}
+		}
+	}
+	return violations, nil
+}
+
+func checkCommitPolicy(pb *git_model.ProtectedBranch, messageRegexp *regexp.Regexp, parents []string, authorEmail, message string) string {
+	if pb.RequireLinearHistory && len(parents) > 1 {
+		return "merge commits are not allowed, the branch requires a linear history"
+	}
+	if pb.RequireSignoff && !hasSignoff(message, authorEmail) {
+		return fmt.Sprintf("missing Signed-off-by trailer of the author <%s>", authorEmail)
+	}
+	if messageRegexp != nil && !messageRegexp.MatchString(message) {
+		return fmt.Sprintf("commit message does not match the required pattern %s", messageRegexp.String())
+	}
+	return ""
+}
+
+func hasSignoff(message, email string) bool {
+	for _, match := range signoffTrailerRegexp.FindAllStringSubmatch(message, -1) {
+		if strings.EqualFold(strings.TrimSpace(match[1]), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatCommitPolicyViolations formats the violations of the commit policy of a branch, one commit per line
+func FormatCommitPolicyViolations(branchName string, violations []*CommitPolicyViolation) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "branch %s is protected by a commit policy which is violated by:", branchName)
+	for _, violation := range violations {
+		sb.WriteString("\n  ")
+		sb.WriteString(violation.String())
+	}
+	if len(violations) >= commitPolicyViolationsLimit {
+		sb.WriteString("\n  ...")
+	}
+	return sb.String()
+}
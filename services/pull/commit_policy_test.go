@@ -0,0 +1,92 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCommitPolicy(t *testing.T) {
+	ctx := git.DefaultContext
+	repoPath := t.TempDir()
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com",
+		"GIT_COMMITTER_NAME=Jane Doe", "GIT_COMMITTER_EMAIL=jane@example.com",
+	)
+	run := func(cmd *git.Command) string {
+		stdout, _, err := cmd.RunStdString(&git.RunOpts{Dir: repoPath, Env: env})
+		require.NoError(t, err)
+		return strings.TrimSpace(stdout)
+	}
+	commit := func(name, message string) string {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(name), 0o644))
+		run(git.NewCommand(ctx, "add").AddDynamicArguments(name))
+		run(git.NewCommand(ctx, "commit", "-m").AddDynamicArguments(message))
+		return run(git.NewCommand(ctx, "rev-parse", "HEAD"))
+	}
+
+	run(git.NewCommand(ctx, "init", "-b", "main"))
+	base := commit("a", "chore: initial commit\n\nSigned-off-by: Jane Doe <jane@example.com>")
+	run(git.NewCommand(ctx, "checkout", "-b", "feature"))
+	feature := commit("b", "feat: add b\n\nSigned-off-by: Jane Doe <JANE@example.com>")
+	run(git.NewCommand(ctx, "checkout", "main"))
+	commit("c", "fix c\n\nSigned-off-by: John Doe <john@example.com>")
+	run(git.NewCommand(ctx, "merge", "--no-ff", "-m", "Merge branch 'feature'", "feature"))
+	head := run(git.NewCommand(ctx, "rev-parse", "HEAD"))
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		violations, err := CheckCommitPolicy(ctx, repoPath, &git_model.ProtectedBranch{}, base, head, env)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("LinearHistory", func(t *testing.T) {
+		violations, err := CheckCommitPolicy(ctx, repoPath, &git_model.ProtectedBranch{RequireLinearHistory: true}, base, head, env)
+		require.NoError(t, err)
+		require.Len(t, violations, 1)
+		assert.Equal(t, head, violations[0].CommitID)
+		assert.Contains(t, violations[0].Reason, "linear history")
+
+		violations, err = CheckCommitPolicy(ctx, repoPath, &git_model.ProtectedBranch{RequireLinearHistory: true}, base, feature, env)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("Signoff", func(t *testing.T) {
+		violations, err := CheckCommitPolicy(ctx, repoPath, &git_model.ProtectedBranch{RequireSignoff: true}, base, head, env)
+		require.NoError(t, err)
+		// the merge commit has no trailer and the trailer of "fix c" is not the one of its author
+		assert.Len(t, violations, 2)
+		for _, violation := range violations {
+			assert.Contains(t, violation.Reason, "<jane@example.com>")
+		}
+	})
+
+	t.Run("MessagePattern", func(t *testing.T) {
+		pb := &git_model.ProtectedBranch{CommitMessagePattern: `^(feat|fix|chore)(\(.+\))?!?: .+`}
+		violations, err := CheckCommitPolicy(ctx, repoPath, pb, base, head, env)
+		require.NoError(t, err)
+		assert.Len(t, violations, 2)
+
+		// a new branch is checked from the commits which are not on other branches
+		violations, err = CheckCommitPolicy(ctx, repoPath, pb, git.Sha1ObjectFormat.EmptyObjectID().String(), feature, env)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+
+		_, err = CheckCommitPolicy(ctx, repoPath, &git_model.ProtectedBranch{CommitMessagePattern: "("}, base, head, env)
+		assert.Error(t, err)
+	})
+
+	msg := FormatCommitPolicyViolations("main", []*CommitPolicyViolation{{CommitID: head, Reason: "reason"}})
+	assert.Equal(t, "branch main is protected by a commit policy which is violated by:\n  "+head[:10]+": reason", msg)
+}
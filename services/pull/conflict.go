@@ -0,0 +1,620 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/typesniffer"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ConflictSide is the side of a conflict picked to resolve it
+type ConflictSide string
+
+// The sides of a conflict, "head" is the head branch of the pull request and "base" the base branch
+const (
+	ConflictSideHead ConflictSide = "head"
+	ConflictSideBase ConflictSide = "base"
+	ConflictSideBoth ConflictSide = "both" // the head version followed by the base version
+)
+
+// The labels of the conflict markers written by git merge-file
+const (
+	conflictMarkerHead     = "<<<<<<< head"
+	conflictMarkerAncestor = "||||||| ancestor"
+	conflictMarkerBase     = "======="
+	conflictMarkerEnd      = ">>>>>>> base"
+)
+
+// ErrInvalidConflictResolution represents a missing or invalid resolution of a conflicting file
+type ErrInvalidConflictResolution struct {
+	Path   string
+	Reason string
+}
+
+// IsErrInvalidConflictResolution checks if an error is an ErrInvalidConflictResolution.
+func IsErrInvalidConflictResolution(err error) bool {
+	_, ok := err.(ErrInvalidConflictResolution)
+	return ok
+}
+
+func (err ErrInvalidConflictResolution) Error() string {
+	return fmt.Sprintf("invalid conflict resolution [path: %s]: %s", err.Path, err.Reason)
+}
+
+func (err ErrInvalidConflictResolution) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ConflictFileVersion is the version of a conflicting file in one of the merged commits
+type ConflictFileVersion struct {
+	Mode    string
+	SHA     string
+	Content string // empty if the file is binary or too large to be displayed
+}
+
+// ConflictHunk is a part of a conflicting text file, either merged cleanly or conflicting
+type ConflictHunk struct {
+	Conflict bool
+	Content  string // the merged content of a hunk without conflict
+	Head     string
+	Ancestor string
+	Base     string
+}
+
+// ConflictFile is a file which can't be merged automatically
+type ConflictFile struct {
+	Path     string
+	Binary   bool                 // the file can only be resolved by picking a whole version or giving its content
+	Ancestor *ConflictFileVersion // the version in the merge base, nil if the file was added
+	Head     *ConflictFileVersion // nil if the file was deleted in the head branch
+	Base     *ConflictFileVersion // nil if the file was deleted in the base branch
+	Hunks    []*ConflictHunk      // nil if the file can't be merged line by line
+}
+
+// ConflictCount returns the number of conflicting hunks
+func (f *ConflictFile) ConflictCount() int {
+	count := 0
+	for _, hunk := range f.Hunks {
+		if hunk.Conflict {
+			count++
+		}
+	}
+	return count
+}
+
+// Merged returns the content of the file with conflict markers in place of the conflicting hunks
+func (f *ConflictFile) Merged() string {
+	var sb strings.Builder
+	for _, hunk := range f.Hunks {
+		if !hunk.Conflict {
+			sb.WriteString(hunk.Content)
+			continue
+		}
+		sb.WriteString(conflictMarkerHead + "\n")
+		sb.WriteString(hunk.Head)
+		sb.WriteString(conflictMarkerBase + "\n")
+		sb.WriteString(hunk.Base)
+		sb.WriteString(conflictMarkerEnd + "\n")
+	}
+	return sb.String()
+}
+
+// ConflictResolution is the resolution of a conflicting file, exactly one way of resolving it has to be given
+type ConflictResolution struct {
+	Path    string
+	Content *string        // the resolved content of the file
+	Delete  bool           // delete the file
+	Side    ConflictSide   // use the whole version of the file of one side, deleting it if it doesn't exist there
+	Hunks   []ConflictSide // the side picked for every conflicting hunk in order
+}
+
+// resolvedFile is the entry of a conflicting file in the index after the resolution
+type resolvedFile struct {
+	Delete  bool
+	Mode    string
+	SHA     string  // the object to use if the content is nil
+	Content *string // the content to write to a new object
+}
+
+func (f *ConflictFile) resolve(res *ConflictResolution) (*resolvedFile, error) {
+	ways := 0
+	for _, given := range []bool{res.Content != nil, res.Delete, res.Side != "", res.Hunks != nil} {
+		if given {
+			ways++
+		}
+	}
+	if ways != 1 {
+		return nil, ErrInvalidConflictResolution{Path: f.Path, Reason: "exactly one of content, delete, side or hunks is required"}
+	}
+
+	mode := "100644"
+	if f.Head != nil {
+		mode = f.Head.Mode
+	} else if f.Base != nil {
+		mode = f.Base.Mode
+	}
+
+	switch {
+	case res.Content != nil:
+		return &resolvedFile{Mode: mode, Content: res.Content}, nil
+	case res.Delete:
+		return &resolvedFile{Delete: true}, nil
+	case res.Side != "":
+		var version *ConflictFileVersion
+		switch res.Side {
+		case ConflictSideHead:
+			version = f.Head
+		case ConflictSideBase:
+			version = f.Base
+		default:
+			return nil, ErrInvalidConflictResolution{Path: f.Path, Reason: fmt.Sprintf("a whole file can't be resolved by %q", res.Side)}
+		}
+		if version == nil {
+			return &resolvedFile{Delete: true}, nil
+		}
+		return &resolvedFile{Mode: version.Mode, SHA: version.SHA}, nil
+	}
+
+	if f.Hunks == nil {
+		return nil, ErrInvalidConflictResolution{Path: f.Path, Reason: "the file can't be resolved by hunks"}
+	}
+	if count := f.ConflictCount(); len(res.Hunks) != count {
+		return nil, ErrInvalidConflictResolution{Path: f.Path, Reason: fmt.Sprintf("%d hunks are resolved but the file has %d conflicting hunks", len(res.Hunks), count)}
+	}
+
+	var sb strings.Builder
+	i := 0
+	for _, hunk := range f.Hunks {
+		if !hunk.Conflict {
+			sb.WriteString(hunk.Content)
+			continue
+		}
+		switch res.Hunks[i] {
+		case ConflictSideHead:
+			sb.WriteString(hunk.Head)
+		case ConflictSideBase:
+			sb.WriteString(hunk.Base)
+		case ConflictSideBoth:
+			sb.WriteString(hunk.Head)
+			sb.WriteString(hunk.Base)
+		default:
+			return nil, ErrInvalidConflictResolution{Path: f.Path, Reason: fmt.Sprintf("unknown side %q for hunk %d", res.Hunks[i], i+1)}
+		}
+		i++
+	}
+	content := sb.String()
+	return &resolvedFile{Mode: mode, Content: &content}, nil
+}
+
+// parseConflictHunks splits the output of git merge-file --diff3 into hunks
+func parseConflictHunks(merged string) []*ConflictHunk {
+	const (
+		stateClean = iota
+		stateHead
+		stateAncestor
+		stateBase
+	)
+
+	hunks := make([]*ConflictHunk, 0, 3)
+	current := &ConflictHunk{}
+	state := stateClean
+	for _, line := range strings.SplitAfter(merged, "\n") {
+		if line == "" {
+			continue
+		}
+		marker := strings.TrimRight(line, "\r\n")
+		switch {
+		case state == stateClean && marker == conflictMarkerHead:
+			if current.Content != "" {
+				hunks = append(hunks, current)
+			}
+			current = &ConflictHunk{Conflict: true}
+			state = stateHead
+		case state == stateHead && marker == conflictMarkerAncestor:
+			state = stateAncestor
+		case (state == stateHead || state == stateAncestor) && marker == conflictMarkerBase:
+			state = stateBase
+		case state == stateBase && marker == conflictMarkerEnd:
+			hunks = append(hunks, current)
+			current = &ConflictHunk{}
+			state = stateClean
+		case state == stateHead:
+			current.Head += line
+		case state == stateAncestor:
+			current.Ancestor += line
+		case state == stateBase:
+			current.Base += line
+		default:
+			current.Content += line
+		}
+	}
+	if current.Conflict || current.Content != "" {
+		hunks = append(hunks, current)
+	}
+	return hunks
+}
+
+// mergeBaseIntoHead merges the base branch of the pull request into its head branch in a temporary repository
+// without committing. The returned files are the conflicts of the merge in the index of the repository.
+func mergeBaseIntoHead(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User) (*mergeContext, context.CancelFunc, []*ConflictFile, error) {
+	if pr.Flow == issues_model.PullRequestFlowAGit {
+		// TODO: update of agit flow pull request's head branch is unsupported
+		return nil, nil, nil, util.NewInvalidArgumentErrorf("update of agit flow pull request's head branch is unsupported")
+	}
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return nil, nil, nil, fmt.Errorf("LoadBaseRepo: %w", err)
+	}
+	if err := pr.LoadHeadRepo(ctx); err != nil {
+		return nil, nil, nil, fmt.Errorf("LoadHeadRepo: %w", err)
+	}
+	if pr.HeadRepo == nil {
+		return nil, nil, nil, repo_model.ErrRepoNotExist{ID: pr.HeadRepoID}
+	}
+
+	// use merge functions but switch repos and branches
+	reversePR := &issues_model.PullRequest{
+		ID: pr.ID,
+
+		HeadRepoID: pr.BaseRepoID,
+		HeadRepo:   pr.BaseRepo,
+		HeadBranch: pr.BaseBranch,
+
+		BaseRepoID: pr.HeadRepoID,
+		BaseRepo:   pr.HeadRepo,
+		BaseBranch: pr.HeadBranch,
+	}
+
+	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, reversePR, doer, "", "")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cmd := git.NewCommand(ctx, "merge", "--no-ff", "--no-commit").AddDynamicArguments(trackingBranch)
+	if err := runMergeCommand(mergeCtx, repo_model.MergeStyleMerge, cmd); err == nil {
+		return mergeCtx, cancel, nil, nil
+	} else if !models.IsErrMergeConflicts(err) {
+		cancel()
+		return nil, nil, nil, err
+	}
+
+	files, err := readConflictFiles(mergeCtx)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	return mergeCtx, cancel, files, nil
+}
+
+// readConflictFiles reads the unmerged files from the index of the temporary repository
+func readConflictFiles(ctx *mergeContext) ([]*ConflictFile, error) {
+	gitRepo, err := git.OpenRepository(ctx, ctx.tmpBasePath)
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %w", err)
+	}
+	defer gitRepo.Close()
+
+	unmerged := make(chan *unmergedFile)
+	go unmergedFiles(ctx, ctx.tmpBasePath, unmerged)
+	defer func() {
+		for range unmerged {
+			// empty the unmerged channel
+		}
+	}()
+
+	var files []*ConflictFile
+	for file := range unmerged {
+		if file.err != nil {
+			return nil, file.err
+		}
+		conflictFile, err := newConflictFile(ctx, gitRepo, file)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, conflictFile)
+	}
+	return files, nil
+}
+
+func newConflictFile(ctx *mergeContext, gitRepo *git.Repository, file *unmergedFile) (*ConflictFile, error) {
+	conflictFile := &ConflictFile{}
+	for _, stage := range []struct {
+		line    *lsFileLine
+		version **ConflictFileVersion
+	}{
+		{file.stage1, &conflictFile.Ancestor},
+		{file.stage2, &conflictFile.Head},
+		{file.stage3, &conflictFile.Base},
+	} {
+		if stage.line == nil {
+			continue
+		}
+		conflictFile.Path = stage.line.path
+		version := &ConflictFileVersion{Mode: stage.line.mode, SHA: stage.line.sha}
+		*stage.version = version
+
+		if stage.line.mode != "100644" && stage.line.mode != "100755" {
+			conflictFile.Binary = true
+			continue
+		}
+		blob, err := gitRepo.GetBlob(stage.line.sha)
+		if err != nil {
+			return nil, fmt.Errorf("GetBlob[%s]: %w", stage.line.sha, err)
+		}
+		if blob.Size() > setting.UI.MaxDisplayFileSize {
+			conflictFile.Binary = true
+			continue
+		}
+		content, err := blob.GetBlobContent(setting.UI.MaxDisplayFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("GetBlobContent[%s]: %w", stage.line.sha, err)
+		}
+		if !typesniffer.DetectContentType([]byte(content)).IsText() {
+			conflictFile.Binary = true
+			continue
+		}
+		version.Content = content
+	}
+
+	if conflictFile.Binary {
+		for _, version := range []*ConflictFileVersion{conflictFile.Ancestor, conflictFile.Head, conflictFile.Base} {
+			if version != nil {
+				version.Content = ""
+			}
+		}
+		return conflictFile, nil
+	}
+	if conflictFile.Head == nil || conflictFile.Base == nil {
+		// modified in one branch but deleted in the other one
+		return conflictFile, nil
+	}
+
+	ancestor := ""
+	if conflictFile.Ancestor != nil {
+		ancestor = conflictFile.Ancestor.Content
+	}
+	merged, err := mergeFile(ctx, conflictFile.Head.Content, ancestor, conflictFile.Base.Content)
+	if err != nil {
+		return nil, fmt.Errorf("merge-file %s: %w", conflictFile.Path, err)
+	}
+	conflictFile.Hunks = parseConflictHunks(merged)
+	return conflictFile, nil
+}
+
+// mergeFile merges the three versions of a file with git merge-file and returns the result with diff3 conflict markers
+func mergeFile(ctx *mergeContext, head, ancestor, base string) (string, error) {
+	tmpDir, err := os.MkdirTemp(ctx.tmpBasePath, "merge-file-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = util.RemoveAll(tmpDir)
+	}()
+
+	for name, content := range map[string]string{"head": head, "ancestor": ancestor, "base": base} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600); err != nil {
+			return "", err
+		}
+	}
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	err = git.NewCommand(ctx, "merge-file", "-p", "--diff3", "-L", "head", "-L", "ancestor", "-L", "base").
+		AddDynamicArguments(filepath.Join(tmpDir, "head"), filepath.Join(tmpDir, "ancestor"), filepath.Join(tmpDir, "base")).
+		Run(&git.RunOpts{Dir: ctx.tmpBasePath, Stdout: stdout, Stderr: stderr})
+	// the exit code is the number of conflicts, negative on errors
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() < 0 || exitErr.ExitCode() > 127) {
+		return "", fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// GetConflictFiles returns the files conflicting when merging the base branch of the pull request into its head branch
+// and the commit ID of the head branch they have been computed for
+func GetConflictFiles(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User) (string, []*ConflictFile, error) {
+	mergeCtx, cancel, files, err := mergeBaseIntoHead(ctx, pr, doer)
+	if err != nil {
+		return "", nil, err
+	}
+	defer cancel()
+
+	headCommitID, err := git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, "original_"+baseBranch)
+	if err != nil {
+		return "", nil, fmt.Errorf("GetFullCommitID: %w", err)
+	}
+	return headCommitID, files, nil
+}
+
+// IsUserAllowedToResolveConflicts checks if the user is allowed to push the resolution of the conflicts of the pull
+// request to its head branch or, if newBranch is set, to a new branch of the head repository
+func IsUserAllowedToResolveConflicts(ctx context.Context, pr *issues_model.PullRequest, user *user_model.User, newBranch bool) (bool, error) {
+	if user == nil || pr.Flow == issues_model.PullRequestFlowAGit {
+		return false, nil
+	}
+	if err := pr.LoadHeadRepo(ctx); err != nil {
+		return false, err
+	}
+	if pr.HeadRepo == nil {
+		return false, nil
+	}
+
+	if !newBranch {
+		allowed, _, err := IsUserAllowedToUpdate(ctx, pr, user)
+		return allowed, err
+	}
+	perm, err := access_model.GetUserRepoPermission(ctx, pr.HeadRepo, user)
+	if err != nil {
+		return false, err
+	}
+	return perm.CanWrite(unit.TypeCode), nil
+}
+
+// ResolveConflictsOptions are the options to resolve the conflicts of a pull request
+type ResolveConflictsOptions struct {
+	Resolutions          []*ConflictResolution
+	Message              string
+	ExpectedHeadCommitID string // if set, the head branch must not have changed since the conflicts have been computed
+	NewBranch            string // if set, the resolution is committed on this new branch instead of the head branch
+}
+
+// ResolveConflicts merges the base branch of the pull request into its head branch using the given resolutions of
+// the conflicting files and pushes the merge commit to the head branch or a new branch of the head repository.
+// It returns the ID of the merge commit.
+func ResolveConflicts(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, opts *ResolveConflictsOptions) (string, error) {
+	pullWorkingPool.CheckIn(fmt.Sprint(pr.ID))
+	defer pullWorkingPool.CheckOut(fmt.Sprint(pr.ID))
+
+	if opts.NewBranch != "" {
+		if err := pr.LoadHeadRepo(ctx); err != nil {
+			return "", fmt.Errorf("LoadHeadRepo: %w", err)
+		}
+		if pr.HeadRepo == nil {
+			return "", repo_model.ErrRepoNotExist{ID: pr.HeadRepoID}
+		}
+		if !git.IsValidRefPattern(opts.NewBranch) {
+			return "", util.NewInvalidArgumentErrorf("invalid branch name: %s", opts.NewBranch)
+		}
+		if git.IsBranchExist(ctx, pr.HeadRepo.RepoPath(), opts.NewBranch) {
+			return "", git_model.ErrBranchAlreadyExists{BranchName: opts.NewBranch}
+		}
+	}
+
+	mergeCtx, cancel, files, err := mergeBaseIntoHead(ctx, pr, doer)
+	if err != nil {
+		return "", err
+	}
+	defer cancel()
+
+	if opts.ExpectedHeadCommitID != "" {
+		headCommitID, err := git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, "original_"+baseBranch)
+		if err != nil {
+			return "", fmt.Errorf("GetFullCommitID: %w", err)
+		}
+		if headCommitID != opts.ExpectedHeadCommitID {
+			return "", models.ErrSHADoesNotMatch{
+				GivenSHA:   opts.ExpectedHeadCommitID,
+				CurrentSHA: headCommitID,
+			}
+		}
+	}
+
+	if err := applyConflictResolutions(mergeCtx, files, opts.Resolutions); err != nil {
+		return "", err
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = fmt.Sprintf("Merge branch '%s' into %s", pr.BaseBranch, pr.HeadBranch)
+	}
+	if err := commitAndSignNoAuthor(mergeCtx, message); err != nil {
+		log.Error("%-v Unable to commit the resolved conflicts: %v", pr, err)
+		return "", err
+	}
+	commitID, err := git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get full commit id for the resolved conflicts: %w", err)
+	}
+
+	var headUser *user_model.User
+	if err := pr.HeadRepo.LoadOwner(ctx); err != nil {
+		if !user_model.IsErrUserNotExist(err) {
+			return "", err
+		}
+		headUser = doer
+	} else {
+		headUser = pr.HeadRepo.Owner
+	}
+
+	targetBranch, prID := pr.HeadBranch, pr.ID
+	if opts.NewBranch != "" {
+		// a new branch isn't part of the pull request
+		targetBranch, prID = opts.NewBranch, 0
+	}
+	mergeCtx.env = repo_module.FullPushingEnvironment(headUser, doer, pr.HeadRepo, pr.HeadRepo.Name, prID)
+	if prID != 0 {
+		mergeCtx.env = append(mergeCtx.env, repo_module.EnvPushTrigger+"="+string(repo_module.PushTriggerPRUpdateWithBase))
+	}
+
+	pushCmd := git.NewCommand(ctx, "push", "origin").AddDynamicArguments(baseBranch + ":" + git.BranchPrefix + targetBranch)
+	if err := pushCmd.Run(mergeCtx.RunOpts()); err != nil {
+		return "", pushErrorFromMergeContext(mergeCtx, err)
+	}
+	mergeCtx.outbuf.Reset()
+	mergeCtx.errbuf.Reset()
+
+	return commitID, nil
+}
+
+// applyConflictResolutions stages the resolutions of the conflicting files, every file has to be resolved
+func applyConflictResolutions(ctx *mergeContext, files []*ConflictFile, resolutions []*ConflictResolution) error {
+	resolutionsByPath := make(map[string]*ConflictResolution, len(resolutions))
+	for _, res := range resolutions {
+		if _, ok := resolutionsByPath[res.Path]; ok {
+			return ErrInvalidConflictResolution{Path: res.Path, Reason: "the file is resolved more than once"}
+		}
+		resolutionsByPath[res.Path] = res
+	}
+
+	gitRepo, err := git.OpenRepository(ctx, ctx.tmpBasePath)
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %w", err)
+	}
+	defer gitRepo.Close()
+
+	for _, file := range files {
+		res, ok := resolutionsByPath[file.Path]
+		if !ok {
+			return ErrInvalidConflictResolution{Path: file.Path, Reason: "the conflicts of the file are not resolved"}
+		}
+		delete(resolutionsByPath, file.Path)
+
+		resolved, err := file.resolve(res)
+		if err != nil {
+			return err
+		}
+		if resolved.Delete {
+			if err := gitRepo.RemoveFilesFromIndex(file.Path); err != nil {
+				return fmt.Errorf("RemoveFilesFromIndex[%s]: %w", file.Path, err)
+			}
+			continue
+		}
+
+		var objectID git.ObjectID
+		if resolved.Content != nil {
+			objectID, err = gitRepo.HashObject(strings.NewReader(*resolved.Content))
+		} else {
+			objectID, err = git.NewIDFromString(resolved.SHA)
+		}
+		if err != nil {
+			return fmt.Errorf("object of %s: %w", file.Path, err)
+		}
+		if err := gitRepo.AddObjectToIndex(resolved.Mode, objectID, file.Path); err != nil {
+			return fmt.Errorf("AddObjectToIndex[%s]: %w", file.Path, err)
+		}
+	}
+
+	for path := range resolutionsByPath {
+		return ErrInvalidConflictResolution{Path: path, Reason: "the file has no conflicts"}
+	}
+	return nil
+}
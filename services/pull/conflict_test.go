@@ -0,0 +1,183 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictHunks(t *testing.T) {
+	merged := "first\n" +
+		"<<<<<<< head\n" +
+		"head line\n" +
+		"||||||| ancestor\n" +
+		"ancestor line\n" +
+		"=======\n" +
+		"base line\n" +
+		">>>>>>> base\n" +
+		"middle\n" +
+		"<<<<<<< head\n" +
+		"||||||| ancestor\n" +
+		"=======\n" +
+		"added in base\n" +
+		">>>>>>> base\n"
+
+	hunks := parseConflictHunks(merged)
+	require.Len(t, hunks, 4)
+	assert.Equal(t, &ConflictHunk{Content: "first\n"}, hunks[0])
+	assert.Equal(t, &ConflictHunk{Conflict: true, Head: "head line\n", Ancestor: "ancestor line\n", Base: "base line\n"}, hunks[1])
+	assert.Equal(t, &ConflictHunk{Content: "middle\n"}, hunks[2])
+	assert.Equal(t, &ConflictHunk{Conflict: true, Base: "added in base\n"}, hunks[3])
+
+	file := &ConflictFile{
+		Path:  "README.md",
+		Head:  &ConflictFileVersion{Mode: "100755", SHA: "1111111111111111111111111111111111111111"},
+		Base:  &ConflictFileVersion{Mode: "100644", SHA: "2222222222222222222222222222222222222222"},
+		Hunks: hunks,
+	}
+	assert.Equal(t, 2, file.ConflictCount())
+	assert.Equal(t, "first\n<<<<<<< head\nhead line\n=======\nbase line\n>>>>>>> base\nmiddle\n<<<<<<< head\n=======\nadded in base\n>>>>>>> base\n", file.Merged())
+
+	t.Run("Hunks", func(t *testing.T) {
+		resolved, err := file.resolve(&ConflictResolution{Path: "README.md", Hunks: []ConflictSide{ConflictSideBoth, ConflictSideBase}})
+		require.NoError(t, err)
+		assert.Equal(t, "100755", resolved.Mode)
+		assert.Equal(t, "first\nhead line\nbase line\nmiddle\nadded in base\n", *resolved.Content)
+
+		_, err = file.resolve(&ConflictResolution{Path: "README.md", Hunks: []ConflictSide{ConflictSideHead}})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+		_, err = file.resolve(&ConflictResolution{Path: "README.md", Hunks: []ConflictSide{ConflictSideHead, "ancestor"}})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+	})
+
+	t.Run("Side", func(t *testing.T) {
+		resolved, err := file.resolve(&ConflictResolution{Path: "README.md", Side: ConflictSideBase})
+		require.NoError(t, err)
+		assert.Equal(t, &resolvedFile{Mode: "100644", SHA: "2222222222222222222222222222222222222222"}, resolved)
+
+		deleted := &ConflictFile{Path: "deleted", Base: file.Base, Binary: true}
+		resolved, err = deleted.resolve(&ConflictResolution{Path: "deleted", Side: ConflictSideHead})
+		require.NoError(t, err)
+		assert.True(t, resolved.Delete)
+
+		_, err = deleted.resolve(&ConflictResolution{Path: "deleted", Side: ConflictSideBoth})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+		_, err = deleted.resolve(&ConflictResolution{Path: "deleted", Hunks: []ConflictSide{}})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+	})
+
+	t.Run("Content", func(t *testing.T) {
+		content := "edited\n"
+		resolved, err := file.resolve(&ConflictResolution{Path: "README.md", Content: &content})
+		require.NoError(t, err)
+		assert.Equal(t, &resolvedFile{Mode: "100755", Content: &content}, resolved)
+
+		_, err = file.resolve(&ConflictResolution{Path: "README.md", Content: &content, Delete: true})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+		_, err = file.resolve(&ConflictResolution{Path: "README.md"})
+		assert.True(t, IsErrInvalidConflictResolution(err))
+	})
+}
+
+func TestReadAndApplyConflictFiles(t *testing.T) {
+	repoPath := t.TempDir()
+	env := append(os.Environ(),
+		"GIT_AUTHOR_NAME=Jane Doe", "GIT_AUTHOR_EMAIL=jane@example.com",
+		"GIT_COMMITTER_NAME=Jane Doe", "GIT_COMMITTER_EMAIL=jane@example.com",
+	)
+	ctx := &mergeContext{
+		prContext: &prContext{
+			Context:     git.DefaultContext,
+			tmpBasePath: repoPath,
+			outbuf:      &strings.Builder{},
+			errbuf:      &strings.Builder{},
+		},
+		env: env,
+	}
+	run := func(cmd *git.Command) string {
+		stdout, _, err := cmd.RunStdString(&git.RunOpts{Dir: repoPath, Env: env})
+		require.NoError(t, err)
+		return strings.TrimSpace(stdout)
+	}
+	commit := func(files map[string]string, message string) {
+		for name, content := range files {
+			if content == "" {
+				run(git.NewCommand(ctx, "rm", "--quiet").AddDynamicArguments(name))
+				continue
+			}
+			require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0o644))
+			run(git.NewCommand(ctx, "add").AddDynamicArguments(name))
+		}
+		run(git.NewCommand(ctx, "commit", "-m").AddDynamicArguments(message))
+	}
+
+	run(git.NewCommand(ctx, "init", "-b", baseBranch))
+	commit(map[string]string{"README.md": "a\nb\nc\n", "LICENSE": "MIT\n"}, "initial")
+	run(git.NewCommand(ctx, "checkout", "-b", trackingBranch))
+	commit(map[string]string{"README.md": "a\nB2\nc\n", "LICENSE": ""}, "base changes")
+	run(git.NewCommand(ctx, "checkout", baseBranch))
+	commit(map[string]string{"README.md": "a\nB1\nc\n", "LICENSE": "Apache\n"}, "head changes")
+
+	cmd := git.NewCommand(ctx, "merge", "--no-ff", "--no-commit").AddDynamicArguments(trackingBranch)
+	require.Error(t, runMergeCommand(ctx, repo_model.MergeStyleMerge, cmd))
+
+	files, err := readConflictFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	license, readme := files[0], files[1]
+	assert.Equal(t, "LICENSE", license.Path)
+	assert.Nil(t, license.Base)
+	assert.Equal(t, "Apache\n", license.Head.Content)
+	assert.Nil(t, license.Hunks)
+
+	assert.Equal(t, "README.md", readme.Path)
+	assert.Equal(t, []*ConflictHunk{
+		{Content: "a\n"},
+		{Conflict: true, Head: "B1\n", Ancestor: "b\n", Base: "B2\n"},
+		{Content: "c\n"},
+	}, readme.Hunks)
+
+	err = applyConflictResolutions(ctx, files, []*ConflictResolution{{Path: "README.md", Hunks: []ConflictSide{ConflictSideBoth}}})
+	assert.True(t, IsErrInvalidConflictResolution(err))
+
+	require.NoError(t, applyConflictResolutions(ctx, files, []*ConflictResolution{
+		{Path: "README.md", Hunks: []ConflictSide{ConflictSideBoth}},
+		{Path: "LICENSE", Side: ConflictSideBase},
+	}))
+	require.NoError(t, commitAndSignNoAuthor(ctx, "Merge branch 'base' into head"))
+
+	assert.Equal(t, "a\nB1\nB2\nc\n", run(git.NewCommand(ctx, "show", "HEAD:README.md"))+"\n")
+	assert.Equal(t, "", run(git.NewCommand(ctx, "ls-tree", "--name-only", "HEAD", "--", "LICENSE")))
+	assert.Len(t, strings.Fields(run(git.NewCommand(ctx, "log", "-1", "--format=%P"))), 2)
+}
+
+func TestGetConflictFilesAGit(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 2})
+	pr.Flow = issues_model.PullRequestFlowAGit
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 1})
+
+	allowed, err := IsUserAllowedToResolveConflicts(db.DefaultContext, pr, doer, false)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, _, err = GetConflictFiles(db.DefaultContext, pr, doer)
+	require.ErrorIs(t, err, util.ErrInvalidArgument)
+}
@@ -198,6 +198,11 @@ func Merge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.U
 		return err
 	}
 
+	return finishMerge(ctx, pr, doer, wasAutoMerged)
+}
+
+// finishMerge sends the notifications of a merged pull request and resolves its cross references
+func finishMerge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, wasAutoMerged bool) (err error) {
 	// reload pull request because it has been updated by post receive hook
 	pr, err = issues_model.GetPullRequestByID(ctx, pr.ID)
 	if err != nil {
@@ -224,6 +229,18 @@ func Merge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.U
 	// Reset cached commit count
 	cache.Remove(pr.Issue.Repo.GetCommitsCountCacheKey(pr.BaseBranch, true))
 
+	// Retarget the pull requests stacked on the merged one to its base branch if the repository rebases them,
+	// otherwise they are only retargeted when the head branch is deleted
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		log.Error("LoadBaseRepo %-v: %v", pr, err)
+	} else if prUnit, err := pr.BaseRepo.GetUnit(ctx, unit.TypePullRequests); err != nil {
+		log.Error("pr.BaseRepo.GetUnit(unit.TypePullRequests): %v", err)
+	} else if prUnit.PullRequestsConfig().RebaseStackedPullsOnMerge {
+		if err := RetargetChildrenOnMerge(ctx, doer, pr); err != nil {
+			log.Error("RetargetChildrenOnMerge %-v: %v", pr, err)
+		}
+	}
+
 	// Resolve cross references
 	refs, err := pr.ResolveCrossReferences(ctx)
 	if err != nil {
@@ -254,32 +271,15 @@ func Merge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.U
 // doMergeAndPush performs the merge operation without changing any pull information in database and pushes it up to the base repository
 func doMergeAndPush(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, mergeStyle repo_model.MergeStyle, expectedHeadCommitID, message string, pushTrigger repo_module.PushTrigger) (string, error) { //nolint:unparam
 	// Clone base repo.
-	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, pr, doer, expectedHeadCommitID)
+	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, pr, doer, expectedHeadCommitID, "")
 	if err != nil {
 		return "", err
 	}
 	defer cancel()
 
 	// Merge commits.
-	switch mergeStyle {
-	case repo_model.MergeStyleMerge:
-		if err := doMergeStyleMerge(mergeCtx, message); err != nil {
-			return "", err
-		}
-	case repo_model.MergeStyleRebase, repo_model.MergeStyleRebaseMerge:
-		if err := doMergeStyleRebase(mergeCtx, mergeStyle, message); err != nil {
-			return "", err
-		}
-	case repo_model.MergeStyleSquash:
-		if err := doMergeStyleSquash(mergeCtx, message); err != nil {
-			return "", err
-		}
-	case repo_model.MergeStyleFastForwardOnly:
-		if err := doMergeStyleFastForwardOnly(mergeCtx); err != nil {
-			return "", err
-		}
-	default:
-		return "", models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
+	if err := doMergeStyle(mergeCtx, mergeStyle, message); err != nil {
+		return "", err
 	}
 
 	// OK we should cache our current head and origin/headbranch
@@ -296,6 +296,22 @@ func doMergeAndPush(ctx context.Context, pr *issues_model.PullRequest, doer *use
 		return "", fmt.Errorf("Failed to get full commit id for the new merge: %w", err)
 	}
 
+	// The pre-receive hook would reject commits violating the commit policy of the base branch as well, check them
+	// beforehand to report which commits are affected
+	pb, err := git_model.GetFirstMatchProtectedBranchRule(ctx, pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		return "", fmt.Errorf("GetFirstMatchProtectedBranchRule: %w", err)
+	}
+	violations, err := CheckCommitPolicy(ctx, mergeCtx.tmpBasePath, pb, mergeBaseSHA, mergeCommitID, nil)
+	if err != nil {
+		return "", fmt.Errorf("CheckCommitPolicy: %w", err)
+	}
+	if len(violations) > 0 {
+		return "", models.ErrDisallowedToMerge{
+			Reason: FormatCommitPolicyViolations(pr.BaseBranch, violations),
+		}
+	}
+
 	// Now it's questionable about where this should go - either after or before the push
 	// I think in the interests of data safety - failures to push to the lfs should prevent
 	// the merge as you can always remerge.
@@ -333,22 +349,7 @@ func doMergeAndPush(ctx context.Context, pr *issues_model.PullRequest, doer *use
 	// This cause an api call to "/api/internal/hook/post-receive/...",
 	// If it's merge, all db transaction and operations should be there but not here to prevent deadlock.
 	if err := pushCmd.Run(mergeCtx.RunOpts()); err != nil {
-		if strings.Contains(mergeCtx.errbuf.String(), "non-fast-forward") {
-			return "", &git.ErrPushOutOfDate{
-				StdOut: mergeCtx.outbuf.String(),
-				StdErr: mergeCtx.errbuf.String(),
-				Err:    err,
-			}
-		} else if strings.Contains(mergeCtx.errbuf.String(), "! [remote rejected]") {
-			err := &git.ErrPushRejected{
-				StdOut: mergeCtx.outbuf.String(),
-				StdErr: mergeCtx.errbuf.String(),
-				Err:    err,
-			}
-			err.GenerateMessage()
-			return "", err
-		}
-		return "", fmt.Errorf("git push: %s", mergeCtx.errbuf.String())
+		return "", pushErrorFromMergeContext(mergeCtx, err)
 	}
 	mergeCtx.outbuf.Reset()
 	mergeCtx.errbuf.Reset()
@@ -356,6 +357,42 @@ func doMergeAndPush(ctx context.Context, pr *issues_model.PullRequest, doer *use
 	return mergeCommitID, nil
 }
 
+// pushErrorFromMergeContext converts the error of a push from the temporary repository using its output
+func pushErrorFromMergeContext(mergeCtx *mergeContext, err error) error {
+	if strings.Contains(mergeCtx.errbuf.String(), "non-fast-forward") {
+		return &git.ErrPushOutOfDate{
+			StdOut: mergeCtx.outbuf.String(),
+			StdErr: mergeCtx.errbuf.String(),
+			Err:    err,
+		}
+	} else if strings.Contains(mergeCtx.errbuf.String(), "! [remote rejected]") {
+		err := &git.ErrPushRejected{
+			StdOut: mergeCtx.outbuf.String(),
+			StdErr: mergeCtx.errbuf.String(),
+			Err:    err,
+		}
+		err.GenerateMessage()
+		return err
+	}
+	return fmt.Errorf("git push: %s", mergeCtx.errbuf.String())
+}
+
+// doMergeStyle merges the tracking branch into the base branch of the temporary repository
+func doMergeStyle(ctx *mergeContext, mergeStyle repo_model.MergeStyle, message string) error {
+	switch mergeStyle {
+	case repo_model.MergeStyleMerge:
+		return doMergeStyleMerge(ctx, message)
+	case repo_model.MergeStyleRebase, repo_model.MergeStyleRebaseMerge:
+		return doMergeStyleRebase(ctx, mergeStyle, message)
+	case repo_model.MergeStyleSquash:
+		return doMergeStyleSquash(ctx, message)
+	case repo_model.MergeStyleFastForwardOnly:
+		return doMergeStyleFastForwardOnly(ctx)
+	default:
+		return models.ErrInvalidMergeStyle{ID: ctx.pr.BaseRepo.ID, Style: mergeStyle}
+	}
+}
+
 func commitAndSignNoAuthor(ctx *mergeContext, message string) error {
 	cmdCommit := git.NewCommand(ctx, "commit").AddOptionFormat("--message=%s", message)
 	if ctx.signKeyID == "" {
@@ -467,6 +504,15 @@ func CheckPullBranchProtections(ctx context.Context, pr *issues_model.PullReques
 			Reason: "There are official review requests",
 		}
 	}
+	missingCodeOwnerApprovals, err := GetMissingCodeOwnerApprovals(ctx, pb, pr)
+	if err != nil {
+		return nil, fmt.Errorf("GetMissingCodeOwnerApprovals: %w", err)
+	}
+	if len(missingCodeOwnerApprovals) > 0 {
+		return pb, models.ErrDisallowedToMerge{
+			Reason: "Missing approvals from code owners: " + formatMissingCodeOwnerApprovals(missingCodeOwnerApprovals),
+		}
+	}
 
 	if issues_model.MergeBlockedByOutdatedBranch(pb, pr) {
 		return pb, models.ErrDisallowedToMerge{
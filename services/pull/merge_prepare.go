@@ -43,7 +43,9 @@ func (ctx *mergeContext) RunOpts() *git.RunOpts {
 	}
 }
 
-func createTemporaryRepoForMerge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, expectedHeadCommitID string) (mergeCtx *mergeContext, cancel context.CancelFunc, err error) {
+// createTemporaryRepoForMerge creates a temporary repository to merge the pull request in.
+// If baseCommitID is given, the pull request is merged onto this commit instead of the head of the base branch.
+func createTemporaryRepoForMerge(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, expectedHeadCommitID, baseCommitID string) (mergeCtx *mergeContext, cancel context.CancelFunc, err error) {
 	// Clone base repo.
 	prCtx, cancel, err := createTemporaryRepoForPR(ctx, pr)
 	if err != nil {
@@ -72,6 +74,17 @@ func createTemporaryRepoForMerge(ctx context.Context, pr *issues_model.PullReque
 		}
 	}
 
+	if baseCommitID != "" {
+		// the commit is available through the alternates of the base repository
+		for _, branch := range []string{baseBranch, "original_" + baseBranch} {
+			if err := git.NewCommand(ctx, "update-ref").AddDynamicArguments(git.BranchPrefix+branch, baseCommitID).Run(mergeCtx.RunOpts()); err != nil {
+				defer cancel()
+				log.Error("%-v Unable to reset %s to %s in %s: %v\n%s\n%s", pr, branch, baseCommitID, mergeCtx.tmpBasePath, err, mergeCtx.outbuf.String(), mergeCtx.errbuf.String())
+				return nil, nil, fmt.Errorf("unable to reset %s to %s in tmpBasePath: %w", branch, baseCommitID, err)
+			}
+		}
+	}
+
 	mergeCtx.outbuf.Reset()
 	mergeCtx.errbuf.Reset()
 	if err := prepareTemporaryRepoForMerge(mergeCtx); err != nil {
@@ -233,9 +246,10 @@ func getDiffTree(ctx context.Context, repoPath, baseBranch, headBranch string, o
 	return err
 }
 
-// rebaseTrackingOnToBase checks out the tracking branch as staging and rebases it on to the base branch
+// rebaseTrackingOnToBase checks out the tracking branch as staging and rebases it on to the base branch,
+// if upstream is set only the commits after it are rebased.
 // if there is a conflict it will return a models.ErrRebaseConflicts
-func rebaseTrackingOnToBase(ctx *mergeContext, mergeStyle repo_model.MergeStyle) error {
+func rebaseTrackingOnToBase(ctx *mergeContext, mergeStyle repo_model.MergeStyle, upstream string) error {
 	// Checkout head branch
 	if err := git.NewCommand(ctx, "checkout", "-b").AddDynamicArguments(stagingBranch, trackingBranch).
 		Run(ctx.RunOpts()); err != nil {
@@ -245,8 +259,13 @@ func rebaseTrackingOnToBase(ctx *mergeContext, mergeStyle repo_model.MergeStyle)
 	ctx.errbuf.Reset()
 
 	// Rebase before merging
-	if err := git.NewCommand(ctx, "rebase").AddDynamicArguments(baseBranch).
-		Run(ctx.RunOpts()); err != nil {
+	cmd := git.NewCommand(ctx, "rebase")
+	if upstream != "" {
+		cmd.AddArguments("--onto").AddDynamicArguments(baseBranch, upstream)
+	} else {
+		cmd.AddDynamicArguments(baseBranch)
+	}
+	if err := cmd.Run(ctx.RunOpts()); err != nil {
 		// Rebase will leave a REBASE_HEAD file in .git if there is a conflict
 		if _, statErr := os.Stat(filepath.Join(ctx.tmpBasePath, ".git", "REBASE_HEAD")); statErr == nil {
 			var commitSha string
@@ -0,0 +1,187 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// CreateMergeQueueCommit creates the speculative merge commit of a pull request on top of baseCommitID
+// and pushes it to the merge queue ref of the pull request.
+// It returns the speculative merge commit and the head commit of the pull request it was created from.
+func CreateMergeQueueCommit(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, mergeStyle repo_model.MergeStyle, message, baseCommitID string) (commitID, headCommitID string, err error) {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return "", "", fmt.Errorf("unable to load base repo: %w", err)
+	} else if err := pr.LoadHeadRepo(ctx); err != nil {
+		return "", "", fmt.Errorf("unable to load head repo: %w", err)
+	}
+
+	pullWorkingPool.CheckIn(fmt.Sprint(pr.ID))
+	defer pullWorkingPool.CheckOut(fmt.Sprint(pr.ID))
+
+	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, pr, doer, "", baseCommitID)
+	if err != nil {
+		return "", "", err
+	}
+	defer cancel()
+
+	headCommitID, err = git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, trackingBranch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get full commit id for the head of %-v: %w", pr, err)
+	}
+
+	if err := doMergeStyle(mergeCtx, mergeStyle, message); err != nil {
+		return "", "", err
+	}
+
+	commitID, err = git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, baseBranch)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get full commit id for the merge queue commit: %w", err)
+	}
+
+	if setting.LFS.StartServer {
+		mergeBaseSHA, err := git.GetFullCommitID(ctx, mergeCtx.tmpBasePath, "original_"+baseBranch)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get full commit id for origin/%s: %w", pr.BaseBranch, err)
+		}
+		if err := LFSPush(ctx, mergeCtx.tmpBasePath, commitID, mergeBaseSHA, pr); err != nil {
+			return "", "", err
+		}
+	}
+
+	env := repo_module.FullPushingEnvironment(doer, doer, pr.BaseRepo, pr.BaseRepo.Name, 0)
+	env = append(env, repo_module.EnvPushTrigger+"="+string(repo_module.PushTriggerMergeQueue))
+	if err := git.Push(ctx, mergeCtx.tmpBasePath, git.PushOptions{
+		Remote: "origin",
+		Branch: baseBranch + ":" + git.RefNameFromMergeQueue(pr.BaseBranch, pr.Index).String(),
+		Force:  true,
+		Env:    env,
+	}); err != nil {
+		return "", "", err
+	}
+
+	return commitID, headCommitID, nil
+}
+
+// RemoveMergeQueueRef deletes the merge queue ref of a pull request
+func RemoveMergeQueueRef(ctx context.Context, pr *issues_model.PullRequest) error {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return err
+	}
+	ref := git.RefNameFromMergeQueue(pr.BaseBranch, pr.Index)
+	if !git.IsReferenceExist(ctx, pr.BaseRepo.RepoPath(), ref.String()) {
+		return nil
+	}
+	return git.NewCommand(ctx, "update-ref", "-d").AddDynamicArguments(ref.String()).Run(&git.RunOpts{Dir: pr.BaseRepo.RepoPath()})
+}
+
+// MergeQueueCommit fast-forwards the base branch of a pull request to its speculative merge commit
+// and marks the pull request as merged.
+func MergeQueueCommit(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, commitID string) error {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return fmt.Errorf("unable to load base repo: %w", err)
+	}
+
+	pullWorkingPool.CheckIn(fmt.Sprint(pr.ID))
+	defer pullWorkingPool.CheckOut(fmt.Sprint(pr.ID))
+
+	defer func() {
+		AddTestPullRequestTask(ctx, doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "", 0)
+	}()
+
+	env := repo_module.FullPushingEnvironment(doer, doer, pr.BaseRepo, pr.BaseRepo.Name, pr.ID)
+	env = append(env, repo_module.EnvPushTrigger+"="+string(repo_module.PushTriggerMergeQueue))
+
+	// This causes an api call to "/api/internal/hook/post-receive/...", which marks the pull request as merged.
+	if err := git.Push(ctx, pr.BaseRepo.RepoPath(), git.PushOptions{
+		Remote: pr.BaseRepo.RepoPath(),
+		Branch: commitID + ":" + git.BranchPrefix + pr.BaseBranch,
+		Env:    env,
+	}); err != nil {
+		return err
+	}
+
+	return finishMerge(ctx, pr, doer, false)
+}
+
+// GetMergeQueueCommitStatusState returns the state of the required status checks of a speculative merge commit
+func GetMergeQueueCommitStatusState(ctx context.Context, pb *git_model.ProtectedBranch, repoID int64, commitID string) (structs.CommitStatusState, error) {
+	if pb == nil || !pb.EnableStatusCheck {
+		return structs.CommitStatusSuccess, nil
+	}
+
+	commitStatuses, _, err := git_model.GetLatestCommitStatus(ctx, repoID, commitID, db.ListOptionsAll)
+	if err != nil {
+		return "", fmt.Errorf("GetLatestCommitStatus: %w", err)
+	}
+	return MergeRequiredContextsCommitStatus(commitStatuses, pb.StatusCheckContexts), nil
+}
+
+// CheckMergeQueueCommit checks whether the base branch of a pull request may be fast-forwarded to commitID
+// by the merge queue: the commit must be the speculative merge commit of the pull request, its required
+// status checks must have passed and the pull request must still be approved.
+func CheckMergeQueueCommit(ctx context.Context, pr *issues_model.PullRequest, pb *git_model.ProtectedBranch, commitID string) error {
+	exists, entry, err := pull_model.GetMergeQueueEntryByPullID(ctx, pr.ID)
+	if err != nil {
+		return err
+	}
+	if !exists || entry.CommitID != commitID {
+		return models.ErrDisallowedToMerge{
+			Reason: "The commit is not the merge queue commit of the pull request",
+		}
+	}
+
+	state, err := GetMergeQueueCommitStatusState(ctx, pb, pr.BaseRepoID, commitID)
+	if err != nil {
+		return err
+	}
+	if !state.IsSuccess() {
+		return models.ErrDisallowedToMerge{
+			Reason: "Not all required status checks successful",
+		}
+	}
+
+	if pb == nil {
+		return nil
+	}
+	if !issues_model.HasEnoughApprovals(ctx, pb, pr) {
+		return models.ErrDisallowedToMerge{
+			Reason: "Does not have enough approvals",
+		}
+	}
+	if issues_model.MergeBlockedByRejectedReview(ctx, pb, pr) {
+		return models.ErrDisallowedToMerge{
+			Reason: "There are requested changes",
+		}
+	}
+	if issues_model.MergeBlockedByOfficialReviewRequests(ctx, pb, pr) {
+		return models.ErrDisallowedToMerge{
+			Reason: "There are official review requests",
+		}
+	}
+	missingCodeOwnerApprovals, err := GetMissingCodeOwnerApprovals(ctx, pb, pr)
+	if err != nil {
+		return err
+	}
+	if len(missingCodeOwnerApprovals) > 0 {
+		return models.ErrDisallowedToMerge{
+			Reason: "Missing approvals from code owners: " + formatMissingCodeOwnerApprovals(missingCodeOwnerApprovals),
+		}
+	}
+
+	return nil
+}
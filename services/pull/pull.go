@@ -18,6 +18,7 @@ import (
 	git_model "code.gitea.io/gitea/models/git"
 	issues_model "code.gitea.io/gitea/models/issues"
 	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/container"
@@ -573,11 +574,33 @@ func (errs errlist) Error() string {
 	return ""
 }
 
-// RetargetChildrenOnMerge retarget children pull requests on merge if possible
+// RetargetChildrenOnMerge retarget children pull requests on merge if possible,
+// their rebase onto their new base branch is queued if the repository rebases stacked pull requests on merge.
 func RetargetChildrenOnMerge(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest) error {
-	if setting.Repository.PullRequest.RetargetChildrenOnMerge && pr.BaseRepoID == pr.HeadRepoID {
-		return RetargetBranchPulls(ctx, doer, pr.HeadRepoID, pr.HeadBranch, pr.BaseBranch)
+	if !setting.Repository.PullRequest.RetargetChildrenOnMerge || pr.BaseRepoID != pr.HeadRepoID {
+		return nil
+	}
+
+	children, err := issues_model.GetUnmergedPullRequestsByBaseInfo(ctx, pr.HeadRepoID, pr.HeadBranch)
+	if err != nil || len(children) == 0 {
+		return err
+	}
+
+	if err := RetargetBranchPulls(ctx, doer, pr.HeadRepoID, pr.HeadBranch, pr.BaseBranch); err != nil {
+		return err
 	}
+
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(ctx, unit.TypePullRequests)
+	if err != nil {
+		return err
+	}
+	if !prUnit.PullRequestsConfig().RebaseStackedPullsOnMerge {
+		return nil
+	}
+	queueStackedPullRebases(doer, pr, children)
 	return nil
 }
 
@@ -1004,19 +1027,28 @@ func GetPullCommits(ctx *gitea_context.Context, issue *issues_model.Issue) ([]Co
 	var lastReviewCommitID string
 	if ctx.IsSigned {
 		// get last review of current user and store information in context (if available)
-		lastreview, err := issues_model.FindLatestReviews(ctx, issues_model.FindReviewOptions{
-			IssueID:    issue.ID,
-			ReviewerID: ctx.Doer.ID,
-			Type:       issues_model.ReviewTypeUnknown,
-		})
-
-		if err != nil && !issues_model.IsErrReviewNotExist(err) {
+		lastReviewCommitID, err = GetLastReviewCommitID(ctx, issue, ctx.Doer)
+		if err != nil {
 			return nil, "", err
 		}
-		if len(lastreview) > 0 {
-			lastReviewCommitID = lastreview[0].CommitID
-		}
 	}
 
 	return commits, lastReviewCommitID, nil
 }
+
+// GetLastReviewCommitID returns the head commit of the pull request at the last review of the user, or an empty
+// string if the user did not review it yet
+func GetLastReviewCommitID(ctx context.Context, issue *issues_model.Issue, user *user_model.User) (string, error) {
+	lastreview, err := issues_model.FindLatestReviews(ctx, issues_model.FindReviewOptions{
+		IssueID:    issue.ID,
+		ReviewerID: user.ID,
+		Type:       issues_model.ReviewTypeUnknown,
+	})
+	if err != nil && !issues_model.IsErrReviewNotExist(err) {
+		return "", err
+	}
+	if len(lastreview) > 0 {
+		return lastreview[0].CommitID, nil
+	}
+	return "", nil
+}
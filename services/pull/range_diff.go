@@ -0,0 +1,29 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/modules/git"
+)
+
+// GetRangeDiff compares the commits of a pull request when its head was at oldCommitID with its commits when its
+// head was at newCommitID. Each range starts at the merge base of its head with the base of the pull request, so
+// commits of the base branch which were pulled in by a rebase are left out.
+func GetRangeDiff(gitRepo *git.Repository, pr *issues_model.PullRequest, oldCommitID, newCommitID string) ([]*git.RangeDiffPair, error) {
+	base := pr.BaseBranch
+	if pr.HasMerged {
+		base = pr.MergeBase
+	}
+
+	oldBase, _, err := gitRepo.GetMergeBase("", base, oldCommitID)
+	if err != nil {
+		return nil, err
+	}
+	newBase, _, err := gitRepo.GetMergeBase("", base, newCommitID)
+	if err != nil {
+		return nil, err
+	}
+	return gitRepo.GetRangeDiff(oldBase, oldCommitID, newBase, newCommitID)
+}
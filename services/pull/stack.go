@@ -0,0 +1,343 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// getStackParent returns the open pull request a pull request is stacked on: the one whose head branch is its base branch
+func getStackParent(ctx context.Context, pr *issues_model.PullRequest) (*issues_model.PullRequest, error) {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return nil, err
+	}
+	// pull requests from the default branch do not start a stack
+	if pr.BaseBranch == pr.BaseRepo.DefaultBranch {
+		return nil, nil
+	}
+	prs, err := issues_model.GetUnmergedPullRequestsByHeadInfo(ctx, pr.BaseRepoID, pr.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	for _, parent := range prs {
+		if parent.BaseRepoID == pr.BaseRepoID && parent.ID != pr.ID {
+			return parent, nil
+		}
+	}
+	return nil, nil
+}
+
+// getStackChildren returns the open pull requests stacked on a pull request: the ones whose base branch is its head branch
+func getStackChildren(ctx context.Context, pr *issues_model.PullRequest) ([]*issues_model.PullRequest, error) {
+	if pr.HeadRepoID != pr.BaseRepoID || pr.Flow != issues_model.PullRequestFlowGithub {
+		return nil, nil
+	}
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return nil, err
+	}
+	if pr.HeadBranch == pr.BaseRepo.DefaultBranch {
+		return nil, nil
+	}
+	prs, err := issues_model.GetUnmergedPullRequestsByBaseInfo(ctx, pr.BaseRepoID, pr.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		return prs[i].Index < prs[j].Index
+	})
+	return prs, nil
+}
+
+// GetPullRequestStack returns the open pull requests of the stack a pull request belongs to, in merge order: the pull
+// requests it is stacked on come first from the bottom of the stack, followed by the pull request and the pull requests
+// stacked on it. A pull request is stacked on another one if its base branch is the head branch of the other one.
+// The returned list only holds the pull request if it is not part of a stack.
+func GetPullRequestStack(ctx context.Context, pr *issues_model.PullRequest) (issues_model.PullRequestList, error) {
+	seen := container.SetOf(pr.ID)
+
+	var stack issues_model.PullRequestList
+	for current := pr; ; {
+		parent, err := getStackParent(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || !seen.Add(parent.ID) {
+			break
+		}
+		stack = append(stack, parent)
+		current = parent
+	}
+	slices.Reverse(stack)
+	stack = append(stack, pr)
+
+	var addChildren func(*issues_model.PullRequest) error
+	addChildren = func(parent *issues_model.PullRequest) error {
+		children, err := getStackChildren(ctx, parent)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if !seen.Add(child.ID) {
+				continue
+			}
+			stack = append(stack, child)
+			if err := addChildren(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := addChildren(pr); err != nil {
+		return nil, err
+	}
+
+	if err := stack.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	return stack, nil
+}
+
+// stackedPullRebase is an item of the queue rebasing the pull requests that were stacked on a merged pull request
+type stackedPullRebase struct {
+	MergedPullID int64
+	PullID       int64
+	DoerID       int64
+}
+
+// stackedPullRebaseQueue represents a queue to rebase stacked pull requests onto their new base branch
+var stackedPullRebaseQueue *queue.WorkerPoolQueue[stackedPullRebase]
+
+func initStackedPullRebaseQueue() error {
+	stackedPullRebaseQueue = queue.CreateSimpleQueue(graceful.GetManager().ShutdownContext(), "pr_stack_rebase", handleStackedPullRebase)
+	if stackedPullRebaseQueue == nil {
+		return fmt.Errorf("unable to create pr_stack_rebase queue")
+	}
+	go graceful.GetManager().RunWithCancel(stackedPullRebaseQueue)
+	return nil
+}
+
+func handleStackedPullRebase(items ...stackedPullRebase) []stackedPullRebase {
+	ctx := graceful.GetManager().ShutdownContext()
+	for _, item := range items {
+		merged, err := issues_model.GetPullRequestByID(ctx, item.MergedPullID)
+		if err != nil {
+			log.Error("Unable to get PR[%d] to rebase the pull requests stacked on it: %v", item.MergedPullID, err)
+			continue
+		}
+		doer, err := user_model.GetUserByID(ctx, item.DoerID)
+		if err != nil {
+			log.Error("Unable to get the user[%d] who merged PR[%d]: %v", item.DoerID, item.MergedPullID, err)
+			continue
+		}
+		if err := rebaseStackedPull(ctx, doer, merged, item.PullID); err != nil {
+			if models.IsErrRebaseConflicts(err) || errors.Is(err, util.ErrPermissionDenied) {
+				log.Info("Unable to rebase PR[%d] onto %s after %-v was merged: %v", item.PullID, merged.BaseBranch, merged, err)
+				continue
+			}
+			log.Error("Unable to rebase PR[%d] stacked on PR[%d]: %v", item.PullID, item.MergedPullID, err)
+		}
+	}
+	return nil
+}
+
+// queueStackedPullRebases queues the rebase of the pull requests that were stacked on a merged pull request onto their
+// new base branch, the commits of the merged pull request are dropped from them.
+func queueStackedPullRebases(doer *user_model.User, merged *issues_model.PullRequest, children []*issues_model.PullRequest) {
+	for _, child := range children {
+		log.Trace("Adding %-v to the stacked pull requests rebase queue", child)
+		if err := stackedPullRebaseQueue.Push(stackedPullRebase{MergedPullID: merged.ID, PullID: child.ID, DoerID: doer.ID}); err != nil {
+			log.Error("Error adding %-v to the stacked pull requests rebase queue: %v", child, err)
+		}
+	}
+}
+
+// rebaseStackedPull rebases a pull request which was stacked on a merged pull request onto its new base branch, the
+// commits of the merged pull request are dropped from it. Nothing is done if the pull request is not stacked on the
+// merged one anymore or if the base branch already holds the commits it shares with the merged one, e.g. because it was
+// rebased already or the merged pull request was merged with a merge commit.
+func rebaseStackedPull(ctx context.Context, doer *user_model.User, merged *issues_model.PullRequest, pullID int64) error {
+	pullWorkingPool.CheckIn(fmt.Sprint(pullID))
+	defer pullWorkingPool.CheckOut(fmt.Sprint(pullID))
+
+	// reload the pull request as it has been retargeted and may have been merged or retargeted again since
+	pr, err := issues_model.GetPullRequestByID(ctx, pullID)
+	if err != nil {
+		return err
+	}
+	if pr.HasMerged || pr.BaseBranch != merged.BaseBranch || pr.BaseRepoID != merged.BaseRepoID || pr.HeadRepoID != pr.BaseRepoID {
+		return nil
+	}
+	if err := pr.LoadIssue(ctx); err != nil {
+		return err
+	}
+	if pr.Issue.IsClosed {
+		return nil
+	}
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return err
+	}
+	if err := pr.LoadHeadRepo(ctx); err != nil {
+		return err
+	}
+
+	gitRepo, err := gitrepo.OpenRepository(ctx, pr.BaseRepo)
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	// the head branch holds the merged commits, the ref of the merged pull request is used if it has been deleted since
+	mergedHeadCommitID, err := gitRepo.GetRefCommitID(git.BranchPrefix + merged.HeadBranch)
+	if err != nil {
+		if mergedHeadCommitID, err = gitRepo.GetRefCommitID(merged.GetGitRefName()); err != nil {
+			return err
+		}
+	}
+	headCommitID, err := gitRepo.GetRefCommitID(git.BranchPrefix + pr.HeadBranch)
+	if err != nil {
+		return err
+	}
+	// the commits of the stacked pull request start after the last commit it shares with the merged pull request
+	upstream, _, err := git.NewCommand(ctx, "merge-base").AddDynamicArguments(mergedHeadCommitID, headCommitID).RunStdString(&git.RunOpts{Dir: gitRepo.Path})
+	if err != nil {
+		return fmt.Errorf("merge-base: %w", err)
+	}
+	upstream = strings.TrimSpace(upstream)
+	if _, _, err := git.NewCommand(ctx, "merge-base", "--is-ancestor").AddDynamicArguments(upstream, git.BranchPrefix+pr.BaseBranch).RunStdString(&git.RunOpts{Dir: gitRepo.Path}); err == nil {
+		return nil
+	}
+
+	_, rebaseAllowed, err := IsUserAllowedToUpdate(ctx, pr, doer)
+	if err != nil {
+		return err
+	}
+	if !rebaseAllowed {
+		return util.NewPermissionDeniedErrorf("%s is not allowed to rebase %s onto %s", doer.Name, pr.HeadBranch, pr.BaseBranch)
+	}
+
+	defer func() {
+		AddTestPullRequestTask(ctx, doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "", 0)
+	}()
+
+	return updateHeadByRebaseOnToBase(ctx, pr, doer, upstream)
+}
+
+// MergeStack merges the pull requests of the stack a pull request belongs to in order, from the bottom of the stack up
+// to the pull request. Every merged pull request retargets the ones stacked on it, so that all of them are merged into
+// the base branch of the bottom of the stack, and the next one is rebased onto it before its merge. Nothing is merged if
+// the pull requests cannot be retargeted. It returns the pull request that could not be merged if it failed.
+func MergeStack(ctx context.Context, doer *user_model.User, perm *access_model.Permission, baseGitRepo *git.Repository, pr *issues_model.PullRequest, mergeStyle repo_model.MergeStyle) (*issues_model.PullRequest, error) {
+	stack, err := GetPullRequestStack(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pull requests stacked on a merged one have to be retargeted to its base branch before they are merged
+	for _, stacked := range stack {
+		if stacked.ID == pr.ID {
+			break
+		}
+		if !setting.Repository.PullRequest.RetargetChildrenOnMerge {
+			return stacked, util.NewInvalidArgumentErrorf("the pull requests stacked on others are not retargeted on merge")
+		}
+		if stacked.HeadRepoID != stacked.BaseRepoID {
+			return stacked, util.NewInvalidArgumentErrorf("the pull requests stacked on a pull request from a fork cannot be retargeted")
+		}
+	}
+
+	for i, stacked := range stack {
+		// reload the pull request as it has been retargeted and rebased after the merge of the previous one
+		current, err := issues_model.GetPullRequestByID(ctx, stacked.ID)
+		if err != nil {
+			return stacked, err
+		}
+		if current.BaseBranch != stack[0].BaseBranch || current.BaseRepoID != stack[0].BaseRepoID {
+			return current, util.NewInvalidArgumentErrorf("the pull request has not been retargeted to %s", stack[0].BaseBranch)
+		}
+		if i > 0 {
+			// the pull request is tested again after its rebase
+			current.Status = issues_model.PullRequestStatusChecking
+		}
+		if err := current.LoadIssue(ctx); err != nil {
+			return current, err
+		}
+		if err := current.Issue.LoadRepo(ctx); err != nil {
+			return current, err
+		}
+		if err := current.LoadBaseRepo(ctx); err != nil {
+			return current, err
+		}
+
+		if current.Status == issues_model.PullRequestStatusChecking {
+			if err := TestPatch(current); err != nil {
+				return current, err
+			}
+			if current.Status == issues_model.PullRequestStatusChecking {
+				current.Status = issues_model.PullRequestStatusMergeable
+			}
+			if err := current.UpdateColsIfNotMerged(ctx, "merge_base", "status", "conflicted_files", "changed_protected_files"); err != nil {
+				return current, err
+			}
+		}
+
+		if err := CheckPullMergeable(ctx, doer, perm, current, MergeCheckTypeGeneral, false); err != nil {
+			return current, err
+		}
+
+		prUnit, err := current.BaseRepo.GetUnit(ctx, unit.TypePullRequests)
+		if err != nil {
+			return current, err
+		}
+		style := mergeStyle
+		if style == "" {
+			style = prUnit.PullRequestsConfig().GetDefaultMergeStyle()
+		}
+
+		message, body, err := GetDefaultMergeMessage(ctx, baseGitRepo, current, style)
+		if err != nil {
+			return current, err
+		}
+		if body != "" {
+			message += "\n\n" + body
+		}
+
+		if err := Merge(ctx, current, doer, baseGitRepo, style, "", message, false); err != nil {
+			return current, err
+		}
+
+		if current.ID == pr.ID {
+			break
+		}
+
+		// the next pull request of the stack has to be retargeted to be merged into the same base branch, and rebased
+		// before it is merged so that it does not carry the commits of the merged one anymore
+		if err := RetargetChildrenOnMerge(ctx, doer, current); err != nil {
+			return current, err
+		}
+		if err := rebaseStackedPull(ctx, doer, current, stack[i+1].ID); err != nil {
+			return stack[i+1], err
+		}
+	}
+	return nil, nil
+}
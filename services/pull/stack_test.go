@@ -0,0 +1,61 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPullRequestStack(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	stackIDs := func(t *testing.T, id int64) []int64 {
+		pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: id})
+		stack, err := GetPullRequestStack(db.DefaultContext, pr)
+		require.NoError(t, err)
+		ids := make([]int64, 0, len(stack))
+		for _, stacked := range stack {
+			assert.NotNil(t, stacked.Issue)
+			ids = append(ids, stacked.ID)
+		}
+		return ids
+	}
+
+	// pull request 5 (pr-to-update -> branch2) is stacked on pull request 2 (branch2 -> master)
+	assert.EqualValues(t, []int64{2, 5}, stackIDs(t, 2))
+	assert.EqualValues(t, []int64{2, 5}, stackIDs(t, 5))
+
+	// pull request 6 is not part of a stack
+	assert.EqualValues(t, []int64{6}, stackIDs(t, 6))
+}
+
+func TestMergeStackWithoutRetarget(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	defer test.MockVariableValue(&setting.Repository.PullRequest.RetargetChildrenOnMerge, false)()
+
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 5})
+	require.NoError(t, pr.LoadBaseRepo(db.DefaultContext))
+	perm, err := access_model.GetUserRepoPermission(db.DefaultContext, pr.BaseRepo, doer)
+	require.NoError(t, err)
+
+	// pull request 5 would be merged into the head branch of pull request 2 instead of master
+	failed, err := MergeStack(db.DefaultContext, doer, &perm, nil, pr, "")
+	require.ErrorIs(t, err, util.ErrInvalidArgument)
+	require.NotNil(t, failed)
+	assert.EqualValues(t, 2, failed.ID)
+	assert.False(t, unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 2}).HasMerged)
+}
@@ -40,7 +40,7 @@ func Update(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.
 			AddTestPullRequestTask(ctx, doer, pr.BaseRepo.ID, pr.BaseBranch, false, "", "", 0)
 		}()
 
-		return updateHeadByRebaseOnToBase(ctx, pr, doer)
+		return updateHeadByRebaseOnToBase(ctx, pr, doer, "")
 	}
 
 	if err := pr.LoadBaseRepo(ctx); err != nil {
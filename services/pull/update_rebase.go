@@ -17,10 +17,11 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 )
 
-// updateHeadByRebaseOnToBase handles updating a PR's head branch by rebasing it on the PR current base branch
-func updateHeadByRebaseOnToBase(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User) error {
+// updateHeadByRebaseOnToBase handles updating a PR's head branch by rebasing it on the PR current base branch,
+// if upstream is set only the commits of the head branch after it are kept.
+func updateHeadByRebaseOnToBase(ctx context.Context, pr *issues_model.PullRequest, doer *user_model.User, upstream string) error {
 	// "Clone" base repo and add the cache headers for the head repo and branch
-	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, pr, doer, "")
+	mergeCtx, cancel, err := createTemporaryRepoForMerge(ctx, pr, doer, "", "")
 	if err != nil {
 		return err
 	}
@@ -31,7 +32,7 @@ func updateHeadByRebaseOnToBase(ctx context.Context, pr *issues_model.PullReques
 	oldMergeBase = strings.TrimSpace(oldMergeBase)
 
 	// Rebase the tracking branch on to the base as the staging branch
-	if err := rebaseTrackingOnToBase(mergeCtx, repo_model.MergeStyleRebaseUpdate); err != nil {
+	if err := rebaseTrackingOnToBase(mergeCtx, repo_model.MergeStyleRebaseUpdate, upstream); err != nil {
 		return err
 	}
 
@@ -54,6 +54,9 @@ func createTag(ctx context.Context, gitRepo *git.Repository, rel *repo_model.Rel
 			// Trim '--' prefix to prevent command line argument vulnerability.
 			rel.TagName = strings.TrimPrefix(rel.TagName, "--")
 			isAllowed, err := git_model.IsUserAllowedToControlTag(ctx, protectedTags, rel.TagName, rel.PublisherID)
+			if err == nil && isAllowed {
+				isAllowed, err = git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, rel.Repo.ID, rel.TagName, rel.PublisherID)
+			}
 			if err != nil {
 				return false, err
 			}
@@ -309,6 +312,9 @@ func DeleteReleaseByID(ctx context.Context, repo *repo_model.Repository, rel *re
 			return fmt.Errorf("GetProtectedTags: %w", err)
 		}
 		isAllowed, err := git_model.IsUserAllowedToControlTag(ctx, protectedTags, rel.TagName, rel.PublisherID)
+		if err == nil && isAllowed {
+			isAllowed, err = git_model.IsUserAllowedToControlTagByOrgRulesets(ctx, rel.RepoID, rel.TagName, rel.PublisherID)
+		}
 		if err != nil {
 			return err
 		}
@@ -180,6 +180,7 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		&actions_model.ActionSchedule{RepoID: repoID},
 		&actions_model.ActionArtifact{RepoID: repoID},
 		&repo_model.RepoArchiveDownloadCount{RepoID: repoID},
+		&repo_model.RepoMaintenance{RepoID: repoID},
 		&actions_model.ActionRunnerToken{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %w", err)
@@ -190,6 +191,11 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		return err
 	}
 
+	// Delete custom issue fields and their values
+	if err := issues_model.DeleteIssueFieldsByRepoID(ctx, repoID); err != nil {
+		return err
+	}
+
 	// Delete Pulls and related objects
 	if err := issues_model.DeletePullsByBaseRepoID(ctx, repoID); err != nil {
 		return err
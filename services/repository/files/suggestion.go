@@ -0,0 +1,284 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package files
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// DefaultCodeSuggestionsMessage is the commit message used when applying code suggestions without a message
+const DefaultCodeSuggestionsMessage = "Apply suggestions from code review"
+
+// ErrCodeSuggestionOutdated represents an error when the line a code suggestion was made on has changed
+type ErrCodeSuggestionOutdated struct {
+	CommentID int64
+	TreePath  string
+	Line      int64
+}
+
+// IsErrCodeSuggestionOutdated checks if an error is an ErrCodeSuggestionOutdated.
+func IsErrCodeSuggestionOutdated(err error) bool {
+	_, ok := err.(ErrCodeSuggestionOutdated)
+	return ok
+}
+
+func (err ErrCodeSuggestionOutdated) Error() string {
+	return fmt.Sprintf("the suggestion of comment %d is outdated, line %d of %s has changed", err.CommentID, err.Line, err.TreePath)
+}
+
+func (err ErrCodeSuggestionOutdated) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// ErrCodeSuggestionConflict represents an error when several applied code suggestions change the same line
+type ErrCodeSuggestionConflict struct {
+	TreePath string
+	Line     int64
+}
+
+// IsErrCodeSuggestionConflict checks if an error is an ErrCodeSuggestionConflict.
+func IsErrCodeSuggestionConflict(err error) bool {
+	_, ok := err.(ErrCodeSuggestionConflict)
+	return ok
+}
+
+func (err ErrCodeSuggestionConflict) Error() string {
+	return fmt.Sprintf("several suggestions change line %d of %s", err.Line, err.TreePath)
+}
+
+func (err ErrCodeSuggestionConflict) Unwrap() error {
+	return util.ErrInvalidArgument
+}
+
+// CanApplyCodeSuggestions returns whether the user may apply the code suggestions of a pull request to its head branch:
+// the user must be allowed to push to the head branch, possibly as a maintainer if the author allows it.
+func CanApplyCodeSuggestions(ctx context.Context, pr *issues_model.PullRequest, user *user_model.User) (bool, error) {
+	if user == nil || pr.HasMerged || pr.Flow == issues_model.PullRequestFlowAGit {
+		return false, nil
+	}
+	if err := pr.LoadIssue(ctx); err != nil {
+		return false, err
+	}
+	if pr.Issue.IsClosed {
+		return false, nil
+	}
+	if err := pr.LoadHeadRepo(ctx); err != nil {
+		return false, err
+	}
+	if pr.HeadRepo == nil || pr.HeadRepo.IsArchived {
+		return false, nil
+	}
+
+	perm, err := access_model.GetUserRepoPermission(ctx, pr.HeadRepo, user)
+	if err != nil {
+		return false, err
+	}
+	if !issues_model.CanMaintainerWriteToBranch(ctx, perm, pr.HeadBranch, user) {
+		return false, nil
+	}
+
+	// like in the web editor, the protection of the head branch may still prevent the user from pushing to it
+	pb, err := git_model.GetFirstMatchProtectedBranchRule(ctx, pr.HeadRepoID, pr.HeadBranch)
+	if err != nil {
+		return false, err
+	}
+	if pb != nil {
+		pb.Repo = pr.HeadRepo
+		return pb.CanUserPush(ctx, user), nil
+	}
+	return true, nil
+}
+
+// ApplyCodeSuggestions applies the suggestions of code comments of a pull request as a single commit on its head branch,
+// the authors of the suggestions are credited as co-authors of the commit.
+func ApplyCodeSuggestions(ctx context.Context, doer *user_model.User, pr *issues_model.PullRequest, commentIDs []int64, message string) (*structs.FilesResponse, error) {
+	if len(commentIDs) == 0 {
+		return nil, util.NewInvalidArgumentErrorf("no suggestion to apply")
+	}
+	if err := pr.LoadHeadRepo(ctx); err != nil {
+		return nil, err
+	}
+	if pr.HeadRepo == nil {
+		return nil, util.NewNotExistErrorf("the head repository of the pull request does not exist")
+	}
+
+	comments := make([]*issues_model.Comment, 0, len(commentIDs))
+	for _, id := range container.SetOf(commentIDs...).Values() {
+		comment, err := issues_model.GetCommentByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if comment.IssueID != pr.IssueID || comment.Type != issues_model.CommentTypeCode {
+			return nil, issues_model.ErrCommentNotExist{ID: id}
+		}
+		comment.LoadSuggestion()
+		if comment.Suggestion == nil {
+			return nil, util.NewInvalidArgumentErrorf("comment %d has no suggestion", id)
+		}
+		if err := comment.LoadPoster(ctx); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if comments[i].TreePath != comments[j].TreePath {
+			return comments[i].TreePath < comments[j].TreePath
+		}
+		return comments[i].Line < comments[j].Line
+	})
+
+	gitRepo, closer, err := gitrepo.RepositoryFromContextOrOpen(ctx, pr.HeadRepo)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	headCommit, err := gitRepo.GetBranchCommit(pr.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ChangeRepoFile
+	for start := 0; start < len(comments); {
+		end := start + 1
+		for end < len(comments) && comments[end].TreePath == comments[start].TreePath {
+			end++
+		}
+		file, err := applyCodeSuggestionsToFile(gitRepo, headCommit, comments[start:end])
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		start = end
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = DefaultCodeSuggestionsMessage
+	}
+	coAuthors := make(container.Set[string])
+	var trailers strings.Builder
+	for _, comment := range comments {
+		if comment.PosterID == doer.ID || comment.Poster.IsGhost() {
+			continue
+		}
+		if sig := comment.Poster.NewGitSig().String(); coAuthors.Add(sig) {
+			trailers.WriteString("\nCo-authored-by: " + sig)
+		}
+	}
+	if trailers.Len() > 0 {
+		message += "\n" + trailers.String()
+	}
+
+	return ChangeRepoFiles(ctx, pr.HeadRepo, doer, &ChangeRepoFilesOptions{
+		LastCommitID: headCommit.ID.String(),
+		OldBranch:    pr.HeadBranch,
+		NewBranch:    pr.HeadBranch,
+		Message:      message,
+		Files:        files,
+	})
+}
+
+// applyCodeSuggestionsToFile replaces the commented lines of a file at the head commit by the lines of the suggestions,
+// comments must be sorted by line.
+func applyCodeSuggestionsToFile(gitRepo *git.Repository, headCommit *git.Commit, comments []*issues_model.Comment) (*ChangeRepoFile, error) {
+	treePath := comments[0].TreePath
+	entry, err := headCommit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readBlob(entry.Blob())
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(content, "\n")
+
+	for i := len(comments) - 1; i >= 0; i-- {
+		comment := comments[i]
+		if i > 0 && comments[i-1].Line == comment.Line {
+			return nil, ErrCodeSuggestionConflict{TreePath: treePath, Line: comment.Line}
+		}
+
+		original, ok := comment.CommentedLine()
+		if !ok {
+			if original, ok, err = readCommentedLine(gitRepo, comment); err != nil {
+				return nil, err
+			}
+		}
+
+		idx := int(comment.Line) - 1
+		if comment.Invalidated || !ok || idx >= len(lines) || (idx == len(lines)-1 && lines[idx] == "") ||
+			strings.TrimSuffix(lines[idx], "\r") != strings.TrimSuffix(original, "\r") {
+			return nil, ErrCodeSuggestionOutdated{CommentID: comment.ID, TreePath: treePath, Line: comment.Line}
+		}
+
+		eol := ""
+		if strings.HasSuffix(lines[idx], "\r") {
+			eol = "\r"
+		}
+		replacement := make([]string, 0, len(comment.Suggestion.Lines))
+		for _, line := range comment.Suggestion.Lines {
+			replacement = append(replacement, line+eol)
+		}
+		lines = append(lines[:idx], append(replacement, lines[idx+1:]...)...)
+	}
+
+	return &ChangeRepoFile{
+		Operation:     "update",
+		TreePath:      treePath,
+		ContentReader: strings.NewReader(strings.Join(lines, "\n")),
+		SHA:           entry.ID.String(),
+	}, nil
+}
+
+// readCommentedLine reads the commented line at the commit a code comment was made on
+func readCommentedLine(gitRepo *git.Repository, comment *issues_model.Comment) (string, bool, error) {
+	commit, err := gitRepo.GetCommit(comment.CommitSHA)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	blob, err := commit.GetBlobByPath(comment.TreePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	content, err := readBlob(blob)
+	if err != nil {
+		return "", false, err
+	}
+	lines := strings.Split(content, "\n")
+	if idx := int(comment.UnsignedLine()) - 1; idx < len(lines) {
+		return lines[idx], true, nil
+	}
+	return "", false, nil
+}
+
+func readBlob(blob *git.Blob) (string, error) {
+	reader, err := blob.DataAsync()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	return string(content), err
+}
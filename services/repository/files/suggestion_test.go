@@ -0,0 +1,29 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package files
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanApplyCodeSuggestions(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	// the head branch of pull request 3 is in the repository of user 13, the pull request is posted by user 11
+	pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: 3})
+	for userID, allowed := range map[int64]bool{11: false, 13: true} {
+		user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: userID})
+		canApply, err := CanApplyCodeSuggestions(db.DefaultContext, pr, user)
+		require.NoError(t, err)
+		assert.Equal(t, allowed, canApply, "user %d", userID)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	system_model "code.gitea.io/gitea/models/system"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	repo_module "code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// MaintainRepositories runs the incremental git maintenance of the repositories which have been pushed to at least
+// pushThreshold times since their last maintenance, or at all if it ran longer than interval ago
+func MaintainRepositories(ctx context.Context, timeout time.Duration, pushThreshold int64, interval time.Duration) error {
+	log.Trace("Doing: MaintainRepositories")
+
+	opts := repo_model.FindRepoMaintenanceOptions{
+		ListOptions: db.ListOptions{PageSize: 50},
+		MinPushes:   max(pushThreshold, 1),
+	}
+	if interval > 0 {
+		opts.LastRunBefore = timeutil.TimeStamp(time.Now().Add(-interval).Unix())
+	}
+
+	// the maintained repositories are not due anymore, so the first page is always read again
+	for {
+		opts.Page = 1
+		dueRepos, err := db.Find[repo_model.RepoMaintenance](ctx, opts)
+		if err != nil {
+			return err
+		}
+		if len(dueRepos) == 0 {
+			break
+		}
+
+		for _, m := range dueRepos {
+			select {
+			case <-ctx.Done():
+				return db.ErrCancelledf("before maintenance of repository %d", m.RepoID)
+			default:
+			}
+			if err := MaintainRepository(ctx, m, timeout); err != nil {
+				return err
+			}
+		}
+	}
+
+	log.Trace("Finished: MaintainRepositories")
+	return nil
+}
+
+// MaintainRepository runs the incremental git maintenance of a repository and records its result. A failure of git
+// is recorded as the error of the maintenance and in a repository notice, only other errors are returned.
+func MaintainRepository(ctx context.Context, m *repo_model.RepoMaintenance, timeout time.Duration) error {
+	if err := m.LoadRepo(ctx); err != nil {
+		if repo_model.IsErrRepoNotExist(err) {
+			// the maintenance of a deleted repository is left over
+			_, err = db.DeleteByID[repo_model.RepoMaintenance](ctx, m.ID)
+			return err
+		}
+		return err
+	}
+	repo := m.Repo
+	pushes := m.Pushes
+
+	log.Trace("Running git maintenance on %-v", repo)
+	start := time.Now()
+	err := git.RunMaintenance(ctx, repo.RepoPath(), timeout)
+	m.LastRun = timeutil.TimeStampNow()
+	m.LastDuration = time.Since(start)
+	m.LastError = ""
+	if err != nil {
+		log.Error("Repository maintenance failed for %-v: %v", repo, err)
+		m.LastError = err.Error()
+		if err := system_model.CreateRepositoryNotice("Repository maintenance failed for %s: %v", repo.FullName(), err); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+	} else if err := repo_module.UpdateRepoSize(ctx, repo); err != nil {
+		log.Error("Updating size as part of the maintenance failed for %-v: %v", repo, err)
+	}
+
+	return repo_model.FinishRepoMaintenance(ctx, m, pushes)
+}
@@ -96,6 +96,11 @@ func pushUpdates(optsList []*repo_module.PushUpdateOptions) error {
 		return fmt.Errorf("Failed to update size for repository: %v", err)
 	}
 
+	// the push is only counted to schedule the maintenance, which must not fail the push
+	if err := repo_model.CountRepoMaintenancePush(ctx, repo.ID); err != nil {
+		log.Error("Failed to count the push for the maintenance of %-v: %v", repo, err)
+	}
+
 	addTags := make([]string, 0, len(optsList))
 	delTags := make([]string, 0, len(optsList))
 	var pusher *user_model.User
@@ -0,0 +1,72 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// The detail error types, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeNoTarget      = "noTarget"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeMutability    = "mutability"
+)
+
+// Error is a SCIM error response, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.12
+type Error struct {
+	Schemas []string `json:"schemas"`
+	Status  string   `json:"status"`
+	Type    string   `json:"scimType,omitempty"`
+	Detail  string   `json:"detail,omitempty"`
+}
+
+func (err *Error) Error() string {
+	if err.Type == "" {
+		return fmt.Sprintf("%s: %s", err.Status, err.Detail)
+	}
+	return fmt.Sprintf("%s %s: %s", err.Status, err.Type, err.Detail)
+}
+
+// StatusCode returns the HTTP status of the error
+func (err *Error) StatusCode() int {
+	status, _ := strconv.Atoi(err.Status)
+	return status
+}
+
+// NewError returns a SCIM error with the HTTP status, the detail error type if any and a message
+func NewError(status int, errorType, format string, args ...any) *Error {
+	return &Error{
+		Schemas: []string{SchemaError},
+		Status:  strconv.Itoa(status),
+		Type:    errorType,
+		Detail:  fmt.Sprintf(format, args...),
+	}
+}
+
+func errBadRequest(errorType, format string, args ...any) *Error {
+	return NewError(http.StatusBadRequest, errorType, format, args...)
+}
+
+func errConflict(format string, args ...any) *Error {
+	return NewError(http.StatusConflict, ErrorTypeUniqueness, format, args...)
+}
+
+func errNotFound(format string, args ...any) *Error {
+	return NewError(http.StatusNotFound, "", format, args...)
+}
+
+// AsError returns the SCIM error which the error wraps, if any
+func AsError(err error) (*Error, bool) {
+	var scimErr *Error
+	ok := errors.As(err, &scimErr)
+	return scimErr, ok
+}
@@ -0,0 +1,417 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// The comparison operators of filters, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+const (
+	opEqual          = "eq"
+	opNotEqual       = "ne"
+	opContains       = "co"
+	opStartsWith     = "sw"
+	opEndsWith       = "ew"
+	opPresent        = "pr"
+	opGreater        = "gt"
+	opGreaterOrEqual = "ge"
+	opLess           = "lt"
+	opLessOrEqual    = "le"
+)
+
+// filterExpression is a node of a parsed filter
+type filterExpression interface {
+	isFilterExpression()
+}
+
+// attributeExpression compares an attribute with a value, the value is a string, a bool, a float64 or nil
+type attributeExpression struct {
+	Path     string
+	Operator string
+	Value    any
+}
+
+// logicalExpression combines two filters with "and" or "or"
+type logicalExpression struct {
+	Operator    string
+	Left, Right filterExpression
+}
+
+// notExpression negates a filter
+type notExpression struct {
+	Filter filterExpression
+}
+
+// valuePathExpression filters the values of a multi-valued attribute, it matches if any value matches
+type valuePathExpression struct {
+	Path   string
+	Filter filterExpression
+}
+
+func (*attributeExpression) isFilterExpression() {}
+func (*logicalExpression) isFilterExpression()   {}
+func (*notExpression) isFilterExpression()       {}
+func (*valuePathExpression) isFilterExpression() {}
+
+// stripSchema removes the schema URN from a fully qualified attribute path
+func stripSchema(path string) string {
+	for _, schema := range []string{SchemaUser, SchemaGroup} {
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			return path[len(schema)+1:]
+		}
+	}
+	return path
+}
+
+type filterToken struct {
+	value string
+	// quoted is set for string literals, whose value is unquoted
+	quoted bool
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, filterToken{value: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(filter) && filter[end] != '"'; end++ {
+				if filter[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(filter) {
+				return nil, errBadRequest(ErrorTypeInvalidFilter, "unterminated string in filter")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, errBadRequest(ErrorTypeInvalidFilter, "invalid string in filter: %v", err)
+			}
+			tokens = append(tokens, filterToken{value: value, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(filter) && !strings.ContainsRune(" \t\n\r()[]\"", rune(filter[end])); end++ {
+			}
+			tokens = append(tokens, filterToken{value: filter[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the keyword or the punctuation
+func (p *filterParser) accept(keyword string) bool {
+	if t, ok := p.peek(); ok && !t.quoted && strings.EqualFold(t.value, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(keyword string) error {
+	if !p.accept(keyword) {
+		return errBadRequest(ErrorTypeInvalidFilter, "expected %q in filter", keyword)
+	}
+	return nil
+}
+
+func (p *filterParser) parseOr() (filterExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{Operator: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpression{Operator: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpression, error) {
+	if p.accept("not") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpression{Filter: f}, p.expect(")")
+	}
+	if p.accept("(") {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	}
+
+	t, ok := p.peek()
+	if !ok || t.quoted || strings.ContainsAny(t.value, "()[]") {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "expected an attribute in filter")
+	}
+	p.pos++
+	path := stripSchema(t.value)
+
+	if p.accept("[") {
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return &valuePathExpression{Path: path, Filter: f}, p.expect("]")
+	}
+
+	op, ok := p.peek()
+	if !ok || op.quoted {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "expected an operator after %q in filter", path)
+	}
+	p.pos++
+	operator := strings.ToLower(op.value)
+	switch operator {
+	case opPresent:
+		return &attributeExpression{Path: path, Operator: operator}, nil
+	case opEqual, opNotEqual, opContains, opStartsWith, opEndsWith, opGreater, opGreaterOrEqual, opLess, opLessOrEqual:
+	default:
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "unknown operator %q in filter", op.value)
+	}
+
+	v, ok := p.peek()
+	if !ok {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "expected a value after %q in filter", op.value)
+	}
+	p.pos++
+	expr := &attributeExpression{Path: path, Operator: operator}
+	switch {
+	case v.quoted:
+		expr.Value = v.value
+	case strings.EqualFold(v.value, "true"):
+		expr.Value = true
+	case strings.EqualFold(v.value, "false"):
+		expr.Value = false
+	case strings.EqualFold(v.value, "null"):
+		expr.Value = nil
+	default:
+		number, err := strconv.ParseFloat(v.value, 64)
+		if err != nil {
+			return nil, errBadRequest(ErrorTypeInvalidFilter, "invalid value %q in filter", v.value)
+		}
+		expr.Value = number
+	}
+	return expr, nil
+}
+
+// parseFilter parses a filter, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2.2
+func parseFilter(filter string) (filterExpression, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "unexpected %q in filter", t.value)
+	}
+	return f, nil
+}
+
+// lookupAttribute returns the value of the attribute of a JSON object, attribute names are case-insensitive
+func lookupAttribute(object map[string]any, name string) (string, any, bool) {
+	if v, ok := object[name]; ok {
+		return name, v, true
+	}
+	for key, v := range object {
+		if strings.EqualFold(key, name) {
+			return key, v, true
+		}
+	}
+	return "", nil, false
+}
+
+// attributeValue returns the value of the path of sub-attributes of a JSON object
+func attributeValue(object map[string]any, path string) any {
+	name, subPath, _ := strings.Cut(path, ".")
+	_, v, ok := lookupAttribute(object, name)
+	if !ok || subPath == "" {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		return attributeValue(v, subPath)
+	case []any:
+		values := make([]any, 0, len(v))
+		for _, element := range v {
+			if element, ok := element.(map[string]any); ok {
+				values = append(values, attributeValue(element, subPath))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// compareValue compares a value of a JSON object with the value of a filter, strings are compared case-insensitively
+func compareValue(operator string, actual, expected any) bool {
+	if values, ok := actual.([]any); ok {
+		for _, v := range values {
+			if compareValue(operator, v, expected) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch actual := actual.(type) {
+	case string:
+		expected, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		a, e := strings.ToLower(actual), strings.ToLower(expected)
+		switch operator {
+		case opEqual:
+			return a == e
+		case opNotEqual:
+			return a != e
+		case opContains:
+			return strings.Contains(a, e)
+		case opStartsWith:
+			return strings.HasPrefix(a, e)
+		case opEndsWith:
+			return strings.HasSuffix(a, e)
+		case opGreater:
+			return a > e
+		case opGreaterOrEqual:
+			return a >= e
+		case opLess:
+			return a < e
+		case opLessOrEqual:
+			return a <= e
+		}
+	case bool:
+		switch operator {
+		case opEqual:
+			return actual == expected
+		case opNotEqual:
+			return actual != expected
+		}
+	case float64:
+		expected, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case opEqual:
+			return actual == expected
+		case opNotEqual:
+			return actual != expected
+		case opGreater:
+			return actual > expected
+		case opGreaterOrEqual:
+			return actual >= expected
+		case opLess:
+			return actual < expected
+		case opLessOrEqual:
+			return actual <= expected
+		}
+	case nil:
+		switch operator {
+		case opEqual:
+			return expected == nil
+		case opNotEqual:
+			return expected != nil
+		}
+	}
+	return false
+}
+
+func isPresent(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []any:
+		for _, element := range v {
+			if isPresent(element) {
+				return true
+			}
+		}
+		return false
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+// matchFilter evaluates a filter on a JSON object, which is how the values of the paths of PATCH operations are
+// selected
+func matchFilter(f filterExpression, object map[string]any) bool {
+	switch f := f.(type) {
+	case *attributeExpression:
+		v := attributeValue(object, f.Path)
+		if f.Operator == opPresent {
+			return isPresent(v)
+		}
+		return compareValue(f.Operator, v, f.Value)
+	case *logicalExpression:
+		if f.Operator == "and" {
+			return matchFilter(f.Left, object) && matchFilter(f.Right, object)
+		}
+		return matchFilter(f.Left, object) || matchFilter(f.Right, object)
+	case *notExpression:
+		return !matchFilter(f.Filter, object)
+	case *valuePathExpression:
+		_, v, _ := lookupAttribute(object, f.Path)
+		values, _ := v.([]any)
+		for _, element := range values {
+			if element, ok := element.(map[string]any); ok && matchFilter(f.Filter, element) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := parseFilter(`userName eq "bjensen"`)
+	require.NoError(t, err)
+	assert.Equal(t, &attributeExpression{Path: "userName", Operator: opEqual, Value: "bjensen"}, f)
+
+	f, err = parseFilter(`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "J"`)
+	require.NoError(t, err)
+	assert.Equal(t, &attributeExpression{Path: "userName", Operator: opStartsWith, Value: "J"}, f)
+
+	f, err = parseFilter(`title pr and userType eq "Employee" or active EQ false`)
+	require.NoError(t, err)
+	assert.Equal(t, &logicalExpression{
+		Operator: "or",
+		Left: &logicalExpression{
+			Operator: "and",
+			Left:     &attributeExpression{Path: "title", Operator: opPresent},
+			Right:    &attributeExpression{Path: "userType", Operator: opEqual, Value: "Employee"},
+		},
+		Right: &attributeExpression{Path: "active", Operator: opEqual, Value: false},
+	}, f)
+
+	f, err = parseFilter(`not (displayName co "a \"b\"") and emails[type eq "work" and value co "@example.com"]`)
+	require.NoError(t, err)
+	assert.Equal(t, &logicalExpression{
+		Operator: "and",
+		Left:     &notExpression{Filter: &attributeExpression{Path: "displayName", Operator: opContains, Value: `a "b"`}},
+		Right: &valuePathExpression{
+			Path: "emails",
+			Filter: &logicalExpression{
+				Operator: "and",
+				Left:     &attributeExpression{Path: "type", Operator: opEqual, Value: "work"},
+				Right:    &attributeExpression{Path: "value", Operator: opContains, Value: "@example.com"},
+			},
+		},
+	}, f)
+
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName is "x"`,
+		`userName eq "x`,
+		`(userName eq "x"`,
+		`userName eq "x" and`,
+		`userName eq "x" foo`,
+		`emails[value eq "x"`,
+		`userName eq unquoted`,
+	} {
+		_, err := parseFilter(filter)
+		scimErr, ok := AsError(err)
+		if assert.True(t, ok, filter) {
+			assert.Equal(t, ErrorTypeInvalidFilter, scimErr.Type, filter)
+		}
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	object := map[string]any{
+		"userName": "BJensen",
+		"active":   true,
+		"name":     map[string]any{"givenName": "Barbara"},
+		"emails": []any{
+			map[string]any{"value": "bjensen@example.com", "type": "work"},
+			map[string]any{"value": "babs@example.org", "type": "home"},
+		},
+	}
+
+	for filter, expected := range map[string]bool{
+		`userName eq "bjensen"`:                             true,
+		`userName ne "bjensen"`:                             false,
+		`username sw "bj" and active eq true`:               true,
+		`active eq false or name.givenName ew "ara"`:        true,
+		`not (name.givenName pr)`:                           false,
+		`title pr`:                                          false,
+		`emails.value co "example.org"`:                     true,
+		`emails[type eq "work" and value ew "example.com"]`: true,
+		`emails[type eq "home" and value ew "example.com"]`: false,
+		`emails[type eq "other"] or userName gt "a"`:        true,
+		`name.givenName lt "Aaron"`:                         false,
+	} {
+		f, err := parseFilter(filter)
+		require.NoError(t, err, filter)
+		assert.Equal(t, expected, matchFilter(f, object), filter)
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"context"
+	"strconv"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	auth_module "code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	source_service "code.gitea.io/gitea/services/auth/source"
+	"code.gitea.io/gitea/services/auth/source/ldap"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/services/auth/source/saml"
+)
+
+// groupTeamMapping returns how the groups of the source map onto teams and whether users are removed from the
+// teams of the groups they leave
+func groupTeamMapping(source *auth_model.Source) (map[string]map[string][]string, bool, error) {
+	var groupTeamMap string
+	var performRemoval bool
+	switch cfg := source.Cfg.(type) {
+	case *oauth2.Source:
+		groupTeamMap, performRemoval = cfg.GroupTeamMap, cfg.GroupTeamMapRemoval
+	case *saml.Source:
+		groupTeamMap, performRemoval = cfg.GroupTeamMap, cfg.GroupTeamMapRemoval
+	case *ldap.Source:
+		groupTeamMap, performRemoval = cfg.GroupTeamMap, cfg.GroupTeamMapRemoval
+	}
+	mapping, err := auth_module.UnmarshalGroupTeamMapping(groupTeamMap)
+	return mapping, performRemoval, err
+}
+
+// syncTeams makes the team memberships of the user follow their SCIM groups
+func syncTeams(ctx context.Context, source *auth_model.Source, u *user_model.User) error {
+	mapping, performRemoval, err := groupTeamMapping(source)
+	if err != nil || len(mapping) == 0 {
+		return err
+	}
+	groups, err := auth_model.GetSCIMGroupsByUserID(ctx, source.ID, u.ID)
+	if err != nil {
+		return err
+	}
+	groupNames := make(container.Set[string], len(groups))
+	for _, g := range groups {
+		groupNames.Add(g.DisplayName)
+	}
+	return source_service.SyncGroupsToTeams(ctx, u, groupNames, mapping, performRemoval)
+}
+
+// syncTeamsOfUsers syncs the team memberships of the users who joined or left a group
+func syncTeamsOfUsers(ctx context.Context, source *auth_model.Source, userIDs []int64) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	users, err := user_model.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := syncTeams(ctx, source, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSourceGroup returns the group of the source with the SCIM ID
+func getSourceGroup(ctx context.Context, source *auth_model.Source, id string) (*auth_model.SCIMGroup, error) {
+	groupID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errNotFound("group %q not found", id)
+	}
+	g, err := auth_model.GetSCIMGroupByID(ctx, source.ID, groupID)
+	if err != nil {
+		if auth_model.IsErrSCIMGroupNotExist(err) {
+			return nil, errNotFound("group %q not found", id)
+		}
+		return nil, err
+	}
+	return g, nil
+}
+
+// toGroup returns the SCIM representation of the group
+func toGroup(ctx context.Context, g *auth_model.SCIMGroup, excludeMembers bool) (*Group, error) {
+	group := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          strconv.FormatInt(g.ID, 10),
+		ExternalID:  g.ExternalID,
+		DisplayName: g.DisplayName,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Created:      formatTime(g.CreatedUnix),
+			LastModified: formatTime(g.UpdatedUnix),
+			Location:     BaseURL() + "/Groups/" + strconv.FormatInt(g.ID, 10),
+		},
+	}
+	if excludeMembers {
+		return group, nil
+	}
+
+	memberIDs, err := auth_model.GetSCIMGroupMemberIDs(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := user_model.GetUsersByIDs(ctx, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range members {
+		group.Members = append(group.Members, Reference{
+			Value:   strconv.FormatInt(u.ID, 10),
+			Ref:     BaseURL() + "/Users/" + strconv.FormatInt(u.ID, 10),
+			Display: u.Name,
+		})
+	}
+	return group, nil
+}
+
+// memberIDs returns the IDs of the users of the source which the members reference
+func memberIDs(ctx context.Context, source *auth_model.Source, members []Reference) ([]int64, error) {
+	ids := make(container.Set[int64], len(members))
+	for _, member := range members {
+		u, err := getSourceUser(ctx, source, member.Value)
+		if err != nil {
+			if _, ok := AsError(err); ok {
+				return nil, errBadRequest(ErrorTypeInvalidValue, "the member %q is not a user of the source", member.Value)
+			}
+			return nil, err
+		}
+		ids.Add(u.ID)
+	}
+	return ids.Values(), nil
+}
+
+// setGroupMembers makes the users the members of the group, and returns the IDs of the users who joined or left
+func setGroupMembers(ctx context.Context, g *auth_model.SCIMGroup, userIDs []int64) ([]int64, error) {
+	currentIDs, err := auth_model.GetSCIMGroupMemberIDs(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	current := container.SetOf(currentIDs...)
+	wanted := container.SetOf(userIDs...)
+
+	var joined, left []int64
+	for id := range wanted {
+		if !current.Contains(id) {
+			joined = append(joined, id)
+		}
+	}
+	for id := range current {
+		if !wanted.Contains(id) {
+			left = append(left, id)
+		}
+	}
+	if err := auth_model.AddSCIMGroupMembers(ctx, g.ID, joined...); err != nil {
+		return nil, err
+	}
+	if err := auth_model.RemoveSCIMGroupMembers(ctx, g.ID, left...); err != nil {
+		return nil, err
+	}
+	return append(joined, left...), nil
+}
+
+func groupError(err error) error {
+	if auth_model.IsErrSCIMGroupAlreadyExist(err) {
+		return errConflict("%v", err)
+	}
+	return err
+}
+
+// ListGroups returns the groups of the source which match the filter
+func ListGroups(ctx context.Context, source *auth_model.Source, opts ListOptions) (*ListResponse, error) {
+	opts.normalize()
+	findOpts := auth_model.FindSCIMGroupsOptions{SourceID: source.ID}
+	if opts.Filter != "" {
+		f, err := parseFilter(opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if findOpts.Filter, err = filterCond(f, groupAttributeResolver); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := db.Count[auth_model.SCIMGroup](ctx, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*auth_model.SCIMGroup, 0, opts.Count)
+	if opts.Count > 0 {
+		if err := db.GetEngine(ctx).Where(findOpts.ToConds()).OrderBy(findOpts.ToOrders()).Limit(opts.Count, opts.StartIndex-1).Find(&groups); err != nil {
+			return nil, err
+		}
+	}
+
+	resources := make([]any, 0, len(groups))
+	for _, g := range groups {
+		group, err := toGroup(ctx, g, opts.ExcludeMembers)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, group)
+	}
+	return NewListResponse(total, opts.StartIndex, resources), nil
+}
+
+// GetGroup returns the group of the source with the SCIM ID
+func GetGroup(ctx context.Context, source *auth_model.Source, id string, excludeMembers bool) (*Group, error) {
+	g, err := getSourceGroup(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	return toGroup(ctx, g, excludeMembers)
+}
+
+// CreateGroup provisions a group of the source, its members join the teams which the group maps onto
+func CreateGroup(ctx context.Context, source *auth_model.Source, group *Group) (*Group, error) {
+	if group.DisplayName == "" {
+		return nil, errBadRequest(ErrorTypeInvalidValue, "displayName is required")
+	}
+	g := &auth_model.SCIMGroup{
+		SourceID:    source.ID,
+		DisplayName: group.DisplayName,
+		ExternalID:  group.ExternalID,
+	}
+	var changed []int64
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		userIDs, err := memberIDs(ctx, source, group.Members)
+		if err != nil {
+			return err
+		}
+		if err := auth_model.CreateSCIMGroup(ctx, g); err != nil {
+			return groupError(err)
+		}
+		changed, err = setGroupMembers(ctx, g, userIDs)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	log.Info("SCIM: the source %s provisioned the group %s", source.Name, g.DisplayName)
+
+	if err := syncTeamsOfUsers(ctx, source, changed); err != nil {
+		return nil, err
+	}
+	return toGroup(ctx, g, false)
+}
+
+// updateGroup applies the changes of the SCIM representation of the group
+func updateGroup(ctx context.Context, source *auth_model.Source, g *auth_model.SCIMGroup, updated *Group, updateMembers bool) error {
+	if updated.DisplayName == "" {
+		return errBadRequest(ErrorTypeInvalidValue, "displayName is required")
+	}
+	renamed := updated.DisplayName != g.DisplayName
+
+	var changed []int64
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if renamed || updated.ExternalID != g.ExternalID {
+			g.DisplayName = updated.DisplayName
+			g.ExternalID = updated.ExternalID
+			if err := auth_model.UpdateSCIMGroup(ctx, g); err != nil {
+				return groupError(err)
+			}
+		}
+		if !updateMembers {
+			return nil
+		}
+		userIDs, err := memberIDs(ctx, source, updated.Members)
+		if err != nil {
+			return err
+		}
+		changed, err = setGroupMembers(ctx, g, userIDs)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if renamed {
+		// a renamed group can map onto other teams, so all its members are synced
+		memberIDs, err := auth_model.GetSCIMGroupMemberIDs(ctx, g.ID)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, memberIDs...)
+	}
+	return syncTeamsOfUsers(ctx, source, container.SetOf(changed...).Values())
+}
+
+// ReplaceGroup replaces the display name, the external ID and the members of the group of the source
+func ReplaceGroup(ctx context.Context, source *auth_model.Source, id string, group *Group) (*Group, error) {
+	g, err := getSourceGroup(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := updateGroup(ctx, source, g, group, true); err != nil {
+		return nil, err
+	}
+	return toGroup(ctx, g, false)
+}
+
+// PatchGroup applies the operations of a PATCH request to the group of the source
+func PatchGroup(ctx context.Context, source *auth_model.Source, id string, patch *PatchOp) (*Group, error) {
+	g, err := getSourceGroup(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toGroup(ctx, g, false)
+	if err != nil {
+		return nil, err
+	}
+	object, err := toObject(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPatchOperations(object, patch.Operations); err != nil {
+		return nil, err
+	}
+	updated := &Group{}
+	if err := fromObject(object, updated); err != nil {
+		return nil, err
+	}
+	if err := updateGroup(ctx, source, g, updated, true); err != nil {
+		return nil, err
+	}
+	return toGroup(ctx, g, false)
+}
+
+// DeleteGroup deletes the group of the source, its members leave the teams which the group maps onto
+func DeleteGroup(ctx context.Context, source *auth_model.Source, id string) error {
+	g, err := getSourceGroup(ctx, source, id)
+	if err != nil {
+		return err
+	}
+	memberIDs, err := auth_model.GetSCIMGroupMemberIDs(ctx, g.ID)
+	if err != nil {
+		return err
+	}
+	if err := auth_model.DeleteSCIMGroup(ctx, g); err != nil {
+		return err
+	}
+	log.Info("SCIM: the source %s deleted the group %s", source.Name, g.DisplayName)
+
+	return syncTeamsOfUsers(ctx, source, memberIDs)
+}
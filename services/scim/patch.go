@@ -0,0 +1,247 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+)
+
+// The operations of PATCH requests, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.5.2
+const (
+	patchAdd     = "add"
+	patchRemove  = "remove"
+	patchReplace = "replace"
+)
+
+// patchPath is a parsed path of a PATCH operation, e.g. `emails[type eq "work"].value`
+type patchPath struct {
+	Attribute    string
+	Filter       filterExpression
+	SubAttribute string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	path = stripSchema(strings.TrimSpace(path))
+	open := strings.IndexByte(path, '[')
+	if open < 0 {
+		attribute, subAttribute, _ := strings.Cut(path, ".")
+		if attribute == "" {
+			return nil, errBadRequest(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		return &patchPath{Attribute: attribute, SubAttribute: subAttribute}, nil
+	}
+
+	end := strings.LastIndexByte(path, ']')
+	if end < open || open == 0 {
+		return nil, errBadRequest(ErrorTypeInvalidPath, "invalid path %q", path)
+	}
+	f, err := parseFilter(path[open+1 : end])
+	if err != nil {
+		return nil, errBadRequest(ErrorTypeInvalidPath, "invalid filter in path %q", path)
+	}
+	p := &patchPath{Attribute: path[:open], Filter: f}
+	if rest := path[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+			return nil, errBadRequest(ErrorTypeInvalidPath, "invalid path %q", path)
+		}
+		p.SubAttribute = rest[1:]
+	}
+	return p, nil
+}
+
+// toObject converts a resource to a JSON object
+func toObject(resource any) (map[string]any, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]any{}
+	return object, json.Unmarshal(data, &object)
+}
+
+// fromObject converts a JSON object to a resource
+func fromObject(object map[string]any, resource any) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, resource); err != nil {
+		return errBadRequest(ErrorTypeInvalidValue, "invalid value: %v", err)
+	}
+	return nil
+}
+
+// applyPatchOperations applies the operations of a PATCH request to the JSON object of a resource
+func applyPatchOperations(object map[string]any, operations []PatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		switch op {
+		case patchAdd, patchRemove, patchReplace:
+		default:
+			return errBadRequest(ErrorTypeInvalidSyntax, "unknown operation %q", operation.Op)
+		}
+
+		if operation.Path == "" {
+			// without a path, the value holds the attributes to add or replace
+			if op == patchRemove {
+				return errBadRequest(ErrorTypeNoTarget, "a remove operation requires a path")
+			}
+			values, ok := operation.Value.(map[string]any)
+			if !ok {
+				return errBadRequest(ErrorTypeInvalidValue, "the value of an operation without a path must be an object")
+			}
+			for name, value := range values {
+				if strings.HasPrefix(strings.ToLower(name), "urn:") && stripSchema(name) == name {
+					// attributes of schema extensions are not supported
+					continue
+				}
+				path, err := parsePatchPath(name)
+				if err != nil {
+					return err
+				}
+				if err := applyPatchOperation(object, op, path, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		path, err := parsePatchPath(operation.Path)
+		if err != nil {
+			return err
+		}
+		if err := applyPatchOperation(object, op, path, operation.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPatchOperation(object map[string]any, op string, path *patchPath, value any) error {
+	key, current, exists := lookupAttribute(object, path.Attribute)
+	if !exists {
+		key = path.Attribute
+	}
+
+	if path.Filter == nil {
+		if path.SubAttribute != "" {
+			complexValue, ok := current.(map[string]any)
+			if !ok {
+				if op == patchRemove {
+					return nil
+				}
+				complexValue = map[string]any{}
+				object[key] = complexValue
+			}
+			return applyPatchOperation(complexValue, op, &patchPath{Attribute: path.SubAttribute}, value)
+		}
+
+		switch op {
+		case patchAdd:
+			if values, ok := current.([]any); ok {
+				if added, ok := value.([]any); ok {
+					object[key] = append(values, added...)
+				} else {
+					object[key] = append(values, value)
+				}
+				return nil
+			}
+			if added, ok := value.(map[string]any); ok {
+				if complexValue, ok := current.(map[string]any); ok {
+					for name, v := range added {
+						complexValue[name] = v
+					}
+					return nil
+				}
+			}
+			object[key] = value
+		case patchReplace:
+			object[key] = value
+		case patchRemove:
+			values, ok := current.([]any)
+			if !ok || value == nil {
+				delete(object, key)
+				return nil
+			}
+			// some identity providers remove values of multi-valued attributes by listing them instead of
+			// filtering them in the path
+			removed, ok := value.([]any)
+			if !ok {
+				removed = []any{value}
+			}
+			object[key] = removeListedValues(values, removed)
+		}
+		return nil
+	}
+
+	values, _ := current.([]any)
+	remaining := make([]any, 0, len(values))
+	matched := false
+	for _, element := range values {
+		complexValue, ok := element.(map[string]any)
+		if !ok || !matchFilter(path.Filter, complexValue) {
+			remaining = append(remaining, element)
+			continue
+		}
+		matched = true
+
+		switch {
+		case op == patchRemove && path.SubAttribute == "":
+			continue
+		case op == patchRemove:
+			if name, _, ok := lookupAttribute(complexValue, path.SubAttribute); ok {
+				delete(complexValue, name)
+			}
+		case path.SubAttribute == "":
+			if op == patchAdd {
+				return errBadRequest(ErrorTypeInvalidPath, "cannot add to filtered values of %q", path.Attribute)
+			}
+			replacement, ok := value.(map[string]any)
+			if !ok {
+				return errBadRequest(ErrorTypeInvalidValue, "the values of %q must be objects", path.Attribute)
+			}
+			complexValue = replacement
+		default:
+			name, _, ok := lookupAttribute(complexValue, path.SubAttribute)
+			if !ok {
+				name = path.SubAttribute
+			}
+			complexValue[name] = value
+		}
+		remaining = append(remaining, complexValue)
+	}
+	if !matched && op != patchRemove {
+		return errBadRequest(ErrorTypeNoTarget, "no values of %q match the filter", path.Attribute)
+	}
+	object[key] = remaining
+	return nil
+}
+
+// removeListedValues removes the values whose "value" sub-attributes are listed
+func removeListedValues(values, removed []any) []any {
+	removedValues := make(map[string]bool, len(removed))
+	for _, v := range removed {
+		if complexValue, ok := v.(map[string]any); ok {
+			if _, v, ok := lookupAttribute(complexValue, "value"); ok {
+				if s, ok := v.(string); ok {
+					removedValues[strings.ToLower(s)] = true
+				}
+			}
+		}
+	}
+	remaining := make([]any, 0, len(values))
+	for _, v := range values {
+		if complexValue, ok := v.(map[string]any); ok {
+			if _, v, ok := lookupAttribute(complexValue, "value"); ok {
+				if s, ok := v.(string); ok && removedValues[strings.ToLower(s)] {
+					continue
+				}
+			}
+		}
+		remaining = append(remaining, v)
+	}
+	return remaining
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatchOperations(t *testing.T) {
+	newUser := func() map[string]any {
+		active := Boolean(true)
+		object, err := toObject(&User{
+			Schemas:  []string{SchemaUser},
+			UserName: "bjensen",
+			Name:     &Name{Formatted: "Barbara Jensen"},
+			Emails:   []Email{{Value: "bjensen@example.com", Type: "work", Primary: true}},
+			Active:   &active,
+		})
+		require.NoError(t, err)
+		return object
+	}
+	patch := func(t *testing.T, object map[string]any, operations string) *User {
+		var patchOp PatchOp
+		require.NoError(t, json.Unmarshal([]byte(`{"Operations":`+operations+`}`), &patchOp))
+		require.NoError(t, applyPatchOperations(object, patchOp.Operations))
+		user := &User{}
+		require.NoError(t, fromObject(object, user))
+		return user
+	}
+
+	t.Run("ReplaceWithoutPath", func(t *testing.T) {
+		user := patch(t, newUser(), `[{"op":"Replace","value":{"active":"False","name.givenName":"Babs","displayName":"Babs Jensen"}}]`)
+		assert.False(t, bool(*user.Active))
+		assert.Equal(t, "Babs", user.Name.GivenName)
+		assert.Equal(t, "Barbara Jensen", user.Name.Formatted)
+		assert.Equal(t, "Babs Jensen", user.DisplayName)
+	})
+
+	t.Run("ReplaceFilteredSubAttribute", func(t *testing.T) {
+		user := patch(t, newUser(), `[{"op":"replace","path":"emails[type eq \"work\"].value","value":"babs@example.com"}]`)
+		assert.Equal(t, "babs@example.com", user.PrimaryEmail())
+	})
+
+	t.Run("AddAndRemove", func(t *testing.T) {
+		user := patch(t, newUser(), `[
+			{"op":"add","path":"emails","value":[{"value":"babs@example.org","type":"home"}]},
+			{"op":"remove","path":"emails[type eq \"work\"]"},
+			{"op":"add","path":"urn:ietf:params:scim:schemas:core:2.0:User:userName","value":"babs"},
+			{"op":"remove","path":"name.formatted"}
+		]`)
+		assert.Equal(t, []Email{{Value: "babs@example.org", Type: "home"}}, user.Emails)
+		assert.Equal(t, "babs", user.UserName)
+		assert.Empty(t, user.Name.Formatted)
+	})
+
+	t.Run("RemoveListedMembers", func(t *testing.T) {
+		object, err := toObject(&Group{
+			DisplayName: "developers",
+			Members:     []Reference{{Value: "1"}, {Value: "2"}, {Value: "3"}},
+		})
+		require.NoError(t, err)
+		var patchOp PatchOp
+		require.NoError(t, json.Unmarshal([]byte(`{"Operations":[
+			{"op":"Remove","path":"members","value":[{"value":"1"}]},
+			{"op":"remove","path":"members[value eq \"3\"]"},
+			{"op":"add","path":"members","value":[{"value":"4"}]}
+		]}`), &patchOp))
+		require.NoError(t, applyPatchOperations(object, patchOp.Operations))
+		group := &Group{}
+		require.NoError(t, fromObject(object, group))
+		assert.Equal(t, []Reference{{Value: "2"}, {Value: "4"}}, group.Members)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for operations, errorType := range map[string]string{
+			`[{"op":"move","path":"userName","value":"x"}]`:                          ErrorTypeInvalidSyntax,
+			`[{"op":"remove"}]`:                                                      ErrorTypeNoTarget,
+			`[{"op":"replace","value":"x"}]`:                                         ErrorTypeInvalidValue,
+			`[{"op":"replace","path":"emails[type eq \"home\"].value","value":"x"}]`: ErrorTypeNoTarget,
+			`[{"op":"replace","path":"emails[type eq].value","value":"x"}]`:          ErrorTypeInvalidPath,
+		} {
+			var patchOp PatchOp
+			require.NoError(t, json.Unmarshal([]byte(`{"Operations":`+operations+`}`), &patchOp))
+			scimErr, ok := AsError(applyPatchOperations(newUser(), patchOp.Operations))
+			if assert.True(t, ok, operations) {
+				assert.Equal(t, errorType, scimErr.Type, operations)
+			}
+		}
+	})
+}
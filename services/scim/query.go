@@ -0,0 +1,234 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// attributeResolver returns the condition on the table of a resource which an attribute expression filters by
+type attributeResolver func(expr *attributeExpression) (builder.Cond, error)
+
+// filterCond converts a filter to a condition on the table of a resource
+func filterCond(f filterExpression, resolve attributeResolver) (builder.Cond, error) {
+	switch f := f.(type) {
+	case *attributeExpression:
+		return resolve(f)
+	case *logicalExpression:
+		left, err := filterCond(f.Left, resolve)
+		if err != nil {
+			return nil, err
+		}
+		right, err := filterCond(f.Right, resolve)
+		if err != nil {
+			return nil, err
+		}
+		if f.Operator == "and" {
+			return builder.And(left, right), nil
+		}
+		return builder.Or(left, right), nil
+	case *notExpression:
+		cond, err := filterCond(f.Filter, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return builder.Not{cond}, nil
+	case *valuePathExpression:
+		// the resources have at most one value of the multi-valued attributes which can be filtered, so the filter
+		// of the values is the filter of their sub-attributes
+		return filterCond(prefixPaths(f.Filter, f.Path), resolve)
+	}
+	return nil, errBadRequest(ErrorTypeInvalidFilter, "invalid filter")
+}
+
+func prefixPaths(f filterExpression, prefix string) filterExpression {
+	switch f := f.(type) {
+	case *attributeExpression:
+		return &attributeExpression{Path: prefix + "." + f.Path, Operator: f.Operator, Value: f.Value}
+	case *logicalExpression:
+		return &logicalExpression{Operator: f.Operator, Left: prefixPaths(f.Left, prefix), Right: prefixPaths(f.Right, prefix)}
+	case *notExpression:
+		return &notExpression{Filter: prefixPaths(f.Filter, prefix)}
+	}
+	return f
+}
+
+func toUpper(value string) string {
+	if setting.Database.Type.IsSQLite3() {
+		return util.ToUpperASCII(value)
+	}
+	return strings.ToUpper(value)
+}
+
+// stringCond is the condition of an attribute expression on a string column
+func stringCond(column string, expr *attributeExpression, caseExact bool) (builder.Cond, error) {
+	if expr.Operator == opPresent {
+		return builder.And(builder.NotNull{column}, builder.Neq{column: ""}), nil
+	}
+	value, ok := expr.Value.(string)
+	if !ok {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "%q must be compared with a string", expr.Path)
+	}
+	if !caseExact {
+		column = "UPPER(" + column + ")"
+		value = toUpper(value)
+	}
+	switch expr.Operator {
+	case opEqual:
+		return builder.Eq{column: value}, nil
+	case opNotEqual:
+		return builder.Neq{column: value}, nil
+	case opContains:
+		return builder.Like{column, "%" + value + "%"}, nil
+	case opStartsWith:
+		return builder.Like{column, value + "%"}, nil
+	case opEndsWith:
+		return builder.Like{column, "%" + value}, nil
+	case opGreater:
+		return builder.Gt{column: value}, nil
+	case opGreaterOrEqual:
+		return builder.Gte{column: value}, nil
+	case opLess:
+		return builder.Lt{column: value}, nil
+	case opLessOrEqual:
+		return builder.Lte{column: value}, nil
+	}
+	return nil, errBadRequest(ErrorTypeInvalidFilter, "invalid operator %q", expr.Operator)
+}
+
+// idCond is the condition of an attribute expression on an ID column, whose SCIM values are strings
+func idCond(column string, expr *attributeExpression) (builder.Cond, error) {
+	if expr.Operator == opPresent {
+		return builder.Expr("1 = 1"), nil
+	}
+	value, ok := expr.Value.(string)
+	if !ok {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "%q must be compared with a string", expr.Path)
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	switch expr.Operator {
+	case opEqual:
+		if err != nil {
+			return builder.Expr("1 = 0"), nil
+		}
+		return builder.Eq{column: id}, nil
+	case opNotEqual:
+		if err != nil {
+			return builder.Expr("1 = 1"), nil
+		}
+		return builder.Neq{column: id}, nil
+	}
+	return nil, errBadRequest(ErrorTypeInvalidFilter, "%q only supports the eq and ne operators", expr.Path)
+}
+
+// timeCond is the condition of an attribute expression on a timestamp column, whose SCIM values are date times
+func timeCond(column string, expr *attributeExpression) (builder.Cond, error) {
+	if expr.Operator == opPresent {
+		return builder.Expr("1 = 1"), nil
+	}
+	value, ok := expr.Value.(string)
+	if !ok {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "%q must be compared with a date time", expr.Path)
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "invalid date time %q", value)
+	}
+	switch expr.Operator {
+	case opEqual:
+		return builder.Eq{column: t.Unix()}, nil
+	case opNotEqual:
+		return builder.Neq{column: t.Unix()}, nil
+	case opGreater:
+		return builder.Gt{column: t.Unix()}, nil
+	case opGreaterOrEqual:
+		return builder.Gte{column: t.Unix()}, nil
+	case opLess:
+		return builder.Lt{column: t.Unix()}, nil
+	case opLessOrEqual:
+		return builder.Lte{column: t.Unix()}, nil
+	}
+	return nil, errBadRequest(ErrorTypeInvalidFilter, "%q does not support the %s operator", expr.Path, expr.Operator)
+}
+
+// activeCond is the condition of an attribute expression on the active attribute of users, who are active if they
+// are activated and are allowed to sign in
+func activeCond(expr *attributeExpression) (builder.Cond, error) {
+	if expr.Operator == opPresent {
+		return builder.Expr("1 = 1"), nil
+	}
+	value, ok := expr.Value.(bool)
+	if !ok || (expr.Operator != opEqual && expr.Operator != opNotEqual) {
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "%q must be compared with a boolean", expr.Path)
+	}
+	active := builder.And(builder.Eq{"is_active": true}, builder.Eq{"prohibit_login": false})
+	if value == (expr.Operator == opEqual) {
+		return active, nil
+	}
+	return builder.Not{active}, nil
+}
+
+func userAttributeResolver(sourceID int64) attributeResolver {
+	return func(expr *attributeExpression) (builder.Cond, error) {
+		switch strings.ToLower(expr.Path) {
+		case "id":
+			return idCond("id", expr)
+		case "username":
+			return stringCond("login_name", expr, false)
+		case "externalid":
+			cond, err := stringCond("external_id", expr, true)
+			if err != nil {
+				return nil, err
+			}
+			return builder.In("id", builder.Select("user_id").From("external_login_user").
+				Where(builder.And(builder.Eq{"login_source_id": sourceID}, cond))), nil
+		case "displayname", "name.formatted":
+			return stringCond("full_name", expr, false)
+		case "emails", "emails.value":
+			return stringCond("email", expr, false)
+		case "active":
+			return activeCond(expr)
+		case "groups", "groups.value":
+			cond, err := idCond("group_id", expr)
+			if err != nil {
+				return nil, err
+			}
+			return builder.In("id", builder.Select("user_id").From("scim_group_member").Where(cond)), nil
+		case "meta.created":
+			return timeCond("created_unix", expr)
+		case "meta.lastmodified":
+			return timeCond("updated_unix", expr)
+		}
+		return nil, errBadRequest(ErrorTypeInvalidFilter, "filtering by %q is not supported", expr.Path)
+	}
+}
+
+func groupAttributeResolver(expr *attributeExpression) (builder.Cond, error) {
+	switch strings.ToLower(expr.Path) {
+	case "id":
+		return idCond("id", expr)
+	case "displayname":
+		return stringCond("display_name", expr, false)
+	case "externalid":
+		return stringCond("external_id", expr, true)
+	case "members", "members.value":
+		cond, err := idCond("user_id", expr)
+		if err != nil {
+			return nil, err
+		}
+		return builder.In("id", builder.Select("group_id").From("scim_group_member").Where(cond)), nil
+	case "meta.created":
+		return timeCond("created_unix", expr)
+	case "meta.lastmodified":
+		return timeCond("updated_unix", expr)
+	}
+	return nil, errBadRequest(ErrorTypeInvalidFilter, "filtering by %q is not supported", expr.Path)
+}
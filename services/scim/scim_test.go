@@ -0,0 +1,181 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"net/http"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	auth_service "code.gitea.io/gitea/services/auth"
+	"code.gitea.io/gitea/services/auth/source/saml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestSource(t *testing.T) *auth_model.Source {
+	source := &auth_model.Source{
+		Type:     auth_model.SAML,
+		Name:     "scim-idp",
+		IsActive: true,
+		Cfg: &saml.Source{
+			GroupTeamMap:        `{"developers": {"org3": ["team1"]}}`,
+			GroupTeamMapRemoval: true,
+		},
+	}
+	require.NoError(t, auth_model.CreateSource(db.DefaultContext, source))
+	return source
+}
+
+func TestProvisionUser(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+	source := createTestSource(t)
+
+	active := Boolean(true)
+	user, err := CreateUser(ctx, source, &User{
+		UserName:   "Barbara.Jensen@example.com",
+		ExternalID: "701984",
+		Name:       &Name{GivenName: "Barbara", FamilyName: "Jensen"},
+		Active:     &active,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Barbara.Jensen@example.com", user.UserName)
+	assert.Equal(t, "Barbara.Jensen@example.com", user.PrimaryEmail())
+	assert.Equal(t, "Barbara Jensen", user.DisplayName)
+	assert.Equal(t, "701984", user.ExternalID)
+	u := unittest.AssertExistsAndLoadBean(t, &user_model.User{LoginSource: source.ID, LoginName: "Barbara.Jensen@example.com"})
+	// the username is derived from the local part of the email address
+	assert.Equal(t, "Barbara-Jensen", u.Name)
+	assert.Equal(t, source.Type, u.LoginType)
+	unittest.AssertCount(t, &user_model.ExternalLoginUser{ExternalID: "701984", LoginSourceID: source.ID, UserID: u.ID}, 1)
+
+	_, err = CreateUser(ctx, source, &User{UserName: "barbara.jensen@example.com"})
+	scimErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusConflict, scimErr.StatusCode())
+	assert.Equal(t, ErrorTypeUniqueness, scimErr.Type)
+
+	for filter, total := range map[string]int64{
+		`userName eq "barbara.jensen@EXAMPLE.com"`: 1,
+		`externalId eq "701984"`:                   1,
+		`externalId eq "701985"`:                   0,
+		`emails[value ew "@example.com"]`:          1,
+		`active eq true and displayName sw "Barb"`: 1,
+		`not (active eq true)`:                     0,
+		`id eq "` + user.ID + `"`:                  1,
+	} {
+		resp, err := ListUsers(ctx, source, ListOptions{Filter: filter, Count: 10})
+		require.NoError(t, err, filter)
+		assert.EqualValues(t, total, resp.TotalResults, filter)
+		assert.Len(t, resp.Resources, int(total), filter)
+	}
+
+	// users of other sources are not found
+	_, err = GetUser(ctx, source, "2")
+	scimErr, ok = AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, scimErr.StatusCode())
+
+	token := &auth_model.AccessToken{UID: u.ID, Name: "scim-test"}
+	require.NoError(t, auth_model.NewAccessToken(ctx, token))
+	// the generation cached before the deactivation is forgotten once it is committed
+	generation, err := auth_service.GetSessionsGeneration(ctx, u.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, generation)
+
+	user, err = PatchUser(ctx, source, user.ID, &PatchOp{Operations: []PatchOperation{
+		{Op: "Replace", Path: "active", Value: "False"},
+		{Op: "replace", Path: `emails[primary eq true].value`, Value: "babs@example.com"},
+	}})
+	require.NoError(t, err)
+	assert.False(t, bool(*user.Active))
+	assert.Equal(t, "babs@example.com", user.PrimaryEmail())
+	u = unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: u.ID})
+	assert.False(t, u.IsActive)
+	assert.True(t, u.ProhibitLogin)
+	assert.Equal(t, "babs@example.com", u.Email)
+	unittest.AssertNotExistsBean(t, &auth_model.AccessToken{ID: token.ID})
+	generation, err = auth_service.GetSessionsGeneration(ctx, u.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, generation)
+
+	user, err = PatchUser(ctx, source, user.ID, &PatchOp{Operations: []PatchOperation{
+		{Op: "replace", Value: map[string]any{"active": true}},
+	}})
+	require.NoError(t, err)
+	assert.True(t, bool(*user.Active))
+}
+
+func TestProvisionGroup(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+	ctx := db.DefaultContext
+	source := createTestSource(t)
+
+	barbara, err := CreateUser(ctx, source, &User{UserName: "bjensen", Emails: []Email{{Value: "bjensen@example.com"}}})
+	require.NoError(t, err)
+	jim, err := CreateUser(ctx, source, &User{UserName: "jsmith", Emails: []Email{{Value: "jsmith@example.com"}}})
+	require.NoError(t, err)
+	barbaraUser := unittest.AssertExistsAndLoadBean(t, &user_model.User{LoginSource: source.ID, LoginName: "bjensen"})
+	jimUser := unittest.AssertExistsAndLoadBean(t, &user_model.User{LoginSource: source.ID, LoginName: "jsmith"})
+	team1 := &organization.TeamUser{OrgID: 3, TeamID: 2}
+
+	isTeamMember := func(u *user_model.User) bool {
+		isMember, err := organization.IsTeamMember(ctx, team1.OrgID, team1.TeamID, u.ID)
+		require.NoError(t, err)
+		return isMember
+	}
+
+	group, err := CreateGroup(ctx, source, &Group{
+		DisplayName: "developers",
+		Members:     []Reference{{Value: barbara.ID}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, group.Members, 1)
+	assert.True(t, isTeamMember(barbaraUser))
+	assert.False(t, isTeamMember(jimUser))
+
+	_, err = CreateGroup(ctx, source, &Group{DisplayName: "Developers2", Members: []Reference{{Value: "1"}}})
+	scimErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, ErrorTypeInvalidValue, scimErr.Type)
+
+	group, err = PatchGroup(ctx, source, group.ID, &PatchOp{Operations: []PatchOperation{
+		{Op: "add", Path: "members", Value: []any{map[string]any{"value": jim.ID}}},
+		{Op: "remove", Path: `members[value eq "` + barbara.ID + `"]`},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: jim.ID, Ref: BaseURL() + "/Users/" + jim.ID, Display: "jsmith"}}, group.Members)
+	assert.False(t, isTeamMember(barbaraUser))
+	assert.True(t, isTeamMember(jimUser))
+
+	resp, err := ListGroups(ctx, source, ListOptions{Filter: `displayName eq "DEVELOPERS" and members[value eq "` + jim.ID + `"]`, Count: 10, ExcludeMembers: true})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resp.TotalResults)
+	assert.Empty(t, resp.Resources[0].(*Group).Members)
+
+	user, err := GetUser(ctx, source, jim.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []Reference{{Value: group.ID, Ref: BaseURL() + "/Groups/" + group.ID, Display: "developers"}}, user.Groups)
+
+	// deprovisioning removes the user from the groups and so from the teams
+	require.NoError(t, DeleteUser(ctx, source, jim.ID))
+	assert.False(t, isTeamMember(jimUser))
+	group, err = GetGroup(ctx, source, group.ID, false)
+	require.NoError(t, err)
+	assert.Empty(t, group.Members)
+
+	group, err = ReplaceGroup(ctx, source, group.ID, &Group{DisplayName: "developers", Members: []Reference{{Value: barbara.ID}}})
+	require.NoError(t, err)
+	assert.True(t, isTeamMember(barbaraUser))
+
+	require.NoError(t, DeleteGroup(ctx, source, group.ID))
+	assert.False(t, isTeamMember(barbaraUser))
+	unittest.AssertNotExistsBean(t, &auth_model.SCIMGroupMember{UserID: barbaraUser.ID})
+}
@@ -0,0 +1,461 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package scim
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	auth_service "code.gitea.io/gitea/services/auth"
+	user_service "code.gitea.io/gitea/services/user"
+
+	"xorm.io/builder"
+)
+
+// MaxResults is the maximum number of resources in a list response
+const MaxResults = 200
+
+// BaseURL is the URL of the SCIM API
+func BaseURL() string {
+	return setting.AppURL + "scim/v2"
+}
+
+// ListOptions are the options of a query, see https://datatracker.ietf.org/doc/html/rfc7644#section-3.4.2
+type ListOptions struct {
+	Filter string
+	// StartIndex is the 1-based index of the first resource
+	StartIndex int
+	Count      int
+	// ExcludeMembers omits the members of groups, which can be many
+	ExcludeMembers bool
+}
+
+func (opts *ListOptions) normalize() {
+	if opts.StartIndex < 1 {
+		opts.StartIndex = 1
+	}
+	if opts.Count < 0 {
+		opts.Count = 0
+	} else if opts.Count > MaxResults {
+		opts.Count = MaxResults
+	}
+}
+
+func formatTime(t timeutil.TimeStamp) string {
+	return t.AsTime().UTC().Format(time.RFC3339)
+}
+
+func isActive(u *user_model.User) bool {
+	return u.IsActive && !u.ProhibitLogin
+}
+
+// getSourceUser returns the user of the source with the SCIM ID
+func getSourceUser(ctx context.Context, source *auth_model.Source, id string) (*user_model.User, error) {
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, errNotFound("user %q not found", id)
+	}
+	u, err := user_model.GetUserByID(ctx, userID)
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			return nil, errNotFound("user %q not found", id)
+		}
+		return nil, err
+	}
+	if u.LoginSource != source.ID || u.Type != user_model.UserTypeIndividual {
+		return nil, errNotFound("user %q not found", id)
+	}
+	return u, nil
+}
+
+func getExternalID(ctx context.Context, source *auth_model.Source, u *user_model.User) (string, error) {
+	externalLoginUser := &user_model.ExternalLoginUser{}
+	has, err := db.GetEngine(ctx).Where("user_id = ? AND login_source_id = ?", u.ID, source.ID).Get(externalLoginUser)
+	if err != nil || !has {
+		return "", err
+	}
+	return externalLoginUser.ExternalID, nil
+}
+
+// setExternalID links the user to the ID of the identity provider, which is how the user signs in through the source
+func setExternalID(ctx context.Context, source *auth_model.Source, u *user_model.User, externalID string) error {
+	if _, err := db.GetEngine(ctx).Delete(&user_model.ExternalLoginUser{UserID: u.ID, LoginSourceID: source.ID}); err != nil {
+		return err
+	}
+	if externalID == "" {
+		return nil
+	}
+	err := user_model.LinkExternalToUser(ctx, u, &user_model.ExternalLoginUser{
+		ExternalID:    externalID,
+		UserID:        u.ID,
+		LoginSourceID: source.ID,
+		Provider:      source.Name,
+		Email:         u.Email,
+		Name:          u.FullName,
+		NickName:      u.Name,
+	})
+	if user_model.IsErrExternalLoginUserAlreadyExist(err) {
+		return errConflict("the external ID %q is already used", externalID)
+	}
+	return err
+}
+
+// toUser returns the SCIM representation of the user
+func toUser(ctx context.Context, source *auth_model.Source, u *user_model.User) (*User, error) {
+	externalID, err := getExternalID(ctx, source, u)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := auth_model.GetSCIMGroupsByUserID(ctx, source.ID, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	active := Boolean(isActive(u))
+	user := &User{
+		Schemas:     []string{SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		ExternalID:  externalID,
+		UserName:    u.LoginName,
+		DisplayName: u.FullName,
+		Active:      &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Created:      formatTime(u.CreatedUnix),
+			LastModified: formatTime(u.UpdatedUnix),
+			Location:     BaseURL() + "/Users/" + strconv.FormatInt(u.ID, 10),
+		},
+	}
+	if u.FullName != "" {
+		user.Name = &Name{Formatted: u.FullName}
+	}
+	if u.Email != "" {
+		user.Emails = []Email{{Value: u.Email, Primary: true}}
+	}
+	for _, g := range groups {
+		user.Groups = append(user.Groups, Reference{
+			Value:   strconv.FormatInt(g.ID, 10),
+			Ref:     BaseURL() + "/Groups/" + strconv.FormatInt(g.ID, 10),
+			Display: g.DisplayName,
+		})
+	}
+	return user, nil
+}
+
+// userNameFor returns the name of the user whose SCIM userName is given. The userName is often an email address, in
+// which case the local part is used.
+func userNameFor(userName string) (string, error) {
+	if user_model.IsUsableUsername(userName) == nil {
+		return userName, nil
+	}
+	localPart, _, _ := strings.Cut(userName, "@")
+	name, err := user_model.NormalizeUserName(localPart)
+	if !setting.Service.AllowDotsInUsernames {
+		name = strings.ReplaceAll(name, ".", "-")
+	}
+	if err != nil || user_model.IsUsableUsername(name) != nil {
+		return "", errBadRequest(ErrorTypeInvalidValue, "no valid username can be derived from %q", userName)
+	}
+	return name, nil
+}
+
+// userError converts the errors of creating or updating a user to SCIM errors
+func userError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case user_model.IsErrUserAlreadyExist(err):
+		return errConflict("%v", err)
+	case user_model.IsErrEmailAlreadyUsed(err):
+		return errConflict("%v", err)
+	case user_model.IsErrEmailInvalid(err), user_model.IsErrEmailCharIsNotSupported(err),
+		db.IsErrNameReserved(err), db.IsErrNamePatternNotAllowed(err), db.IsErrNameCharsNotAllowed(err):
+		return errBadRequest(ErrorTypeInvalidValue, "%v", err)
+	}
+	return err
+}
+
+func checkUserNameUnique(ctx context.Context, source *auth_model.Source, userName string, userID int64) error {
+	has, err := db.GetEngine(ctx).Where(builder.And(
+		builder.Eq{"login_source": source.ID},
+		builder.Eq{"UPPER(login_name)": toUpper(userName)},
+		builder.Neq{"id": userID},
+	)).Exist(new(user_model.User))
+	if err != nil {
+		return err
+	} else if has {
+		return errConflict("the userName %q is already used", userName)
+	}
+	return nil
+}
+
+// ListUsers returns the users of the source which match the filter
+func ListUsers(ctx context.Context, source *auth_model.Source, opts ListOptions) (*ListResponse, error) {
+	opts.normalize()
+	cond := builder.NewCond().And(builder.Eq{"login_source": source.ID}, builder.Eq{"type": user_model.UserTypeIndividual})
+	if opts.Filter != "" {
+		f, err := parseFilter(opts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := filterCond(f, userAttributeResolver(source.ID))
+		if err != nil {
+			return nil, err
+		}
+		cond = cond.And(filter)
+	}
+
+	total, err := db.GetEngine(ctx).Where(cond).Count(&user_model.User{})
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*user_model.User, 0, opts.Count)
+	if opts.Count > 0 {
+		if err := db.GetEngine(ctx).Where(cond).Asc("id").Limit(opts.Count, opts.StartIndex-1).Find(&users); err != nil {
+			return nil, err
+		}
+	}
+
+	resources := make([]any, 0, len(users))
+	for _, u := range users {
+		user, err := toUser(ctx, source, u)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, user)
+	}
+	return NewListResponse(total, opts.StartIndex, resources), nil
+}
+
+// GetUser returns the user of the source with the SCIM ID
+func GetUser(ctx context.Context, source *auth_model.Source, id string) (*User, error) {
+	u, err := getSourceUser(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	return toUser(ctx, source, u)
+}
+
+// CreateUser provisions a user of the source
+func CreateUser(ctx context.Context, source *auth_model.Source, user *User) (*User, error) {
+	if user.UserName == "" {
+		return nil, errBadRequest(ErrorTypeInvalidValue, "userName is required")
+	}
+	email := user.PrimaryEmail()
+	if email == "" && strings.Contains(user.UserName, "@") {
+		email = user.UserName
+	}
+	if email == "" {
+		return nil, errBadRequest(ErrorTypeInvalidValue, "an email address is required")
+	}
+	name, err := userNameFor(user.UserName)
+	if err != nil {
+		return nil, err
+	}
+	active := user.Active == nil || bool(*user.Active)
+
+	u := &user_model.User{
+		Name:        name,
+		FullName:    user.FullName(),
+		Email:       email,
+		LoginType:   source.Type,
+		LoginSource: source.ID,
+		LoginName:   user.UserName,
+	}
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if err := checkUserNameUnique(ctx, source, user.UserName, 0); err != nil {
+			return err
+		}
+		if err := user_model.CreateUser(ctx, u, &user_model.CreateUserOverwriteOptions{
+			IsActive: optional.Some(active),
+		}); err != nil {
+			return userError(err)
+		}
+		if !active {
+			if err := user_service.UpdateAuth(ctx, u, &user_service.UpdateAuthOptions{ProhibitLogin: optional.Some(true)}); err != nil {
+				return err
+			}
+		}
+		return setExternalID(ctx, source, u, user.ExternalID)
+	}); err != nil {
+		return nil, err
+	}
+	log.Info("SCIM: the source %s provisioned the user %s", source.Name, u.Name)
+
+	return toUser(ctx, source, u)
+}
+
+// updateUser applies the changes of the SCIM representation of the user
+func updateUser(ctx context.Context, source *auth_model.Source, u *user_model.User, current, updated *User) error {
+	if updated.UserName == "" {
+		return errBadRequest(ErrorTypeInvalidValue, "userName is required")
+	}
+	email := updated.PrimaryEmail()
+	if email == "" {
+		return errBadRequest(ErrorTypeInvalidValue, "an email address is required")
+	}
+	active := isActive(u)
+	if updated.Active != nil {
+		active = bool(*updated.Active)
+	}
+	deactivated := !active && isActive(u)
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if updated.UserName != current.UserName {
+			if err := checkUserNameUnique(ctx, source, updated.UserName, u.ID); err != nil {
+				return err
+			}
+			if err := user_service.UpdateAuth(ctx, u, &user_service.UpdateAuthOptions{LoginName: optional.Some(updated.UserName)}); err != nil {
+				return err
+			}
+		}
+		if fullName := updated.FullName(); fullName != u.FullName {
+			if err := user_service.UpdateUser(ctx, u, &user_service.UpdateOptions{FullName: optional.Some(fullName)}); err != nil {
+				return err
+			}
+		}
+		if err := user_service.AdminAddOrSetPrimaryEmailAddress(ctx, u, email); err != nil {
+			return userError(err)
+		}
+		if updated.ExternalID != current.ExternalID {
+			if err := setExternalID(ctx, source, u, updated.ExternalID); err != nil {
+				return err
+			}
+		}
+		if active != isActive(u) {
+			if active {
+				return activateUser(ctx, u)
+			}
+			return deactivateUser(ctx, u)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	if deactivated {
+		auth_service.ForgetSessionsGeneration(u.ID)
+	}
+
+	if active != bool(*current.Active) {
+		log.Info("SCIM: the source %s set the user %s active: %t", source.Name, u.Name, active)
+	}
+	return nil
+}
+
+// ReplaceUser replaces the attributes of the user of the source. The email address, the external ID and whether the
+// user is active are kept if they are omitted, because Forgejo requires an email address and the external ID is what
+// the user signs in with.
+func ReplaceUser(ctx context.Context, source *auth_model.Source, id string, user *User) (*User, error) {
+	u, err := getSourceUser(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toUser(ctx, source, u)
+	if err != nil {
+		return nil, err
+	}
+	if len(user.Emails) == 0 {
+		user.Emails = current.Emails
+	}
+	if user.ExternalID == "" {
+		user.ExternalID = current.ExternalID
+	}
+	if err := updateUser(ctx, source, u, current, user); err != nil {
+		return nil, err
+	}
+	return toUser(ctx, source, u)
+}
+
+// PatchUser applies the operations of a PATCH request to the user of the source
+func PatchUser(ctx context.Context, source *auth_model.Source, id string, patch *PatchOp) (*User, error) {
+	u, err := getSourceUser(ctx, source, id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := toUser(ctx, source, u)
+	if err != nil {
+		return nil, err
+	}
+	object, err := toObject(current)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPatchOperations(object, patch.Operations); err != nil {
+		return nil, err
+	}
+	updated := &User{}
+	if err := fromObject(object, updated); err != nil {
+		return nil, err
+	}
+	if err := updateUser(ctx, source, u, current, updated); err != nil {
+		return nil, err
+	}
+	return toUser(ctx, source, u)
+}
+
+// DeleteUser deprovisions the user of the source: the user is deactivated and removed from the SCIM groups and so
+// from the teams which they map onto. The account is kept, since it owns repositories and issues.
+func DeleteUser(ctx context.Context, source *auth_model.Source, id string) error {
+	u, err := getSourceUser(ctx, source, id)
+	if err != nil {
+		return err
+	}
+	deactivated := isActive(u)
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if deactivated {
+			if err := deactivateUser(ctx, u); err != nil {
+				return err
+			}
+		}
+		_, err := db.GetEngine(ctx).
+			Where(builder.In("group_id", builder.Select("id").From("scim_group").Where(builder.Eq{"source_id": source.ID}))).
+			And("user_id = ?", u.ID).
+			Delete(&auth_model.SCIMGroupMember{})
+		return err
+	}); err != nil {
+		return err
+	}
+	if deactivated {
+		auth_service.ForgetSessionsGeneration(u.ID)
+	}
+	log.Info("SCIM: the source %s deprovisioned the user %s", source.Name, u.Name)
+
+	return syncTeams(ctx, source, u)
+}
+
+func activateUser(ctx context.Context, u *user_model.User) error {
+	if err := user_service.UpdateUser(ctx, u, &user_service.UpdateOptions{IsActive: optional.Some(true)}); err != nil {
+		return err
+	}
+	return user_service.UpdateAuth(ctx, u, &user_service.UpdateAuthOptions{ProhibitLogin: optional.Some(false)})
+}
+
+// deactivateUser disables the account of the user and revokes their access tokens, OAuth2 grants and sessions, it is
+// called in a transaction so the caller must forget the generation of the sessions of the user once it is committed
+func deactivateUser(ctx context.Context, u *user_model.User) error {
+	if err := user_service.UpdateUser(ctx, u, &user_service.UpdateOptions{IsActive: optional.Some(false)}); err != nil {
+		return err
+	}
+	if err := user_service.UpdateAuth(ctx, u, &user_service.UpdateAuthOptions{ProhibitLogin: optional.Some(true)}); err != nil {
+		return err
+	}
+	if err := db.DeleteBeans(ctx,
+		&auth_model.AccessToken{UID: u.ID},
+		&auth_model.AuthorizationToken{UID: u.ID},
+		&auth_model.OAuth2Grant{UserID: u.ID},
+	); err != nil {
+		return fmt.Errorf("revoke tokens: %w", err)
+	}
+	return auth_service.RevokeSessions(ctx, u.ID)
+}
@@ -74,6 +74,7 @@ func deleteUser(ctx context.Context, u *user_model.User, purge bool) (err error)
 
 	if err = db.DeleteBeans(ctx,
 		&auth_model.AccessToken{UID: u.ID},
+		&auth_model.SCIMGroupMember{UserID: u.ID},
 		&repo_model.Collaboration{UserID: u.ID},
 		&access_model.Access{UserID: u.ID},
 		&repo_model.Watch{UserID: u.ID},
@@ -177,6 +178,9 @@ func deleteUser(ctx context.Context, u *user_model.User, purge bool) (err error)
 				}
 			}
 		}
+		if err := git_model.RemoveUserIDFromOrgRulesets(ctx, u.ID); err != nil {
+			return err
+		}
 	}
 	// ***** END: Branch Protections *****
 
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
 )
 
 type UpdateOptions struct {
@@ -222,3 +223,27 @@ func UpdateAuth(ctx context.Context, u *user_model.User, opts *UpdateAuthOptions
 
 	return user_model.UpdateUserCols(ctx, u, "login_type", "login_source", "login_name", "passwd", "passwd_hash_algo", "salt", "must_change_password", "prohibit_login")
 }
+
+// DisablePassword removes the password of the user, who signs in with their passkeys from then on. A new password can
+// be set again later like for any user without a password.
+func DisablePassword(ctx context.Context, u *user_model.User) error {
+	if !u.IsLocal() {
+		return util.NewInvalidArgumentErrorf("only the password of local users can be disabled")
+	}
+	hasPasskeys, err := auth_model.HasPasskeysByUID(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if !hasPasskeys {
+		return util.NewInvalidArgumentErrorf("a passkey must be registered before the password can be disabled")
+	}
+
+	// Invalidate all authentication tokens for this user, like a password change does.
+	if err := auth_model.DeleteAuthTokenByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	u.Passwd = ""
+	u.PasswdHashAlgo = ""
+	u.Salt = ""
+	return user_model.UpdateUserCols(ctx, u, "passwd", "passwd_hash_algo", "salt")
+}
@@ -6,13 +6,16 @@ package user
 import (
 	"testing"
 
+	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
 	password_module "code.gitea.io/gitea/modules/auth/password"
 	"code.gitea.io/gitea/modules/optional"
 	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -118,3 +121,24 @@ func TestUpdateAuth(t *testing.T) {
 		Password: optional.Some("aaaa"),
 	}), password_module.ErrMinLength)
 }
+
+func TestDisablePassword(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	assert.True(t, user.IsPasswordSet())
+
+	// a security key which is not a passkey is not enough
+	_, err := auth_model.CreateCredential(db.DefaultContext, user.ID, "Security key", &webauthn.Credential{ID: []byte("Security key")}, false)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, DisablePassword(db.DefaultContext, user), util.ErrInvalidArgument)
+	assert.True(t, user.IsPasswordSet())
+
+	_, err = auth_model.CreateCredential(db.DefaultContext, user.ID, "Passkey", &webauthn.Credential{ID: []byte("Passkey")}, true)
+	assert.NoError(t, err)
+	assert.NoError(t, DisablePassword(db.DefaultContext, user))
+	assert.False(t, user.IsPasswordSet())
+
+	user = unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	assert.False(t, user.IsPasswordSet())
+}
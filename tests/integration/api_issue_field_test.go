@@ -0,0 +1,173 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIIssueFields(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+	issue := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{RepoID: repo.ID, Index: 1})
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteIssue, auth_model.AccessTokenScopeWriteRepository)
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/issue_fields", owner.Name, repo.Name)
+
+	// CreateIssueField
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.CreateIssueFieldOption{
+		Name:    "Priority",
+		Type:    "single_select",
+		Options: []string{"Low", "High"},
+	}).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	priority := new(api.IssueField)
+	DecodeJSON(t, resp, priority)
+	assert.Equal(t, []string{"Low", "High"}, priority.Options)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.IssueField{ID: priority.ID, RepoID: repo.ID})
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateIssueFieldOption{
+		Name: "Reviewer",
+		Type: "user",
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusCreated)
+	reviewer := new(api.IssueField)
+	DecodeJSON(t, resp, reviewer)
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateIssueFieldOption{
+		Name: "priority",
+		Type: "text",
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusConflict)
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateIssueFieldOption{
+		Name: "Empty",
+		Type: "single_select",
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// ListIssueFields
+	req = NewRequest(t, "GET", urlStr).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var fields []*api.IssueField
+	DecodeJSON(t, resp, &fields)
+	assert.Len(t, fields, 2)
+
+	// SetIssueFieldValue
+	valuesURL := fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/fields", owner.Name, repo.Name, issue.Index)
+	req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/%d", valuesURL, priority.ID), &api.SetIssueFieldValueOption{
+		Values: []string{"high"},
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var values []*api.IssueFieldValue
+	DecodeJSON(t, resp, &values)
+	require.Len(t, values, 1)
+	assert.Equal(t, []string{"High"}, values[0].Values)
+
+	req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/%d", valuesURL, priority.ID), &api.SetIssueFieldValueOption{
+		Values: []string{"Medium"},
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("%s/%d", valuesURL, reviewer.ID), &api.SetIssueFieldValueOption{
+		Values: []string{owner.Name},
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusOK)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.IssueFieldValue{IssueID: issue.ID, FieldID: reviewer.ID, Value: fmt.Sprint(owner.ID)})
+
+	// ListIssueFieldValues
+	req = NewRequest(t, "GET", valuesURL).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &values)
+	require.Len(t, values, 2)
+	assert.Equal(t, "Priority", values[0].Name)
+	assert.Equal(t, []string{owner.Name}, values[1].Values)
+
+	// CreateIssue with values of fields
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/issues", owner.Name, repo.Name), &api.CreateIssueOption{
+		Title:  "issue with fields",
+		Fields: map[string][]string{"Priority": {"Low"}},
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusCreated)
+	newIssue := new(api.Issue)
+	DecodeJSON(t, resp, newIssue)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.IssueFieldValue{IssueID: newIssue.ID, FieldID: priority.ID, Value: "Low"})
+
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/issues", owner.Name, repo.Name), &api.CreateIssueOption{
+		Title:  "issue with an unknown field",
+		Fields: map[string][]string{"Severity": {"Low"}},
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// EditIssueField removes the values which are not an option anymore
+	fieldURL := fmt.Sprintf("%s/%d", urlStr, priority.ID)
+	newName := "Importance"
+	req = NewRequestWithJSON(t, "PATCH", fieldURL, &api.EditIssueFieldOption{
+		Name:    &newName,
+		Options: []string{"High", "Critical"},
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, priority)
+	assert.Equal(t, "Importance", priority.Name)
+	unittest.AssertNotExistsBean(t, &issues_model.IssueFieldValue{IssueID: newIssue.ID, FieldID: priority.ID})
+	unittest.AssertExistsAndLoadBean(t, &issues_model.IssueFieldValue{IssueID: issue.ID, FieldID: priority.ID, Value: "High"})
+
+	// DeleteIssueFieldValue
+	req = NewRequest(t, "DELETE", fmt.Sprintf("%s/%d", valuesURL, reviewer.ID)).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	unittest.AssertNotExistsBean(t, &issues_model.IssueFieldValue{IssueID: issue.ID, FieldID: reviewer.ID})
+
+	// DeleteIssueField
+	req = NewRequest(t, "DELETE", fieldURL).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	unittest.AssertNotExistsBean(t, &issues_model.IssueField{ID: priority.ID})
+	unittest.AssertNotExistsBean(t, &issues_model.IssueFieldValue{FieldID: priority.ID})
+
+	req = NewRequest(t, "GET", fieldURL).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIOrgIssueFields(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 3})
+	org := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteOrganization, auth_model.AccessTokenScopeWriteIssue)
+
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/orgs/%s/issue_fields", org.Name), &api.CreateIssueFieldOption{
+		Name: "Estimate",
+		Type: "number",
+	}).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	field := new(api.IssueField)
+	DecodeJSON(t, resp, field)
+	assert.True(t, field.IsOrgField)
+
+	// the fields of the organization are fields of its repositories
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/%s/issue_fields/%d", org.Name, repo.Name, field.ID)).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusOK)
+
+	// but they cannot be modified through a repository
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/%s/%s/issue_fields/%d", org.Name, repo.Name, field.ID)).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/orgs/%s/issue_fields/%d", org.Name, field.ID)).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	unittest.AssertNotExistsBean(t, &issues_model.IssueField{ID: field.ID})
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIIssueSubIssues(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo1 := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	repo2 := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 2})
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo1.OwnerID})
+	parent := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{RepoID: repo1.ID, Index: 1})
+	closedIssue := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{RepoID: repo1.ID, Index: 4})
+	otherRepoIssue := unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{RepoID: repo2.ID, Index: 2})
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteIssue, auth_model.AccessTokenScopeWriteRepository)
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/sub_issues", owner.Name, repo1.Name, parent.Index)
+
+	// AddSubIssue
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: closedIssue.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusCreated)
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo2.Name, Index: otherRepoIssue.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusCreated)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.SubIssue{ParentID: parent.ID, IssueID: otherRepoIssue.ID})
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: closedIssue.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusConflict)
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: parent.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: 2}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// ListSubIssues
+	req = NewRequest(t, "GET", urlStr).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var subIssues []*api.Issue
+	DecodeJSON(t, resp, &subIssues)
+	require.Len(t, subIssues, 2)
+	assert.Equal(t, closedIssue.ID, subIssues[0].ID)
+	assert.Equal(t, otherRepoIssue.ID, subIssues[1].ID)
+
+	// the progress is part of the issue
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d", owner.Name, repo1.Name, parent.Index)).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	apiIssue := new(api.Issue)
+	DecodeJSON(t, resp, apiIssue)
+	require.NotNil(t, apiIssue.SubIssues)
+	assert.Equal(t, api.SubIssueProgress{Total: 2, Closed: 1}, *apiIssue.SubIssues)
+
+	// GetParentIssue
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/parent", owner.Name, repo2.Name, otherRepoIssue.Index)).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, apiIssue)
+	assert.Equal(t, parent.ID, apiIssue.ID)
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/parent", owner.Name, repo1.Name, parent.Index)).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	// MoveSubIssue
+	req = NewRequestWithJSON(t, "PATCH", urlStr, &api.MoveSubIssueOption{
+		SubIssue: api.IssueMeta{Owner: owner.Name, Name: repo2.Name, Index: otherRepoIssue.Index},
+		Position: 0,
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &subIssues)
+	require.Len(t, subIssues, 2)
+	assert.Equal(t, otherRepoIssue.ID, subIssues[0].ID)
+
+	// closing the last open sub-issue closes the parent which has opted in
+	closeWithSubIssues := true
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d", owner.Name, repo1.Name, parent.Index), &api.EditIssueOption{
+		CloseWithSubIssues: &closeWithSubIssues,
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusCreated)
+	closed := "closed"
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d", owner.Name, repo2.Name, otherRepoIssue.Index), &api.EditIssueOption{
+		State: &closed,
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusCreated)
+	unittest.AssertExistsAndLoadBean(t, &issues_model.Issue{ID: parent.ID, IsClosed: true})
+
+	// RemoveSubIssue
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: closedIssue.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	unittest.AssertNotExistsBean(t, &issues_model.SubIssue{ParentID: parent.ID, IssueID: closedIssue.ID})
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.IssueMeta{Owner: owner.Name, Name: repo1.Name, Index: closedIssue.Index}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNotFound)
+}
@@ -27,6 +27,7 @@ import (
 
 	oci "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPackageContainer(t *testing.T) {
@@ -640,6 +641,208 @@ func TestPackageContainer(t *testing.T) {
 		})
 	}
 
+	t.Run("Scope", func(t *testing.T) {
+		type TokenResponse struct {
+			Token        string `json:"token"`
+			AccessToken  string `json:"access_token"`
+			Scope        string `json:"scope"`
+			RefreshToken string `json:"refresh_token"`
+		}
+
+		url := fmt.Sprintf("%sv2/%s/%s", setting.AppURL, user.Name, images[0])
+		scope := fmt.Sprintf("repository:%s/%s:pull", user.LowerName, images[0])
+
+		t.Run("Pull", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%sv2/token?scope=%s", setting.AppURL, scope)).
+				AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			tokenResponse := &TokenResponse{}
+			DecodeJSON(t, resp, &tokenResponse)
+
+			assert.NotEmpty(t, tokenResponse.Token)
+			assert.Equal(t, tokenResponse.Token, tokenResponse.AccessToken)
+			assert.Equal(t, scope, tokenResponse.Scope)
+			assert.Empty(t, tokenResponse.RefreshToken)
+
+			pullToken := fmt.Sprintf("Bearer %s", tokenResponse.Token)
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth(pullToken)
+			MakeRequest(t, req, http.StatusOK)
+
+			req = NewRequest(t, "POST", fmt.Sprintf("%s/blobs/uploads", url)).
+				AddTokenAuth(pullToken)
+			resp = MakeRequest(t, req, http.StatusUnauthorized)
+			assert.Equal(t, `Bearer realm="`+setting.AppURL+`v2/token",service="container_registry",scope="repository:`+user.LowerName+`/`+images[0]+`:pull,push",error="insufficient_scope"`, resp.Header().Get("WWW-Authenticate"))
+
+			req = NewRequest(t, "DELETE", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth(pullToken)
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%sv2/%s/%s/manifests/%s", setting.AppURL, user.Name, images[1], tags[0])).
+				AddTokenAuth(pullToken)
+			resp = MakeRequest(t, req, http.StatusUnauthorized)
+			assert.Contains(t, resp.Header().Get("WWW-Authenticate"), `error="insufficient_scope"`)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%sv2/_catalog", setting.AppURL)).
+				AddTokenAuth(pullToken)
+			MakeRequest(t, req, http.StatusUnauthorized)
+		})
+
+		t.Run("ReadOnlyAccessToken", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%sv2/token?scope=repository:%s/%s:pull,push", setting.AppURL, user.LowerName, images[0]))
+			req.Request.SetBasicAuth(user.Name, token)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			tokenResponse := &TokenResponse{}
+			DecodeJSON(t, resp, &tokenResponse)
+
+			assert.Equal(t, scope, tokenResponse.Scope)
+
+			// the token keeps the scope of the personal access token when no scope is requested
+			req = NewRequest(t, "GET", fmt.Sprintf("%sv2/token", setting.AppURL))
+			req.Request.SetBasicAuth(user.Name, token)
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			tokenResponse = &TokenResponse{}
+			DecodeJSON(t, resp, &tokenResponse)
+
+			assert.Empty(t, tokenResponse.Scope)
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth("Bearer " + tokenResponse.Token)
+			MakeRequest(t, req, http.StatusOK)
+
+			req = NewRequest(t, "POST", fmt.Sprintf("%s/blobs/uploads", url)).
+				AddTokenAuth("Bearer " + tokenResponse.Token)
+			MakeRequest(t, req, http.StatusUnauthorized)
+		})
+
+		t.Run("OAuth2", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type": "password",
+				"username":   user.Name,
+				"password":   "wrong",
+				"scope":      scope,
+			})
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":  "password",
+				"username":    user.Name,
+				"password":    userPassword,
+				"scope":       scope,
+				"access_type": "offline",
+			})
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			tokenResponse := &TokenResponse{}
+			DecodeJSON(t, resp, &tokenResponse)
+
+			assert.NotEmpty(t, tokenResponse.AccessToken)
+			assert.Equal(t, scope, tokenResponse.Scope)
+			assert.NotEmpty(t, tokenResponse.RefreshToken)
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth("Bearer " + tokenResponse.AccessToken)
+			MakeRequest(t, req, http.StatusOK)
+
+			// a refresh token can only be exchanged for an access token
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth("Bearer " + tokenResponse.RefreshToken)
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": tokenResponse.AccessToken,
+			})
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": tokenResponse.RefreshToken,
+				"scope":         scope,
+			})
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			refreshed := &TokenResponse{}
+			DecodeJSON(t, resp, &refreshed)
+
+			assert.NotEmpty(t, refreshed.AccessToken)
+			assert.Equal(t, scope, refreshed.Scope)
+			assert.Empty(t, refreshed.RefreshToken)
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type": "client_credentials",
+			})
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			// a password change revokes the refresh token
+			u := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: user.ID})
+			require.NoError(t, u.SetPassword(userPassword))
+			require.NoError(t, user_model.UpdateUserCols(db.DefaultContext, u, "passwd", "passwd_hash_algo", "salt"))
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": tokenResponse.RefreshToken,
+			})
+			MakeRequest(t, req, http.StatusUnauthorized)
+		})
+
+		t.Run("RevokedAccessToken", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			accessToken := createAPIAccessTokenWithoutCleanUp(t, "container-registry", user, &[]auth_model.AccessTokenScope{auth_model.AccessTokenScopeWritePackage})
+
+			req := NewRequest(t, "GET", fmt.Sprintf("%sv2/token?offline_token=true", setting.AppURL))
+			req.Request.SetBasicAuth(user.Name, accessToken.Token)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			tokenResponse := &TokenResponse{}
+			DecodeJSON(t, resp, &tokenResponse)
+
+			assert.NotEmpty(t, tokenResponse.RefreshToken)
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth("Bearer " + tokenResponse.Token)
+			MakeRequest(t, req, http.StatusOK)
+
+			// the refresh token is exchanged for a token limited like the personal access token
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": tokenResponse.RefreshToken,
+			})
+			resp = MakeRequest(t, req, http.StatusOK)
+
+			refreshed := &TokenResponse{}
+			DecodeJSON(t, resp, &refreshed)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("%sv2/_catalog", setting.AppURL)).
+				AddTokenAuth("Bearer " + refreshed.AccessToken)
+			MakeRequest(t, req, http.StatusOK)
+
+			// the deletion of the personal access token revokes the tokens it was exchanged for
+			require.NoError(t, auth_model.DeleteAccessTokenByID(db.DefaultContext, accessToken.ID, user.ID))
+
+			req = NewRequest(t, "HEAD", fmt.Sprintf("%s/manifests/%s", url, tags[0])).
+				AddTokenAuth("Bearer " + tokenResponse.Token)
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithValues(t, "POST", fmt.Sprintf("%sv2/token", setting.AppURL), map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": tokenResponse.RefreshToken,
+			})
+			MakeRequest(t, req, http.StatusUnauthorized)
+		})
+	})
+
 	// https://github.com/go-gitea/gitea/issues/19586
 	t.Run("ParallelUpload", func(t *testing.T) {
 		defer tests.PrintCurrentTest(t)()
@@ -0,0 +1,45 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISearchCodeGrep(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	defer test.MockVariableValue(&setting.Indexer.RepoIndexerEnabled, false)()
+
+	req := NewRequestf(t, "GET", "/api/v1/repos/search/code?q=%s", url.QueryEscape("/Desc\\w+ for repo1/ path:readme repo:user2/repo1"))
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	var results api.CodeSearchResults
+	DecodeJSON(t, resp, &results)
+	assert.Equal(t, "1", resp.Header().Get("X-Total-Count"))
+	if assert.Len(t, results.Data, 1) {
+		result := results.Data[0]
+		assert.EqualValues(t, 1, result.RepoID)
+		assert.Equal(t, "user2/repo1", result.RepoFullName)
+		assert.Equal(t, "README.md", result.Filename)
+		assert.Equal(t, "Markdown", result.Language)
+		if assert.NotEmpty(t, result.Lines) {
+			assert.Equal(t, "Description for repo1", result.Lines[len(result.Lines)-1].Content)
+		}
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/search/code?q=%s", url.QueryEscape("/[a-z]+/"))
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/search/code")
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
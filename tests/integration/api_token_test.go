@@ -7,10 +7,13 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
+	asymkey_model "code.gitea.io/gitea/models/asymkey"
 	auth_model "code.gitea.io/gitea/models/auth"
 	"code.gitea.io/gitea/models/unittest"
 	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/lfs"
 	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/tests"
@@ -572,3 +575,170 @@ func deleteAPIAccessToken(t *testing.T, accessToken api.AccessToken, user *user_
 
 	unittest.AssertNotExistsBean(t, &auth_model.AccessToken{ID: accessToken.ID})
 }
+
+func TestAPIRestrictedToken(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	createToken := func(payload map[string]any, expectedStatus int) api.AccessToken {
+		req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", payload).AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, expectedStatus)
+		var token api.AccessToken
+		if expectedStatus == http.StatusCreated {
+			DecodeJSON(t, resp, &token)
+		}
+		return token
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		createToken(map[string]any{"name": "invalid", "repositories": []string{"user2/nonexistent"}}, http.StatusBadRequest)
+		createToken(map[string]any{"name": "invalid", "organization": "user2"}, http.StatusBadRequest)
+		createToken(map[string]any{"name": "invalid", "organization": "org3", "repositories": []string{"user2/repo1"}}, http.StatusBadRequest)
+		createToken(map[string]any{"name": "invalid", "expires_at": time.Now().Add(-time.Hour)}, http.StatusBadRequest)
+	})
+
+	t.Run("Repositories", func(t *testing.T) {
+		token := createToken(map[string]any{
+			"name":         "repositories",
+			"scopes":       []string{"write:repository", "write:organization"},
+			"repositories": []string{"user2/repo1", "user2/repo1"},
+			"expires_at":   time.Now().Add(time.Hour),
+		}, http.StatusCreated)
+		assert.Equal(t, []string{"user2/repo1"}, token.Repositories)
+		assert.NotNil(t, token.ExpiresAt)
+
+		req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusOK)
+		req = NewRequest(t, "GET", "/api/v1/repos/user2/repo2").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo2", &api.EditRepoOption{}).AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequest(t, "GET", "/api/v1/repositories/2").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequest(t, "GET", "/api/v1/orgs/org3").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequestWithJSON(t, "POST", "/api/v1/user/repos", &api.CreateRepoOption{Name: "restricted"}).AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", "/user2/repo1.git/info/refs")
+		req.Request.SetBasicAuth("user2", token.Token)
+		MakeRequest(t, req, http.StatusOK)
+		req = NewRequest(t, "GET", "/user2/repo2.git/info/refs")
+		req.Request.SetBasicAuth("user2", token.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", "/api/v1/users/user2/tokens").AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var tokens []api.AccessToken
+		DecodeJSON(t, resp, &tokens)
+		for _, listed := range tokens {
+			if listed.ID == token.ID {
+				assert.Equal(t, []string{"user2/repo1"}, listed.Repositories)
+			}
+		}
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		token := createToken(map[string]any{
+			"name":         "organization",
+			"scopes":       []string{"read:repository", "read:organization"},
+			"organization": "org3",
+		}, http.StatusCreated)
+		assert.Equal(t, "org3", token.Organization)
+
+		req := NewRequest(t, "GET", "/api/v1/orgs/org3").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusOK)
+		req = NewRequest(t, "GET", "/api/v1/repos/org3/repo3").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusOK)
+		req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1").AddTokenAuth(token.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Routes across repositories", func(t *testing.T) {
+		scopes := []string{"read:repository", "read:issue", "read:notification", "read:package", "read:user"}
+		unrestricted := createToken(map[string]any{"name": "unrestricted", "scopes": scopes}, http.StatusCreated)
+		repositories := createToken(map[string]any{"name": "repositories-listing", "scopes": scopes, "repositories": []string{"user2/repo1"}}, http.StatusCreated)
+		organization := createToken(map[string]any{"name": "organization-listing", "scopes": scopes, "organization": "org3"}, http.StatusCreated)
+
+		for _, url := range []string{
+			"/api/v1/user/repos",
+			"/api/v1/user/starred",
+			"/api/v1/user/subscriptions",
+			"/api/v1/user/times",
+			"/api/v1/user/stopwatches",
+			"/api/v1/repos/search",
+			"/api/v1/repos/search/code?q=repo",
+			"/api/v1/repos/search/commits?q=init",
+			"/api/v1/repos/issues/search",
+			"/api/v1/notifications",
+			"/api/v1/notifications/new",
+			"/api/v1/users/user2/repos",
+			"/api/v1/packages/user2/",
+		} {
+			req := NewRequest(t, "GET", url).AddTokenAuth(unrestricted.Token)
+			MakeRequest(t, req, http.StatusOK)
+			req = NewRequest(t, "GET", url).AddTokenAuth(repositories.Token)
+			MakeRequest(t, req, http.StatusForbidden)
+			req = NewRequest(t, "GET", url).AddTokenAuth(organization.Token)
+			MakeRequest(t, req, http.StatusForbidden)
+		}
+
+		// the listings of the organization itself are allowed to the tokens restricted to it
+		for _, url := range []string{"/api/v1/users/org3/repos", "/api/v1/packages/org3/"} {
+			req := NewRequest(t, "GET", url).AddTokenAuth(organization.Token)
+			MakeRequest(t, req, http.StatusOK)
+			req = NewRequest(t, "GET", url).AddTokenAuth(repositories.Token)
+			MakeRequest(t, req, http.StatusForbidden)
+		}
+
+		// the package registries only serve the packages of the organization
+		req := NewRequest(t, "GET", "/api/packages/user2/generic/test-package/1.0.0/file.bin")
+		req.Request.SetBasicAuth("user2", unrestricted.Token)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequest(t, "GET", "/api/packages/user2/generic/test-package/1.0.0/file.bin")
+		req.Request.SetBasicAuth("user2", organization.Token)
+		MakeRequest(t, req, http.StatusUnauthorized)
+	})
+
+	t.Run("Routes outside repositories", func(t *testing.T) {
+		scopes := []string{"write:user", "write:organization", "write:repository"}
+		unrestricted := createToken(map[string]any{"name": "unrestricted-user", "scopes": scopes}, http.StatusCreated)
+		restricted := createToken(map[string]any{"name": "restricted-user", "scopes": scopes, "repositories": []string{"user2/repo1"}}, http.StatusCreated)
+
+		for _, url := range []string{
+			"/api/v1/user",
+			"/api/v1/user/keys",
+			"/api/v1/user/gpg_keys",
+			"/api/v1/user/hooks",
+			"/api/v1/user/orgs",
+			"/api/v1/user/teams",
+			"/api/v1/user/applications/oauth2",
+		} {
+			req := NewRequest(t, "GET", url).AddTokenAuth(unrestricted.Token)
+			MakeRequest(t, req, http.StatusOK)
+			req = NewRequest(t, "GET", url).AddTokenAuth(restricted.Token)
+			MakeRequest(t, req, http.StatusForbidden)
+		}
+
+		// an SSH key would give access to all the repositories of the user
+		req := NewRequestWithJSON(t, "POST", "/api/v1/user/keys", &api.CreateKeyOption{
+			Title: "restricted",
+			Key:   "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABgQC4cn+iXnA4KvcQYSV88vGn0Yi91vG47t1P7okprVmhNTkipNRIHWr6WdCO4VDr/cvsRkuVJAsLO2enwjGWWueOO6BodiBgyAOZ/5t5nJNMCNuLGT5UIo/RI1b0WRQwxEZTRjt6mFNw6lH14wRd8ulsr9toSWBPMOGWoYs1PDeDL0JuTjL+tr1SZi/EyxCngpYszKdXllJEHyI79KQgeD0Vt3pTrkbNVTOEcCNqZePSVmUH8X8Vhugz3bnE0/iE9Pb5fkWO9c4AnM1FgI/8Bvp27Fw2ShryIXuR6kKvUqhVMTuOSDHwu6A8jLE5Owt3GAYugDpDYuwTVNGrHLXKpPzrGGPE/jPmaLCMZcsdkec95dYeU3zKODEm8UQZFhmJmDeWVJ36nGrGZHL4J5aTTaeFUJmmXDaJYiJ+K2/ioKgXqnXvltu0A9R8/LGy4nrTJRr4JMLuJFoUXvGm1gXQ70w2LSpk6yl71RNC0hCtsBe8BP8IhYCM0EP5jh7eCMQZNvM=",
+		}).AddTokenAuth(restricted.Token)
+		MakeRequest(t, req, http.StatusForbidden)
+		unittest.AssertNotExistsBean(t, &asymkey_model.PublicKey{OwnerID: user.ID, Name: "restricted"})
+
+		// the LFS objects of the other repositories are not served either
+		lfsBatchRequest := func(repo, token string) *RequestWrapper {
+			req := NewRequestWithJSON(t, "POST", "/user2/"+repo+".git/info/lfs/objects/batch", &lfs.BatchRequest{
+				Operation: "download",
+				Objects:   []lfs.Pointer{{Oid: "fb8f7d8435968c4f82a726a92395be4d16f2f63116caf36c8ad35c60831ab041", Size: 6}},
+			}).SetHeader("Accept", lfs.AcceptHeader).SetHeader("Content-Type", lfs.MediaType)
+			req.Request.SetBasicAuth("user2", token)
+			return req
+		}
+		MakeRequest(t, lfsBatchRequest("repo2", unrestricted.Token), http.StatusOK)
+		MakeRequest(t, lfsBatchRequest("repo2", restricted.Token), http.StatusUnauthorized)
+		MakeRequest(t, lfsBatchRequest("repo1", restricted.Token), http.StatusOK)
+	})
+}
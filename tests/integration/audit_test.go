@@ -0,0 +1,132 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	audit_model "code.gitea.io/gitea/models/audit"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	defer test.MockVariableValue(&setting.Audit.Enabled, true)()
+
+	owner := loginUser(t, "user2")
+	ownerToken := getTokenForLoggedInUser(t, owner, auth_model.AccessTokenScopeWriteRepository, auth_model.AccessTokenScopeWriteOrganization)
+	member := loginUser(t, "user4")
+	memberToken := getTokenForLoggedInUser(t, member, auth_model.AccessTokenScopeReadRepository, auth_model.AccessTokenScopeReadOrganization)
+	admin := loginUser(t, "user1")
+	adminToken := getTokenForLoggedInUser(t, admin, auth_model.AccessTokenScopeReadAdmin)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/keys", api.CreateKeyOption{
+		Title: "audited",
+		Key:   "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKOCOM5pKhQtTaVeG3qgWw7MxT/6r/sMc48XgHcZAcT1 audited",
+	}).AddTokenAuth(ownerToken)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var key api.DeployKey
+	DecodeJSON(t, resp, &key)
+	MakeRequest(t, NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/user2/repo1/keys/%d", key.ID)).AddTokenAuth(ownerToken), http.StatusNoContent)
+
+	MakeRequest(t, NewRequestWithJSON(t, "POST", "/api/v1/orgs/org3/teams", api.CreateTeamOption{
+		Name:       "audited",
+		Permission: "write",
+		Units:      []string{"repo.code"},
+	}).AddTokenAuth(ownerToken), http.StatusCreated)
+
+	t.Run("Recorded", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		added := unittest.AssertExistsAndLoadBean(t, &audit_model.Event{Action: audit_model.ActionDeployKeyAdd, TargetID: key.ID})
+		assert.EqualValues(t, "user2", added.ActorName)
+		assert.NotEmpty(t, added.ActorIP)
+		assert.EqualValues(t, 2, added.OwnerID)
+		assert.EqualValues(t, 1, added.RepoID)
+		assert.Contains(t, added.After, `"name":"audited"`)
+		unittest.AssertExistsAndLoadBean(t, &audit_model.Event{Action: audit_model.ActionDeployKeyRemove, TargetID: key.ID})
+		unittest.AssertExistsAndLoadBean(t, &audit_model.Event{Action: audit_model.ActionUserSignIn, ActorName: "user4"})
+	})
+
+	t.Run("RepoAPI", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/audit").AddTokenAuth(ownerToken), http.StatusOK)
+		var events []*api.AuditEvent
+		DecodeJSON(t, resp, &events)
+		require.Len(t, events, 2)
+		assert.Equal(t, string(audit_model.ActionDeployKeyRemove), events[0].Action)
+		assert.Equal(t, string(audit_model.ActionDeployKeyAdd), events[1].Action)
+		assert.Equal(t, "audited", events[1].After["name"])
+		assert.Equal(t, events[1].Hash, events[0].PrevHash)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/audit?action=deploy_key_add").AddTokenAuth(ownerToken), http.StatusOK)
+		DecodeJSON(t, resp, &events)
+		assert.Len(t, events, 1)
+
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/audit?action=unknown").AddTokenAuth(ownerToken), http.StatusUnprocessableEntity)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/org3/repo3/audit").AddTokenAuth(memberToken), http.StatusForbidden)
+	})
+
+	t.Run("OrgAPI", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3/audit").AddTokenAuth(ownerToken), http.StatusOK)
+		var events []*api.AuditEvent
+		DecodeJSON(t, resp, &events)
+		require.Len(t, events, 1)
+		assert.Equal(t, string(audit_model.ActionTeamCreate), events[0].Action)
+		assert.Equal(t, "audited", events[0].TargetName)
+
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3/audit").AddTokenAuth(memberToken), http.StatusForbidden)
+	})
+
+	t.Run("AdminAPI", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/api/v1/admin/audit?actor=user4").AddTokenAuth(adminToken), http.StatusOK)
+		var events []*api.AuditEvent
+		DecodeJSON(t, resp, &events)
+		require.NotEmpty(t, events)
+		for _, e := range events {
+			assert.Equal(t, "user4", e.ActorName)
+		}
+	})
+
+	t.Run("Web", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := owner.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/settings/audit"), http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Equal(t, 2, htmlDoc.Find("tbody tr code").Length())
+
+		resp = owner.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/settings/audit/export?format=csv"), http.StatusOK)
+		lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "id,created_at,action,"))
+		assert.Contains(t, lines[2], string(audit_model.ActionDeployKeyAdd))
+
+		owner.MakeRequest(t, NewRequest(t, "GET", "/org/org3/settings/audit"), http.StatusOK)
+		member.MakeRequest(t, NewRequest(t, "GET", "/org/org3/settings/audit"), http.StatusNotFound)
+
+		req := NewRequestWithValues(t, "POST", "/admin/audit/verify", map[string]string{
+			"_csrf": GetCSRF(t, admin, "/admin/audit"),
+		})
+		admin.MakeRequest(t, req, http.StatusSeeOther)
+		flashCookie := admin.GetCookie(setting.CookiePrefix + "_flash")
+		require.NotNil(t, flashCookie)
+		assert.Contains(t, flashCookie.Value, "success")
+	})
+}
@@ -23,3 +23,22 @@ func TestExploreCodeSearchIndexer(t *testing.T) {
 
 	assert.EqualValues(t, 0, len(msg.Nodes))
 }
+
+func TestExploreCodeSearchGrep(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	defer test.MockVariableValue(&setting.Indexer.RepoIndexerEnabled, false)()
+
+	req := NewRequest(t, "GET", "/explore/code?q=Description+path:README+repo:user2/repo1")
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	doc := NewHTMLParser(t, resp.Body)
+	container := doc.Find(".explore").Find(".ui.container")
+	assert.EqualValues(t, 1, len(container.Find(".ui.message[data-test-tag=grep]").Nodes))
+	assert.EqualValues(t, 1, len(container.Find(".repo-search-result").Nodes))
+
+	req = NewRequest(t, "GET", "/explore/code?q=/[a-z]%2B/")
+	resp = MakeRequest(t, req, http.StatusOK)
+
+	doc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 1, len(doc.Find(".explore").Find(".ui.container").Find(".ui.error.message").Nodes))
+}
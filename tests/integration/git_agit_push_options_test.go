@@ -0,0 +1,172 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doAGitPush pushes the HEAD of a clone to refs/for/master/<topic> with the given push options and returns the
+// output of git
+func doAGitPush(dstPath, topic string, options ...string) (string, error) {
+	cmd := git.NewCommand(git.DefaultContext, "push", "origin")
+	for _, option := range options {
+		cmd.AddOptionValues("-o", option)
+	}
+	_, stdErr, err := cmd.AddDynamicArguments("HEAD:refs/for/master/" + topic).RunStdString(&git.RunOpts{Dir: dstPath})
+	return stdErr, err
+}
+
+func TestAGitPushOptions(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		if git.CheckGitVersionAtLeast("2.29") != nil {
+			t.Skip("AGit flow requires git >= 2.29")
+		}
+
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		user4 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{OwnerName: "user2", Name: "repo1"})
+		label := unittest.AssertExistsAndLoadBean(t, &issues_model.Label{RepoID: repo.ID, Name: "label1"})
+		milestone := unittest.AssertExistsAndLoadBean(t, &issues_model.Milestone{RepoID: repo.ID, Name: "milestone1"})
+
+		gitRepo, err := gitrepo.OpenRepository(db.DefaultContext, repo)
+		require.NoError(t, err)
+		defer gitRepo.Close()
+
+		clone := func(t *testing.T, user *user_model.User) string {
+			t.Helper()
+			dstPath := t.TempDir()
+			cloneURL, _ := url.Parse(u.String())
+			cloneURL.Path = "user2/repo1.git"
+			cloneURL.User = url.UserPassword(user.Name, userPassword)
+			doGitClone(dstPath, cloneURL)(t)
+			return dstPath
+		}
+		commit := func(t *testing.T, dstPath string, user *user_model.User) string {
+			t.Helper()
+			_, err := generateCommitWithNewData(littleSize, dstPath, user.Email, user.Name, "agit-push-options")
+			require.NoError(t, err)
+			commitID, err := git.GetFullCommitID(git.DefaultContext, dstPath, "HEAD")
+			require.NoError(t, err)
+			return commitID
+		}
+		loadPull := func(t *testing.T, user *user_model.User, topic string) *issues_model.PullRequest {
+			t.Helper()
+			pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{
+				BaseRepoID: repo.ID,
+				HeadBranch: user.LowerName + "/" + topic,
+				Flow:       issues_model.PullRequestFlowAGit,
+			})
+			require.NoError(t, pr.LoadIssue(db.DefaultContext))
+			return pr
+		}
+
+		t.Run("Create with metadata", func(t *testing.T) {
+			dstPath := clone(t, user2)
+			commit(t, dstPath, user2)
+
+			_, err := doAGitPush(dstPath, "metadata",
+				"title=metadata", "draft", "label="+label.Name, "milestone="+milestone.Name,
+				"assignee="+user2.Name, "reviewer="+user4.Name, "merge-when-checks-succeed")
+			require.NoError(t, err)
+
+			pr := loadPull(t, user2, "metadata")
+			assert.Equal(t, "WIP: metadata", pr.Issue.Title)
+			assert.Equal(t, milestone.ID, pr.Issue.MilestoneID)
+			unittest.AssertExistsAndLoadBean(t, &issues_model.IssueLabel{IssueID: pr.IssueID, LabelID: label.ID})
+			unittest.AssertExistsAndLoadBean(t, &issues_model.IssueAssignees{IssueID: pr.IssueID, AssigneeID: user2.ID})
+			unittest.AssertExistsAndLoadBean(t, &issues_model.Review{IssueID: pr.IssueID, ReviewerID: user4.ID, Type: issues_model.ReviewTypeRequest})
+			unittest.AssertExistsAndLoadBean(t, &pull_model.AutoMerge{PullID: pr.ID, DoerID: user2.ID})
+
+			t.Run("Update", func(t *testing.T) {
+				commitID := commit(t, dstPath, user2)
+				_, err := doAGitPush(dstPath, "metadata", "draft=false", "milestone=")
+				require.NoError(t, err)
+
+				pr := loadPull(t, user2, "metadata")
+				assert.Equal(t, "metadata", pr.Issue.Title)
+				assert.Zero(t, pr.Issue.MilestoneID)
+				headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+				require.NoError(t, err)
+				assert.Equal(t, commitID, headCommitID)
+			})
+
+			t.Run("Rejected update is not applied", func(t *testing.T) {
+				pr := loadPull(t, user2, "metadata")
+				oldCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+				require.NoError(t, err)
+
+				commit(t, dstPath, user2)
+				stdErr, err := doAGitPush(dstPath, "metadata", "title=rejected", "label=does-not-exist")
+				require.Error(t, err)
+				assert.Contains(t, stdErr, `The label "does-not-exist" does not exist.`)
+
+				stdErr, err = doAGitPush(dstPath, "metadata", "title=rejected", "reviewer="+user2.Name)
+				require.Error(t, err)
+				assert.Contains(t, stdErr, `A review cannot be requested from "user2".`)
+
+				pr = loadPull(t, user2, "metadata")
+				assert.Equal(t, "metadata", pr.Issue.Title)
+				headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+				require.NoError(t, err)
+				assert.Equal(t, oldCommitID, headCommitID)
+			})
+
+			t.Run("Force push with a legacy value", func(t *testing.T) {
+				_, _, err := git.NewCommand(git.DefaultContext, "reset", "--hard", "HEAD~1").RunStdString(&git.RunOpts{Dir: dstPath})
+				require.NoError(t, err)
+				commitID := commit(t, dstPath, user2)
+
+				_, err = doAGitPush(dstPath, "metadata", "force-push=yes")
+				require.NoError(t, err)
+
+				pr := loadPull(t, user2, "metadata")
+				headCommitID, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+				require.NoError(t, err)
+				assert.Equal(t, commitID, headCommitID)
+			})
+		})
+
+		t.Run("Permission denials", func(t *testing.T) {
+			dstPath := clone(t, user4)
+			commit(t, dstPath, user4)
+
+			for _, option := range []string{"label=" + label.Name, "milestone=" + milestone.Name, "assignee=" + user4.Name} {
+				stdErr, err := doAGitPush(dstPath, "denied", option)
+				require.Error(t, err, option)
+				assert.Contains(t, stdErr, "You are not allowed to set the labels, the milestone or the assignees of pull requests.", option)
+			}
+
+			stdErr, err := doAGitPush(dstPath, "denied", "merge-when-checks-succeed")
+			require.Error(t, err)
+			assert.Contains(t, stdErr, `You are not allowed to merge pull requests into "master".`)
+
+			stdErr, err = doAGitPush(dstPath, "denied", "reviewer="+user4.Name)
+			require.Error(t, err)
+			assert.Contains(t, stdErr, `A review cannot be requested from "user4".`)
+
+			unittest.AssertNotExistsBean(t, &issues_model.PullRequest{BaseRepoID: repo.ID, HeadBranch: "user4/denied"})
+
+			// without the denied options the pull request is created
+			_, err = doAGitPush(dstPath, "denied", "draft")
+			require.NoError(t, err)
+			pr := loadPull(t, user4, "denied")
+			assert.True(t, strings.HasPrefix(pr.Issue.Title, "WIP:"))
+		})
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -327,7 +328,7 @@ func TestAccessTokenExchangeWithInvalidCredentials(t *testing.T) {
 	parsedError = new(auth.AccessTokenError)
 	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsedError))
 	assert.Equal(t, "unsupported_grant_type", string(parsedError.ErrorCode))
-	assert.Equal(t, "Only refresh_token or authorization_code grant type is supported", parsedError.ErrorDescription)
+	assert.Equal(t, "Only refresh_token, authorization_code, urn:ietf:params:oauth:grant-type:device_code or client_credentials grant type is supported", parsedError.ErrorDescription)
 }
 
 func TestAccessTokenExchangeWithBasicAuth(t *testing.T) {
@@ -732,3 +733,198 @@ func TestOAuth_GrantApplicationOAuth(t *testing.T) {
 	resp = ctx.MakeRequest(t, req, http.StatusSeeOther)
 	assert.Contains(t, test.RedirectURL(resp), "error=access_denied&error_description=the+request+is+denied")
 }
+
+func TestOAuth_DeviceAuthorizationGrant(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	req := NewRequestWithValues(t, "POST", "/login/oauth/device_authorization", map[string]string{
+		"client_id": "ce5a1322-42a7-11ed-b878-0242ac120002",
+		"scope":     "read:repository",
+	})
+	resp := MakeRequest(t, req, http.StatusOK)
+	deviceAuthorization := new(auth.DeviceAuthorizationResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), deviceAuthorization))
+	assert.True(t, len(deviceAuthorization.DeviceCode) > 30)
+	assert.Len(t, deviceAuthorization.UserCode, 9)
+	assert.Equal(t, setting.AppURL+"login/device", deviceAuthorization.VerificationURI)
+	assert.Equal(t, setting.AppURL+"login/device?user_code="+deviceAuthorization.UserCode, deviceAuthorization.VerificationURIComplete)
+
+	pollToken := func(expectedStatus int) *httptest.ResponseRecorder {
+		req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+			"grant_type":  "urn:ietf:params:oauth:grant-type:device_code",
+			"client_id":   "ce5a1322-42a7-11ed-b878-0242ac120002",
+			"device_code": deviceAuthorization.DeviceCode,
+		})
+		return MakeRequest(t, req, expectedStatus)
+	}
+	assertTokenError := func(errorCode string) {
+		parsedError := new(auth.AccessTokenError)
+		assert.NoError(t, json.Unmarshal(pollToken(http.StatusBadRequest).Body.Bytes(), parsedError))
+		assert.Equal(t, errorCode, string(parsedError.ErrorCode))
+	}
+	assertTokenError("authorization_pending")
+	assertTokenError("slow_down")
+
+	session := loginUser(t, "user2")
+	req = NewRequest(t, "GET", "/login/device?user_code=invalid")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, "#authorize-device", false)
+	htmlDoc.AssertElement(t, "#user_code", true)
+
+	req = NewRequest(t, "GET", deviceAuthorization.VerificationURIComplete)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, "#authorize-device", true)
+
+	req = NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     htmlDoc.GetCSRF(),
+		"user_code": deviceAuthorization.UserCode,
+		"granted":   "true",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	resp = pollToken(http.StatusOK)
+	parsed := new(auth.AccessTokenResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+	assert.True(t, len(parsed.AccessToken) > 10)
+	assert.True(t, len(parsed.RefreshToken) > 10)
+
+	// the token is restricted to the requested scope
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1").AddTokenAuth(parsed.AccessToken)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "GET", "/api/v1/user").AddTokenAuth(parsed.AccessToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// the device code can only be exchanged once
+	assertTokenError("invalid_grant")
+}
+
+func TestOAuth_DeviceAuthorizationGrantDenied(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	req := NewRequestWithValues(t, "POST", "/login/oauth/device_authorization", map[string]string{
+		"client_id": "da7da3ba-9a13-4167-856f-3899de0b0138",
+	})
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/device_authorization", map[string]string{
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+	})
+	resp := MakeRequest(t, req, http.StatusOK)
+	deviceAuthorization := new(auth.DeviceAuthorizationResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), deviceAuthorization))
+
+	session := loginUser(t, "user4")
+	req = NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     GetCSRF(t, session, "/login/device"),
+		"user_code": deviceAuthorization.UserCode,
+		"granted":   "false",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "urn:ietf:params:oauth:grant-type:device_code",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"device_code":   deviceAuthorization.DeviceCode,
+	})
+	resp = MakeRequest(t, req, http.StatusBadRequest)
+	parsedError := new(auth.AccessTokenError)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsedError))
+	assert.Equal(t, "access_denied", string(parsedError.ErrorCode))
+	unittest.AssertNotExistsBean(t, &auth_model.OAuth2Grant{ApplicationID: 1, UserID: 4})
+}
+
+func TestOAuth_DeviceAuthorizationGrantScope(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	requestAuthorization := func(scope string, expectedStatus int) *httptest.ResponseRecorder {
+		req := NewRequestWithValues(t, "POST", "/login/oauth/device_authorization", map[string]string{
+			"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+			"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+			"scope":         scope,
+		})
+		return MakeRequest(t, req, expectedStatus)
+	}
+
+	resp := requestAuthorization("openid write:admin-everything", http.StatusBadRequest)
+	parsedError := new(auth.AccessTokenError)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsedError))
+	assert.Equal(t, "invalid_scope", string(parsedError.ErrorCode))
+
+	// the scope the user consents to is added to the one of the existing grant instead of replacing it
+	resp = requestAuthorization("email  read:repository", http.StatusOK)
+	deviceAuthorization := new(auth.DeviceAuthorizationResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), deviceAuthorization))
+
+	session := loginUser(t, "user1")
+	req := NewRequestWithValues(t, "POST", "/login/device", map[string]string{
+		"_csrf":     GetCSRF(t, session, "/login/device"),
+		"user_code": deviceAuthorization.UserCode,
+		"granted":   "true",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	// the existing grant was not restricted to any access token scope and stays so
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Grant{ID: 1, Scope: "openid profile email"})
+}
+
+func TestOAuth_ClientCredentialsGrant(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	requestToken := func(clientID, scope string, expectedStatus int) *httptest.ResponseRecorder {
+		req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+			"grant_type": "client_credentials",
+			"scope":      scope,
+		})
+		req.Request.SetBasicAuth(clientID, "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=")
+		return MakeRequest(t, req, expectedStatus)
+	}
+	assertTokenError := func(clientID, scope, errorCode string) {
+		parsedError := new(auth.AccessTokenError)
+		assert.NoError(t, json.Unmarshal(requestToken(clientID, scope, http.StatusBadRequest).Body.Bytes(), parsedError))
+		assert.Equal(t, errorCode, string(parsedError.ErrorCode))
+	}
+
+	// public clients cannot use the grant and the grant is disabled by default
+	assertTokenError("ce5a1322-42a7-11ed-b878-0242ac120002", "", "unauthorized_client")
+	assertTokenError("da7da3ba-9a13-4167-856f-3899de0b0138", "", "unauthorized_client")
+
+	// the applications of users can only act as their owners
+	session := loginUser(t, "user1")
+	req := NewRequestWithValues(t, "POST", "/user/settings/applications/oauth2/1", map[string]string{
+		"_csrf":                   GetCSRF(t, session, "/user/settings/applications/oauth2/1"),
+		"application_name":        "Test",
+		"redirect_uris":           "a\nhttps://example.com/xyzzy",
+		"confidential_client":     "on",
+		"client_credentials_user": "user2",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 1, ClientCredentialsUserID: 0})
+
+	req = NewRequestWithValues(t, "POST", "/user/settings/applications/oauth2/1", map[string]string{
+		"_csrf":                   GetCSRF(t, session, "/user/settings/applications/oauth2/1"),
+		"application_name":        "Test",
+		"redirect_uris":           "a\nhttps://example.com/xyzzy",
+		"confidential_client":     "on",
+		"client_credentials_user": "user1",
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.OAuth2Application{ID: 1, ClientCredentialsUserID: 1})
+
+	assertTokenError("da7da3ba-9a13-4167-856f-3899de0b0138", "openid", "invalid_scope")
+
+	resp := requestToken("da7da3ba-9a13-4167-856f-3899de0b0138", "read:user", http.StatusOK)
+	parsed := new(auth.AccessTokenResponse)
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), parsed))
+	assert.True(t, len(parsed.AccessToken) > 10)
+	assert.Empty(t, parsed.RefreshToken)
+
+	req = NewRequest(t, "GET", "/api/v1/user").AddTokenAuth(parsed.AccessToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), `"login":"user1"`)
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1").AddTokenAuth(parsed.AccessToken)
+	MakeRequest(t, req, http.StatusForbidden)
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgAuthPolicy(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	owner := loginUser(t, "user2")
+	member := loginUser(t, "user4")
+	memberToken := getTokenForLoggedInUser(t, member, auth_model.AccessTokenScopeReadOrganization, auth_model.AccessTokenScopeReadRepository)
+
+	t.Run("Settings", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// the owner cannot require two-factor authentication without having it
+		req := NewRequestWithValues(t, "POST", "/org/org3/settings/auth_policy", map[string]string{
+			"_csrf":              GetCSRF(t, owner, "/org/org3/settings/auth_policy"),
+			"require_two_factor": "on",
+			"grace_period_days":  "7",
+		})
+		owner.MakeRequest(t, req, http.StatusSeeOther)
+		policy, err := organization.GetAuthPolicy(db.DefaultContext, 3)
+		require.NoError(t, err)
+		assert.False(t, policy.IsEnabled())
+
+		_, err = auth_model.CreateCredential(db.DefaultContext, 2, "Security key", &webauthn.Credential{ID: []byte("Security key")}, false)
+		require.NoError(t, err)
+		req = NewRequestWithValues(t, "POST", "/org/org3/settings/auth_policy", map[string]string{
+			"_csrf":              GetCSRF(t, owner, "/org/org3/settings/auth_policy"),
+			"require_two_factor": "on",
+			"grace_period_days":  "7",
+		})
+		owner.MakeRequest(t, req, http.StatusSeeOther)
+		policy = unittest.AssertExistsAndLoadBean(t, &organization.AuthPolicy{OrgID: 3})
+		assert.True(t, policy.RequireTwoFactor)
+		assert.True(t, policy.InGracePeriod())
+
+		// the report lists the members without two-factor authentication
+		resp := owner.MakeRequest(t, NewRequest(t, "GET", "/org/org3/settings/auth_policy"), http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Positive(t, htmlDoc.Find(`.flex-item a[href="/user4"]`).Length())
+		assert.Zero(t, htmlDoc.Find(`.flex-item a[href="/user2"]`).Length())
+	})
+
+	t.Run("GracePeriod", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		resp := member.MakeRequest(t, NewRequest(t, "GET", "/org3/repo3"), http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Positive(t, htmlDoc.Find(".flash-warning").Length())
+
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3").AddTokenAuth(memberToken), http.StatusOK)
+	})
+
+	policy := unittest.AssertExistsAndLoadBean(t, &organization.AuthPolicy{OrgID: 3})
+	policy.GracePeriodEndUnix = timeutil.TimeStampNow().Add(-1)
+	require.NoError(t, organization.UpdateAuthPolicy(db.DefaultContext, policy))
+
+	t.Run("Enforced", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		member.MakeRequest(t, NewRequest(t, "GET", "/org3"), http.StatusForbidden)
+		member.MakeRequest(t, NewRequest(t, "GET", "/org3/repo3"), http.StatusForbidden)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3").AddTokenAuth(memberToken), http.StatusForbidden)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/org3/repo3").AddTokenAuth(memberToken), http.StatusForbidden)
+		MakeRequest(t, NewRequest(t, "GET", "/org3/repo3.git/info/refs?service=git-upload-pack").AddBasicAuth("user4"), http.StatusForbidden)
+
+		// the LFS objects and the packages of the organization are blocked as well
+		lfsBatchRequest := func(user string) *RequestWrapper {
+			return NewRequestWithJSON(t, "POST", "/org3/repo3.git/info/lfs/objects/batch", &lfs.BatchRequest{
+				Operation: "download",
+				Objects:   []lfs.Pointer{{Oid: "fb8f7d8435968c4f82a726a92395be4d16f2f63116caf36c8ad35c60831ab041", Size: 6}},
+			}).SetHeader("Accept", lfs.AcceptHeader).SetHeader("Content-Type", lfs.MediaType).AddBasicAuth(user)
+		}
+		MakeRequest(t, lfsBatchRequest("user4"), http.StatusUnauthorized)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/org3/generic/test-package/1.0.0/file.bin").AddBasicAuth("user4"), http.StatusUnauthorized)
+
+		// the owner and the users outside of the organization are not concerned
+		owner.MakeRequest(t, NewRequest(t, "GET", "/org3/repo3"), http.StatusOK)
+		MakeRequest(t, lfsBatchRequest("user2"), http.StatusOK)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/org3/generic/test-package/1.0.0/file.bin").AddBasicAuth("user2"), http.StatusNotFound)
+		loginUser(t, "user5").MakeRequest(t, NewRequest(t, "GET", "/org3"), http.StatusOK)
+
+		// the member gets access back once enrolled
+		_, err := auth_model.CreateCredential(db.DefaultContext, 4, "Security key", &webauthn.Credential{ID: []byte("Security key 4")}, false)
+		require.NoError(t, err)
+		member.MakeRequest(t, NewRequest(t, "GET", "/org3"), http.StatusOK)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3").AddTokenAuth(memberToken), http.StatusOK)
+	})
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	issues_model "code.gitea.io/gitea/models/issues"
+	pull_model "code.gitea.io/gitea/models/pull"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/mergequeue"
+	commitstatus_service "code.gitea.io/gitea/services/repository/commitstatus"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForMergeQueueEntry waits until the speculative merge commit of the pull request was created on top of baseCommitID
+func waitForMergeQueueEntry(t *testing.T, pr *issues_model.PullRequest, baseCommitID string) *pull_model.MergeQueueEntry {
+	t.Helper()
+	var entry *pull_model.MergeQueueEntry
+	assert.Eventually(t, func() bool {
+		exists, e, err := pull_model.GetMergeQueueEntryByPullID(db.DefaultContext, pr.ID)
+		if err != nil || !exists {
+			return false
+		}
+		entry = e
+		return e.IsTesting() && e.BaseCommitID == baseCommitID
+	}, 10*time.Second, 100*time.Millisecond)
+	require.NotNil(t, entry)
+	return entry
+}
+
+func TestPullMergeQueue(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{OwnerName: "user2", Name: "repo1"})
+		session := loginUser(t, user2.Name)
+
+		gitRepo, err := gitrepo.OpenRepository(db.DefaultContext, repo)
+		require.NoError(t, err)
+		defer gitRepo.Close()
+		masterCommitID, err := gitRepo.GetBranchCommitID("master")
+		require.NoError(t, err)
+		defer testResetRepo(t, repo.RepoPath(), "master", masterCommitID)
+
+		// three pull requests adding different files, so their merge commits do not conflict
+		prs := make([]*issues_model.PullRequest, 0, 3)
+		for i := 1; i <= 3; i++ {
+			branch := fmt.Sprintf("merge-queue-%d", i)
+			_, err := files_service.ChangeRepoFiles(db.DefaultContext, repo, user2, &files_service.ChangeRepoFilesOptions{
+				Files: []*files_service.ChangeRepoFile{
+					{
+						Operation:     "create",
+						TreePath:      branch + ".txt",
+						ContentReader: strings.NewReader(branch),
+					},
+				},
+				OldBranch: "master",
+				NewBranch: branch,
+				Message:   "add " + branch,
+			})
+			require.NoError(t, err)
+			testPullCreate(t, session, user2.Name, repo.Name, false, "master", branch, "merge queue "+branch)
+			prs = append(prs, unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{BaseRepoID: repo.ID, HeadBranch: branch}))
+		}
+
+		csrf := GetCSRF(t, session, "/user2/repo1/settings/branches")
+		req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/branches/edit", map[string]string{
+			"_csrf":                 csrf,
+			"rule_name":             "master",
+			"enable_push":           "true",
+			"enable_status_check":   "true",
+			"status_check_contexts": "ci/test",
+			"enable_merge_queue":    "true",
+		})
+		session.MakeRequest(t, req, http.StatusSeeOther)
+
+		t.Run("Enqueue", func(t *testing.T) {
+			for _, pr := range prs {
+				added, err := mergequeue.AddToMergeQueue(db.DefaultContext, user2, pr, repo_model.MergeStyleMerge, "merge "+pr.HeadBranch)
+				require.NoError(t, err)
+				assert.True(t, added)
+			}
+			_, err := mergequeue.AddToMergeQueue(db.DefaultContext, user2, prs[0], repo_model.MergeStyleMerge, "")
+			assert.True(t, pull_model.IsErrAlreadyInMergeQueue(err))
+
+			// every entry is tested on top of the entry ahead of it
+			baseCommitID := masterCommitID
+			for _, pr := range prs {
+				entry := waitForMergeQueueEntry(t, pr, baseCommitID)
+				refCommitID, err := gitRepo.GetRefCommitID(git.RefNameFromMergeQueue("master", pr.Index).String())
+				require.NoError(t, err)
+				assert.Equal(t, entry.CommitID, refCommitID)
+				baseCommitID = entry.CommitID
+			}
+			unittest.AssertExistsAndLoadBean(t, &issues_model.Comment{Type: issues_model.CommentTypePRAddedToMergeQueue, IssueID: prs[0].IssueID})
+		})
+
+		t.Run("Rebuild after removal", func(t *testing.T) {
+			require.NoError(t, mergequeue.RemoveFromMergeQueue(db.DefaultContext, user2, prs[1], ""))
+			assert.False(t, git.IsReferenceExist(db.DefaultContext, repo.RepoPath(), git.RefNameFromMergeQueue("master", prs[1].Index).String()))
+
+			// the entry behind the removed one is tested again without it
+			first := waitForMergeQueueEntry(t, prs[0], masterCommitID)
+			waitForMergeQueueEntry(t, prs[2], first.CommitID)
+		})
+
+		t.Run("Eject when checks fail", func(t *testing.T) {
+			_, entry, err := pull_model.GetMergeQueueEntryByPullID(db.DefaultContext, prs[2].ID)
+			require.NoError(t, err)
+			require.NoError(t, commitstatus_service.CreateCommitStatus(db.DefaultContext, repo, user2, entry.CommitID, &git_model.CommitStatus{
+				State:   api.CommitStatusFailure,
+				Context: "ci/test",
+			}))
+
+			assert.Eventually(t, func() bool {
+				exists, _, err := pull_model.GetMergeQueueEntryByPullID(db.DefaultContext, prs[2].ID)
+				return err == nil && !exists
+			}, 10*time.Second, 100*time.Millisecond)
+			unittest.AssertExistsAndLoadBean(t, &issues_model.Comment{
+				Type:    issues_model.CommentTypePRRemovedFromMergeQueue,
+				IssueID: prs[2].IssueID,
+				Content: "the required status checks failed",
+			})
+			pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: prs[2].ID})
+			assert.False(t, pr.HasMerged)
+		})
+
+		t.Run("Fast-forward the base branch", func(t *testing.T) {
+			_, entry, err := pull_model.GetMergeQueueEntryByPullID(db.DefaultContext, prs[0].ID)
+			require.NoError(t, err)
+			require.NoError(t, commitstatus_service.CreateCommitStatus(db.DefaultContext, repo, user2, entry.CommitID, &git_model.CommitStatus{
+				State:   api.CommitStatusSuccess,
+				Context: "ci/test",
+			}))
+
+			assert.Eventually(t, func() bool {
+				pr := unittest.AssertExistsAndLoadBean(t, &issues_model.PullRequest{ID: prs[0].ID})
+				return pr.HasMerged
+			}, 10*time.Second, 100*time.Millisecond)
+			branchCommitID, err := gitRepo.GetBranchCommitID("master")
+			require.NoError(t, err)
+			assert.Equal(t, entry.CommitID, branchCommitID)
+			unittest.AssertNotExistsBean(t, &pull_model.MergeQueueEntry{PullID: prs[0].ID})
+			assert.False(t, git.IsReferenceExist(db.DefaultContext, repo.RepoPath(), git.RefNameFromMergeQueue("master", prs[0].Index).String()))
+		})
+
+		t.Run("Reject pushes to merge queue refs", func(t *testing.T) {
+			dstPath := t.TempDir()
+			cloneURL, _ := url.Parse(u.String())
+			cloneURL.Path = "user2/repo1.git"
+			cloneURL.User = url.UserPassword(user2.Name, userPassword)
+			doGitClone(dstPath, cloneURL)(t)
+
+			doGitPushTestRepositoryFail(dstPath, "origin", "HEAD:"+git.RefNameFromMergeQueue("master", prs[1].Index).String())(t)
+			assert.False(t, git.IsReferenceExist(db.DefaultContext, repo.RepoPath(), git.RefNameFromMergeQueue("master", prs[1].Index).String()))
+		})
+	})
+}
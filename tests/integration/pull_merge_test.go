@@ -44,6 +44,7 @@ import (
 	webhook_service "code.gitea.io/gitea/services/webhook"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type optionsPullMerge map[string]string
@@ -622,6 +623,76 @@ func TestPullDontRetargetChildOnWrongRepo(t *testing.T) {
 	})
 }
 
+func TestPullMergeStack(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testEditFileToNewBranch(t, session, "user2", "repo1", "master", "base-pr", "README.md", "Hello, World\n(Edited - TestPullMergeStack - base PR)\n")
+		testEditFileToNewBranch(t, session, "user2", "repo1", "base-pr", "child-pr", "README.md", "Hello, World\n(Edited - TestPullMergeStack - base PR)\n(Edited - TestPullMergeStack - child PR)\n")
+
+		respBasePR := testPullCreate(t, session, "user2", "repo1", true, "master", "base-pr", "Base Pull Request")
+		elemBasePR := strings.Split(test.RedirectURL(respBasePR), "/")
+		assert.EqualValues(t, "pulls", elemBasePR[3])
+		respChildPR := testPullCreate(t, session, "user2", "repo1", true, "base-pr", "child-pr", "Child Pull Request")
+		elemChildPR := strings.Split(test.RedirectURL(respChildPR), "/")
+		assert.EqualValues(t, "pulls", elemChildPR[3])
+
+		repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{OwnerName: "user2", Name: "repo1"})
+		gitRepo, err := gitrepo.OpenRepository(git.DefaultContext, repo)
+		require.NoError(t, err)
+		defer gitRepo.Close()
+		masterCommitID, err := gitRepo.GetBranchCommitID("master")
+		require.NoError(t, err)
+
+		// the stack cannot be merged if the child pull request is not retargeted to master
+		func() {
+			defer test.MockVariableValue(&setting.Repository.PullRequest.RetargetChildrenOnMerge, false)()
+
+			req := NewRequestWithValues(t, "POST", fmt.Sprintf("/user2/repo1/pulls/%s/merge_stack", elemChildPR[4]), map[string]string{
+				"_csrf":       GetCSRF(t, session, test.RedirectURL(respChildPR)),
+				"merge_style": string(repo_model.MergeStyleSquash),
+			})
+			resp := session.MakeRequest(t, req, http.StatusSeeOther)
+			assert.Equal(t, "/user2/repo1/pulls/"+elemBasePR[4], test.RedirectURL(resp))
+
+			commitID, err := gitRepo.GetBranchCommitID("master")
+			require.NoError(t, err)
+			assert.Equal(t, masterCommitID, commitID)
+		}()
+
+		req := NewRequestWithValues(t, "POST", fmt.Sprintf("/user2/repo1/pulls/%s/merge_stack", elemChildPR[4]), map[string]string{
+			"_csrf":       GetCSRF(t, session, test.RedirectURL(respChildPR)),
+			"merge_style": string(repo_model.MergeStyleSquash),
+		})
+		resp := session.MakeRequest(t, req, http.StatusSeeOther)
+		assert.Equal(t, test.RedirectURL(respChildPR), test.RedirectURL(resp))
+
+		for _, index := range []string{elemBasePR[4], elemChildPR[4]} {
+			index, err := strconv.ParseInt(index, 10, 64)
+			require.NoError(t, err)
+			pr, err := issues_model.GetPullRequestByIndex(db.DefaultContext, repo.ID, index)
+			require.NoError(t, err)
+			assert.True(t, pr.HasMerged)
+			assert.Equal(t, "master", pr.BaseBranch)
+		}
+
+		// the child pull request was rebased before its merge, master holds one squashed commit for each pull request
+		head, err := gitRepo.GetBranchCommit("master")
+		require.NoError(t, err)
+		content, err := head.GetFileContent("README.md", 1024)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello, World\n(Edited - TestPullMergeStack - base PR)\n(Edited - TestPullMergeStack - child PR)\n", content)
+		assert.Contains(t, head.CommitMessage, "Child Pull Request")
+		require.Equal(t, 1, head.ParentCount())
+		parent, err := head.Parent(0)
+		require.NoError(t, err)
+		assert.Contains(t, parent.CommitMessage, "Base Pull Request")
+		require.Equal(t, 1, parent.ParentCount())
+		parentID, err := parent.ParentID(0)
+		require.NoError(t, err)
+		assert.Equal(t, masterCommitID, parentID.String())
+	})
+}
+
 func TestPullMergeIndexerNotifier(t *testing.T) {
 	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
 		// create a pull request
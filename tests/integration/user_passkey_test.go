@@ -0,0 +1,127 @@
+// Copyright 2024 The Forgejo Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDisablePassword(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+
+	// without a passkey the password cannot be disabled
+	req := NewRequestWithValues(t, "POST", "/user/settings/security/disable_password", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user/settings/security"),
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	assert.True(t, user.IsPasswordSet())
+
+	passkey, err := auth_model.CreateCredential(db.DefaultContext, user.ID, "Passkey", &webauthn.Credential{ID: []byte("Passkey")}, true)
+	require.NoError(t, err)
+
+	req = NewRequestWithValues(t, "POST", "/user/settings/security/disable_password", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user/settings/security"),
+	})
+	session.MakeRequest(t, req, http.StatusSeeOther)
+	user = unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	assert.False(t, user.IsPasswordSet())
+
+	// the last passkey cannot be removed without a password
+	req = NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/delete", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user/settings/security"),
+		"id":    strconv.FormatInt(passkey.ID, 10),
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	unittest.AssertExistsAndLoadBean(t, &auth_model.WebAuthnCredential{ID: passkey.ID})
+
+	// the password cannot be used to sign in anymore
+	loginSession := emptyTestSession(t)
+	req = NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+		"_csrf":     GetCSRF(t, loginSession, "/user/login"),
+		"user_name": "user2",
+		"password":  userPassword,
+	})
+	loginSession.MakeRequest(t, req, http.StatusOK)
+}
+
+func TestPhishingResistantMFARequired(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+	defer test.MockVariableValue(&setting.RequirePhishingResistantMFA, true)()
+
+	t.Run("Enrollment", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// the site administrator has to register a security key before using the instance
+		session := loginUser(t, "user1")
+		resp := session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/profile"), http.StatusSeeOther)
+		assert.Equal(t, "/user/settings/security", test.RedirectURL(resp))
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/security"), http.StatusOK)
+
+		_, err := auth_model.CreateCredential(db.DefaultContext, 1, "Security key", &webauthn.Credential{ID: []byte("Security key")}, false)
+		require.NoError(t, err)
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/profile"), http.StatusOK)
+
+		// the policy does not apply to the other users
+		session = loginUser(t, "user4")
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/profile"), http.StatusOK)
+	})
+
+	t.Run("NoTOTP", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		// user24 is enrolled in TOTP
+		user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 24})
+		user.IsAdmin = true
+		require.NoError(t, user_model.UpdateUserCols(db.DefaultContext, user, "is_admin"))
+
+		session := emptyTestSession(t)
+		req := NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+			"_csrf":     GetCSRF(t, session, "/user/login"),
+			"user_name": user.Name,
+			"password":  userPassword,
+		})
+		resp := session.MakeRequest(t, req, http.StatusSeeOther)
+		assert.Equal(t, "/user/two_factor", test.RedirectURL(resp))
+
+		// the two-factor code is allowed until a security key is registered
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/two_factor"), http.StatusOK)
+
+		_, err := auth_model.CreateCredential(db.DefaultContext, user.ID, "Security key", &webauthn.Credential{ID: []byte("Security key 24")}, false)
+		require.NoError(t, err)
+		resp = session.MakeRequest(t, NewRequest(t, "GET", "/user/two_factor"), http.StatusSeeOther)
+		assert.Equal(t, "/user/webauthn", test.RedirectURL(resp))
+
+		// the scratch code still recovers the account
+		twofa, err := auth_model.GetTwoFactorByUID(db.DefaultContext, user.ID)
+		require.NoError(t, err)
+		scratchToken, err := twofa.GenerateScratchToken()
+		require.NoError(t, err)
+		require.NoError(t, auth_model.UpdateTwoFactor(db.DefaultContext, twofa))
+
+		req = NewRequestWithValues(t, "POST", "/user/two_factor/scratch", map[string]string{
+			"_csrf": GetCSRF(t, session, "/user/two_factor/scratch"),
+			"token": scratchToken,
+		})
+		resp = session.MakeRequest(t, req, http.StatusSeeOther)
+		assert.Equal(t, "/user/settings/security", test.RedirectURL(resp))
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/profile"), http.StatusOK)
+	})
+}
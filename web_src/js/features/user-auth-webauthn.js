@@ -28,7 +28,7 @@ export async function initUserAuthWebAuthn() {
     const credential = await navigator.credentials.get({
       publicKey: options.publicKey,
     });
-    await verifyAssertion(credential);
+    await verifyAssertion(credential, `${appSubUrl}/user/webauthn/assertion`);
   } catch (err) {
     if (!options.publicKey.extensions?.appid) {
       webAuthnError('general', err.message);
@@ -39,14 +39,44 @@ export async function initUserAuthWebAuthn() {
       const credential = await navigator.credentials.get({
         publicKey: options.publicKey,
       });
-      await verifyAssertion(credential);
+      await verifyAssertion(credential, `${appSubUrl}/user/webauthn/assertion`);
     } catch (err) {
       webAuthnError('general', err.message);
     }
   }
 }
 
-async function verifyAssertion(assertedCredential) {
+export function initUserAuthPasskeyLogin() {
+  const elLogin = document.getElementById('passkey-login');
+  if (!elLogin) {
+    return;
+  }
+  elLogin.addEventListener('click', async (e) => {
+    e.preventDefault();
+    if (!detectWebAuthnSupport()) {
+      return;
+    }
+
+    const res = await GET(`${appSubUrl}/user/webauthn/passkey`);
+    if (res.status !== 200) {
+      webAuthnError('unknown');
+      return;
+    }
+    const options = await res.json();
+    options.publicKey.challenge = decodeURLEncodedBase64(options.publicKey.challenge);
+    try {
+      const credential = await navigator.credentials.get({
+        publicKey: options.publicKey,
+      });
+      const remember = document.querySelector('.user.signin input[name="remember"]')?.checked;
+      await verifyAssertion(credential, `${appSubUrl}/user/webauthn/passkey${remember ? '?remember=true' : ''}`);
+    } catch (err) {
+      webAuthnError('general', err.message);
+    }
+  });
+}
+
+async function verifyAssertion(assertedCredential, url) {
   // Move data into Arrays in case it is super long
   const authData = new Uint8Array(assertedCredential.response.authenticatorData);
   const clientDataJSON = new Uint8Array(assertedCredential.response.clientDataJSON);
@@ -54,7 +84,7 @@ async function verifyAssertion(assertedCredential) {
   const sig = new Uint8Array(assertedCredential.response.signature);
   const userHandle = new Uint8Array(assertedCredential.response.userHandle);
 
-  const res = await POST(`${appSubUrl}/user/webauthn/assertion`, {
+  const res = await POST(url, {
     data: {
       id: assertedCredential.id,
       rawId: encodeURLEncodedBase64(rawId),
@@ -144,21 +174,28 @@ export function initUserAuthWebAuthnRegister() {
   if (!elRegister) {
     return;
   }
+  const elRegisterPasskey = document.getElementById('register-passkey');
   if (!detectWebAuthnSupport()) {
     elRegister.disabled = true;
+    elRegisterPasskey.disabled = true;
     return;
   }
   elRegister.addEventListener('click', async (e) => {
     e.preventDefault();
-    await webAuthnRegisterRequest();
+    await webAuthnRegisterRequest(false);
+  });
+  elRegisterPasskey.addEventListener('click', async (e) => {
+    e.preventDefault();
+    await webAuthnRegisterRequest(true);
   });
 }
 
-async function webAuthnRegisterRequest() {
+async function webAuthnRegisterRequest(passkey) {
   const elNickname = document.getElementById('nickname');
 
   const formData = new FormData();
   formData.append('name', elNickname.value);
+  formData.append('passkey', String(passkey));
 
   const res = await POST(`${appSubUrl}/user/settings/security/webauthn/request_register`, {
     data: formData,